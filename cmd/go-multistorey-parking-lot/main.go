@@ -1,77 +1,309 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/prasaria/go-multistorey-parking-lot/internal/cli"
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/config"
 )
 
+// Exit statuses of the tool, a failed command exiting with the status of
+// its error's category
+const (
+	exitOK      = cli.ExitOK
+	exitFailure = cli.ExitFailure
+	exitUsage   = cli.ExitUsage
+)
+
+// flags holds the command-line flags given before any command
+type flags struct {
+	scriptFile      string
+	continueOnError bool
+	stateFile       string
+	// color forces colored output when stdout is not a terminal
+	color bool
+	// noColor turns colored output off, even with --color
+	noColor bool
+	// logFormat is the format log messages are written in
+	logFormat cli.LogFormat
+	// logFile is the file log messages are copied to, rolled over once it
+	// reaches logMaxSize bytes with logMaxFiles of them kept
+	logFile     string
+	logMaxSize  int64
+	logMaxFiles int
+	// pprofAddr is the address to serve net/http/pprof on, and cpuProfile
+	// and memProfile the files to profile a single command into
+	pprofAddr  string
+	cpuProfile string
+	memProfile string
+
+	// command is the command to run on its own and its arguments, if given
+	command []string
+}
+
 func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the tool and returns its exit status. A command given in args
+// is run on its own and a script given with --script is run through; with
+// neither, commands are read from stdin, at a prompt when stdin and stdout
+// are terminals.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	// Flags override what the environment configures
+	env, err := config.FromEnv()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+	f, err := parseFlags(args, env)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	// Colors and the prompt are only for a terminal; piped output stays plain
+	cli.SetColorOutput(cli.ColorEnabled(f.color, f.noColor, isTerminal(stdout)))
+	cli.SetLogFormat(f.logFormat)
+	logLevel := cli.LogLevelDebug
+	if env.LogLevel != "" {
+		if logLevel, err = cli.ParseLogLevel(env.LogLevel); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return exitUsage
+		}
+	}
+	cli.SetLogLevel(logLevel)
+
+	// Logs are kept in a file besides going to stderr, when one is named
+	if f.logFile != "" {
+		logFile, err := cli.OpenRotatingWriter(f.logFile, f.logMaxSize, f.logMaxFiles)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return exitFailure
+		}
+		cli.SetLogFile(logFile)
+		defer func() {
+			cli.SetLogFile(nil)
+			logFile.Close()
+		}()
+	}
+	if isTerminal(stdout) {
+		cli.SetTerminalWidth(cli.TerminalWidth(stdout.(*os.File)))
+	}
+	interactive := isTerminal(stdin) && isTerminal(stdout)
+
+	// Times may be shown in a layout and zone of the user's own
+	if err := cli.SetTimeDisplay(env.Display); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
 	// Create and initialize command registry
 	registry := cli.NewCommandRegistry()
 	registry.RegisterAllCommands()
+	registry.SetOutput(stdout, stderr)
+	if env.Format != "" {
+		format, err := cli.ParseOutputFormat(env.Format)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return exitUsage
+		}
+		registry.SetSessionFormat(format)
+	}
+	if env.LogLevel != "" && logLevel == cli.LogLevelDebug {
+		registry.SetSessionVerbose(true)
+	}
 
-	// Create interactive mode
-	interactive := NewInteractiveMode(registry)
+	// Optionally carry command usage stats across sessions
+	if env.StatsFile != "" {
+		if err := registry.LoadCommandStats(env.StatsFile); err != nil {
+			fmt.Fprintf(stderr, "Warning: %v\n", err)
+		}
+	}
 
-	// Print welcome message
-	fmt.Println("Welcome to Parking Lot CLI")
-	fmt.Println("Type 'help' to see available commands or 'exit' to quit")
-	fmt.Println("Options:")
-	fmt.Println("  --json    Output results in JSON format")
-	fmt.Println("  --verbose Show detailed operation logs")
+	// Aliases defined ahead of time are ready from the first command
+	if env.AliasesFile != "" {
+		if err := registry.LoadAliases(env.AliasesFile); err != nil {
+			fmt.Fprintf(stderr, "Warning: %v\n", err)
+		}
+	}
 
-	// Create scanner for reading user input
-	scanner := bufio.NewScanner(os.Stdin)
+	// Optionally carry the lot itself across runs
+	if f.stateFile != "" {
+		if err := registry.LoadState(f.stateFile); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return exitFailure
+		}
+	}
 
-	// Main loop
-	for {
-		// Show prompt
-		fmt.Print("> ")
+	// A lot configured by the environment is ready unless one was loaded
+	if env.Lot != nil && registry.GetParkingLot() == nil {
+		if err := registry.InitializeLot(env.Name, *env.Lot); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return exitFailure
+		}
+	}
 
-		// Read input
-		if !scanner.Scan() {
-			break
+	// The profiling server stops before the tool exits, whichever way it does
+	if f.pprofAddr != "" {
+		server, err := startPprof(f.pprofAddr)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return exitFailure
 		}
+		fmt.Fprintf(stderr, "Serving pprof on http://%s/debug/pprof/\n", server.Addr())
+		defer func() {
+			if err := server.Shutdown(); err != nil {
+				fmt.Fprintf(stderr, "Warning: failed to stop the profiling server: %v\n", err)
+			}
+		}()
+	}
+
+	switch {
+	case len(f.command) > 0:
+		err = profileCommand(f.cpuProfile, f.memProfile, stderr, func() error {
+			return registry.ExecuteCommand(f.command[0], f.command[1:])
+		})
+	case f.scriptFile != "":
+		err = registry.RunScript(f.scriptFile, f.continueOnError)
+	default:
+		mode := cli.NewInteractiveMode(registry)
+		mode.Prompt = interactive
+		mode.ContinueOnError = f.continueOnError
+		if interactive {
+			mode.HistoryFile = env.HistoryFile
+			registry.SetPagerTerminal(stdin.(*os.File), stdout.(*os.File))
+		}
+		mode.Run(stdin, stdout, stderr)
+		if interactive {
+			registry.PrintSessionSummary()
+		}
+	}
 
-		// Process command
-		line := scanner.Text()
-		if !interactive.ProcessCommand(line) {
-			break
+	// The lot is saved even after a failure, as it holds what succeeded
+	if f.stateFile != "" {
+		if saveErr := registry.SaveState(f.stateFile); saveErr != nil {
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", saveErr)
+			} else {
+				err = saveErr
+			}
 		}
 	}
+	saveCommandStats(registry, env.StatsFile, stderr)
+
+	if err != nil {
+		// An error printed as JSON already is not printed again
+		if !cli.IsReported(err) {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+		}
+		return cli.ExitCode(err)
+	}
+	return exitOK
 }
 
-// splitCommandLine splits a command line into parts, handling quotes
-func splitCommandLine(line string) []string {
-	var parts []string
-	var currentPart strings.Builder
-	inQuotes := false
-
-	for _, char := range line {
-		switch {
-		case char == ' ' && !inQuotes:
-			// Space outside quotes, end current part
-			if currentPart.Len() > 0 {
-				parts = append(parts, currentPart.String())
-				currentPart.Reset()
+// parseFlags parses the flags before the command, if any, over the values
+// the environment gives; everything from the first argument not a flag on
+// is the command and its arguments
+func parseFlags(args []string, env config.EnvConfig) (flags, error) {
+	f := flags{stateFile: env.StateFile, logMaxSize: cli.DefaultLogMaxSize, logMaxFiles: cli.DefaultLogMaxFiles}
+	if env.LogFormat != "" {
+		if err := f.setValue("--log-format", env.LogFormat); err != nil {
+			return flags{}, err
+		}
+	}
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		switch args[0] {
+		case "--script", "--state", "--log-format", "--log-file", "--log-max-size", "--log-max-files",
+			"--pprof", "--cpuprofile", "--memprofile":
+			if len(args) < 2 {
+				return flags{}, fmt.Errorf("missing value for %s", args[0])
 			}
-		case char == '"':
-			// Toggle quote state
-			inQuotes = !inQuotes
+			if err := f.setValue(args[0], args[1]); err != nil {
+				return flags{}, err
+			}
+			args = args[1:]
+		case "--continue-on-error":
+			f.continueOnError = true
+		case "--color":
+			f.color = true
+		case "--no-color":
+			f.noColor = true
 		default:
-			// Add character to current part
-			currentPart.WriteRune(char)
+			return flags{}, fmt.Errorf("unknown flag: %s\nUsage: parking-lot [--color|--no-color] [--log-format <text|json>] [--log-file <file> [--log-max-size <MB>] [--log-max-files <n>]] [--pprof <addr>] [--cpuprofile <file>] [--memprofile <file>] [--state <file>] [--continue-on-error] [--script <file> | <command> [args...]]", args[0])
 		}
+		args = args[1:]
 	}
+	f.command = args
 
-	// Add the last part if not empty
-	if currentPart.Len() > 0 {
-		parts = append(parts, currentPart.String())
+	if f.continueOnError && len(f.command) > 0 {
+		return flags{}, fmt.Errorf("--continue-on-error cannot be combined with a command")
+	}
+	if f.scriptFile != "" && len(f.command) > 0 {
+		return flags{}, fmt.Errorf("--script cannot be combined with a command")
+	}
+	if f.pprofAddr != "" && len(f.command) > 0 {
+		return flags{}, fmt.Errorf("--pprof cannot be combined with a command, use --cpuprofile or --memprofile")
 	}
+	if (f.cpuProfile != "" || f.memProfile != "") && len(f.command) == 0 {
+		return flags{}, fmt.Errorf("--cpuprofile and --memprofile need a command to profile")
+	}
+
+	return f, nil
+}
+
+// setValue sets the flag taking a value to value
+func (f *flags) setValue(name, value string) error {
+	switch name {
+	case "--script":
+		f.scriptFile = value
+	case "--state":
+		f.stateFile = value
+	case "--log-format":
+		format, err := cli.ParseLogFormat(value)
+		if err != nil {
+			return err
+		}
+		f.logFormat = format
+	case "--log-file":
+		f.logFile = value
+	case "--pprof":
+		f.pprofAddr = value
+	case "--cpuprofile":
+		f.cpuProfile = value
+	case "--memprofile":
+		f.memProfile = value
+	case "--log-max-size", "--log-max-files":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid value %q for %s, expected a positive number", value, name)
+		}
+		if name == "--log-max-size" {
+			f.logMaxSize = int64(n) << 20
+		} else {
+			f.logMaxFiles = n
+		}
+	}
+	return nil
+}
+
+// isTerminal reports whether v is a file attached to a terminal
+func isTerminal(v any) bool {
+	f, ok := v.(*os.File)
+	return ok && cli.IsTerminal(f)
+}
 
-	return parts
+// saveCommandStats writes the usage stats to the stats file, if one is set
+func saveCommandStats(registry *cli.CommandRegistry, statsFile string, stderr io.Writer) {
+	if statsFile == "" {
+		return
+	}
+
+	if err := registry.SaveCommandStats(statsFile); err != nil {
+		fmt.Fprintf(stderr, "Warning: %v\n", err)
+	}
 }
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/cli"
+	"github.com/prasaria/go-multistorey-parking-lot/internal/model"
+)
+
+func TestRunSingleCommands(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "lot.json")
+
+	// Each run loads the lots the one before saved, with their options,
+	// settings and history
+	steps := []struct {
+		command []string
+		code    int
+		want    string
+	}{
+		{[]string{"init", "Annex", "1", "1", "4"}, exitOK, ""},
+		{[]string{"init", "1", "2", "5", "--name", "Depot", "--spot-ids", "signage"}, exitOK, ""},
+		{[]string{"park", "automobile", "KA-01-HH-0001", "--json"}, exitOK, "F0-R00-C02"},
+		{[]string{"park", "automobile", "KA-01-HH-0002"}, exitOK, "F0-R00-C03"},
+		{[]string{"unpark", "F0-R00-C02", "KA-01-HH-0001"}, exitOK, ""},
+		{[]string{"history", "KA-01-HH-0001"}, exitOK, "F0-R00-C02"},
+		{[]string{"label", "F0-R01-C04", "EV-7"}, exitOK, ""},
+		{[]string{"spots", "--json"}, exitOK, "EV-7"},
+		{[]string{"quota", "automobile", "0"}, exitOK, ""},
+		{[]string{"park", "automobile", "KA-77"}, cli.ExitRefused, ""},
+		{[]string{"lots"}, exitOK, "Annex"},
+		{[]string{"status"}, exitOK, "Depot"},
+	}
+	for _, step := range steps {
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"--state", state}, step.command...)
+		if code := run(args, strings.NewReader(""), &stdout, &stderr); code != step.code {
+			t.Fatalf("Expected %v to exit with %d, got %d: %s", step.command, step.code, code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), step.want) {
+			t.Errorf("Expected %q from %v, got %q", step.want, step.command, stdout.String())
+		}
+	}
+
+	// A failing command exits with the status of its error and names it
+	var stderr bytes.Buffer
+	args := []string{"--state", state, "park", "automobile", "KA-01-HH-0002"}
+	if code := run(args, strings.NewReader(""), &bytes.Buffer{}, &stderr); code != cli.ExitConflict {
+		t.Errorf("Expected a repeated park to fail, got exit status %d", code)
+	}
+	if !strings.Contains(stderr.String(), "failed to park vehicle") {
+		t.Errorf("Expected the error on stderr, got %q", stderr.String())
+	}
+
+	// A state file holding one snapshot, as saved before, still loads
+	lot, _ := model.CreateParkingLot("Legacy", 1, 2, 5)
+	if _, err := lot.Park(model.VehicleTypeAutomobile, "KA-09"); err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	legacy := filepath.Join(t.TempDir(), "legacy.json")
+	if err := model.SaveSnapshot(legacy, lot.Snapshot()); err != nil {
+		t.Fatalf("Failed to save a snapshot: %v", err)
+	}
+	var stdout bytes.Buffer
+	if code := run([]string{"--state", legacy, "search", "KA-09"}, strings.NewReader(""), &stdout, &bytes.Buffer{}); code != exitOK {
+		t.Errorf("Expected the snapshot's vehicle found, got exit status %d: %s", code, stdout.String())
+	}
+}
+
+func TestRunFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"--frobnicate"}, exitUsage},
+		{[]string{"--state"}, exitUsage},
+		{[]string{"--log-format", "xml"}, exitUsage},
+		{[]string{"--log-format", "json", "help"}, exitOK},
+		{[]string{"--log-max-files", "none", "help"}, exitUsage},
+		{[]string{"--log-file", filepath.Join(t.TempDir(), "missing", "parking.log"), "help"}, exitFailure},
+		{[]string{"--cpuprofile", "cpu.prof"}, exitUsage},
+		{[]string{"--pprof", "localhost:0", "help"}, exitUsage},
+		{[]string{"--pprof", "256.0.0.1:0"}, exitFailure},
+		{[]string{"--continue-on-error", "status"}, exitUsage},
+		{[]string{"--continue-on-error"}, exitOK},
+		{[]string{"--script", "setup.txt", "status"}, exitUsage},
+		{[]string{"status"}, exitFailure},
+		{[]string{"frobnicate"}, exitUsage},
+		{[]string{"help", "park", "unpark"}, exitUsage},
+		{[]string{"status", "--lot"}, exitUsage},
+		{[]string{"unpark", "0-0-0", "KA-01-HH-0001"}, exitFailure},
+		{[]string{"help"}, exitOK},
+	}
+
+	for _, test := range tests {
+		if code := run(test.args, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{}); code != test.code {
+			t.Errorf("Expected exit status %d for %v, got %d", test.code, test.args, code)
+		}
+	}
+}
+
+func TestRunTimeDisplay(t *testing.T) {
+	t.Setenv("PARKINGLOT_TIME_FORMAT", "Jan 2 15:04")
+	t.Setenv("PARKINGLOT_TIMEZONE", "Asia/Kolkata")
+	var stdout bytes.Buffer
+	if code := run([]string{"show", "options"}, strings.NewReader(""), &stdout, &bytes.Buffer{}); code != exitOK {
+		t.Fatalf("Expected show options to succeed, got exit status %d", code)
+	}
+	if !strings.Contains(stdout.String(), "Jan 2 15:04") || !strings.Contains(stdout.String(), "Asia/Kolkata") {
+		t.Errorf("Expected the display from the environment, got %q", stdout.String())
+	}
+
+	// An unknown zone is rejected before any command runs
+	t.Setenv("PARKINGLOT_TIMEZONE", "Mars/Base")
+	var stderr bytes.Buffer
+	if code := run([]string{"help"}, strings.NewReader(""), &bytes.Buffer{}, &stderr); code != exitUsage {
+		t.Errorf("Expected an unknown zone to be a usage error, got exit status %d", code)
+	}
+	if !strings.Contains(stderr.String(), "unknown timezone") {
+		t.Errorf("Expected the zone named on stderr, got %q", stderr.String())
+	}
+}
+
+func TestRunEnv(t *testing.T) {
+	t.Setenv("PARKINGLOT_FLOORS", "2")
+	t.Setenv("PARKINGLOT_ROWS", "1")
+	t.Setenv("PARKINGLOT_COLUMNS", "4")
+	t.Setenv("PARKINGLOT_NAME", "Depot")
+	t.Setenv("PARKINGLOT_FORMAT", "json")
+	var stdout bytes.Buffer
+	if code := run([]string{"status"}, strings.NewReader(""), &stdout, &bytes.Buffer{}); code != exitOK {
+		t.Fatalf("Expected status of the configured lot to succeed, got exit status %d", code)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(stdout.String()), "{") || !strings.Contains(stdout.String(), `"totalSpots": 8`) {
+		t.Errorf("Expected the 2x1x4 lot as JSON, got %q", stdout.String())
+	}
+
+	// A flag given explicitly overrides the environment
+	stdout.Reset()
+	if code := run([]string{"status", "--format", "text"}, strings.NewReader(""), &stdout, &bytes.Buffer{}); code != exitOK {
+		t.Fatalf("Expected status to succeed, got exit status %d", code)
+	}
+	if strings.HasPrefix(strings.TrimSpace(stdout.String()), "{") {
+		t.Errorf("Expected --format text over the environment, got %q", stdout.String())
+	}
+
+	// A lot loaded from the state file wins over the configured one
+	state := filepath.Join(t.TempDir(), "lot.json")
+	if code := run([]string{"--state", state, "init", "1", "1", "1"}, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{}); code != exitOK {
+		t.Fatalf("Expected init to succeed, got exit status %d", code)
+	}
+	stdout.Reset()
+	if code := run([]string{"--state", state, "status"}, strings.NewReader(""), &stdout, &bytes.Buffer{}); code != exitOK {
+		t.Fatalf("Expected status to succeed, got exit status %d", code)
+	}
+	if !strings.Contains(stdout.String(), `"totalSpots": 1`) {
+		t.Errorf("Expected the saved lot, got %q", stdout.String())
+	}
+
+	// A bad value names its variable and stops the tool
+	t.Setenv("PARKINGLOT_FLOORS", "two")
+	var stderr bytes.Buffer
+	if code := run([]string{"help"}, strings.NewReader(""), &bytes.Buffer{}, &stderr); code != exitUsage {
+		t.Errorf("Expected a bad floor count to be a usage error, got exit status %d", code)
+	}
+	if !strings.Contains(stderr.String(), "PARKINGLOT_FLOORS") {
+		t.Errorf("Expected the error to name the variable, got %q", stderr.String())
+	}
+}
+
+func TestRunEnvLogLevel(t *testing.T) {
+	// Debug in any case turns on verbose logs, as set verbose on does
+	for _, level := range []string{"debug", "DEBUG"} {
+		t.Setenv("PARKINGLOT_LOG_LEVEL", level)
+		var stderr bytes.Buffer
+		if code := run([]string{"init", "1", "1", "1"}, strings.NewReader(""), &bytes.Buffer{}, &stderr); code != exitOK {
+			t.Fatalf("Expected init to succeed with log level %s, got exit status %d", level, code)
+		}
+		if !strings.Contains(stderr.String(), "[DEBUG] Initializing parking lot") {
+			t.Errorf("Expected debug logs with log level %s, got %q", level, stderr.String())
+		}
+	}
+
+	t.Setenv("PARKINGLOT_LOG_LEVEL", "info")
+	var stderr bytes.Buffer
+	if code := run([]string{"init", "1", "1", "1"}, strings.NewReader(""), &bytes.Buffer{}, &stderr); code != exitOK {
+		t.Fatalf("Expected init to succeed, got exit status %d", code)
+	}
+	if strings.Contains(stderr.String(), "[DEBUG]") {
+		t.Errorf("Expected no debug logs at info level, got %q", stderr.String())
+	}
+}
+
+func TestRunPiped(t *testing.T) {
+	t.Cleanup(func() { cli.SetColorOutput(true) })
+
+	// Piped commands get their output with no prompt, banner or colors
+	var stdout bytes.Buffer
+	stdin := strings.NewReader("init 1 2 5\npark automobile KA-01-HH-0001\nexit\nstatus\n")
+	if code := run(nil, stdin, &stdout, &bytes.Buffer{}); code != exitOK {
+		t.Errorf("Expected the session to exit cleanly, got exit status %d", code)
+	}
+	out := stdout.String()
+
+	if !strings.Contains(out, "parked successfully at spot") {
+		t.Errorf("Expected the park output, got %q", out)
+	}
+	for _, unwanted := range []string{"Welcome to Parking Lot CLI", "> ", "\033[", "Session summary"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("Expected no %q in the piped output, got %q", unwanted, out)
+		}
+	}
+
+	// --color keeps the colors
+	stdout.Reset()
+	run([]string{"--color"}, strings.NewReader("init 1 2 5\n"), &stdout, &bytes.Buffer{})
+	if out := stdout.String(); !strings.Contains(out, "\033[") {
+		t.Errorf("Expected colors with --color, got %q", out)
+	}
+
+	// --no-color wins over --color
+	stdout.Reset()
+	run([]string{"--color", "--no-color"}, strings.NewReader("init 1 2 5\n"), &stdout, &bytes.Buffer{})
+	if out := stdout.String(); strings.Contains(out, "\033[") {
+		t.Errorf("Expected no colors with --no-color, got %q", out)
+	}
+}
+
+func TestRunJSONError(t *testing.T) {
+	// A failure with --json is printed once, as JSON on stdout
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"status", "--json"}, strings.NewReader(""), &stdout, &stderr); code != exitFailure {
+		t.Errorf("Expected exit status %d, got %d", exitFailure, code)
+	}
+	if out := stdout.String(); !strings.Contains(out, `"success": false`) || !strings.Contains(out, `"code": "LOT_NOT_INITIALIZED"`) {
+		t.Errorf("Expected a JSON error, got %q", out)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Expected nothing on stderr, got %q", stderr.String())
+	}
+}
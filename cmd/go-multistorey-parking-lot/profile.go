@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"time"
+)
+
+// pprofShutdownTimeout is how long requests to the profiling server are
+// given to finish once the tool is exiting
+const pprofShutdownTimeout = 5 * time.Second
+
+// pprofServer serves the net/http/pprof handlers while the tool runs
+type pprofServer struct {
+	server   *http.Server
+	listener net.Listener
+	done     chan error
+}
+
+// startPprof starts serving the profiling handlers under /debug/pprof/ on
+// addr, such as ":6060"
+func startPprof(addr string) (*pprofServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the profiling server: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	s := &pprofServer{
+		server:   &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second},
+		listener: listener,
+		done:     make(chan error, 1),
+	}
+	go func() { s.done <- s.server.Serve(listener) }()
+	return s, nil
+}
+
+// Addr returns the address the server listens on
+func (s *pprofServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Shutdown stops the server, letting requests in progress finish first
+func (s *pprofServer) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), pprofShutdownTimeout)
+	defer cancel()
+
+	err := s.server.Shutdown(ctx)
+	if serveErr := <-s.done; !errors.Is(serveErr, http.ErrServerClosed) && err == nil {
+		err = serveErr
+	}
+	return err
+}
+
+// profileCommand runs fn with a CPU profile written to cpuFile and a heap
+// profile to memFile after it, each when named
+func profileCommand(cpuFile, memFile string, stderr io.Writer, fn func() error) error {
+	if cpuFile != "" {
+		f, err := os.Create(cpuFile)
+		if err != nil {
+			return fmt.Errorf("failed to create CPU profile: %v", err)
+		}
+		defer f.Close()
+		if err := runtimepprof.StartCPUProfile(f); err != nil {
+			return fmt.Errorf("failed to start CPU profile: %v", err)
+		}
+		defer runtimepprof.StopCPUProfile()
+	}
+
+	err := fn()
+
+	if memFile != "" {
+		if memErr := writeHeapProfile(memFile); memErr != nil {
+			fmt.Fprintf(stderr, "Warning: %v\n", memErr)
+		}
+	}
+	return err
+}
+
+// writeHeapProfile writes a profile of the memory in use to path
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create memory profile: %v", err)
+	}
+	defer f.Close()
+
+	// Collect garbage first, so the profile shows only live memory
+	runtime.GC()
+	if err := runtimepprof.WriteHeapProfile(f); err != nil {
+		return fmt.Errorf("failed to write memory profile: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPprofServer(t *testing.T) {
+	server, err := startPprof("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start the profiling server: %v", err)
+	}
+
+	resp, err := http.Get("http://" + server.Addr() + "/debug/pprof/")
+	if err != nil {
+		t.Fatalf("Failed to reach the pprof index: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "goroutine") {
+		t.Errorf("Expected the pprof index, got status %d: %q", resp.StatusCode, body)
+	}
+
+	if err := server.Shutdown(); err != nil {
+		t.Errorf("Failed to stop the profiling server: %v", err)
+	}
+	if _, err := http.Get("http://" + server.Addr() + "/debug/pprof/"); err == nil {
+		t.Error("Expected the profiling server stopped")
+	}
+}
+
+func TestRunProfiles(t *testing.T) {
+	dir := t.TempDir()
+	cpu, mem := filepath.Join(dir, "cpu.prof"), filepath.Join(dir, "mem.prof")
+	var stderr bytes.Buffer
+	args := []string{"--cpuprofile", cpu, "--memprofile", mem, "help"}
+	if code := run(args, strings.NewReader(""), &bytes.Buffer{}, &stderr); code != exitOK {
+		t.Fatalf("Expected a profiled command to succeed, got exit status %d: %s", code, stderr.String())
+	}
+
+	for _, path := range []string{cpu, mem} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Errorf("Expected a profile written to %s: %v", path, err)
+		} else if info.Size() == 0 {
+			t.Errorf("Expected %s not empty", path)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/api"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DefineAlias makes name stand for a command and its leading arguments.
+// An alias cannot take the name of a command, and it expands only once, so
+// it must stand for a command rather than another alias.
+func (r *CommandRegistry) DefineAlias(name string, expansion []string) error {
+	if name == "" || strings.ContainsAny(name, " \t;\"") {
+		return fmt.Errorf("invalid alias name: %q", name)
+	}
+	if _, found := r.Commands[name]; found {
+		return fmt.Errorf("alias %s would shadow the %s command", name, name)
+	}
+	if len(expansion) == 0 {
+		return fmt.Errorf("alias %s needs a command to stand for", name)
+	}
+	if _, found := r.aliases[expansion[0]]; found {
+		return fmt.Errorf("alias %s cannot stand for another alias, %s", name, expansion[0])
+	}
+	if _, found := r.Commands[expansion[0]]; !found {
+		return fmt.Errorf("unknown command: %s", expansion[0])
+	}
+
+	r.aliases[name] = append([]string(nil), expansion...)
+	return nil
+}
+
+// RemoveAlias removes an alias
+func (r *CommandRegistry) RemoveAlias(name string) error {
+	if _, found := r.aliases[name]; !found {
+		return fmt.Errorf("unknown alias: %s", name)
+	}
+	delete(r.aliases, name)
+	return nil
+}
+
+// expandAlias returns the command and arguments an alias stands for, with
+// args after its own, or name and args unchanged when name is no alias
+func (r *CommandRegistry) expandAlias(name string, args []string) (string, []string) {
+	expansion, found := r.aliases[name]
+	if !found {
+		return name, args
+	}
+
+	expanded := append(append([]string(nil), expansion[1:]...), args...)
+	return expansion[0], expanded
+}
+
+// LoadAliases defines the aliases in a file, one per line as the alias
+// name, the command and its leading arguments. Blank lines and lines
+// starting with # are skipped; a missing file defines none.
+func (r *CommandRegistry) LoadAliases(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read aliases: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts, err := ParseCommandLine(line)
+		if err == nil {
+			err = r.DefineAlias(parts[0], parts[1:])
+		}
+		if err != nil {
+			return fmt.Errorf("%s:%d: %v", path, lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read aliases: %v", err)
+	}
+
+	return nil
+}
+
+// handleAlias handles the alias command
+func (r *CommandRegistry) handleAlias(args []string) error {
+	if len(args) > 1 {
+		if err := r.DefineAlias(args[0], args[1:]); err != nil {
+			return err
+		}
+		r.out.Success("Alias %s: %s", args[0], strings.Join(args[1:], " "))
+		return nil
+	}
+
+	names := make([]string, 0, len(r.aliases))
+	for name := range r.aliases {
+		if len(args) == 0 || name == args[0] {
+			names = append(names, name)
+		}
+	}
+	if len(args) == 1 && len(names) == 0 {
+		return fmt.Errorf("unknown alias: %s", args[0])
+	}
+	sort.Strings(names)
+
+	if r.Options.Format == OutputFormatJSON {
+		result := api.AliasesResult{Aliases: make([]api.AliasResult, len(names))}
+		for i, name := range names {
+			result.Aliases[i] = api.AliasResult{Name: name, Command: strings.Join(r.aliases[name], " ")}
+		}
+		return r.out.JSON("alias", result, nil)
+	}
+
+	if len(names) == 0 {
+		r.out.Info("No aliases defined")
+		return nil
+	}
+
+	rows := make([][]string, len(names))
+	for i, name := range names {
+		rows[i] = []string{name, strings.Join(r.aliases[name], " ")}
+	}
+	r.out.Println(FormatTable([]string{"Alias", "Command"}, rows))
+
+	return nil
+}
+
+// handleUnalias handles the unalias command
+func (r *CommandRegistry) handleUnalias(args []string) error {
+	if err := r.RemoveAlias(args[0]); err != nil {
+		return err
+	}
+
+	r.out.Success("Removed alias %s", args[0])
+	return nil
+}
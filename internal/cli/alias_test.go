@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAliases(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+
+	if err := registry.ExecuteCommand("init", []string{"1", "2", "5"}); err != nil {
+		t.Fatalf("Failed to init: %v", err)
+	}
+	if err := registry.ExecuteCommand("alias", []string{"pa", "park", "automobile"}); err != nil {
+		t.Fatalf("Failed to define alias: %v", err)
+	}
+
+	// The alias expands before dispatch, with the arguments appended
+	if err := registry.ExecuteCommand("pa", []string{"KA-01-HH-1234"}); err != nil {
+		t.Fatalf("Failed to run alias: %v", err)
+	}
+	if !registry.GetParkingLot().IsVehicleParked("KA-01-HH-1234") {
+		t.Error("Expected pa to park KA-01-HH-1234")
+	}
+	for _, usage := range registry.GetCommandStats() {
+		if usage.Name == "pa" {
+			t.Error("Expected the usage counted under the real command")
+		}
+	}
+
+	// Usage errors name the real command
+	err := registry.ExecuteCommand("pa", nil)
+	if err == nil || !strings.Contains(err.Error(), "too few arguments for command 'park'") {
+		t.Errorf("Expected the park usage error, got %v", err)
+	}
+
+	if err := registry.ExecuteCommand("alias", nil); err != nil {
+		t.Errorf("Failed to list aliases: %v", err)
+	}
+	if err := registry.ExecuteCommand("alias", []string{"pa", "--json"}); err != nil {
+		t.Errorf("Failed to show alias: %v", err)
+	}
+
+	if err := registry.ExecuteCommand("unalias", []string{"pa"}); err != nil {
+		t.Fatalf("Failed to remove alias: %v", err)
+	}
+	if err := registry.ExecuteCommand("pa", []string{"KA-01-HH-0002"}); err == nil {
+		t.Error("Expected a removed alias to be unknown")
+	}
+	if err := registry.ExecuteCommand("unalias", []string{"pa"}); err == nil {
+		t.Error("Expected removing an unknown alias to fail")
+	}
+}
+
+func TestDefineAliasRejections(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+
+	if err := registry.DefineAlias("st", []string{"status"}); err != nil {
+		t.Fatalf("Failed to define alias: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		expansion []string
+		reason    string
+	}{
+		{"status", []string{"stats"}, "shadow"},
+		{"s2", []string{"st"}, "another alias"},
+		{"st", []string{"st"}, "another alias"},
+		{"fly", []string{"fly"}, "unknown command"},
+		{"empty", nil, "needs a command"},
+		{"two words", []string{"status"}, "invalid alias name"},
+	}
+
+	for _, test := range tests {
+		err := registry.DefineAlias(test.name, test.expansion)
+		if err == nil || !strings.Contains(err.Error(), test.reason) {
+			t.Errorf("Expected alias %q rejected for %q, got %v", test.name, test.reason, err)
+		}
+	}
+
+	// An alias expands once; what it stands for is never looked up again
+	name, args := registry.expandAlias("st", []string{"--json"})
+	if name != "status" || len(args) != 1 {
+		t.Errorf("Expected st to expand to status --json, got %s %v", name, args)
+	}
+}
+
+func TestLoadAliases(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+
+	path := writeScript(t, "aliases", "# Daily shortcuts\npa park automobile\n\npb park bicycle\n")
+	if err := registry.LoadAliases(path); err != nil {
+		t.Fatalf("Failed to load aliases: %v", err)
+	}
+	if name, args := registry.expandAlias("pb", []string{"B-1"}); name != "park" || strings.Join(args, " ") != "bicycle B-1" {
+		t.Errorf("Expected pb to expand to park bicycle B-1, got %s %v", name, args)
+	}
+
+	path = writeScript(t, "broken", "pa park automobile\nhelp status\n")
+	err := registry.LoadAliases(path)
+	if err == nil || !strings.Contains(err.Error(), "broken:2:") {
+		t.Errorf("Expected the shadowing line reported, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync/atomic"
+
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/api"
+)
+
+// commandCounter tracks how often a command was executed and how often it failed
+type commandCounter struct {
+	executions atomic.Int64
+	failures   atomic.Int64
+}
+
+// record updates the counters for a single command execution
+func (c *commandCounter) record(err error) {
+	c.executions.Add(1)
+	if err != nil {
+		c.failures.Add(1)
+	}
+}
+
+// GetCommandStats returns the usage counters of every registered command,
+// sorted by command name
+func (r *CommandRegistry) GetCommandStats() []api.CommandUsage {
+	usage := make([]api.CommandUsage, 0, len(r.counters))
+	for name, counter := range r.counters {
+		usage = append(usage, api.CommandUsage{
+			Name:       name,
+			Executions: counter.executions.Load(),
+			Failures:   counter.failures.Load(),
+		})
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Name < usage[j].Name
+	})
+
+	return usage
+}
+
+// SaveCommandStats writes the current usage counters to a JSON file
+func (r *CommandRegistry) SaveCommandStats(path string) error {
+	data, err := json.MarshalIndent(r.GetCommandStats(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode command stats: %v", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write command stats: %v", err)
+	}
+
+	return nil
+}
+
+// LoadCommandStats adds the usage counters stored in a JSON file to the
+// registry's counters. A missing file is not an error.
+func (r *CommandRegistry) LoadCommandStats(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read command stats: %v", err)
+	}
+
+	var usage []api.CommandUsage
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return fmt.Errorf("failed to decode command stats: %v", err)
+	}
+
+	for _, u := range usage {
+		// Ignore stats for commands that no longer exist
+		if counter, found := r.counters[u.Name]; found {
+			counter.executions.Add(u.Executions)
+			counter.failures.Add(u.Failures)
+		}
+	}
+
+	return nil
+}
+
+// formatCommandStatsTable renders usage counters for commands that have been used
+func formatCommandStatsTable(usage []api.CommandUsage) string {
+	rows := make([][]string, 0, len(usage))
+	for _, u := range usage {
+		if u.Executions == 0 {
+			continue
+		}
+		rows = append(rows, []string{
+			u.Name,
+			fmt.Sprintf("%d", u.Executions),
+			fmt.Sprintf("%d", u.Failures),
+		})
+	}
+
+	return FormatTable([]string{"Command", "Executions", "Failures"}, rows)
+}
+
+// PrintSessionSummary prints the command usage of the current session
+func (r *CommandRegistry) PrintSessionSummary() {
+	var executions, failures int64
+	usage := r.GetCommandStats()
+	for _, u := range usage {
+		executions += u.Executions
+		failures += u.Failures
+	}
+
+	r.out.Printf("Session summary: %d commands executed, %d failed\n", executions, failures)
+	if executions > 0 {
+		r.out.Println(formatCommandStatsTable(usage))
+	}
+}
@@ -2,7 +2,10 @@ package cli
 
 import (
 	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -10,6 +13,8 @@ import (
 
 	perrors "github.com/prasaria/go-multistorey-parking-lot/internal/errors"
 	"github.com/prasaria/go-multistorey-parking-lot/internal/model"
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/api"
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/config"
 )
 
 // Command represents a CLI command
@@ -20,6 +25,16 @@ type Command struct {
 	MinArgs     int
 	MaxArgs     int
 	Handler     func(args []string) error
+
+	// Flags defines the command's own flags on a flag set, binding them to
+	// the variables the handler reads and resetting those to the defaults.
+	// The flags are then parsed wherever they appear in the arguments, and
+	// MinArgs and MaxArgs count only the positional ones left.
+	Flags func(fs *flag.FlagSet)
+
+	// NoPager keeps the command's output off the pager, for a command that
+	// redraws the screen or reads keys itself
+	NoPager bool
 }
 
 // OutputFormat represents the format of command output
@@ -34,6 +49,12 @@ const (
 type CommandOptions struct {
 	Format  OutputFormat
 	Verbose bool
+	// Quiet prints only the primary result of each command
+	Quiet bool
+	// Output names the file the command's output goes to, given with -o
+	Output string
+	// Paging pages output too long for the terminal in a session at a prompt
+	Paging bool
 }
 
 // Update CommandRegistry to include options
@@ -42,8 +63,43 @@ type CommandRegistry struct {
 	parkingLot *model.ParkingLot
 	Options    CommandOptions
 	Logger     *Logger
+
+	// Where commands write their output
+	out *Output
+
+	// The options set for the session, which each command starts from
+	defaults CommandOptions
+
+	// Where printResult writes while quiet mode discards standard output
+	resultOutput io.Writer
+
+	// The terminal long output is paged on, and whether a pager has it now
+	pagerIn, pagerOut *os.File
+	paging            bool
+
+	// Lots initialized this session by name; parkingLot is the active one
+	lots      map[string]*model.ParkingLot
+	activeLot string
+
+	// The last parks and unparks of each lot, which undo inverts
+	journals map[*model.ParkingLot]*operationJournal
+
+	// How many scripts are running, each started by the one before
+	scriptDepth int
+
+	// Usage counters per command name, created on registration
+	counters map[string]*commandCounter
+
+	// The command and leading arguments each alias stands for
+	aliases map[string][]string
+
+	// The phases of the park or unpark the running command does, timed
+	timings *model.OperationTimings
 }
 
+// defaultLotName names the lot created by init when no name is given
+const defaultLotName = "default"
+
 // NewCommandRegistry creates a new command registry
 func NewCommandRegistry() *CommandRegistry {
 	return &CommandRegistry{
@@ -52,13 +108,36 @@ func NewCommandRegistry() *CommandRegistry {
 			Format:  OutputFormatText,
 			Verbose: false,
 		},
-		Logger: NewLogger(false),
+		defaults: CommandOptions{Paging: true},
+		Logger:   NewLogger(false),
+		out:      StdOutput(),
+		lots:     make(map[string]*model.ParkingLot),
+		journals: make(map[*model.ParkingLot]*operationJournal),
+		counters: make(map[string]*commandCounter),
+		aliases:  make(map[string][]string),
 	}
 }
 
+// SetOutput sends the output of commands to out and the errors they
+// report along the way to errOut, instead of standard output and error
+func (r *CommandRegistry) SetOutput(out, errOut io.Writer) {
+	r.out = NewOutput(out, errOut)
+	r.Logger.SetOutput(errOut)
+}
+
+// newLogger returns a logger writing to where commands report errors
+func (r *CommandRegistry) newLogger(verbose bool) *Logger {
+	logger := NewLogger(verbose)
+	logger.SetOutput(r.out.Err)
+	return logger
+}
+
 // RegisterCommand adds a command to the registry
 func (r *CommandRegistry) RegisterCommand(cmd *Command) {
 	r.Commands[cmd.Name] = cmd
+	if _, found := r.counters[cmd.Name]; !found {
+		r.counters[cmd.Name] = &commandCounter{}
+	}
 }
 
 // GetCommand returns a command by name
@@ -74,51 +153,179 @@ func (r *CommandRegistry) GetCommands() map[string]*Command {
 
 // Add option parsing to ExecuteCommand
 func (r *CommandRegistry) ExecuteCommand(name string, args []string) error {
-	// Parse options first
-	filteredArgs := make([]string, 0)
-	for _, arg := range args {
-		if arg == "--json" {
-			r.Options.Format = OutputFormatJSON
-		} else if arg == "--verbose" || arg == "-v" {
-			r.Options.Verbose = true
-			r.Logger = NewLogger(true)
-		} else {
-			filteredArgs = append(filteredArgs, arg)
-		}
-	}
+	// Expand an alias, so argument checks and errors name the real command
+	name, args = r.expandAlias(name, args)
 
 	// Look up command
+	r.Options = r.defaults
 	cmd, found := r.GetCommand(name)
 	if !found {
-		return fmt.Errorf("unknown command: %s\nType 'help' to see available commands", name)
+		r.detectJSON(args)
+		err := r.reportError(name, newUsageError("unknown command: %s\nType 'help' to see available commands", name))
+		r.Options = r.defaults
+		return err
+	}
+
+	// Parse options first, over the session's own
+	lotName := ""
+	filteredArgs, err := r.parseArgs(cmd, args, &lotName)
+	if err == nil {
+		err = r.runRedirected(r.Options.Output, func() error {
+			return r.runPaged(cmd, func() error {
+				return r.runQuietly(func() error {
+					if lotName != "" {
+						return r.runCommandOnLot(cmd, filteredArgs, lotName)
+					}
+					return r.runCommand(cmd, filteredArgs)
+				})
+			})
+		})
+	}
+	r.counters[name].record(err)
+	err = r.reportError(name, err)
+
+	// Reset options after command execution, dropping any flags given
+	if r.Options.Verbose != r.defaults.Verbose {
+		r.Logger = r.newLogger(r.defaults.Verbose)
+	}
+	r.Options = r.defaults
+
+	return err
+}
+
+// ReportedError is an error a command has already printed, as JSON, so
+// that whoever ran the command need not print it again
+type ReportedError struct {
+	Err error
+}
+
+// Error returns the message of the error reported
+func (e *ReportedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error reported
+func (e *ReportedError) Unwrap() error {
+	return e.Err
+}
+
+// IsReported reports whether err has already been printed
+func IsReported(err error) bool {
+	var reported *ReportedError
+	return errors.As(err, &reported)
+}
+
+// reportError prints the error of a command as JSON when JSON output is
+// selected, returning it marked as reported; otherwise it is left to the
+// caller to print
+func (r *CommandRegistry) reportError(name string, err error) error {
+	if err == nil || r.Options.Format != OutputFormatJSON || IsReported(err) {
+		return err
 	}
 
+	// An error that cannot be written as JSON is left to be printed as text
+	if jsonErr := r.out.JSON(name, nil, err); jsonErr != nil {
+		r.Logger.Debug("Reporting the error as JSON failed: %v", jsonErr)
+		return err
+	}
+	return &ReportedError{Err: err}
+}
+
+// runCommand validates the argument count and runs the command handler
+func (r *CommandRegistry) runCommand(cmd *Command, args []string) error {
 	// Validate argument count (with filtered args now)
-	if len(filteredArgs) < cmd.MinArgs {
-		return fmt.Errorf("too few arguments for command '%s'\nUsage: %s", name, cmd.Usage)
+	if len(args) < cmd.MinArgs {
+		return newUsageError("too few arguments for command '%s'\nUsage: %s", cmd.Name, cmd.UsageText())
 	}
 
-	if cmd.MaxArgs >= 0 && len(filteredArgs) > cmd.MaxArgs {
-		return fmt.Errorf("too many arguments for command '%s'\nUsage: %s", name, cmd.Usage)
+	if cmd.MaxArgs >= 0 && len(args) > cmd.MaxArgs {
+		return newUsageError("too many arguments for command '%s'\nUsage: %s", cmd.Name, cmd.UsageText())
 	}
 
-	// Execute the command with filtered args
-	err := cmd.Handler(filteredArgs)
+	return r.runTimed(cmd, args)
+}
+
+// runCommandOnLot runs the command against the named lot, leaving the active
+// lot unchanged
+func (r *CommandRegistry) runCommandOnLot(cmd *Command, args []string, lotName string) error {
+	if cmd.Name == "init" || cmd.Name == "use" || cmd.Name == "run" {
+		return fmt.Errorf("--lot cannot be used with %s", cmd.Name)
+	}
 
-	// Reset options after command execution
-	r.Options = CommandOptions{
-		Format:  OutputFormatText,
-		Verbose: false,
+	lot, found := r.lots[lotName]
+	if !found {
+		return fmt.Errorf("unknown lot: %s", lotName)
 	}
 
-	return err
+	active := r.parkingLot
+	r.parkingLot = lot
+	defer func() { r.parkingLot = active }()
+
+	return r.runCommand(cmd, args)
 }
 
-// SetParkingLot sets the parking lot instance for the command registry
+// SetParkingLot sets the parking lot instance for the command registry,
+// replacing the active lot or creating the default one
+// A sampler left running on the previous lot is stopped
 func (r *CommandRegistry) SetParkingLot(lot *model.ParkingLot) {
+	name := r.activeLot
+	if name == "" {
+		name = defaultLotName
+	}
+	r.addLot(name, lot)
+}
+
+// addLot stores the lot under the name and makes it the active lot
+// A sampler left running on a lot it replaces is stopped
+func (r *CommandRegistry) addLot(name string, lot *model.ParkingLot) {
+	if previous := r.lots[name]; previous != nil && previous != lot {
+		if previous.IsSampling() {
+			_ = previous.StopSampling()
+		}
+		delete(r.journals, previous)
+	}
+	r.lots[name] = lot
+	r.activeLot = name
 	r.parkingLot = lot
 }
 
+// lotNames returns the names of the lots, sorted
+func (r *CommandRegistry) lotNames() []string {
+	names := make([]string, 0, len(r.lots))
+	for name := range r.lots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// now returns the current time of the lot's clock, which every duration shown is measured against
+func (r *CommandRegistry) now() time.Time {
+	if r.parkingLot == nil {
+		return time.Now()
+	}
+	return r.parkingLot.GetClock().Now()
+}
+
+// location returns the zone text output shows times in: the one set with
+// set timezone, or else the zone of the lot's metadata
+func (r *CommandRegistry) location() *time.Location {
+	if r.parkingLot == nil {
+		return displayLocation(time.UTC)
+	}
+	return displayLocation(r.parkingLot.Location())
+}
+
+// formatTime shows a time in the zone text output shows times in
+func (r *CommandRegistry) formatTime(t time.Time) string {
+	return formatTimestamp(t, r.location())
+}
+
+// formatTimestamp shows a time in the given zone and the layout set
+func formatTimestamp(t time.Time, location *time.Location) string {
+	return t.In(location).Format(timeLayout())
+}
+
 // GetParkingLot returns the current parking lot instance
 func (r *CommandRegistry) GetParkingLot() *model.ParkingLot {
 	return r.parkingLot
@@ -129,7 +336,7 @@ func (r *CommandRegistry) RegisterAllCommands() {
 	// Help command
 	r.RegisterCommand(&Command{
 		Name:        "help",
-		Usage:       "help [command]",
+		Usage:       "help [command|exit-codes]",
 		Description: "Show help for all commands or a specific command",
 		MinArgs:     0,
 		MaxArgs:     1,
@@ -139,27 +346,313 @@ func (r *CommandRegistry) RegisterAllCommands() {
 	// Init command
 	r.RegisterCommand(&Command{
 		Name:        "init",
-		Usage:       "init <floors> <rows> <columns>",
-		Description: "Initialize a new parking lot",
-		MinArgs:     3,
-		MaxArgs:     3,
+		Usage:       "init [<name>] <floors> <rows> <columns> | init [<name>] -floors <n> -rows <n> -columns <n>, either with [--start-floor <n>] [--mix <b:m:a[:...]>] [--inactive <fraction>] [--seed <n|random>] [--template <name>] [--pillars <rows:columns[:width]|none>] [--preset <small|standard|mega>] | init [<name>] --map <file> [--start-floor <n>]; every form takes [--name <text>] [--plate-pattern <regexp>] [--plate-length <min>:<max>] [--plate-spaces <true|false>] [--plate-separators <strict|ignore>] [--spot-ids <dash|signage>] [--accessible <strict|overflow>]",
+		Description: "Initialize a new parking lot, named default unless a name is given, and make it the active lot",
+		MinArgs:     2,
+		MaxArgs:     35,
 		Handler:     r.handleInit,
 	})
 
+	// Lot commands
+	r.RegisterCommand(&Command{
+		Name:        "use",
+		Usage:       "use <name>",
+		Description: "Make an initialized lot the one commands act on; --lot <name> picks one for a single command",
+		MinArgs:     1,
+		MaxArgs:     1,
+		Handler:     r.handleUse,
+	})
+
+	r.RegisterCommand(&Command{
+		Name:        "clone",
+		Usage:       "clone <new_name>",
+		Description: "Copy the layout of the active lot into a new empty lot, leaving the active lot unchanged",
+		MinArgs:     1,
+		MaxArgs:     1,
+		Handler:     r.handleClone,
+	})
+
+	r.RegisterCommand(&Command{
+		Name:        "lots",
+		Usage:       "lots",
+		Description: "List the initialized lots with their spot counts; * marks the active lot",
+		MinArgs:     0,
+		MaxArgs:     0,
+		Handler:     r.handleLots,
+	})
+
+	// Add floor command
+	r.RegisterCommand(&Command{
+		Name:        "add-floor",
+		Usage:       "add-floor <rows> <columns>",
+		Description: "Add a new floor to the parking lot",
+		MinArgs:     2,
+		MaxArgs:     2,
+		Handler:     r.handleAddFloor,
+	})
+
+	// Expand floor command
+	r.RegisterCommand(&Command{
+		Name:        "expand-floor",
+		Usage:       "expand-floor <floor> <rows> <columns> [spot_type]",
+		Description: "Resize a floor, filling new cells with the given spot type (default A-1)",
+		MinArgs:     3,
+		MaxArgs:     4,
+		Handler:     r.handleExpandFloor,
+	})
+
+	// Remove floor command
+	var confirmRemove bool
+	r.RegisterCommand(&Command{
+		Name:        "remove-floor",
+		Usage:       "remove-floor <floor>",
+		Description: "Remove an empty floor from the parking lot",
+		MinArgs:     1,
+		MaxArgs:     1,
+		Flags:       defineConfirmation(&confirmRemove, "confirm removing the floor"),
+		Handler: func(args []string) error {
+			return r.handleRemoveFloor(args, confirmRemove)
+		},
+	})
+
+	// Close floor command
+	var confirmClose bool
+	r.RegisterCommand(&Command{
+		Name:        "close-floor",
+		Usage:       "close-floor <floor>",
+		Description: "Stop a floor from accepting new vehicles",
+		MinArgs:     1,
+		MaxArgs:     1,
+		Flags:       defineConfirmation(&confirmClose, "confirm closing the floor"),
+		Handler: func(args []string) error {
+			return r.handleCloseFloor(args, confirmClose)
+		},
+	})
+
+	// Open floor command
+	r.RegisterCommand(&Command{
+		Name:        "open-floor",
+		Usage:       "open-floor <floor>",
+		Description: "Let a closed floor accept new vehicles again",
+		MinArgs:     1,
+		MaxArgs:     1,
+		Handler:     r.handleOpenFloor,
+	})
+
+	// Restrict floor command
+	var clearRestriction bool
+	r.RegisterCommand(&Command{
+		Name:        "restrict-floor",
+		Usage:       "restrict-floor <floor> [<vehicle_type>...]",
+		Description: "Let a floor accept only some vehicle types, or clear its restriction",
+		MinArgs:     1,
+		MaxArgs:     -1,
+		Flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&clearRestriction, "clear", false, "clear the restriction, in place of the vehicle types")
+		},
+		Handler: func(args []string) error {
+			return r.handleRestrictFloor(args, clearRestriction)
+		},
+	})
+
+	// Set spot command
+	r.RegisterCommand(&Command{
+		Name:        "set-spot",
+		Usage:       "set-spot <spot_id> <spot_type>",
+		Description: "Change the type of an empty spot (B-1, M-1, A-1, X-0)",
+		MinArgs:     2,
+		MaxArgs:     2,
+		Handler:     r.handleSetSpot,
+	})
+
+	// Label command
+	r.RegisterCommand(&Command{
+		Name:        "label",
+		Usage:       "label <spot_id> [text]",
+		Description: "Name a spot, or remove its name when no text is given; @<label> refers to a spot by name",
+		MinArgs:     1,
+		MaxArgs:     -1,
+		Handler:     r.handleLabel,
+	})
+
+	// Tag commands
+	r.RegisterCommand(&Command{
+		Name:        "tag",
+		Usage:       "tag <spot_id> <tag>...",
+		Description: "Mark a spot with attributes such as ev or covered, for park --require",
+		MinArgs:     2,
+		MaxArgs:     -1,
+		Handler:     r.handleTag,
+	})
+
+	r.RegisterCommand(&Command{
+		Name:        "untag",
+		Usage:       "untag <spot_id> <tag>...",
+		Description: "Remove attributes from a spot",
+		MinArgs:     2,
+		MaxArgs:     -1,
+		Handler:     r.handleUntag,
+	})
+
+	// Charge status command
+	r.RegisterCommand(&Command{
+		Name:        "charge-status",
+		Usage:       "charge-status <spot_id> <available|charging|out_of_order>",
+		Description: "Record the state of the EV charger of a spot tagged ev",
+		MinArgs:     2,
+		MaxArgs:     2,
+		Handler:     r.handleChargeStatus,
+	})
+
+	r.RegisterCommand(&Command{
+		Name:        "tags",
+		Usage:       "tags",
+		Description: "List the spot tags in use with how many tagged spots are available",
+		MinArgs:     0,
+		MaxArgs:     0,
+		Handler:     r.handleTags,
+	})
+
 	// Park command
+	var park parkFlags
 	r.RegisterCommand(&Command{
 		Name:        "park",
 		Usage:       "park <vehicle_type> <vehicle_number>",
-		Description: "Park a vehicle in the lot",
+		Description: "Park a vehicle in the lot, in a spot carrying every required tag; --ev prefers a working charger",
 		MinArgs:     2,
 		MaxArgs:     2,
-		Handler:     r.handlePark,
+		Flags:       park.define,
+		Handler: func(args []string) error {
+			return r.handlePark(args, park)
+		},
+	})
+
+	// Park at command
+	var parkAt parkFlags
+	r.RegisterCommand(&Command{
+		Name:        "park-at",
+		Usage:       "park-at <spot_id|@label> <vehicle_type> <vehicle_number>",
+		Description: "Park a vehicle in a chosen spot",
+		MinArgs:     3,
+		MaxArgs:     3,
+		Flags:       parkAt.defineAt,
+		Handler: func(args []string) error {
+			return r.handleParkAt(args, parkAt)
+		},
+	})
+
+	// Permit command
+	var revokePermit bool
+	r.RegisterCommand(&Command{
+		Name:        "permit",
+		Usage:       "permit [<vehicle_number>]",
+		Description: "Register an accessibility permit for a vehicle, or list the permits",
+		MinArgs:     0,
+		MaxArgs:     1,
+		Flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&revokePermit, "revoke", false, "revoke the permit of the vehicle instead")
+		},
+		Handler: func(args []string) error {
+			return r.handlePermit(args, revokePermit)
+		},
+	})
+
+	// Quota command
+	var quota quotaFlags
+	r.RegisterCommand(&Command{
+		Name:        "quota",
+		Usage:       "quota [<vehicle_type> <n|off>]",
+		Description: "Limit the general parks of a vehicle type, exempt a reserved vehicle, or list the quotas",
+		MinArgs:     0,
+		MaxArgs:     2,
+		Flags:       quota.define,
+		Handler: func(args []string) error {
+			return r.handleQuota(args, quota)
+		},
+	})
+
+	// Rename command
+	r.RegisterCommand(&Command{
+		Name:        "rename",
+		Usage:       "rename <new name>",
+		Description: "Change the name the lot is shown with; use and --lot still take the name given to init",
+		MinArgs:     1,
+		MaxArgs:     -1,
+		Handler:     r.handleRename,
+	})
+
+	// Run command
+	var continueOnError bool
+	r.RegisterCommand(&Command{
+		Name:        "run",
+		Usage:       "run <file>",
+		Description: "Execute the commands of a script file, one per line, stopping at the first failure",
+		MinArgs:     1,
+		MaxArgs:     1,
+		Flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&continueOnError, "continue-on-error", false, "run every line, listing the ones that failed at the end")
+		},
+		Handler: func(args []string) error {
+			return r.handleRun(args, continueOnError)
+		},
+	})
+
+	// Simulate command
+	var sim simulation
+	r.RegisterCommand(&Command{
+		Name:        "simulate",
+		Usage:       "simulate",
+		Description: "Run random parks, unparks and searches against the lot and report on them",
+		MinArgs:     0,
+		MaxArgs:     0,
+		Flags:       sim.define,
+		Handler: func([]string) error {
+			return r.handleSimulate(sim)
+		},
+	})
+
+	// Undo command
+	r.RegisterCommand(&Command{
+		Name:        "undo",
+		Usage:       "undo",
+		Description: fmt.Sprintf("Undo the last park or unpark of the lot, up to %d back", journalLimit),
+		MinArgs:     0,
+		MaxArgs:     0,
+		Handler:     r.handleUndo,
+	})
+
+	// Reset command
+	var reset resetFlags
+	r.RegisterCommand(&Command{
+		Name:        "reset",
+		Usage:       "reset",
+		Description: "Clear the lot's vehicles and history, or only one of them, and with --zero-stats the operation counts too",
+		MinArgs:     0,
+		MaxArgs:     0,
+		Flags:       reset.define,
+		Handler: func([]string) error {
+			return r.handleReset(reset)
+		},
+	})
+
+	// Describe command
+	var describe describeFlags
+	r.RegisterCommand(&Command{
+		Name:        "describe",
+		Usage:       "describe",
+		Description: "Set the address, timezone and notes of the lot, or show them; times are shown in the timezone",
+		MinArgs:     0,
+		MaxArgs:     0,
+		Flags:       describe.define,
+		Handler: func([]string) error {
+			return r.handleDescribe(describe)
+		},
 	})
 
 	// Unpark command
 	r.RegisterCommand(&Command{
 		Name:        "unpark",
-		Usage:       "unpark <spot_id> <vehicle_number>",
+		Usage:       "unpark <spot_id|@label> <vehicle_number>",
 		Description: "Remove a vehicle from the lot",
 		MinArgs:     2,
 		MaxArgs:     2,
@@ -167,526 +660,4133 @@ func (r *CommandRegistry) RegisterAllCommands() {
 	})
 
 	// Available command
+	var available availableFlags
 	r.RegisterCommand(&Command{
 		Name:        "available",
 		Usage:       "available <vehicle_type>",
-		Description: "Display available spots for a vehicle type",
+		Description: "Display available spots for a vehicle type, grouped by floor",
 		MinArgs:     1,
 		MaxArgs:     1,
-		Handler:     r.handleAvailable,
+		Flags:       available.define,
+		Handler: func(args []string) error {
+			return r.handleAvailable(args, &available)
+		},
+	})
+
+	// Set entrance command
+	r.RegisterCommand(&Command{
+		Name:        "set-entrance",
+		Usage:       "set-entrance <floor> <row> <column>",
+		Description: "Set the entrance used to rank spots for available --nearest",
+		MinArgs:     3,
+		MaxArgs:     3,
+		Handler:     r.handleSetEntrance,
 	})
 
 	// Search command
+	var search searchFlags
 	r.RegisterCommand(&Command{
 		Name:        "search",
-		Usage:       "search <vehicle_number>",
-		Description: "Search for a vehicle in the lot",
-		MinArgs:     1,
+		Usage:       "search <vehicle_number> | search --like <pattern>",
+		Description: "Search for a vehicle in the lot, or every lot, or for every vehicle matching a pattern",
+		MinArgs:     0,
 		MaxArgs:     1,
-		Handler:     r.handleSearch,
+		Flags:       search.define,
+		Handler: func(args []string) error {
+			return r.handleSearch(args, search)
+		},
 	})
 
 	// Status command
+	var status statusFlags
 	r.RegisterCommand(&Command{
 		Name:        "status",
 		Usage:       "status",
-		Description: "Show the current status of the parking lot",
+		Description: "Show the current status of the parking lot, and with --ops the operations done on it",
 		MinArgs:     0,
 		MaxArgs:     0,
-		Handler:     r.handleStatus,
+		Flags:       status.define,
+		Handler: func([]string) error {
+			return r.handleStatus(status)
+		},
 	})
 
-	// Exit command
+	// Occupancy command
 	r.RegisterCommand(&Command{
-		Name:        "exit",
-		Usage:       "exit",
-		Description: "Exit the application",
+		Name:        "occupancy",
+		Usage:       "occupancy",
+		Description: "Show utilization percentages overall, per floor and per spot type",
 		MinArgs:     0,
 		MaxArgs:     0,
-		Handler:     r.handleExit,
+		Handler:     r.handleOccupancy,
 	})
-}
-
-// Command handlers
 
-// handleHelp handles the help command
-func (r *CommandRegistry) handleHelp(args []string) error {
-	if len(args) == 0 {
-		// Show help for all commands
-		fmt.Println("Available commands:")
-		fmt.Println()
+	// History command
+	r.RegisterCommand(&Command{
+		Name:        "history",
+		Usage:       "history <vehicle_number>",
+		Description: "Show the parking history of a vehicle",
+		MinArgs:     1,
+		MaxArgs:     1,
+		Handler:     r.handleHistory,
+	})
 
-		// Get and sort command names
-		commandNames := make([]string, 0, len(r.Commands))
-		for name := range r.Commands {
-			commandNames = append(commandNames, name)
-		}
+	// History range command
+	var historyNDJSON bool
+	r.RegisterCommand(&Command{
+		Name:        "history-range",
+		Usage:       "history-range <from> <to>",
+		Description: "List parking sessions overlapping a time range (RFC3339, now, or relative like -2h)",
+		MinArgs:     2,
+		MaxArgs:     2,
+		Flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&historyNDJSON, "ndjson", false, "stream the sessions as JSON, one object a line")
+		},
+		Handler: func(args []string) error {
+			return r.handleHistoryRange(args, historyNDJSON)
+		},
+	})
 
-		// Sort commands alphabetically
-		// sort.Strings(commandNames)
+	// Prune history command
+	r.RegisterCommand(&Command{
+		Name:        "prune-history",
+		Usage:       "prune-history <age>",
+		Description: "Remove completed parking records older than an age like 30d or 12h",
+		MinArgs:     1,
+		MaxArgs:     1,
+		Handler:     r.handlePruneHistory,
+	})
 
-		// Display all commands
-		for _, name := range commandNames {
-			cmd := r.Commands[name]
-			fmt.Printf("  %-12s %s\n", name, cmd.Description)
-		}
+	// Spot history command
+	var spotHistoryLimit optionalInt
+	r.RegisterCommand(&Command{
+		Name:        "spot-history",
+		Usage:       "spot-history <spot_id>",
+		Description: "Show which vehicles have recently used a spot",
+		MinArgs:     1,
+		MaxArgs:     1,
+		Flags: func(fs *flag.FlagSet) {
+			spotHistoryLimit = optionalInt{}
+			fs.Var(&spotHistoryLimit, "limit", "show only the last `n` vehicles")
+		},
+		Handler: func(args []string) error {
+			return r.handleSpotHistory(args, spotHistoryLimit)
+		},
+	})
 
-		fmt.Println()
-		fmt.Println("Type 'help <command>' for more information about a specific command.")
-	} else {
-		// Show help for a specific command
+	// Longest command
+	var longestType optionalString
+	r.RegisterCommand(&Command{
+		Name:        "longest",
+		Usage:       "longest [n]",
+		Description: "List the vehicles parked the longest (10 by default)",
+		MinArgs:     0,
+		MaxArgs:     1,
+		Flags: func(fs *flag.FlagSet) {
+			longestType = optionalString{}
+			fs.Var(&longestType, "type", "list only vehicles of the `vehicle_type`")
+		},
+		Handler: func(args []string) error {
+			return r.handleLongest(args, longestType)
+		},
+	})
+
+	// Timeseries command
+	var sampleCapacity optionalInt
+	r.RegisterCommand(&Command{
+		Name:        "timeseries",
+		Usage:       "timeseries [start <interval_seconds> | stop]",
+		Description: "Sample occupancy over time, or show the samples taken",
+		MinArgs:     0,
+		MaxArgs:     2,
+		Flags: func(fs *flag.FlagSet) {
+			sampleCapacity = optionalInt{}
+			fs.Var(&sampleCapacity, "capacity", fmt.Sprintf("with start, keep the last `n` samples (default %d)", model.DefaultSampleCapacity))
+		},
+		Handler: func(args []string) error {
+			return r.handleTimeseries(args, sampleCapacity)
+		},
+	})
+
+	// Vehicles command
+	var vehicles vehiclesFlags
+	r.RegisterCommand(&Command{
+		Name:        "vehicles",
+		Usage:       "vehicles",
+		Description: "List parked vehicles, longest parked first",
+		MinArgs:     0,
+		MaxArgs:     0,
+		Flags:       vehicles.define,
+		Handler: func([]string) error {
+			return r.handleVehicles(vehicles)
+		},
+	})
+
+	// Spots command
+	var spots spotsFlags
+	r.RegisterCommand(&Command{
+		Name:        "spots",
+		Usage:       "spots",
+		Description: "List spots matching filters, 100 at a time by default; --ndjson streams them all",
+		MinArgs:     0,
+		MaxArgs:     0,
+		Flags:       spots.define,
+		Handler: func([]string) error {
+			return r.handleSpots(spots)
+		},
+	})
+
+	// Snapshot commands
+	r.RegisterCommand(&Command{
+		Name:        "save",
+		Usage:       "save <snapshot_path>",
+		Description: "Save the layout and parked vehicles of the lot to a snapshot file",
+		MinArgs:     1,
+		MaxArgs:     1,
+		Handler:     r.handleSave,
+	})
+
+	r.RegisterCommand(&Command{
+		Name:        "diff",
+		Usage:       "diff <snapshot_path>",
+		Description: "Show the spots and vehicles that changed since a snapshot was saved, floor by floor",
+		MinArgs:     1,
+		MaxArgs:     1,
+		Handler:     r.handleDiff,
+	})
+
+	// Watch command
+	var watchMap bool
+	r.RegisterCommand(&Command{
+		Name:        "watch",
+		Usage:       "watch [interval_seconds]",
+		Description: "Refresh the status every few seconds until Enter or Ctrl+C",
+		MinArgs:     0,
+		MaxArgs:     1,
+		Flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&watchMap, "map", false, "show the floor map under the status")
+		},
+		Handler: func(args []string) error {
+			return r.handleWatch(args, watchMap)
+		},
+		NoPager: true,
+	})
+
+	// Map command
+	var floorMap mapFlags
+	r.RegisterCommand(&Command{
+		Name:        "map",
+		Usage:       "map [floor]",
+		Description: "Show floors as a grid of spot letters",
+		MinArgs:     0,
+		MaxArgs:     1,
+		Flags:       floorMap.define,
+		Handler: func(args []string) error {
+			return r.handleMap(args, floorMap)
+		},
+	})
+
+	// Templates command
+	r.RegisterCommand(&Command{
+		Name:        "templates",
+		Usage:       "templates",
+		Description: "List the built-in layout templates for init --template",
+		MinArgs:     0,
+		MaxArgs:     0,
+		Handler:     r.handleTemplates,
+	})
+
+	// Stats command
+	var commandStats bool
+	r.RegisterCommand(&Command{
+		Name:        "stats",
+		Usage:       "stats",
+		Description: "Show parking statistics, or command usage with --commands",
+		MinArgs:     0,
+		MaxArgs:     0,
+		Flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&commandStats, "commands", false, "show how often each command ran instead")
+		},
+		Handler: func([]string) error {
+			return r.handleStats(commandStats)
+		},
+	})
+
+	// Alias command
+	r.RegisterCommand(&Command{
+		Name:        "alias",
+		Usage:       "alias [<name> [<command> [args...]]]",
+		Description: "Define a short name for a command and its leading arguments, or list the aliases",
+		MinArgs:     0,
+		MaxArgs:     -1,
+		Handler:     r.handleAlias,
+	})
+
+	// Unalias command
+	r.RegisterCommand(&Command{
+		Name:        "unalias",
+		Usage:       "unalias <name>",
+		Description: "Remove an alias",
+		MinArgs:     1,
+		MaxArgs:     1,
+		Handler:     r.handleUnalias,
+	})
+
+	// Set command
+	r.RegisterCommand(&Command{
+		Name:        "set",
+		Usage:       "set <format|verbose|quiet|width|wrap|color|paging|timefmt|timezone> <value>",
+		Description: "Set an option for the rest of the session: format json|text, verbose on|off, quiet on|off, width <columns>|auto, wrap on|off, color on|off, paging on|off, timefmt <layout>|default or timezone <zone>|lot",
+		MinArgs:     2,
+		MaxArgs:     -1,
+		Flags:       noFlags,
+		Handler:     r.handleSet,
+	})
+
+	// Show command
+	r.RegisterCommand(&Command{
+		Name:        "show",
+		Usage:       "show options",
+		Description: "Show the options set for the session",
+		MinArgs:     1,
+		MaxArgs:     1,
+		Handler:     r.handleShow,
+	})
+
+	// Exit command
+	r.RegisterCommand(&Command{
+		Name:        "exit",
+		Usage:       "exit",
+		Description: "Exit the application",
+		MinArgs:     0,
+		MaxArgs:     0,
+		Handler:     r.handleExit,
+	})
+}
+
+// Command handlers
+
+// handleHelp handles the help command
+func (r *CommandRegistry) handleHelp(args []string) error {
+	if len(args) == 0 {
+		// Show help for all commands
+		r.out.Println("Available commands:")
+		r.out.Println()
+
+		// Get and sort command names
+		commandNames := make([]string, 0, len(r.Commands))
+		for name := range r.Commands {
+			commandNames = append(commandNames, name)
+		}
+
+		// Sort commands alphabetically
+		// sort.Strings(commandNames)
+
+		// Display all commands
+		for _, name := range commandNames {
+			cmd := r.Commands[name]
+			r.out.Printf("  %-12s %s\n", name, cmd.Description)
+		}
+
+		r.out.Println()
+		r.out.Printf("Vehicle types: %s\n", vehicleTypeNames())
+		r.out.Println("Type 'help <command>' for more information about a specific command.")
+		r.out.Println("Type 'help exit-codes' for the exit codes of a command run on its own.")
+	} else if args[0] == "exit-codes" {
+		printExitCodes(r.out)
+	} else {
+		// Show help for a specific command
 		cmdName := args[0]
 		cmd, found := r.GetCommand(cmdName)
 		if !found {
 			return fmt.Errorf("unknown command: %s", cmdName)
 		}
 
-		fmt.Printf("Command: %s\n", cmd.Name)
-		fmt.Printf("Description: %s\n", cmd.Description)
-		fmt.Printf("Usage: %s\n", cmd.Usage)
+		r.out.Printf("Command: %s\n", cmd.Name)
+		r.out.Printf("Description: %s\n", cmd.Description)
+		r.out.Printf("Usage: %s\n", cmd.UsageText())
+		if flags := cmd.FlagHelp(); flags != "" {
+			r.out.Printf("Flags:\n%s", flags)
+		}
+		if strings.Contains(cmd.Usage, "<vehicle_type>") {
+			r.out.Printf("Vehicle types: %s\n", vehicleTypeNames())
+		}
+	}
+
+	return nil
+}
+
+// parseLengthRange parses a <min>:<max> length range, 0 meaning no limit
+func parseLengthRange(value string) (int, int, error) {
+	minText, maxText, found := strings.Cut(value, ":")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid plate length: %s (expected <min>:<max>)", value)
+	}
+
+	minLength, err := strconv.Atoi(minText)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid plate length: %s (expected <min>:<max>)", value)
+	}
+	maxLength, err := strconv.Atoi(maxText)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid plate length: %s (expected <min>:<max>)", value)
+	}
+
+	return minLength, maxLength, nil
+}
+
+// newVehicleNumberValidator builds the lot's validator from a configured rule
+func newVehicleNumberValidator(rule config.VehicleNumberRule) (*model.VehicleNumberValidator, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	return model.NewVehicleNumberValidator(model.VehicleNumberRule{
+		Pattern:   rule.Pattern,
+		MinLength: rule.MinLength,
+		MaxLength: rule.MaxLength,
+		NoSpaces:  !rule.AllowSpaces,
+	})
+}
+
+// InitializeLot creates the default lot from a configuration, as init with
+// the same dimensions would, without printing anything; an empty name gives
+// the lot init's default name
+func (r *CommandRegistry) InitializeLot(name string, cfg config.ParkingLotConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if name == "" {
+		name = "Parking Lot"
+	}
+	validator, err := newVehicleNumberValidator(cfg.VehicleNumbers)
+	if err != nil {
+		return err
+	}
+
+	mix, pillars, err := layoutOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	r.Logger.Debug("Creating parking lot with %d floors, %d rows, %d columns from the configuration",
+		cfg.Floors, cfg.Rows, cfg.Columns)
+	parkingLot, err := model.CreateParkingLotWithFloors(name, 0,
+		uniformFloorSpecs(cfg.Floors, cfg.Rows, cfg.Columns, mix, pillars),
+		model.WithLogger(lotLogger{registry: r}), model.WithVehicleNumberValidator(validator))
+	if err != nil {
+		return fmt.Errorf("failed to create parking lot: %w", err)
+	}
+	r.addLot(defaultLotName, parkingLot)
+	return nil
+}
+
+// layoutOptions parses the spot mix and pillar pattern of a configuration,
+// each nil when the configuration leaves it to the default
+func layoutOptions(cfg config.ParkingLotConfig) (*model.DistributionSpec, *model.PillarPattern, error) {
+	var mix *model.DistributionSpec
+	if cfg.Mix != "" {
+		spec, err := model.ParseDistributionSpec(cfg.Mix)
+		if err != nil {
+			return nil, nil, err
+		}
+		mix = &spec
+	}
+
+	var pillars *model.PillarPattern
+	if cfg.Pillars != "" {
+		pattern, err := model.ParsePillarPattern(cfg.Pillars)
+		if err != nil {
+			return nil, nil, err
+		}
+		pillars = &pattern
+	}
+	return mix, pillars, nil
+}
+
+// uniformFloorSpecs returns the specs of floors of the same dimensions, each
+// generated from the mix and pillar pattern given
+func uniformFloorSpecs(floors, rows, columns int, mix *model.DistributionSpec, pillars *model.PillarPattern) []model.FloorSpec {
+	specs := make([]model.FloorSpec, floors)
+	for f := range specs {
+		specs[f] = model.FloorSpec{Rows: rows, Columns: columns, Distribution: mix, Pillars: pillars}
+	}
+	return specs
+}
+
+// initDimensionFlags are the flags giving init's dimensions, which
+// config.ParseInitCommand parses
+var initDimensionFlags = map[string]bool{"floors": true, "rows": true, "columns": true}
+
+// splitDimensionFlags takes the dimension flags, written -floors 3, --floors 3
+// or -floors=3, out of init's arguments, returning them and the rest
+func splitDimensionFlags(args []string) (dimensions, rest []string) {
+	for i := 0; i < len(args); i++ {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !strings.HasPrefix(args[i], "-") || !initDimensionFlags[name] {
+			rest = append(rest, args[i])
+			continue
+		}
+		dimensions = append(dimensions, args[i])
+		if !hasValue && i+1 < len(args) {
+			dimensions = append(dimensions, args[i+1])
+			i++
+		}
+	}
+	return dimensions, rest
+}
+
+// handleInit handles the init command
+func (r *CommandRegistry) handleInit(args []string) error {
+	r.Logger.Debug("Initializing parking lot with args: %v", args)
+
+	// A leading name that is neither a dimension nor an option names the lot
+	// in the session, and is also its name unless --name gives another
+	lotName, name := defaultLotName, "Parking Lot"
+	if _, err := strconv.Atoi(args[0]); err != nil && !strings.HasPrefix(args[0], "-") {
+		lotName, name = args[0], args[0]
+		args = args[1:]
+		if len(args) == 0 {
+			return newUsageError("usage: init [<name>] <floors> <rows> <columns> or init [<name>] --map <file>")
+		}
+	}
+
+	// Dimensions come first, or as -floors, -rows and -columns among the
+	// options, unless the layout is loaded from a map file
+	var floors, rows, columns int
+	var err error
+	options := args
+	if dimensions, rest := splitDimensionFlags(args); len(dimensions) > 0 {
+		if len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+			return newUsageError("dimensions cannot be given both as arguments and as flags\nUsage: %s", r.Commands["init"].UsageText())
+		}
+		cfg, err := config.ParseInitCommand(dimensions)
+		if err != nil {
+			return newUsageError("invalid dimensions: %v\nUsage: %s", err, r.Commands["init"].UsageText())
+		}
+		floors, rows, columns = cfg.Floors, cfg.Rows, cfg.Columns
+		options = rest
+	} else if !strings.HasPrefix(args[0], "-") {
+		if len(args) < 3 {
+			return newUsageError("usage: init [<name>] <floors> <rows> <columns> or init [<name>] --map <file>")
+		}
+
+		// Parse arguments
+		floors, err = strconv.Atoi(args[0])
+		if err != nil {
+			return newUsageError("invalid floors value: %s", args[0])
+		}
+
+		rows, err = strconv.Atoi(args[1])
+		if err != nil {
+			return newUsageError("invalid rows value: %s", args[1])
+		}
+
+		columns, err = strconv.Atoi(args[2])
+		if err != nil {
+			return newUsageError("invalid columns value: %s", args[2])
+		}
+
+		options = args[3:]
+	}
+
+	// Parse options; a negative starting floor adds basements
+	startFloor := 0
+	var distribution *model.DistributionSpec
+	inactiveFraction := 0.0
+	var seed *int64
+	mapFile := ""
+	template := ""
+	numberRule := config.DefaultConfig().VehicleNumbers
+	ignoreSeparators := false
+	accessibleOverflow := false
+	var spotIDs model.SpotIDFormatter
+	var pillars *model.PillarPattern
+	var preset *config.ParkingLotConfig
+	for i := 0; i < len(options); i++ {
+		if i+1 >= len(options) {
+			return newUsageError("missing value for %s", options[i])
+		}
+
+		switch options[i] {
+		case "--start-floor":
+			startFloor, err = strconv.Atoi(options[i+1])
+			if err != nil {
+				return newUsageError("invalid start floor value: %s", options[i+1])
+			}
+		case "--mix":
+			mix, err := model.ParseDistributionSpec(options[i+1])
+			if err != nil {
+				return err
+			}
+			distribution = &mix
+		case "--inactive":
+			inactiveFraction, err = strconv.ParseFloat(options[i+1], 64)
+			if err != nil {
+				return fmt.Errorf("invalid inactive fraction: %s", options[i+1])
+			}
+		case "--seed":
+			// A random seed is printed, so the layout can still be reproduced
+			value := time.Now().UnixNano()
+			if options[i+1] != "random" {
+				value, err = strconv.ParseInt(options[i+1], 10, 64)
+				if err != nil {
+					return newUsageError("invalid seed value: %s", options[i+1])
+				}
+			}
+			seed = &value
+		case "--map":
+			mapFile = options[i+1]
+		case "--template":
+			template = options[i+1]
+		case "--preset":
+			cfg, err := config.GetPreset(options[i+1])
+			if err != nil {
+				return err
+			}
+			preset = &cfg
+		case "--pillars":
+			pattern, err := model.ParsePillarPattern(options[i+1])
+			if err != nil {
+				return err
+			}
+			pillars = &pattern
+		case "--name":
+			name = options[i+1]
+			if strings.TrimSpace(name) == "" {
+				return fmt.Errorf("invalid name: lot name cannot be empty")
+			}
+		case "--plate-pattern":
+			numberRule.Pattern = options[i+1]
+		case "--plate-length":
+			numberRule.MinLength, numberRule.MaxLength, err = parseLengthRange(options[i+1])
+			if err != nil {
+				return err
+			}
+		case "--plate-spaces":
+			numberRule.AllowSpaces, err = strconv.ParseBool(options[i+1])
+			if err != nil {
+				return newUsageError("invalid plate spaces value: %s", options[i+1])
+			}
+		case "--plate-separators":
+			switch options[i+1] {
+			case "strict":
+				ignoreSeparators = false
+			case "ignore":
+				ignoreSeparators = true
+			default:
+				return newUsageError("invalid plate separators value: %s (expected strict or ignore)", options[i+1])
+			}
+		case "--spot-ids":
+			spotIDs, err = model.SpotIDFormatterByName(options[i+1])
+			if err != nil {
+				return err
+			}
+		case "--accessible":
+			switch options[i+1] {
+			case "strict":
+				accessibleOverflow = false
+			case "overflow":
+				accessibleOverflow = true
+			default:
+				return newUsageError("invalid accessible value: %s (expected strict or overflow)", options[i+1])
+			}
+		default:
+			return newUsageError("unknown option: %s", options[i])
+		}
+		i++
+	}
+
+	// A preset gives the dimensions, mix and pillars the command leaves out;
+	// a seeded layout is shuffled, so it takes no pillars from one
+	if preset != nil {
+		if mapFile != "" || template != "" {
+			return fmt.Errorf("--preset cannot be combined with --map or --template")
+		}
+		if floors == 0 {
+			floors, rows, columns = preset.Floors, preset.Rows, preset.Columns
+		}
+		mix, presetPillars, err := layoutOptions(*preset)
+		if err != nil {
+			return err
+		}
+		if distribution == nil {
+			distribution = mix
+		}
+		if pillars == nil && seed == nil {
+			pillars = presetPillars
+		}
+	}
+
+	if inactiveFraction != 0 && distribution == nil {
+		return fmt.Errorf("--inactive requires --mix")
+	}
+
+	if mapFile != "" && (floors != 0 || distribution != nil || template != "") {
+		return fmt.Errorf("--map cannot be combined with dimensions, --mix or --template")
+	}
+
+	if template != "" && distribution != nil {
+		return fmt.Errorf("--template cannot be combined with --mix")
+	}
+
+	if pillars != nil && (mapFile != "" || template != "" || seed != nil) {
+		return fmt.Errorf("--pillars cannot be combined with --map, --template or --seed")
+	}
+
+	if seed != nil && (mapFile != "" || template != "") {
+		return fmt.Errorf("--seed cannot be combined with --map or --template")
+	}
+
+	if mapFile == "" && floors == 0 {
+		return newUsageError("usage: init [<name>] <floors> <rows> <columns> or init [<name>] --map <file>")
+	}
+
+	validator, err := newVehicleNumberValidator(numberRule)
+	if err != nil {
+		return err
+	}
+
+	// Create the parking lot, sending its diagnostics to the CLI logger
+	var parkingLot *model.ParkingLot
+	lotOptions := []model.LotOption{
+		model.WithLogger(lotLogger{registry: r}),
+		model.WithVehicleNumberValidator(validator),
+	}
+	if ignoreSeparators {
+		lotOptions = append(lotOptions, model.WithSeparatorInsensitiveMatching())
+	}
+	if spotIDs != nil {
+		lotOptions = append(lotOptions, model.WithSpotIDFormatter(spotIDs))
+	}
+	if accessibleOverflow {
+		lotOptions = append(lotOptions, model.WithAccessibleOverflow())
+	}
+	switch {
+	case mapFile != "":
+		r.Logger.Debug("Loading layout map from %s", mapFile)
+
+		layout, err := model.LoadLayoutMap(mapFile)
+		if err != nil {
+			return err
+		}
+
+		parkingLot, err = model.CreateParkingLotWithFloors(name, startFloor, layout.FloorSpecs(),
+			lotOptions...)
+		if err != nil {
+			return fmt.Errorf("failed to create parking lot: %w", err)
+		}
+
+		// Report the dimensions of the first floor; mapped floors may differ
+		floors = len(layout.SpotMap)
+		rows, columns, _ = layout.GetFloorDimensions(0)
+	case template != "":
+		r.Logger.Debug("Creating parking lot with %d floors, %d rows, %d columns, starting at floor %d, template %s",
+			floors, rows, columns, startFloor, template)
+
+		layout, err := model.NewSpotLayoutFromTemplate(template, floors, rows, columns)
+		if err != nil {
+			return fmt.Errorf("failed to create parking lot: %w", err)
+		}
+
+		parkingLot, err = model.CreateParkingLotWithFloors(name, startFloor, layout.FloorSpecs(),
+			lotOptions...)
+		if err != nil {
+			return fmt.Errorf("failed to create parking lot: %w", err)
+		}
+	case seed != nil:
+		if distribution != nil {
+			distribution.InactiveFraction = inactiveFraction
+		}
+		r.Logger.Debug("Creating parking lot with %d floors, %d rows, %d columns, starting at floor %d, layout seed %d",
+			floors, rows, columns, startFloor, *seed)
+
+		layout, err := model.NewSeededSpotLayout(floors, rows, columns, distribution, *seed)
+		if err != nil {
+			return fmt.Errorf("failed to create parking lot: %w", err)
+		}
+
+		parkingLot, err = model.CreateParkingLotWithFloors(name, startFloor, layout.FloorSpecs(),
+			lotOptions...)
+		if err != nil {
+			return fmt.Errorf("failed to create parking lot: %w", err)
+		}
+	case distribution != nil:
+		distribution.InactiveFraction = inactiveFraction
+		r.Logger.Debug("Creating parking lot with %d floors, %d rows, %d columns, starting at floor %d, mix %d:%d:%d with %.2f inactive",
+			floors, rows, columns, startFloor, distribution.BicycleWeight,
+			distribution.MotorcycleWeight, distribution.AutomobileWeight, inactiveFraction)
+
+		if pillars != nil {
+			parkingLot, err = model.CreateParkingLotWithFloors(name, startFloor,
+				uniformFloorSpecs(floors, rows, columns, distribution, pillars), lotOptions...)
+		} else {
+			parkingLot, err = model.CreateParkingLotWithDistribution(name, startFloor,
+				floors, rows, columns, *distribution, lotOptions...)
+		}
+	case pillars != nil:
+		r.Logger.Debug("Creating parking lot with %d floors, %d rows, %d columns, starting at floor %d, pillars %s",
+			floors, rows, columns, startFloor, pillars)
+
+		parkingLot, err = model.CreateParkingLotWithFloors(name, startFloor,
+			uniformFloorSpecs(floors, rows, columns, nil, pillars), lotOptions...)
+	default:
+		r.Logger.Debug("Creating parking lot with %d floors, %d rows, %d columns, starting at floor %d",
+			floors, rows, columns, startFloor)
+
+		parkingLot, err = model.CreateParkingLotFromFloor(name, startFloor,
+			floors, rows, columns, lotOptions...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create parking lot: %w", err)
+	}
+
+	// Store the parking lot in the registry as the active lot
+	r.addLot(lotName, parkingLot)
+
+	// Get counts by type
+	counts := parkingLot.GetSpotCountByType()
+
+	if r.Options.Format == OutputFormatJSON {
+		// Output as JSON
+		result := api.InitResult{
+			Lot:     lotName,
+			Floors:  floors,
+			Rows:    rows,
+			Columns: columns,
+			Total:   parkingLot.GetTotalSpotCount(),
+			Counts:  convertSpotTypeMap(counts),
+			Seed:    seed,
+		}
+
+		return r.out.JSON("init", result, nil)
+	}
+
+	// Output as text
+	r.out.Success("Created parking lot with %d floors, %d rows, and %d columns",
+		floors, rows, columns)
+	if lotName != defaultLotName {
+		r.out.Info("Active lot: %s", lotName)
+	}
+	r.out.Info("Total spots: %d", parkingLot.GetTotalSpotCount())
+	if seed != nil {
+		r.out.Info("Layout seed: %d", *seed)
+	}
+
+	// Show counts by type in a table
+	r.out.Println("Spot types:")
+	r.out.Println(formatSpotCountTable(counts))
+
+	return nil
+}
+
+// handleUse handles the use command
+func (r *CommandRegistry) handleUse(args []string) error {
+	lot, found := r.lots[args[0]]
+	if !found {
+		return fmt.Errorf("unknown lot: %s (initialized lots: %s)", args[0], strings.Join(r.lotNames(), ", "))
+	}
+
+	r.activeLot = args[0]
+	r.parkingLot = lot
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("use", r.lotSummary(args[0]), nil)
+	}
+
+	r.out.Success("Active lot: %s", args[0])
+
+	return nil
+}
+
+// handleClone handles the clone command
+func (r *CommandRegistry) handleClone(args []string) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	name := args[0]
+	if _, found := r.lots[name]; found {
+		return fmt.Errorf("lot %s already exists", name)
+	}
+	if strings.HasPrefix(name, "--") {
+		return fmt.Errorf("invalid lot name: %s", name)
+	}
+
+	r.Logger.Debug("Cloning the layout of %s into %s", r.parkingLot.GetName(), name)
+
+	clone, err := r.parkingLot.CloneLayout(name)
+	if err != nil {
+		return fmt.Errorf("failed to clone lot: %w", err)
+	}
+	r.lots[name] = clone
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("clone", r.lotSummary(name), nil)
+	}
+
+	r.out.Success("Cloned the layout of %s into lot %s", r.parkingLot.GetName(), name)
+	r.out.Info("Total spots: %d; switch to it with 'use %s'", clone.GetTotalSpotCount(), name)
+
+	return nil
+}
+
+// handleLots handles the lots command
+func (r *CommandRegistry) handleLots(args []string) error {
+	if len(r.lots) == 0 {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	results := make([]api.LotSummaryResult, 0, len(r.lots))
+	for _, name := range r.lotNames() {
+		results = append(results, r.lotSummary(name))
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("lots", results, nil)
+	}
+
+	r.out.Println(formatLotsTable(results))
+	return nil
+}
+
+// lotSummary returns the spot counts of the named lot
+func (r *CommandRegistry) lotSummary(name string) api.LotSummaryResult {
+	stats := r.lots[name].Stats()
+	return api.LotSummaryResult{
+		Name:           name,
+		Active:         name == r.activeLot,
+		NumFloors:      len(stats.Floors),
+		TotalSpots:     stats.TotalSpots,
+		OccupiedSpots:  stats.OccupiedSpots,
+		AvailableSpots: stats.AvailableSpots,
+	}
+}
+
+// formatLotsTable lists the lots, marking the active one with an asterisk
+func formatLotsTable(lots []api.LotSummaryResult) string {
+	rows := make([][]string, 0, len(lots))
+	for _, lot := range lots {
+		name := lot.Name
+		if lot.Active {
+			name += " *"
+		}
+		rows = append(rows, []string{
+			name,
+			fmt.Sprintf("%d", lot.NumFloors),
+			fmt.Sprintf("%d", lot.TotalSpots),
+			fmt.Sprintf("%d", lot.OccupiedSpots),
+			fmt.Sprintf("%d", lot.AvailableSpots),
+		})
+	}
+	return FormatTable([]string{"Lot", "Floors", "Spots", "Occupied", "Available"}, rows)
+}
+
+// handleAddFloor handles the add-floor command
+func (r *CommandRegistry) handleAddFloor(args []string) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	// Parse arguments
+	rows, err := strconv.Atoi(args[0])
+	if err != nil {
+		return newUsageError("invalid rows value: %s", args[0])
+	}
+
+	columns, err := strconv.Atoi(args[1])
+	if err != nil {
+		return newUsageError("invalid columns value: %s", args[1])
+	}
+
+	r.Logger.Debug("Adding floor with %d rows, %d columns", rows, columns)
+
+	if err := r.parkingLot.AddFloor(rows, columns, nil); err != nil {
+		return fmt.Errorf("failed to add floor: %w", err)
+	}
+
+	floors := r.parkingLot.GetFloors()
+	floor := floors[len(floors)-1]
+	counts := floor.GetSpotCountByType()
+
+	if r.Options.Format == OutputFormatJSON {
+		// Output as JSON
+		result := api.AddFloorResult{
+			Floor:   floor.FloorNumber,
+			Rows:    rows,
+			Columns: columns,
+			Total:   floor.GetSpotCount(),
+			Counts:  convertSpotTypeMap(counts),
+		}
+
+		return r.out.JSON("add-floor", result, nil)
+	}
+
+	// Output as text
+	r.out.Success("Added floor %d with %d rows and %d columns",
+		floor.FloorNumber, rows, columns)
+	r.out.Info("Total spots on floor: %d", floor.GetSpotCount())
+
+	return nil
+}
+
+// handleExpandFloor handles the expand-floor command
+func (r *CommandRegistry) handleExpandFloor(args []string) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	// Parse arguments
+	floorNum, err := strconv.Atoi(args[0])
+	if err != nil {
+		return newUsageError("invalid floor value: %s", args[0])
+	}
+
+	rows, err := strconv.Atoi(args[1])
+	if err != nil {
+		return newUsageError("invalid rows value: %s", args[1])
+	}
+
+	columns, err := strconv.Atoi(args[2])
+	if err != nil {
+		return newUsageError("invalid columns value: %s", args[2])
+	}
+
+	fill := model.SpotTypeAutomobile
+	if len(args) > 3 {
+		fill, err = model.ParseSpotType(args[3])
+		if err != nil {
+			return fmt.Errorf("invalid spot type: %s", args[3])
+		}
+	}
+
+	r.Logger.Debug("Expanding floor %d to %d rows, %d columns with %s spots",
+		floorNum, rows, columns, fill)
+
+	if err := r.parkingLot.ExpandFloor(floorNum, rows, columns, fill); err != nil {
+		return fmt.Errorf("failed to expand floor: %w", err)
+	}
+
+	floor, err := r.parkingLot.GetFloor(floorNum)
+	if err != nil {
+		return fmt.Errorf("failed to get floor: %w", err)
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		// Output as JSON
+		result := api.AddFloorResult{
+			Floor:   floorNum,
+			Rows:    rows,
+			Columns: columns,
+			Total:   floor.GetSpotCount(),
+			Counts:  convertSpotTypeMap(floor.GetSpotCountByType()),
+		}
+
+		return r.out.JSON("expand-floor", result, nil)
+	}
+
+	// Output as text
+	r.out.Success("Floor %d resized to %d rows and %d columns", floorNum, rows, columns)
+	r.out.Info("Total spots on floor: %d", floor.GetSpotCount())
+
+	return nil
+}
+
+// defineConfirmation returns the flags of a command that must be confirmed
+// with --yes, binding it to confirmed
+func defineConfirmation(confirmed *bool, usage string) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.BoolVar(confirmed, "yes", false, usage)
+	}
+}
+
+// parseFloorArg parses the floor number of a floor management command
+func parseFloorArg(arg string) (int, error) {
+	floorNum, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, newUsageError("invalid floor value: %s", arg)
+	}
+	return floorNum, nil
+}
+
+// confirmFloorCommand returns an error for a floor management command run
+// without --yes
+func confirmFloorCommand(command string, floorNum int, confirmed bool) error {
+	if confirmed {
+		return nil
+	}
+	return fmt.Errorf("%s %d requires confirmation, run '%s %d --yes' to proceed",
+		command, floorNum, command, floorNum)
+}
+
+// handleRemoveFloor handles the remove-floor command
+func (r *CommandRegistry) handleRemoveFloor(args []string, confirmed bool) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	floorNum, err := parseFloorArg(args[0])
+	if err != nil {
+		return err
+	}
+	if err := confirmFloorCommand("remove-floor", floorNum, confirmed); err != nil {
+		return err
+	}
+
+	r.Logger.Debug("Removing floor %d", floorNum)
+
+	if err := r.parkingLot.RemoveFloor(floorNum); err != nil {
+		return fmt.Errorf("failed to remove floor: %w", err)
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("remove-floor", api.FloorResult{Floor: floorNum}, nil)
+	}
+
+	r.out.Success("Removed floor %d, %d floors remaining",
+		floorNum, r.parkingLot.GetNumFloors())
+
+	return nil
+}
+
+// handleCloseFloor handles the close-floor command
+func (r *CommandRegistry) handleCloseFloor(args []string, confirmed bool) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	floorNum, err := parseFloorArg(args[0])
+	if err != nil {
+		return err
+	}
+	if err := confirmFloorCommand("close-floor", floorNum, confirmed); err != nil {
+		return err
+	}
+
+	r.Logger.Debug("Closing floor %d", floorNum)
+
+	if err := r.parkingLot.CloseFloor(floorNum); err != nil {
+		return fmt.Errorf("failed to close floor: %w", err)
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("close-floor", api.FloorResult{Floor: floorNum, Closed: true}, nil)
+	}
+
+	r.out.Success("Floor %d closed to new vehicles", floorNum)
+
+	return nil
+}
+
+// handleOpenFloor handles the open-floor command
+func (r *CommandRegistry) handleOpenFloor(args []string) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	floorNum, err := parseFloorArg(args[0])
+	if err != nil {
+		return err
+	}
+
+	r.Logger.Debug("Opening floor %d", floorNum)
+
+	if err := r.parkingLot.OpenFloor(floorNum); err != nil {
+		return fmt.Errorf("failed to open floor: %w", err)
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("open-floor", api.FloorResult{Floor: floorNum, Closed: false}, nil)
+	}
+
+	r.out.Success("Floor %d open to new vehicles", floorNum)
+
+	return nil
+}
+
+// handleRestrictFloor handles the restrict-floor command
+func (r *CommandRegistry) handleRestrictFloor(args []string, clearRestriction bool) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	floorNum, err := parseFloorArg(args[0])
+	if err != nil {
+		return err
+	}
+	if clearRestriction == (len(args) > 1) {
+		return newUsageError("give either the vehicle types or --clear\nUsage: %s", r.Commands["restrict-floor"].UsageText())
+	}
+
+	var allowed []model.VehicleType
+	if clearRestriction {
+		r.Logger.Debug("Clearing the restriction of floor %d", floorNum)
+
+		if err := r.parkingLot.ClearFloorRestriction(floorNum); err != nil {
+			return fmt.Errorf("failed to clear floor restriction: %w", err)
+		}
+	} else {
+		for _, arg := range args[1:] {
+			vehicleType, err := model.ParseVehicleType(arg)
+			if err != nil {
+				return withVehicleTypeNames(err)
+			}
+			allowed = append(allowed, vehicleType)
+		}
+
+		r.Logger.Debug("Restricting floor %d to %v", floorNum, allowed)
+
+		if err := r.parkingLot.RestrictFloor(floorNum, allowed); err != nil {
+			return fmt.Errorf("failed to restrict floor: %w", err)
+		}
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("restrict-floor", api.FloorResult{Floor: floorNum, Allowed: convertVehicleTypes(allowed)}, nil)
+	}
+
+	if allowed == nil {
+		r.out.Success("Floor %d accepts every vehicle type", floorNum)
+	} else {
+		r.out.Success("Floor %d restricted to %s", floorNum, formatAllowedVehicleTypes(allowed))
+	}
+
+	return nil
+}
+
+// formatAllowedVehicleTypes lists the vehicle types of a floor restriction
+// by display name, e.g. "Bicycle, Motorcycle"
+func formatAllowedVehicleTypes(allowed []model.VehicleType) string {
+	names := make([]string, len(allowed))
+	for i, vehicleType := range allowed {
+		names[i] = model.GetVehicleTypeDisplay(vehicleType)
+	}
+	return strings.Join(names, ", ")
+}
+
+// resolveSpotID returns the spot ID an argument names, looking up
+// arguments of the form @<label> by the spot's label
+func (r *CommandRegistry) resolveSpotID(arg string) (string, error) {
+	label, byLabel := strings.CutPrefix(arg, "@")
+	if !byLabel {
+		return arg, nil
+	}
+
+	spot, err := r.parkingLot.FindSpotByLabel(label)
+	if err != nil {
+		return "", err
+	}
+	return spot.GetSpotID(), nil
+}
+
+// describeSpot returns a spot ID followed by the spot's label, when it has one
+func describeSpot(spotID, label string) string {
+	if label == "" {
+		return spotID
+	}
+	return fmt.Sprintf("%s (%s)", spotID, label)
+}
+
+// handleLabel handles the label command
+func (r *CommandRegistry) handleLabel(args []string) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	spotID, err := r.resolveSpotID(args[0])
+	if err != nil {
+		return err
+	}
+	label := strings.Join(args[1:], " ")
+
+	r.Logger.Debug("Labelling spot %s as %q", spotID, label)
+
+	if err := r.parkingLot.SetSpotLabel(spotID, label); err != nil {
+		return fmt.Errorf("failed to label spot: %w", err)
+	}
+
+	spot, err := r.parkingLot.GetSpotByID(spotID)
+	if err != nil {
+		return err
+	}
+	label = spot.GetLabel()
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("label", api.LabelResult{SpotID: spot.GetSpotID(), Label: label}, nil)
+	}
+
+	if label == "" {
+		r.out.Success("Spot %s no longer has a label", spot.GetSpotID())
+	} else {
+		r.out.Success("Spot %s is now labelled %s", spot.GetSpotID(), label)
+	}
+
+	return nil
+}
+
+// handleTag handles the tag command
+func (r *CommandRegistry) handleTag(args []string) error {
+	return r.changeSpotTags(args, "tag", r.parkingLot.TagSpot)
+}
+
+// handleUntag handles the untag command
+func (r *CommandRegistry) handleUntag(args []string) error {
+	return r.changeSpotTags(args, "untag", r.parkingLot.UntagSpot)
+}
+
+// changeSpotTags applies change to the spot named by args[0] for each tag
+// that follows, then prints the spot's tags
+func (r *CommandRegistry) changeSpotTags(args []string, command string, change func(spotID, tag string) error) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	spotID, err := r.resolveSpotID(args[0])
+	if err != nil {
+		return err
+	}
+
+	r.Logger.Debug("Running %s on spot %s with %v", command, spotID, args[1:])
+
+	for _, tag := range args[1:] {
+		if err := change(spotID, tag); err != nil {
+			return fmt.Errorf("failed to %s spot: %w", command, err)
+		}
+	}
+
+	spot, err := r.parkingLot.GetSpotByID(spotID)
+	if err != nil {
+		return err
+	}
+	tags := spot.GetTags()
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON(command, api.TagResult{SpotID: spot.GetSpotID(), Tags: tags}, nil)
+	}
+
+	if len(tags) == 0 {
+		r.out.Success("Spot %s has no tags", spot.GetSpotID())
+	} else {
+		r.out.Success("Spot %s is tagged %s", spot.GetSpotID(), strings.Join(tags, ", "))
+	}
+
+	return nil
+}
+
+// handleChargeStatus handles the charge-status command
+func (r *CommandRegistry) handleChargeStatus(args []string) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	spotID, err := r.resolveSpotID(args[0])
+	if err != nil {
+		return err
+	}
+
+	state, err := model.ParseChargerState(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid charger state: %w", err)
+	}
+
+	r.Logger.Debug("Setting charger of spot %s to %s", spotID, state)
+
+	if err := r.parkingLot.SetChargerState(spotID, state); err != nil {
+		return fmt.Errorf("failed to set charger state: %w", err)
+	}
+
+	spot, err := r.parkingLot.GetSpotByID(spotID)
+	if err != nil {
+		return err
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("charge-status", api.ChargeStatusResult{SpotID: spot.GetSpotID(), State: string(state)}, nil)
+	}
+
+	r.out.Success("Charger of spot %s is now %s", spot.GetSpotID(), formatChargerState(state))
+
+	return nil
+}
+
+// formatChargerState returns a charger state as words
+func formatChargerState(state model.ChargerState) string {
+	return strings.ReplaceAll(string(state), "_", " ")
+}
+
+// handleTags handles the tags command
+func (r *CommandRegistry) handleTags(args []string) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	counts := r.parkingLot.TagCounts()
+
+	if r.Options.Format == OutputFormatJSON {
+		result := api.TagsResult{Tags: make([]api.TagCountResult, 0, len(counts))}
+		for _, count := range counts {
+			result.Tags = append(result.Tags, api.TagCountResult{
+				Tag:       count.Tag,
+				Spots:     count.Spots,
+				Available: count.Available,
+			})
+		}
+
+		return r.out.JSON("tags", result, nil)
+	}
+
+	if len(counts) == 0 {
+		r.out.Println("No spots are tagged")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(counts))
+	for _, count := range counts {
+		rows = append(rows, []string{count.Tag, fmt.Sprintf("%d", count.Spots), fmt.Sprintf("%d", count.Available)})
+	}
+	r.out.Println(FormatTable([]string{"Tag", "Spots", "Available"}, rows))
+
+	return nil
+}
+
+// handleSetSpot handles the set-spot command
+func (r *CommandRegistry) handleSetSpot(args []string) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	spotID, err := r.resolveSpotID(args[0])
+	if err != nil {
+		return err
+	}
+	spotType, err := model.ParseSpotType(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid spot type: %s (valid types: B-1, M-1, A-1, X-0)", args[1])
+	}
+
+	r.Logger.Debug("Setting spot %s to type %s", spotID, spotType)
+
+	if err := r.parkingLot.SetSpotType(spotID, spotType); err != nil {
+		return fmt.Errorf("failed to set spot type: %w", err)
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("set-spot", api.SetSpotResult{SpotID: spotID, SpotType: string(spotType)}, nil)
+	}
+
+	r.out.Success("Spot %s is now %s", spotID, model.GetSpotTypeDisplay(spotType))
+
+	return nil
+}
+
+// parkFlags are the flags of park and park-at
+type parkFlags struct {
+	require  stringList
+	ev       bool
+	permit   bool
+	reserved bool
+}
+
+// define binds the flags of park to fs, resetting them to their defaults
+func (f *parkFlags) define(fs *flag.FlagSet) {
+	f.defineAt(fs)
+	fs.Var(&f.require, "require", "park only in a spot carrying the `tag`; may be given more than once")
+	fs.BoolVar(&f.ev, "ev", false, "prefer a spot with a working charger")
+}
+
+// defineAt binds the flags of park-at to fs, resetting them to their defaults
+func (f *parkFlags) defineAt(fs *flag.FlagSet) {
+	*f = parkFlags{}
+	fs.BoolVar(&f.permit, "permit", false, "the vehicle has an accessibility permit")
+	fs.BoolVar(&f.reserved, "reserved", false, "park as reserved, neither counted against nor refused by the quotas")
+}
+
+// handlePark handles the park command
+func (r *CommandRegistry) handlePark(args []string, flags parkFlags) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	// Parse arguments
+	vehicleTypeStr := strings.ToUpper(args[0])
+	vehicleNumber := args[1]
+
+	opts := model.ParkOptions{
+		Tags:          flags.require,
+		PreferCharger: flags.ev,
+		Permit:        flags.permit,
+		Reserved:      flags.reserved,
+	}
+	if opts.PreferCharger && len(opts.Tags) > 0 {
+		return fmt.Errorf("--ev cannot be combined with --require")
+	}
+
+	r.Logger.Debug("Attempting to park vehicle: type=%s, number=%s, options=%+v",
+		vehicleTypeStr, vehicleNumber, opts)
+
+	return r.parkVehicle(vehicleTypeStr, vehicleNumber, opts)
+}
+
+// handleParkAt handles the park-at command
+func (r *CommandRegistry) handleParkAt(args []string, flags parkFlags) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	spotID, err := r.resolveSpotID(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to park vehicle: %w", err)
+	}
+
+	opts := model.ParkOptions{SpotID: spotID, Permit: flags.permit, Reserved: flags.reserved}
+
+	r.Logger.Debug("Attempting to park vehicle: type=%s, number=%s, spot=%s",
+		args[1], args[2], spotID)
+
+	return r.parkVehicle(strings.ToUpper(args[1]), args[2], opts)
+}
+
+// parkVehicle parks a vehicle with the options and prints where it went
+func (r *CommandRegistry) parkVehicle(vehicleTypeStr, vehicleNumber string, opts model.ParkOptions) error {
+	// Convert vehicle type
+	vehicleType, err := model.ParseVehicleType(vehicleTypeStr)
+	if err != nil {
+		return withVehicleTypeNames(err)
+	}
+
+	// Try to park the vehicle
+	opts.Timings = r.timings
+	detail, err := r.parkingLot.ParkWithOptions(vehicleType, vehicleNumber, opts)
+	if err != nil {
+		return fmt.Errorf("failed to park vehicle: %w", err)
+	}
+
+	r.Logger.WithFields("vehicle", detail.NormalizedNumber, "spot", detail.SpotID).Debug("Vehicle parked successfully")
+	r.printResult("%s", detail.SpotID)
+	r.record(journalEntry{Vehicle: model.ParkedVehicle{
+		VehicleNumber: detail.NormalizedNumber,
+		VehicleType:   detail.VehicleType,
+		SpotID:        detail.SpotID,
+		ParkedAt:      detail.ParkedAt,
+		SpotIDs:       detail.SpotIDs,
+		Reserved:      opts.Reserved,
+	}})
+
+	if r.Options.Format == OutputFormatJSON {
+		// Output as JSON
+		result := api.ParkResult{
+			VehicleType:   string(detail.VehicleType),
+			VehicleNumber: detail.NormalizedNumber,
+			SpotID:        detail.SpotID,
+			Location:      api.SpotLocation{Floor: detail.Floor, Row: detail.Row, Column: detail.Column},
+			Floor:         detail.Floor,
+			Row:           detail.Row,
+			Column:        detail.Column,
+			Label:         detail.Label,
+			Charger:       detail.Charger,
+			SpotIDs:       detail.SpotIDs,
+			ParkedAt:      jsonTime(detail.ParkedAt),
+		}
+
+		return r.out.JSON("park", result, nil)
+	}
+
+	if len(detail.SpotIDs) > 1 {
+		// An oversized vehicle is reported with every spot it takes
+		r.out.Success("Vehicle %s parked successfully across spots %s", vehicleNumber,
+			strings.Join(detail.SpotIDs, ", "))
+	} else {
+		// Output as text
+		r.out.Success("Vehicle %s parked successfully at spot %s", vehicleNumber,
+			describeSpot(detail.SpotID, detail.Label))
+		if opts.PreferCharger && !detail.Charger {
+			r.out.Warning("No charging spot was free, %s has no working charger", describeSpot(detail.SpotID, detail.Label))
+		}
+	}
+
+	return nil
+}
+
+// handlePermit handles the permit command
+func (r *CommandRegistry) handlePermit(args []string, revoke bool) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	if len(args) == 0 {
+		if revoke {
+			return newUsageError("--revoke needs a vehicle number\nUsage: %s", r.Commands["permit"].UsageText())
+		}
+
+		permits := r.parkingLot.GetPermits()
+		if r.Options.Format == OutputFormatJSON {
+			return r.out.JSON("permit", api.PermitsResult{Permits: permits}, nil)
+		}
+
+		if len(permits) == 0 {
+			r.out.Println("No permits registered")
+		} else {
+			r.out.Printf("Vehicles with an accessibility permit: %s\n", strings.Join(permits, ", "))
+		}
+		return nil
+	}
+
+	vehicleNumber := args[0]
+
+	r.Logger.Debug("Changing permit of %s, revoke=%v", vehicleNumber, revoke)
+
+	if revoke {
+		if !r.parkingLot.RevokePermit(vehicleNumber) {
+			return fmt.Errorf("vehicle %s has no registered permit", vehicleNumber)
+		}
+	} else if err := r.parkingLot.RegisterPermit(vehicleNumber); err != nil {
+		return fmt.Errorf("failed to register permit: %w", err)
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("permit", api.PermitResult{VehicleNumber: vehicleNumber, Permit: !revoke}, nil)
+	}
+
+	if revoke {
+		r.out.Success("Permit of vehicle %s revoked", vehicleNumber)
+	} else {
+		r.out.Success("Vehicle %s may now use accessible spots", vehicleNumber)
+	}
+
+	return nil
+}
+
+// quotaFlags are the flags of quota
+type quotaFlags struct {
+	exempt optionalString
+	revoke bool
+}
+
+// define binds the flags to fs, resetting them to their defaults
+func (f *quotaFlags) define(fs *flag.FlagSet) {
+	*f = quotaFlags{}
+	fs.Var(&f.exempt, "exempt", "exempt the vehicle `number` from the quotas, as a reserved vehicle")
+	fs.BoolVar(&f.revoke, "revoke", false, "with --exempt, make the vehicle count against the quotas again")
+}
+
+// handleQuota handles the quota command
+func (r *CommandRegistry) handleQuota(args []string, flags quotaFlags) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	usage := r.Commands["quota"].UsageText()
+	if flags.exempt.set {
+		if len(args) > 0 {
+			return newUsageError("--exempt cannot be combined with a quota\nUsage: %s", usage)
+		}
+		return r.exemptFromQuotas(flags.exempt.value, flags.revoke)
+	}
+	if flags.revoke {
+		return newUsageError("--revoke needs --exempt\nUsage: %s", usage)
+	}
+
+	if len(args) == 0 {
+		quotas := r.parkingLot.GetQuotas()
+		if r.Options.Format == OutputFormatJSON {
+			return r.out.JSON("quota", newQuotasResult(quotas), nil)
+		}
+
+		if len(quotas) == 0 {
+			r.out.Println("No quotas set")
+		} else {
+			r.out.Println(formatQuotas(quotas))
+		}
+		return nil
+	}
+
+	if len(args) != 2 {
+		return newUsageError("too few arguments for command 'quota'\nUsage: %s", usage)
+	}
+
+	vehicleType, err := model.ParseVehicleType(args[0])
+	if err != nil {
+		return withVehicleTypeNames(err)
+	}
+
+	if args[1] == "off" {
+		r.Logger.Debug("Clearing the quota of %s", vehicleType)
+
+		if err := r.parkingLot.ClearQuota(vehicleType); err != nil {
+			return fmt.Errorf("failed to clear quota: %w", err)
+		}
+
+		if r.Options.Format == OutputFormatJSON {
+			return r.out.JSON("quota", newQuotasResult(r.parkingLot.GetQuotas()), nil)
+		}
+
+		r.out.Success("Quota of %s cleared", model.GetVehicleTypeDisplay(vehicleType))
+		return nil
+	}
+
+	limit, err := strconv.Atoi(args[1])
+	if err != nil {
+		return newUsageError("invalid quota: %s\nUsage: %s", args[1], usage)
+	}
+
+	r.Logger.Debug("Setting the quota of %s to %d", vehicleType, limit)
+
+	if err := r.parkingLot.SetQuota(vehicleType, limit); err != nil {
+		return fmt.Errorf("failed to set quota: %w", err)
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("quota", newQuotasResult(r.parkingLot.GetQuotas()), nil)
+	}
+
+	r.out.Success("Quota of %s set to %d general parks", model.GetVehicleTypeDisplay(vehicleType), limit)
+
+	return nil
+}
+
+// handleRename handles the rename command
+func (r *CommandRegistry) handleRename(args []string) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	// The name may be quoted or given as several words
+	oldName := r.parkingLot.GetName()
+	newName := strings.Join(args, " ")
+
+	r.Logger.Debug("Renaming lot %s to %s", oldName, newName)
+
+	if err := r.parkingLot.SetName(newName); err != nil {
+		return fmt.Errorf("failed to rename lot: %w", err)
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("rename", api.RenameResult{OldName: oldName, Name: r.parkingLot.GetName()}, nil)
+	}
+
+	r.out.Success("Renamed lot %s to %s", oldName, r.parkingLot.GetName())
+
+	return nil
+}
+
+// resetFlags are the flags of reset
+type resetFlags struct {
+	yes          bool
+	vehiclesOnly bool
+	historyOnly  bool
+	zeroStats    bool
+}
+
+// define binds the flags to fs, resetting them to their defaults
+func (f *resetFlags) define(fs *flag.FlagSet) {
+	*f = resetFlags{}
+	fs.BoolVar(&f.yes, "yes", false, "confirm the reset")
+	fs.BoolVar(&f.vehiclesOnly, "vehicles-only", false, "remove only the parked vehicles, keeping the history")
+	fs.BoolVar(&f.historyOnly, "history-only", false, "remove only the completed records, keeping the vehicles parked")
+	fs.BoolVar(&f.zeroStats, "zero-stats", false, "also set the operation counts back to zero")
+}
+
+// handleReset handles the reset command
+func (r *CommandRegistry) handleReset(flags resetFlags) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	mode, zeroStats := "all", flags.zeroStats
+	switch {
+	case flags.vehiclesOnly && flags.historyOnly:
+		return fmt.Errorf("--vehicles-only and --history-only cannot be combined")
+	case flags.vehiclesOnly:
+		mode = "vehicles"
+	case flags.historyOnly:
+		mode = "history"
+	}
+
+	if zeroStats && mode != "all" {
+		return fmt.Errorf("--zero-stats cannot be combined with --%s-only", mode)
+	}
+	if !flags.yes {
+		command := "reset"
+		if mode != "all" {
+			command += " --" + mode + "-only"
+		}
+		if zeroStats {
+			command += " --zero-stats"
+		}
+		return fmt.Errorf("reset requires confirmation, run '%s --yes' to proceed", command)
+	}
+
+	r.Logger.Debug("Resetting %s of lot %s", mode, r.parkingLot.GetName())
+
+	// Parks and unparks from before the reset are no longer undone
+	delete(r.journals, r.parkingLot)
+
+	result := api.ResetResult{Mode: mode, StatsZeroed: zeroStats}
+	switch mode {
+	case "vehicles":
+		result.Vehicles = r.parkingLot.ClearVehicles()
+	case "history":
+		result.Records = r.parkingLot.ClearHistory()
+	default:
+		result.Vehicles, result.Records = r.parkingLot.ResetWithOptions(model.ResetOptions{ZeroOperationStats: zeroStats})
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("reset", result, nil)
+	}
+
+	switch mode {
+	case "vehicles":
+		r.out.Success("Removed %d parked vehicles, history kept", result.Vehicles)
+	case "history":
+		r.out.Success("Removed %d completed parking records, parked vehicles kept", result.Records)
+	default:
+		r.out.Success("Removed %d parked vehicles and %d parking records", result.Vehicles, result.Records)
+		if zeroStats {
+			r.out.Info("Peak occupancy, quota usage and operation counts cleared")
+		} else {
+			r.out.Info("Peak occupancy and quota usage cleared")
+		}
+	}
+
+	return nil
+}
+
+// describeFlags are the flags of describe, each replacing only the field it names
+type describeFlags struct {
+	address  optionalString
+	timezone optionalString
+	notes    optionalString
+}
+
+// define binds the flags to fs, resetting them to their defaults
+func (f *describeFlags) define(fs *flag.FlagSet) {
+	*f = describeFlags{}
+	fs.Var(&f.address, "address", "set the address of the lot to `text`, or clear it given empty")
+	fs.Var(&f.timezone, "timezone", "set the timezone of the lot to `zone`, or clear it given empty")
+	fs.Var(&f.notes, "notes", "set the notes on the lot to `text`, or clear them given empty")
+}
+
+// handleDescribe handles the describe command
+func (r *CommandRegistry) handleDescribe(flags describeFlags) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	// Options replace only the fields they name; an empty value clears one
+	metadata := r.parkingLot.Metadata()
+	fields := []struct {
+		flag  optionalString
+		value *string
+	}{
+		{flags.address, &metadata.Address},
+		{flags.timezone, &metadata.Timezone},
+		{flags.notes, &metadata.Notes},
+	}
+	changed := false
+	for _, field := range fields {
+		if field.flag.set {
+			*field.value, changed = field.flag.value, true
+		}
+	}
+
+	if changed {
+		r.Logger.Debug("Describing lot: address=%q, timezone=%q, notes=%q",
+			metadata.Address, metadata.Timezone, metadata.Notes)
+
+		if err := r.parkingLot.SetMetadata(metadata); err != nil {
+			return fmt.Errorf("failed to describe lot: %w", err)
+		}
+		metadata = r.parkingLot.Metadata()
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("describe", newLotMetadataResult(metadata), nil)
+	}
+
+	if text := formatLotMetadata(metadata); text != "" {
+		r.out.Println(text)
+	} else {
+		r.out.Println("No description set")
+	}
+
+	return nil
+}
+
+// formatLotMetadata renders the metadata fields that are set, one per line
+func formatLotMetadata(metadata model.LotMetadata) string {
+	var lines []string
+	if metadata.Address != "" {
+		lines = append(lines, "Address: "+metadata.Address)
+	}
+	if metadata.Timezone != "" {
+		lines = append(lines, "Timezone: "+metadata.Timezone)
+	}
+	if metadata.Notes != "" {
+		lines = append(lines, "Notes: "+metadata.Notes)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exemptFromQuotas handles quota --exempt, or with --revoke takes the
+// exemption back
+func (r *CommandRegistry) exemptFromQuotas(vehicleNumber string, revoke bool) error {
+	r.Logger.Debug("Changing quota exemption of %s, revoke=%v", vehicleNumber, revoke)
+
+	if revoke {
+		if !r.parkingLot.RevokeQuotaExemption(vehicleNumber) {
+			return fmt.Errorf("vehicle %s is not exempt from quotas", vehicleNumber)
+		}
+	} else if err := r.parkingLot.ExemptFromQuotas(vehicleNumber); err != nil {
+		return fmt.Errorf("failed to exempt vehicle: %w", err)
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("quota", api.QuotaExemptionResult{VehicleNumber: vehicleNumber, Exempt: !revoke}, nil)
+	}
+
+	if revoke {
+		r.out.Success("Vehicle %s counts against quotas again", vehicleNumber)
+	} else {
+		r.out.Success("Vehicle %s is exempt from quotas", vehicleNumber)
+	}
+
+	return nil
+}
+
+// formatQuotas renders the quotas on one line, e.g.
+// "Quotas (general parks/quota): Automobile 48/50"
+func formatQuotas(quotas []model.Quota) string {
+	parts := make([]string, len(quotas))
+	for i, quota := range quotas {
+		parts[i] = fmt.Sprintf("%s %d/%d", model.GetVehicleTypeDisplay(quota.VehicleType), quota.Used, quota.Limit)
+	}
+	return "Quotas (general parks/quota): " + strings.Join(parts, ", ")
+}
+
+// handleUnpark handles the unpark command
+func (r *CommandRegistry) handleUnpark(args []string) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	// Parse arguments
+	spotID, err := r.resolveSpotID(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to unpark vehicle: %w", err)
+	}
+	vehicleNumber := args[1]
+
+	r.Logger.Debug("Attempting to remove vehicle %s from spot %s",
+		vehicleNumber, spotID)
+
+	// Try to unpark the vehicle. Without a parking record it is still
+	// unparked, only the duration is unknown.
+	parked, _ := r.parkingLot.GetParkedVehicle(vehicleNumber)
+	duration, err := r.parkingLot.UnparkTimed(spotID, vehicleNumber, r.timings)
+	recorded := !errors.Is(err, perrors.ErrNoParkingRecord)
+	if err != nil && recorded {
+		return fmt.Errorf("failed to unpark vehicle: %w", err)
+	}
+	r.record(journalEntry{Unparked: true, Vehicle: parked})
+
+	r.Logger.WithFields("vehicle", vehicleNumber, "spot", spotID, "recorded", recorded).Debug("Vehicle removed")
+
+	if r.Options.Format == OutputFormatJSON {
+		// Output as JSON
+		location, err := spotLocation(r.parkingLot, spotID)
+		if err != nil {
+			return err
+		}
+		result := api.UnparkResult{
+			VehicleNumber:   vehicleNumber,
+			SpotID:          spotID,
+			Location:        location,
+			DurationSeconds: duration.Seconds(),
+		}
+
+		return r.out.JSON("unpark", result, nil)
+	}
+
+	if recorded {
+		// Output as text
+		r.out.Success("Vehicle %s successfully removed from spot %s, parked for %s\n",
+			vehicleNumber, spotID, FormatDuration(duration, DurationVerbose))
+	} else {
+		r.out.Success("Vehicle %s successfully removed from spot %s\n", vehicleNumber, spotID)
+	}
+
+	return nil
+}
+
+// availableFlags holds the flags of the available command
+type availableFlags struct {
+	floor     optionalInt
+	limit     optionalInt
+	nearest   optionalInt
+	countOnly bool
+	require   stringList
+	ndjson    bool
+}
+
+// define binds the flags to fs, resetting them to their defaults
+func (f *availableFlags) define(fs *flag.FlagSet) {
+	*f = availableFlags{}
+	fs.Var(&f.floor, "floor", "list only the spots on floor `n`")
+	fs.Var(&f.limit, "limit", "list at most `n` spots")
+	fs.Var(&f.nearest, "nearest", "list the `n` spots nearest the entrance")
+	fs.BoolVar(&f.countOnly, "count-only", false, "show only how many spots are available")
+	fs.Var(&f.require, "require", "list only spots carrying the `tag`; may be given more than once")
+	fs.BoolVar(&f.ndjson, "ndjson", false, "stream the spots as JSON, one object a line")
+}
+
+// handleAvailable handles the available command
+func (r *CommandRegistry) handleAvailable(args []string, flags *availableFlags) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	// Parse arguments
+	vehicleTypeStr := strings.ToUpper(args[0])
+
+	r.Logger.Debug("Searching for available spots for vehicle type: %s", vehicleTypeStr)
+
+	// Convert vehicle type
+	vehicleType, err := model.ParseVehicleType(vehicleTypeStr)
+	if err != nil {
+		return withVehicleTypeNames(err)
+	}
+
+	// Check options
+	var floor *model.ParkingFloor
+	floorNum := flags.floor.value
+	if flags.floor.set {
+		floor, err = r.parkingLot.GetFloor(floorNum)
+		if err != nil {
+			return fmt.Errorf("failed to get floor: %w", err)
+		}
+	}
+	limit := -1
+	if flags.limit.set {
+		if flags.limit.value < 1 {
+			return newUsageError("invalid limit value: %d", flags.limit.value)
+		}
+		limit = flags.limit.value
+	}
+	nearest := -1
+	if flags.nearest.set {
+		if flags.nearest.value < 1 {
+			return newUsageError("invalid nearest value: %d", flags.nearest.value)
+		}
+		nearest = flags.nearest.value
+	}
+	countOnly := flags.countOnly
+	var required []string
+	for _, value := range flags.require {
+		tag, err := model.NormalizeSpotTag(value)
+		if err != nil {
+			return fmt.Errorf("invalid tag: %w", err)
+		}
+		required = append(required, tag)
+	}
+
+	if flags.ndjson {
+		if nearest > 0 || countOnly || len(required) > 0 {
+			return fmt.Errorf("--ndjson cannot be combined with --nearest, --count-only or --require")
+		}
+		return r.streamAvailable(vehicleType, floor, limit)
+	}
+
+	if nearest > 0 {
+		if floor != nil || limit > 0 || countOnly || len(required) > 0 {
+			return fmt.Errorf("--nearest cannot be combined with --floor, --limit, --count-only or --require")
+		}
+		return r.printNearestSpots(vehicleType, nearest)
+	}
+
+	if len(required) > 0 {
+		return r.printTaggedAvailable(vehicleType, required, floor, limit, countOnly)
+	}
+
+	if countOnly {
+		return r.printAvailableCount(vehicleType, floor)
+	}
+
+	// Get available spots; a limit stops the scan early instead of truncating
+	var spotsByFloor map[int][]string
+	switch {
+	case floor != nil:
+		var spots []*model.ParkingSpot
+		if limit > 0 {
+			spots = floor.GetAvailableSpotsN(vehicleType, limit)
+		} else {
+			spots = floor.GetAvailableSpots(vehicleType)
+		}
+
+		spotsByFloor = make(map[int][]string)
+		for _, spot := range spots {
+			spotsByFloor[floorNum] = append(spotsByFloor[floorNum], spot.GetSpotID())
+		}
+	case limit > 0:
+		spots, err := r.parkingLot.AvailableSpotN(vehicleType, limit)
+		if err != nil {
+			return fmt.Errorf("failed to get available spots: %w", err)
+		}
+
+		spotsByFloor = make(map[int][]string)
+		for _, spotID := range spots {
+			n, _, _, _ := r.parkingLot.ParseSpotID(spotID)
+			spotsByFloor[n] = append(spotsByFloor[n], spotID)
+		}
+	default:
+		spotsByFloor, err = r.parkingLot.AvailableSpotsByFloor(vehicleType)
+		if err != nil {
+			return fmt.Errorf("failed to get available spots: %w", err)
+		}
+	}
+
+	count := 0
+	floors := make([]int, 0, len(spotsByFloor))
+	for n, spots := range spotsByFloor {
+		count += len(spots)
+		floors = append(floors, n)
+	}
+	sort.Ints(floors)
+
+	r.Logger.Debug("Found %d available spots for %s on %d floors", count, vehicleTypeStr, len(floors))
+
+	// Racks can hold several bicycles, so free capacity may exceed the spot count
+	capacity := r.parkingLot.GetAvailableCapacityByType()[vehicleType]
+	if floor != nil {
+		capacity = floor.GetAvailableCapacityByType()[vehicleType]
+	}
+
+	accessible := r.countAccessible(vehicleType, floor, spotsByFloor)
+
+	if r.Options.Format == OutputFormatJSON {
+		// Output as JSON
+		locations, err := locateSpots(r.parkingLot, floors, spotsByFloor)
+		if err != nil {
+			return err
+		}
+		result := api.AvailableResult{
+			VehicleType:       string(vehicleType),
+			SpotsByFloor:      spotsByFloor,
+			Locations:         locations,
+			Count:             count,
+			AvailableCapacity: capacity,
+			Accessible:        accessible,
+		}
+
+		return r.out.JSON("available", result, nil)
+	}
+
+	// Output as text
+	if count == 0 {
+		r.out.Printf("No available spots for vehicle type %s\n", vehicleTypeStr)
+		return nil
+	}
+
+	r.out.Printf("Available spots for %s:\n", model.GetVehicleTypeDisplay(vehicleType))
+
+	for _, n := range floors {
+		spots := spotsByFloor[n]
+		r.out.Printf("Floor %s (%d available):\n", model.FormatFloorNumber(n), len(spots))
+		r.out.Println(formatSpotIDTable(spots))
+	}
+
+	if limit > 0 && count == limit {
+		r.out.Printf("Showing the first %d available spots\n", count)
+	} else if floor != nil {
+		r.out.Printf("Total available on floor %s: %d\n", model.FormatFloorNumber(floorNum), count)
+	} else if vehicleType == model.VehicleTypeBicycle {
+		r.out.Printf("Total available: %d racks, %d slots free\n", count, capacity)
+	} else {
+		r.out.Printf("Total available: %d\n", count)
+	}
+	printAccessibleShare(r.out, accessible)
+
+	return nil
+}
+
+// countAccessible returns how many of the listed spots are accessible
+func (r *CommandRegistry) countAccessible(vehicleType model.VehicleType, floor *model.ParkingFloor,
+	spotsByFloor map[int][]string) int {
+	listed := make(map[string]bool)
+	for _, spots := range spotsByFloor {
+		for _, spotID := range spots {
+			listed[spotID] = true
+		}
+	}
+
+	count := 0
+	for _, spotID := range r.accessibleSpotIDs(vehicleType, floor) {
+		if listed[spotID] {
+			count++
+		}
+	}
+	return count
+}
+
+// accessibleSpotIDs returns the available accessible spots of the floor, or
+// of the lot when floor is nil
+func (r *CommandRegistry) accessibleSpotIDs(vehicleType model.VehicleType, floor *model.ParkingFloor) []string {
+	tags := []string{model.AccessibleTag}
+	if floor == nil {
+		spotIDs, _ := r.parkingLot.AvailableSpotsWithTags(vehicleType, tags, -1)
+		return spotIDs
+	}
+
+	var spotIDs []string
+	for _, spot := range floor.GetAvailableSpotsWithTags(vehicleType, tags, -1) {
+		spotIDs = append(spotIDs, spot.GetSpotID())
+	}
+	return spotIDs
+}
+
+// printAccessibleShare notes how many available spots need a permit
+func printAccessibleShare(out *Output, accessible int) {
+	if accessible > 0 {
+		out.Printf("Accessible spots among them: %d (permit holders only)\n", accessible)
+	}
+}
+
+// printTaggedAvailable prints the available spots carrying every one of the
+// normalized tags, like available does for all spots
+func (r *CommandRegistry) printTaggedAvailable(vehicleType model.VehicleType, tags []string,
+	floor *model.ParkingFloor, limit int, countOnly bool) error {
+	floors := r.parkingLot.GetFloors()
+	if floor != nil {
+		floors = []*model.ParkingFloor{floor}
+	}
+
+	// Racks can hold several bicycles, so free capacity is summed per spot
+	var spots []*model.ParkingSpot
+	for _, f := range floors {
+		remaining := -1
+		if limit > 0 {
+			remaining = limit - len(spots)
+			if remaining == 0 {
+				break
+			}
+		}
+		spots = append(spots, f.GetAvailableSpotsWithTags(vehicleType, tags, remaining)...)
+	}
+
+	spotsByFloor := make(map[int][]string)
+	capacity := 0
+	for _, spot := range spots {
+		spotsByFloor[spot.Floor] = append(spotsByFloor[spot.Floor], spot.GetSpotID())
+		capacity += spot.GetAvailableCapacity()
+	}
+	count := len(spots)
+	accessible := r.countAccessible(vehicleType, floor, spotsByFloor)
+
+	r.Logger.Debug("Found %d available spots for %s tagged %v", count, vehicleType, tags)
+
+	if r.Options.Format == OutputFormatJSON {
+		result := api.AvailableResult{
+			VehicleType:       string(vehicleType),
+			Tags:              tags,
+			Count:             count,
+			AvailableCapacity: capacity,
+			Accessible:        accessible,
+		}
+		if !countOnly {
+			result.SpotsByFloor = spotsByFloor
+			for _, spot := range spots {
+				result.Locations = append(result.Locations,
+					api.LocatedSpotResult{SpotID: spot.GetSpotID(), Location: convertSpotLocation(spot)})
+			}
+		}
+
+		return r.out.JSON("available", result, nil)
+	}
+
+	tagList := strings.Join(tags, ", ")
+	if countOnly {
+		r.printResult("%d", count)
+		r.out.Printf("Available spots for %s tagged %s: %d\n", model.GetVehicleTypeDisplay(vehicleType), tagList, count)
+		printAccessibleShare(r.out, accessible)
+		return nil
+	}
+	if count == 0 {
+		r.out.Printf("No available spots for vehicle type %s tagged %s\n", vehicleType, tagList)
+		return nil
+	}
+
+	r.out.Printf("Available spots for %s tagged %s:\n", model.GetVehicleTypeDisplay(vehicleType), tagList)
+
+	floorNumbers := make([]int, 0, len(spotsByFloor))
+	for n := range spotsByFloor {
+		floorNumbers = append(floorNumbers, n)
+	}
+	sort.Ints(floorNumbers)
+	for _, n := range floorNumbers {
+		r.out.Printf("Floor %s (%d available):\n", model.FormatFloorNumber(n), len(spotsByFloor[n]))
+		r.out.Println(formatSpotIDTable(spotsByFloor[n]))
+	}
+
+	if limit > 0 && count == limit {
+		r.out.Printf("Showing the first %d available spots\n", count)
+	} else {
+		r.out.Printf("Total available: %d\n", count)
+	}
+	printAccessibleShare(r.out, accessible)
+
+	return nil
+}
+
+// printNearestSpots prints the available spots closest to the entrance
+func (r *CommandRegistry) printNearestSpots(vehicleType model.VehicleType, n int) error {
+	spots, err := r.parkingLot.NearestAvailableSpots(vehicleType, n)
+	if err != nil {
+		return fmt.Errorf("failed to get nearest spots: %w", err)
+	}
+
+	entrance := r.parkingLot.GetEntrance()
+	r.Logger.Debug("Found %d nearest spots for %s from entrance %s", len(spots), vehicleType,
+		r.parkingLot.FormatSpotID(entrance.Floor, entrance.Row, entrance.Column))
+
+	if r.Options.Format == OutputFormatJSON {
+		result := api.AvailableResult{
+			VehicleType:       string(vehicleType),
+			Nearest:           make([]api.NearestSpotResult, 0, len(spots)),
+			Count:             len(spots),
+			AvailableCapacity: r.parkingLot.GetAvailableCapacityByType()[vehicleType],
+		}
+		for _, spot := range spots {
+			location, err := spotLocation(r.parkingLot, spot.SpotID)
+			if err != nil {
+				return err
+			}
+			result.Nearest = append(result.Nearest, api.NearestSpotResult{SpotID: spot.SpotID, Location: location, Distance: spot.Distance})
+		}
+
+		return r.out.JSON("available", result, nil)
+	}
+
+	if len(spots) == 0 {
+		r.out.Printf("No available spots for vehicle type %s\n", vehicleType)
+		return nil
+	}
+
+	r.out.Printf("Nearest spots for %s from the entrance at %s:\n", model.GetVehicleTypeDisplay(vehicleType),
+		r.parkingLot.FormatSpotID(entrance.Floor, entrance.Row, entrance.Column))
+
+	rows := make([][]string, 0, len(spots))
+	for _, spot := range spots {
+		rows = append(rows, []string{spot.SpotID, fmt.Sprintf("%d", spot.Distance)})
+	}
+	r.out.Println(FormatTable([]string{"Spot ID", "Distance"}, rows))
+
+	return nil
+}
+
+// handleSetEntrance handles the set-entrance command
+func (r *CommandRegistry) handleSetEntrance(args []string) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	values := make([]int, len(args))
+	for i, arg := range args {
+		value, err := strconv.Atoi(arg)
+		if err != nil {
+			return newUsageError("invalid value: %s", arg)
+		}
+		values[i] = value
+	}
+
+	r.Logger.Debug("Moving entrance to floor %d, row %d, column %d", values[0], values[1], values[2])
+
+	if err := r.parkingLot.SetEntrance(values[0], values[1], values[2]); err != nil {
+		return fmt.Errorf("failed to set entrance: %w", err)
+	}
+
+	spotID := r.parkingLot.FormatSpotID(values[0], values[1], values[2])
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("set-entrance", api.EntranceResult{Floor: values[0], Row: values[1], Column: values[2], SpotID: spotID}, nil)
+	}
+
+	r.out.Success("Entrance set to %s", spotID)
+
+	return nil
+}
+
+// printAvailableCount prints the number of available spots without listing them
+func (r *CommandRegistry) printAvailableCount(vehicleType model.VehicleType, floor *model.ParkingFloor) error {
+	var count, capacity int
+	if floor != nil {
+		count = floor.CountAvailableSpots(vehicleType)
+		capacity = floor.GetAvailableCapacityByType()[vehicleType]
+	} else {
+		var err error
+		count, err = r.parkingLot.CountAvailableSpots(vehicleType)
+		if err != nil {
+			return fmt.Errorf("failed to count available spots: %w", err)
+		}
+		capacity = r.parkingLot.GetAvailableCapacityByType()[vehicleType]
+	}
+
+	accessible := len(r.accessibleSpotIDs(vehicleType, floor))
+
+	r.Logger.Debug("Counted %d available spots for %s", count, vehicleType)
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("available", api.AvailableResult{
+			VehicleType:       string(vehicleType),
+			Count:             count,
+			AvailableCapacity: capacity,
+			Accessible:        accessible,
+		}, nil)
+	}
+
+	r.printResult("%d", count)
+	r.out.Printf("Available spots for %s: %d\n", model.GetVehicleTypeDisplay(vehicleType), count)
+	printAccessibleShare(r.out, accessible)
+
+	return nil
+}
+
+// formatSpotIDTable lays out spot IDs in a table with a fixed number of columns
+func formatSpotIDTable(spots []string) string {
+	const maxColsPerRow = 5
+	rows := [][]string{}
+	currentRow := []string{}
+
+	for _, spotID := range spots {
+		currentRow = append(currentRow, spotID)
+
+		if len(currentRow) == maxColsPerRow {
+			rows = append(rows, currentRow)
+			currentRow = []string{}
+		}
+	}
+
+	// Add the last partial row if any
+	if len(currentRow) > 0 {
+		// Pad with empty cells
+		for len(currentRow) < maxColsPerRow {
+			currentRow = append(currentRow, "")
+		}
+		rows = append(rows, currentRow)
+	}
+
+	// Create headers
+	headers := make([]string, maxColsPerRow)
+	for i := 0; i < maxColsPerRow; i++ {
+		headers[i] = fmt.Sprintf("Spot %d", i+1)
+	}
+
+	return FormatTable(headers, rows)
+}
+
+// occupancyBarWidth is the width of the utilization bars printed by occupancy
+const occupancyBarWidth = 20
+
+// handleOccupancy handles the occupancy command
+func (r *CommandRegistry) handleOccupancy(args []string) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	report := r.parkingLot.GetOccupancyReport()
+	r.Logger.Debug("Overall utilization: %d of %d active spots", report.Overall.Occupied, report.Overall.Active)
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("occupancy", convertOccupancyReport(report), nil)
+	}
+
+	r.out.Info("Overall utilization: %.1f%% %s (%d of %d active spots)", report.Overall.Percent(),
+		FormatBar(report.Overall.Percent(), occupancyBarWidth), report.Overall.Occupied, report.Overall.Active)
+
+	r.out.Println("\nBy floor:")
+	rows := make([][]string, 0, len(report.Floors))
+	for _, floor := range report.Floors {
+		label := "Floor " + model.FormatFloorNumber(floor.FloorNumber)
+		if floor.Closed {
+			label += " (closed)"
+		}
+		rows = append(rows, formatUtilizationRow(label, floor.Utilization))
+	}
+	r.out.Println(FormatTable(append([]string{"Floor"}, utilizationHeaders...), rows))
+
+	r.out.Println("By spot type:")
+	rows = make([][]string, 0, len(report.ByType))
+	for _, info := range model.RegisteredSpotTypes() {
+		if info.Type == model.SpotTypeInactive {
+			continue
+		}
+		rows = append(rows, formatUtilizationRow(info.Display, report.ByType[info.Type]))
+	}
+	r.out.Println(FormatTable(append([]string{"Spot Type"}, utilizationHeaders...), rows))
+
+	return nil
+}
+
+// utilizationHeaders are the table headers following the label of a utilization row
+var utilizationHeaders = []string{"Occupied", "Active", "Utilization", "Usage"}
+
+// formatUtilizationRow renders one labelled utilization as a table row
+func formatUtilizationRow(label string, u model.Utilization) []string {
+	return []string{
+		label,
+		fmt.Sprintf("%d", u.Occupied),
+		fmt.Sprintf("%d", u.Active),
+		fmt.Sprintf("%.1f%%", u.Percent()),
+		FormatBar(u.Percent(), occupancyBarWidth),
+	}
+}
+
+// handleLongest handles the longest command
+func (r *CommandRegistry) handleLongest(args []string, typeFlag optionalString) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	n := 10
+	if len(args) > 0 {
+		count, err := strconv.Atoi(args[0])
+		if err != nil || count < 1 {
+			return newUsageError("invalid count: %s\nUsage: %s", args[0], r.Commands["longest"].UsageText())
+		}
+		n = count
+	}
+	var vehicleType model.VehicleType
+	if typeFlag.set {
+		vt, err := model.ParseVehicleType(typeFlag.value)
+		if err != nil {
+			return fmt.Errorf("invalid vehicle type: %w", err)
+		}
+		vehicleType = vt
+	}
+
+	vehicles, err := r.parkingLot.LongestParked(vehicleType, n)
+	if err != nil {
+		return fmt.Errorf("failed to get longest parked vehicles: %w", err)
+	}
+
+	// Measure every duration against the same instant
+	now := r.now()
+	r.Logger.Debug("Found %d longest parked vehicles", len(vehicles))
+
+	if r.Options.Format == OutputFormatJSON {
+		results := make([]api.LongestParkedResult, 0, len(vehicles))
+		for _, vehicle := range vehicles {
+			floor, _, _, _ := r.parkingLot.ParseSpotID(vehicle.SpotID)
+			results = append(results, api.LongestParkedResult{
+				VehicleResult: api.VehicleResult{
+					VehicleNumber: vehicle.VehicleNumber,
+					VehicleType:   string(vehicle.VehicleType),
+					SpotID:        vehicle.SpotID,
+					ParkedAt:      jsonTime(vehicle.ParkedAt),
+				},
+				Floor:           floor,
+				DurationSeconds: vehicle.DurationAt(now).Seconds(),
+			})
+		}
+
+		return r.out.JSON("longest", results, nil)
+	}
+
+	if len(vehicles) == 0 {
+		r.out.Println("No vehicles currently parked")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(vehicles))
+	for i, vehicle := range vehicles {
+		floor, _, _, _ := r.parkingLot.ParseSpotID(vehicle.SpotID)
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", i+1),
+			vehicle.VehicleNumber,
+			model.GetVehicleTypeDisplay(vehicle.VehicleType),
+			vehicle.SpotID,
+			model.FormatFloorNumber(floor),
+			r.formatTime(vehicle.ParkedAt),
+			FormatDuration(vehicle.DurationAt(now), DurationCompact),
+		})
+	}
+
+	r.out.Println("Longest parked vehicles:")
+	r.out.Println(FormatTable([]string{"#", "Vehicle Number", "Type", "Spot ID", "Floor", "Parked Since", "Duration"}, rows))
+
+	return nil
+}
+
+// handleTimeseries handles the timeseries command
+func (r *CommandRegistry) handleTimeseries(args []string, capacityFlag optionalInt) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	usage := "Usage: " + r.Commands["timeseries"].UsageText()
+
+	if capacityFlag.set && (len(args) == 0 || args[0] != "start") {
+		return newUsageError("--capacity only applies to timeseries start. %s", usage)
+	}
+	if len(args) == 0 {
+		return r.printOccupancySamples()
+	}
+
+	switch args[0] {
+	case "start":
+		if len(args) < 2 {
+			return newUsageError("missing sampling interval. %s", usage)
+		}
+
+		seconds, err := strconv.Atoi(args[1])
+		if err != nil || seconds < 1 {
+			return newUsageError("invalid interval value: %s (expected whole seconds)", args[1])
+		}
+
+		capacity := model.DefaultSampleCapacity
+		if capacityFlag.set {
+			if capacityFlag.value < 1 {
+				return newUsageError("invalid capacity value: %d", capacityFlag.value)
+			}
+			capacity = capacityFlag.value
+		}
+
+		interval := time.Duration(seconds) * time.Second
+		if err := r.parkingLot.StartSampling(interval, capacity); err != nil {
+			return fmt.Errorf("failed to start sampling: %w", err)
+		}
+
+		r.Logger.Debug("Sampling occupancy every %s, keeping %d samples", interval, capacity)
+		r.out.Success("Sampling occupancy every %s, keeping the last %d samples", interval, capacity)
+		return nil
+
+	case "stop":
+		if len(args) > 1 {
+			return newUsageError("too many arguments for timeseries stop. %s", usage)
+		}
+		if err := r.parkingLot.StopSampling(); err != nil {
+			return fmt.Errorf("failed to stop sampling: %w", err)
+		}
+
+		r.out.Success("Stopped sampling occupancy")
+		return nil
+
+	default:
+		return newUsageError("unknown timeseries action: %s. %s", args[0], usage)
+	}
+}
+
+// printOccupancySamples prints the occupancy samples taken so far
+func (r *CommandRegistry) printOccupancySamples() error {
+	samples := r.parkingLot.GetOccupancySamples()
+	r.Logger.Debug("Found %d occupancy samples", len(samples))
+
+	if r.Options.Format == OutputFormatJSON {
+		results := make([]api.OccupancySampleResult, 0, len(samples))
+		for _, sample := range samples {
+			results = append(results, api.OccupancySampleResult{
+				At:     jsonTime(sample.At),
+				Total:  sample.Total,
+				ByType: convertVehicleTypeMap(sample.ByType),
+			})
+		}
+
+		return r.out.JSON("timeseries", results, nil)
+	}
+
+	if len(samples) == 0 {
+		if r.parkingLot.IsSampling() {
+			r.out.Println("No occupancy samples yet")
+		} else {
+			r.out.Println("No occupancy samples, use 'timeseries start <interval_seconds>' to start sampling")
+		}
+		return nil
+	}
+
+	vehicleTypes := singleSpotVehicleTypes()
+	headers := []string{"Time", "Total"}
+	for _, info := range vehicleTypes {
+		headers = append(headers, info.Display)
+	}
+
+	rows := make([][]string, 0, len(samples))
+	for _, sample := range samples {
+		row := []string{
+			r.formatTime(sample.At),
+			fmt.Sprintf("%d", sample.Total),
+		}
+		for _, info := range vehicleTypes {
+			row = append(row, fmt.Sprintf("%d", sample.ByType[info.Type]))
+		}
+		rows = append(rows, row)
+	}
+
+	r.out.Printf("Occupancy samples: %d\n", len(samples))
+	r.out.Println(FormatTable(headers, rows))
+
+	return nil
+}
+
+// vehiclesFlags holds the flags of the vehicles command
+type vehiclesFlags struct {
+	vehicleType optionalString
+	floor       optionalInt
+}
+
+// define binds the flags to fs, resetting them to their defaults
+func (f *vehiclesFlags) define(fs *flag.FlagSet) {
+	*f = vehiclesFlags{}
+	fs.Var(&f.vehicleType, "type", "list only vehicles of the `vehicle_type`")
+	fs.Var(&f.floor, "floor", "list only vehicles parked on floor `n`")
+}
+
+// handleVehicles handles the vehicles command
+func (r *CommandRegistry) handleVehicles(flags vehiclesFlags) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	var filter model.VehicleFilter
+	if flags.vehicleType.set {
+		vehicleType, err := model.ParseVehicleType(flags.vehicleType.value)
+		if err != nil {
+			return fmt.Errorf("invalid vehicle type: %w", err)
+		}
+		filter.VehicleType = vehicleType
+	}
+	if flags.floor.set {
+		floorNum := flags.floor.value
+		if _, err := r.parkingLot.GetFloor(floorNum); err != nil {
+			return fmt.Errorf("failed to get floor: %w", err)
+		}
+		filter.Floor = &floorNum
+	}
+
+	vehicles := r.parkingLot.ListParkedVehicles(filter)
+	r.Logger.Debug("Found %d parked vehicles matching filter", len(vehicles))
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("vehicles", convertParkedVehicles(vehicles), nil)
+	}
+
+	if len(vehicles) == 0 {
+		r.out.Println("No parked vehicles match the filter")
+		return nil
+	}
+
+	now := r.now()
+	rows := make([][]string, 0, len(vehicles))
+	for _, vehicle := range vehicles {
+		rows = append(rows, []string{
+			vehicle.VehicleNumber,
+			model.GetVehicleTypeDisplay(vehicle.VehicleType),
+			vehicle.SpotID,
+			r.formatTime(vehicle.ParkedAt),
+			FormatDuration(vehicle.DurationAt(now), DurationCompact),
+		})
+	}
+
+	r.out.Printf("Parked vehicles: %d\n", len(vehicles))
+	r.out.Println(FormatTable([]string{"Vehicle Number", "Type", "Spot ID", "Parked Since", "Duration"}, rows))
+
+	return nil
+}
+
+// defaultSpotsLimit caps how many spots the spots command lists at once
+const defaultSpotsLimit = 100
+
+// spotsFlags holds the flags of the spots command
+type spotsFlags struct {
+	floor    optionalInt
+	spotType optionalString
+	occupied bool
+	free     bool
+	rows     optionalString
+	columns  optionalString
+	tag      stringList
+	limit    optionalInt
+	offset   optionalInt
+	ndjson   bool
+}
+
+// define binds the flags to fs, resetting them to their defaults
+func (f *spotsFlags) define(fs *flag.FlagSet) {
+	*f = spotsFlags{}
+	fs.Var(&f.floor, "floor", "list only the spots on floor `n`")
+	fs.Var(&f.spotType, "type", "list only spots of the `spot_type`")
+	fs.BoolVar(&f.occupied, "occupied", false, "list only occupied spots")
+	fs.BoolVar(&f.free, "free", false, "list only free spots")
+	fs.Var(&f.rows, "rows", "list only spots in the rows `a-b`")
+	fs.Var(&f.columns, "columns", "list only spots in the columns `a-b`")
+	fs.Var(&f.tag, "tag", "list only spots carrying the `tag`; may be given more than once")
+	fs.Var(&f.limit, "limit", fmt.Sprintf("list at most `n` spots (default %d)", defaultSpotsLimit))
+	fs.Var(&f.offset, "offset", "skip the first `n` matching spots")
+	fs.BoolVar(&f.ndjson, "ndjson", false, "stream the spots as JSON, one object a line")
+}
+
+// handleSpots handles the spots command
+func (r *CommandRegistry) handleSpots(flags spotsFlags) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	var filter model.SpotFilter
+	if flags.occupied && flags.free {
+		return newUsageError("--occupied and --free cannot be combined")
+	}
+	if flags.occupied || flags.free {
+		occupied := flags.occupied
+		filter.Occupied = &occupied
+	}
+	if flags.floor.set {
+		floorNum := flags.floor.value
+		if _, err := r.parkingLot.GetFloor(floorNum); err != nil {
+			return fmt.Errorf("failed to get floor: %w", err)
+		}
+		filter.Floor = &floorNum
+	}
+	if flags.spotType.set {
+		spotType, err := model.ParseSpotType(flags.spotType.value)
+		if err != nil {
+			return fmt.Errorf("invalid spot type: %w", err)
+		}
+		filter.Type = spotType
+	}
+	for _, value := range flags.tag {
+		tag, err := model.NormalizeSpotTag(value)
+		if err != nil {
+			return fmt.Errorf("invalid tag: %w", err)
+		}
+		filter.Tags = append(filter.Tags, tag)
+	}
+	if flags.rows.set {
+		rng, err := parseIntRange(flags.rows.value)
+		if err != nil {
+			return newUsageError("invalid rows value: %v", err)
+		}
+		filter.Rows = &rng
+	}
+	if flags.columns.set {
+		rng, err := parseIntRange(flags.columns.value)
+		if err != nil {
+			return newUsageError("invalid columns value: %v", err)
+		}
+		filter.Columns = &rng
+	}
+
+	limit := defaultSpotsLimit
+	if flags.limit.set {
+		if flags.limit.value < 1 {
+			return newUsageError("invalid limit value: %d", flags.limit.value)
+		}
+		limit = flags.limit.value
+	}
+	offset := 0
+	if flags.offset.set {
+		if flags.offset.value < 0 {
+			return newUsageError("invalid offset value: %d", flags.offset.value)
+		}
+		offset = flags.offset.value
+	}
+
+	// A stream has no pages, so it runs to the end unless limited
+	if flags.ndjson {
+		if !flags.limit.set {
+			limit = 0
+		}
+		return r.streamSpots(filter, offset, limit)
+	}
+
+	spots := r.parkingLot.ListSpots(filter)
+	total := len(spots)
+	r.Logger.Debug("Found %d spots matching filter", total)
+
+	// Page through large results
+	end := offset + limit
+	if offset > total {
+		offset = total
+	}
+	if end > total {
+		end = total
+	}
+	page := spots[offset:end]
+
+	if r.Options.Format == OutputFormatJSON {
+		result := api.SpotsResult{
+			Spots:  make([]api.SpotInfoResult, 0, len(page)),
+			Total:  total,
+			Offset: offset,
+			Limit:  limit,
+		}
+		for _, spot := range page {
+			result.Spots = append(result.Spots, convertSpotInfo(spot))
+		}
+
+		return r.out.JSON("spots", result, nil)
+	}
+
+	if total == 0 {
+		r.out.Println("No spots match the filter")
+		return nil
+	}
+
+	// Labels and tags get a column only when some spot on the page has them
+	labelled, tagged := false, false
+	for _, spot := range page {
+		labelled = labelled || spot.Label != ""
+		tagged = tagged || len(spot.Tags) > 0
+	}
+
+	rows := make([][]string, 0, len(page))
+	for _, spot := range page {
+		occupied, vehicles, since := "No", "-", "-"
+		if spot.IsOccupied() {
+			occupied = "Yes"
+			vehicles = strings.Join(spot.Vehicles, ", ")
+			since = r.formatTime(spot.OccupiedSince)
+		}
+		row := []string{spot.SpotID}
+		if labelled {
+			label := spot.Label
+			if label == "" {
+				label = "-"
+			}
+			row = append(row, label)
+		}
+		if tagged {
+			tags := strings.Join(spot.Tags, ", ")
+			if tags == "" {
+				tags = "-"
+			}
+			row = append(row, tags)
+		}
+		rows = append(rows, append(row, string(spot.Type), occupied, vehicles, since))
+	}
+
+	headers := []string{"Spot ID"}
+	if labelled {
+		headers = append(headers, "Label")
+	}
+	if tagged {
+		headers = append(headers, "Tags")
+	}
+	headers = append(headers, "Type", "Occupied", "Vehicle", "Occupied Since")
+	r.out.Println(FormatTable(headers, rows))
+	if len(page) > 0 && len(page) < total {
+		r.out.Printf("Showing spots %d-%d of %d", offset+1, end, total)
+		if end < total {
+			r.out.Printf(", use --offset %d for more", end)
+		}
+		r.out.Println()
+	}
+
+	return nil
+}
+
+// parseIntRange parses an inclusive range like "2-5", or a single number
+func parseIntRange(s string) (model.IntRange, error) {
+	minStr, maxStr, found := strings.Cut(s, "-")
+	if !found {
+		maxStr = minStr
+	}
+
+	minValue, err := strconv.Atoi(minStr)
+	if err != nil {
+		return model.IntRange{}, fmt.Errorf("expected a number or a range like 2-5, got %s", s)
+	}
+	maxValue, err := strconv.Atoi(maxStr)
+	if err != nil {
+		return model.IntRange{}, fmt.Errorf("expected a number or a range like 2-5, got %s", s)
+	}
+	if minValue > maxValue {
+		return model.IntRange{}, fmt.Errorf("range start %d is after its end %d", minValue, maxValue)
+	}
+
+	return model.IntRange{Min: minValue, Max: maxValue}, nil
+}
+
+// handleHistory handles the history command
+func (r *CommandRegistry) handleHistory(args []string) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	vehicleNumber := args[0]
+	r.Logger.Debug("Getting parking history for vehicle: %s", vehicleNumber)
+
+	records, err := r.parkingLot.GetVehicleRecords(vehicleNumber)
+	if err != nil {
+		return err
+	}
+
+	r.Logger.Debug("Found %d parking records", len(records))
+	now := r.now()
+
+	if r.Options.Format == OutputFormatJSON {
+		results := make([]api.ParkingRecordResult, 0, len(records))
+		for _, record := range records {
+			results = append(results, convertParkingRecord(record, now))
+		}
+
+		return r.out.JSON("history", results, nil)
+	}
+
+	r.out.Printf("Parking history for %s:\n", model.NormalizeVehicleNumber(vehicleNumber))
+	r.out.Println(formatParkingRecordsTable(records, now, r.location()))
+
+	return nil
+}
+
+// handlePruneHistory handles the prune-history command
+func (r *CommandRegistry) handlePruneHistory(args []string) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	age, err := parseAge(args[0])
+	if err != nil {
+		return err
+	}
+
+	olderThan := r.now().Add(-age)
+	r.Logger.Debug("Pruning parking records that ended before %s", r.formatTime(olderThan))
+
+	removed := r.parkingLot.PruneHistory(olderThan)
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("prune-history", api.PruneHistoryResult{
+			Removed:   removed,
+			OlderThan: jsonTime(olderThan),
+		}, nil)
+	}
+
+	r.out.Success("Removed %d parking records that ended before %s",
+		removed, r.formatTime(olderThan))
+
+	return nil
+}
+
+// parseAge parses a positive age like "30d", "12h" or "90m"
+func parseAge(s string) (time.Duration, error) {
+	age, err := parseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age: %s (expected e.g. 30d, 12h or 90m)", s)
+	}
+
+	if age <= 0 {
+		return 0, fmt.Errorf("invalid age: %s (must be positive)", s)
+	}
+
+	return age, nil
+}
+
+// parseDuration parses a Go duration, also accepting whole days like "2d" or "-1d"
+func parseDuration(s string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(s, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(s)
+}
+
+// parseTime parses an RFC3339 time, "now", or a duration relative to now like "-2h"
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if s == "now" {
+		return now, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	d, err := parseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time: %s (expected RFC3339, now, or a relative duration like -2h)", s)
+	}
+
+	return now.Add(d), nil
+}
+
+// handleHistoryRange handles the history-range command
+func (r *CommandRegistry) handleHistoryRange(args []string, ndjson bool) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	now := r.now()
+	from, err := parseTime(args[0], now)
+	if err != nil {
+		return err
+	}
+	to, err := parseTime(args[1], now)
+	if err != nil {
+		return err
+	}
+	if from.After(to) {
+		return fmt.Errorf("invalid range: %s is after %s", r.formatTime(from), r.formatTime(to))
+	}
+
+	r.Logger.Debug("Querying history from %s to %s", r.formatTime(from), r.formatTime(to))
+
+	if ndjson {
+		return r.streamHistoryRange(from, to)
+	}
+
+	entries := r.parkingLot.QueryHistory(from, to)
+
+	if r.Options.Format == OutputFormatJSON {
+		results := make([]api.HistoryEntryResult, 0, len(entries))
+		for _, entry := range entries {
+			results = append(results, api.HistoryEntryResult{
+				VehicleNumber:       entry.VehicleNumber,
+				VehicleType:         string(entry.VehicleType),
+				ParkingRecordResult: convertParkingRecord(entry.ParkingRecord, now),
+			})
+		}
+
+		return r.out.JSON("history-range", results, nil)
+	}
+
+	if len(entries) == 0 {
+		r.out.Printf("No vehicles parked between %s and %s\n",
+			r.formatTime(from), r.formatTime(to))
+		return nil
+	}
+
+	rows := make([][]string, 0, len(entries))
+	for _, entry := range entries {
+		unparkedAt := "Still Parked"
+		if entry.IsComplete() {
+			unparkedAt = r.formatTime(*entry.UnparkedAt)
+		}
+
+		rows = append(rows, []string{
+			entry.VehicleNumber,
+			model.GetVehicleTypeDisplay(entry.VehicleType),
+			entry.SpotID,
+			r.formatTime(entry.ParkedAt),
+			unparkedAt,
+			FormatDuration(entry.DurationAt(now), DurationCompact),
+		})
+	}
+
+	r.out.Printf("Vehicles parked between %s and %s: %d\n",
+		r.formatTime(from), r.formatTime(to), len(entries))
+	r.out.Println(FormatTable([]string{"Vehicle Number", "Type", "Spot ID", "Parked At", "Unparked At", "Duration"}, rows))
+
+	return nil
+}
+
+// handleSpotHistory handles the spot-history command
+func (r *CommandRegistry) handleSpotHistory(args []string, limitFlag optionalInt) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	spotID, err := r.resolveSpotID(args[0])
+	if err != nil {
+		return err
+	}
+	limit := 0
+	if limitFlag.set {
+		if limitFlag.value < 1 {
+			return newUsageError("invalid limit value: %d", limitFlag.value)
+		}
+		limit = limitFlag.value
+	}
+
+	r.Logger.Debug("Getting occupancy history for spot: %s", spotID)
+
+	occupancies, err := r.parkingLot.GetSpotHistory(spotID, limit)
+	if err != nil {
+		return fmt.Errorf("failed to get spot history: %w", err)
+	}
+	now := r.now()
+
+	if r.Options.Format == OutputFormatJSON {
+		result := api.SpotHistoryResult{
+			SpotID:      spotID,
+			Occupancies: make([]api.SpotOccupancyResult, 0, len(occupancies)),
+		}
+		for _, occupancy := range occupancies {
+			result.Occupancies = append(result.Occupancies, convertSpotOccupancy(occupancy, now))
+		}
+
+		return r.out.JSON("spot-history", result, nil)
+	}
+
+	if len(occupancies) == 0 {
+		r.out.Printf("No recorded occupancies for spot %s\n", spotID)
+		return nil
+	}
+
+	rows := make([][]string, 0, len(occupancies))
+	for _, occupancy := range occupancies {
+		vacatedAt := "Still Parked"
+		end := now
+		if occupancy.VacatedAt != nil {
+			vacatedAt = r.formatTime(*occupancy.VacatedAt)
+			end = *occupancy.VacatedAt
+		}
+
+		rows = append(rows, []string{
+			occupancy.VehicleNumber,
+			r.formatTime(occupancy.ParkedAt),
+			vacatedAt,
+			FormatDuration(end.Sub(occupancy.ParkedAt), DurationCompact),
+		})
+	}
+
+	r.out.Printf("Recent occupancies of spot %s, most recent first (up to %d kept):\n",
+		spotID, r.parkingLot.GetSpotHistoryLimit())
+	r.out.Println(FormatTable([]string{"Vehicle Number", "Parked At", "Vacated At", "Duration"}, rows))
+
+	return nil
+}
+
+// formatParkingRecordsTable renders parking records, numbered oldest first,
+// with times in the given zone
+func formatParkingRecordsTable(records []model.ParkingRecord, now time.Time, location *time.Location) string {
+	rows := make([][]string, 0, len(records))
+	for i, record := range records {
+		status, unparkedAt := "Active", "Still Parked"
+		if record.IsComplete() {
+			status = "Completed"
+			unparkedAt = formatTimestamp(*record.UnparkedAt, location)
+		}
+
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", i+1),
+			record.SpotID,
+			formatTimestamp(record.ParkedAt, location),
+			unparkedAt,
+			FormatDuration(record.DurationAt(now), DurationCompact),
+			status,
+		})
+	}
+
+	return FormatTable([]string{"#", "Spot ID", "Parked At", "Unparked At", "Duration", "Status"}, rows)
+}
+
+// searchFlags holds the flags of the search command
+type searchFlags struct {
+	allLots bool
+	like    optionalString
+	limit   optionalInt
+}
+
+// define binds the flags to fs, resetting them to their defaults
+func (f *searchFlags) define(fs *flag.FlagSet) {
+	*f = searchFlags{}
+	fs.BoolVar(&f.allLots, "all-lots", false, "search every lot of the session")
+	fs.Var(&f.like, "like", "list every vehicle whose number matches the `pattern`")
+	fs.Var(&f.limit, "limit", fmt.Sprintf("with --like, list at most `n` vehicles (default %d)", defaultSearchMatchLimit))
+}
+
+// handleSearch handles the search command
+func (r *CommandRegistry) handleSearch(args []string, flags searchFlags) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	usage := "Usage: " + r.Commands["search"].UsageText()
+	if flags.like.set {
+		if len(args) > 0 || flags.allLots {
+			return newUsageError("--like takes the place of the vehicle number and --all-lots\n%s", usage)
+		}
+		return r.handleSearchLike(flags.like.value, flags.limit)
+	}
+	if flags.limit.set {
+		return newUsageError("--limit only applies to --like\n%s", usage)
+	}
+	if len(args) == 0 {
+		return newUsageError("missing vehicle number\n%s", usage)
+	}
+	if flags.allLots {
+		return r.handleSearchAllLots(args[0])
+	}
+
+	// Parse arguments
+	vehicleNumber := args[0]
+
+	r.Logger.Debug("Searching for vehicle with number: %s", vehicleNumber)
+
+	// Search for the vehicle
+	info, err := r.parkingLot.SearchVehicleDetailed(vehicleNumber)
+
+	// Special case for "not found" errors, which still carry what the
+	// vehicle's history knows
+	var notFoundErr *perrors.VehicleNotFoundError
+	if errors.As(err, &notFoundErr) {
+		r.Logger.Debug("Vehicle %s not found in the parking lot", vehicleNumber)
+
+		if r.Options.Format == OutputFormatJSON {
+			// Use nil for error to indicate "not found" is not really an error in this context
+			result, convertErr := convertSearchInfo(r.parkingLot, vehicleNumber, info)
+			if convertErr != nil {
+				return convertErr
+			}
+			if jsonErr := r.out.JSON("search", result, nil); jsonErr != nil {
+				return jsonErr
+			}
+		} else if info != nil && info.VehicleType != "" {
+			r.out.Warning("Vehicle %s (%s) is not parked and has no recorded spot",
+				vehicleNumber, model.GetVehicleTypeDisplay(info.VehicleType))
+		} else {
+			r.out.Warning("Vehicle %s not found in the parking lot", vehicleNumber)
+		}
+		return notFoundErr
+	}
+
+	// Handle other errors
+	if err != nil {
+		r.Logger.Debug("Error searching for vehicle: %v", err)
+		return fmt.Errorf("failed to search for vehicle: %w", err)
+	}
+
+	r.Logger.Debug("Vehicle found: spotID=%s, isParked=%v", info.SpotID, info.IsParked)
+
+	if r.Options.Format == OutputFormatJSON {
+		// Output as JSON
+		result, err := convertSearchInfo(r.parkingLot, vehicleNumber, info)
+		if err != nil {
+			return err
+		}
+		return r.out.JSON("search", result, nil)
+	}
+
+	// Output as text
+	if info.IsParked {
+		r.printResult("%s", info.SpotID)
+		r.out.Success("Vehicle %s is currently parked at spot %s since %s",
+			vehicleNumber, describeSpot(info.SpotID, info.SpotLabel),
+			r.formatTime(info.ParkedAt))
+	} else if !info.LastSeenAt.IsZero() {
+		r.out.Info("Vehicle %s is not currently parked, but was last seen at spot %s at %s",
+			vehicleNumber, describeSpot(info.SpotID, info.SpotLabel),
+			r.formatTime(info.LastSeenAt))
+	} else {
+		r.out.Info("Vehicle %s is not currently parked, but was last seen at spot %s",
+			vehicleNumber, describeSpot(info.SpotID, info.SpotLabel))
+	}
+
+	// If verbose, try to get more information about the vehicle's history
+	if r.Options.Verbose {
+		if records, err := r.parkingLot.GetVehicleRecords(vehicleNumber); err == nil {
+			r.out.Println("\nParking History:")
+			r.out.Println(formatParkingRecordsTable(records, r.now(), r.location()))
+		}
 	}
 
 	return nil
 }
 
-// handleInit handles the init command
-func (r *CommandRegistry) handleInit(args []string) error {
-	r.Logger.Debug("Initializing parking lot with args: %v", args)
+// lotSearchInfo is what one lot knows of a vehicle searched across lots
+type lotSearchInfo struct {
+	lot  string
+	info *model.SearchInfo
+}
 
-	// Parse arguments
-	floors, err := strconv.Atoi(args[0])
-	if err != nil {
-		return fmt.Errorf("invalid floors value: %s", args[0])
+// searchAllLots searches every lot for the vehicle, in lot name order,
+// returning the lots that have it parked or have seen it before
+func (r *CommandRegistry) searchAllLots(vehicleNumber string) ([]lotSearchInfo, error) {
+	var found []lotSearchInfo
+	for _, name := range r.lotNames() {
+		info, err := r.lots[name].SearchVehicleDetailed(vehicleNumber)
+		var notFoundErr *perrors.VehicleNotFoundError
+		if errors.As(err, &notFoundErr) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to search lot %s for vehicle: %w", name, err)
+		}
+		found = append(found, lotSearchInfo{lot: name, info: info})
 	}
+	return found, nil
+}
 
-	rows, err := strconv.Atoi(args[1])
+// handleSearchAllLots reports every lot that has the vehicle parked or has
+// seen it before
+func (r *CommandRegistry) handleSearchAllLots(vehicleNumber string) error {
+	found, err := r.searchAllLots(vehicleNumber)
 	if err != nil {
-		return fmt.Errorf("invalid rows value: %s", args[1])
+		return err
 	}
 
-	columns, err := strconv.Atoi(args[2])
-	if err != nil {
-		return fmt.Errorf("invalid columns value: %s", args[2])
+	if r.Options.Format == OutputFormatJSON {
+		results := make([]api.LotSearchResult, 0, len(found))
+		for _, f := range found {
+			result, err := convertSearchInfo(r.lots[f.lot], vehicleNumber, f.info)
+			if err != nil {
+				return err
+			}
+			results = append(results, api.LotSearchResult{Lot: f.lot, SearchResult: result})
+		}
+		if err := r.out.JSON("search", results, nil); err != nil {
+			return err
+		}
+	} else if len(found) == 0 {
+		r.out.Warning("Vehicle %s not found in any lot", vehicleNumber)
+	} else {
+		for _, f := range found {
+			if f.info.IsParked {
+				r.out.Success("Lot %s: vehicle %s is currently parked at spot %s since %s", f.lot,
+					vehicleNumber, describeSpot(f.info.SpotID, f.info.SpotLabel),
+					r.formatTime(f.info.ParkedAt))
+			} else {
+				r.out.Info("Lot %s: vehicle %s is not currently parked, but was last seen at spot %s", f.lot,
+					vehicleNumber, describeSpot(f.info.SpotID, f.info.SpotLabel))
+			}
+		}
 	}
 
-	r.Logger.Debug("Creating parking lot with %d floors, %d rows, %d columns",
-		floors, rows, columns)
+	if len(found) == 0 {
+		return perrors.NewVehicleNotFoundError(vehicleNumber)
+	}
+	return nil
+}
 
-	// Create the parking lot
-	parkingLot, err := model.CreateParkingLot("Parking Lot", floors, rows, columns)
-	if err != nil {
-		return fmt.Errorf("failed to create parking lot: %v", err)
+// defaultSearchMatchLimit caps how many vehicles search --like lists
+const defaultSearchMatchLimit = 50
+
+// handleSearchLike lists every vehicle, parked or seen before, whose number
+// matches a pattern
+func (r *CommandRegistry) handleSearchLike(pattern string, limitFlag optionalInt) error {
+	limit := defaultSearchMatchLimit
+	if limitFlag.set {
+		if limitFlag.value < 1 {
+			return newUsageError("invalid limit value: %d", limitFlag.value)
+		}
+		limit = limitFlag.value
 	}
 
-	// Store the parking lot in the registry
-	r.SetParkingLot(parkingLot)
+	r.Logger.Debug("Searching for vehicles matching %q, up to %d", pattern, limit)
 
-	// Get counts by type
-	counts := parkingLot.GetSpotCountByType()
+	matches, truncated, err := r.parkingLot.FindVehiclesMatching(pattern, limit)
+	if err != nil {
+		return fmt.Errorf("failed to search for vehicles: %w", err)
+	}
+
+	r.Logger.Debug("Found %d vehicles matching %q", len(matches), pattern)
 
 	if r.Options.Format == OutputFormatJSON {
-		// Output as JSON
-		result := InitResult{
-			Floors:  floors,
-			Rows:    rows,
-			Columns: columns,
-			Total:   parkingLot.GetTotalSpotCount(),
-			Counts:  convertSpotTypeMap(counts),
+		result := api.SearchMatchesResult{
+			Pattern:   pattern,
+			Matches:   make([]api.SearchResult, 0, len(matches)),
+			Truncated: truncated,
+		}
+		for i := range matches {
+			match, err := convertSearchInfo(r.parkingLot, matches[i].VehicleNumber, &matches[i])
+			if err != nil {
+				return err
+			}
+			result.Matches = append(result.Matches, match)
 		}
 
-		PrintJSON("init", result, nil)
-	} else {
-		// Output as text
-		PrintSuccess("Created parking lot with %d floors, %d rows, and %d columns",
-			floors, rows, columns)
-		PrintInfo("Total spots: %d", parkingLot.GetTotalSpotCount())
+		return r.out.JSON("search", result, nil)
+	}
 
-		// Show counts by type in a table
-		tableRows := [][]string{
-			{"Bicycle", fmt.Sprintf("%d", counts[model.SpotTypeBicycle])},
-			{"Motorcycle", fmt.Sprintf("%d", counts[model.SpotTypeMotorcycle])},
-			{"Automobile", fmt.Sprintf("%d", counts[model.SpotTypeAutomobile])},
-			{"Inactive", fmt.Sprintf("%d", counts[model.SpotTypeInactive])},
-		}
+	if len(matches) == 0 {
+		r.out.Warning("No vehicles match %s", pattern)
+		return nil
+	}
 
-		fmt.Println("Spot types:")
-		fmt.Println(FormatTable([]string{"Type", "Count"}, tableRows))
+	r.out.Println(formatSearchMatchesTable(matches, r.location()))
+	if truncated {
+		r.out.Warning("Showing the first %d matches; narrow the pattern or raise --limit to see more", limit)
 	}
 
 	return nil
 }
 
-// handlePark handles the park command
-func (r *CommandRegistry) handlePark(args []string) error {
-	// Check if parking lot is initialized
-	if r.parkingLot == nil {
-		return fmt.Errorf("parking lot not initialized, use 'init' command first")
+// formatSearchMatchesTable renders the vehicles found by search --like, with
+// times in the given zone
+func formatSearchMatchesTable(matches []model.SearchInfo, location *time.Location) string {
+	rows := make([][]string, 0, len(matches))
+	for _, match := range matches {
+		status, since := "Left", ""
+		if match.IsParked {
+			status = "Parked"
+			since = formatTimestamp(match.ParkedAt, location)
+		} else if !match.LastSeenAt.IsZero() {
+			since = formatTimestamp(match.LastSeenAt, location)
+		}
+
+		rows = append(rows, []string{
+			match.VehicleNumber,
+			model.GetVehicleTypeDisplay(match.VehicleType),
+			status,
+			match.SpotID,
+			since,
+		})
 	}
 
-	// Parse arguments
-	vehicleTypeStr := strings.ToUpper(args[0])
-	vehicleNumber := args[1]
+	return FormatTable([]string{"Vehicle Number", "Type", "Status", "Spot ID", "Since"}, rows)
+}
 
-	r.Logger.Debug("Attempting to park vehicle: type=%s, number=%s",
-		vehicleTypeStr, vehicleNumber)
+// statusFlags holds the flags of the status command
+type statusFlags struct {
+	floor    optionalInt
+	byNumber bool
+	ops      bool
+}
 
-	// Convert vehicle type
-	vehicleType, err := model.ParseVehicleType(vehicleTypeStr)
-	if err != nil {
-		return fmt.Errorf("invalid vehicle type: %s", vehicleTypeStr)
+// define binds the flags to fs, resetting them to their defaults
+func (f *statusFlags) define(fs *flag.FlagSet) {
+	*f = statusFlags{}
+	fs.Var(&f.floor, "floor", "show only floor `n`")
+	fs.BoolVar(&f.byNumber, "by-number", false, "sort the parked vehicles by number")
+	fs.BoolVar(&f.ops, "ops", false, "also show the operations done on the lot")
+}
+
+// handleStatus handles the status command
+func (r *CommandRegistry) handleStatus(flags statusFlags) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
 	}
 
-	// Try to park the vehicle
-	spotID, err := r.parkingLot.Park(vehicleType, vehicleNumber)
-	if err != nil {
-		return fmt.Errorf("failed to park vehicle: %v", err)
+	byNumber, showOps := flags.byNumber, flags.ops
+	if flags.floor.set && showOps {
+		return newUsageError("--ops cannot be combined with --floor")
+	}
+	if flags.floor.set {
+		return r.printFloorStatus(flags.floor.value, byNumber)
 	}
 
-	r.Logger.Debug("Vehicle parked successfully at spot %s", spotID)
+	r.Logger.Debug("Retrieving parking lot status")
+	status := r.collectStatus()
+	if byNumber {
+		sortParkedVehiclesByNumber(status.parkedVehicles)
+	}
 
 	if r.Options.Format == OutputFormatJSON {
 		// Output as JSON
-		result := ParkResult{
-			VehicleType:   string(vehicleType),
-			VehicleNumber: vehicleNumber,
-			SpotID:        spotID,
+		result := api.StatusResult{
+			Name:                    r.parkingLot.GetName(),
+			NumFloors:               len(status.floors),
+			TotalSpots:              status.totalSpots,
+			ActiveSpots:             status.activeSpots,
+			OccupiedSpots:           status.occupiedSpots,
+			AvailableSpots:          status.availableSpots,
+			SpotCounts:              convertSpotTypeMap(status.spotCounts),
+			AvailableCounts:         convertVehicleTypeMap(status.availableCounts),
+			AvailableCapacityByType: convertVehicleTypeMap(status.availableCapacity),
+			OccupiedCapacityByType:  convertVehicleTypeMap(status.occupiedCapacity),
+			ParkedVehicles:          parkedVehicleSpots(status.parkedVehicles),
+			Vehicles:                convertParkedVehicles(status.parkedVehicles),
+			PeakOccupancy:           convertPeakOccupancy(status.peak),
+			Chargers:                convertChargerSummary(status.chargers),
+			Accessible:              convertAccessibleCount(status.accessible),
+			Quotas:                  convertQuotas(status.quotas),
+			Metadata:                convertLotMetadata(status.metadata),
+			Floors:                  make([]api.FloorSummaryResult, 0, len(status.floors)),
+		}
+		for _, summary := range status.floors {
+			result.Floors = append(result.Floors, convertFloorSummary(summary))
+		}
+		if showOps {
+			operations := convertOperationStats(r.parkingLot.OperationStats())
+			result.Operations = &operations
 		}
 
-		PrintJSON("park", result, nil)
-	} else {
-		// Output as text
-		PrintSuccess("Vehicle %s parked successfully at spot %s", vehicleNumber, spotID)
+		return r.out.JSON("status", result, nil)
+	}
+
+	// Output as text
+	r.writeStatusText(r.out.Out, status)
+	if showOps {
+		r.out.Println("\nOperations:")
+		r.out.Println(formatOperationStats(r.parkingLot.OperationStats()))
 	}
 
 	return nil
 }
 
-// handleUnpark handles the unpark command
-func (r *CommandRegistry) handleUnpark(args []string) error {
-	// Check if parking lot is initialized
-	if r.parkingLot == nil {
-		return fmt.Errorf("parking lot not initialized, use 'init' command first")
+// formatOperationStats shows the counts of a lot's operations as a table,
+// the failed parks broken down by error code
+func formatOperationStats(stats model.OperationStats) string {
+	rows := [][]string{
+		{"Parks", strconv.FormatInt(stats.Parks, 10)},
+		{"Unparks", strconv.FormatInt(stats.Unparks, 10)},
+		{"Failed parks", strconv.FormatInt(stats.FailedParks, 10)},
+	}
+	codes := make([]string, 0, len(stats.FailedParksByCode))
+	for code := range stats.FailedParksByCode {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		rows = append(rows, []string{"  " + code, strconv.FormatInt(stats.FailedParksByCode[code], 10)})
 	}
+	rows = append(rows,
+		[]string{"Searches", strconv.FormatInt(stats.Searches, 10)},
+		[]string{"Resets", strconv.FormatInt(stats.Resets, 10)})
 
-	// Parse arguments
-	spotID := args[0]
-	vehicleNumber := args[1]
+	return FormatTable([]string{"Operation", "Count"}, rows)
+}
 
-	r.Logger.Debug("Attempting to remove vehicle %s from spot %s",
-		vehicleNumber, spotID)
+// statusSnapshot holds the figures shown by the status and watch commands
+type statusSnapshot struct {
+	totalSpots        int
+	activeSpots       int
+	occupiedSpots     int
+	availableSpots    int
+	spotCounts        map[model.SpotType]int
+	availableCounts   map[model.VehicleType]int
+	availableCapacity map[model.VehicleType]int
+	occupiedCapacity  map[model.VehicleType]int
+	parkedVehicles    []model.ParkedVehicle
+	now               time.Time
+	peak              model.PeakOccupancyReport
+	chargers          model.ChargerSummary
+	accessible        model.AccessibleCount
+	quotas            []model.Quota
+	metadata          model.LotMetadata
+	floors            []model.FloorSummary
+}
 
-	// Try to unpark the vehicle
-	err := r.parkingLot.Unpark(spotID, vehicleNumber)
-	if err != nil {
-		return fmt.Errorf("failed to unpark vehicle: %v", err)
+// collectStatus gathers the current status of the parking lot
+func (r *CommandRegistry) collectStatus() statusSnapshot {
+	var status statusSnapshot
+
+	// Get every spot count in one pass
+	stats := r.parkingLot.Stats()
+	status.totalSpots = stats.TotalSpots
+	status.activeSpots = stats.ActiveSpots
+	status.occupiedSpots = stats.OccupiedSpots
+	status.availableSpots = stats.AvailableSpots
+
+	// Get counts by type
+	status.spotCounts = stats.SpotCounts
+	r.Logger.Debug("Spot counts by type: %s", formatSpotTypeCounts(status.spotCounts))
+
+	// Get available counts by vehicle type
+	status.availableCounts = stats.AvailableByType
+	r.Logger.Debug("Available spots by vehicle type: %s", formatVehicleTypeCounts(status.availableCounts))
+
+	// Get capacity by vehicle type (differs from spot counts for bicycle racks)
+	status.availableCapacity = stats.AvailableCapacityByType
+	status.occupiedCapacity = stats.OccupiedCapacityByType
+
+	// Get per-floor counts
+	status.floors = stats.Floors
+
+	// Get parked vehicles, longest parked first
+	status.parkedVehicles = r.parkingLot.GetParkedVehicleDetails()
+	status.now = r.now()
+	r.Logger.Debug("Total parked vehicles: %d", len(status.parkedVehicles))
+
+	// Get the most vehicles parked at once
+	status.peak = r.parkingLot.GetPeakOccupancy()
+
+	status.chargers = r.parkingLot.GetChargerSummary()
+	status.accessible = r.parkingLot.GetAccessibleCount()
+	status.quotas = r.parkingLot.GetQuotas()
+	status.metadata = r.parkingLot.Metadata()
+
+	return status
+}
+
+// printFloorStatus prints the status of a single floor
+func (r *CommandRegistry) printFloorStatus(floorNum int, byNumber bool) error {
+	r.Logger.Debug("Retrieving status of floor %d", floorNum)
+
+	var summary *model.FloorSummary
+	for _, s := range r.parkingLot.GetFloorSummaries() {
+		if s.FloorNumber == floorNum {
+			summary = &s
+			break
+		}
+	}
+
+	if summary == nil {
+		return fmt.Errorf("failed to get floor: floor %d not found", floorNum)
 	}
 
-	r.Logger.Debug("Vehicle %s successfully removed from spot %s\n", vehicleNumber, spotID)
+	// Keep only the vehicles parked on this floor
+	parkedVehicles := r.parkingLot.ListParkedVehicles(model.VehicleFilter{Floor: &floorNum})
+	if byNumber {
+		sortParkedVehiclesByNumber(parkedVehicles)
+	}
 
 	if r.Options.Format == OutputFormatJSON {
-		// Output as JSON
-		result := UnparkResult{
-			VehicleNumber: vehicleNumber,
-			SpotID:        spotID,
-		}
+		return r.out.JSON("status", api.FloorStatusResult{
+			FloorSummaryResult: convertFloorSummary(*summary),
+			ParkedVehicles:     parkedVehicleSpots(parkedVehicles),
+			Vehicles:           convertParkedVehicles(parkedVehicles),
+		}, nil)
+	}
 
-		PrintJSON("unpark", result, nil)
+	r.out.Info("Floor %s", model.FormatFloorNumber(floorNum))
+	r.out.Println(formatFloorSummaryTable([]model.FloorSummary{*summary}))
+
+	if len(parkedVehicles) > 0 {
+		r.out.Printf("Vehicles parked on this floor: %d\n", len(parkedVehicles))
+		r.out.Println(formatParkedVehiclesTable(parkedVehicles, r.now(), r.location()))
 	} else {
-		// Output as text
-		PrintSuccess("Vehicle %s successfully removed from spot %s\n", vehicleNumber, spotID)
+		r.out.Println("No vehicles parked on this floor")
 	}
 
 	return nil
 }
 
-// handleAvailable handles the available command
-func (r *CommandRegistry) handleAvailable(args []string) error {
-	// Check if parking lot is initialized
-	if r.parkingLot == nil {
-		return fmt.Errorf("parking lot not initialized, use 'init' command first")
+// formatFloorSummaryTable renders one row per floor with its spot counts
+func formatFloorSummaryTable(summaries []model.FloorSummary) string {
+	vehicleTypes := singleSpotVehicleTypes()
+	headers := []string{"Floor", "Total", "Active", "Occupied", "Available"}
+	for _, info := range vehicleTypes {
+		headers = append(headers, info.Display)
 	}
 
-	// Parse arguments
-	vehicleTypeStr := strings.ToUpper(args[0])
+	rows := make([][]string, 0, len(summaries))
+	for _, summary := range summaries {
+		floor := model.FormatFloorNumber(summary.FloorNumber)
+		if summary.Closed {
+			floor += " (closed)"
+		}
+		if summary.Allowed != nil {
+			floor += " (" + formatAllowedVehicleTypes(summary.Allowed) + " only)"
+		}
 
-	r.Logger.Debug("Searching for available spots for vehicle type: %s", vehicleTypeStr)
+		row := []string{
+			floor,
+			fmt.Sprintf("%d", summary.TotalSpots),
+			fmt.Sprintf("%d", summary.ActiveSpots),
+			fmt.Sprintf("%d", summary.OccupiedSpots),
+			fmt.Sprintf("%d", summary.AvailableSpots),
+		}
+		for _, info := range vehicleTypes {
+			row = append(row, fmt.Sprintf("%d", summary.AvailableByType[info.Type]))
+		}
+		rows = append(rows, row)
+	}
 
-	// Convert vehicle type
-	vehicleType, err := model.ParseVehicleType(vehicleTypeStr)
-	if err != nil {
-		return fmt.Errorf("invalid vehicle type: %s", vehicleTypeStr)
+	return FormatTable(headers, rows)
+}
+
+// formatParkedVehiclesTable renders parked vehicles with how long each has
+// been parked, with times in the given zone
+func formatParkedVehiclesTable(parkedVehicles []model.ParkedVehicle, now time.Time, location *time.Location) string {
+	rows := make([][]string, 0, len(parkedVehicles))
+	for _, vehicle := range parkedVehicles {
+		rows = append(rows, []string{
+			vehicle.VehicleNumber,
+			vehicle.SpotID,
+			formatTimestamp(vehicle.ParkedAt, location),
+			FormatDuration(vehicle.DurationAt(now), DurationCompact),
+		})
 	}
 
-	// Get available spots
-	spots, err := r.parkingLot.AvailableSpot(vehicleType)
-	if err != nil {
-		return fmt.Errorf("failed to get available spots: %v", err)
+	return FormatTable([]string{"Vehicle Number", "Spot ID", "Parked Since", "Duration"}, rows)
+}
+
+// formatPeakOccupancy renders the peak occupancy as a single status line,
+// with its time in the given zone
+func formatPeakOccupancy(peak model.PeakOccupancyReport, location *time.Location) string {
+	if peak.Overall.Count == 0 {
+		return "Peak occupancy: no vehicles parked yet"
 	}
 
-	r.Logger.Debug("Found %d available spots for %s", len(spots), vehicleTypeStr)
+	counts := make(map[model.VehicleType]int, len(peak.ByType))
+	for vehicleType, entry := range peak.ByType {
+		counts[vehicleType] = entry.Count
+	}
 
-	if r.Options.Format == OutputFormatJSON {
-		// Output as JSON
-		result := AvailableResult{
-			VehicleType: string(vehicleType),
-			SpotIDs:     spots,
-			Count:       len(spots),
-		}
+	return fmt.Sprintf("Peak occupancy: %d vehicles at %s (%s)",
+		peak.Overall.Count, formatTimestamp(peak.Overall.At, location),
+		formatVehicleTypeCounts(counts))
+}
 
-		PrintJSON("available", result, nil)
-	} else {
-		// Output as text
-		if len(spots) == 0 {
-			fmt.Printf("No available spots for vehicle type %s\n", vehicleTypeStr)
-			return nil
+// singleSpotVehicleTypes returns the registered vehicle types that take one
+// spot, which per-type columns and rows are shown for
+func singleSpotVehicleTypes() []model.VehicleTypeInfo {
+	var list []model.VehicleTypeInfo
+	for _, info := range model.RegisteredVehicleTypes() {
+		if info.Spans == "" {
+			list = append(list, info)
 		}
+	}
+	return list
+}
 
-		fmt.Printf("Available spots for %s:\n", model.GetVehicleTypeDisplay(vehicleType))
-
-		// Display spots in a table format
-		const maxColsPerRow = 5
-		rows := [][]string{}
-		currentRow := []string{}
+// vehicleTypeNames lists the registered vehicle type names for help and
+// error text, e.g. "bicycle, motorcycle, automobile, minibus"
+func vehicleTypeNames() string {
+	registered := model.RegisteredVehicleTypes()
+	names := make([]string, len(registered))
+	for i, info := range registered {
+		names[i] = strings.ToLower(string(info.Type))
+	}
+	return strings.Join(names, ", ")
+}
 
-		for _, spotID := range spots {
-			currentRow = append(currentRow, spotID)
+// withVehicleTypeNames adds the registered vehicle type names to the
+// error parsing a vehicle type, keeping its code
+func withVehicleTypeNames(err error) error {
+	return fmt.Errorf("%w (expected one of: %s)", err, vehicleTypeNames())
+}
 
-			if len(currentRow) == maxColsPerRow {
-				rows = append(rows, currentRow)
-				currentRow = []string{}
-			}
+// vehicleTypeShortName returns the one-letter alias of a vehicle type, or
+// its name when it has none
+func vehicleTypeShortName(info model.VehicleTypeInfo) string {
+	for _, alias := range info.Aliases {
+		if len(alias) == 1 {
+			return alias
 		}
+	}
+	return string(info.Type)
+}
 
-		// Add the last partial row if any
-		if len(currentRow) > 0 {
-			// Pad with empty cells
-			for len(currentRow) < maxColsPerRow {
-				currentRow = append(currentRow, "")
-			}
-			rows = append(rows, currentRow)
-		}
+// spotTypeLabel returns the short name of a spot type for tables, its
+// display name without the trailing " Spot"
+func spotTypeLabel(info model.SpotTypeInfo) string {
+	return strings.TrimSuffix(info.Display, " Spot")
+}
 
-		// Create headers
-		headers := make([]string, maxColsPerRow)
-		for i := 0; i < maxColsPerRow; i++ {
-			headers[i] = fmt.Sprintf("Spot %d", i+1)
-		}
+// formatSpotCountTable renders the count of spots of each registered type
+func formatSpotCountTable(counts map[model.SpotType]int) string {
+	registered := model.RegisteredSpotTypes()
+	rows := make([][]string, 0, len(registered))
+	for _, info := range registered {
+		rows = append(rows, []string{spotTypeLabel(info), fmt.Sprintf("%d", counts[info.Type])})
+	}
 
-		fmt.Println(FormatTable(headers, rows))
-		fmt.Printf("Total available: %d\n", len(spots))
+	return FormatTable([]string{"Type", "Count"}, rows)
+}
+
+// formatSpotTypeCounts renders counts by spot letter on one line, e.g. "B=1, M=2, A=3, X=0"
+func formatSpotTypeCounts(counts map[model.SpotType]int) string {
+	registered := model.RegisteredSpotTypes()
+	parts := make([]string, len(registered))
+	for i, info := range registered {
+		parts[i] = fmt.Sprintf("%c=%d", info.Letter(), counts[info.Type])
 	}
+	return strings.Join(parts, ", ")
+}
 
-	return nil
+// formatVehicleTypeCounts renders counts of single-spot vehicle types on one
+// line by their first letter or name, e.g. "B=1, M=2, A=3"
+func formatVehicleTypeCounts(counts map[model.VehicleType]int) string {
+	vehicleTypes := singleSpotVehicleTypes()
+	parts := make([]string, len(vehicleTypes))
+	for i, info := range vehicleTypes {
+		parts[i] = fmt.Sprintf("%s=%d", vehicleTypeShortName(info), counts[info.Type])
+	}
+	return strings.Join(parts, ", ")
 }
 
-// handleSearch handles the search command
-func (r *CommandRegistry) handleSearch(args []string) error {
-	// Check if parking lot is initialized
-	if r.parkingLot == nil {
-		return fmt.Errorf("parking lot not initialized, use 'init' command first")
+// sortParkedVehiclesByNumber orders parked vehicles by vehicle number
+func sortParkedVehiclesByNumber(parkedVehicles []model.ParkedVehicle) {
+	sort.Slice(parkedVehicles, func(i, j int) bool {
+		return parkedVehicles[i].VehicleNumber < parkedVehicles[j].VehicleNumber
+	})
+}
+
+// writeStatusText renders the text form of the status command
+func (r *CommandRegistry) writeStatusText(w io.Writer, status statusSnapshot) {
+	fmt.Fprintln(w, style(kindInfo, r.parkingLot.String()))
+	if metadata := formatLotMetadata(status.metadata); metadata != "" {
+		fmt.Fprintln(w, metadata)
 	}
 
-	// Parse arguments
-	vehicleNumber := args[0]
+	// Show counts by type in a table
+	fmt.Fprintln(w, "Spot types:")
+	fmt.Fprintln(w, formatSpotCountTable(status.spotCounts))
 
-	r.Logger.Debug("Searching for vehicle with number: %s", vehicleNumber)
+	// Show available spots and capacity by vehicle type
+	vehicleTypes := singleSpotVehicleTypes()
+	availableTableRows := make([][]string, 0, len(vehicleTypes))
+	capacityTableRows := make([][]string, 0, len(vehicleTypes))
+	for _, info := range vehicleTypes {
+		availableTableRows = append(availableTableRows,
+			[]string{info.Display, fmt.Sprintf("%d", status.availableCounts[info.Type])})
+		capacityTableRows = append(capacityTableRows, []string{info.Display,
+			fmt.Sprintf("%d", status.occupiedCapacity[info.Type]),
+			fmt.Sprintf("%d", status.availableCapacity[info.Type])})
+	}
 
-	// Search for the vehicle
-	spotID, isParked, err := r.parkingLot.SearchVehicle(vehicleNumber)
+	fmt.Fprintln(w, "Available spots by vehicle type:")
+	fmt.Fprintln(w, FormatTable([]string{"Vehicle Type", "Available Spots"}, availableTableRows))
 
-	// Special case for "not found" errors
-	var notFoundErr *perrors.VehicleNotFoundError
-	if errors.As(err, &notFoundErr) {
-		r.Logger.Debug("Vehicle %s not found in the parking lot", vehicleNumber)
+	fmt.Fprintln(w, "Capacity by vehicle type:")
+	fmt.Fprintln(w, FormatTable([]string{"Vehicle Type", "Parked", "Free"}, capacityTableRows))
 
-		if r.Options.Format == OutputFormatJSON {
-			result := SearchResult{
-				VehicleNumber: vehicleNumber,
-				SpotID:        "",
-				IsParked:      false,
-			}
+	fmt.Fprintln(w, formatPeakOccupancy(status.peak, r.location()))
 
-			// Use nil for error to indicate "not found" is not really an error in this context
-			PrintJSON("search", result, nil)
-		} else {
-			PrintWarning("Vehicle %s not found in the parking lot", vehicleNumber)
+	// Show EV chargers by state, when the lot has any
+	if status.chargers.Spots > 0 {
+		states := make([]string, 0, len(model.ChargerStates))
+		for _, state := range model.ChargerStates {
+			states = append(states, fmt.Sprintf("%d %s", status.chargers.States[state], formatChargerState(state)))
 		}
-		return notFoundErr
+		fmt.Fprintf(w, "EV charging spots: %d (%s)\n", status.chargers.Spots, strings.Join(states, ", "))
 	}
 
-	// Handle other errors
-	if err != nil {
-		r.Logger.Debug("Error searching for vehicle: %v", err)
-		return fmt.Errorf("failed to search for vehicle: %v", err)
+	// Accessible spots are counted in the tables too, but only permit holders get them
+	if status.accessible.Spots > 0 {
+		fmt.Fprintf(w, "Accessible spots: %d (%d available, permit holders only)\n",
+			status.accessible.Spots, status.accessible.Available)
+	}
+
+	if len(status.quotas) > 0 {
+		fmt.Fprintln(w, formatQuotas(status.quotas))
 	}
 
-	r.Logger.Debug("Vehicle found: spotID=%s, isParked=%v", spotID, isParked)
+	// Show per-floor counts; the type columns are available spots
+	fmt.Fprintln(w, "Floors:")
+	fmt.Fprintln(w, formatFloorSummaryTable(status.floors))
 
-	if r.Options.Format == OutputFormatJSON {
-		// Output as JSON
-		result := SearchResult{
-			VehicleNumber: vehicleNumber,
-			SpotID:        spotID,
-			IsParked:      isParked,
-		}
+	// Show parked vehicles
+	if len(status.parkedVehicles) > 0 {
+		fmt.Fprintf(w, "Currently parked vehicles: %d\n", len(status.parkedVehicles))
 
-		PrintJSON("search", result, nil)
+		fmt.Fprintln(w, formatParkedVehiclesTable(status.parkedVehicles, status.now, r.location()))
 	} else {
-		// Output as text
-		if isParked {
-			PrintSuccess("Vehicle %s is currently parked at spot %s", vehicleNumber, spotID)
-		} else {
-			PrintInfo("Vehicle %s is not currently parked, but was last seen at spot %s",
-				vehicleNumber, spotID)
-		}
-
-		// If verbose, try to get more information about the vehicle's history
-		if r.Options.Verbose {
-			history, found := r.parkingLot.GetVehicleHistory(vehicleNumber)
-			if found && history != nil {
-				fmt.Println("\nParking History:")
-
-				records := history.Records
-				if len(records) > 0 {
-					historyRows := make([][]string, 0, len(records))
-
-					for i, record := range records {
-						var duration, status string
-						if record.IsComplete() {
-							duration = FormatDuration(record.Duration())
-							status = "Completed"
-						} else {
-							duration = FormatDuration(time.Since(record.ParkedAt))
-							status = "Active"
-						}
-
-						parkedAt := record.ParkedAt.Format("2006-01-02 15:04:05")
-						var unparkedAt string
-						if record.UnparkedAt != nil {
-							unparkedAt = record.UnparkedAt.Format("2006-01-02 15:04:05")
-						} else {
-							unparkedAt = "Still Parked"
-						}
-
-						historyRows = append(historyRows, []string{
-							fmt.Sprintf("%d", i+1),
-							record.SpotID,
-							parkedAt,
-							unparkedAt,
-							duration,
-							status,
-						})
-					}
+		fmt.Fprintln(w, "No vehicles currently parked")
+	}
+}
 
-					headers := []string{"#", "Spot ID", "Parked At", "Unparked At", "Duration", "Status"}
-					fmt.Println(FormatTable(headers, historyRows))
-				}
-			}
-		}
+// handleStats handles the stats command
+func (r *CommandRegistry) handleStats(commands bool) error {
+	if !commands {
+		return r.printParkingStats()
+	}
+
+	usage := r.GetCommandStats()
+	r.Logger.Debug("Collected usage stats for %d commands", len(usage))
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("stats", api.CommandStatsResult{Commands: usage}, nil)
 	}
 
+	r.out.Println("Command usage:")
+	r.out.Println(formatCommandStatsTable(usage))
+
 	return nil
 }
 
-// handleStatus handles the status command
-func (r *CommandRegistry) handleStatus(args []string) error {
+// printParkingStats prints statistics over the lot's parking sessions
+func (r *CommandRegistry) printParkingStats() error {
 	// Check if parking lot is initialized
 	if r.parkingLot == nil {
-		return fmt.Errorf("parking lot not initialized, use 'init' command first")
+		return perrors.NewLotNotInitializedError()
 	}
 
-	r.Logger.Debug("Retrieving parking lot status")
+	stats := r.parkingLot.GetParkingStats()
+	operations := r.parkingLot.OperationStats()
+	r.Logger.Debug("Collected parking stats over %d vehicles", stats.DistinctVehicles)
 
-	// Get parking lot information
-	totalSpots := r.parkingLot.GetTotalSpotCount()
-	activeSpots := r.parkingLot.GetActiveSpotCount()
-	occupiedSpots := r.parkingLot.GetOccupiedSpotCount()
-	availableSpots := r.parkingLot.GetAvailableSpotCount()
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("stats", api.ParkingStatsResult{
+			CompletedSessions:      stats.CompletedSessions,
+			ActiveSessions:         stats.ActiveSessions,
+			DistinctVehicles:       stats.DistinctVehicles,
+			AverageDurationSeconds: stats.AverageDuration.Seconds(),
+			MedianDurationSeconds:  stats.MedianDuration.Seconds(),
+			BusiestSpotID:          stats.BusiestSpotID,
+			BusiestSpotSessions:    stats.BusiestSpotSessions,
+			Operations:             convertOperationStats(operations),
+		}, nil)
+	}
 
-	// Get counts by type
-	spotCounts := r.parkingLot.GetSpotCountByType()
-	r.Logger.Debug("Spot counts by type: B=%d, M=%d, A=%d, X=%d",
-		spotCounts[model.SpotTypeBicycle],
-		spotCounts[model.SpotTypeMotorcycle],
-		spotCounts[model.SpotTypeAutomobile],
-		spotCounts[model.SpotTypeInactive])
+	if stats.DistinctVehicles == 0 {
+		r.out.Println("No parking sessions recorded yet")
+	} else {
+		r.printSessionStats(stats)
+	}
 
-	// Get available counts by vehicle type
-	availableCounts := r.parkingLot.GetAvailableSpotCountByType()
-	r.Logger.Debug("Available spots by vehicle type: B=%d, M=%d, A=%d",
-		availableCounts[model.VehicleTypeBicycle],
-		availableCounts[model.VehicleTypeMotorcycle],
-		availableCounts[model.VehicleTypeAutomobile])
+	r.out.Println("\nOperations:")
+	r.out.Println(formatOperationStats(operations))
+	return nil
+}
 
-	// Get parked vehicles
-	parkedVehicles := r.parkingLot.GetAllParkedVehicles()
-	r.Logger.Debug("Total parked vehicles: %d", len(parkedVehicles))
+// printSessionStats prints the statistics over parking sessions as a table
+func (r *CommandRegistry) printSessionStats(stats model.ParkingStats) {
 
-	if r.Options.Format == OutputFormatJSON {
-		// Output as JSON
-		result := StatusResult{
-			Name:            r.parkingLot.Name,
-			Floors:          r.parkingLot.GetNumFloors(),
-			TotalSpots:      totalSpots,
-			ActiveSpots:     activeSpots,
-			OccupiedSpots:   occupiedSpots,
-			AvailableSpots:  availableSpots,
-			SpotCounts:      convertSpotTypeMap(spotCounts),
-			AvailableCounts: convertVehicleTypeMap(availableCounts),
-			ParkedVehicles:  parkedVehicles,
-		}
-
-		PrintJSON("status", result, nil)
-	} else {
-		// Output as text
-		PrintInfo("%s", r.parkingLot.String())
+	rows := [][]string{
+		{"Completed sessions", fmt.Sprintf("%d", stats.CompletedSessions)},
+		{"Sessions in progress", fmt.Sprintf("%d", stats.ActiveSessions)},
+		{"Distinct vehicles", fmt.Sprintf("%d", stats.DistinctVehicles)},
+	}
+	if stats.CompletedSessions > 0 {
+		rows = append(rows,
+			[]string{"Average duration", FormatDuration(stats.AverageDuration, DurationCompact)},
+			[]string{"Median duration", FormatDuration(stats.MedianDuration, DurationCompact)})
+	}
+	sessions := "sessions"
+	if stats.BusiestSpotSessions == 1 {
+		sessions = "session"
+	}
+	rows = append(rows, []string{"Busiest spot",
+		fmt.Sprintf("%s (%d %s)", stats.BusiestSpotID, stats.BusiestSpotSessions, sessions)})
+
+	r.out.Println("Parking statistics:")
+	r.out.Println(FormatTable([]string{"Statistic", "Value"}, rows))
+}
+
+// mapFlags holds the flags of the map command
+type mapFlags struct {
+	legend bool
+	width  optionalInt
+}
+
+// define binds the flags to fs, resetting them to their defaults
+func (f *mapFlags) define(fs *flag.FlagSet) {
+	*f = mapFlags{}
+	fs.BoolVar(&f.legend, "legend", false, "explain the letters under the map")
+	fs.Var(&f.width, "width", "wrap the map at `n` columns (default the terminal width)")
+}
 
-		// Show counts by type in a table
-		typeTableRows := [][]string{
-			{"Bicycle", fmt.Sprintf("%d", spotCounts[model.SpotTypeBicycle])},
-			{"Motorcycle", fmt.Sprintf("%d", spotCounts[model.SpotTypeMotorcycle])},
-			{"Automobile", fmt.Sprintf("%d", spotCounts[model.SpotTypeAutomobile])},
-			{"Inactive", fmt.Sprintf("%d", spotCounts[model.SpotTypeInactive])},
+// handleMap handles the map command
+func (r *CommandRegistry) handleMap(args []string, flags mapFlags) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	// A bare number selects a single floor
+	showLegend := flags.legend
+	width := 80
+	if tableWidth := TableWidth(); tableWidth > 0 {
+		width = tableWidth
+	}
+	if flags.width.set {
+		if flags.width.value < 1 {
+			return newUsageError("invalid width value: %d", flags.width.value)
+		}
+		width = flags.width.value
+	}
+	floorNum, singleFloor := 0, len(args) > 0
+	if singleFloor {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return newUsageError("invalid floor value: %s", args[0])
+		}
+		floorNum = n
+	}
+
+	floors := r.parkingLot.GetFloors()
+	if singleFloor {
+		floor, err := r.parkingLot.GetFloor(floorNum)
+		if err != nil {
+			return fmt.Errorf("failed to get floor: %w", err)
+		}
+		floors = []*model.ParkingFloor{floor}
+	}
+
+	r.Logger.Debug("Rendering map of %d floors", len(floors))
+
+	if r.Options.Format == OutputFormatJSON {
+		result := api.MapResult{Floors: make([]api.FloorMapResult, 0, len(floors))}
+		for _, floor := range floors {
+			result.Floors = append(result.Floors, api.FloorMapResult{
+				Floor: floor.FloorNumber,
+				Grid:  floor.GetDisplayState(),
+			})
 		}
 
-		fmt.Println("Spot types:")
-		fmt.Println(FormatTable([]string{"Type", "Count"}, typeTableRows))
+		return r.out.JSON("map", result, nil)
+	}
 
-		// Show available spots by vehicle type
-		availableTableRows := [][]string{
-			{"Bicycle", fmt.Sprintf("%d", availableCounts[model.VehicleTypeBicycle])},
-			{"Motorcycle", fmt.Sprintf("%d", availableCounts[model.VehicleTypeMotorcycle])},
-			{"Automobile", fmt.Sprintf("%d", availableCounts[model.VehicleTypeAutomobile])},
+	for i, floor := range floors {
+		if i > 0 {
+			r.out.Println()
 		}
+		title := fmt.Sprintf("Floor %s", model.FormatFloorNumber(floor.FloorNumber))
+		r.out.Print(FormatFloorMap(title, floor.GetDisplayState(), width))
+	}
 
-		fmt.Println("Available spots by vehicle type:")
-		fmt.Println(FormatTable([]string{"Vehicle Type", "Available Spots"}, availableTableRows))
+	if showLegend {
+		r.out.Println()
+		r.out.Println(mapLegend)
+	}
 
-		// Show parked vehicles
-		if len(parkedVehicles) > 0 {
-			fmt.Printf("Currently parked vehicles: %d\n", len(parkedVehicles))
+	return nil
+}
 
-			vehicleTableRows := make([][]string, 0, len(parkedVehicles))
-			for vehicleNumber, spotID := range parkedVehicles {
-				vehicleTableRows = append(vehicleTableRows, []string{vehicleNumber, spotID})
-			}
+// handleTemplates handles the templates command
+func (r *CommandRegistry) handleTemplates(args []string) error {
+	templates := model.GetLayoutTemplates()
+	r.Logger.Debug("Listing %d layout templates", len(templates))
 
-			// Sort the rows by vehicle number for consistent output
-			sort.Slice(vehicleTableRows, func(i, j int) bool {
-				return vehicleTableRows[i][0] < vehicleTableRows[j][0]
-			})
+	results := make([]api.TemplateResult, 0, len(templates))
+	for _, template := range templates {
+		// Describe the mix using a sample floor large enough to show the pattern
+		layout, err := model.NewSpotLayoutFromTemplate(template.Name, 1, 20, 20)
+		if err != nil {
+			return fmt.Errorf("failed to generate template %s: %w", template.Name, err)
+		}
 
-			fmt.Println(FormatTable([]string{"Vehicle Number", "Spot ID"}, vehicleTableRows))
-		} else {
-			fmt.Println("No vehicles currently parked")
+		mix := make(map[string]int)
+		for spotType, count := range layout.CountSpotsByType() {
+			mix[string(spotType)] = count * 100 / (20 * 20)
 		}
+
+		results = append(results, api.TemplateResult{
+			Name:        template.Name,
+			Description: template.Description,
+			Mix:         mix,
+		})
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("templates", api.TemplatesResult{Templates: results}, nil)
+	}
+
+	tableRows := make([][]string, 0, len(results))
+	for _, result := range results {
+		tableRows = append(tableRows, []string{
+			result.Name,
+			result.Description,
+			fmt.Sprintf("B %d%% / M %d%% / A %d%% / X %d%%",
+				result.Mix[string(model.SpotTypeBicycle)], result.Mix[string(model.SpotTypeMotorcycle)],
+				result.Mix[string(model.SpotTypeAutomobile)], result.Mix[string(model.SpotTypeInactive)]),
+		})
 	}
 
+	r.out.Println("Layout templates:")
+	r.out.Println(FormatTable([]string{"Name", "Description", "Mix"}, tableRows))
+
 	return nil
 }
 
 // handleExit handles the exit command
 func (r *CommandRegistry) handleExit(args []string) error {
-	fmt.Println("Exiting...")
+	r.out.Println("Exiting...")
 	return nil
 }
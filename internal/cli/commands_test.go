@@ -1,69 +1,2111 @@
 package cli
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	perrors "github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+	"github.com/prasaria/go-multistorey-parking-lot/internal/model"
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/api"
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/config"
 )
 
-func TestCommandRegistry(t *testing.T) {
-	registry := NewCommandRegistry()
-	registry.RegisterAllCommands()
+// testCLI is a registry with every command registered, its output going to
+// a buffer, uncolored, so that tests can check what commands print
+type testCLI struct {
+	*CommandRegistry
+	t   *testing.T
+	out bytes.Buffer
+}
 
-	// Test help command
-	err := registry.ExecuteCommand("help", []string{})
-	if err != nil {
-		t.Errorf("Failed to execute help command: %v", err)
+// newTestCLI returns a testCLI for the test
+func newTestCLI(t *testing.T) *testCLI {
+	t.Helper()
+	c := &testCLI{CommandRegistry: NewCommandRegistry(), t: t}
+	c.RegisterAllCommands()
+	c.SetOutput(&c.out, &c.out)
+
+	colored := ColorOutput()
+	SetColorOutput(false)
+	t.Cleanup(func() { SetColorOutput(colored) })
+	return c
+}
+
+// run runs a command that should succeed and returns its output
+func (c *testCLI) run(name string, args ...string) string {
+	c.t.Helper()
+	c.out.Reset()
+	if err := c.ExecuteCommand(name, args); err != nil {
+		c.t.Fatalf("Failed to run %s %v: %v", name, args, err)
 	}
+	return c.out.String()
+}
 
-	// Test unknown command
-	err = registry.ExecuteCommand("unknown", []string{})
+// fail runs a command that should fail and returns its error
+func (c *testCLI) fail(name string, args ...string) error {
+	c.t.Helper()
+	c.out.Reset()
+	err := c.ExecuteCommand(name, args)
 	if err == nil {
-		t.Errorf("Expected error for unknown command")
+		c.t.Errorf("Expected %s %v to fail, got:\n%s", name, args, c.out.String())
 	}
+	return err
+}
 
-	// Test init command
-	err = registry.ExecuteCommand("init", []string{"2", "3", "4"})
-	if err != nil {
-		t.Errorf("Failed to execute init command: %v", err)
+// expect checks that the output holds each of the lines or fragments
+func (c *testCLI) expect(output string, fragments ...string) {
+	c.t.Helper()
+	for _, fragment := range fragments {
+		if !strings.Contains(output, fragment) {
+			c.t.Errorf("Expected %q in the output:\n%s", fragment, output)
+		}
 	}
+}
 
-	// Verify parking lot was created
-	if registry.GetParkingLot() == nil {
-		t.Errorf("Parking lot not initialized after init command")
+// table returns the rows of the first table in the output, each split
+// into its cells
+func table(output string) [][]string {
+	var rows [][]string
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "---") || i == 0 {
+			continue
+		}
+		rows = append(rows, strings.Fields(lines[i-1]))
+		for _, row := range lines[i+1:] {
+			if strings.TrimSpace(row) == "" {
+				break
+			}
+			rows = append(rows, strings.Fields(row))
+		}
+		break
 	}
+	return rows
+}
 
-	// Test park command
-	err = registry.ExecuteCommand("park", []string{"automobile", "TEST-1234"})
-	if err != nil {
-		t.Errorf("Failed to execute park command: %v", err)
+func TestCommandRegistry(t *testing.T) {
+	c := newTestCLI(t)
+
+	c.expect(c.run("help"), "Available commands:", "  park ", "Vehicle types: bicycle, motorcycle, automobile")
+
+	if err := c.fail("unknown"); !strings.Contains(err.Error(), "unknown command: unknown") {
+		t.Errorf("Expected an unknown command error, got %v", err)
 	}
 
-	// Test available command
-	err = registry.ExecuteCommand("available", []string{"automobile"})
-	if err != nil {
-		t.Errorf("Failed to execute available command: %v", err)
+	c.expect(c.run("init", "2", "3", "4"),
+		"Created parking lot with 2 floors, 3 rows, and 4 columns", "Total spots: 24")
+	if c.GetParkingLot() == nil {
+		t.Fatal("Parking lot not initialized after init command")
 	}
 
-	// Test search command
-	err = registry.ExecuteCommand("search", []string{"TEST-1234"})
+	c.expect(c.run("park", "automobile", "TEST-1234"),
+		"Vehicle TEST-1234 parked successfully at spot 0-0-2")
+	c.expect(c.run("available", "automobile"),
+		"Available spots for Automobile:", "Floor 0 (5 available):", "Floor 1 (6 available):", "Total available: 11")
+	c.expect(c.run("search", "TEST-1234"), "Vehicle TEST-1234 is currently parked at spot 0-0-2 since ")
+
+	spot, err := c.GetParkingLot().FindVehicle("TEST-1234")
 	if err != nil {
-		t.Errorf("Failed to execute search command: %v", err)
+		t.Fatalf("Failed to find parked vehicle: %v", err)
 	}
+	c.expect(c.run("unpark", spot.GetSpotID(), "TEST-1234"),
+		"Vehicle TEST-1234 successfully removed from spot 0-0-2, parked for 0 seconds")
 
-	// Test unpark command
-	// First find where the vehicle is parked
-	spot, err := registry.GetParkingLot().FindVehicle("TEST-1234")
-	if err != nil {
-		t.Errorf("Failed to find parked vehicle: %v", err)
+	output := c.run("status")
+	c.expect(output, "Parking Lot: 2 floors, 24 total spots, 20 active, 0 occupied, 20 available",
+		"No vehicles currently parked")
+	if rows := table(output); len(rows) != 5 || strings.Join(rows[3], " ") != "Automobile 12" {
+		t.Errorf("Expected the spot type counts first, got %v", rows)
+	}
+}
+
+func TestCommandStats(t *testing.T) {
+	c := newTestCLI(t)
+
+	// Scripted session: 2 successful parks, 1 failed park, 1 usage error
+	script := []struct {
+		name string
+		args []string
+	}{
+		{"init", []string{"1", "3", "4"}},
+		{"park", []string{"automobile", "STAT-1"}},
+		{"park", []string{"automobile", "STAT-2"}},
+		{"park", []string{"automobile", "STAT-1"}},
+		{"park", []string{"automobile"}},
+		{"status", []string{}},
+		{"unknown", []string{}},
 	}
 
-	err = registry.ExecuteCommand("unpark", []string{spot.GetSpotID(), "TEST-1234"})
-	if err != nil {
-		t.Errorf("Failed to execute unpark command: %v", err)
+	for _, step := range script {
+		_ = c.ExecuteCommand(step.name, step.args)
+	}
+
+	usage := make(map[string]api.CommandUsage)
+	for _, u := range c.GetCommandStats() {
+		usage[u.Name] = u
+	}
+
+	if _, found := usage["unknown"]; found {
+		t.Errorf("Unknown commands should not be tracked")
+	}
+
+	expected := map[string][2]int64{
+		"init":   {1, 0},
+		"park":   {4, 2},
+		"status": {1, 0},
+		"search": {0, 0},
+	}
+
+	for name, counts := range expected {
+		if usage[name].Executions != counts[0] {
+			t.Errorf("Expected %d executions for %s, got %d", counts[0], name, usage[name].Executions)
+		}
+		if usage[name].Failures != counts[1] {
+			t.Errorf("Expected %d failures for %s, got %d", counts[1], name, usage[name].Failures)
+		}
+	}
+
+	// The table lists the commands run, park among them
+	output := c.run("stats", "--commands")
+	c.expect(output, "Command usage:")
+	found := false
+	for _, row := range table(output) {
+		if strings.Join(row, " ") == "park 4 2" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected park with 4 executions and 2 failures, got:\n%s", output)
+	}
+
+	// The stats command itself is counted
+	var result struct {
+		Data struct {
+			Commands []struct {
+				Name       string `json:"name"`
+				Executions int64  `json:"executions"`
+			} `json:"commands"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(c.run("stats", "--commands", "--json")), &result); err != nil {
+		t.Fatalf("Expected the command usage as JSON: %v", err)
+	}
+	for _, command := range result.Data.Commands {
+		if command.Name == "stats" && command.Executions != 1 {
+			t.Errorf("Expected the earlier stats counted in the JSON, got %d", command.Executions)
+		}
+	}
+
+	c.fail("stats", "--bogus")
+
+	for _, u := range c.GetCommandStats() {
+		if u.Name == "stats" && (u.Executions != 3 || u.Failures != 1) {
+			t.Errorf("Expected stats to have 3 executions and 1 failure, got %d and %d",
+				u.Executions, u.Failures)
+		}
+	}
+}
+
+func TestCommandStatsPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+
+	c := newTestCLI(t)
+	c.run("help")
+	c.fail("park", "automobile", "NOLOT-1")
+
+	if err := c.SaveCommandStats(path); err != nil {
+		t.Fatalf("Failed to save command stats: %v", err)
+	}
+
+	restored := newTestCLI(t)
+	if err := restored.LoadCommandStats(path); err != nil {
+		t.Fatalf("Failed to load command stats: %v", err)
+	}
+	restored.run("help")
+
+	for _, u := range restored.GetCommandStats() {
+		switch u.Name {
+		case "help":
+			if u.Executions != 2 {
+				t.Errorf("Expected 2 help executions after reload, got %d", u.Executions)
+			}
+		case "park":
+			if u.Executions != 1 || u.Failures != 1 {
+				t.Errorf("Expected 1 failed park after reload, got %d/%d", u.Executions, u.Failures)
+			}
+		}
+	}
+
+	// A missing stats file is not an error
+	if err := restored.LoadCommandStats(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("Expected no error for missing stats file, got %v", err)
+	}
+}
+
+func TestAddFloorCommand(t *testing.T) {
+	c := newTestCLI(t)
+
+	// Adding a floor requires an initialized lot
+	c.fail("add-floor", "2", "3")
+
+	c.run("init", "1", "2", "3")
+	c.expect(c.run("add-floor", "2", "3"), "Added floor 1 with 2 rows and 3 columns", "Total spots on floor: 6")
+
+	if c.GetParkingLot().GetNumFloors() != 2 {
+		t.Errorf("Expected 2 floors, got %d", c.GetParkingLot().GetNumFloors())
+	}
+
+	if _, err := c.GetParkingLot().GetFloor(1); err != nil {
+		t.Errorf("Expected new floor to be numbered 1: %v", err)
+	}
+
+	c.fail("add-floor", "x", "3")
+}
+
+func TestFloorManagementCommands(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "2", "3", "4")
+
+	// Destructive commands require confirmation
+	if err := c.fail("remove-floor", "1"); err != nil && !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("Expected the refusal to name --yes, got %v", err)
+	}
+	c.fail("close-floor", "0")
+
+	c.expect(c.run("close-floor", "0", "--yes"), "Floor 0 closed to new vehicles")
+	c.expect(c.run("park", "automobile", "FLOOR-1"), "parked successfully at spot 1-0-2")
+
+	spot, _ := c.GetParkingLot().FindVehicle("FLOOR-1")
+	if spot.Floor != 1 {
+		t.Errorf("Expected vehicle on floor 1, got floor %d", spot.Floor)
+	}
+
+	// Floor 1 is occupied and cannot be removed
+	c.fail("remove-floor", "1", "--yes")
+
+	c.expect(c.run("expand-floor", "1", "4", "5"), "Floor 1 resized to 4 rows and 5 columns", "Total spots on floor: 20")
+
+	floor, _ := c.GetParkingLot().GetFloor(1)
+	if floor.GetSpotCount() != 20 {
+		t.Errorf("Expected 20 spots on expanded floor, got %d", floor.GetSpotCount())
+	}
+
+	c.fail("expand-floor", "1", "4", "5", "Q-1")
+
+	c.expect(c.run("open-floor", "0"), "Floor 0 open to new vehicles")
+	c.expect(c.run("remove-floor", "0", "--yes"), "Removed floor 0, 1 floors remaining")
+
+	if c.GetParkingLot().GetNumFloors() != 1 {
+		t.Errorf("Expected 1 floor, got %d", c.GetParkingLot().GetNumFloors())
+	}
+}
+
+func TestInitWithBasements(t *testing.T) {
+	c := newTestCLI(t)
+
+	c.expect(c.run("init", "3", "2", "4", "--start-floor", "-2"), "Created parking lot with 3 floors, 2 rows, and 4 columns")
+	if _, err := c.GetParkingLot().GetFloor(-2); err != nil {
+		t.Errorf("Expected floor -2 to exist: %v", err)
+	}
+
+	// Basement floors are written with a B
+	c.expect(c.run("park", "automobile", "BASE-1"), "Vehicle BASE-1 parked successfully at spot B2-0-2")
+
+	spot, _ := c.GetParkingLot().FindVehicle("BASE-1")
+	c.expect(c.run("unpark", spot.GetSpotID(), "BASE-1"), "Vehicle BASE-1 successfully removed from spot B2-0-2")
+
+	c.fail("init", "3", "2", "4", "--start-floor")
+	c.fail("init", "3", "2", "4", "--bogus", "1")
+}
+
+func TestInitWithFlags(t *testing.T) {
+	c := newTestCLI(t)
+
+	c.expect(c.run("init", "-floors", "2", "-rows", "3", "-columns", "4"), "Created parking lot with 2 floors, 3 rows, and 4 columns")
+	lot := c.GetParkingLot()
+	if lot.GetNumFloors() != 2 || lot.GetTotalSpotCount() != 24 {
+		t.Errorf("Expected 2 floors and 24 spots, got %d and %d", lot.GetNumFloors(), lot.GetTotalSpotCount())
+	}
+
+	// Flags take the other options, and dimensions not given are the defaults
+	c.expect(c.run("init", "south", "--floors=1", "--start-floor", "-1", "--name", "South"),
+		"Created parking lot with 1 floors, 5 rows, and 10 columns", "Active lot: south")
+	if _, err := c.GetParkingLot().GetFloor(-1); err != nil || c.GetParkingLot().GetName() != "South" {
+		t.Errorf("Expected the south lot on floor -1, got %v", err)
+	}
+
+	// The positional form still works alongside
+	c.expect(c.run("init", "1", "2", "3"), "Created parking lot with 1 floors, 2 rows, and 3 columns")
+
+	invalid := [][]string{
+		{"3", "-rows", "5", "-columns", "10"},
+		{"3", "5", "10", "-floors", "2"},
+		{"-floors", "3", "5", "10"},
+		{"-floors", "three"},
+		{"-floors", "9"},
+	}
+	for _, args := range invalid {
+		err := c.ExecuteCommand("init", args)
+		if ExitCode(err) != ExitUsage || !strings.Contains(err.Error(), "Usage: init") {
+			t.Errorf("Expected init %v to fail with the usage, got %v", args, err)
+		}
+	}
+}
+
+func TestInitWithPillars(t *testing.T) {
+	c := newTestCLI(t)
+
+	c.expect(c.run("init", "2", "10", "20", "--pillars", "1:9"), "Inactive    60")
+	c.expect(c.run("init", "2", "10", "20", "--pillars", "none"), "Inactive    0")
+	c.expect(c.run("init", "-floors", "1", "-rows", "10", "-columns", "20", "--pillars", "7:7:2"), "Inactive    12")
+
+	// A mix has no pillars of its own, so they are only those placed
+	c.expect(c.run("init", "1", "10", "20", "--mix", "1:1:1", "--pillars", "1:9"), "Inactive    30")
+
+	invalid := [][]string{
+		{"1", "10", "20", "--pillars", "9"},
+		{"1", "10", "20", "--pillars", "1:9:10"},
+		{"1", "10", "20", "--pillars", "1:9", "--seed", "1"},
+		{"1", "10", "20", "--pillars", "1:9", "--template", "mall"},
+	}
+	for _, args := range invalid {
+		c.fail("init", args...)
+	}
+}
+
+func TestInitWithPresets(t *testing.T) {
+	c := newTestCLI(t)
+
+	for _, preset := range config.Presets() {
+		cfg := preset.Config
+		if err := c.InitializeLot("", cfg); err != nil {
+			t.Fatalf("Failed to build the %s preset: %v", preset.Name, err)
+		}
+
+		lot := c.GetParkingLot()
+		counts := lot.GetSpotCountByType()
+		if lot.GetNumFloors() != cfg.Floors || lot.GetTotalSpotCount() != cfg.Floors*cfg.Rows*cfg.Columns {
+			t.Errorf("Expected %s to have %d floors of %dx%d, got %d floors and %d spots", preset.Name,
+				cfg.Floors, cfg.Rows, cfg.Columns, lot.GetNumFloors(), lot.GetTotalSpotCount())
+		}
+		if counts["B-1"] == 0 || counts["M-1"] == 0 || counts["A-1"] == 0 {
+			t.Errorf("Expected every active spot type in %s, got %v", preset.Name, counts)
+		}
+		if inactive := counts["X-0"]; (cfg.Pillars == "none") != (inactive == 0) {
+			t.Errorf("Expected %s with pillars %s to have inactive spots only with pillars, got %d",
+				preset.Name, cfg.Pillars, inactive)
+		}
+	}
+
+	c.expect(c.run("init", "--preset", "small"), "Created parking lot with 1 floors, 5 rows, and 10 columns", "Inactive    0")
+	c.expect(c.run("init", "--preset", "mega"), "Created parking lot with 8 floors, 1000 rows, and 1000 columns")
+	c.expect(c.run("init", "--preset", "standard", "-floors", "2"), "Created parking lot with 2 floors, 5 rows, and 10 columns")
+	c.expect(c.run("init", "1", "10", "20", "--preset", "standard", "--pillars", "1:9"), "Inactive    30")
+
+	err := c.ExecuteCommand("init", []string{"--preset", "huge"})
+	if err == nil || !strings.Contains(err.Error(), "small, standard, mega") {
+		t.Errorf("Expected an unknown preset to list the presets, got %v", err)
+	}
+	c.fail("init", "--preset", "small", "--template", "mall")
+}
+
+func TestInitWithMix(t *testing.T) {
+	c := newTestCLI(t)
+
+	output := c.run("init", "1", "10", "10", "--mix", "10:20:70")
+	counts := c.GetParkingLot().GetSpotCountByType()
+	if counts["B-1"] != 10 || counts["M-1"] != 20 || counts["A-1"] != 70 {
+		t.Errorf("Expected 10/20/70 spots, got %v", counts)
+	}
+	rows := table(output)
+	if len(rows) != 5 || strings.Join(rows[1], " ") != "Bicycle 10" || strings.Join(rows[3], " ") != "Automobile 70" {
+		t.Errorf("Expected the mix in the spot types table, got %v", rows)
+	}
+
+	c.expect(c.run("init", "1", "10", "10", "--mix", "1:1:2", "--inactive", "0.2"), "Inactive    20")
+	if inactive := c.GetParkingLot().GetSpotCountByType()["X-0"]; inactive != 20 {
+		t.Errorf("Expected 20 inactive spots, got %d", inactive)
+	}
+
+	invalid := [][]string{
+		{"1", "10", "10", "--mix", "10:20"},
+		{"1", "10", "10", "--mix", "0:20:80"},
+		{"1", "10", "10", "--inactive", "0.1"},
+		{"1", "10", "10", "--mix", "1:1:1", "--inactive", "1.5"},
+	}
+	for _, args := range invalid {
+		c.fail("init", args...)
+	}
+}
+
+func TestInitWithMap(t *testing.T) {
+	c := newTestCLI(t)
+
+	path := filepath.Join(t.TempDir(), "garage.txt")
+	if err := os.WriteFile(path, []byte("# ground\nBMAA\nBMXA\n---\nAAA\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write map file: %v", err)
+	}
+
+	c.expect(c.run("init", "--map", path, "--start-floor", "-1"),
+		"Created parking lot with 2 floors, 2 rows, and 4 columns", "Total spots: 11")
+
+	lot := c.GetParkingLot()
+	if lot.GetNumFloors() != 2 || lot.GetTotalSpotCount() != 11 {
+		t.Errorf("Expected 2 floors and 11 spots, got %d and %d", lot.GetNumFloors(), lot.GetTotalSpotCount())
+	}
+
+	spot, err := lot.GetSpotByID("B1-1-2")
+	if err != nil || spot.Type != "X-0" {
+		t.Errorf("Expected inactive spot at B1-1-2, got %v (%v)", spot, err)
 	}
 
-	// Test status command
-	err = registry.ExecuteCommand("status", []string{})
+	invalid := [][]string{
+		{"--map", filepath.Join(t.TempDir(), "missing.txt")},
+		{"--map", path, "--mix", "1:1:1"},
+		{"3", "2", "4", "--map", path},
+		{"-floors", "3", "--map", path},
+		{"--start-floor", "1"},
+	}
+	for _, args := range invalid {
+		c.fail("init", args...)
+	}
+}
+
+func TestInitWithTemplate(t *testing.T) {
+	c := newTestCLI(t)
+
+	output := c.run("templates")
+	c.expect(output, "Layout templates:")
+	var names []string
+	for _, row := range table(output)[1:] {
+		names = append(names, row[0])
+	}
+	if strings.Join(names, " ") != "mixed compact mall" {
+		t.Errorf("Expected the templates listed, got %v", names)
+	}
+
+	c.run("init", "3", "10", "20", "--template", "compact")
+	counts := c.GetParkingLot().GetSpotCountByType()
+	if counts["B-1"] <= counts["A-1"] {
+		t.Errorf("Expected compact template to favour bicycles, got %v", counts)
+	}
+
+	invalid := [][]string{
+		{"3", "10", "20", "--template", "stadium"},
+		{"3", "10", "20", "--template", "mall", "--mix", "1:1:1"},
+	}
+	for _, args := range invalid {
+		c.fail("init", args...)
+	}
+}
+
+func TestInitWithSeed(t *testing.T) {
+	c := newTestCLI(t)
+
+	// layoutOf initializes a lot and returns its spot map
+	layoutOf := func(args ...string) string {
+		t.Helper()
+		c.run("init", args...)
+		return fmt.Sprint(c.GetParkingLot().GetSpotLayout().SpotMap)
+	}
+
+	seeded := layoutOf("2", "6", "8", "--seed", "42")
+	c.expect(c.out.String(), "Layout seed: 42")
+	if layoutOf("2", "6", "8", "--seed", "42") != seeded {
+		t.Error("Expected the same seed to give the same layout")
+	}
+	if layoutOf("2", "6", "8", "--seed", "7") == seeded || layoutOf("2", "6", "8") == seeded {
+		t.Error("Expected another seed, or none, to give another layout")
+	}
+
+	mixed := layoutOf("2", "6", "8", "--mix", "1:1:2", "--inactive", "0.1", "--seed", "42")
+	if layoutOf("2", "6", "8", "--mix", "1:1:2", "--inactive", "0.1", "--seed", "42") != mixed {
+		t.Error("Expected the same seed and mix to give the same layout")
+	}
+
+	// A random seed is shown so the layout can be made again
+	c.expect(c.run("init", "2", "6", "8", "--seed", "random"), "Layout seed: ")
+
+	invalid := [][]string{
+		{"2", "6", "8", "--seed", "soon"},
+		{"2", "6", "8", "--template", "mall", "--seed", "42"},
+		{"--map", "lot.map", "--seed", "42"},
+	}
+	for _, args := range invalid {
+		c.fail("init", args...)
+	}
+}
+
+func TestInitWithPlateRule(t *testing.T) {
+	c := newTestCLI(t)
+
+	c.run("init", "1", "2", "2", "--plate-pattern", "[A-Z]{2}-[0-9]{2}-[A-Z]{1,3}-[0-9]{4}",
+		"--plate-length", "10:13", "--plate-spaces", "false")
+
+	c.expect(c.run("park", "automobile", "KA-01-HH-1234"), "Vehicle KA-01-HH-1234 parked successfully")
+	c.fail("park", "automobile", "ABC123")
+	if c.out.Len() != 0 {
+		t.Errorf("Expected nothing printed for a rejected plate, got %q", c.out.String())
+	}
+
+	invalid := [][]string{
+		{"1", "2", "2", "--plate-pattern", "[A-Z"},
+		{"1", "2", "2", "--plate-length", "10"},
+		{"1", "2", "2", "--plate-length", "13:10"},
+		{"1", "2", "2", "--plate-spaces", "sometimes"},
+		{"1", "2", "2", "--plate-separators", "loose"},
+		{"1", "2", "2", "--spot-ids", "roman"},
+	}
+	for _, args := range invalid {
+		c.fail("init", args...)
+	}
+}
+
+func TestInitIgnoringPlateSeparators(t *testing.T) {
+	c := newTestCLI(t)
+
+	c.run("init", "1", "4", "5", "--plate-separators", "ignore")
+	c.run("park", "automobile", "KA-01-HH-1234")
+
+	spotID, _, err := c.GetParkingLot().SearchVehicle("KA01HH1234")
 	if err != nil {
-		t.Errorf("Failed to execute status command: %v", err)
+		t.Fatalf("Expected the vehicle to be found without separators, got %v", err)
+	}
+	c.expect(c.run("search", "KA 01 HH 1234"), "is currently parked at spot "+spotID)
+	c.expect(c.run("unpark", spotID, "KA 01 HH 1234"), "successfully removed from spot "+spotID)
+}
+
+func TestLabelCommand(t *testing.T) {
+	c := newTestCLI(t)
+
+	c.fail("label", "0-0-0", "EV-7")
+
+	c.run("init", "1", "4", "5")
+	c.run("park", "automobile", "KA-01-HH-1234")
+	lot := c.GetParkingLot()
+	spotID, _, _ := lot.SearchVehicle("KA-01-HH-1234")
+
+	// Labels may span several words
+	c.expect(c.run("label", spotID, "near", "elevator", "A"), "Spot 0-0-2 is now labelled near elevator A")
+	if info, _ := lot.SearchVehicleDetailed("KA-01-HH-1234"); info.SpotLabel != "near elevator A" {
+		t.Errorf("Expected search to report the label, got %+v", info)
+	}
+
+	// A label in use elsewhere is refused
+	c.fail("label", "0-3-4", "Near", "Elevator", "A")
+
+	// Spots can be named by label wherever a spot ID is taken
+	c.expect(c.run("label", "@near elevator a", "EV-7"), "Spot 0-0-2 is now labelled EV-7")
+	c.run("spot-history", "@ev-7")
+	c.fail("unpark", "@EV-8", "KA-01-HH-1234")
+	c.expect(c.run("unpark", "@EV-7", "KA-01-HH-1234"), "Vehicle KA-01-HH-1234 successfully removed from spot 0-0-2")
+	if lot.IsVehicleParked("KA-01-HH-1234") {
+		t.Error("Expected the vehicle to be unparked")
+	}
+
+	// No text removes the label
+	c.run("label", "@EV-7")
+	if _, err := lot.FindSpotByLabel("EV-7"); err == nil {
+		t.Error("Expected the label to be gone")
+	}
+}
+
+func TestSetSpotCommand(t *testing.T) {
+	c := newTestCLI(t)
+
+	c.fail("set-spot", "0-0-0", "X-0")
+
+	c.run("init", "1", "1", "3")
+	c.expect(c.run("set-spot", "0-0-2", "X-0"), "Spot 0-0-2 is now Inactive Spot")
+	c.fail("park", "automobile", "CAR-1")
+
+	c.expect(c.run("set-spot", "0-0-2", "a-1"), "Spot 0-0-2 is now Automobile Spot")
+	c.expect(c.run("park", "automobile", "CAR-1"), "parked successfully at spot 0-0-2")
+
+	c.fail("set-spot", "0-0-2", "B-1")
+	c.fail("set-spot", "0-0-1", "Q-1")
+}
+
+func TestMapCommand(t *testing.T) {
+	c := newTestCLI(t)
+
+	c.fail("map")
+
+	c.run("init", "2", "3", "4")
+	c.run("park", "automobile", "MAP-1")
+
+	floor0 := "Floor 0\n  0 1 2 3\n0 X X a A\n1 B M A A\n2 B M A A\n"
+	floor1 := "Floor 1\n  0 1 2 3\n0 X X A A\n1 B M A A\n2 B M A A\n"
+	if got := c.run("map"); got != floor0+"\n"+floor1 {
+		t.Errorf("Unexpected map:\n%s", got)
+	}
+	if got := c.run("map", "1"); got != floor1 {
+		t.Errorf("Unexpected map of floor 1:\n%s", got)
+	}
+
+	// A narrow width splits the columns, and the legend follows
+	output := c.run("map", "0", "--legend", "--width", "5")
+	c.expect(output, "Columns 0-1:\n  0 1\n0 X X\n", "Columns 2-3:\n  2 3\n0 a A\n", "\n"+mapLegend+"\n")
+
+	var result struct {
+		Data api.MapResult `json:"data"`
 	}
+	if err := json.Unmarshal([]byte(c.run("map", "--json")), &result); err != nil || len(result.Data.Floors) != 2 {
+		t.Errorf("Expected both floors in the JSON map, got %q (%v)", c.out.String(), err)
+	} else if row := strings.Join(result.Data.Floors[0].Grid[0], ""); row != "XXaA" {
+		t.Errorf("Expected the occupied spot in the JSON grid, got %q", row)
+	}
+
+	invalid := [][]string{
+		{"5"},
+		{"0", "1"},
+		{"--width"},
+		{"--width", "0"},
+		{"--bogus"},
+	}
+	for _, args := range invalid {
+		c.fail("map", args...)
+	}
+}
+
+func TestStatusCommandFloors(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "2", "3", "5")
+	c.run("park", "automobile", "FLOOR-1")
+
+	c.expect(c.run("status"), "Parking Lot: 2 floors, 30 total spots, 26 active, 1 occupied, 25 available")
+
+	output := c.run("status", "--floor", "0")
+	c.expect(output, "Floor 0\n", "Vehicles parked on this floor: 1")
+	if rows := table(output); len(rows) != 2 || strings.Join(rows[1], " ") != "0 15 13 1 12 2 2 8" {
+		t.Errorf("Expected the counts of floor 0, got %v", rows)
+	}
+	c.expect(c.run("status", "--floor", "1"), "Floor 1\n", "No vehicles parked on this floor")
+
+	for _, args := range [][]string{{"--json"}, {"--floor", "1", "--json"}} {
+		if output := c.run("status", args...); !isJSON(output) {
+			t.Errorf("Expected status %v to print JSON, got %q", args, output)
+		}
+	}
+
+	invalid := [][]string{
+		{"--floor"},
+		{"--floor", "x"},
+		{"--floor", "7"},
+		{"--bogus", "1"},
+	}
+	for _, args := range invalid {
+		c.fail("status", args...)
+	}
+}
+
+func TestAvailableCommandFloors(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "2", "3", "5")
+
+	c.expect(c.run("available", "automobile"), "Floor 0 (9 available):", "Floor 1 (9 available):", "Total available: 18")
+
+	output := c.run("available", "automobile", "--floor", "1")
+	c.expect(output, "Floor 1 (9 available):", "Total available on floor 1: 9")
+	if strings.Contains(output, "Floor 0") {
+		t.Errorf("Expected only floor 1, got:\n%s", output)
+	}
+	if rows := table(output); len(rows) != 3 || rows[1][0] != "1-0-2" {
+		t.Errorf("Expected floor 1's spots from 1-0-2, got %v", rows)
+	}
+
+	if output := c.run("available", "bicycle", "--floor", "0", "--json"); !isJSON(output) {
+		t.Errorf("Expected JSON, got %q", output)
+	}
+
+	invalid := [][]string{
+		{"automobile", "--floor"},
+		{"automobile", "--floor", "x"},
+		{"automobile", "--floor", "4"},
+		{"automobile", "--bogus", "1"},
+	}
+	for _, args := range invalid {
+		c.fail("available", args...)
+	}
+}
+
+func TestAvailableCommandLimits(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "2", "3", "5")
+
+	if got := c.run("available", "automobile", "--count-only"); got != "Available spots for Automobile: 18\n" {
+		t.Errorf("Expected only the count, got %q", got)
+	}
+
+	var counted struct {
+		Data struct {
+			Count int `json:"count"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(c.run("available", "automobile", "--count-only", "--floor", "1", "--json")), &counted); err != nil || counted.Data.Count != 9 {
+		t.Errorf("Expected a count of 9 on floor 1, got %q (%v)", c.out.String(), err)
+	}
+
+	output := c.run("available", "automobile", "--limit", "3")
+	c.expect(output, "Floor 0 (3 available):", "Showing the first 3 available spots")
+	if rows := table(output); len(rows) != 2 || strings.Join(rows[1], " ") != "0-0-2 0-0-3 0-0-4" {
+		t.Errorf("Expected the first 3 spots, got %v", rows)
+	}
+	if strings.Contains(output, "Floor 1") {
+		t.Errorf("Expected the limit reached on floor 0, got:\n%s", output)
+	}
+
+	if output := c.run("available", "automobile", "--limit", "12", "--json"); !isJSON(output) {
+		t.Errorf("Expected JSON, got %q", output)
+	}
+	c.expect(c.run("available", "automobile", "--floor", "1", "--limit", "2"),
+		"Floor 1 (2 available):", "1-0-2   1-0-3", "Showing the first 2 available spots")
+
+	invalid := [][]string{
+		{"automobile", "--limit"},
+		{"automobile", "--limit", "0"},
+		{"automobile", "--limit", "x"},
+	}
+	for _, args := range invalid {
+		c.fail("available", args...)
+	}
+}
+
+func TestAvailableCommandNearest(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "2", "3", "5")
+
+	c.expect(c.run("set-entrance", "1", "2", "4"), "Entrance set to 1-2-4")
+
+	output := c.run("available", "automobile", "--nearest", "5")
+	c.expect(output, "Nearest spots for Automobile from the entrance at 1-2-4:")
+	var nearest []string
+	for _, row := range table(output)[1:] {
+		nearest = append(nearest, row[0]+"@"+row[1])
+	}
+	if strings.Join(nearest, " ") != "1-2-4@0 1-1-4@1 1-2-3@1 1-0-4@2 1-1-3@2" {
+		t.Errorf("Expected the spots nearest the entrance, got %v", nearest)
+	}
+
+	if output := c.run("set-entrance", "0", "0", "0", "--json"); !isJSON(output) {
+		t.Errorf("Expected JSON, got %q", output)
+	}
+	if output := c.run("available", "bicycle", "--nearest", "2", "--json"); !isJSON(output) {
+		t.Errorf("Expected JSON, got %q", output)
+	}
+
+	c.fail("set-entrance", "2", "0", "0")
+	c.fail("set-entrance", "0", "x", "0")
+	c.fail("available", "automobile", "--nearest")
+	c.fail("available", "automobile", "--nearest", "0")
+	c.fail("available", "automobile", "--nearest", "3", "--limit", "2")
+}
+
+func TestSpotLocationsJSON(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "2", "3", "5")
+	c.run("set-entrance", "1", "2", "4")
+	c.run("tag", "1-1-4", "ev")
+
+	// locations decodes the located spots of an available result
+	locations := func(args ...string) []api.LocatedSpotResult {
+		t.Helper()
+		var result struct {
+			Data api.AvailableResult `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(c.run("available", append(args, "--json")...)), &result); err != nil {
+			t.Fatalf("Expected JSON, got %q (%v)", c.out.String(), err)
+		}
+		located := result.Data.Locations
+		for _, nearest := range result.Data.Nearest {
+			located = append(located, api.LocatedSpotResult{SpotID: nearest.SpotID, Location: nearest.Location})
+		}
+		return located
+	}
+
+	for _, args := range [][]string{
+		{"automobile", "--limit", "12"},
+		{"automobile"},
+		{"automobile", "--floor", "1"},
+		{"automobile", "--require", "ev"},
+		{"automobile", "--nearest", "3"},
+	} {
+		located := locations(args...)
+		if len(located) == 0 {
+			t.Errorf("Expected spot locations for available %v", args)
+		}
+		for _, spot := range located {
+			floor, row, column, err := model.ParseSpotID(spot.SpotID)
+			if err != nil || spot.Location != (api.SpotLocation{Floor: floor, Row: row, Column: column}) {
+				t.Errorf("Expected the location of %s for available %v, got %+v", spot.SpotID, args, spot.Location)
+			}
+		}
+	}
+	if located := locations("automobile", "--limit", "12"); len(located) != 12 || located[9].SpotID != "1-0-2" {
+		t.Errorf("Expected 12 spots floor by floor, got %+v", located)
+	}
+
+	// An ID the lot cannot read back is an internal error, not spot 0-0-0
+	if _, err := spotLocation(c.GetParkingLot(), "bogus"); perrors.CodeOf(err) != perrors.CodeInternalError {
+		t.Errorf("Expected an internal error for an unreadable spot ID, got %v", err)
+	}
+}
+
+func TestVehiclesCommand(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "2", "3", "5")
+	c.run("park", "automobile", "KA-01-HH-1234")
+	c.run("park", "bicycle", "KA-01-HH-9999")
+
+	// vehicleRows returns the number, type and spot of each vehicle listed
+	vehicleRows := func(output string) string {
+		var vehicles []string
+		for _, row := range table(output)[1:] {
+			vehicles = append(vehicles, strings.Join(row[:3], " "))
+		}
+		return strings.Join(vehicles, ", ")
+	}
+
+	output := c.run("vehicles")
+	c.expect(output, "Parked vehicles: 2")
+	if got := vehicleRows(output); got != "KA-01-HH-1234 Automobile 0-0-2, KA-01-HH-9999 Bicycle 0-1-0" {
+		t.Errorf("Expected both vehicles listed, got %q", got)
+	}
+
+	output = c.run("vehicles", "--type", "automobile")
+	if got := vehicleRows(output); got != "KA-01-HH-1234 Automobile 0-0-2" {
+		t.Errorf("Expected only the automobile, got %q", got)
+	}
+	c.expect(c.run("vehicles", "--floor", "1"), "No parked vehicles match the filter")
+
+	var result struct {
+		Data []api.VehicleResult `json:"data"`
+	}
+	c.run("vehicles", "--type", "bicycle", "--floor", "0", "--json")
+	if err := json.Unmarshal(c.out.Bytes(), &result); err != nil {
+		t.Errorf("Expected JSON, got %q (%v)", c.out.String(), err)
+	}
+
+	invalid := [][]string{
+		{"--type"},
+		{"--type", "truck"},
+		{"--floor", "5"},
+		{"--floor", "x"},
+		{"--color", "red"},
+	}
+	for _, args := range invalid {
+		c.fail("vehicles", args...)
+	}
+}
+
+func TestSearchLikeCommand(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "2", "3", "5")
+	c.run("park", "automobile", "KA-01-HH-1234")
+	c.run("park", "bicycle", "MH-02-AB-1234")
+
+	// matches returns the vehicle numbers a search lists
+	matches := func(output string) string {
+		var numbers []string
+		for _, row := range table(output)[1:] {
+			numbers = append(numbers, row[0])
+		}
+		return strings.Join(numbers, " ")
+	}
+
+	if got := matches(c.run("search", "--like", "*1234")); got != "KA-01-HH-1234 MH-02-AB-1234" {
+		t.Errorf("Expected both vehicles to match, got %q", got)
+	}
+	if got := matches(c.run("search", "--like", "1234", "--limit", "1")); strings.Count(got, "-1234") != 1 {
+		t.Errorf("Expected a single match, got %q", got)
+	}
+	c.expect(c.run("search", "--like", "9999"), "No vehicles match 9999")
+	if output := c.run("search", "--like", "ka-*", "--json"); !isJSON(output) || !strings.Contains(output, "KA-01-HH-1234") {
+		t.Errorf("Expected the match as JSON, got %q", output)
+	}
+
+	invalid := [][]string{
+		{"--like"},
+		{"--like", "*"},
+		{"--like", "1234", "--limit"},
+		{"--like", "1234", "--limit", "0"},
+		{"--like", "1234", "--color", "red"},
+		{"KA-01-HH-1234", "extra"},
+	}
+	for _, args := range invalid {
+		c.fail("search", args...)
+	}
+}
+
+func TestSpotsCommand(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "2", "3", "5")
+	c.run("park", "automobile", "KA-01-HH-1234")
+
+	// spotIDs returns the spot IDs a listing holds
+	spotIDs := func(output string) string {
+		var ids []string
+		for _, row := range table(output)[1:] {
+			ids = append(ids, row[0])
+		}
+		return strings.Join(ids, " ")
+	}
+
+	output := c.run("spots")
+	if got := strings.Count(spotIDs(output), " ") + 1; got != 30 {
+		t.Errorf("Expected all 30 spots listed, got %d", got)
+	}
+
+	output = c.run("spots", "--floor", "0", "--type", "A-1", "--occupied")
+	if rows := table(output); len(rows) != 2 || strings.Join(rows[1][:4], " ") != "0-0-2 A-1 Yes KA-01-HH-1234" {
+		t.Errorf("Expected only the occupied spot, got %v", rows)
+	}
+	if got := spotIDs(c.run("spots", "--free", "--rows", "1-2", "--columns", "3")); got != "0-1-3 0-2-3 1-1-3 1-2-3" {
+		t.Errorf("Expected the free spots of column 3 in rows 1-2, got %q", got)
+	}
+
+	var paged struct {
+		Data api.SpotsResult `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(c.run("spots", "--limit", "5", "--offset", "10", "--json")), &paged); err != nil ||
+		len(paged.Data.Spots) != 5 || paged.Data.Spots[0].SpotID != "0-2-0" {
+		t.Errorf("Expected 5 spots from the 11th as JSON, got %q (%v)", c.out.String(), err)
+	}
+	c.expect(c.run("spots", "--limit", "5"), "Showing spots 1-5 of 30, use --offset 5 for more")
+
+	// Past the last spot there is nothing to show
+	if got := c.run("spots", "--offset", "100"); got != "No data to display\n" {
+		t.Errorf("Expected no spots past the end, got %q", got)
+	}
+
+	invalid := [][]string{
+		{"--floor", "7"},
+		{"--type", "Z-9"},
+		{"--occupied", "--free"},
+		{"--rows", "3-1"},
+		{"--columns", "a-b"},
+		{"--limit", "0"},
+		{"--offset", "-1"},
+		{"--limit"},
+		{"--sort"},
+	}
+	for _, args := range invalid {
+		c.fail("spots", args...)
+	}
+}
+
+func TestOccupancyCommand(t *testing.T) {
+	c := newTestCLI(t)
+
+	c.fail("occupancy")
+
+	c.run("init", "2", "3", "5")
+	c.run("park", "automobile", "KA-01-HH-1234")
+
+	output := c.run("occupancy")
+	c.expect(output, "Overall utilization: 3.8% [#...................] (1 of 26 active spots)",
+		"Floor 0  1         13      7.7%", "Floor 1  0         13      0.0%",
+		"Automobile Spot  1         18      5.6%")
+	if output := c.run("occupancy", "--json"); !isJSON(output) {
+		t.Errorf("Expected JSON, got %q", output)
+	}
+}
+
+func TestStatsCommandParking(t *testing.T) {
+	c := newTestCLI(t)
+
+	// Parking stats need a lot, command usage does not
+	c.fail("stats")
+	c.expect(c.run("stats", "--commands"), "Command usage:")
+
+	c.run("init", "1", "2", "5")
+
+	// No sessions yet
+	c.expect(c.run("stats"), "No parking sessions recorded yet")
+
+	c.run("park", "automobile", "KA-01-HH-1234")
+	spot, err := c.parkingLot.FindVehicle("KA-01-HH-1234")
+	if err != nil {
+		t.Fatalf("Failed to find vehicle: %v", err)
+	}
+	c.run("unpark", spot.GetSpotID(), "KA-01-HH-1234")
+
+	c.expect(c.run("stats"), "Parking statistics:", "Completed sessions    1", "Busiest spot          0-0-2 (1 session)")
+	if output := c.run("stats", "--json"); !isJSON(output) {
+		t.Errorf("Expected JSON, got %q", output)
+	}
+}
+
+func TestOperationStatsCommands(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "1", "2", "5")
+
+	c.run("park", "automobile", "KA-01-HH-0001")
+	c.fail("park", "automobile", "KA-01-HH-0001")
+	c.fail("park", "truck", "KA-01-HH-0002")
+	c.run("unpark", "0-0-2", "KA-01-HH-0001")
+	c.fail("unpark", "0-0-2", "KA-01-HH-0001")
+	c.run("search", "KA-01-HH-0001")
+	c.run("search", "--like", "KA-*")
+	c.run("reset", "--yes")
+
+	// counts returns the operation counts shown in the output
+	counts := func(output string) map[string]string {
+		rows := table(output[strings.Index(output, "Operations:"):])
+		counted := make(map[string]string)
+		for _, row := range rows[1:] {
+			counted[strings.Join(row[:len(row)-1], " ")] = row[len(row)-1]
+		}
+		return counted
+	}
+	expected := map[string]string{
+		"Parks": "1", "Unparks": "1", "Failed parks": "1", "VEHICLE_ALREADY_PARKED": "1",
+		"Searches": "2", "Resets": "1",
+	}
+	for _, name := range []string{"status", "stats"} {
+		args := map[string][]string{"status": {"--ops"}, "stats": nil}[name]
+		if got := counts(c.run(name, args...)); fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Errorf("Expected %s to show %v, got %v", name, expected, got)
+		}
+	}
+	if strings.Contains(c.run("status"), "Operations:") {
+		t.Error("Expected no operation counts without --ops")
+	}
+
+	var result struct {
+		Data api.ParkingStatsResult `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(c.run("stats", "--json")), &result); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if ops := result.Data.Operations; ops.Parks != 1 || ops.FailedParksByCode["VEHICLE_ALREADY_PARKED"] != 1 || ops.Resets != 1 {
+		t.Errorf("Unexpected operation counts: %+v", ops)
+	}
+
+	// A reset zeroing the counts leaves none
+	c.fail("reset", "--vehicles-only", "--zero-stats", "--yes")
+	c.expect(c.run("reset", "--zero-stats", "--yes"), "operation counts cleared")
+	c.expect(c.run("status", "--ops", "--json"), `"parks": 0`, `"resets": 0`)
+	c.fail("status", "--ops", "--floor", "0")
+}
+
+// setClock puts the active lot on a fake clock at 09:00 on 1 January 2024
+func (c *testCLI) setClock() *model.FakeClock {
+	clock := model.NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	c.GetParkingLot().SetClock(clock)
+	return clock
+}
+
+func TestHistoryCommand(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "1", "2", "5")
+	clock := c.setClock()
+	c.run("park", "automobile", "KA-01-HH-1234")
+	clock.Advance(5 * time.Minute)
+
+	output := c.run("history", "KA-01-HH-1234")
+	c.expect(output, "Parking history for KA-01-HH-1234:")
+	if rows := table(output); len(rows) != 2 || strings.Join(rows[1], " ") != "1 0-0-2 2024-01-01 09:00:00 Still Parked 5m Active" {
+		t.Errorf("Expected the open session, got %v", rows)
+	}
+	if output := c.run("history", "ka-01-hh-1234", "--json"); !isJSON(output) {
+		t.Errorf("Expected JSON, got %q", output)
+	}
+
+	// Unknown vehicles report not found instead of an empty table
+	var notFoundErr *perrors.VehicleNotFoundError
+	for _, args := range [][]string{{"KA-99-ZZ-0000"}, {"KA-99-ZZ-0000", "--json"}} {
+		if err := c.ExecuteCommand("history", args); !errors.As(err, &notFoundErr) {
+			t.Errorf("Expected vehicle not found for history %v, got %v", args, err)
+		}
+	}
+
+	// Verbose search renders the same history table
+	c.expect(c.run("search", "KA-01-HH-1234", "--verbose"), "Parking History:",
+		"1  0-0-2    2024-01-01 09:00:00  Still Parked")
+}
+
+func TestSpotHistoryCommand(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "1", "2", "5")
+	c.setClock()
+	c.run("park", "automobile", "KA-01-HH-1234")
+	spot, err := c.parkingLot.FindVehicle("KA-01-HH-1234")
+	if err != nil {
+		t.Fatalf("Failed to find vehicle: %v", err)
+	}
+	spotID := spot.GetSpotID()
+
+	output := c.run("spot-history", spotID)
+	c.expect(output, "Recent occupancies of spot 0-0-2, most recent first")
+	if rows := table(output); len(rows) != 2 || rows[1][0] != "KA-01-HH-1234" {
+		t.Errorf("Expected the vehicle in the spot's history, got %v", rows)
+	}
+	if output := c.run("spot-history", spotID, "--limit", "1", "--json"); !isJSON(output) {
+		t.Errorf("Expected JSON, got %q", output)
+	}
+	c.expect(c.run("spot-history", "0-1-4"), "No recorded occupancies for spot 0-1-4")
+
+	invalid := [][]string{
+		{"9-0-0"},
+		{"bogus"},
+		{spotID, "--limit", "0"},
+		{spotID, "--limit"},
+		{spotID, "--since", "1"},
+	}
+	for _, args := range invalid {
+		c.fail("spot-history", args...)
+	}
+}
+
+func TestPruneHistoryCommand(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "1", "2", "5")
+	c.setClock()
+
+	c.expect(c.run("prune-history", "30d"), "Removed 0 parking records that ended before 2023-12-02 09:00:00")
+	c.expect(c.run("prune-history", "12h"), "ended before 2023-12-31 21:00:00")
+	if output := c.run("prune-history", "90m", "--json"); !isJSON(output) {
+		t.Errorf("Expected JSON, got %q", output)
+	}
+
+	for _, args := range [][]string{{"0d"}, {"-1h"}, {"week"}, {"xd"}} {
+		c.fail("prune-history", args...)
+	}
+}
+
+func TestHistoryRangeCommand(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "1", "2", "5")
+	c.setClock()
+	c.run("park", "automobile", "KA-01-HH-1234")
+
+	output := c.run("history-range", "-2h", "now")
+	c.expect(output, "Vehicles parked between 2024-01-01 07:00:00 and 2024-01-01 09:00:00: 1")
+	if rows := table(output); len(rows) != 2 || strings.Join(rows[1][:3], " ") != "KA-01-HH-1234 Automobile 0-0-2" {
+		t.Errorf("Expected the session in the range, got %v", rows)
+	}
+	if output := c.run("history-range", "-1d", "+1h", "--json"); !isJSON(output) {
+		t.Errorf("Expected JSON, got %q", output)
+	}
+
+	// Times with offsets are shown in UTC
+	c.expect(c.run("history-range", "2023-12-31T18:00:00Z", "2023-12-31T20:00:00+01:00"),
+		"No vehicles parked between 2023-12-31 18:00:00 and 2023-12-31 19:00:00")
+
+	invalid := [][]string{
+		{"now", "-1h"},
+		{"yesterday", "now"},
+		{"-2h", "2024-13-01T00:00:00Z"},
+	}
+	for _, args := range invalid {
+		c.fail("history-range", args...)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		input    string
+		expected time.Time
+	}{
+		{"now", now},
+		{"-2h", now.Add(-2 * time.Hour)},
+		{"30m", now.Add(30 * time.Minute)},
+		{"-1d", now.Add(-24 * time.Hour)},
+		{"2024-01-01T18:00:00Z", time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		got, err := parseTime(tc.input, now)
+		if err != nil {
+			t.Errorf("parseTime(%q): unexpected error: %v", tc.input, err)
+			continue
+		}
+		if !got.Equal(tc.expected) {
+			t.Errorf("parseTime(%q): expected %v, got %v", tc.input, tc.expected, got)
+		}
+	}
+}
+
+func TestStatusParkedSince(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "2", "3", "5")
+	clock := c.setClock()
+	for _, number := range []string{"ZZ-01", "AA-01"} {
+		c.run("park", "automobile", number)
+		clock.Advance(90 * time.Second)
+	}
+
+	// Longest parked first by default
+	output := c.run("status")
+	c.expect(output, "Parked Since", "Duration", "2024-01-01 09:00:00", "3m", "1m30s")
+	if strings.Index(output, "ZZ-01") > strings.Index(output, "AA-01") {
+		t.Errorf("Expected ZZ-01, parked first, to be listed first, got:\n%s", output)
+	}
+
+	// Vehicle number order on request
+	for _, args := range [][]string{{"--by-number"}, {"--floor", "0", "--by-number"}} {
+		output = c.run("status", args...)
+		if strings.Index(output, "AA-01") > strings.Index(output, "ZZ-01") {
+			t.Errorf("Expected AA-01 to be listed first by number for %v, got:\n%s", args, output)
+		}
+	}
+	for _, args := range [][]string{{"--by-number", "--json"}, {"--by-number", "--floor", "0", "--json"}} {
+		output = c.run("status", args...)
+		if !isJSON(output) || strings.Index(output, "AA-01") > strings.Index(output, "ZZ-01") {
+			t.Errorf("Expected AA-01 first in the JSON for %v, got:\n%s", args, output)
+		}
+	}
+}
+
+func TestStatusPeakOccupancy(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "1", "3", "5")
+
+	c.expect(c.run("status"), "Peak occupancy: no vehicles parked yet")
+
+	for _, args := range [][]string{{"automobile", "KA-01"}, {"automobile", "KA-02"}, {"bicycle", "BI-01"}} {
+		c.run("park", args...)
+	}
+	spotID, _, _ := c.parkingLot.SearchVehicle("KA-01")
+	c.run("unpark", spotID, "KA-01")
+
+	c.expect(c.run("status"), "Peak occupancy: 3 vehicles at ", "(B=1, M=0, A=2)")
+
+	result := convertPeakOccupancy(c.parkingLot.GetPeakOccupancy())
+	if result.Overall.Count != 3 || result.Overall.At == "" {
+		t.Errorf("Expected overall peak of 3 with a time, got %+v", result.Overall)
+	}
+	if motorcycle := result.ByType["MOTORCYCLE"]; motorcycle.Count != 0 || motorcycle.At != "" {
+		t.Errorf("Expected empty motorcycle peak, got %+v", motorcycle)
+	}
+}
+
+func TestLongestCommand(t *testing.T) {
+	c := newTestCLI(t)
+
+	c.fail("longest")
+
+	c.run("init", "2", "3", "5")
+	clock := c.setClock()
+
+	// An empty lot is not an error
+	c.expect(c.run("longest"), "No vehicles currently parked")
+
+	for _, args := range [][]string{{"automobile", "KA-01-HH-1234"}, {"bicycle", "KA-01-HH-9999"}} {
+		c.run("park", args...)
+		clock.Advance(time.Hour)
+	}
+
+	// ranking returns the vehicle numbers listed, longest parked first
+	ranking := func(output string) string {
+		var numbers []string
+		for _, row := range table(output)[1:] {
+			numbers = append(numbers, row[1])
+		}
+		return strings.Join(numbers, " ")
+	}
+
+	output := c.run("longest")
+	c.expect(output, "Longest parked vehicles:", "2h")
+	if got := ranking(output); got != "KA-01-HH-1234 KA-01-HH-9999" {
+		t.Errorf("Expected the automobile first, got %q", got)
+	}
+	if got := ranking(c.run("longest", "1")); got != "KA-01-HH-1234" {
+		t.Errorf("Expected only the longest parked, got %q", got)
+	}
+	if got := ranking(c.run("longest", "5", "--type", "bicycle")); got != "KA-01-HH-9999" {
+		t.Errorf("Expected only the bicycle, got %q", got)
+	}
+	if output := c.run("longest", "--type", "automobile", "2", "--json"); !isJSON(output) {
+		t.Errorf("Expected JSON, got %q", output)
+	}
+
+	invalid := [][]string{
+		{"0"},
+		{"-3"},
+		{"x"},
+		{"--type"},
+		{"--type", "truck"},
+	}
+	for _, args := range invalid {
+		c.fail("longest", args...)
+	}
+}
+
+func TestTimeseriesCommand(t *testing.T) {
+	c := newTestCLI(t)
+
+	c.fail("timeseries")
+
+	c.run("init", "1", "3", "5")
+
+	c.expect(c.run("timeseries"), "No occupancy samples, use 'timeseries start <interval_seconds>' to start sampling")
+	if output := c.run("timeseries", "--json"); !isJSON(output) {
+		t.Errorf("Expected JSON, got %q", output)
+	}
+	c.expect(c.run("timeseries", "start", "60", "--capacity", "10"),
+		"Sampling occupancy every 1m0s, keeping the last 10 samples")
+	c.expect(c.run("timeseries"), "No occupancy samples yet")
+	c.expect(c.run("timeseries", "stop"), "Stopped sampling occupancy")
+	c.run("timeseries", "start", "3600")
+
+	invalid := [][]string{
+		{"start", "60"},
+		{"start"},
+		{"start", "0"},
+		{"start", "x"},
+		{"start", "60", "--capacity"},
+		{"start", "60", "--capacity", "0"},
+		{"start", "60", "--every", "2"},
+		{"pause"},
+	}
+	for _, args := range invalid {
+		c.fail("timeseries", args...)
+	}
+
+	// Re-initializing stops the sampler of the replaced lot
+	previous := c.GetParkingLot()
+	c.run("init", "1", "3", "5")
+	if previous.IsSampling() {
+		t.Error("Expected sampler of the replaced lot to be stopped")
+	}
+	c.fail("timeseries", "stop")
+}
+
+func TestTagCommands(t *testing.T) {
+	c := newTestCLI(t)
+
+	c.fail("tag", "0-0-0", "ev")
+
+	c.run("init", "1", "4", "5")
+	lot := c.GetParkingLot()
+
+	// Tag three automobile spots, then fill the first two
+	var evSpots []string
+	for _, info := range lot.ListSpots(model.SpotFilter{Type: model.SpotTypeAutomobile})[1:4] {
+		evSpots = append(evSpots, info.SpotID)
+		c.expect(c.run("tag", info.SpotID, "EV", "covered"), "Spot "+info.SpotID+" is tagged covered, ev")
+	}
+	for _, number := range []string{"KA-01-HH-0001", "KA-01-HH-0002"} {
+		c.run("park", "automobile", number, "--require", "ev")
+	}
+
+	// The third tagged spot is chosen
+	c.expect(c.run("park", "automobile", "KA-01-HH-0003", "--require", "ev"),
+		"Vehicle KA-01-HH-0003 parked successfully at spot "+evSpots[2])
+
+	// The requirement is never dropped when tagged spots run out
+	if err := c.fail("park", "automobile", "KA-01-HH-0004", "--require", "ev"); err == nil || !strings.Contains(err.Error(), "ev") {
+		t.Errorf("Expected a no space error naming ev, got %v", err)
+	}
+	if lot.IsVehicleParked("KA-01-HH-0004") {
+		t.Error("Expected the vehicle not to be parked")
+	}
+
+	c.expect(c.run("available", "automobile", "--require", "covered", "--count-only"),
+		"Available spots for Automobile tagged covered: 0")
+	c.expect(c.run("spots", "--tag", "ev", "--free"), "No spots")
+	if rows := table(c.run("tags")); len(rows) != 3 || strings.Join(rows[2], " ") != "ev 3 0" {
+		t.Errorf("Expected the ev tag on 3 spots, got %v", rows)
+	}
+
+	c.expect(c.run("untag", evSpots[0], "ev"), "Spot "+evSpots[0]+" is tagged covered")
+	c.fail("untag", evSpots[0], "ev")
+	if counts := lot.TagCounts(); len(counts) != 2 || counts[1] != (model.TagCount{Tag: "ev", Spots: 2, Available: 0}) {
+		t.Errorf("Unexpected tag counts %+v", counts)
+	}
+
+	for _, args := range [][]string{
+		{"park", "automobile", "KA-01-HH-0005", "--require"},
+		{"park", "automobile", "KA-01-HH-0005", "--prefer", "ev"},
+		{"available", "automobile", "--require", "no tags"},
+		{"available", "automobile", "--require", "ev", "--nearest", "2"},
+		{"spots", "--tag"},
+	} {
+		c.fail(args[0], args[1:]...)
+	}
+}
+
+func TestChargeStatusCommand(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "1", "4", "5")
+	lot := c.GetParkingLot()
+
+	automobileSpots := lot.ListSpots(model.SpotFilter{Type: model.SpotTypeAutomobile})
+	broken, working := automobileSpots[1].SpotID, automobileSpots[3].SpotID
+	for _, spotID := range []string{broken, working} {
+		c.run("tag", spotID, "ev")
+	}
+
+	c.fail("charge-status", automobileSpots[0].SpotID, "charging")
+	c.fail("charge-status", broken, "broken")
+	c.expect(c.run("charge-status", broken, "out-of-order"), "Charger of spot "+broken+" is now out of order")
+
+	// The working charger is preferred, then any spot
+	for _, expected := range []string{working, automobileSpots[0].SpotID} {
+		number := "KA-01-EV-" + strings.ReplaceAll(expected, "-", "")
+		c.expect(c.run("park", "automobile", number, "--ev"),
+			"Vehicle "+number+" parked successfully at spot "+expected)
+	}
+
+	c.expect(c.run("status"), "EV charging spots: 2 (1 available, 0 charging, 1 out of order)")
+
+	c.fail("park", "automobile", "KA-01-HH-0001", "--ev", "--require", "ev")
+}
+
+func TestAccessibleSpotCommands(t *testing.T) {
+	for _, mode := range []string{"strict", "overflow"} {
+		t.Run(mode, func(t *testing.T) {
+			c := newTestCLI(t)
+			c.run("init", "1", "2", "5", "--accessible", mode)
+			lot := c.GetParkingLot()
+
+			var spots []string
+			for _, info := range lot.ListSpots(model.SpotFilter{Type: model.SpotTypeAutomobile}) {
+				spots = append(spots, info.SpotID)
+			}
+			c.run("tag", spots[0], "accessible")
+
+			// Choosing the accessible spot needs a permit in either mode
+			err := c.fail("park-at", spots[0], "automobile", "KA-01-HH-0001")
+			if err == nil || !strings.Contains(err.Error(), perrors.CodePermitRequired) {
+				t.Errorf("Expected a %s error, got %v", perrors.CodePermitRequired, err)
+			}
+
+			// Fill every other spot, then see whether the accessible one overflows
+			for i, spotID := range spots[1:] {
+				c.run("park-at", spotID, "automobile", fmt.Sprintf("KA-01-HH-%04d", i+1))
+			}
+			if mode == "strict" {
+				c.fail("park", "automobile", "KA-01-HH-9999")
+			} else {
+				c.expect(c.run("park", "automobile", "KA-01-HH-9999"), "parked successfully at spot "+spots[0])
+			}
+		})
+	}
+
+	c := newTestCLI(t)
+	c.run("init", "1", "2", "5")
+	lot := c.GetParkingLot()
+	accessibleSpot := lot.ListSpots(model.SpotFilter{Type: model.SpotTypeAutomobile})[2].SpotID
+	c.run("tag", accessibleSpot, "accessible")
+
+	// A registered permit lets the vehicle go straight to the accessible spot
+	c.expect(c.run("permit", "KA-01-PH-0001"), "Vehicle KA-01-PH-0001 may now use accessible spots")
+	c.expect(c.run("park", "automobile", "KA-01-PH-0001"), "parked successfully at spot "+accessibleSpot)
+
+	c.expect(c.run("status"), "Accessible spots: 1 (0 available, permit holders only)")
+
+	c.expect(c.run("permit", "KA-01-PH-0001", "--revoke"), "Permit of vehicle KA-01-PH-0001 revoked")
+	c.fail("permit", "KA-01-PH-0001", "--revoke")
+	c.fail("init", "1", "2", "5", "--accessible", "sometimes")
+}
+
+func TestOversizedVehicleCommands(t *testing.T) {
+	c := newTestCLI(t)
+
+	// The only pair of adjacent automobile spots is 0-1-1 and 0-1-2
+	path := filepath.Join(t.TempDir(), "narrow.txt")
+	if err := os.WriteFile(path, []byte("AXA\nMAA\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write map file: %v", err)
+	}
+	c.run("init", "--map", path)
+	lot := c.GetParkingLot()
+
+	c.expect(c.run("park", "bus", "KA-01-MB-0007"), "parked successfully across spots 0-1-1, 0-1-2")
+	if spotID, parked, _ := lot.SearchVehicle("KA-01-MB-0007"); !parked || spotID != "0-1-1" {
+		t.Errorf("Expected the minibus at 0-1-1, got %q (parked %v)", spotID, parked)
+	}
+	c.fail("park", "minibus", "KA-02-MB-0008")
+
+	c.expect(c.run("status"), "Currently parked vehicles: 1\n")
+
+	c.run("unpark", "0-1-2", "KA-01-MB-0007")
+	if count := lot.GetOccupiedSpotCount(); count != 0 {
+		t.Errorf("Expected both spots freed, got %d occupied", count)
+	}
+}
+
+// registerVanType registers a van vehicle type with its own V-1 spots;
+// types stay registered for the rest of the test binary
+func registerVanType(t *testing.T) {
+	t.Helper()
+
+	if _, ok := model.LookupVehicleType("VAN"); ok {
+		return
+	}
+	if err := model.RegisterSpotType(model.SpotTypeInfo{Type: "V-1", Display: "Van Spot"}); err != nil {
+		t.Fatalf("Failed to register the van spot type: %v", err)
+	}
+	err := model.RegisterVehicleType(model.VehicleTypeInfo{Type: "VAN", Display: "Van",
+		SpotTypes: []model.SpotType{"V-1"}})
+	if err != nil {
+		t.Fatalf("Failed to register the van vehicle type: %v", err)
+	}
+}
+
+func TestRegisteredVehicleTypeCommands(t *testing.T) {
+	registerVanType(t)
+
+	c := newTestCLI(t)
+
+	path := filepath.Join(t.TempDir(), "vans.txt")
+	if err := os.WriteFile(path, []byte("AVA\nMVV\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write map file: %v", err)
+	}
+	c.run("init", "--map", path)
+	lot := c.GetParkingLot()
+
+	c.expect(c.run("available", "van"), "0-0-1", "0-1-1", "0-1-2")
+	c.expect(c.run("park", "van", "KA-01-VN-0001"), "at spot 0-0-1")
+	if count, err := lot.CountAvailableSpots("VAN"); err != nil || count != 2 {
+		t.Errorf("Expected 2 van spots left, got %d (%v)", count, err)
+	}
+	if err := c.fail("park", "truck", "KA-02-TR-0002"); err == nil || !strings.Contains(err.Error(), "van") {
+		t.Errorf("Expected an unknown type error listing van, got %v", err)
+	}
+
+	output := c.run("status")
+	rows := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		rows[strings.Join(strings.Fields(line), " ")] = true
+	}
+	// Rows of the spot type, available spot and capacity tables
+	for _, want := range []string{"Van 3", "Van 2", "Van 1 2"} {
+		if !rows[want] {
+			t.Errorf("Expected status to have a row %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestRestrictFloorCommand(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "2", "2", "5")
+	lot := c.GetParkingLot()
+
+	c.expect(c.run("restrict-floor", "0", "bicycle", "motorcycle"), "Floor 0 restricted to Bicycle, Motorcycle")
+	c.expect(c.run("park", "automobile", "KA-01-HH-1234"), "parked successfully at spot 1-")
+	if spotID, _, _ := lot.SearchVehicle("KA-01-HH-1234"); !strings.HasPrefix(spotID, "1-") {
+		t.Errorf("Expected the automobile on floor 1, got %s", spotID)
+	}
+
+	c.expect(c.run("status"), "0 (Bicycle, Motorcycle only)")
+
+	for _, args := range [][]string{{"0", "truck"}, {"x", "bicycle"}, {"9", "bicycle"}} {
+		c.fail("restrict-floor", args...)
+	}
+
+	c.expect(c.run("restrict-floor", "0", "--clear"), "Floor 0 accepts every vehicle type")
+	if floor, _ := lot.GetFloor(0); floor.AllowedVehicleTypes() != nil {
+		t.Errorf("Expected floor 0 unrestricted, got %v", floor.AllowedVehicleTypes())
+	}
+}
+
+func TestQuotaCommand(t *testing.T) {
+	c := newTestCLI(t)
+
+	path := filepath.Join(t.TempDir(), "cars.txt")
+	if err := os.WriteFile(path, []byte("AAAA\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write map file: %v", err)
+	}
+	c.run("init", "--map", path)
+
+	c.expect(c.run("quota", "car", "1"), "Quota of Automobile set to 1 general parks")
+	c.run("park", "automobile", "KA-01-HH-0001")
+	if err := c.fail("park", "automobile", "KA-01-HH-0002"); err == nil || !strings.Contains(err.Error(), "Quota of 1 general parks") {
+		t.Errorf("Expected the quota to refuse a second automobile, got %v", err)
+	}
+	c.run("park", "automobile", "KA-02-RS-0001", "--reserved")
+	c.expect(c.run("quota", "--exempt", "KA-02-RS-0002"), "Vehicle KA-02-RS-0002 is exempt from quotas")
+	c.run("park-at", "0-0-3", "automobile", "KA-02-RS-0002")
+
+	c.expect(c.run("status"), "Quotas (general parks/quota): Automobile 1/1\n")
+
+	for _, args := range [][]string{{"truck", "1"}, {"car", "many"}, {"car", "-1"}, {"car"}, {"--exempt"}} {
+		c.fail("quota", args...)
+	}
+
+	c.expect(c.run("quota", "car", "off"), "Quota of Automobile cleared")
+	c.run("park", "automobile", "KA-01-HH-0002")
+}
+
+func TestMultipleLots(t *testing.T) {
+	c := newTestCLI(t)
+
+	for _, name := range []string{"north", "south"} {
+		c.expect(c.run("init", name, "1", "1", "5"), "Active lot: "+name)
+	}
+	if c.GetParkingLot().GetName() != "south" {
+		t.Errorf("Expected the last lot initialized to be active, got %s", c.GetParkingLot().GetName())
+	}
+
+	// The same vehicle parks in each lot, the second time through --lot
+	c.run("park", "automobile", "KA-01-HH-1234")
+	c.run("park", "automobile", "KA-01-HH-1234", "--lot", "north")
+	c.run("park", "automobile", "KA-02-HH-5678")
+	if c.GetParkingLot().GetName() != "south" {
+		t.Errorf("Expected --lot to leave south active, got %s", c.GetParkingLot().GetName())
+	}
+
+	// Each lot holds only its own vehicles
+	north, south := c.lots["north"], c.lots["south"]
+	if north.GetOccupiedSpotCount() != 1 || south.GetOccupiedSpotCount() != 2 {
+		t.Errorf("Expected 1 vehicle in north and 2 in south, got %d and %d",
+			north.GetOccupiedSpotCount(), south.GetOccupiedSpotCount())
+	}
+	c.expect(c.run("use", "north"), "Active lot: north")
+	c.fail("search", "KA-02-HH-5678")
+
+	found, err := c.searchAllLots("KA-01-HH-1234")
+	if err != nil || len(found) != 2 || found[0].lot != "north" || found[1].lot != "south" {
+		t.Fatalf("Expected the vehicle found in north and south, got %v (%v)", found, err)
+	}
+	for _, f := range found {
+		if !f.info.IsParked {
+			t.Errorf("Expected the vehicle parked in %s", f.lot)
+		}
+	}
+	c.expect(c.run("search", "KA-01-HH-1234", "--all-lots"),
+		"Lot north: vehicle KA-01-HH-1234 is currently parked at spot ",
+		"Lot south: vehicle KA-01-HH-1234 is currently parked at spot ")
+	if found, _ := c.searchAllLots("KA-02-HH-5678"); len(found) != 1 || found[0].lot != "south" {
+		t.Errorf("Expected the second vehicle found in south only, got %v", found)
+	}
+	c.fail("search", "KA-09-ZZ-0000", "--all-lots")
+	c.expect(c.out.String(), "Vehicle KA-09-ZZ-0000 not found in any lot")
+
+	summaries := []api.LotSummaryResult{c.lotSummary("north"), c.lotSummary("south")}
+	lotsTable := formatLotsTable(summaries)
+	if !strings.Contains(lotsTable, "north *") || strings.Contains(lotsTable, "south *") {
+		t.Errorf("Expected north marked active, got:\n%s", lotsTable)
+	}
+	if summaries[1].OccupiedSpots != 2 || summaries[1].AvailableSpots != 3 {
+		t.Errorf("Expected south to show 2 occupied and 3 available, got %+v", summaries[1])
+	}
+	if rows := table(c.run("lots")); len(rows) != 3 || strings.Join(rows[2], " ") != "south 1 5 2 3" {
+		t.Errorf("Expected south listed with 2 occupied spots, got %v", rows)
+	}
+
+	for _, args := range [][]string{{"status", "--lot", "west"}, {"use", "west"}} {
+		c.fail(args[0], args[1:]...)
+	}
+	c.fail("use", "south", "--lot", "north")
+
+	// An unnamed init keeps its old behaviour under the default lot
+	c.run("init", "1", "1", "2")
+	if c.activeLot != defaultLotName || len(c.lots) != 3 {
+		t.Errorf("Expected a third, default lot active, got %s of %v", c.activeLot, c.lotNames())
+	}
+}
+
+func TestDescribeCommand(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "1", "1", "5")
+	lot := c.GetParkingLot()
+	c.setClock()
+	c.run("park", "automobile", "KA-01-HH-1234")
+	records, err := lot.GetVehicleRecords("KA-01-HH-1234")
+	if err != nil {
+		t.Fatalf("Failed to get records: %v", err)
+	}
+
+	// The same park is shown in the zone of each description
+	zones := []struct {
+		timezone string
+		parkedAt string
+	}{
+		{"Asia/Kolkata", "2024-01-01 14:30:00"},
+		{"America/New_York", "2024-01-01 04:00:00"},
+	}
+	for _, zone := range zones {
+		c.expect(c.run("describe", "--timezone", zone.timezone), "Timezone: "+zone.timezone)
+		recordsTable := formatParkingRecordsTable(records, c.now(), c.location())
+		if !strings.Contains(recordsTable, zone.parkedAt) {
+			t.Errorf("Expected the park shown at %s in %s, got:\n%s", zone.parkedAt, zone.timezone, recordsTable)
+		}
+		c.expect(c.run("history", "KA-01-HH-1234"), zone.parkedAt)
+	}
+	if !records[0].ParkedAt.Equal(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the record kept in UTC, got %v", records[0].ParkedAt)
+	}
+
+	c.fail("describe", "--timezone", "Mars/Olympus_Mons")
+	c.expect(c.run("describe", "--address", "1 Main Street", "--notes", "Gate B closed at night"),
+		"Address: 1 Main Street")
+
+	output := c.run("status")
+	c.expect(output, "Address: 1 Main Street\n", "Timezone: America/New_York\n", "Notes: Gate B closed at night\n")
+	if metadata := convertLotMetadata(c.collectStatus().metadata); metadata == nil || metadata.Timezone != "America/New_York" {
+		t.Errorf("Expected the status JSON to carry the metadata, got %+v", metadata)
+	}
+
+	for _, args := range [][]string{{"--timezone"}, {"--zone", "UTC"}} {
+		c.fail("describe", args...)
+	}
+}
+
+func TestRenameCommand(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "1", "1", "5", "--name", "Orion Mall P2")
+	c.expect(c.run("status"), "Orion Mall P2: 1 floors")
+
+	// Several words make one name
+	c.expect(c.run("rename", "Orion", "Mall", "P3"), "Renamed lot Orion Mall P2 to Orion Mall P3")
+	c.expect(c.run("status"), "Orion Mall P3: 1 floors")
+	if c.GetParkingLot().GetName() != "Orion Mall P3" {
+		t.Errorf("Expected the lot renamed, got %s", c.GetParkingLot().GetName())
+	}
+
+	// A renamed lot keeps the name use and --lot know it by
+	c.expect(c.run("status", "--lot", defaultLotName), "Orion Mall P3: 1 floors")
+
+	c.fail("rename", " ")
+	c.fail("init", "1", "1", "5", "--name", "")
+
+	// Status can be read while the lot is renamed
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			_ = c.GetParkingLot().SetName(fmt.Sprintf("Orion Mall P%d", i))
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		c.expect(c.run("status"), "Orion Mall P")
+	}
+	<-done
+}
+
+func TestUndoCommand(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "2", "2", "5")
+	lot := c.GetParkingLot()
+	c.fail("undo")
+
+	// Undoing a park removes the vehicle
+	c.run("park", "automobile", "KA-01-HH-0001")
+	parked, _ := lot.GetParkedVehicle("KA-01-HH-0001")
+	c.expect(c.run("undo"), "Undid park: vehicle KA-01-HH-0001 removed from spot "+parked.SpotID)
+	if lot.IsVehicleParked("KA-01-HH-0001") || lot.GetOccupiedSpotCount() != 0 {
+		t.Errorf("Expected the park undone, got %s", lot.String())
+	}
+
+	// Undoing an unpark parks the vehicle again in its old spot
+	c.run("park-at", parked.SpotID, "automobile", "KA-01-HH-0001")
+	c.run("unpark", parked.SpotID, "KA-01-HH-0001")
+	c.expect(c.run("undo"), "Undid unpark")
+	if again, found := lot.GetParkedVehicle("KA-01-HH-0001"); !found || again.SpotID != parked.SpotID {
+		t.Errorf("Expected KA-01-HH-0001 back at %s, got %+v", parked.SpotID, again)
+	}
+
+	// An unpark whose spot has since been taken stays in the journal
+	c.run("unpark", parked.SpotID, "KA-01-HH-0001")
+	if _, err := lot.ParkAt(parked.SpotID, model.VehicleTypeAutomobile, "KA-02-HH-0002"); err != nil {
+		t.Fatalf("Failed to take the spot: %v", err)
+	}
+	if err := c.fail("undo"); err == nil || !strings.Contains(err.Error(), "cannot undo unpark of KA-01-HH-0001") {
+		t.Errorf("Expected the undo refused while the spot is taken, got %v", err)
+	}
+	if lot.IsVehicleParked("KA-01-HH-0001") {
+		t.Error("Expected the refused undo to leave KA-01-HH-0001 unparked")
+	}
+	if err := lot.Unpark(parked.SpotID, "KA-02-HH-0002"); err != nil {
+		t.Fatalf("Failed to free the spot: %v", err)
+	}
+	c.run("undo")
+
+	// The journal only goes back so far
+	c.run("reset", "--yes")
+	for i := 0; i <= journalLimit; i++ {
+		c.run("park", "automobile", "KA-03-HH-0003")
+		c.run("unpark", parked.SpotID, "KA-03-HH-0003")
+	}
+	if size := len(c.journals[lot].entries); size != journalLimit {
+		t.Errorf("Expected the journal capped at %d entries, got %d", journalLimit, size)
+	}
+}
+
+func TestResetCommand(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "2", "2", "5")
+	park := func(numbers ...string) {
+		t.Helper()
+		for _, number := range numbers {
+			c.run("park", "automobile", number)
+		}
+	}
+	lot := c.GetParkingLot()
+	park("KA-01-HH-0001", "KA-01-HH-0002")
+
+	// Nothing is cleared without confirmation
+	for _, args := range [][]string{{}, {"--vehicles-only"}, {"--history-only"}} {
+		c.fail("reset", args...)
+	}
+	c.fail("reset", "--vehicles-only", "--history-only")
+	if lot.GetParkedVehicleCount() != 2 {
+		t.Fatalf("Expected the refused resets to keep both vehicles, got %d", lot.GetParkedVehicleCount())
+	}
+
+	// --vehicles-only empties the lot but keeps the records
+	c.expect(c.run("reset", "--vehicles-only", "--yes"), "Removed 2 parked vehicles, history kept")
+	if lot.GetParkedVehicleCount() != 0 || lot.GetOccupiedSpotCount() != 0 {
+		t.Errorf("Expected no vehicles left, got %s", lot.String())
+	}
+	if records, err := lot.GetVehicleRecords("KA-01-HH-0001"); err != nil || len(records) != 1 {
+		t.Errorf("Expected the history kept, got %v (%v)", records, err)
+	}
+
+	// --history-only drops the completed records and keeps parked vehicles
+	park("KA-01-HH-0003")
+	c.expect(c.run("reset", "--history-only", "--yes"), "Removed 2 completed parking records, parked vehicles kept")
+	if _, err := lot.GetVehicleRecords("KA-01-HH-0001"); err == nil {
+		t.Error("Expected the completed history dropped")
+	}
+	if !lot.IsVehicleParked("KA-01-HH-0003") {
+		t.Error("Expected KA-01-HH-0003 to stay parked")
+	}
+
+	// A full reset clears both
+	c.expect(c.run("reset", "--yes"), "Removed 1 parked vehicles and 1 parking records",
+		"Peak occupancy and quota usage cleared")
+	if lot.GetParkedVehicleCount() != 0 {
+		t.Errorf("Expected no vehicles after a full reset, got %d", lot.GetParkedVehicleCount())
+	}
+	if _, err := lot.GetVehicleRecords("KA-01-HH-0003"); err == nil {
+		t.Error("Expected a full reset to drop all history")
+	}
+	if peak := lot.GetPeakOccupancy().Overall.Count; peak != 0 {
+		t.Errorf("Expected a full reset to clear the peak, got %d", peak)
+	}
+}
+
+func TestCloneCommand(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "production", "2", "2", "5")
+	c.run("park", "automobile", "KA-01-HH-1234")
+	c.expect(c.run("clone", "scratch"), "Cloned the layout of production into lot scratch")
+
+	// The clone is added empty, leaving production active
+	production, scratch := c.lots["production"], c.lots["scratch"]
+	if c.GetParkingLot() != production {
+		t.Error("Expected production to stay active")
+	}
+	if scratch.GetOccupiedSpotCount() != 0 || scratch.GetTotalSpotCount() != production.GetTotalSpotCount() {
+		t.Errorf("Expected an empty clone of %d spots, got %s", production.GetTotalSpotCount(), scratch.String())
+	}
+	c.run("park", "automobile", "KA-02-HH-5678", "--lot", "scratch")
+	if production.IsVehicleParked("KA-02-HH-5678") {
+		t.Error("Expected a park in the clone not to reach production")
+	}
+
+	for _, name := range []string{"production", "--scratch"} {
+		c.fail("clone", name)
+	}
+}
+
+func TestDiffCommand(t *testing.T) {
+	c := newTestCLI(t)
+
+	mapPath := filepath.Join(t.TempDir(), "lot.txt")
+	if err := os.WriteFile(mapPath, []byte("AAA\n---\nMA\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write map file: %v", err)
+	}
+	c.run("init", "--map", mapPath)
+	c.run("park-at", "0-0-0", "automobile", "KA-01-HH-0001")
+	snapshotPath := filepath.Join(t.TempDir(), "before.json")
+	c.expect(c.run("save", snapshotPath), "Saved 2 floors and 1 parked vehicles to "+snapshotPath)
+
+	diff, _, err := c.diffSnapshot(snapshotPath)
+	if err != nil || !diff.IsEmpty() {
+		t.Fatalf("Expected no differences right after saving, got %+v (%v)", diff, err)
+	}
+
+	commands := [][]string{
+		{"set-spot", "0-0-2", "X-0"},
+		{"unpark", "0-0-0", "KA-01-HH-0001"},
+		{"park-at", "1-0-0", "motorcycle", "KA-02-MC-0002"},
+	}
+	for _, args := range commands {
+		c.run(args[0], args[1:]...)
+	}
+
+	diff, _, err = c.diffSnapshot(snapshotPath)
+	if err != nil {
+		t.Fatalf("Failed to diff: %v", err)
+	}
+	text := formatLotDiff(diff, "then")
+	for _, want := range []string{
+		"Floor 0:", "0-0-0  A-1 KA-01-HH-0001  A-1 free", "0-0-2  A-1 free", "X-0 inactive",
+		"Floor 1:", "1-0-0  M-1 free", "M-1 KA-02-MC-0002",
+		"Only in the snapshot: KA-01-HH-0001", "Only in the current lot: KA-02-MC-0002",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected the diff to contain %q, got:\n%s", want, text)
+		}
+	}
+	c.expect(c.run("diff", snapshotPath), "Differences from the snapshot taken ",
+		"Only in the snapshot: KA-01-HH-0001", "Only in the current lot: KA-02-MC-0002")
+
+	result := convertLotDiff(snapshotPath, time.Now(), diff)
+	if len(result.Floors) != 2 || len(result.Floors[0].Spots) != 2 || len(result.Floors[1].Spots) != 1 {
+		t.Errorf("Expected the JSON grouped as two spots on floor 0 and one on floor 1, got %+v", result.Floors)
+	}
+
+	c.fail("diff", filepath.Join(t.TempDir(), "missing.json"))
+}
+
+func TestJSONErrors(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "1", "1", "1")
+
+	tests := []struct {
+		args    []string
+		code    string
+		details map[string]interface{}
+	}{
+		{[]string{"automobile", "KA-01-HH-1234", "--json"}, perrors.CodeNoSpaceAvailable,
+			map[string]interface{}{"vehicleType": "AUTOMOBILE"}},
+		{[]string{"bicycle", "", "--json"}, perrors.CodeInvalidVehicleNumber,
+			map[string]interface{}{"field": "vehicleNumber"}},
+	}
+
+	for _, test := range tests {
+		err := c.fail("park", test.args...)
+		if err == nil || !IsReported(err) {
+			t.Errorf("Expected park %q to fail with the error reported, got %v", test.args, err)
+		}
+
+		output := c.out.String()
+		var result api.JSONResult
+		if jsonErr := json.Unmarshal([]byte(output), &result); jsonErr != nil {
+			t.Fatalf("Expected a JSON result, got %q", output)
+		}
+		if result.Success || result.Command != "park" || result.Error == nil {
+			t.Fatalf("Expected a failed park result, got %q", output)
+		}
+		if result.Error.Code != test.code || result.Error.Message != err.Error() {
+			t.Errorf("Expected code %s and the error message, got %+v", test.code, result.Error)
+		}
+		if fmt.Sprint(result.Error.Details) != fmt.Sprint(test.details) {
+			t.Errorf("Expected details %v, got %v", test.details, result.Error.Details)
+		}
+	}
+
+	// Without --json the error is left to the caller to print
+	if err := c.fail("park", "automobile", "KA-01-HH-1234"); err == nil || IsReported(err) {
+		t.Errorf("Expected an unreported error, got %v", err)
+	}
+	if output := c.out.String(); output != "" {
+		t.Errorf("Expected nothing printed, got %q", output)
+	}
+}
+
+func TestJSONUsageErrors(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "1", "1", "1")
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"park", []string{"automobile", "--json"}},
+		{"available", []string{"bicycle", "--limit", "abc", "--json"}},
+		{"available", []string{"bicycle", "--format", "json", "--limit", "abc"}},
+		{"vehicles", []string{"--json", "--output"}},
+		{"vehicles", []string{"--bogus", "--json"}},
+		{"unknown", []string{"--json"}},
+	}
+
+	for _, test := range tests {
+		err := c.fail(test.name, test.args...)
+		if err == nil || !IsReported(err) || ExitCode(err) != ExitUsage {
+			t.Errorf("Expected %s %q to fail with a reported usage error, got %v", test.name, test.args, err)
+			continue
+		}
+
+		output := c.out.String()
+		var result api.JSONResult
+		if jsonErr := json.Unmarshal([]byte(output), &result); jsonErr != nil {
+			t.Fatalf("Expected a JSON result for %s %q, got %q", test.name, test.args, output)
+		}
+		if result.Success || result.Command != test.name || result.Error == nil {
+			t.Fatalf("Expected a failed %s result, got %q", test.name, output)
+		}
+		if result.Error.Code != perrors.CodeInvalidInput || result.Error.Message != err.Error() {
+			t.Errorf("Expected code %s and the error message, got %+v", perrors.CodeInvalidInput, result.Error)
+		}
+	}
+
+	// A flag error without --json is left to the caller to print
+	if err := c.fail("available", "bicycle", "--limit", "abc"); err == nil || IsReported(err) {
+		t.Errorf("Expected an unreported error, got %v", err)
+	}
+}
+
+// decodeNDJSON decodes NDJSON output line by line, returning each record
+func decodeNDJSON(t *testing.T, output string) []map[string]interface{} {
+	t.Helper()
+
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		var record map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Expected a JSON object on each line, got %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestNDJSONOutput(t *testing.T) {
+	c := newTestCLI(t)
+	c.run("init", "3", "10", "20")
+	for i := 0; i < 5; i++ {
+		c.run("park", "automobile", fmt.Sprintf("KA-01-HH-%04d", i))
+	}
+	lot := c.GetParkingLot()
+
+	// run runs a command and decodes its NDJSON output
+	run := func(name string, args ...string) []map[string]interface{} {
+		t.Helper()
+		return decodeNDJSON(t, c.run(name, args...))
+	}
+
+	available, _ := lot.CountAvailableSpots(model.VehicleTypeAutomobile)
+	records := run("available", "automobile", "--ndjson")
+	if len(records) != available {
+		t.Errorf("Expected %d available spots, got %d records", available, len(records))
+	}
+	if records[0]["spotId"] == "" || records[0]["vehicleType"] != "AUTOMOBILE" || records[0]["location"] == nil {
+		t.Errorf("Expected a spot record, got %v", records[0])
+	}
+	if records := run("available", "automobile", "--ndjson", "--floor", "1", "--limit", "7"); len(records) != 7 {
+		t.Errorf("Expected the limit to stop the stream at 7, got %d", len(records))
+	}
+
+	// The stream runs past the page size spots stops at
+	if records := run("spots", "--ndjson"); len(records) != lot.GetTotalSpotCount() {
+		t.Errorf("Expected all %d spots, got %d records", lot.GetTotalSpotCount(), len(records))
+	}
+	if records := run("spots", "--occupied", "--ndjson", "--offset", "1"); len(records) != 4 {
+		t.Errorf("Expected the occupied spots after the first, got %d records", len(records))
+	}
+
+	if records := run("history-range", "-1h", "now", "--ndjson"); len(records) != 5 {
+		t.Errorf("Expected 5 sessions, got %d records", len(records))
+	}
+
+	c.fail("available", "automobile", "--ndjson", "--count-only")
 }
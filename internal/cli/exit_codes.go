@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	perrors "github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// Exit statuses of a command run on its own, by what its error was about
+const (
+	ExitOK       = 0
+	ExitFailure  = 1
+	ExitUsage    = 2
+	ExitNotFound = 3
+	ExitNoSpace  = 4
+	ExitConflict = 5
+	ExitRefused  = 6
+	ExitInternal = 10
+)
+
+// exitCodes maps the codes of errors to exit statuses
+var exitCodes = map[string]int{
+	perrors.CodeLotNotInitialized:    ExitFailure,
+	perrors.CodeCommandFailed:        ExitFailure,
+	perrors.CodeInvalidInput:         ExitUsage,
+	perrors.CodeInvalidSpotID:        ExitUsage,
+	perrors.CodeInvalidVehicleType:   ExitUsage,
+	perrors.CodeInvalidVehicleNumber: ExitUsage,
+	perrors.CodeInvalidSpotType:      ExitUsage,
+	perrors.CodeInvalidFloor:         ExitUsage,
+	perrors.CodeVehicleNotFound:      ExitNotFound,
+	perrors.CodeSpotNotOccupied:      ExitNotFound,
+	perrors.CodeNoSpaceAvailable:     ExitNoSpace,
+	perrors.CodeVehicleAlreadyParked: ExitConflict,
+	perrors.CodeVehicleNumberClash:   ExitConflict,
+	perrors.CodeSpotAlreadyOccupied:  ExitConflict,
+	perrors.CodeVehicleMismatch:      ExitConflict,
+	perrors.CodeInvalidOperation:     ExitRefused,
+	perrors.CodeSpotInactive:         ExitRefused,
+	perrors.CodePermitRequired:       ExitRefused,
+	perrors.CodeFloorRestricted:      ExitRefused,
+	perrors.CodeQuotaExceeded:        ExitRefused,
+	perrors.CodeInternalError:        ExitInternal,
+}
+
+// exitCodeHelp describes each exit status, in order, for help exit-codes
+var exitCodeHelp = []struct {
+	code        int
+	description string
+}{
+	{ExitOK, "the command succeeded"},
+	{ExitFailure, "the command failed for another reason, such as no lot initialized, or script commands failed with --continue-on-error"},
+	{ExitUsage, "wrong arguments or flags, or an invalid value such as a spot ID or vehicle number"},
+	{ExitNotFound, "the vehicle, or a vehicle at the spot, was not found"},
+	{ExitNoSpace, "no spot had room for the vehicle"},
+	{ExitConflict, "the vehicle is already parked, or the spot is taken by another vehicle"},
+	{ExitRefused, "the lot's rules refused the operation: an inactive spot, a permit, a floor restriction or a quota"},
+	{ExitInternal, "an internal error"},
+}
+
+// UsageError is returned for a command given the wrong arguments or flags
+type UsageError struct {
+	message string
+}
+
+// Error returns the message of the error, with the usage of the command
+func (e *UsageError) Error() string {
+	return e.message
+}
+
+// newUsageError creates a UsageError with a formatted message
+func newUsageError(format string, args ...interface{}) error {
+	return &UsageError{message: fmt.Sprintf(format, args...)}
+}
+
+// ErrorCode returns the code of the error a command returned, which its
+// exit status and its JSON output both go by. A usage error is invalid
+// input; otherwise the first parking error in its chain whose code has an
+// exit status decides it, a chain of parking errors with none being
+// internal and an error of no parking code at all a failure of the command.
+func ErrorCode(err error) string {
+	var usageErr *UsageError
+	if errors.As(err, &usageErr) {
+		return perrors.CodeInvalidInput
+	}
+
+	codes := perrors.CodesOf(err)
+	for _, code := range codes {
+		if _, found := exitCodes[code]; found {
+			return code
+		}
+	}
+	if len(codes) > 0 {
+		return perrors.CodeInternalError
+	}
+	return perrors.CodeCommandFailed
+}
+
+// ExitCode returns the exit status for a command that returned err, by
+// the code ErrorCode gives it
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	return exitCodes[ErrorCode(err)]
+}
+
+// printExitCodes prints the exit statuses for help exit-codes
+func printExitCodes(out *Output) {
+	out.Println("Exit codes of a command run on its own or of a script:")
+	out.Println()
+	for _, help := range exitCodeHelp {
+		out.Printf("  %-3d %s\n", help.code, help.description)
+	}
+}
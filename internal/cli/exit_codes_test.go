@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	perrors "github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{nil, ExitOK},
+		{errors.New("unknown lot: depot"), ExitFailure},
+		{perrors.NewLotNotInitializedError(), ExitFailure},
+		{newUsageError("too few arguments"), ExitUsage},
+		{perrors.NewValidationError("vehicleNumber", "ABC", "too short"), ExitUsage},
+		{perrors.NewInvalidSpotIDError("9-9", "too few parts"), ExitUsage},
+		{perrors.NewInvalidVehicleTypeError("LORRY"), ExitUsage},
+		{perrors.NewInvalidVehicleNumberError("", "empty"), ExitUsage},
+		{perrors.NewInvalidSpotTypeError("Z-1"), ExitUsage},
+		{perrors.NewLayoutMapError(3, 5, "Q", "invalid spot character"), ExitUsage},
+		{perrors.NewVehicleNotFoundError("KA-01-HH-1234"), ExitNotFound},
+		{perrors.NewSpotNotOccupiedError("0-1-2"), ExitNotFound},
+		{perrors.NewNoSpaceError("AUTOMOBILE"), ExitNoSpace},
+		{perrors.NewNoTaggedSpaceError("AUTOMOBILE", []string{"ev"}), ExitNoSpace},
+		{perrors.NewVehicleAlreadyParkedError("KA-01-HH-1234", "0-1-2"), ExitConflict},
+		{perrors.NewVehicleNumberClashError("KA01HH1234", "KA-01-HH-1234", "0-1-2"), ExitConflict},
+		{perrors.NewSpotAlreadyOccupiedError("0-1-2"), ExitConflict},
+		{perrors.NewVehicleMismatchError("0-1-2", "KA-01-HH-1234", "KA-01-HH-9999"), ExitConflict},
+		{perrors.NewInvalidOperationError("park", "lot closed"), ExitRefused},
+		{perrors.NewVehicleSpotTypeMismatchError("AUTOMOBILE", "B-1"), ExitRefused},
+		{perrors.NewSpotInactiveError("0-0-4"), ExitRefused},
+		{perrors.NewPermitRequiredError("0-1-2", "KA-01-HH-1234"), ExitRefused},
+		{perrors.NewFloorRestrictedError("0", "0-1-2", "AUTOMOBILE"), ExitRefused},
+		{perrors.NewQuotaExceededError("AUTOMOBILE", 2), ExitRefused},
+		{perrors.NewParkingError(perrors.CodeInternalError, "broken", nil), ExitInternal},
+
+		// The first categorized code in the chain decides, through wrapping
+		{fmt.Errorf("failed to park vehicle: %w", perrors.NewNoSpaceError("AUTOMOBILE")), ExitNoSpace},
+		{perrors.WrapError(perrors.NewSpotInactiveError("0-0-4"), "OCCUPATION_ERROR", "failed to occupy"), ExitRefused},
+		{&ReportedError{Err: perrors.NewVehicleNotFoundError("KA-01-HH-1234")}, ExitNotFound},
+		{perrors.WrapError(errors.New("disk full"), "HISTORY_ERROR", "failed to record"), ExitInternal},
+	}
+
+	for _, test := range tests {
+		if code := ExitCode(test.err); code != test.code {
+			t.Errorf("Expected exit code %d for %v, got %d", test.code, test.err, code)
+		}
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		code string
+	}{
+		{errors.New("unknown lot: depot"), perrors.CodeCommandFailed},
+		{perrors.NewLotNotInitializedError(), perrors.CodeLotNotInitialized},
+		{newUsageError("unknown option: --bogus"), perrors.CodeInvalidInput},
+		{perrors.NewInvalidVehicleTypeError("LORRY"), perrors.CodeInvalidVehicleType},
+		{fmt.Errorf("failed to park vehicle: %w", perrors.NewNoSpaceError("AUTOMOBILE")), perrors.CodeNoSpaceAvailable},
+		{perrors.WrapError(perrors.NewSpotInactiveError("0-0-4"), "OCCUPATION_ERROR", "failed to occupy"), perrors.CodeSpotInactive},
+		{perrors.WrapError(errors.New("disk full"), "HISTORY_ERROR", "failed to record"), perrors.CodeInternalError},
+	}
+
+	for _, test := range tests {
+		if code := ErrorCode(test.err); code != test.code {
+			t.Errorf("Expected code %s for %v, got %s", test.code, test.err, code)
+		}
+	}
+}
+
+func TestExitCodeOfCommands(t *testing.T) {
+	c := newTestCLI(t)
+
+	// Without a lot, the JSON code and the exit status agree
+	err := c.fail("status", "--json")
+	if ExitCode(err) != ExitFailure || !strings.Contains(c.out.String(), `"code": "LOT_NOT_INITIALIZED"`) {
+		t.Errorf("Expected exit status %d and LOT_NOT_INITIALIZED, got %d and %s", ExitFailure, ExitCode(err), c.out.String())
+	}
+
+	c.run("init", "1", "2", "2")
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"park", []string{"foo", "X-1"}, ExitUsage},
+		{"available", []string{"foo"}, ExitUsage},
+		{"park", []string{"automobile"}, ExitUsage},
+		{"park", []string{"automobile", "KA-01", "--bogus"}, ExitUsage},
+		{"available", []string{"automobile", "--bogus"}, ExitUsage},
+		{"vehicles", []string{"--bogus"}, ExitUsage},
+		{"spots", []string{"--floor", "one"}, ExitUsage},
+		{"set", []string{"format", "json", "--bogus"}, ExitUsage},
+		{"run", []string{"script.txt", "--bogus"}, ExitUsage},
+		{"search", []string{"KA-01-HH-1234"}, ExitNotFound},
+	}
+
+	for _, test := range tests {
+		err := c.fail(test.name, test.args...)
+		if code := ExitCode(err); code != test.code {
+			t.Errorf("Expected exit code %d for %s %q, got %d (%v)", test.code, test.name, test.args, code, err)
+		}
+	}
+
+	// The vehicle types accepted are still named
+	if err := c.fail("park", "foo", "X-1"); !strings.Contains(err.Error(), "expected one of: ") {
+		t.Errorf("Expected the vehicle types named, got %v", err)
+	}
+}
@@ -0,0 +1,297 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// optionalInt is an int flag that records whether it was given
+type optionalInt struct {
+	value int
+	set   bool
+}
+
+func (o *optionalInt) String() string {
+	if !o.set {
+		return ""
+	}
+	return strconv.Itoa(o.value)
+}
+
+func (o *optionalInt) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return errors.New("not a number")
+	}
+	o.value, o.set = n, true
+	return nil
+}
+
+// optionalString is a string flag that records whether it was given, so
+// that an empty value can be told from no value
+type optionalString struct {
+	value string
+	set   bool
+}
+
+func (o *optionalString) String() string {
+	return o.value
+}
+
+func (o *optionalString) Set(s string) error {
+	o.value, o.set = s, true
+	return nil
+}
+
+// stringList is a string flag that may be given several times
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// flagErrors rewrites the flag package's errors to name flags as typed
+var flagErrors = strings.NewReplacer(
+	"flag provided but not defined: -", "unknown flag: --",
+	"for flag -", "for flag --",
+	"needs an argument: -", "needs an argument: --",
+)
+
+// newFlagSet returns an empty flag set for a command, reporting errors
+// only through the error Parse returns
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// defineGlobalFlags defines the flags every command takes on fs
+func (r *CommandRegistry) defineGlobalFlags(fs *flag.FlagSet, lotName *string) {
+	fs.BoolFunc("json", "output results in JSON format", func(string) error {
+		r.Options.Format = OutputFormatJSON
+		return nil
+	})
+	fs.Func("format", "output results as `json|text`", func(value string) error {
+		format, err := ParseOutputFormat(value)
+		if err == nil {
+			r.Options.Format = format
+		}
+		return err
+	})
+	verbose := func(string) error {
+		r.Options.Verbose = true
+		r.Logger = r.newLogger(true)
+		return nil
+	}
+	fs.BoolFunc("verbose", "show detailed operation logs", verbose)
+	fs.BoolFunc("v", "show detailed operation logs", verbose)
+	quiet := func(string) error {
+		r.Options.Quiet = true
+		return nil
+	}
+	fs.BoolFunc("quiet", "print only the primary result", quiet)
+	fs.BoolFunc("q", "print only the primary result", quiet)
+	fs.StringVar(lotName, "lot", "", "run the command against the initialized lot `name`")
+	output := func(path string) error {
+		if strings.TrimPrefix(path, "+") == "" {
+			return errors.New("no file named")
+		}
+		r.Options.Output = path
+		return nil
+	}
+	fs.Func("o", "write the output to `file`, or append to it given as +file", output)
+	fs.Func("output", "write the output to `file`, or append to it given as +file", output)
+}
+
+// parseArgs parses the command's flags and the global flags out of args
+// and returns the positional arguments left. A command without flags of
+// its own takes only the global ones out, leaving the rest to its handler.
+func (r *CommandRegistry) parseArgs(cmd *Command, args []string, lotName *string) ([]string, error) {
+	fs := newFlagSet(cmd.Name)
+	r.defineGlobalFlags(fs, lotName)
+	if cmd.Flags == nil {
+		positional, err := takeFlags(fs, args)
+		if err != nil {
+			r.detectJSON(args)
+			return nil, newUsageError("%v", err)
+		}
+		return positional, nil
+	}
+
+	cmd.Flags(fs)
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		r.detectJSON(args)
+		return nil, newUsageError("%s\nUsage: %s", flagErrors.Replace(err.Error()), cmd.UsageText())
+	}
+	return positional, nil
+}
+
+// detectJSON selects JSON output when args ask for it, so that an error
+// parsing the other flags is still reported as JSON
+func (r *CommandRegistry) detectJSON(args []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		switch {
+		case name == "json" && (!hasValue || value == "true"):
+			r.Options.Format = OutputFormatJSON
+		case name == "format" && !hasValue && i+1 < len(args):
+			value, hasValue = args[i+1], true
+			fallthrough
+		case name == "format" && hasValue:
+			if format, err := ParseOutputFormat(value); err == nil {
+				r.Options.Format = format
+			}
+		}
+	}
+}
+
+// parseInterspersed parses the flags in args wherever they appear among
+// the positional arguments, which it returns; everything after -- is
+// positional, and so is a negative number or relative time such as -1 or
+// -2h, which no flag name starts with
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for len(args) > 0 {
+		arg := args[0]
+		if arg == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		if !isFlagArg(arg) {
+			positional = append(positional, arg)
+			args = args[1:]
+			continue
+		}
+
+		// Parse the flag alone, with the value following it if it takes one
+		n := 1
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if f := fs.Lookup(name); f != nil && !hasValue && !isBoolFlag(f) && len(args) > 1 {
+			n = 2
+		}
+		if err := fs.Parse(args[:n]); err != nil {
+			return nil, err
+		}
+		args = args[n:]
+	}
+	return positional, nil
+}
+
+// isFlagArg reports whether arg names a flag rather than being a
+// positional argument
+func isFlagArg(arg string) bool {
+	return len(arg) > 1 && arg[0] == '-' && (arg[1] < '0' || arg[1] > '9')
+}
+
+// takeFlags takes the flags defined on fs out of args, leaving any other
+// argument, flag-like or not, in place
+func takeFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		name, isFlag := strings.CutPrefix(args[i], "--")
+		if !isFlag {
+			name, isFlag = strings.CutPrefix(args[i], "-")
+		}
+		f := fs.Lookup(name)
+		if !isFlag || f == nil {
+			rest = append(rest, args[i])
+			continue
+		}
+
+		flagArg, value := args[i], "true"
+		if !isBoolFlag(f) {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", flagArg)
+			}
+			value = args[i+1]
+			i++
+		}
+		if err := fs.Set(name, value); err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %v", flagArg, err)
+		}
+	}
+	return rest, nil
+}
+
+// isBoolFlag reports whether a flag takes no value
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// noFlags defines no flags, for a command with none of its own whose
+// flag-like arguments are to be refused rather than taken as values
+func noFlags(*flag.FlagSet) {}
+
+// UsageText returns the usage of the command, with its flags appended when
+// it defines them
+func (c *Command) UsageText() string {
+	if c.Flags == nil {
+		return c.Usage
+	}
+
+	var builder strings.Builder
+	builder.WriteString(c.Usage)
+	c.flagSet().VisitAll(func(f *flag.Flag) {
+		name, _ := flag.UnquoteUsage(f)
+		if isBoolFlag(f) {
+			fmt.Fprintf(&builder, " [--%s]", f.Name)
+		} else {
+			fmt.Fprintf(&builder, " [--%s <%s>]", f.Name, name)
+		}
+	})
+	return builder.String()
+}
+
+// FlagHelp describes each of the command's flags on a line of its own,
+// empty for a command without flags
+func (c *Command) FlagHelp() string {
+	if c.Flags == nil {
+		return ""
+	}
+
+	var rows [][]string
+	c.flagSet().VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		flagText := "--" + f.Name
+		if !isBoolFlag(f) {
+			flagText += " <" + name + ">"
+		}
+		if f.DefValue != "" && f.DefValue != "0" && f.DefValue != "false" {
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		rows = append(rows, []string{flagText, usage})
+	})
+
+	var builder strings.Builder
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row[0]))
+	}
+	for _, row := range rows {
+		fmt.Fprintf(&builder, "  %-*s  %s\n", width, row[0], row[1])
+	}
+	return builder.String()
+}
+
+// flagSet returns a set holding only the command's own flags
+func (c *Command) flagSet() *flag.FlagSet {
+	fs := newFlagSet(c.Name)
+	c.Flags(fs)
+	return fs
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// flagTestCommand registers a command with its own flags that records
+// what it was given
+func flagTestCommand(registry *CommandRegistry) (*int, *bool, *[]string) {
+	var count int
+	var loud bool
+	var positional []string
+	registry.RegisterCommand(&Command{
+		Name:    "echo",
+		Usage:   "echo <word>...",
+		MinArgs: 1,
+		MaxArgs: 3,
+		Flags: func(fs *flag.FlagSet) {
+			fs.IntVar(&count, "count", 1, "repeat `n` times")
+			fs.BoolVar(&loud, "loud", false, "shout")
+		},
+		Handler: func(args []string) error {
+			positional = args
+			return nil
+		},
+	})
+	return &count, &loud, &positional
+}
+
+func TestCommandFlags(t *testing.T) {
+	registry := NewCommandRegistry()
+	count, loud, positional := flagTestCommand(registry)
+
+	// Flags may come before, between or after the positional arguments
+	err := registry.ExecuteCommand("echo", []string{"hello", "--count", "3", "big", "--loud", "world"})
+	if err != nil {
+		t.Fatalf("Failed to run echo: %v", err)
+	}
+	if *count != 3 || !*loud || !reflect.DeepEqual(*positional, []string{"hello", "big", "world"}) {
+		t.Errorf("Expected count 3, loud and three words, got %d, %v, %v", *count, *loud, *positional)
+	}
+
+	// Each run starts from the defaults, and the global flags work too
+	if err := registry.ExecuteCommand("echo", []string{"-count=2", "hi", "--json"}); err != nil {
+		t.Fatalf("Failed to run echo: %v", err)
+	}
+	if *count != 2 || *loud || !reflect.DeepEqual(*positional, []string{"hi"}) {
+		t.Errorf("Expected count 2, not loud and one word, got %d, %v, %v", *count, *loud, *positional)
+	}
+
+	// Everything after -- is positional
+	if err := registry.ExecuteCommand("echo", []string{"--", "--loud"}); err != nil {
+		t.Fatalf("Failed to run echo: %v", err)
+	}
+	if *loud || !reflect.DeepEqual(*positional, []string{"--loud"}) {
+		t.Errorf("Expected --loud taken as a word, got %v, %v", *loud, *positional)
+	}
+
+	tests := []struct {
+		args   []string
+		reason string
+	}{
+		{[]string{"hi", "--silent"}, "unknown flag: --silent"},
+		{[]string{"hi", "--count", "many"}, `invalid value "many" for flag --count`},
+		{[]string{"hi", "--count"}, "flag needs an argument: --count"},
+		{[]string{"--loud"}, "too few arguments"},
+		{[]string{"a", "b", "c", "d"}, "too many arguments"},
+	}
+	for _, test := range tests {
+		err := registry.ExecuteCommand("echo", test.args)
+		if err == nil || !strings.Contains(err.Error(), test.reason) {
+			t.Errorf("Expected echo %v to fail with %q, got %v", test.args, test.reason, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), "Usage: echo <word>... [--count <n>] [--loud]") {
+			t.Errorf("Expected the generated usage in %q", err)
+		}
+	}
+}
+
+func TestCommandFlagHelp(t *testing.T) {
+	registry := NewCommandRegistry()
+	flagTestCommand(registry)
+
+	cmd, _ := registry.GetCommand("echo")
+	want := "  --count <n>  repeat n times (default 1)\n  --loud       shout\n"
+	if got := cmd.FlagHelp(); got != want {
+		t.Errorf("Unexpected flag help:\n%s\nexpected:\n%s", got, want)
+	}
+}
+
+func TestGlobalFlags(t *testing.T) {
+	registry := NewCommandRegistry()
+	var got []string
+	registry.RegisterCommand(&Command{
+		Name:    "raw",
+		MinArgs: 0,
+		MaxArgs: -1,
+		Handler: func(args []string) error {
+			got = args
+			if registry.Options.Format != OutputFormatJSON {
+				t.Error("Expected --json to select JSON output")
+			}
+			return nil
+		},
+	})
+
+	// A command without flags of its own keeps every other argument
+	if err := registry.ExecuteCommand("raw", []string{"a", "--json", "--own", "b"}); err != nil {
+		t.Fatalf("Failed to run raw: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"a", "--own", "b"}) {
+		t.Errorf("Expected the global flag taken out, got %v", got)
+	}
+	if registry.Options.Format != OutputFormatText {
+		t.Error("Expected the options reset after the command")
+	}
+
+	if err := registry.ExecuteCommand("raw", []string{"--lot"}); err == nil || !strings.Contains(err.Error(), "missing value for --lot") {
+		t.Errorf("Expected a missing --lot value rejected, got %v", err)
+	}
+}
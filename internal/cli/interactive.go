@@ -0,0 +1,441 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/model"
+)
+
+// historyLimit caps the commands kept in the history and its file
+const historyLimit = 500
+
+// completionLimit caps the completions offered for a spot or vehicle
+const completionLimit = 20
+
+// CommandRegistryInterface defines the interface for the command registry
+type CommandRegistryInterface interface {
+	ExecuteCommand(name string, args []string) error
+	GetParkingLot() *model.ParkingLot
+	GetCommands() map[string]*Command
+}
+
+// InteractiveMode contains enhancements for interactive command-line mode
+type InteractiveMode struct {
+	Registry CommandRegistryInterface
+	History  []string
+
+	// ContinueOnError runs the rest of a line's commands after one fails
+	ContinueOnError bool
+
+	// Prompt shows the banner and prompt and edits lines in place when the
+	// terminal allows it; it is off for piped input
+	Prompt bool
+
+	// HistoryFile keeps the history across sessions when set
+	HistoryFile string
+
+	// Where the session's own output, such as echoed history references,
+	// and the errors of its lines are written
+	stdout, stderr io.Writer
+}
+
+// NewInteractiveMode creates a new interactive mode, writing where the
+// registry writes
+func NewInteractiveMode(registry CommandRegistryInterface) *InteractiveMode {
+	output := StdOutput()
+	if r, ok := registry.(*CommandRegistry); ok {
+		output = r.out
+	}
+	return &InteractiveMode{
+		Registry: registry,
+		History:  make([]string, 0),
+		stdout:   output.Out,
+		stderr:   output.Err,
+	}
+}
+
+// Run reads commands from stdin and processes them until exit or the end
+// of input
+func (i *InteractiveMode) Run(stdin io.Reader, stdout, stderr io.Writer) {
+	i.stdout, i.stderr = stdout, stderr
+
+	// Print welcome message
+	if i.Prompt {
+		fmt.Fprintln(stdout, "Welcome to Parking Lot CLI")
+		fmt.Fprintln(stdout, "Type 'help' to see available commands or 'exit' to quit")
+		fmt.Fprintln(stdout, "Options:")
+		fmt.Fprintln(stdout, "  --json    Output results in JSON format")
+		fmt.Fprintln(stdout, "  --verbose Show detailed operation logs")
+		fmt.Fprintln(stdout, "  --lot <name> Run one command against another initialized lot")
+	}
+
+	if i.HistoryFile != "" {
+		if err := i.LoadHistory(i.HistoryFile); err != nil {
+			fmt.Fprintf(stderr, "Warning: %v\n", err)
+		}
+	}
+
+	// Read lines with the editor where possible, falling back to a scanner
+	readLine := scanLines(stdin, stdout, i.Prompt)
+	if f, ok := stdin.(*os.File); ok && i.Prompt {
+		history := func() []string { return i.History }
+		if editor := newTerminalEditor(f, stdout, history, i.AutoComplete); editor != nil {
+			readLine = editor.ReadLine
+		}
+	}
+
+	// Main loop
+	for {
+		line, err := readLine("> ")
+		if err != nil {
+			break
+		}
+
+		// Process command
+		if !i.ProcessCommand(line) {
+			break
+		}
+	}
+
+	if i.HistoryFile != "" {
+		if err := i.SaveHistory(i.HistoryFile); err != nil {
+			fmt.Fprintf(stderr, "Warning: %v\n", err)
+		}
+	}
+}
+
+// scanLines returns a function reading whole lines from stdin, showing the
+// prompt first when prompt is set
+func scanLines(stdin io.Reader, stdout io.Writer, prompt bool) func(string) (string, error) {
+	scanner := bufio.NewScanner(stdin)
+	return func(p string) (string, error) {
+		if prompt {
+			fmt.Fprint(stdout, p)
+		}
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return "", err
+			}
+			return "", io.EOF
+		}
+		return scanner.Text(), nil
+	}
+}
+
+// AddToHistory adds a command to the history, dropping the oldest once
+// it holds historyLimit commands
+func (i *InteractiveMode) AddToHistory(command string) {
+	i.History = append(i.History, command)
+	if len(i.History) > historyLimit {
+		i.History = i.History[len(i.History)-historyLimit:]
+	}
+}
+
+// LoadHistory adds the commands in a history file to the history; a
+// missing file leaves it empty
+func (i *InteractiveMode) LoadHistory(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read history: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			i.AddToHistory(line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read history: %v", err)
+	}
+	return nil
+}
+
+// SaveHistory writes the history to a file, one command per line
+func (i *InteractiveMode) SaveHistory(path string) error {
+	var builder strings.Builder
+	for _, command := range i.History {
+		builder.WriteString(command)
+		builder.WriteString("\n")
+	}
+
+	if err := os.WriteFile(path, []byte(builder.String()), 0o600); err != nil {
+		return fmt.Errorf("failed to save history: %v", err)
+	}
+	return nil
+}
+
+// ProcessCommand processes a command line, running its commands in order
+// when several are separated by semicolons. It returns false once the
+// session should end.
+func (i *InteractiveMode) ProcessCommand(line string) bool {
+	line = strings.TrimSpace(line)
+
+	// Skip empty lines
+	if line == "" {
+		return true
+	}
+
+	// Expand !! and !N to the commands they refer to and echo the result
+	commands := SplitCommands(line)
+	expanded := false
+	for n, command := range commands {
+		if !strings.HasPrefix(command, "!") {
+			continue
+		}
+		command, err := i.ExpandHistory(command)
+		if err != nil {
+			fmt.Fprintf(i.stderr, "Error: %v\n", err)
+			return true
+		}
+		commands[n] = command
+		expanded = true
+	}
+	if expanded {
+		line = strings.Join(commands, "; ")
+		fmt.Fprintln(i.stdout, line)
+		// An expanded command may itself hold several
+		commands = SplitCommands(line)
+	}
+
+	// Add to history
+	i.AddToHistory(line)
+
+	for n := range commands {
+		keepGoing, err := i.runCommand(commands[n])
+		if !keepGoing {
+			return false
+		}
+		if err != nil {
+			if !IsReported(err) {
+				fmt.Fprintf(i.stderr, "Error: %v\n", WrapCommandError(commands, n, err))
+			}
+			if !i.ContinueOnError {
+				break
+			}
+		}
+	}
+
+	return true
+}
+
+// runCommand runs one command of a line. It returns false for exit.
+func (i *InteractiveMode) runCommand(line string) (bool, error) {
+	// Parse command and arguments
+	parts, err := ParseCommandLine(line)
+	if err != nil {
+		return true, err
+	}
+	if len(parts) == 0 {
+		return true, nil
+	}
+
+	command := parts[0]
+	args := parts[1:]
+
+	// Handle exit command directly
+	if command == "exit" {
+		return false, nil
+	}
+
+	// history on its own lists this session's commands; with a vehicle
+	// number it is the registry's vehicle history
+	if command == "history" && len(args) == 0 {
+		i.PrintHistory()
+		return true, nil
+	}
+
+	// Execute the command
+	return true, i.Registry.ExecuteCommand(command, args)
+}
+
+// ExpandHistory returns the command a history reference stands for: !! for
+// the last command, !N for the N-th as numbered by history
+func (i *InteractiveMode) ExpandHistory(ref string) (string, error) {
+	if len(i.History) == 0 {
+		return "", fmt.Errorf("%s: history is empty", ref)
+	}
+
+	index := len(i.History) - 1
+	if ref != "!!" {
+		n, err := strconv.Atoi(ref[1:])
+		if err != nil || n < 1 || n > len(i.History) {
+			return "", fmt.Errorf("%s: no such command in history (1-%d)", ref, len(i.History))
+		}
+		index = n - 1
+	}
+
+	// Expanded commands are what the history holds, but a loaded history
+	// file may still carry a reference; it is not expanded again
+	command := i.History[index]
+	if strings.HasPrefix(command, "!") {
+		return "", fmt.Errorf("%s: refers to another history reference, %s", ref, command)
+	}
+	return command, nil
+}
+
+// PrintHistory lists the commands in the history, numbered for !N
+func (i *InteractiveMode) PrintHistory() {
+	for n, command := range i.History {
+		fmt.Fprintf(i.stdout, "%5d  %s\n", n+1, command)
+	}
+}
+
+// AutoComplete returns the completions of the last word of a partial
+// command line, each as the whole line: command names first, then the
+// arguments of the commands that take vehicle types, spots or vehicles
+func (i *InteractiveMode) AutoComplete(partial string) []string {
+	words := strings.Fields(partial)
+	// After a space the word to complete is a new, empty one
+	if len(words) == 0 || unicode.IsSpace(rune(partial[len(partial)-1])) {
+		words = append(words, "")
+	}
+	word := words[len(words)-1]
+	prefix := partial[:len(partial)-len(word)]
+
+	// The words before, with any quotes in them taken off
+	before := SplitCommandLine(prefix)
+
+	var candidates []string
+	if len(before) == 0 {
+		for name := range i.Registry.GetCommands() {
+			candidates = append(candidates, name)
+		}
+		sort.Strings(candidates)
+	} else {
+		candidates = i.argumentCompletions(before[0], before[1:], word)
+	}
+
+	var completions []string
+	for _, candidate := range candidates {
+		if !strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(word)) {
+			continue
+		}
+		completions = append(completions, prefix+candidate)
+		if len(completions) == completionLimit {
+			break
+		}
+	}
+
+	return completions
+}
+
+// argumentCompletions returns the values the next argument of a command
+// can take, given the arguments before it and the start of the word typed
+func (i *InteractiveMode) argumentCompletions(command string, args []string, word string) []string {
+	switch {
+	case (command == "park" || command == "available") && len(args) == 0:
+		return vehicleTypeCompletions()
+	case command == "park-at" && len(args) == 0:
+		return i.spotIDs(false, word)
+	case command == "park-at" && len(args) == 1:
+		return vehicleTypeCompletions()
+	case command == "unpark" && len(args) == 0:
+		return i.spotIDs(true, word)
+	case command == "unpark" && len(args) == 1:
+		return i.vehiclesAt(args[0])
+	}
+	return nil
+}
+
+// vehicleTypeCompletions returns the names of the registered vehicle types
+func vehicleTypeCompletions() []string {
+	var names []string
+	for _, info := range model.RegisteredVehicleTypes() {
+		names = append(names, strings.ToLower(string(info.Type)))
+	}
+	return names
+}
+
+// spotIDs returns the IDs starting with prefix of the lot's occupied spots,
+// or of the spots with room for another vehicle, stopping at completionLimit
+// so a large lot is not walked on every Tab
+func (i *InteractiveMode) spotIDs(occupied bool, prefix string) []string {
+	parkingLot := i.Registry.GetParkingLot()
+	if parkingLot == nil {
+		return nil
+	}
+
+	prefix = strings.ToLower(prefix)
+	var ids []string
+	parkingLot.ForEachSpot(model.SpotFilter{}, func(spot model.SpotInfo) bool {
+		if !strings.HasPrefix(strings.ToLower(spot.SpotID), prefix) {
+			return true
+		}
+		if occupied && spot.IsOccupied() {
+			ids = append(ids, spot.SpotID)
+		}
+		if !occupied && spot.Type != model.SpotTypeInactive && len(spot.Vehicles) < spot.Capacity {
+			ids = append(ids, spot.SpotID)
+		}
+		return len(ids) < completionLimit
+	})
+	return ids
+}
+
+// vehiclesAt returns the vehicles parked at a spot given by ID or @label
+func (i *InteractiveMode) vehiclesAt(spotRef string) []string {
+	parkingLot := i.Registry.GetParkingLot()
+	if parkingLot == nil {
+		return nil
+	}
+
+	var spot *model.ParkingSpot
+	var err error
+	if label, ok := strings.CutPrefix(spotRef, "@"); ok {
+		spot, err = parkingLot.FindSpotByLabel(label)
+	} else {
+		spot, err = parkingLot.GetSpotByID(spotRef)
+	}
+	if err != nil {
+		return nil
+	}
+	return spot.GetVehicleNumbers()
+}
+
+// GetExampleCommands returns example commands for an empty prompt
+func (i *InteractiveMode) GetExampleCommands() []string {
+	examples := []string{
+		"init 3 5 10",
+		"park automobile KA-01-HH-1234",
+		"unpark 0-1-2 KA-01-HH-1234",
+		"available bicycle",
+		"search KA-01-HH-1234",
+		"status",
+		"help",
+		"exit",
+	}
+
+	// Add more tailored examples if the parking lot is initialized
+	parkingLot := i.Registry.GetParkingLot()
+	if parkingLot != nil {
+		// Get counts by vehicle type to suggest available types
+		availableCounts := parkingLot.GetAvailableSpotCountByType()
+
+		var vehicleType model.VehicleType
+		for _, info := range model.RegisteredVehicleTypes() {
+			if info.Spans == "" && availableCounts[info.Type] > 0 {
+				vehicleType = info.Type
+				break
+			}
+		}
+
+		if vehicleType != "" {
+			examples = append(examples,
+				fmt.Sprintf("park %s ABC-123", strings.ToLower(string(vehicleType))))
+		}
+	}
+
+	return examples
+}
@@ -0,0 +1,324 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/model"
+)
+
+// captureStdout returns everything fn writes to standard output
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	return capture(t, &os.Stdout, fn)
+}
+
+// captureStderr returns everything fn writes to standard error
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	return capture(t, &os.Stderr, fn)
+}
+
+// capture returns everything fn writes to the file f points at
+func capture(t *testing.T, f **os.File, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+
+	saved := *f
+	*f = w
+	defer func() { *f = saved }()
+
+	fn()
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
+
+// recordingRegistry records the commands it is asked to run; fail fails
+type recordingRegistry struct {
+	executed []string
+}
+
+func (r *recordingRegistry) ExecuteCommand(name string, args []string) error {
+	r.executed = append(r.executed, strings.Join(append([]string{name}, args...), " "))
+	if name == "fail" {
+		return errors.New("failed on purpose")
+	}
+	return nil
+}
+
+func (r *recordingRegistry) GetParkingLot() *model.ParkingLot { return nil }
+
+func (r *recordingRegistry) GetCommands() map[string]*Command { return nil }
+
+func TestProcessCommandHistory(t *testing.T) {
+	registry := &recordingRegistry{}
+	mode := NewInteractiveMode(registry)
+
+	out := captureStdout(t, func() {
+		for _, line := range []string{
+			"init 1 2 5",
+			"park automobile KA-01-HH-0001",
+			"!!",
+			"!1",
+			"history",
+			"!7",
+			"!x",
+			"history KA-01-HH-0001",
+		} {
+			if !mode.ProcessCommand(line) {
+				t.Errorf("Expected %q to keep the session going", line)
+			}
+		}
+	})
+
+	want := []string{
+		"init 1 2 5",
+		"park automobile KA-01-HH-0001",
+		"park automobile KA-01-HH-0001",
+		"init 1 2 5",
+		"history KA-01-HH-0001",
+	}
+	if !reflect.DeepEqual(registry.executed, want) {
+		t.Errorf("Expected %v run, got %v", want, registry.executed)
+	}
+
+	// The history holds the expanded commands, not the references
+	wantHistory := []string{
+		"init 1 2 5",
+		"park automobile KA-01-HH-0001",
+		"park automobile KA-01-HH-0001",
+		"init 1 2 5",
+		"history",
+		"history KA-01-HH-0001",
+	}
+	if !reflect.DeepEqual(mode.History, wantHistory) {
+		t.Errorf("Expected history %v, got %v", wantHistory, mode.History)
+	}
+
+	// Expanded commands are echoed and history lists them by number
+	for _, expected := range []string{"park automobile KA-01-HH-0001\n", "    2  park automobile KA-01-HH-0001\n", "    5  history\n"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected %q in the output, got %q", expected, out)
+		}
+	}
+}
+
+func TestExpandHistory(t *testing.T) {
+	mode := NewInteractiveMode(&recordingRegistry{})
+	if _, err := mode.ExpandHistory("!!"); err == nil {
+		t.Error("Expected !! to fail with an empty history")
+	}
+
+	// A reference in a loaded history file is not expanded again
+	mode.AddToHistory("status")
+	mode.AddToHistory("!!")
+	if _, err := mode.ExpandHistory("!!"); err == nil {
+		t.Error("Expected !! to refuse to expand another reference")
+	}
+	if got, err := mode.ExpandHistory("!1"); err != nil || got != "status" {
+		t.Errorf("Expected !1 to expand to status, got %q, %v", got, err)
+	}
+	if _, err := mode.ExpandHistory("!0"); err == nil {
+		t.Error("Expected !0 to be rejected")
+	}
+}
+
+func TestAutoComplete(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+	mode := NewInteractiveMode(registry)
+
+	// Before init there are no spots to offer
+	if got := mode.AutoComplete("unpark "); got != nil {
+		t.Errorf("Expected no spots before init, got %v", got)
+	}
+
+	for _, command := range [][]string{
+		{"init", "1", "2", "2"},
+		{"park-at", "0-1-0", "automobile", "KA-01-HH-0001"},
+		{"park-at", "0-1-1", "automobile", "KA-01-HH-0002"},
+	} {
+		if err := registry.ExecuteCommand(command[0], command[1:]); err != nil {
+			t.Fatalf("Failed to run %v: %v", command, err)
+		}
+	}
+
+	tests := []struct {
+		partial string
+		want    []string
+	}{
+		{"unp", []string{"unpark"}},
+		{"park", []string{"park", "park-at"}},
+		{"park a", []string{"park automobile"}},
+		{"available m", []string{"available motorcycle", "available minibus"}},
+		{"park-at 0-", []string{"park-at 0-0-0", "park-at 0-0-1"}},
+		{"park-at 0-1", nil},
+		{"park-at 0-0-0 mo", []string{"park-at 0-0-0 motorcycle"}},
+		{"unpark ", []string{"unpark 0-1-0", "unpark 0-1-1"}},
+		{"unpark 0-1-1 ", []string{"unpark 0-1-1 KA-01-HH-0002"}},
+		{"unpark 0-0-0 ", nil},
+		{"unpark 9-9-9 ", nil},
+		{"park automobile KA", nil},
+		{"frobnicate ", nil},
+	}
+
+	for _, test := range tests {
+		if got := mode.AutoComplete(test.partial); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("AutoComplete(%q) = %v, expected %v", test.partial, got, test.want)
+		}
+	}
+}
+
+func TestAutoCompleteLimit(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+	if err := registry.ExecuteCommand("init", []string{"2", "10", "10"}); err != nil {
+		t.Fatalf("Failed to init: %v", err)
+	}
+
+	mode := NewInteractiveMode(registry)
+	got := mode.AutoComplete("park-at ")
+	if len(got) != completionLimit {
+		t.Errorf("Expected %d spots offered, got %d", completionLimit, len(got))
+	}
+
+	// The limit counts the spots matching what was typed, not the first
+	// spots of the lot
+	got = mode.AutoComplete("park-at 1-")
+	if len(got) != completionLimit {
+		t.Fatalf("Expected %d spots of floor 1 offered, got %d", completionLimit, len(got))
+	}
+	for _, completion := range got {
+		if !strings.HasPrefix(completion, "park-at 1-") {
+			t.Errorf("Expected only spots of floor 1, got %q", completion)
+		}
+	}
+}
+
+func TestProcessCommandSequence(t *testing.T) {
+	registry := &recordingRegistry{}
+	mode := NewInteractiveMode(registry)
+
+	// Commands run in order, skipping empty ones, and the first failure
+	// stops the line
+	stderr := captureStderr(t, func() {
+		mode.ProcessCommand(`init 2 3 4;; park bicycle B-1 ; fail now; status`)
+	})
+	want := []string{"init 2 3 4", "park bicycle B-1", "fail now"}
+	if !reflect.DeepEqual(registry.executed, want) {
+		t.Errorf("Expected %v run, got %v", want, registry.executed)
+	}
+	if !strings.Contains(stderr, "command 3 of 4 (fail now): failed on purpose") {
+		t.Errorf("Expected the failing command named, got %q", stderr)
+	}
+
+	// With ContinueOnError the rest of the line runs
+	registry.executed = nil
+	mode.ContinueOnError = true
+	captureStderr(t, func() { mode.ProcessCommand("fail; status") })
+	if want := []string{"fail", "status"}; !reflect.DeepEqual(registry.executed, want) {
+		t.Errorf("Expected %v run, got %v", want, registry.executed)
+	}
+
+	// Semicolons in quotes stay in the argument, and exit ends the session
+	registry.executed = nil
+	if mode.ProcessCommand(`describe --notes "north; gate"; exit; status`) {
+		t.Error("Expected exit to end the session")
+	}
+	if want := []string{"describe --notes north; gate"}; !reflect.DeepEqual(registry.executed, want) {
+		t.Errorf("Expected %v run, got %v", want, registry.executed)
+	}
+
+	// A history reference to a line of several runs them all
+	registry.executed = nil
+	captureStdout(t, func() { mode.ProcessCommand("!1") })
+	if want := []string{"init 2 3 4", "park bicycle B-1", "fail now", "status"}; !reflect.DeepEqual(registry.executed, want) {
+		t.Errorf("Expected %v run, got %v", want, registry.executed)
+	}
+}
+
+func TestRunInjectedOutput(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+	var stdout, stderr bytes.Buffer
+	registry.SetOutput(&stdout, &stderr)
+
+	// Logs, failed commands and bad history references go to stderr
+	input := "init 1 2 5 --verbose\npark automobile KA-01-HH-0001\npark automobile KA-01-HH-0001\n!9\n!1\nhistory\nexit\n"
+	var process string
+	processOut := captureStdout(t, func() {
+		process = captureStderr(t, func() {
+			NewInteractiveMode(registry).Run(strings.NewReader(input), &stdout, &stderr)
+		})
+	})
+	if process != "" || processOut != "" {
+		t.Errorf("Expected nothing on the process's stdout and stderr, got %q and %q", processOut, process)
+	}
+
+	// Echoed history references and the history listing go to stdout
+	for _, expected := range []string{"init 1 2 5 --verbose\n", "    4  init 1 2 5 --verbose\n"} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("Expected %q on the session's stdout, got %q", expected, stdout.String())
+		}
+	}
+	for _, expected := range []string{"[DEBUG]", "Error: failed to park vehicle", "Error: !9: no such command in history"} {
+		if !strings.Contains(stderr.String(), expected) {
+			t.Errorf("Expected %q on the session's stderr, got %q", expected, stderr.String())
+		}
+	}
+}
+
+func TestProcessCommandUnterminatedQuote(t *testing.T) {
+	registry := &recordingRegistry{}
+	mode := NewInteractiveMode(registry)
+
+	stderr := captureStderr(t, func() {
+		mode.ProcessCommand(`describe --notes "north gate`)
+	})
+	if len(registry.executed) != 0 {
+		t.Errorf("Expected nothing run, got %v", registry.executed)
+	}
+	if !strings.Contains(stderr, "unterminated double quote") {
+		t.Errorf("Expected the open quote reported, got %q", stderr)
+	}
+}
+
+func TestInteractiveHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	mode := NewInteractiveMode(nil)
+	for i := 0; i < historyLimit+5; i++ {
+		mode.AddToHistory(fmt.Sprintf("search KA-01-HH-%04d", i))
+	}
+	if len(mode.History) != historyLimit || mode.History[0] != "search KA-01-HH-0005" {
+		t.Fatalf("Expected the oldest commands dropped at the limit, got %d starting with %q",
+			len(mode.History), mode.History[0])
+	}
+	if err := mode.SaveHistory(path); err != nil {
+		t.Fatalf("Failed to save history: %v", err)
+	}
+
+	// The next session starts with the saved history
+	next := NewInteractiveMode(nil)
+	if err := next.LoadHistory(path); err != nil {
+		t.Fatalf("Failed to load history: %v", err)
+	}
+	if len(next.History) != historyLimit || next.History[historyLimit-1] != mode.History[historyLimit-1] {
+		t.Errorf("Expected the saved history loaded, got %d commands", len(next.History))
+	}
+
+	if err := NewInteractiveMode(nil).LoadHistory(filepath.Join(t.TempDir(), "missing")); err != nil {
+		t.Errorf("Expected a missing history file to be skipped, got %v", err)
+	}
+}
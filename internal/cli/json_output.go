@@ -3,83 +3,35 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"time"
 
+	perrors "github.com/prasaria/go-multistorey-parking-lot/internal/errors"
 	"github.com/prasaria/go-multistorey-parking-lot/internal/model"
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/api"
 )
 
-// JSON output structures
-
-// JSONResult is the root structure for JSON output
-type JSONResult struct {
-	Success bool        `json:"success"`
-	Command string      `json:"command"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
-	Time    string      `json:"time"`
-}
-
-// InitResult contains data for init command output
-type InitResult struct {
-	Floors  int            `json:"floors"`
-	Rows    int            `json:"rows"`
-	Columns int            `json:"columns"`
-	Total   int            `json:"totalSpots"`
-	Counts  map[string]int `json:"spotCounts"`
-}
-
-// ParkResult contains data for park command output
-type ParkResult struct {
-	VehicleType   string `json:"vehicleType"`
-	VehicleNumber string `json:"vehicleNumber"`
-	SpotID        string `json:"spotId"`
-}
-
-// UnparkResult contains data for unpark command output
-type UnparkResult struct {
-	VehicleNumber string `json:"vehicleNumber"`
-	SpotID        string `json:"spotId"`
+// PrintJSON prints a result as JSON
+func PrintJSON(command string, data interface{}, err error) error {
+	return StdOutput().JSON(command, data, err)
 }
 
-// AvailableResult contains data for available command output
-type AvailableResult struct {
-	VehicleType string   `json:"vehicleType"`
-	SpotIDs     []string `json:"spotIds"`
-	Count       int      `json:"count"`
-}
-
-// SearchResult contains data for search command output
-type SearchResult struct {
-	VehicleNumber string `json:"vehicleNumber"`
-	SpotID        string `json:"spotId"`
-	IsParked      bool   `json:"isParked"`
-}
-
-// StatusResult contains data for status command output
-type StatusResult struct {
-	Name            string            `json:"name"`
-	Floors          int               `json:"floors"`
-	TotalSpots      int               `json:"totalSpots"`
-	ActiveSpots     int               `json:"activeSpots"`
-	OccupiedSpots   int               `json:"occupiedSpots"`
-	AvailableSpots  int               `json:"availableSpots"`
-	SpotCounts      map[string]int    `json:"spotCounts"`
-	AvailableCounts map[string]int    `json:"availableCounts"`
-	ParkedVehicles  map[string]string `json:"parkedVehicles"`
-}
-
-// Helper functions
-
-// PrintJSON outputs a result as JSON
-func PrintJSON(command string, data interface{}, err error) {
-	result := JSONResult{
-		Success: err == nil,
-		Command: command,
-		Time:    time.Now().Format(time.RFC3339),
+// JSON writes a result as JSON, returning an error when the result cannot
+// be marshaled or written
+func (o *Output) JSON(command string, data interface{}, err error) error {
+	result := api.JSONResult{
+		SchemaVersion: api.SchemaVersion,
+		Success:       err == nil,
+		Command:       command,
+		Time:          jsonTime(time.Now()),
 	}
 
 	if err != nil {
-		result.Error = err.Error()
+		result.Error = &api.JSONError{
+			Code:    ErrorCode(err),
+			Message: err.Error(),
+			Details: perrors.DetailsOf(err),
+		}
 	} else {
 		result.Data = data
 	}
@@ -87,11 +39,13 @@ func PrintJSON(command string, data interface{}, err error) {
 	// Marshal to JSON
 	jsonBytes, jsonErr := json.MarshalIndent(result, "", "  ")
 	if jsonErr != nil {
-		fmt.Printf("Error marshaling JSON: %v\n", jsonErr)
-		return
+		return fmt.Errorf("failed to marshal %s result: %v", command, jsonErr)
 	}
 
-	fmt.Println(string(jsonBytes))
+	if _, writeErr := fmt.Fprintln(o.Out, string(jsonBytes)); writeErr != nil {
+		return fmt.Errorf("failed to write %s result: %v", command, writeErr)
+	}
+	return nil
 }
 
 // Convert SpotType map to string map for JSON
@@ -103,6 +57,32 @@ func convertSpotTypeMap(m map[model.SpotType]int) map[string]int {
 	return result
 }
 
+// Convert FloorSummary to its JSON form
+func convertFloorSummary(summary model.FloorSummary) api.FloorSummaryResult {
+	return api.FloorSummaryResult{
+		Floor:           summary.FloorNumber,
+		Closed:          summary.Closed,
+		Allowed:         convertVehicleTypes(summary.Allowed),
+		TotalSpots:      summary.TotalSpots,
+		ActiveSpots:     summary.ActiveSpots,
+		OccupiedSpots:   summary.OccupiedSpots,
+		AvailableSpots:  summary.AvailableSpots,
+		AvailableCounts: convertVehicleTypeMap(summary.AvailableByType),
+	}
+}
+
+// Convert a list of vehicle types to strings for JSON, nil staying nil
+func convertVehicleTypes(vehicleTypes []model.VehicleType) []string {
+	if vehicleTypes == nil {
+		return nil
+	}
+	result := make([]string, len(vehicleTypes))
+	for i, vehicleType := range vehicleTypes {
+		result[i] = string(vehicleType)
+	}
+	return result
+}
+
 // Convert VehicleType map to string map for JSON
 func convertVehicleTypeMap(m map[model.VehicleType]int) map[string]int {
 	result := make(map[string]int)
@@ -111,3 +91,302 @@ func convertVehicleTypeMap(m map[model.VehicleType]int) map[string]int {
 	}
 	return result
 }
+
+// convertSearchInfo converts what a search of lot knows about a vehicle to
+// its JSON form, keeping the number as it was searched for
+func convertSearchInfo(lot *model.ParkingLot, vehicleNumber string, info *model.SearchInfo) (api.SearchResult, error) {
+	result := api.SearchResult{VehicleNumber: vehicleNumber}
+	if info == nil {
+		return result, nil
+	}
+
+	result.SpotID = info.SpotID
+	result.SpotLabel = info.SpotLabel
+	result.IsParked = info.IsParked
+	result.VehicleType = string(info.VehicleType)
+	if info.SpotID != "" {
+		floor := info.Floor
+		result.Floor = &floor
+		location, err := spotLocation(lot, info.SpotID)
+		if err != nil {
+			return result, err
+		}
+		result.Location = &location
+	}
+	if !info.ParkedAt.IsZero() {
+		result.ParkedAt = jsonTime(info.ParkedAt)
+	}
+	if !info.LastSeenAt.IsZero() {
+		result.LastSeenAt = jsonTime(info.LastSeenAt)
+	}
+
+	return result, nil
+}
+
+// spotLocation returns the location of a spot of the lot, read from its ID.
+// The lot gave the ID out, so one that does not parse is an internal error.
+func spotLocation(lot *model.ParkingLot, spotID string) (api.SpotLocation, error) {
+	floor, row, column, err := lot.ParseSpotID(spotID)
+	if err != nil {
+		return api.SpotLocation{}, perrors.WrapError(err, perrors.CodeInternalError,
+			fmt.Sprintf("failed to locate spot %s", spotID))
+	}
+	return api.SpotLocation{Floor: floor, Row: row, Column: column}, nil
+}
+
+// locateSpots returns the spots listed by floor with their locations, in
+// order of floor
+func locateSpots(lot *model.ParkingLot, floors []int, spotsByFloor map[int][]string) ([]api.LocatedSpotResult, error) {
+	var located []api.LocatedSpotResult
+	for _, n := range floors {
+		for _, spotID := range spotsByFloor[n] {
+			location, err := spotLocation(lot, spotID)
+			if err != nil {
+				return nil, err
+			}
+			located = append(located, api.LocatedSpotResult{SpotID: spotID, Location: location})
+		}
+	}
+	return located, nil
+}
+
+// convertSpotLocation returns the location of a spot
+func convertSpotLocation(spot *model.ParkingSpot) api.SpotLocation {
+	return api.SpotLocation{Floor: spot.Floor, Row: spot.Row, Column: spot.Column}
+}
+
+// convertSpotInfo converts a model spot snapshot to its JSON form
+func convertSpotInfo(spot model.SpotInfo) api.SpotInfoResult {
+	result := api.SpotInfoResult{
+		SpotID:   spot.SpotID,
+		Floor:    spot.Floor,
+		Row:      spot.Row,
+		Column:   spot.Column,
+		Type:     string(spot.Type),
+		Capacity: spot.Capacity,
+		Label:    spot.Label,
+		Tags:     spot.Tags,
+		Occupied: spot.IsOccupied(),
+	}
+	if result.Occupied {
+		result.Vehicles = spot.Vehicles
+		result.OccupiedSince = jsonTime(spot.OccupiedSince)
+	}
+
+	return result
+}
+
+// convertUtilization converts a model utilization, rounding the percentage to one decimal
+func convertUtilization(u model.Utilization) api.UtilizationResult {
+	return api.UtilizationResult{
+		Occupied: u.Occupied,
+		Active:   u.Active,
+		Percent:  math.Round(u.Percent()*10) / 10,
+	}
+}
+
+// convertOccupancyReport converts a model occupancy report to its JSON form
+func convertOccupancyReport(report model.OccupancyReport) api.OccupancyResult {
+	result := api.OccupancyResult{
+		Overall: convertUtilization(report.Overall),
+		Floors:  make([]api.FloorOccupancyResult, 0, len(report.Floors)),
+		ByType:  make(map[string]api.UtilizationResult, len(report.ByType)),
+	}
+	for _, floor := range report.Floors {
+		result.Floors = append(result.Floors, api.FloorOccupancyResult{
+			Floor:             floor.FloorNumber,
+			Closed:            floor.Closed,
+			UtilizationResult: convertUtilization(floor.Utilization),
+		})
+	}
+	for spotType, u := range report.ByType {
+		result.ByType[string(spotType)] = convertUtilization(u)
+	}
+
+	return result
+}
+
+// convertParkingRecord converts a parking record with RFC3339 timestamps,
+// measuring a record in progress until now
+func convertParkingRecord(record model.ParkingRecord, now time.Time) api.ParkingRecordResult {
+	result := api.ParkingRecordResult{
+		SpotID:          record.SpotID,
+		ParkedAt:        jsonTime(record.ParkedAt),
+		DurationSeconds: record.DurationAt(now).Seconds(),
+		Status:          "active",
+	}
+	if record.IsComplete() {
+		result.UnparkedAt = jsonTime(*record.UnparkedAt)
+		result.Status = "completed"
+	}
+
+	return result
+}
+
+// convertSpotOccupancy converts a spot occupancy with RFC3339 timestamps,
+// measuring an occupancy in progress until now
+func convertSpotOccupancy(occupancy model.SpotOccupancy, now time.Time) api.SpotOccupancyResult {
+	result := api.SpotOccupancyResult{
+		VehicleNumber:   occupancy.VehicleNumber,
+		ParkedAt:        jsonTime(occupancy.ParkedAt),
+		DurationSeconds: now.Sub(occupancy.ParkedAt).Seconds(),
+	}
+	if occupancy.VacatedAt != nil {
+		result.VacatedAt = jsonTime(*occupancy.VacatedAt)
+		result.DurationSeconds = occupancy.VacatedAt.Sub(occupancy.ParkedAt).Seconds()
+	}
+
+	return result
+}
+
+// convertParkedVehicles converts parked vehicles with RFC3339 parked times, keeping their order
+func convertParkedVehicles(vehicles []model.ParkedVehicle) []api.VehicleResult {
+	results := make([]api.VehicleResult, 0, len(vehicles))
+	for _, vehicle := range vehicles {
+		results = append(results, api.VehicleResult{
+			VehicleNumber: vehicle.VehicleNumber,
+			VehicleType:   string(vehicle.VehicleType),
+			SpotID:        vehicle.SpotID,
+			ParkedAt:      jsonTime(vehicle.ParkedAt),
+		})
+	}
+
+	return results
+}
+
+// parkedVehicleSpots maps each parked vehicle number to its spot ID
+func parkedVehicleSpots(vehicles []model.ParkedVehicle) map[string]string {
+	spots := make(map[string]string, len(vehicles))
+	for _, vehicle := range vehicles {
+		spots[vehicle.VehicleNumber] = vehicle.SpotID
+	}
+
+	return spots
+}
+
+// convertPeakOccupancy converts a peak occupancy report to its JSON form
+func convertPeakOccupancy(report model.PeakOccupancyReport) api.PeakOccupancyResult {
+	convert := func(peak model.PeakOccupancy) api.PeakResult {
+		result := api.PeakResult{Count: peak.Count}
+		if !peak.At.IsZero() {
+			result.At = jsonTime(peak.At)
+		}
+		return result
+	}
+
+	result := api.PeakOccupancyResult{
+		Overall: convert(report.Overall),
+		ByType:  make(map[string]api.PeakResult, len(report.ByType)),
+	}
+	for vehicleType, peak := range report.ByType {
+		result.ByType[string(vehicleType)] = convert(peak)
+	}
+
+	return result
+}
+
+// convertOperationStats converts the counts of a lot's operations
+func convertOperationStats(stats model.OperationStats) api.OperationStatsResult {
+	return api.OperationStatsResult{
+		Parks:             stats.Parks,
+		Unparks:           stats.Unparks,
+		FailedParks:       stats.FailedParks,
+		FailedParksByCode: stats.FailedParksByCode,
+		Searches:          stats.Searches,
+		Resets:            stats.Resets,
+	}
+}
+
+// convertQuotas converts the quotas set, nil when there are none
+func convertQuotas(quotas []model.Quota) []api.QuotaResult {
+	if len(quotas) == 0 {
+		return nil
+	}
+
+	result := make([]api.QuotaResult, len(quotas))
+	for i, quota := range quotas {
+		result[i] = api.QuotaResult{VehicleType: string(quota.VehicleType), Limit: quota.Limit, Used: quota.Used}
+	}
+	return result
+}
+
+// convertLotDiff groups a diff of a snapshot against the lot by floor for diff output
+func convertLotDiff(path string, takenAt time.Time, diff model.LotDiff) api.DiffResult {
+	result := api.DiffResult{
+		Snapshot:       path,
+		TakenAt:        jsonTime(takenAt),
+		Floors:         []api.FloorDiffResult{},
+		OnlyInSnapshot: append([]string{}, diff.OnlyInA...),
+		OnlyInCurrent:  append([]string{}, diff.OnlyInB...),
+	}
+	for _, spot := range diff.Spots {
+		if n := len(result.Floors); n == 0 || result.Floors[n-1].Floor != spot.Floor {
+			result.Floors = append(result.Floors, api.FloorDiffResult{Floor: spot.Floor})
+		}
+		floor := &result.Floors[len(result.Floors)-1]
+		floor.Spots = append(floor.Spots, api.SpotDiffResult{
+			SpotID:   spot.SpotID,
+			Row:      spot.Row,
+			Column:   spot.Column,
+			Snapshot: convertSpotState(spot.A),
+			Current:  convertSpotState(spot.B),
+		})
+	}
+	return result
+}
+
+// convertSpotState converts the state of a spot in a diff, with an empty
+// rather than nil vehicle list
+func convertSpotState(state model.SpotState) api.SpotStateResult {
+	return api.SpotStateResult{
+		Missing:  state.Missing,
+		Type:     string(state.Type),
+		Active:   state.Active,
+		Vehicles: append([]string{}, state.Vehicles...),
+	}
+}
+
+// newLotMetadataResult converts lot metadata for describe output
+func newLotMetadataResult(metadata model.LotMetadata) api.LotMetadataResult {
+	return api.LotMetadataResult{Address: metadata.Address, Timezone: metadata.Timezone, Notes: metadata.Notes}
+}
+
+// convertLotMetadata converts lot metadata for status output, nil when nothing is set
+func convertLotMetadata(metadata model.LotMetadata) *api.LotMetadataResult {
+	if metadata.Address == "" && metadata.Timezone == "" && metadata.Notes == "" {
+		return nil
+	}
+
+	result := newLotMetadataResult(metadata)
+	return &result
+}
+
+// newQuotasResult lists the quotas set for quota output, empty rather than nil
+func newQuotasResult(quotas []model.Quota) api.QuotasResult {
+	return api.QuotasResult{Quotas: append([]api.QuotaResult{}, convertQuotas(quotas)...)}
+}
+
+// convertChargerSummary converts a charger summary, nil when the lot has no chargers
+func convertChargerSummary(summary model.ChargerSummary) *api.ChargerSummaryResult {
+	if summary.Spots == 0 {
+		return nil
+	}
+
+	result := &api.ChargerSummaryResult{
+		Spots:  summary.Spots,
+		States: make(map[string]int, len(summary.States)),
+	}
+	for state, count := range summary.States {
+		result.States[string(state)] = count
+	}
+
+	return result
+}
+
+// convertAccessibleCount converts accessible spot counts, nil when the lot has none
+func convertAccessibleCount(count model.AccessibleCount) *api.AccessibleResult {
+	if count.Spots == 0 {
+		return nil
+	}
+	return &api.AccessibleResult{Spots: count.Spots, Available: count.Available}
+}
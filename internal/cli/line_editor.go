@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Keys the line editor acts on
+const (
+	keyCtrlA     = 1
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlH     = 8
+	keyTab       = 9
+	keyCtrlK     = 11
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// lineEditor reads lines typed at a terminal, with the arrow keys moving
+// through the line and stepping through the history and Tab completing
+type lineEditor struct {
+	in      *bufio.Reader
+	out     io.Writer
+	history func() []string
+	// complete returns the whole-line completions of the text before the
+	// cursor; Tab does nothing when it is nil
+	complete func(string) []string
+
+	// raw puts the terminal into raw mode for one line and returns the
+	// function restoring it; it is nil when the input is not a terminal
+	raw func() (func(), error)
+}
+
+// lineState is the line being edited
+type lineState struct {
+	prompt string
+	line   []rune
+	pos    int
+}
+
+// newTerminalEditor returns a line editor for the terminal f, or nil when
+// the terminal does not support raw mode
+func newTerminalEditor(f *os.File, out io.Writer, history func() []string, complete func(string) []string) *lineEditor {
+	fd := int(f.Fd())
+	restore, err := makeRaw(fd)
+	if err != nil {
+		return nil
+	}
+	restore()
+
+	return &lineEditor{
+		in:       bufio.NewReader(f),
+		out:      out,
+		history:  history,
+		complete: complete,
+		raw:      func() (func(), error) { return makeRaw(fd) },
+	}
+}
+
+// ReadLine shows the prompt and returns the line typed. Ctrl+C drops the
+// line and starts over; Ctrl+D on an empty line returns io.EOF.
+func (e *lineEditor) ReadLine(prompt string) (string, error) {
+	if e.raw != nil {
+		restore, err := e.raw()
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+	}
+
+	s := &lineState{prompt: prompt}
+	history := e.history()
+	index := len(history)
+	// The line being typed while stepping through the history
+	var typed []rune
+
+	fmt.Fprint(e.out, prompt)
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			if err == io.EOF && len(s.line) > 0 {
+				fmt.Fprintln(e.out)
+				return string(s.line), nil
+			}
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprintln(e.out)
+			return string(s.line), nil
+		case keyCtrlC:
+			fmt.Fprintln(e.out, "^C")
+			fmt.Fprint(e.out, prompt)
+			s.line, s.pos, index = nil, 0, len(history)
+			continue
+		case keyCtrlD:
+			if len(s.line) == 0 {
+				fmt.Fprintln(e.out)
+				return "", io.EOF
+			}
+			s.deleteAt(s.pos)
+		case keyBackspace, keyCtrlH:
+			if s.pos > 0 {
+				s.pos--
+				s.deleteAt(s.pos)
+			}
+		case keyTab:
+			if e.complete == nil {
+				continue
+			}
+			e.completeLine(s)
+		case keyCtrlA:
+			s.pos = 0
+		case keyCtrlE:
+			s.pos = len(s.line)
+		case keyCtrlK:
+			s.line = s.line[:s.pos]
+		case keyCtrlU:
+			s.line, s.pos = s.line[s.pos:], 0
+		case keyEscape:
+			switch e.readEscape() {
+			case "A":
+				if index == 0 {
+					continue
+				}
+				if index == len(history) {
+					typed = s.line
+				}
+				index--
+				s.set([]rune(history[index]))
+			case "B":
+				if index == len(history) {
+					continue
+				}
+				index++
+				if index == len(history) {
+					s.set(typed)
+				} else {
+					s.set([]rune(history[index]))
+				}
+			case "C":
+				if s.pos < len(s.line) {
+					s.pos++
+				}
+			case "D":
+				if s.pos > 0 {
+					s.pos--
+				}
+			case "H", "1~", "7~":
+				s.pos = 0
+			case "F", "4~", "8~":
+				s.pos = len(s.line)
+			case "3~":
+				s.deleteAt(s.pos)
+			default:
+				continue
+			}
+		default:
+			if !unicode.IsPrint(r) {
+				continue
+			}
+			s.line = append(s.line[:s.pos], append([]rune{r}, s.line[s.pos:]...)...)
+			s.pos++
+		}
+
+		s.redraw(e.out)
+	}
+}
+
+// readEscape reads the rest of an escape sequence after the escape key and
+// returns what follows its introducer, such as "A" for the up arrow or
+// "3~" for Delete
+func (e *lineEditor) readEscape() string {
+	r, _, err := e.in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return ""
+	}
+
+	var seq []rune
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return ""
+		}
+		seq = append(seq, r)
+		if r < '0' || r > '9' {
+			return string(seq)
+		}
+	}
+}
+
+// completeLine completes the text before the cursor. A single completion
+// replaces it; several extend it to the prefix they share or, when they
+// share no more, are listed under the line.
+func (e *lineEditor) completeLine(s *lineState) {
+	completions := e.complete(string(s.line[:s.pos]))
+	switch len(completions) {
+	case 0:
+		return
+	case 1:
+		s.replaceBeforeCursor([]rune(completions[0] + " "))
+		return
+	}
+
+	if common := commonPrefix(completions); len(common) > s.pos {
+		s.replaceBeforeCursor(common)
+		return
+	}
+
+	words := make([]string, len(completions))
+	for i, completion := range completions {
+		words[i] = completion[strings.LastIndex(completion, " ")+1:]
+	}
+	fmt.Fprintln(e.out)
+	fmt.Fprintln(e.out, strings.Join(words, "  "))
+}
+
+// commonPrefix returns the longest prefix all the lines share
+func commonPrefix(lines []string) []rune {
+	common := []rune(lines[0])
+	for _, line := range lines[1:] {
+		runes := []rune(line)
+		n := 0
+		for n < len(common) && n < len(runes) && common[n] == runes[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	return common
+}
+
+// replaceBeforeCursor replaces the text before the cursor, leaving the
+// cursor after the new text
+func (s *lineState) replaceBeforeCursor(text []rune) {
+	s.line = append(append([]rune(nil), text...), s.line[s.pos:]...)
+	s.pos = len(text)
+}
+
+// set replaces the line, leaving the cursor at its end
+func (s *lineState) set(line []rune) {
+	s.line = append([]rune(nil), line...)
+	s.pos = len(s.line)
+}
+
+// deleteAt deletes the rune at i, if there is one
+func (s *lineState) deleteAt(i int) {
+	if i < len(s.line) {
+		s.line = append(s.line[:i], s.line[i+1:]...)
+	}
+}
+
+// redraw rewrites the prompt and line and puts the cursor back in place
+func (s *lineState) redraw(out io.Writer) {
+	fmt.Fprintf(out, "\r%s%s\033[K", s.prompt, string(s.line))
+	if back := len(s.line) - s.pos; back > 0 {
+		fmt.Fprintf(out, "\033[%dD", back)
+	}
+}
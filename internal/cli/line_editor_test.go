@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// newTestEditor returns a line editor reading the keys typed from input
+func newTestEditor(input string, history []string) *lineEditor {
+	return &lineEditor{
+		in:      bufio.NewReader(strings.NewReader(input)),
+		out:     &bytes.Buffer{},
+		history: func() []string { return history },
+	}
+}
+
+func TestLineEditor(t *testing.T) {
+	history := []string{"init 1 2 5", "status"}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"typed", "status\r", "status"},
+		{"backspace", "statsu\x7f\x7fus\r", "status"},
+		{"left arrow inserts in place", "sttus\x1b[D\x1b[D\x1b[Da\r", "status"},
+		{"home and delete", "xstatus\x1b[H\x1b[3~\r", "status"},
+		{"up arrow", "\x1b[A\r", "status"},
+		{"up twice", "\x1b[A\x1b[A\r", "init 1 2 5"},
+		{"up past the oldest", "\x1b[A\x1b[A\x1b[A\r", "init 1 2 5"},
+		{"down back to the typed line", "help\x1b[A\x1b[B\r", "help"},
+		{"edit a recalled command", "\x1b[A\x1b[A\x7f3\r", "init 1 2 3"},
+		{"ctrl+c drops the line", "park automobile\x03help\r", "help"},
+		{"ctrl+u clears before the cursor", "abc\x15help\r", "help"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := newTestEditor(test.input, history).ReadLine("> ")
+			if err != nil {
+				t.Fatalf("Failed to read line: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("Expected %q, got %q", test.want, got)
+			}
+		})
+	}
+
+	// Ctrl+D ends the input on an empty line only
+	if _, err := newTestEditor("\x04", nil).ReadLine("> "); err != io.EOF {
+		t.Errorf("Expected io.EOF for Ctrl+D, got %v", err)
+	}
+	if got, _ := newTestEditor("ab\x1b[D\x04\r", nil).ReadLine("> "); got != "a" {
+		t.Errorf("Expected Ctrl+D to delete under the cursor, got %q", got)
+	}
+}
+
+func TestLineEditorCompletion(t *testing.T) {
+	complete := func(line string) []string {
+		var completions []string
+		for _, command := range []string{"park", "park-at", "permit", "status"} {
+			if strings.HasPrefix(command, line) {
+				completions = append(completions, command)
+			}
+		}
+		return completions
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"st\t\r", "status "},
+		{"pa\t\r", "park"},
+		{"p\t\t\r", "p"},
+		{"x\t\r", "x"},
+	}
+
+	for _, test := range tests {
+		out := &bytes.Buffer{}
+		editor := newTestEditor(test.input, nil)
+		editor.out, editor.complete = out, complete
+		got, err := editor.ReadLine("> ")
+		if err != nil {
+			t.Fatalf("Failed to read line: %v", err)
+		}
+		if got != test.want {
+			t.Errorf("Expected %q for %q, got %q", test.want, test.input, got)
+		}
+		if test.input == "p\t\t\r" && !strings.Contains(out.String(), "park  park-at  permit") {
+			t.Errorf("Expected the completions listed, got %q", out.String())
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// Defaults for the rotation of log files
+const (
+	DefaultLogMaxSize  = 10 << 20
+	DefaultLogMaxFiles = 5
+)
+
+// RotatingWriter appends to a file until writing to it would take it past
+// a size, when the file is rolled over to path.1, path.1 to path.2 and so
+// on, keeping at most a number of the files rolled over
+type RotatingWriter struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+// OpenRotatingWriter opens the file at path for appending, rolling it over
+// once it would grow past maxSize bytes and keeping maxFiles rolled over
+func OpenRotatingWriter(path string, maxSize int64, maxFiles int) (*RotatingWriter, error) {
+	if maxSize < 1 || maxFiles < 1 {
+		return nil, errors.New("log files need a size and a number to keep of at least 1")
+	}
+	w := &RotatingWriter{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// open opens the file at the writer's path, noting how much it holds
+func (w *RotatingWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	w.file, w.size = file, info.Size()
+	return nil
+}
+
+// Write appends p to the file, rolling it over first when p would take it
+// past the size. A write larger than the size goes to a file of its own.
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	if w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotate rolls the file over, dropping the oldest one kept; w.mu must be
+// held
+func (w *RotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to roll over log file: %w", err)
+	}
+	w.file = nil
+
+	for n := w.maxFiles - 1; n >= 1; n-- {
+		older := fmt.Sprintf("%s.%d", w.path, n)
+		if err := os.Rename(older, fmt.Sprintf("%s.%d", w.path, n+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to roll over log file: %w", err)
+		}
+	}
+	if err := os.Rename(w.path, w.path+".1"); err != nil {
+		return fmt.Errorf("failed to roll over log file: %w", err)
+	}
+	return w.open()
+}
+
+// Close closes the file
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// logFile is where log messages are copied to besides stderr, if anywhere
+var logFile atomic.Pointer[io.Writer]
+
+// SetLogFile copies every log message to w, without colors, as well as
+// writing it to stderr; nil stops the copying
+func SetLogFile(w io.Writer) {
+	if w == nil {
+		logFile.Store(nil)
+		return
+	}
+	logFile.Store(&w)
+}
+
+// writeLogFile copies a log line to the log file, if one is set, without
+// its colors
+func writeLogFile(line string) {
+	if w := logFile.Load(); w != nil {
+		fmt.Fprintln(*w, colorCodes.ReplaceAllString(line, ""))
+	}
+}
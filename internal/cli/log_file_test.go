@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "parking.log")
+	w, err := OpenRotatingWriter(path, 20, 2)
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+	defer w.Close()
+
+	// Each line of 10 bytes fills half a file
+	for _, line := range []string{"line 0001\n", "line 0002\n", "line 0003\n", "line 0004\n", "line 0005\n", "line 0006\n", "line 0007\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Failed to write %q: %v", line, err)
+		}
+	}
+
+	// The oldest lines are dropped once two files are rolled over
+	expected := map[string]string{
+		path:        "line 0007\n",
+		path + ".1": "line 0005\nline 0006\n",
+		path + ".2": "line 0003\nline 0004\n",
+	}
+	for file, contents := range expected {
+		got, err := os.ReadFile(file)
+		if err != nil || string(got) != contents {
+			t.Errorf("Expected %s to hold %q, got %q (%v)", filepath.Base(file), contents, got, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected no third file rolled over, got %v", err)
+	}
+
+	// Reopening appends to the file already there
+	w.Close()
+	w, err = OpenRotatingWriter(path, 20, 2)
+	if err != nil {
+		t.Fatalf("Failed to reopen log file: %v", err)
+	}
+	w.Write([]byte("line 0008\n"))
+	w.Write([]byte("line 0009\n"))
+	if got, _ := os.ReadFile(path + ".1"); string(got) != "line 0007\nline 0008\n" {
+		t.Errorf("Expected the reopened file rolled over when full, got %q", got)
+	}
+
+	if _, err := OpenRotatingWriter(filepath.Join(t.TempDir(), "missing", "parking.log"), 20, 2); err == nil {
+		t.Error("Expected a log file in a missing directory to fail to open")
+	}
+}
+
+func TestLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "parking.log")
+	w, err := OpenRotatingWriter(path, DefaultLogMaxSize, DefaultLogMaxFiles)
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+	defer w.Close()
+	SetColorOutput(true)
+	SetLogFile(w)
+	t.Cleanup(func() { SetLogFile(nil) })
+
+	// The file gets the message without the colors stderr gets
+	stderr := captureStderr(t, func() {
+		NewLogger(false).Warning("Lot %s", colorize(colorRed, "full"))
+	})
+	if !strings.Contains(stderr, colorYellow) {
+		t.Errorf("Expected a colored warning on stderr, got %q", stderr)
+	}
+	logged, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if !strings.HasSuffix(string(logged), "] [WARN] Lot full\n") || strings.Contains(string(logged), "\033") {
+		t.Errorf("Expected the warning logged without colors, got %q", logged)
+	}
+}
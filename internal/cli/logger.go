@@ -1,9 +1,16 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/model"
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/config"
 )
 
 // LogLevel represents the level of a log message
@@ -16,63 +23,235 @@ const (
 	LogLevelError
 )
 
+// LogFormat represents how log messages are written
+type LogFormat int
+
+const (
+	// LogFormatText writes a colored line of text a message
+	LogFormatText LogFormat = iota
+	// LogFormatJSON writes a JSON object a line, for log aggregators
+	LogFormatJSON
+)
+
+// logFormatSetting is the format every logger writes in
+var logFormatSetting atomic.Int32
+
+// SetLogFormat sets the format every logger writes in; text by default
+func SetLogFormat(format LogFormat) {
+	logFormatSetting.Store(int32(format))
+}
+
+// LogFormatSetting returns the format loggers write in
+func LogFormatSetting() LogFormat {
+	return LogFormat(logFormatSetting.Load())
+}
+
+// ParseLogFormat parses the name of a log format
+func ParseLogFormat(value string) (LogFormat, error) {
+	switch value {
+	case "text":
+		return LogFormatText, nil
+	case "json":
+		return LogFormatJSON, nil
+	}
+	return LogFormatText, fmt.Errorf("unknown log format %q, expected text or json", value)
+}
+
+// logLevelSetting is the lowest level every logger writes
+var logLevelSetting atomic.Int32
+
+// SetLogLevel sets the lowest level every logger writes, for loggers
+// created after; debug messages still need verbose mode
+func SetLogLevel(level LogLevel) {
+	logLevelSetting.Store(int32(level))
+}
+
+// LogLevelSetting returns the lowest level loggers write
+func LogLevelSetting() LogLevel {
+	return LogLevel(logLevelSetting.Load())
+}
+
+// ParseLogLevel parses the name of a log level, in any case
+func ParseLogLevel(value string) (LogLevel, error) {
+	switch strings.ToLower(value) {
+	case "debug":
+		return LogLevelDebug, nil
+	case "info":
+		return LogLevelInfo, nil
+	case "warn":
+		return LogLevelWarning, nil
+	case "error":
+		return LogLevelError, nil
+	}
+	return LogLevelInfo, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", value)
+}
+
 // Logger is a simple logger with levels
 type Logger struct {
 	Verbose bool
 	Level   LogLevel
+
+	// fields are the key/value pairs added to every message
+	fields []interface{}
+
+	// out is where messages are written
+	out io.Writer
 }
 
-// NewLogger creates a new logger
+// NewLogger creates a new logger writing to standard error
 func NewLogger(verbose bool) *Logger {
 	level := LogLevelInfo
 	if verbose {
 		level = LogLevelDebug
 	}
+	if lowest := LogLevelSetting(); level < lowest {
+		level = lowest
+	}
 
 	return &Logger{
 		Verbose: verbose,
 		Level:   level,
+		out:     stdWriter{&os.Stderr},
 	}
 }
 
-// formatLogMessage formats a log message with timestamp and level
+// SetOutput sends the logger's messages to w instead of standard error
+func (l *Logger) SetOutput(w io.Writer) {
+	l.out = w
+}
+
+// WithFields returns a logger adding the key/value pairs to every message,
+// after those the logger already adds, e.g.
+// WithFields("vehicle", number, "spot", spotID)
+func (l *Logger) WithFields(keyvals ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(keyvals))
+	fields = append(append(fields, l.fields...), keyvals...)
+	return &Logger{Verbose: l.Verbose, Level: l.Level, fields: fields, out: l.out}
+}
+
+// formatLogMessage formats a log message with timestamp and level. The
+// timestamp is in the zone set for times, and in the layout set when one is.
 func formatLogMessage(level string, message string) string {
-	timestamp := time.Now().Format("15:04:05.000")
+	layout := "15:04:05.000"
+	if custom := timeLayout(); custom != config.DefaultTimeLayout {
+		layout = custom
+	}
+	timestamp := time.Now().In(displayLocation(time.Local)).Format(layout)
 	return fmt.Sprintf("[%s] [%s] %s", timestamp, level, message)
 }
 
+// logFields pairs up key/value pairs, naming a value left without a key
+// !BADKEY as log/slog does
+func logFields(keyvals []interface{}) [][2]interface{} {
+	var fields [][2]interface{}
+	for i := 0; i < len(keyvals); i += 2 {
+		if i+1 == len(keyvals) {
+			fields = append(fields, [2]interface{}{"!BADKEY", keyvals[i]})
+			break
+		}
+		fields = append(fields, [2]interface{}{fmt.Sprint(keyvals[i]), keyvals[i+1]})
+	}
+	return fields
+}
+
+// formatJSONLogMessage formats a log message as a JSON object with its
+// timestamp in RFC 3339 and UTC, its level, the message and its fields
+func formatJSONLogMessage(level string, message string, keyvals []interface{}) string {
+	var builder strings.Builder
+	add := func(key string, value interface{}) {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		encodedValue, err := json.Marshal(value)
+		if err != nil {
+			encodedValue, _ = json.Marshal(fmt.Sprint(value))
+		}
+		encodedKey, _ := json.Marshal(key)
+		if builder.Len() > 0 {
+			builder.WriteByte(',')
+		}
+		builder.Write(encodedKey)
+		builder.WriteByte(':')
+		builder.Write(encodedValue)
+	}
+
+	add("ts", time.Now().UTC().Format(time.RFC3339Nano))
+	add("level", strings.ToLower(level))
+	add("msg", message)
+	for _, field := range logFields(keyvals) {
+		add(field[0].(string), field[1])
+	}
+	return "{" + builder.String() + "}"
+}
+
+// log writes a message at a level in the format set, to the logger's
+// output and the log file
+func (l *Logger) log(level string, kind messageKind, format string, args []interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if LogFormatSetting() == LogFormatJSON {
+		line := formatJSONLogMessage(level, message, l.fields)
+		fmt.Fprintln(l.out, line)
+		writeLogFile(line)
+		return
+	}
+
+	for _, field := range logFields(l.fields) {
+		message += fmt.Sprintf(" %s=%v", field[0], field[1])
+	}
+	line := formatLogMessage(level, message)
+	fmt.Fprintln(l.out, style(kind, line))
+	writeLogFile(line)
+}
+
 // Debug logs a debug message
 func (l *Logger) Debug(format string, args ...interface{}) {
 	if l.Verbose && l.Level <= LogLevelDebug {
-		message := fmt.Sprintf(format, args...)
-		formattedMsg := formatLogMessage("DEBUG", message)
-		fmt.Fprintln(os.Stderr, colorCyan+formattedMsg+colorReset)
+		l.log("DEBUG", kindDebug, format, args)
 	}
 }
 
 // Info logs an info message
 func (l *Logger) Info(format string, args ...interface{}) {
 	if l.Level <= LogLevelInfo {
-		message := fmt.Sprintf(format, args...)
-		formattedMsg := formatLogMessage("INFO", message)
-		fmt.Fprintln(os.Stderr, colorBlue+formattedMsg+colorReset)
+		l.log("INFO", kindInfo, format, args)
 	}
 }
 
 // Warning logs a warning message
 func (l *Logger) Warning(format string, args ...interface{}) {
 	if l.Level <= LogLevelWarning {
-		message := fmt.Sprintf(format, args...)
-		formattedMsg := formatLogMessage("WARN", message)
-		fmt.Fprintln(os.Stderr, colorYellow+formattedMsg+colorReset)
+		l.log("WARN", kindWarning, format, args)
 	}
 }
 
+// Warn logs a warning message, as Warning does
+func (l *Logger) Warn(format string, args ...interface{}) {
+	l.Warning(format, args...)
+}
+
 // Error logs an error message
 func (l *Logger) Error(format string, args ...interface{}) {
 	if l.Level <= LogLevelError {
-		message := fmt.Sprintf(format, args...)
-		formattedMsg := formatLogMessage("ERROR", message)
-		fmt.Fprintln(os.Stderr, colorRed+formattedMsg+colorReset)
+		l.log("ERROR", kindError, format, args)
 	}
 }
+
+// lotLogger passes a parking lot's diagnostics to the registry's logger. It
+// looks the logger up on every message because --verbose replaces it after
+// the lot is created.
+type lotLogger struct {
+	registry *CommandRegistry
+}
+
+var _ model.Logger = lotLogger{}
+
+// Debug logs a lot's debug message with its key/value pairs as fields,
+// shown with --verbose
+func (l lotLogger) Debug(msg string, keyvals ...interface{}) {
+	l.registry.Logger.WithFields(keyvals...).Debug("%s", msg)
+}
+
+// Warn logs a lot's warning with its key/value pairs as fields
+func (l lotLogger) Warn(msg string, keyvals ...interface{}) {
+	l.registry.Logger.WithFields(keyvals...).Warning("%s", msg)
+}
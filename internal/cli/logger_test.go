@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJSONLogging(t *testing.T) {
+	SetLogFormat(LogFormatJSON)
+	t.Cleanup(func() { SetLogFormat(LogFormatText) })
+
+	// Info hides debug messages, and fields are added after the logger's own
+	logger := NewLogger(false).WithFields("vehicle", "KA-01-HH-1234")
+	output := captureStderr(t, func() {
+		logger.Debug("not shown")
+		logger.WithFields("spot", "0-0-2", "attempt", 2).Info("Parked %s", "car")
+		logger.Error("Failed: %v", errors.New("lot full"))
+		logger.WithFields("dangling").Warning("odd fields")
+	})
+
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Expected a JSON object a line, got %q: %v", line, err)
+		}
+		lines = append(lines, entry)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines without the debug one, got %d: %q", len(lines), output)
+	}
+
+	parked := lines[0]
+	if parked["level"] != "info" || parked["msg"] != "Parked car" || parked["vehicle"] != "KA-01-HH-1234" ||
+		parked["spot"] != "0-0-2" || parked["attempt"] != 2.0 {
+		t.Errorf("Unexpected info line: %v", parked)
+	}
+	if ts, ok := parked["ts"].(string); !ok || !strings.HasSuffix(ts, "Z") {
+		t.Errorf("Expected a UTC timestamp, got %v", parked["ts"])
+	}
+	if lines[1]["level"] != "error" || lines[1]["msg"] != "Failed: lot full" || lines[1]["spot"] != nil {
+		t.Errorf("Unexpected error line: %v", lines[1])
+	}
+	if lines[2]["level"] != "warn" || lines[2]["!BADKEY"] != "dangling" {
+		t.Errorf("Expected a value without a key kept, got %v", lines[2])
+	}
+
+	// Verbose shows debug messages too
+	output = captureStderr(t, func() { NewLogger(true).Debug("shown") })
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &entry); err != nil || entry["level"] != "debug" || entry["msg"] != "shown" {
+		t.Errorf("Expected the debug line, got %q", output)
+	}
+}
+
+func TestLotLoggerFields(t *testing.T) {
+	SetLogFormat(LogFormatJSON)
+	t.Cleanup(func() { SetLogFormat(LogFormatText) })
+
+	// A lot's key/value pairs become fields, and its message is never taken
+	// as a format
+	c := newTestCLI(t)
+	c.Logger = c.newLogger(true)
+	logger := lotLogger{registry: c.CommandRegistry}
+	logger.Debug("100% taken", "spot", "0-0-2", "attempt", 2)
+	logger.Warn("undo failed", "vehicle", "KA-01-HH-1234")
+
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(c.out.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Expected a JSON object a line, got %q: %v", line, err)
+		}
+		lines = append(lines, entry)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %q", len(lines), c.out.String())
+	}
+	if lines[0]["level"] != "debug" || lines[0]["msg"] != "100% taken" ||
+		lines[0]["spot"] != "0-0-2" || lines[0]["attempt"] != 2.0 {
+		t.Errorf("Unexpected debug line: %v", lines[0])
+	}
+	if lines[1]["level"] != "warn" || lines[1]["msg"] != "undo failed" || lines[1]["vehicle"] != "KA-01-HH-1234" {
+		t.Errorf("Unexpected warning line: %v", lines[1])
+	}
+}
+
+func TestTextLogging(t *testing.T) {
+	SetColorOutput(false)
+	t.Cleanup(func() { SetColorOutput(true) })
+
+	output := captureStderr(t, func() {
+		NewLogger(false).WithFields("vehicle", "KA-01-HH-1234", "spot", "0-0-2").Info("Parked")
+	})
+	if !strings.HasSuffix(output, "] [INFO] Parked vehicle=KA-01-HH-1234 spot=0-0-2\n") {
+		t.Errorf("Expected the fields after the message, got %q", output)
+	}
+}
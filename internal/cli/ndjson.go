@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/model"
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/api"
+)
+
+// ndjsonWriter writes results as NDJSON, one JSON object a line, as they
+// are produced, so that no list of them is built
+type ndjsonWriter struct {
+	out     *bufio.Writer
+	encoder *json.Encoder
+	err     error
+}
+
+// newNDJSONWriter returns a writer of NDJSON to w
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	out := bufio.NewWriter(w)
+	return &ndjsonWriter{out: out, encoder: json.NewEncoder(out)}
+}
+
+// write writes a record on a line of its own, returning false once writing
+// has failed, so that the walk producing the records stops
+func (w *ndjsonWriter) write(record interface{}) bool {
+	if w.err == nil {
+		w.err = w.encoder.Encode(record)
+	}
+	return w.err == nil
+}
+
+// close flushes the records written and returns the first failure
+func (w *ndjsonWriter) close() error {
+	if err := w.out.Flush(); w.err == nil {
+		w.err = err
+	}
+	if w.err != nil {
+		return fmt.Errorf("failed to write results: %v", w.err)
+	}
+	return nil
+}
+
+// streamAvailable writes the available spots for a vehicle type as NDJSON,
+// on one floor when floor is set and at most limit of them when limit is
+// positive
+func (r *CommandRegistry) streamAvailable(vehicleType model.VehicleType, floor *model.ParkingFloor, limit int) error {
+	w := newNDJSONWriter(r.out.Out)
+	written := 0
+	var locateErr error
+	write := func(floorNum int, spotID string) bool {
+		location, err := spotLocation(r.parkingLot, spotID)
+		if err != nil {
+			locateErr = err
+			return false
+		}
+		written++
+		return w.write(api.AvailableSpotResult{VehicleType: string(vehicleType), Floor: floorNum, SpotID: spotID, Location: location}) &&
+			(limit <= 0 || written < limit)
+	}
+
+	if floor != nil {
+		floor.ForEachAvailableSpot(vehicleType, func(spot *model.ParkingSpot) bool {
+			return write(floor.FloorNumber, spot.GetSpotID())
+		})
+	} else if err := r.parkingLot.ForEachAvailableSpot(vehicleType, write); err != nil {
+		return fmt.Errorf("failed to get available spots: %w", err)
+	}
+
+	r.Logger.Debug("Streamed %d available spots for %s", written, vehicleType)
+	if err := w.close(); err != nil {
+		return err
+	}
+	return locateErr
+}
+
+// streamSpots writes the spots matching the filter as NDJSON, skipping
+// offset of them and stopping after limit when limit is positive
+func (r *CommandRegistry) streamSpots(filter model.SpotFilter, offset, limit int) error {
+	w := newNDJSONWriter(r.out.Out)
+	matched, written := 0, 0
+	r.parkingLot.ForEachSpot(filter, func(spot model.SpotInfo) bool {
+		matched++
+		if matched <= offset {
+			return true
+		}
+		written++
+		return w.write(convertSpotInfo(spot)) && (limit <= 0 || written < limit)
+	})
+
+	r.Logger.Debug("Streamed %d spots matching filter", written)
+	return w.close()
+}
+
+// streamHistoryRange writes the parking sessions overlapping [from, to] as
+// NDJSON, in no particular order since sorting them would need them all
+func (r *CommandRegistry) streamHistoryRange(from, to time.Time) error {
+	now := r.now()
+	w := newNDJSONWriter(r.out.Out)
+	r.parkingLot.ForEachHistoryEntry(from, to, func(entry model.HistoryEntry) bool {
+		return w.write(api.HistoryEntryResult{
+			VehicleNumber:       entry.VehicleNumber,
+			VehicleType:         string(entry.VehicleType),
+			ParkingRecordResult: convertParkingRecord(entry.ParkingRecord, now),
+		})
+	})
+	return w.close()
+}
@@ -2,8 +2,15 @@ package cli
 
 import (
 	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 )
 
 // Colors for terminal output
@@ -18,59 +25,250 @@ const (
 	colorWhite  = "\033[37m"
 )
 
+// messageKind is the kind of a message printed or logged, which decides
+// its color
+type messageKind int
+
+const (
+	kindError messageKind = iota
+	kindSuccess
+	kindInfo
+	kindWarning
+	kindDebug
+)
+
+// kindColors holds the color of each kind of message
+var kindColors = [...]string{
+	kindError:   colorRed,
+	kindSuccess: colorGreen,
+	kindInfo:    colorBlue,
+	kindWarning: colorYellow,
+	kindDebug:   colorCyan,
+}
+
+// colorCodes matches the escape sequences coloring output
+var colorCodes = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// plainOutput turns colors off, for output not going to a terminal
+var plainOutput atomic.Bool
+
+// ColorEnabled decides whether output is colored: never with disable,
+// always with force, and otherwise only when it goes to a terminal and the
+// NO_COLOR environment variable is not set
+func ColorEnabled(force, disable, tty bool) bool {
+	switch {
+	case disable:
+		return false
+	case force:
+		return true
+	case os.Getenv("NO_COLOR") != "":
+		return false
+	}
+	return tty
+}
+
+// SetColorOutput turns colored output on or off; it is on by default
+func SetColorOutput(enabled bool) {
+	plainOutput.Store(!enabled)
+}
+
+// ColorOutput reports whether output is colored
+func ColorOutput() bool {
+	return !plainOutput.Load()
+}
+
+// colorize wraps text in the color, unless colors are off
+func colorize(color, text string) string {
+	if plainOutput.Load() {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// style colors a message by its kind, unless colors are off
+func style(kind messageKind, text string) string {
+	return colorize(kindColors[kind], text)
+}
+
+// Output is where commands write: their results to Out and the errors
+// they report along the way to Err
+type Output struct {
+	Out io.Writer
+	Err io.Writer
+}
+
+// NewOutput returns an output writing results to out and errors to errOut
+func NewOutput(out, errOut io.Writer) *Output {
+	return &Output{Out: out, Err: errOut}
+}
+
+// StdOutput returns an output writing to standard output and error
+func StdOutput() *Output {
+	return NewOutput(stdWriter{&os.Stdout}, stdWriter{&os.Stderr})
+}
+
+// stdWriter writes to the standard output or error the process has at
+// the time of each write, so that it follows the file being replaced
+type stdWriter struct {
+	file **os.File
+}
+
+func (w stdWriter) Write(p []byte) (int, error) {
+	return (*w.file).Write(p)
+}
+
+// terminal reports whether results go to a terminal
+func (o *Output) terminal() bool {
+	switch out := o.Out.(type) {
+	case stdWriter:
+		return IsTerminal(*out.file)
+	case *os.File:
+		return IsTerminal(out)
+	}
+	return false
+}
+
+// Printf writes formatted text to the output
+func (o *Output) Printf(format string, args ...any) {
+	fmt.Fprintf(o.Out, format, args...)
+}
+
+// Println writes its arguments and a newline to the output
+func (o *Output) Println(args ...any) {
+	fmt.Fprintln(o.Out, args...)
+}
+
+// Print writes its arguments to the output
+func (o *Output) Print(args ...any) {
+	fmt.Fprint(o.Out, args...)
+}
+
+// Error writes an error message in red
+func (o *Output) Error(format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	o.Println(style(kindError, "Error: "+message))
+}
+
+// Success writes a success message in green
+func (o *Output) Success(format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	o.Println(style(kindSuccess, message))
+}
+
+// Info writes an info message in blue
+func (o *Output) Info(format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	o.Println(style(kindInfo, message))
+}
+
+// Warning writes a warning message in yellow
+func (o *Output) Warning(format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	o.Println(style(kindWarning, message))
+}
+
 // PrintError prints an error message in red
 func PrintError(format string, args ...any) {
-	message := fmt.Sprintf(format, args...)
-	fmt.Printf("%sError: %s%s\n", colorRed, message, colorReset)
+	StdOutput().Error(format, args...)
 }
 
 // PrintSuccess prints a success message in green
 func PrintSuccess(format string, args ...any) {
-	message := fmt.Sprintf(format, args...)
-	fmt.Printf("%s%s%s\n", colorGreen, message, colorReset)
+	StdOutput().Success(format, args...)
 }
 
 // PrintInfo prints an info message in blue
 func PrintInfo(format string, args ...any) {
-	message := fmt.Sprintf(format, args...)
-	fmt.Printf("%s%s%s\n", colorBlue, message, colorReset)
+	StdOutput().Info(format, args...)
 }
 
 // PrintWarning prints a warning message in yellow
 func PrintWarning(format string, args ...any) {
-	message := fmt.Sprintf(format, args...)
-	fmt.Printf("%s%s%s\n", colorYellow, message, colorReset)
+	StdOutput().Warning(format, args...)
 }
 
-// FormatTable formats data as a table with columns
+// terminalWidth is the width of the terminal output goes to, 0 when it
+// goes elsewhere
+var terminalWidth atomic.Int64
+
+// tableWidthSetting is the width set for tables, 0 to follow the terminal
+var tableWidthSetting atomic.Int64
+
+// wrapCells wraps cells too long for their column rather than truncate them
+var wrapCells atomic.Bool
+
+// SetTerminalWidth records the width of the terminal output goes to, 0
+// when it is not a terminal
+func SetTerminalWidth(width int) {
+	terminalWidth.Store(int64(width))
+}
+
+// SetTableWidth sets the width tables are fitted to, 0 to follow the
+// terminal's
+func SetTableWidth(width int) {
+	tableWidthSetting.Store(int64(width))
+}
+
+// TableWidthSetting returns the width set for tables, 0 when they follow
+// the terminal's
+func TableWidthSetting() int {
+	return int(tableWidthSetting.Load())
+}
+
+// TableWidth returns the width tables are fitted to, 0 for no limit
+func TableWidth() int {
+	if width := tableWidthSetting.Load(); width > 0 {
+		return int(width)
+	}
+	return int(terminalWidth.Load())
+}
+
+// SetCellWrapping turns wrapping long cells onto continuation lines on or
+// off; they are truncated by default
+func SetCellWrapping(enabled bool) {
+	wrapCells.Store(enabled)
+}
+
+// CellWrapping reports whether long cells are wrapped
+func CellWrapping() bool {
+	return wrapCells.Load()
+}
+
+// FormatTable formats data as a table with columns, fitted to the table width
 func FormatTable(headers []string, rows [][]string) string {
+	return formatTable(headers, rows, TableWidth(), CellWrapping())
+}
+
+// formatTable formats data as a table within width characters, or as wide
+// as its cells when width is 0. Columns too wide to fit are shrunk, their
+// cells truncated with an ellipsis or, with wrap, continued on more lines.
+// A row longer than the headers adds columns and a shorter one is padded.
+func formatTable(headers []string, rows [][]string, width int, wrap bool) string {
 	if len(rows) == 0 {
 		return "No data to display"
 	}
 
 	// Calculate column widths
-	colWidths := make([]int, len(headers))
+	columns := len(headers)
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	colWidths := make([]int, columns)
 	for i, header := range headers {
-		colWidths[i] = len(header)
+		colWidths[i] = utf8.RuneCountInString(header)
 	}
-
 	for _, row := range rows {
 		for i, cell := range row {
-			if i < len(colWidths) && len(cell) > colWidths[i] {
-				colWidths[i] = len(cell)
-			}
+			colWidths[i] = max(colWidths[i], utf8.RuneCountInString(cell))
 		}
 	}
+	if width > 0 {
+		colWidths = fitColumns(colWidths, width)
+	}
 
 	// Build the table
 	var builder strings.Builder
-
-	// Add headers
-	for i, header := range headers {
-		format := fmt.Sprintf("%%-%ds", colWidths[i]+2)
-		builder.WriteString(fmt.Sprintf(format, header))
-	}
-	builder.WriteString("\n")
+	writeTableRow(&builder, headers, colWidths, wrap)
 
 	// Add separator
 	for _, width := range colWidths {
@@ -78,33 +276,260 @@ func FormatTable(headers []string, rows [][]string) string {
 	}
 	builder.WriteString("\n")
 
-	// Add rows
 	for _, row := range rows {
-		for i, cell := range row {
-			if i < len(colWidths) {
-				format := fmt.Sprintf("%%-%ds", colWidths[i]+2)
-				builder.WriteString(fmt.Sprintf(format, cell))
+		writeTableRow(&builder, row, colWidths, wrap)
+	}
+
+	return builder.String()
+}
+
+// fitColumns shrinks column widths to fit a table within width characters,
+// each column taking 2 more for its padding. Columns narrower than an even
+// share of the room keep their width and the rest share what is left in
+// proportion to their widths.
+func fitColumns(colWidths []int, width int) []int {
+	room := width - 2*len(colWidths)
+	total := 0
+	for _, w := range colWidths {
+		total += w
+	}
+	if total <= room {
+		return colWidths
+	}
+
+	fitted := make([]int, len(colWidths))
+	copy(fitted, colWidths)
+	wide := make([]int, len(colWidths))
+	for i := range wide {
+		wide[i] = i
+	}
+
+	// Settle the narrow columns, which may leave a bigger share for the rest
+	for settled := true; settled && len(wide) > 0; {
+		settled = false
+		share := room / len(wide)
+		var wider []int
+		for _, i := range wide {
+			if colWidths[i] <= share {
+				room -= colWidths[i]
+				settled = true
+			} else {
+				wider = append(wider, i)
 			}
 		}
+		wide = wider
+	}
+
+	wideTotal := 0
+	for _, i := range wide {
+		wideTotal += colWidths[i]
+	}
+	left := room
+	for _, i := range wide {
+		fitted[i] = max(1, colWidths[i]*room/wideTotal)
+		left -= fitted[i]
+	}
+
+	// Hand out what rounding down left over, a character a column
+	for left > 0 {
+		grown := false
+		for _, i := range wide {
+			if left > 0 && fitted[i] < colWidths[i] {
+				fitted[i]++
+				left--
+				grown = true
+			}
+		}
+		if !grown {
+			break
+		}
+	}
+	return fitted
+}
+
+// writeTableRow writes a row with each cell padded to its column's width,
+// continuing wrapped cells on lines with the other columns left blank
+func writeTableRow(builder *strings.Builder, row []string, colWidths []int, wrap bool) {
+	cells := make([][]string, len(colWidths))
+	lines := 1
+	for i, width := range colWidths {
+		cell := ""
+		if i < len(row) {
+			cell = row[i]
+		}
+		if wrap {
+			cells[i] = wrapCell(cell, width)
+		} else {
+			cells[i] = []string{truncateCell(cell, width)}
+		}
+		lines = max(lines, len(cells[i]))
+	}
+
+	for line := 0; line < lines; line++ {
+		for i, width := range colWidths {
+			text := ""
+			if line < len(cells[i]) {
+				text = cells[i][line]
+			}
+			builder.WriteString(fmt.Sprintf("%-*s", width+2, text))
+		}
 		builder.WriteString("\n")
 	}
+}
 
-	return builder.String()
+// truncateCell cuts a cell longer than width, ending it with an ellipsis
+func truncateCell(cell string, width int) string {
+	runes := []rune(cell)
+	if len(runes) <= width {
+		return cell
+	}
+	return string(runes[:width-1]) + "…"
 }
 
-// FormatDuration formats a duration in a human-readable format
-func FormatDuration(d time.Duration) string {
-	if d < time.Minute {
-		return fmt.Sprintf("%d seconds", int(d.Seconds()))
-	} else if d < time.Hour {
-		return fmt.Sprintf("%d minutes, %d seconds",
-			int(d.Minutes()), int(d.Seconds())%60)
-	} else if d < 24*time.Hour {
-		return fmt.Sprintf("%d hours, %d minutes",
-			int(d.Hours()), int(d.Minutes())%60)
-	} else {
-		days := int(d.Hours()) / 24
-		return fmt.Sprintf("%d days, %d hours",
-			days, int(d.Hours())%24)
+// wrapCell splits a cell into lines of at most width, breaking at the last
+// space that fits or, with none, within the word
+func wrapCell(cell string, width int) []string {
+	runes := []rune(cell)
+	var lines []string
+	for len(runes) > width {
+		split := width
+		for i := width; i > 0; i-- {
+			if runes[i] == ' ' {
+				split = i
+				break
+			}
+		}
+		lines = append(lines, strings.TrimRight(string(runes[:split]), " "))
+		runes = []rune(strings.TrimLeft(string(runes[split:]), " "))
 	}
+	return append(lines, string(runes))
+}
+
+// DurationStyle selects how FormatDuration writes a duration
+type DurationStyle int
+
+const (
+	// DurationVerbose writes the two largest units in words, e.g.
+	// "2 hours, 13 minutes"
+	DurationVerbose DurationStyle = iota
+	// DurationCompact writes the two largest units in short, e.g. "2h13m",
+	// to fit table cells
+	DurationCompact
+	// DurationExtended writes words like DurationVerbose but down to the
+	// second, e.g. "2 hours, 13 minutes, 5 seconds"
+	DurationExtended
+)
+
+// FormatDuration formats a duration in a human-readable format. A negative
+// duration, as from a clock set back after a load, shows as none at all.
+func FormatDuration(d time.Duration, style DurationStyle) string {
+	d = max(d, 0)
+	days := int(d / (24 * time.Hour))
+	hours := int(d/time.Hour) % 24
+	minutes := int(d/time.Minute) % 60
+	seconds := int(d/time.Second) % 60
+
+	if style == DurationCompact {
+		switch {
+		case d < time.Second && d > 0:
+			return fmt.Sprintf("%dms", d.Milliseconds())
+		case d < time.Minute:
+			return fmt.Sprintf("%ds", seconds)
+		case d < time.Hour:
+			return compactUnits(minutes, "m", seconds, "s")
+		case d < 24*time.Hour:
+			return compactUnits(hours, "h", minutes, "m")
+		default:
+			return compactUnits(days, "d", hours, "h")
+		}
+	}
+
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%d seconds", seconds)
+	case d < time.Hour:
+		return fmt.Sprintf("%d minutes, %d seconds", minutes, seconds)
+	case style == DurationExtended && d < 24*time.Hour:
+		return fmt.Sprintf("%d hours, %d minutes, %d seconds", hours, minutes, seconds)
+	case style == DurationExtended:
+		return fmt.Sprintf("%d days, %d hours, %d minutes, %d seconds", days, hours, minutes, seconds)
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%d hours, %d minutes", hours, minutes)
+	default:
+		return fmt.Sprintf("%d days, %d hours", days, hours)
+	}
+}
+
+// compactUnits writes a count of a unit and of the next smaller one,
+// leaving the smaller one out when there is none
+func compactUnits(count int, unit string, rest int, restUnit string) string {
+	if rest == 0 {
+		return fmt.Sprintf("%d%s", count, unit)
+	}
+	return fmt.Sprintf("%d%s%d%s", count, unit, rest, restUnit)
+}
+
+// FormatBar renders a percentage as a text bar of the given width, e.g. [###.......]
+func FormatBar(percent float64, width int) string {
+	filled := int(math.Round(percent / 100 * float64(width)))
+	if filled < 0 {
+		filled = 0
+	} else if filled > width {
+		filled = width
+	}
+
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", width-filled) + "]"
+}
+
+// mapLegend explains the letters used by FormatFloorMap
+const mapLegend = "Legend: B/M/A = free bicycle/motorcycle/automobile spot, lowercase = occupied, including each spot an oversized vehicle spans, X = inactive"
+
+// FormatFloorMap renders a floor's display grid with row and column labels.
+// Columns are split into chunks so each line fits within width characters.
+func FormatFloorMap(title string, grid [][]string, width int) string {
+	var builder strings.Builder
+	builder.WriteString(title + "\n")
+
+	if len(grid) == 0 || len(grid[0]) == 0 {
+		return builder.String()
+	}
+
+	rows, columns := len(grid), len(grid[0])
+	rowLabelWidth := len(strconv.Itoa(rows - 1))
+	cellWidth := len(strconv.Itoa(columns - 1))
+
+	// Each cell takes a separating space plus its padded label
+	perChunk := (width - rowLabelWidth) / (cellWidth + 1)
+	if perChunk < 1 {
+		perChunk = 1
+	}
+
+	for start := 0; start < columns; start += perChunk {
+		end := min(start+perChunk, columns)
+
+		if start > 0 {
+			builder.WriteString("\n")
+		}
+		if perChunk < columns {
+			builder.WriteString(fmt.Sprintf("Columns %d-%d:\n", start, end-1))
+		}
+
+		// Column axis
+		builder.WriteString(strings.Repeat(" ", rowLabelWidth))
+		for c := start; c < end; c++ {
+			builder.WriteString(fmt.Sprintf(" %*d", cellWidth, c))
+		}
+		builder.WriteString("\n")
+
+		// Rows with their axis label
+		for r := 0; r < rows; r++ {
+			builder.WriteString(fmt.Sprintf("%*d", rowLabelWidth, r))
+			for c := start; c < end; c++ {
+				builder.WriteString(fmt.Sprintf(" %*s", cellWidth, grid[r][c]))
+			}
+			builder.WriteString("\n")
+		}
+	}
+
+	return builder.String()
 }
@@ -0,0 +1,328 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// tableHeaders and tableRows are a fixture table wider than a terminal
+var (
+	tableHeaders = []string{"Spot ID", "Type", "Vehicle Number", "Parked At", "Tags"}
+	tableRows    = [][]string{
+		{"0-0-2", "A-1", "KA-01-HH-1234", "2024-03-01 09:15:00", "ev-charging, covered, near-exit"},
+		{"0-1-0", "B-1", "KA-01-HH-0001", "2024-03-01 09:20:30", ""},
+		{"1-4-12", "M-1", "MH-12-AB-98765-TEMPORARY", "2024-03-01 10:02:45", "reserved for staff on weekdays"},
+		{"2-0-7", "A-1", "DL-3C-AY-4321", "2024-03-01 11:47:10", "wheelchair-accessible"},
+	}
+)
+
+// checkGolden compares output with the golden file testdata/name, writing
+// it instead when run with -update
+func checkGolden(t *testing.T, name, output string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(output), 0o644); err != nil {
+			t.Fatalf("Failed to update %s: %v", path, err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	if output != string(expected) {
+		t.Errorf("Unexpected table for %s:\n%s\nexpected:\n%s", name, output, expected)
+	}
+}
+
+func TestFormatTableWidths(t *testing.T) {
+	for _, width := range []int{60, 80, 120} {
+		for _, wrap := range []bool{false, true} {
+			name := fmt.Sprintf("table_%d.golden", width)
+			if wrap {
+				name = fmt.Sprintf("table_%d_wrap.golden", width)
+			}
+			output := formatTable(tableHeaders, tableRows, width, wrap)
+			checkGolden(t, name, output)
+
+			for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+				if n := len([]rune(line)); n > width {
+					t.Errorf("Expected lines of %s within %d, got %d: %q", name, width, n, line)
+				}
+			}
+		}
+	}
+
+	// With no width the table is as wide as its cells
+	if got, want := formatTable(tableHeaders, tableRows, 0, false), formatTable(tableHeaders, tableRows, 120, false); got != want {
+		t.Errorf("Expected a table fitting the width unchanged, got:\n%s\nexpected:\n%s", got, want)
+	}
+}
+
+func TestFormatTableUnevenRows(t *testing.T) {
+	output := formatTable([]string{"Spot", "Vehicle"}, [][]string{
+		{"0-0-1"},
+		{"0-0-2", "KA-01", "extra"},
+	}, 0, false)
+
+	expected := "Spot   Vehicle  " + "       \n" +
+		"----------------" + "-------\n" +
+		"0-0-1           " + "       \n" +
+		"0-0-2  KA-01    " + "extra  \n"
+	if output != expected {
+		t.Errorf("Unexpected table output:\n%q\nexpected:\n%q", output, expected)
+	}
+}
+
+func TestTruncateAndWrapCell(t *testing.T) {
+	if got := truncateCell("KA-01-HH-1234", 6); got != "KA-01…" {
+		t.Errorf("Expected the cell truncated with an ellipsis, got %q", got)
+	}
+	if got := truncateCell("B-1", 6); got != "B-1" {
+		t.Errorf("Expected a short cell kept, got %q", got)
+	}
+
+	got := wrapCell("reserved for staff", 9)
+	if strings.Join(got, "|") != "reserved|for staff" {
+		t.Errorf("Expected the cell wrapped at spaces, got %q", got)
+	}
+	got = wrapCell("KA-01-HH-1234", 5)
+	if strings.Join(got, "|") != "KA-01|-HH-1|234" {
+		t.Errorf("Expected a long word broken, got %q", got)
+	}
+}
+
+func TestFormatFloorMap(t *testing.T) {
+	grid := [][]string{
+		{"B", "M", "a", "A"},
+		{"B", "X", "A", "A"},
+	}
+
+	expected := `Floor 0
+  0 1 2 3
+0 B M a A
+1 B X A A
+`
+	if got := FormatFloorMap("Floor 0", grid, 80); got != expected {
+		t.Errorf("Unexpected map output:\n%s\nexpected:\n%s", got, expected)
+	}
+
+	// A narrow width splits the columns into chunks
+	expected = `Floor 0
+Columns 0-1:
+  0 1
+0 B M
+1 B X
+
+Columns 2-3:
+  2 3
+0 a A
+1 A A
+`
+	if got := FormatFloorMap("Floor 0", grid, 5); got != expected {
+		t.Errorf("Unexpected chunked map output:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestFormatFloorMapWideLabels(t *testing.T) {
+	grid := make([][]string, 11)
+	for r := range grid {
+		grid[r] = make([]string, 12)
+		for c := range grid[r] {
+			grid[r][c] = "A"
+		}
+	}
+	grid[10][11] = "a"
+
+	got := FormatFloorMap("Floor B1", grid, 80)
+	lines := strings.Split(got, "\n")
+
+	if lines[1] != "    0  1  2  3  4  5  6  7  8  9 10 11" {
+		t.Errorf("Unexpected column axis: %q", lines[1])
+	}
+	if lines[12] != "10  A  A  A  A  A  A  A  A  A  A  A  a" {
+		t.Errorf("Unexpected last row: %q", lines[12])
+	}
+}
+
+func TestFormatBar(t *testing.T) {
+	tests := []struct {
+		percent  float64
+		expected string
+	}{
+		{0, "[..........]"},
+		{25, "[###.......]"},
+		{100.0 / 3, "[###.......]"},
+		{100, "[##########]"},
+		{150, "[##########]"},
+		{-5, "[..........]"},
+	}
+
+	for _, tc := range tests {
+		if got := FormatBar(tc.percent, 10); got != tc.expected {
+			t.Errorf("FormatBar(%f): expected %s, got %s", tc.percent, tc.expected, got)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		duration                   time.Duration
+		verbose, compact, extended string
+	}{
+		{-time.Second, "0 seconds", "0s", "0 seconds"},
+		{250 * time.Millisecond, "0 seconds", "250ms", "0 seconds"},
+		{59 * time.Second, "59 seconds", "59s", "59 seconds"},
+		{60 * time.Second, "1 minutes, 0 seconds", "1m", "1 minutes, 0 seconds"},
+		{59*time.Minute + 59*time.Second, "59 minutes, 59 seconds", "59m59s", "59 minutes, 59 seconds"},
+		{23 * time.Hour, "23 hours, 0 minutes", "23h", "23 hours, 0 minutes, 0 seconds"},
+		{25*time.Hour + 5*time.Second, "1 days, 1 hours", "1d1h", "1 days, 1 hours, 0 minutes, 5 seconds"},
+		{8 * 24 * time.Hour, "8 days, 0 hours", "8d", "8 days, 0 hours, 0 minutes, 0 seconds"},
+	}
+
+	for _, tc := range tests {
+		for style, expected := range map[DurationStyle]string{
+			DurationVerbose:  tc.verbose,
+			DurationCompact:  tc.compact,
+			DurationExtended: tc.extended,
+		} {
+			if got := FormatDuration(tc.duration, style); got != expected {
+				t.Errorf("FormatDuration(%v, %d): expected %q, got %q", tc.duration, style, expected, got)
+			}
+		}
+	}
+}
+
+func TestColorize(t *testing.T) {
+	t.Cleanup(func() { SetColorOutput(true) })
+
+	if got := colorize(colorRed, "full"); got != colorRed+"full"+colorReset {
+		t.Errorf("Expected colored text, got %q", got)
+	}
+
+	SetColorOutput(false)
+	if ColorOutput() {
+		t.Error("Expected colors off")
+	}
+	if got := colorize(colorRed, "full"); got != "full" {
+		t.Errorf("Expected plain text with colors off, got %q", got)
+	}
+}
+
+func TestColoredAndPlainOutput(t *testing.T) {
+	t.Cleanup(func() { SetColorOutput(true) })
+
+	logger := NewLogger(true)
+	print := func() string {
+		stderr := captureStderr(t, func() {
+			logger.Debug("checking %d spots", 3)
+			logger.Info("lot ready")
+			logger.Warning("floor %d full", 1)
+			logger.Error("spot %s broken", "0-0-1")
+		})
+		stdout := captureStdout(t, func() {
+			PrintError("no spot for %s", "KA-01-HH-1234")
+			PrintSuccess("parked at %s", "0-0-2")
+			PrintInfo("5 spots free")
+			PrintWarning("nearly full")
+		})
+		return stdout + stderr
+	}
+
+	SetColorOutput(true)
+	colored := print()
+	SetColorOutput(false)
+	plain := print()
+
+	if strings.Count(colored, colorReset) != 8 {
+		t.Errorf("Expected every message colored, got %q", colored)
+	}
+	if strings.Contains(plain, "\033[") {
+		t.Errorf("Expected no escape codes with colors off, got %q", plain)
+	}
+
+	// Timestamps of the log lines differ between the runs
+	timestamps := regexp.MustCompile(`\[\d\d:\d\d:\d\d\.\d{3}\]`)
+	stripped := timestamps.ReplaceAllString(colorCodes.ReplaceAllString(colored, ""), "")
+	if want := timestamps.ReplaceAllString(plain, ""); stripped != want {
+		t.Errorf("Expected the colored output to match the plain one once stripped, got:\n%s\nexpected:\n%s", stripped, want)
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	tests := []struct {
+		force, disable, tty bool
+		noColor             string
+		want                bool
+	}{
+		{tty: true, want: true},
+		{tty: false, want: false},
+		{force: true, want: true},
+		{disable: true, tty: true, want: false},
+		{force: true, disable: true, want: false},
+		{tty: true, noColor: "1", want: false},
+		{force: true, noColor: "1", want: true},
+	}
+	for _, test := range tests {
+		t.Setenv("NO_COLOR", test.noColor)
+		if got := ColorEnabled(test.force, test.disable, test.tty); got != test.want {
+			t.Errorf("ColorEnabled(%v, %v, %v) with NO_COLOR=%q: expected %v, got %v",
+				test.force, test.disable, test.tty, test.noColor, test.want, got)
+		}
+	}
+}
+
+func TestOutputWriters(t *testing.T) {
+	t.Cleanup(func() { SetColorOutput(true) })
+	SetColorOutput(false)
+
+	var out, errOut strings.Builder
+	output := NewOutput(&out, &errOut)
+	output.Success("parked at %s", "0-0-2")
+	output.Error("no spot for %s", "KA-01-HH-1234")
+	if out.String() != "parked at 0-0-2\nError: no spot for KA-01-HH-1234\n" || errOut.Len() != 0 {
+		t.Errorf("Expected the messages written to out, got %q and %q", out.String(), errOut.String())
+	}
+
+	// Commands write to the registry's output, not standard output
+	out.Reset()
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+	registry.SetOutput(&out, &errOut)
+	stdout := captureStdout(t, func() {
+		if err := registry.ExecuteCommand("init", []string{"1", "1", "5"}); err != nil {
+			t.Errorf("Failed to init: %v", err)
+		}
+	})
+	if stdout != "" || !strings.Contains(out.String(), "Created parking lot with 1 floors") {
+		t.Errorf("Expected the output written only to out, got %q and standard output %q", out.String(), stdout)
+	}
+}
+
+func TestOutputJSON(t *testing.T) {
+	var out strings.Builder
+	output := NewOutput(&out, &out)
+
+	if err := output.JSON("status", map[string]int{"spots": 10}, nil); err != nil {
+		t.Fatalf("Failed to write JSON: %v", err)
+	}
+	if !strings.Contains(out.String(), `"schemaVersion": 1,`) {
+		t.Errorf("Expected the schema version in the result, got %s", out.String())
+	}
+
+	// A result that cannot be marshaled is an error, with nothing written
+	out.Reset()
+	if err := output.JSON("status", make(chan int), nil); err == nil || out.Len() != 0 {
+		t.Errorf("Expected a marshal error and no output, got %v and %q", err, out.String())
+	}
+}
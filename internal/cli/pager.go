@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// defaultPager pages long output when PAGER is not set
+const defaultPager = "less -R"
+
+// pagerPrompt is shown by the built-in pager after each page
+const pagerPrompt = "--More-- (space: next page, enter: next line, q: quit)"
+
+// SetPagerTerminal pages the output of each command too long for the
+// terminal, reading keys from in and showing it on out. Only a session at
+// a prompt sets it, so piped and redirected output is never paged.
+func (r *CommandRegistry) SetPagerTerminal(in, out *os.File) {
+	r.pagerIn, r.pagerOut = in, out
+}
+
+// pages reports whether the command's output is paged. A command run by
+// another, as by a script, is paged with the output of the one running it.
+func (r *CommandRegistry) pages(cmd *Command) bool {
+	return r.pagerOut != nil && r.defaults.Paging && !r.paging && !cmd.NoPager && r.Options.Output == ""
+}
+
+// runPaged runs fn with its output held back until it is more than fits on
+// the terminal, when the pager takes it and the rest as it is written
+func (r *CommandRegistry) runPaged(cmd *Command, fn func() error) error {
+	if !r.pages(cmd) {
+		return fn()
+	}
+	height := terminalHeight(r.pagerOut)
+	if height < 2 {
+		return fn()
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return fn()
+	}
+	paged := make(chan error, 1)
+	go func() {
+		paged <- r.pageOutput(reader, height-1)
+		reader.Close()
+	}()
+
+	out := r.out.Out
+	r.out.Out, r.paging = writer, true
+	err = fn()
+	r.out.Out, r.paging = out, false
+	writer.Close()
+
+	if pageErr := <-paged; pageErr != nil {
+		r.Logger.Debug("Paging the output failed: %v", pageErr)
+	}
+	return err
+}
+
+// pageOutput shows output as it is when it has no more than lines lines,
+// and through the pager when it has more. Output left once the pager quits
+// is read and thrown away, so the command writing it still runs to the end.
+func (r *CommandRegistry) pageOutput(output io.Reader, lines int) error {
+	defer func() { _, _ = io.Copy(io.Discard, output) }()
+
+	buffered := bufio.NewReader(output)
+	var head bytes.Buffer
+	for n := 0; n < lines; n++ {
+		line, err := buffered.ReadBytes('\n')
+		head.Write(line)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := buffered.Peek(1); err == io.EOF {
+		_, err = r.pagerOut.Write(head.Bytes())
+		return err
+	}
+
+	return r.runPager(io.MultiReader(&head, buffered), lines)
+}
+
+// runPager pages output through the program PAGER names, or less, falling
+// back to the built-in pager when there is no such program
+func (r *CommandRegistry) runPager(output io.Reader, lines int) error {
+	command := strings.Fields(os.Getenv("PAGER"))
+	if len(command) == 0 {
+		command = strings.Fields(defaultPager)
+	}
+
+	path, err := exec.LookPath(command[0])
+	if err != nil {
+		return pageBuiltin(output, r.pagerOut, lines, func() (byte, error) {
+			return readKey(r.pagerIn)
+		})
+	}
+
+	// A pager quitting early only ends the copy of the output to it
+	pager := exec.Command(path, command[1:]...)
+	pager.Stdin, pager.Stdout, pager.Stderr = output, r.pagerOut, os.Stderr
+	return pager.Run()
+}
+
+// pageBuiltin shows output a page of lines at a time, then waits for a
+// key: space shows the next page, enter the next line and q stops
+func pageBuiltin(output io.Reader, out io.Writer, lines int, readKey func() (byte, error)) error {
+	buffered := bufio.NewReader(output)
+	show := lines
+	for {
+		for ; show > 0; show-- {
+			line, err := buffered.ReadBytes('\n')
+			if _, writeErr := out.Write(line); writeErr != nil {
+				return writeErr
+			}
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if _, err := buffered.Peek(1); err == io.EOF {
+			return nil
+		}
+
+		fmt.Fprint(out, pagerPrompt)
+		key, err := readKey()
+		fmt.Fprint(out, "\r\033[K")
+		if err != nil {
+			return err
+		}
+		switch key {
+		case ' ':
+			show = lines
+		case '\r', '\n':
+			show = 1
+		case 'q', 'Q':
+			return nil
+		}
+	}
+}
+
+// readKey reads a key from the terminal in, without waiting for Enter
+// where the terminal allows it
+func readKey(in *os.File) (byte, error) {
+	if restore, err := makeRaw(int(in.Fd())); err == nil {
+		defer restore()
+	}
+	var key [1]byte
+	_, err := in.Read(key[:])
+	return key[0], err
+}
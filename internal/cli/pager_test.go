@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// numberedLines returns n lines of output, numbered from 1
+func numberedLines(n int) string {
+	var builder strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&builder, "line %d\n", i)
+	}
+	return builder.String()
+}
+
+func TestPageBuiltin(t *testing.T) {
+	// keys returns a key reader pressing the keys in turn
+	keys := func(pressed string) func() (byte, error) {
+		return func() (byte, error) {
+			if pressed == "" {
+				return 0, io.EOF
+			}
+			key := pressed[0]
+			pressed = pressed[1:]
+			return key, nil
+		}
+	}
+	page := func(output string, pressed string) string {
+		var out bytes.Buffer
+		if err := pageBuiltin(strings.NewReader(output), &out, 3, keys(pressed)); err != nil {
+			t.Errorf("Failed to page: %v", err)
+		}
+		return strings.ReplaceAll(out.String(), pagerPrompt+"\r\033[K", "|")
+	}
+
+	if got := page(numberedLines(3), ""); got != numberedLines(3) {
+		t.Errorf("Expected a page of output shown without a prompt, got %q", got)
+	}
+
+	// Space shows the next page and enter the next line
+	got := page(numberedLines(8), " \rq")
+	if got != "line 1\nline 2\nline 3\n|line 4\nline 5\nline 6\n|line 7\n|" {
+		t.Errorf("Expected paging by page then line, got %q", got)
+	}
+
+	// q stops paging
+	if got := page(numberedLines(8), "q"); got != "line 1\nline 2\nline 3\n|" {
+		t.Errorf("Expected q to stop after the first page, got %q", got)
+	}
+}
+
+func TestPageOutput(t *testing.T) {
+	registry := NewCommandRegistry()
+	outPath := filepath.Join(t.TempDir(), "terminal")
+	out, err := os.Create(outPath)
+	if err != nil {
+		t.Fatalf("Failed to create output: %v", err)
+	}
+	defer out.Close()
+	registry.SetPagerTerminal(os.Stdin, out)
+
+	// page pages output of a given length and returns what was shown
+	page := func(output string) string {
+		if err := out.Truncate(0); err != nil {
+			t.Fatalf("Failed to reset output: %v", err)
+		}
+		if _, err := out.Seek(0, io.SeekStart); err != nil {
+			t.Fatalf("Failed to reset output: %v", err)
+		}
+		if err := registry.pageOutput(strings.NewReader(output), 5); err != nil {
+			t.Errorf("Failed to page: %v", err)
+		}
+		shown, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		return string(shown)
+	}
+
+	t.Setenv("PAGER", "sed s/^/paged:/")
+	if got := page(numberedLines(5)); got != numberedLines(5) {
+		t.Errorf("Expected output that fits shown as it is, got %q", got)
+	}
+	if got := page(numberedLines(6)); got != strings.ReplaceAll(numberedLines(6), "line", "paged:line") {
+		t.Errorf("Expected longer output through the pager, got %q", got)
+	}
+
+	// A pager quitting early leaves the writer free to finish
+	t.Setenv("PAGER", "head -n 1")
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	written := make(chan error, 1)
+	go func() {
+		_, err := io.WriteString(writer, numberedLines(100000))
+		writer.Close()
+		written <- err
+	}()
+	if err := registry.pageOutput(reader, 5); err != nil {
+		t.Errorf("Expected the pager quitting early to be no error, got %v", err)
+	}
+	if err := <-written; err != nil {
+		t.Errorf("Expected the whole output written, got %v", err)
+	}
+	reader.Close()
+}
+
+func TestPagingOff(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+	watch := registry.Commands["watch"]
+	status := registry.Commands["status"]
+
+	if registry.pages(status) {
+		t.Error("Expected no paging without a terminal")
+	}
+	registry.SetPagerTerminal(os.Stdin, os.Stdout)
+	if !registry.pages(status) || registry.pages(watch) {
+		t.Error("Expected status paged and watch not")
+	}
+
+	captureStdout(t, func() {
+		if err := registry.ExecuteCommand("set", []string{"paging", "off"}); err != nil {
+			t.Errorf("Failed to set paging: %v", err)
+		}
+	})
+	if registry.pages(status) {
+		t.Error("Expected no paging once it is set off")
+	}
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+)
+
+// quiet reports whether the command prints only its primary result. JSON
+// output is kept whole, as it is the result itself.
+func (r *CommandRegistry) quiet() bool {
+	return r.Options.Quiet && r.Options.Format != OutputFormatJSON
+}
+
+// runQuietly runs fn with its output discarded in quiet mode, so that only
+// what printResult writes comes out. A command run by another quiet one,
+// as by a script, runs inside the same silence.
+func (r *CommandRegistry) runQuietly(fn func() error) error {
+	if !r.quiet() || r.resultOutput != nil {
+		return fn()
+	}
+
+	out := r.out.Out
+	r.out.Out, r.resultOutput = io.Discard, out
+	defer func() { r.out.Out, r.resultOutput = out, nil }()
+
+	return fn()
+}
+
+// printResult prints the primary result of a command in quiet mode, the one
+// value a script needs from it; otherwise the command's usual output
+// already shows it
+func (r *CommandRegistry) printResult(format string, args ...any) {
+	if !r.quiet() || r.resultOutput == nil {
+		return
+	}
+	fmt.Fprintf(r.resultOutput, format+"\n", args...)
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runRedirected runs fn with its output sent to the file at path, as
+// given to -o, uncolored and with tables as wide as their cells unless a
+// width is set. The output goes to a temporary file beside it
+// that takes its place only once fn succeeds, so a failed command leaves
+// the file as it was. A path starting with + is appended to instead.
+func (r *CommandRegistry) runRedirected(path string, fn func() error) error {
+	if path == "" {
+		return fn()
+	}
+	appendTo := strings.HasPrefix(path, "+")
+	path = strings.TrimPrefix(path, "+")
+
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %v", err)
+	}
+	defer os.Remove(temp.Name())
+
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if appendTo {
+		if err := copyFileTo(temp, path); err != nil {
+			temp.Close()
+			return fmt.Errorf("failed to append to output file: %v", err)
+		}
+	}
+
+	out, colored, width := r.out.Out, ColorOutput(), terminalWidth.Load()
+	r.out.Out = temp
+	SetColorOutput(false)
+	SetTerminalWidth(0)
+	err = fn()
+	r.out.Out = out
+	SetColorOutput(colored)
+	SetTerminalWidth(int(width))
+
+	if closeErr := temp.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write output file: %v", closeErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(temp.Name(), mode); err != nil {
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	return nil
+}
+
+// copyFileTo copies the file at path to w; a missing file copies nothing
+func copyFileTo(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(w, file)
+	return err
+}
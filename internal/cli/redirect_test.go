@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputRedirection(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+
+	if err := registry.ExecuteCommand("init", []string{"1", "2", "5"}); err != nil {
+		t.Fatalf("Failed to init: %v", err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.txt")
+	read := func() string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read output file: %v", err)
+		}
+		return string(data)
+	}
+
+	// The output goes to the file alone
+	output := captureStdout(t, func() {
+		if err := registry.ExecuteCommand("park", []string{"automobile", "KA-01-HH-1234", "-o", path}); err != nil {
+			t.Errorf("Failed to park: %v", err)
+		}
+	})
+	if output != "" {
+		t.Errorf("Expected nothing on stdout, got %q", output)
+	}
+	if got := read(); got != "Vehicle KA-01-HH-1234 parked successfully at spot 0-0-2\n" {
+		t.Errorf("Expected the uncolored park output in the file, got %q", got)
+	}
+
+	// +file appends, and --json output is redirected whole
+	if err := registry.ExecuteCommand("status", []string{"--json", "--output", "+" + path}); err != nil {
+		t.Fatalf("Failed to run status: %v", err)
+	}
+	got := read()
+	if !strings.HasPrefix(got, "Vehicle KA-01-HH-1234 parked") || !strings.Contains(got, `"command": "status"`) {
+		t.Errorf("Expected the status appended as JSON, got %q", got)
+	}
+
+	// A failure leaves the file as it was, with no temporary file behind
+	if err := registry.ExecuteCommand("park", []string{"automobile", "KA-01-HH-1234", "-o", path}); err == nil {
+		t.Fatal("Expected parking a parked vehicle to fail")
+	}
+	if read() != got {
+		t.Error("Expected the file unchanged after the failure")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected only the output file in the directory, got %d entries", len(entries))
+	}
+
+	// Later commands write to stdout again
+	output = captureStdout(t, func() {
+		if err := registry.ExecuteCommand("search", []string{"KA-01-HH-1234"}); err != nil {
+			t.Errorf("Failed to search: %v", err)
+		}
+	})
+	if !strings.Contains(output, "0-0-2") {
+		t.Errorf("Expected the search output on stdout, got %q", output)
+	}
+
+	if err := registry.ExecuteCommand("status", []string{"-o", filepath.Join(dir, "missing", "out.txt")}); err == nil {
+		t.Error("Expected an output file in a missing directory to fail")
+	}
+	if err := registry.ExecuteCommand("status", []string{"-o", "+"}); err == nil {
+		t.Error("Expected -o without a file name to fail")
+	}
+}
@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxScriptDepth is how deeply scripts may run other scripts
+const maxScriptDepth = 8
+
+// ParseCommandLine splits a command line into words, separated by spaces
+// and tabs outside quotes. Single quotes keep everything up to the next one
+// as typed, as do double quotes, except that a backslash in them escapes a
+// double quote or a backslash. Quoted and unquoted parts next to each other
+// form one word, and "" is an empty one. A quote left open is an error.
+func ParseCommandLine(line string) ([]string, error) {
+	words, quote := splitWords(line)
+	if quote != 0 {
+		name := "double"
+		if quote == '\'' {
+			name = "single"
+		}
+		return nil, fmt.Errorf("unterminated %s quote in: %s", name, line)
+	}
+	return words, nil
+}
+
+// SplitCommandLine splits a command line into words like ParseCommandLine,
+// but runs a quote left open to the end of the line, as for completing a
+// partly typed one
+func SplitCommandLine(line string) []string {
+	words, _ := splitWords(line)
+	return words
+}
+
+// splitWords splits a command line into words, returning the quote still
+// open at its end, if any
+func splitWords(line string) ([]string, rune) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, char := range line {
+		switch {
+		case escaped:
+			// Only a double quote or backslash is escaped; otherwise the
+			// backslash is kept
+			if char != '"' && char != '\\' {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(char)
+			escaped = false
+		case quote == '\'':
+			if char == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(char)
+			}
+		case quote == '"':
+			switch char {
+			case '\\':
+				escaped = true
+			case '"':
+				quote = 0
+			default:
+				word.WriteRune(char)
+			}
+		case char == ' ' || char == '\t':
+			// Space outside quotes, end current word
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case char == '"' || char == '\'':
+			quote = char
+			inWord = true
+		default:
+			word.WriteRune(char)
+			inWord = true
+		}
+	}
+
+	// Add the last word, even an empty quoted one
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, quote
+}
+
+// SplitCommands splits a line into the commands separated by semicolons
+// outside quotes, dropping empty ones. The quotes are kept, for
+// ParseCommandLine to handle.
+func SplitCommands(line string) []string {
+	var commands []string
+	var current strings.Builder
+	var quote rune
+	escaped := false
+
+	flush := func() {
+		if command := strings.TrimSpace(current.String()); command != "" {
+			commands = append(commands, command)
+		}
+		current.Reset()
+	}
+
+	for _, char := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && char == '\\':
+			escaped = true
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			quote = char
+		case char == ';':
+			flush()
+			continue
+		}
+		current.WriteRune(char)
+	}
+	flush()
+
+	return commands
+}
+
+// WrapCommandError names the failing command of a line holding several
+func WrapCommandError(commands []string, n int, err error) error {
+	if len(commands) == 1 {
+		return err
+	}
+	return fmt.Errorf("command %d of %d (%s): %w", n+1, len(commands), commands[n], err)
+}
+
+// RunScript executes the commands of a script file, one per line or several
+// separated by semicolons. Blank lines and lines starting with # are
+// skipped, and exit ends the script.
+// It stops at the first failing command unless continueOnError is set, in
+// which case every failure is reported and the script fails at the end.
+func (r *CommandRegistry) RunScript(path string, continueOnError bool) error {
+	if r.scriptDepth >= maxScriptDepth {
+		return fmt.Errorf("script %s not run: scripts can only run one another %d deep", path, maxScriptDepth)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open script: %v", err)
+	}
+	defer file.Close()
+
+	r.scriptDepth++
+	defer func() { r.scriptDepth-- }()
+
+	return r.runScript(file, path, continueOnError)
+}
+
+// runScript executes the commands read from script, naming it in errors
+func (r *CommandRegistry) runScript(script io.Reader, name string, continueOnError bool) error {
+	var failedLines []string
+	failedCommands := 0
+	scanner := bufio.NewScanner(script)
+	exited := false
+	for lineNumber := 1; !exited && scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		commands := SplitCommands(line)
+		failed := false
+		for n, command := range commands {
+			// A line that does not parse fails like a failing command
+			parts, err := ParseCommandLine(command)
+			if err == nil {
+				if len(parts) == 0 {
+					continue
+				}
+				if parts[0] == "exit" {
+					exited = true
+					break
+				}
+
+				if !r.defaults.Quiet {
+					r.out.Printf("> %s\n", command)
+				}
+				err = r.ExecuteCommand(parts[0], parts[1:])
+			}
+			if err == nil {
+				continue
+			}
+			err = WrapCommandError(commands, n, err)
+			if !continueOnError {
+				return fmt.Errorf("%s:%d: %w", name, lineNumber, err)
+			}
+			if !IsReported(err) {
+				fmt.Fprintf(r.out.Err, "Error: %s:%d: %v\n", name, lineNumber, err)
+			}
+			failed = true
+			failedCommands++
+		}
+		if failed {
+			failedLines = append(failedLines, fmt.Sprintf("%d", lineNumber))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read script %s: %v", name, err)
+	}
+
+	if len(failedLines) > 0 {
+		return fmt.Errorf("%s: %d commands failed, on lines %s", name, failedCommands,
+			strings.Join(failedLines, ", "))
+	}
+
+	return nil
+}
+
+// handleRun handles the run command
+func (r *CommandRegistry) handleRun(args []string, continueOnError bool) error {
+	r.Logger.Debug("Running script %s, continue on error=%v", args[0], continueOnError)
+
+	return r.RunScript(args[0], continueOnError)
+}
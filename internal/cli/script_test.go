@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeScript writes a script file in the test's temporary directory
+func writeScript(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	return path
+}
+
+func TestRunScript(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+
+	script := writeScript(t, "setup.txt", `# Set up the lot
+init 2 2 5 --name "Orion Mall"
+
+park automobile KA-01-HH-0001
+park automobile KA-01-HH-0002
+   # indented comments are skipped too
+status
+exit
+park automobile KA-01-HH-0003
+`)
+	if err := registry.ExecuteCommand("run", []string{script}); err != nil {
+		t.Fatalf("Failed to run script: %v", err)
+	}
+
+	lot := registry.GetParkingLot()
+	if lot == nil || lot.GetName() != "Orion Mall" {
+		t.Fatalf("Expected the script to create Orion Mall, got %v", lot)
+	}
+	if lot.GetParkedVehicleCount() != 2 || lot.IsVehicleParked("KA-01-HH-0003") {
+		t.Errorf("Expected the two vehicles parked before exit, got %d", lot.GetParkedVehicleCount())
+	}
+}
+
+func TestRunScriptFailures(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+
+	script := writeScript(t, "broken.txt", `init 1 2 5
+park lorry KA-01-HH-0001
+park automobile KA-01-HH-0002
+unpark 9-9-9 KA-01-HH-0002; park lorry KA-01-HH-0004
+park automobile KA-01-HH-0003
+`)
+
+	// The first failure stops the script and names its line
+	err := registry.RunScript(script, false)
+	if err == nil || !strings.Contains(err.Error(), "broken.txt:2:") {
+		t.Errorf("Expected the failure reported at line 2, got %v", err)
+	}
+	if registry.GetParkingLot().GetParkedVehicleCount() != 0 {
+		t.Error("Expected no command run after the failure")
+	}
+
+	// With --continue-on-error every line runs and the failures are listed
+	err = registry.ExecuteCommand("run", []string{script, "--continue-on-error"})
+	if err == nil || !strings.Contains(err.Error(), "3 commands failed, on lines 2, 4") {
+		t.Errorf("Expected 3 failures on lines 2 and 4 reported, got %v", err)
+	}
+	if registry.GetParkingLot().GetParkedVehicleCount() != 2 {
+		t.Errorf("Expected the later parks to run, got %d parked", registry.GetParkingLot().GetParkedVehicleCount())
+	}
+
+	if err := registry.ExecuteCommand("run", []string{filepath.Join(t.TempDir(), "missing.txt")}); err == nil {
+		t.Error("Expected a missing script to fail")
+	}
+	if err := registry.ExecuteCommand("run", []string{script, "--keep-going"}); err == nil {
+		t.Error("Expected an unknown option to be rejected")
+	}
+}
+
+func TestRunNestedScripts(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+
+	dir := t.TempDir()
+	inner := filepath.Join(dir, "inner.txt")
+	if err := os.WriteFile(inner, []byte("park automobile KA-01-HH-0001\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	outer := writeScript(t, "outer.txt", "init 1 2 5\nrun "+inner+"\n")
+	if err := registry.RunScript(outer, false); err != nil {
+		t.Fatalf("Failed to run nested scripts: %v", err)
+	}
+	if !registry.GetParkingLot().IsVehicleParked("KA-01-HH-0001") {
+		t.Error("Expected the inner script to park KA-01-HH-0001")
+	}
+
+	// A script running itself stops at the depth limit
+	loop := filepath.Join(dir, "loop.txt")
+	if err := os.WriteFile(loop, []byte("run "+loop+"\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	err := registry.RunScript(loop, false)
+	if err == nil || !strings.Contains(err.Error(), "deep") {
+		t.Errorf("Expected the depth limit to stop the loop, got %v", err)
+	}
+	if registry.scriptDepth != 0 {
+		t.Errorf("Expected the depth restored, got %d", registry.scriptDepth)
+	}
+}
+
+func TestSplitCommands(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"status", []string{"status"}},
+		{"init 2 3 4; park bicycle B-1; status", []string{"init 2 3 4", "park bicycle B-1", "status"}},
+		{" ; status;; ;", []string{"status"}},
+		{`describe --notes "north; gate"; status`, []string{`describe --notes "north; gate"`, "status"}},
+		{`describe --notes 'a;b' ; status`, []string{`describe --notes 'a;b'`, "status"}},
+		{`describe --notes "say \"x;y\""; status`, []string{`describe --notes "say \"x;y\""`, "status"}},
+		{";", nil},
+	}
+
+	for _, test := range tests {
+		if got := SplitCommands(test.line); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("SplitCommands(%q) = %q, expected %q", test.line, got, test.want)
+		}
+	}
+}
+
+func TestRunScriptSequences(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+
+	script := writeScript(t, "demo.txt", `init 1 2 5; park automobile KA-01-HH-0001
+park automobile KA-01-HH-0002; park lorry KA-01-HH-0003; park automobile KA-01-HH-0004
+`)
+	err := registry.RunScript(script, false)
+	if err == nil || !strings.Contains(err.Error(), "demo.txt:2: command 2 of 3 (park lorry KA-01-HH-0003)") {
+		t.Errorf("Expected the failing command of line 2 named, got %v", err)
+	}
+	if count := registry.GetParkingLot().GetParkedVehicleCount(); count != 2 {
+		t.Errorf("Expected the commands before the failure to run, got %d parked", count)
+	}
+}
+
+func TestParseCommandLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{`park automobile KA-01-HH-1234`, []string{"park", "automobile", "KA-01-HH-1234"}, false},
+		{"  status \t --json ", []string{"status", "--json"}, false},
+		{`init 1 2 5 --name "Orion Mall"`, []string{"init", "1", "2", "5", "--name", "Orion Mall"}, false},
+		{`label 0-0-0 'North gate'`, []string{"label", "0-0-0", "North gate"}, false},
+		{`describe --notes "the \"west\" ramp"`, []string{"describe", "--notes", `the "west" ramp`}, false},
+		{`describe --notes 'say "hi"'`, []string{"describe", "--notes", `say "hi"`}, false},
+		{`describe --notes "it's open"`, []string{"describe", "--notes", "it's open"}, false},
+		{`tag a"b c"'d e'f`, []string{"tag", "ab cd ef"}, false},
+		{`label 0-0-0 ""`, []string{"label", "0-0-0", ""}, false},
+		{`save "C:\\lots\\a.json"`, []string{"save", `C:\lots\a.json`}, false},
+		{`save "C:\lots"`, []string{"save", `C:\lots`}, false},
+		{`save C:\lots\`, []string{"save", `C:\lots\`}, false},
+		{`'single \ stays'`, []string{`single \ stays`}, false},
+		{`describe --notes "unterminated`, nil, true},
+		{`describe --notes 'unterminated`, nil, true},
+		{`describe --notes "trailing backslash\`, nil, true},
+		{`describe --notes "escaped close\"`, nil, true},
+		{"", nil, false},
+	}
+
+	for _, test := range tests {
+		got, err := ParseCommandLine(test.line)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseCommandLine(%q) error = %v, expected an error: %v", test.line, err, test.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseCommandLine(%q) = %q, expected %q", test.line, got, test.want)
+		}
+	}
+
+	// SplitCommandLine runs an open quote to the end instead
+	if got := SplitCommandLine(`label 0-0-0 "North ga`); !reflect.DeepEqual(got, []string{"label", "0-0-0", "North ga"}) {
+		t.Errorf("Expected the open quote run to the end, got %q", got)
+	}
+}
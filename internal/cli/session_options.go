@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/api"
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/config"
+)
+
+// ParseOutputFormat parses the name of an output format
+func ParseOutputFormat(value string) (OutputFormat, error) {
+	switch value {
+	case "json":
+		return OutputFormatJSON, nil
+	case "text":
+		return OutputFormatText, nil
+	}
+	return OutputFormatText, fmt.Errorf("unknown format %q, expected json or text", value)
+}
+
+// String returns the name of the output format
+func (f OutputFormat) String() string {
+	if f == OutputFormatJSON {
+		return "json"
+	}
+	return "text"
+}
+
+// parseSwitch parses an on or off value
+func parseSwitch(value string) (bool, error) {
+	switch value {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("unknown value %q, expected on or off", value)
+}
+
+// switchText returns on or off for a setting
+func switchText(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// parseTableWidth parses a table width, auto following the terminal's
+func parseTableWidth(value string) (int, error) {
+	if value == "auto" {
+		return 0, nil
+	}
+	width, err := strconv.Atoi(value)
+	if err != nil || width < 1 {
+		return 0, fmt.Errorf("invalid width %q, expected a number of columns or auto", value)
+	}
+	return width, nil
+}
+
+// widthText returns the table width set, or auto
+func widthText(width int) string {
+	if width == 0 {
+		return "auto"
+	}
+	return strconv.Itoa(width)
+}
+
+// parseTimeLayout parses a time layout, default for the default one
+func parseTimeLayout(value string) string {
+	if value == "default" {
+		return config.DefaultTimeLayout
+	}
+	return value
+}
+
+// parseTimezone parses a zone name, lot for the zone of the lot
+func parseTimezone(value string) string {
+	if value == "lot" {
+		return ""
+	}
+	return value
+}
+
+// timezoneText returns the zone set for times, or lot
+func timezoneText(timezone string) string {
+	if timezone == "" {
+		return "lot"
+	}
+	return timezone
+}
+
+// SetSessionFormat sets the output format every later command starts from,
+// as set format does
+func (r *CommandRegistry) SetSessionFormat(format OutputFormat) {
+	r.defaults.Format = format
+	r.Options.Format = format
+}
+
+// SetSessionVerbose turns verbose mode on or off for every later command,
+// as set verbose does
+func (r *CommandRegistry) SetSessionVerbose(verbose bool) {
+	r.defaults.Verbose = verbose
+	r.Options.Verbose = verbose
+	r.Logger = r.newLogger(verbose)
+}
+
+// handleSet handles the set command. The option stays set for every later
+// command, which can still override it with its own flags. Only a time
+// layout may have spaces, so the value is the rest of the arguments.
+func (r *CommandRegistry) handleSet(args []string) error {
+	option, value := args[0], strings.Join(args[1:], " ")
+	if len(args) > 2 && option != "timefmt" {
+		return newUsageError("too many arguments for command 'set'\nUsage: %s", r.Commands["set"].UsageText())
+	}
+	switch option {
+	case "format":
+		format, err := ParseOutputFormat(value)
+		if err != nil {
+			return err
+		}
+		r.SetSessionFormat(format)
+	case "verbose":
+		verbose, err := parseSwitch(value)
+		if err != nil {
+			return err
+		}
+		r.SetSessionVerbose(verbose)
+	case "quiet":
+		quiet, err := parseSwitch(value)
+		if err != nil {
+			return err
+		}
+		r.defaults.Quiet = quiet
+		r.Options.Quiet = quiet
+	case "width":
+		width, err := parseTableWidth(value)
+		if err != nil {
+			return err
+		}
+		SetTableWidth(width)
+	case "wrap":
+		wrap, err := parseSwitch(value)
+		if err != nil {
+			return err
+		}
+		SetCellWrapping(wrap)
+	case "color":
+		color, err := parseSwitch(value)
+		if err != nil {
+			return err
+		}
+		SetColorOutput(color)
+	case "paging":
+		paging, err := parseSwitch(value)
+		if err != nil {
+			return err
+		}
+		r.defaults.Paging = paging
+		r.Options.Paging = paging
+	case "timefmt":
+		display := TimeDisplay()
+		display.TimeLayout = parseTimeLayout(value)
+		if err := SetTimeDisplay(display); err != nil {
+			return err
+		}
+	case "timezone":
+		display := TimeDisplay()
+		display.Timezone = parseTimezone(value)
+		if err := SetTimeDisplay(display); err != nil {
+			return err
+		}
+	default:
+		return newUsageError("unknown option: %s\nUsage: %s", option, r.Commands["set"].UsageText())
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("set", r.optionsResult(), nil)
+	}
+
+	// Turning quiet mode on is already quiet
+	if !r.quiet() {
+		r.out.Success("Set %s to %s", option, value)
+	}
+	return nil
+}
+
+// handleShow handles the show command
+func (r *CommandRegistry) handleShow(args []string) error {
+	if args[0] != "options" {
+		return newUsageError("unknown setting group: %s\nUsage: show options", args[0])
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("show", r.optionsResult(), nil)
+	}
+
+	rows := [][]string{
+		{"format", r.defaults.Format.String()},
+		{"verbose", switchText(r.defaults.Verbose)},
+		{"quiet", switchText(r.defaults.Quiet)},
+		{"width", widthText(TableWidthSetting())},
+		{"wrap", switchText(CellWrapping())},
+		{"color", switchText(ColorOutput())},
+		{"paging", switchText(r.defaults.Paging)},
+		{"timefmt", TimeDisplay().TimeLayout},
+		{"timezone", timezoneText(TimeDisplay().Timezone)},
+	}
+	r.out.Println(FormatTable([]string{"Option", "Value"}, rows))
+
+	return nil
+}
+
+// optionsResult returns the options set for the session
+func (r *CommandRegistry) optionsResult() api.OptionsResult {
+	display := TimeDisplay()
+	return api.OptionsResult{
+		Format:     r.defaults.Format.String(),
+		Verbose:    r.defaults.Verbose,
+		Quiet:      r.defaults.Quiet,
+		Width:      TableWidthSetting(),
+		Wrap:       CellWrapping(),
+		Color:      ColorOutput(),
+		Paging:     r.defaults.Paging,
+		TimeFormat: display.TimeLayout,
+		Timezone:   timezoneText(display.Timezone),
+	}
+}
@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/api"
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/config"
+)
+
+// isJSON reports whether the output is a single JSON result
+func isJSON(output string) bool {
+	var result api.JSONResult
+	return json.Unmarshal([]byte(output), &result) == nil
+}
+
+func TestSetOptions(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+
+	if err := registry.ExecuteCommand("init", []string{"1", "2", "5"}); err != nil {
+		t.Fatalf("Failed to init: %v", err)
+	}
+	if err := registry.ExecuteCommand("set", []string{"format", "json"}); err != nil {
+		t.Fatalf("Failed to set format: %v", err)
+	}
+
+	// Later commands print JSON without asking
+	output := captureStdout(t, func() {
+		if err := registry.ExecuteCommand("status", nil); err != nil {
+			t.Errorf("Failed to run status: %v", err)
+		}
+	})
+	if !isJSON(output) {
+		t.Errorf("Expected status to print JSON, got %q", output)
+	}
+	output = captureStdout(t, func() {
+		if err := registry.ExecuteCommand("park", []string{"automobile", "KA-01-HH-1234"}); err != nil {
+			t.Errorf("Failed to park: %v", err)
+		}
+	})
+	if !isJSON(output) {
+		t.Errorf("Expected park to print JSON, got %q", output)
+	}
+
+	// A flag overrides the setting for one command only
+	output = captureStdout(t, func() {
+		if err := registry.ExecuteCommand("status", []string{"--format", "text"}); err != nil {
+			t.Errorf("Failed to run status: %v", err)
+		}
+	})
+	if isJSON(output) {
+		t.Errorf("Expected --format text to print text, got %q", output)
+	}
+	if registry.Options.Format != OutputFormatJSON {
+		t.Error("Expected the set format kept after the override")
+	}
+
+	output = captureStdout(t, func() {
+		if err := registry.ExecuteCommand("show", []string{"options"}); err != nil {
+			t.Errorf("Failed to show options: %v", err)
+		}
+	})
+	var result struct {
+		Data api.OptionsResult `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil || result.Data != (api.OptionsResult{Format: "json", Color: ColorOutput(), Paging: true, TimeFormat: "2006-01-02 15:04:05", Timezone: "lot"}) {
+		t.Errorf("Expected show options to report json, got %q", output)
+	}
+}
+
+func TestSetTimeDisplay(t *testing.T) {
+	t.Cleanup(func() { _ = SetTimeDisplay(config.DefaultDisplayConfig()) })
+	c := newTestCLI(t)
+	c.run("init", "1", "2", "5")
+	c.setClock()
+	c.run("park", "automobile", "KA-01-HH-1234")
+
+	// history shows the same record under each display set
+	c.expect(c.run("history", "KA-01-HH-1234"), "2024-01-01 09:00:00")
+	c.run("set", "timefmt", "02", "Jan", "2006", "3:04PM", "MST")
+	c.run("set", "timezone", "Asia/Kolkata")
+	c.expect(c.run("history", "KA-01-HH-1234"), "01 Jan 2024 2:30PM IST")
+	c.expect(c.run("status"), "01 Jan 2024 2:30PM IST")
+
+	// JSON output stays in RFC 3339 and UTC
+	c.expect(c.run("search", "KA-01-HH-1234"), "since 01 Jan 2024 2:30PM IST")
+	c.expect(c.run("history", "KA-01-HH-1234", "--json"), `"parkedAt": "2024-01-01T09:00:00Z"`)
+	c.expect(c.run("show", "options"), "02 Jan 2006 3:04PM MST", "Asia/Kolkata")
+
+	// Invalid settings are rejected and leave the display as it was
+	for _, args := range [][]string{{"timefmt", "hello"}, {"timezone", "Mars/Base"}, {"timezone", "Asia/Kolkata", "UTC"}} {
+		if err := c.fail("set", args...); err == nil {
+			t.Errorf("Expected set %v rejected", args)
+		}
+	}
+	if display := TimeDisplay(); display.TimeLayout != "02 Jan 2006 3:04PM MST" || display.Timezone != "Asia/Kolkata" {
+		t.Errorf("Expected the display kept, got %+v", display)
+	}
+
+	c.run("set", "timefmt", "default")
+	c.run("set", "timezone", "lot")
+	c.expect(c.run("status"), "2024-01-01 09:00:00")
+}
+
+func TestSetVerbose(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+
+	if err := registry.ExecuteCommand("set", []string{"verbose", "on"}); err != nil {
+		t.Fatalf("Failed to set verbose: %v", err)
+	}
+	if !registry.Options.Verbose || !registry.Logger.Verbose {
+		t.Error("Expected verbose logging kept on")
+	}
+	if err := registry.ExecuteCommand("set", []string{"verbose", "off"}); err != nil {
+		t.Fatalf("Failed to set verbose: %v", err)
+	}
+
+	// A one-off --verbose leaves the logger quiet afterwards
+	if err := registry.ExecuteCommand("show", []string{"options", "--verbose"}); err != nil {
+		t.Fatalf("Failed to show options: %v", err)
+	}
+	if registry.Options.Verbose || registry.Logger.Verbose {
+		t.Error("Expected verbose logging off after the command")
+	}
+
+	for _, args := range [][]string{{"format", "xml"}, {"verbose", "yes"}, {"colour", "on"}} {
+		if err := registry.ExecuteCommand("set", args); err == nil {
+			t.Errorf("Expected set %v rejected", args)
+		}
+	}
+	if err := registry.ExecuteCommand("show", []string{"lots"}); err == nil {
+		t.Error("Expected show lots rejected")
+	}
+	if err := registry.ExecuteCommand("status", []string{"--format", "xml"}); err == nil {
+		t.Error("Expected an unknown --format rejected")
+	}
+}
+
+func TestQuietOutput(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+
+	if err := registry.ExecuteCommand("init", []string{"1", "2", "5"}); err != nil {
+		t.Fatalf("Failed to init: %v", err)
+	}
+
+	// quiet runs a command and returns what it wrote to stdout and stderr
+	quiet := func(name string, args ...string) (string, string) {
+		var stdout string
+		stderr := captureStderr(t, func() {
+			stdout = captureStdout(t, func() {
+				if err := registry.ExecuteCommand(name, args); err != nil {
+					t.Errorf("Failed to run %s %v: %v", name, args, err)
+				}
+			})
+		})
+		return stdout, stderr
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"park", []string{"automobile", "KA-01-HH-1234", "--quiet"}, "0-0-2\n"},
+		{"available", []string{"automobile", "--count-only", "-q"}, "5\n"},
+		{"search", []string{"KA-01-HH-1234", "--quiet"}, "0-0-2\n"},
+		{"status", []string{"--quiet"}, ""},
+		{"unpark", []string{"0-0-2", "KA-01-HH-1234", "--quiet"}, ""},
+	}
+	for _, test := range tests {
+		stdout, stderr := quiet(test.name, test.args...)
+		if stdout != test.want || stderr != "" {
+			t.Errorf("Expected %s %v to print %q and nothing on stderr, got %q and %q",
+				test.name, test.args, test.want, stdout, stderr)
+		}
+	}
+
+	// The setting keeps every later command quiet, and errors are still returned
+	if err := registry.ExecuteCommand("set", []string{"quiet", "on"}); err != nil {
+		t.Fatalf("Failed to set quiet: %v", err)
+	}
+	if stdout, _ := quiet("park", "bicycle", "KA-01-HH-0001"); stdout != "0-1-0\n" {
+		t.Errorf("Expected only the spot ID, got %q", stdout)
+	}
+	output := captureStdout(t, func() {
+		if err := registry.ExecuteCommand("unpark", []string{"0-0-2", "KA-01-HH-9999"}); err == nil {
+			t.Error("Expected unparking an empty spot to fail")
+		}
+	})
+	if output != "" {
+		t.Errorf("Expected nothing printed for the failure, got %q", output)
+	}
+
+	// JSON output is kept whole
+	if stdout, _ := quiet("status", "--json"); !isJSON(stdout) {
+		t.Errorf("Expected JSON in quiet mode, got %q", stdout)
+	}
+}
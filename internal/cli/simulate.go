@@ -0,0 +1,337 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	perrors "github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+	"github.com/prasaria/go-multistorey-parking-lot/internal/model"
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/api"
+)
+
+// simulation describes a simulate run against a lot
+type simulation struct {
+	// vehicles is the size of the pool of vehicle numbers the workers use
+	vehicles int
+	workers  int
+	duration time.Duration
+	// rate caps the operations per second of all workers together; zero
+	// runs them as fast as the lot allows
+	rate int
+	// mix weighs the vehicle types parked
+	mix  model.DistributionSpec
+	seed int64
+	// keep leaves the vehicles parked by the run in the lot
+	keep bool
+}
+
+// simulationWorker performs random operations on its share of the vehicles
+type simulationWorker struct {
+	lot     *model.ParkingLot
+	numbers []string
+	rng     *rand.Rand
+	mix     model.DistributionSpec
+
+	// The spot of each vehicle the worker has parked
+	parked map[string]string
+
+	parks, unparks, searches int
+	failures                 map[string]int
+}
+
+// newSimulation returns a simulation with the defaults of the simulate command
+func newSimulation() simulation {
+	return simulation{
+		vehicles: 100,
+		workers:  8,
+		duration: 10 * time.Second,
+		mix:      model.DistributionSpec{BicycleWeight: 20, MotorcycleWeight: 30, AutomobileWeight: 50},
+		seed:     time.Now().UnixNano(),
+	}
+}
+
+// simulationVehicleNumber returns the number of the i-th vehicle of a simulation
+func simulationVehicleNumber(i int) string {
+	return fmt.Sprintf("SIM-%04d", i+1)
+}
+
+// run performs the simulation on the lot and reports on it. The workers
+// own disjoint vehicles, so each one's operations follow from the seed
+// alone, though how they interleave does not.
+func (s simulation) run(lot *model.ParkingLot) (api.SimulateResult, error) {
+	if err := lot.VehicleNumberValidator().Validate(simulationVehicleNumber(0)); err != nil {
+		return api.SimulateResult{}, fmt.Errorf("the lot's plate rule rejects simulated vehicle numbers like %s: %v",
+			simulationVehicleNumber(0), err)
+	}
+
+	workers := make([]*simulationWorker, min(s.workers, s.vehicles))
+	for i := range workers {
+		workers[i] = &simulationWorker{
+			lot:      lot,
+			rng:      rand.New(rand.NewSource(s.seed + int64(i))),
+			mix:      s.mix,
+			parked:   make(map[string]string),
+			failures: make(map[string]int),
+		}
+	}
+	for i := 0; i < s.vehicles; i++ {
+		worker := workers[i%len(workers)]
+		worker.numbers = append(worker.numbers, simulationVehicleNumber(i))
+	}
+
+	// Workers share one ticker, so the rate holds however many there are
+	var ticks <-chan time.Time
+	if s.rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(s.rate))
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	stop := make(chan struct{})
+	timer := time.AfterFunc(s.duration, func() { close(stop) })
+	defer timer.Stop()
+
+	started := time.Now()
+	var wg sync.WaitGroup
+	for _, worker := range workers {
+		wg.Add(1)
+		go func(worker *simulationWorker) {
+			defer wg.Done()
+			worker.work(stop, ticks)
+		}(worker)
+	}
+	wg.Wait()
+	elapsed := time.Since(started)
+
+	result := api.SimulateResult{
+		Seed:            s.seed,
+		Workers:         len(workers),
+		DurationSeconds: elapsed.Seconds(),
+		Errors:          make(map[string]int),
+	}
+	for _, worker := range workers {
+		result.Parks += worker.parks
+		result.Unparks += worker.unparks
+		result.Searches += worker.searches
+		for code, count := range worker.failures {
+			result.Errors[code] += count
+			result.Failures += count
+		}
+	}
+	result.Operations = result.Parks + result.Unparks + result.Searches
+	if elapsed > 0 {
+		result.Throughput = float64(result.Operations) / elapsed.Seconds()
+	}
+
+	// Clean up unless asked to keep the vehicles, then check the lot's
+	// spots against its vehicles
+	for _, worker := range workers {
+		if s.keep {
+			result.Kept += len(worker.parked)
+			continue
+		}
+		for number, spotID := range worker.parked {
+			if err := lot.Unpark(spotID, number); err != nil {
+				result.CleanupFailures++
+				continue
+			}
+			result.Removed++
+		}
+	}
+
+	result.OccupiedSpots = lot.GetOccupiedSpotCount()
+	held := make(map[string]bool)
+	for _, vehicle := range lot.GetAllParkedVehicles() {
+		for _, spotID := range vehicle.GetSpotIDs() {
+			held[spotID] = true
+		}
+	}
+	result.HeldSpots = len(held)
+	result.Consistent = result.OccupiedSpots == result.HeldSpots
+
+	return result, nil
+}
+
+// work performs operations until stop is closed, waiting for a tick before
+// each one when ticks is set
+func (w *simulationWorker) work(stop <-chan struct{}, ticks <-chan time.Time) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if ticks != nil {
+			select {
+			case <-stop:
+				return
+			case <-ticks:
+			}
+		}
+
+		w.step()
+	}
+}
+
+// step parks a random vehicle of the worker, or unparks or searches for it
+// when it is parked
+func (w *simulationWorker) step() {
+	number := w.numbers[w.rng.Intn(len(w.numbers))]
+	spotID, parked := w.parked[number]
+
+	var err error
+	switch {
+	case !parked:
+		w.parks++
+		spotID, err = w.lot.Park(w.vehicleType(), number)
+		if err == nil {
+			w.parked[number] = spotID
+		}
+	case w.rng.Intn(2) == 0:
+		w.unparks++
+		err = w.lot.Unpark(spotID, number)
+		if err == nil {
+			delete(w.parked, number)
+		}
+	default:
+		w.searches++
+		_, _, err = w.lot.SearchVehicle(number)
+	}
+
+	if err != nil {
+		w.failures[perrors.CodeOf(err)]++
+	}
+}
+
+// vehicleType returns a random vehicle type weighted by the mix
+func (w *simulationWorker) vehicleType() model.VehicleType {
+	n := w.rng.Intn(w.mix.BicycleWeight + w.mix.MotorcycleWeight + w.mix.AutomobileWeight)
+	switch {
+	case n < w.mix.BicycleWeight:
+		return model.VehicleTypeBicycle
+	case n < w.mix.BicycleWeight+w.mix.MotorcycleWeight:
+		return model.VehicleTypeMotorcycle
+	default:
+		return model.VehicleTypeAutomobile
+	}
+}
+
+// define binds the simulate flags to s, resetting it to the defaults
+func (s *simulation) define(fs *flag.FlagSet) {
+	*s = newSimulation()
+	fs.IntVar(&s.vehicles, "vehicles", s.vehicles, "use a pool of `n` vehicle numbers")
+	fs.IntVar(&s.workers, "workers", s.workers, "run `n` workers at once")
+	fs.Func("duration", "run for `duration`, such as 30s (default 10s)", func(value string) error {
+		duration, err := parseDuration(value)
+		if err != nil || duration <= 0 {
+			return errors.New("not a positive duration")
+		}
+		s.duration = duration
+		return nil
+	})
+	fs.IntVar(&s.rate, "rate", 0, "cap the operations of all workers at `ops` per second")
+	fs.Func("mix", "weigh the vehicle types parked as `b:m:a` (default 20:30:50)", func(value string) error {
+		mix, err := model.ParseDistributionSpec(value)
+		if err != nil {
+			return err
+		}
+		if len(mix.ExtraWeights) > 0 {
+			return errors.New("expected bicycle:motorcycle:automobile")
+		}
+		s.mix = mix
+		return nil
+	})
+	fs.Func("seed", "seed the workers with `n` (default the current time)", func(value string) error {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.New("not a number")
+		}
+		s.seed = seed
+		return nil
+	})
+	fs.BoolVar(&s.keep, "keep", false, "leave the simulated vehicles parked")
+}
+
+// handleSimulate handles the simulate command
+func (r *CommandRegistry) handleSimulate(s simulation) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	if s.vehicles < 1 {
+		return newUsageError("invalid vehicles value: %d", s.vehicles)
+	}
+	if s.workers < 1 {
+		return newUsageError("invalid workers value: %d", s.workers)
+	}
+	if s.rate < 0 || s.rate > int(time.Second) {
+		return newUsageError("invalid rate value: %d", s.rate)
+	}
+
+	r.Logger.Debug("Simulating %d vehicles with %d workers for %s, seed %d",
+		s.vehicles, s.workers, s.duration, s.seed)
+
+	result, err := s.run(r.parkingLot)
+	if err != nil {
+		return fmt.Errorf("failed to simulate: %v", err)
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("simulate", result, nil)
+	}
+
+	r.out.Success("Simulated %d operations in %.1fs with %d workers: %.1f operations/s",
+		result.Operations, result.DurationSeconds, result.Workers, result.Throughput)
+	r.out.Printf("Parks: %d, unparks: %d, searches: %d (seed %d)\n",
+		result.Parks, result.Unparks, result.Searches, result.Seed)
+	if result.Failures > 0 {
+		r.out.Printf("Failed operations: %d\n", result.Failures)
+		r.out.Println(formatSimulationErrors(result.Errors))
+	}
+
+	if s.keep {
+		r.out.Info("Left %d simulated vehicles parked", result.Kept)
+	} else {
+		r.out.Info("Removed the %d simulated vehicles still parked", result.Removed)
+	}
+	if result.CleanupFailures > 0 {
+		r.out.Warning("Failed to remove %d simulated vehicles", result.CleanupFailures)
+	}
+
+	if result.Consistent {
+		r.out.Success("Consistent: %d occupied spots, all held by parked vehicles", result.OccupiedSpots)
+	} else {
+		r.out.Warning("Inconsistent: %d occupied spots, but parked vehicles hold %d",
+			result.OccupiedSpots, result.HeldSpots)
+	}
+
+	return nil
+}
+
+// formatSimulationErrors formats the failed operations by error code, most
+// frequent first
+func formatSimulationErrors(errors map[string]int) string {
+	codes := make([]string, 0, len(errors))
+	for code := range errors {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if errors[codes[i]] != errors[codes[j]] {
+			return errors[codes[i]] > errors[codes[j]]
+		}
+		return codes[i] < codes[j]
+	})
+
+	rows := make([][]string, len(codes))
+	for i, code := range codes {
+		rows[i] = []string{code, strconv.Itoa(errors[code])}
+	}
+	return FormatTable([]string{"Error", "Count"}, rows)
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	perrors "github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+func TestSimulate(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+
+	if err := registry.ExecuteCommand("init", []string{"1", "2", "5"}); err != nil {
+		t.Fatalf("Failed to init: %v", err)
+	}
+	if err := registry.ExecuteCommand("park", []string{"automobile", "KA-01-HH-1234"}); err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	lot := registry.GetParkingLot()
+
+	// More vehicles than spots, so some parks find the lot full
+	s := newSimulation()
+	s.vehicles, s.workers, s.duration, s.seed = 40, 4, 50*time.Millisecond, 7
+	result, err := s.run(lot)
+	if err != nil {
+		t.Fatalf("Failed to simulate: %v", err)
+	}
+	if result.Operations == 0 || result.Operations != result.Parks+result.Unparks+result.Searches {
+		t.Errorf("Expected operations counted by kind, got %+v", result)
+	}
+	if result.Errors[perrors.CodeNoSpaceAvailable] == 0 {
+		t.Errorf("Expected parks refused once the lot is full, got %v", result.Errors)
+	}
+	if !result.Consistent || result.CleanupFailures != 0 {
+		t.Errorf("Expected a consistent lot after the run, got %+v", result)
+	}
+
+	// The run leaves only the vehicle parked before it
+	if lot.GetParkedVehicleCount() != 1 || !lot.IsVehicleParked("KA-01-HH-1234") {
+		t.Errorf("Expected the simulated vehicles removed, got %d parked", lot.GetParkedVehicleCount())
+	}
+
+	// --keep leaves them parked
+	s.keep = true
+	result, err = s.run(lot)
+	if err != nil {
+		t.Fatalf("Failed to simulate: %v", err)
+	}
+	if result.Kept == 0 || lot.GetParkedVehicleCount() != 1+result.Kept {
+		t.Errorf("Expected %d simulated vehicles kept, got %d parked", result.Kept, lot.GetParkedVehicleCount())
+	}
+
+	// A rate limits the operations
+	s.keep, s.rate, s.duration = false, 100, 100*time.Millisecond
+	result, err = s.run(lot)
+	if err != nil {
+		t.Fatalf("Failed to simulate: %v", err)
+	}
+	if result.Operations > 15 {
+		t.Errorf("Expected about 10 operations at 100/s for 100ms, got %d", result.Operations)
+	}
+}
+
+func TestSimulateCommand(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+
+	if err := registry.ExecuteCommand("simulate", nil); err == nil {
+		t.Error("Expected simulate to fail before init")
+	}
+	if err := registry.ExecuteCommand("init", []string{"1", "2", "5"}); err != nil {
+		t.Fatalf("Failed to init: %v", err)
+	}
+
+	args := []string{"--vehicles", "20", "--duration", "20ms", "--mix", "10:10:80", "--seed", "1"}
+	if err := registry.ExecuteCommand("simulate", args); err != nil {
+		t.Errorf("Failed to simulate: %v", err)
+	}
+	if count := registry.GetParkingLot().GetParkedVehicleCount(); count != 0 {
+		t.Errorf("Expected simulate to clean up, got %d vehicles parked", count)
+	}
+
+	for _, args := range [][]string{
+		{"--vehicles", "0"},
+		{"--duration", "soon"},
+		{"--mix", "0:0:0"},
+		{"--seed"},
+		{"--fast"},
+	} {
+		if err := registry.ExecuteCommand("simulate", args); err == nil {
+			t.Errorf("Expected simulate %v to be rejected", args)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	perrors "github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+	"github.com/prasaria/go-multistorey-parking-lot/internal/model"
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/api"
+)
+
+// handleSave handles the save command
+func (r *CommandRegistry) handleSave(args []string) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	path := args[0]
+	r.Logger.Debug("Saving a snapshot of the lot to %s", path)
+
+	snapshot := r.parkingLot.Snapshot()
+	if err := model.SaveSnapshot(path, snapshot); err != nil {
+		return err
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("save", api.SaveResult{
+			Path:     path,
+			TakenAt:  jsonTime(snapshot.TakenAt),
+			Floors:   len(snapshot.Floors),
+			Vehicles: len(snapshot.Vehicles),
+		}, nil)
+	}
+
+	r.out.Success("Saved %d floors and %d parked vehicles to %s",
+		len(snapshot.Floors), len(snapshot.Vehicles), path)
+
+	return nil
+}
+
+// handleDiff handles the diff command
+func (r *CommandRegistry) handleDiff(args []string) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	path := args[0]
+	r.Logger.Debug("Comparing the lot with the snapshot in %s", path)
+
+	diff, snapshot, err := r.diffSnapshot(path)
+	if err != nil {
+		return err
+	}
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("diff", convertLotDiff(path, snapshot.TakenAt, diff), nil)
+	}
+
+	r.out.Print(formatLotDiff(diff, r.formatTime(snapshot.TakenAt)))
+
+	return nil
+}
+
+// diffSnapshot compares the snapshot saved at path, as the first lot, with
+// the lot. The snapshot is restored with the lot's vehicle number rule and
+// spot IDs, so vehicles the lot accepted are accepted again.
+func (r *CommandRegistry) diffSnapshot(path string) (model.LotDiff, *model.LotSnapshot, error) {
+	snapshot, err := model.LoadSnapshot(path)
+	if err != nil {
+		return model.LotDiff{}, nil, err
+	}
+
+	saved, err := snapshot.Restore(
+		model.WithVehicleNumberValidator(r.parkingLot.VehicleNumberValidator()),
+		model.WithSpotIDFormatter(r.parkingLot.SpotIDFormatter()))
+	if err != nil {
+		return model.LotDiff{}, nil, fmt.Errorf("failed to restore snapshot: %v", err)
+	}
+
+	return model.DiffLots(saved, r.parkingLot), snapshot, nil
+}
+
+// formatLotDiff renders a diff of a snapshot against the lot, a table per
+// floor followed by the vehicles in only one of them
+func formatLotDiff(diff model.LotDiff, takenAt string) string {
+	var sb strings.Builder
+	if diff.IsEmpty() {
+		fmt.Fprintf(&sb, "No differences from the snapshot taken %s\n", takenAt)
+		return sb.String()
+	}
+
+	fmt.Fprintf(&sb, "Differences from the snapshot taken %s:\n", takenAt)
+	for _, floor := range diff.Floors() {
+		var rows [][]string
+		for _, spot := range diff.Spots {
+			if spot.Floor == floor {
+				rows = append(rows, []string{spot.SpotID, describeSpotState(spot.A), describeSpotState(spot.B)})
+			}
+		}
+		fmt.Fprintf(&sb, "\nFloor %s:\n", model.FormatFloorNumber(floor))
+		sb.WriteString(FormatTable([]string{"Spot", "Snapshot", "Current"}, rows))
+	}
+
+	if len(diff.OnlyInA) > 0 {
+		fmt.Fprintf(&sb, "\nOnly in the snapshot: %s\n", strings.Join(diff.OnlyInA, ", "))
+	}
+	if len(diff.OnlyInB) > 0 {
+		fmt.Fprintf(&sb, "\nOnly in the current lot: %s\n", strings.Join(diff.OnlyInB, ", "))
+	}
+	return sb.String()
+}
+
+// describeSpotState renders a spot's type and occupants in a diff, e.g.
+// "A-1 free" or "A-1 KA-01-HH-1234"
+func describeSpotState(state model.SpotState) string {
+	switch {
+	case state.Missing:
+		return "no spot"
+	case !state.Active:
+		return fmt.Sprintf("%s inactive", state.Type)
+	case len(state.Vehicles) == 0:
+		return fmt.Sprintf("%s free", state.Type)
+	default:
+		return fmt.Sprintf("%s %s", state.Type, strings.Join(state.Vehicles, ", "))
+	}
+}
+
+// stateFile is what SaveState writes: every lot of the session by name,
+// with its options, settings and history, and which one is active
+type stateFile struct {
+	Active string                     `json:"active"`
+	Lots   map[string]*model.LotState `json:"lots"`
+}
+
+// LoadState restores the lots saved in a state file and makes the one
+// active then the active lot. A file holding a single snapshot, as saved
+// before, restores that lot only. A missing file is not an error, as the
+// first run has none yet.
+func (r *CommandRegistry) LoadState(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state: %v", err)
+	}
+
+	var state stateFile
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to read state: %v", err)
+	}
+	if state.Lots == nil {
+		snapshot, err := model.ReadSnapshot(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		lot, err := snapshot.Restore(model.WithLogger(lotLogger{registry: r}))
+		if err != nil {
+			return fmt.Errorf("failed to restore state from %s: %v", path, err)
+		}
+		r.SetParkingLot(lot)
+		return nil
+	}
+
+	if _, found := state.Lots[state.Active]; !found {
+		return fmt.Errorf("failed to restore state from %s: no lot named %q", path, state.Active)
+	}
+	for name, saved := range state.Lots {
+		lot, err := saved.Restore(model.WithLogger(lotLogger{registry: r}))
+		if err != nil {
+			return fmt.Errorf("failed to restore lot %s from %s: %v", name, path, err)
+		}
+		r.lots[name] = lot
+	}
+	r.addLot(state.Active, r.lots[state.Active])
+
+	return nil
+}
+
+// SaveState saves every lot to a state file for LoadState, if one is
+// initialized
+func (r *CommandRegistry) SaveState(path string) error {
+	if r.parkingLot == nil {
+		return nil
+	}
+
+	state := stateFile{Active: r.activeLot, Lots: make(map[string]*model.LotState, len(r.lots))}
+	for name, lot := range r.lots {
+		state.Lots[name] = lot.State()
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to write state: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write state: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+//go:build linux
+
+package cli
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// makeRaw turns off line buffering, echo and signal keys on the terminal
+// fd and returns a function restoring its previous mode. Output processing
+// stays on, so newlines still return the carriage.
+func makeRaw(fd int) (func(), error) {
+	var old syscall.Termios
+	if err := termios(fd, syscall.TCGETS, &old); err != nil {
+		return nil, err
+	}
+
+	raw := old
+	raw.Iflag &^= syscall.ICRNL | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := termios(fd, syscall.TCSETS, &raw); err != nil {
+		return nil, err
+	}
+
+	return func() { _ = termios(fd, syscall.TCSETS, &old) }, nil
+}
+
+// termios gets or sets the terminal attributes of fd
+func termios(fd int, request uintptr, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), request, uintptr(unsafe.Pointer(t)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// TerminalWidth returns the number of columns of the terminal f is
+// attached to, or 0 when it is not a terminal
+func TerminalWidth(f *os.File) int {
+	width, _ := terminalSize(f)
+	return width
+}
+
+// terminalHeight returns the number of rows of the terminal f is attached
+// to, or 0 when it is not a terminal
+func terminalHeight(f *os.File) int {
+	_, height := terminalSize(f)
+	return height
+}
+
+// terminalSize returns the columns and rows of the terminal f is attached
+// to, both 0 when it is not a terminal
+func terminalSize(f *os.File) (int, int) {
+	var size struct {
+		rows, columns, xPixels, yPixels uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&size)))
+	if errno != 0 {
+		return 0, 0
+	}
+	return int(size.columns), int(size.rows)
+}
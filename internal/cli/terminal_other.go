@@ -0,0 +1,23 @@
+//go:build !linux
+
+package cli
+
+import (
+	"errors"
+	"os"
+)
+
+// makeRaw is not supported here, so the prompt reads whole lines instead
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
+
+// TerminalWidth cannot ask the terminal here, so tables are not fitted
+func TerminalWidth(f *os.File) int {
+	return 0
+}
+
+// terminalHeight cannot ask the terminal here, so output is not paged
+func terminalHeight(f *os.File) int {
+	return 0
+}
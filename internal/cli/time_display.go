@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/config"
+)
+
+// timeDisplay is how text output shows times, with its zone loaded
+type timeDisplay struct {
+	config   config.DisplayConfig
+	location *time.Location
+}
+
+// timeDisplaySetting is the display set for times, the default when nil
+var timeDisplaySetting atomic.Pointer[timeDisplay]
+
+// SetTimeDisplay sets how text output shows times, rejecting a layout or
+// zone that is not valid
+func SetTimeDisplay(display config.DisplayConfig) error {
+	if err := display.Validate(); err != nil {
+		return err
+	}
+	location, err := display.Location()
+	if err != nil {
+		return err
+	}
+	timeDisplaySetting.Store(&timeDisplay{config: display, location: location})
+	return nil
+}
+
+// TimeDisplay returns how text output shows times
+func TimeDisplay() config.DisplayConfig {
+	if display := timeDisplaySetting.Load(); display != nil {
+		return display.config
+	}
+	return config.DefaultDisplayConfig()
+}
+
+// timeLayout returns the layout text output shows times in
+func timeLayout() string {
+	return TimeDisplay().TimeLayout
+}
+
+// displayLocation returns the zone set for times, or fallback when times
+// follow the lot's zone
+func displayLocation(fallback *time.Location) *time.Location {
+	if display := timeDisplaySetting.Load(); display != nil && display.location != nil {
+		return display.location
+	}
+	return fallback
+}
+
+// jsonTime shows a time as JSON output does, in RFC 3339 and UTC whatever
+// the display set
+func jsonTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
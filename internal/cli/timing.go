@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/model"
+)
+
+// runTimed runs a command's handler and, with verbose logging, logs how
+// long it took and the phases of the park or unpark it did
+func (r *CommandRegistry) runTimed(cmd *Command, args []string) error {
+	// A script's commands are timed on their own, inside the script's time
+	saved := r.timings
+	r.timings = &model.OperationTimings{}
+	defer func() { r.timings = saved }()
+
+	started := time.Now()
+	err := cmd.Handler(args)
+	elapsed := time.Since(started)
+
+	outcome := "completed"
+	if err != nil {
+		outcome = "failed"
+	}
+	r.Logger.Debug("%s %s in %s%s", cmd.Name, outcome, formatElapsed(elapsed), formatPhases(r.timings.Phases))
+	return err
+}
+
+// formatElapsed shows a short time to about three significant figures,
+// e.g. "3.2ms"
+func formatElapsed(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		d = d.Round(10 * time.Millisecond)
+	case d >= time.Millisecond:
+		d = d.Round(100 * time.Microsecond)
+	case d >= time.Microsecond:
+		d = d.Round(100 * time.Nanosecond)
+	}
+	return d.String()
+}
+
+// formatPhases lists the phases timed, e.g. " (selection 2.9ms, bookkeeping
+// 0.3ms)", empty when none were
+func formatPhases(phases []model.PhaseTiming) string {
+	if len(phases) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(phases))
+	for _, phase := range phases {
+		parts = append(parts, phase.Phase+" "+formatElapsed(phase.Duration))
+	}
+	return fmt.Sprintf(" (%s)", strings.Join(parts, ", "))
+}
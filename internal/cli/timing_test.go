@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCommandTiming(t *testing.T) {
+	SetColorOutput(false)
+	t.Cleanup(func() { SetColorOutput(true) })
+	c := newTestCLI(t)
+	c.run("init", "1", "2", "5")
+
+	// logged runs a command and returns what it logged, which goes to the
+	// registry's error output
+	logged := func(name string, args ...string) string {
+		return c.run(name, args...)
+	}
+
+	parkLine := regexp.MustCompile(`\[DEBUG\] park completed in \S+ \(selection \S+, bookkeeping \S+\)\n`)
+	if log := logged("park", "automobile", "KA-01-HH-1234", "--verbose"); !parkLine.MatchString(log) {
+		t.Errorf("Expected the park timed with its phases, got %q", log)
+	}
+	unparkLine := regexp.MustCompile(`\[DEBUG\] unpark completed in \S+ \(lookup \S+, bookkeeping \S+\)\n`)
+	if log := logged("unpark", "0-0-2", "KA-01-HH-1234", "-v"); !unparkLine.MatchString(log) {
+		t.Errorf("Expected the unpark timed with its phases, got %q", log)
+	}
+	statusLine := regexp.MustCompile(`\[DEBUG\] status completed in \S+\n`)
+	if log := logged("status", "--verbose"); !statusLine.MatchString(log) {
+		t.Errorf("Expected the status timed, got %q", log)
+	}
+
+	// Without --verbose nothing is timed
+	if log := logged("park", "automobile", "KA-01-HH-9999"); strings.Contains(log, "completed in") {
+		t.Errorf("Expected no timing without --verbose, got %q", log)
+	}
+}
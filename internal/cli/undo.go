@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+
+	perrors "github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+	"github.com/prasaria/go-multistorey-parking-lot/internal/model"
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/api"
+)
+
+// journalLimit is how many operations of a lot undo can step back through
+const journalLimit = 20
+
+// journalEntry is a successful park or unpark, with what undo needs to invert it
+type journalEntry struct {
+	// Unparked is set for an unpark, which undo parks again; a park is
+	// undone by unparking the vehicle
+	Unparked bool
+	Vehicle  model.ParkedVehicle
+}
+
+// operationJournal holds the last operations of a lot, oldest first
+type operationJournal struct {
+	entries []journalEntry
+}
+
+// push records an operation, dropping the oldest past journalLimit
+func (j *operationJournal) push(entry journalEntry) {
+	j.entries = append(j.entries, entry)
+	if len(j.entries) > journalLimit {
+		j.entries = j.entries[len(j.entries)-journalLimit:]
+	}
+}
+
+// last returns the latest operation, if any
+func (j *operationJournal) last() (journalEntry, bool) {
+	if j == nil || len(j.entries) == 0 {
+		return journalEntry{}, false
+	}
+	return j.entries[len(j.entries)-1], true
+}
+
+// pop drops the latest operation
+func (j *operationJournal) pop() {
+	j.entries = j.entries[:len(j.entries)-1]
+}
+
+// record adds an operation to the journal of the active lot
+func (r *CommandRegistry) record(entry journalEntry) {
+	if r.journals == nil {
+		r.journals = make(map[*model.ParkingLot]*operationJournal)
+	}
+	journal, found := r.journals[r.parkingLot]
+	if !found {
+		journal = &operationJournal{}
+		r.journals[r.parkingLot] = journal
+	}
+	journal.push(entry)
+}
+
+// handleUndo handles the undo command
+func (r *CommandRegistry) handleUndo(args []string) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	journal := r.journals[r.parkingLot]
+	entry, found := journal.last()
+	if !found {
+		return fmt.Errorf("nothing to undo")
+	}
+	vehicle := entry.Vehicle
+
+	// A failed undo keeps the entry, so it can be retried once the way is clear
+	result := api.UndoResult{VehicleNumber: vehicle.VehicleNumber, SpotID: vehicle.SpotID}
+	if entry.Unparked {
+		result.Operation = "unpark"
+		r.Logger.Debug("Undoing unpark of %s from spot %s", vehicle.VehicleNumber, vehicle.SpotID)
+
+		// The vehicle parked there before, so a permit the spot needs is
+		// taken as held
+		opts := model.ParkOptions{SpotID: vehicle.SpotID, Permit: true, Reserved: vehicle.Reserved}
+		if _, err := r.parkingLot.ParkWithOptions(vehicle.VehicleType, vehicle.VehicleNumber, opts); err != nil {
+			return fmt.Errorf("cannot undo unpark of %s from spot %s: %v", vehicle.VehicleNumber, vehicle.SpotID, err)
+		}
+	} else {
+		result.Operation = "park"
+		r.Logger.Debug("Undoing park of %s at spot %s", vehicle.VehicleNumber, vehicle.SpotID)
+
+		if err := r.parkingLot.Unpark(vehicle.SpotID, vehicle.VehicleNumber); err != nil {
+			return fmt.Errorf("cannot undo park of %s at spot %s: %v", vehicle.VehicleNumber, vehicle.SpotID, err)
+		}
+	}
+	journal.pop()
+
+	if r.Options.Format == OutputFormatJSON {
+		return r.out.JSON("undo", result, nil)
+	}
+
+	if entry.Unparked {
+		r.out.Success("Undid unpark: vehicle %s parked again at spot %s", vehicle.VehicleNumber, vehicle.SpotID)
+	} else {
+		r.out.Success("Undid park: vehicle %s removed from spot %s", vehicle.VehicleNumber, vehicle.SpotID)
+	}
+
+	return nil
+}
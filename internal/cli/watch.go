@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	perrors "github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+	"github.com/prasaria/go-multistorey-parking-lot/internal/model"
+)
+
+// Terminal control sequences used while watching
+const (
+	clearScreen = "\033[H\033[2J"
+	hideCursor  = "\033[?25l"
+	showCursor  = "\033[?25h"
+)
+
+// IsTerminal reports whether f is attached to a terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// handleWatch handles the watch command
+func (r *CommandRegistry) handleWatch(args []string, showMap bool) error {
+	// Check if parking lot is initialized
+	if r.parkingLot == nil {
+		return perrors.NewLotNotInitializedError()
+	}
+
+	interval := 2 * time.Second
+	if len(args) > 0 {
+		seconds, err := strconv.Atoi(args[0])
+		if err != nil || seconds < 1 {
+			return newUsageError("invalid interval value: %s (expected whole seconds)", args[0])
+		}
+		interval = time.Duration(seconds) * time.Second
+	}
+
+	r.Logger.Debug("Watching status every %s", interval)
+
+	// Stop on Enter, or on Ctrl+C instead of exiting the program
+	stop := make(chan struct{})
+	enter := make(chan struct{})
+	go func() {
+		_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+		close(enter)
+	}()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	interrupted := false
+	go func() {
+		select {
+		case <-enter:
+		case <-interrupt:
+			interrupted = true
+		}
+		close(stop)
+	}()
+
+	tty := r.out.terminal()
+	if tty {
+		r.out.Print(hideCursor)
+	}
+
+	r.watchStatus(r.out.Out, interval, showMap, tty, stop)
+
+	if tty {
+		r.out.Print(colorReset + showCursor)
+	}
+
+	// The Enter reader is still waiting; let it consume the next line here
+	// rather than swallow the next command at the prompt
+	if interrupted {
+		r.out.Println("Stopped watching, press Enter to continue")
+		<-enter
+	}
+
+	return nil
+}
+
+// watchStatus renders the status every interval until stop is closed.
+// The screen is only cleared between frames when clear is set.
+func (r *CommandRegistry) watchStatus(w io.Writer, interval time.Duration, showMap, clear bool,
+	stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.writeWatchFrame(w, interval, showMap, clear)
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeWatchFrame renders a single refresh of the watch command
+func (r *CommandRegistry) writeWatchFrame(w io.Writer, interval time.Duration, showMap, clear bool) {
+	if clear {
+		fmt.Fprint(w, clearScreen)
+	}
+
+	fmt.Fprintf(w, "Every %s: status at %s (press Enter or Ctrl+C to stop)\n\n",
+		interval, r.now().In(r.location()).Format("15:04:05"))
+
+	r.writeStatusText(w, r.collectStatus())
+
+	if showMap {
+		for _, floor := range r.parkingLot.GetFloors() {
+			title := fmt.Sprintf("Floor %s", model.FormatFloorNumber(floor.FloorNumber))
+			fmt.Fprintln(w, FormatFloorMap(title, floor.GetDisplayState(), 80))
+		}
+	}
+}
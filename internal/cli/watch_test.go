@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchStatus(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.RegisterAllCommands()
+
+	if err := registry.ExecuteCommand("init", []string{"1", "2", "4"}); err != nil {
+		t.Fatalf("Failed to init: %v", err)
+	}
+	if err := registry.ExecuteCommand("park", []string{"automobile", "WATCH-1"}); err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+
+	// A closed stop channel runs exactly one refresh cycle
+	stop := make(chan struct{})
+	close(stop)
+
+	var buf bytes.Buffer
+	registry.watchStatus(&buf, time.Second, true, false, stop)
+
+	output := buf.String()
+	for _, expected := range []string{"Spot types:", "WATCH-1", "Floor 0"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected watch output to contain %q, got:\n%s", expected, output)
+		}
+	}
+
+	if strings.Contains(output, clearScreen) {
+		t.Errorf("Expected no clear codes when not writing to a terminal")
+	}
+	if strings.Count(output, "Spot types:") != 1 {
+		t.Errorf("Expected a single refresh, got %d", strings.Count(output, "Spot types:"))
+	}
+
+	buf.Reset()
+	registry.writeWatchFrame(&buf, time.Second, false, true)
+	if !strings.HasPrefix(buf.String(), clearScreen) {
+		t.Errorf("Expected frame to start by clearing the screen")
+	}
+
+	if err := registry.ExecuteCommand("watch", []string{"0"}); err == nil {
+		t.Errorf("Expected error for a zero interval")
+	}
+}
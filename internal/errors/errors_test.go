@@ -2,6 +2,7 @@ package errors
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -124,6 +125,36 @@ func TestSpecificErrors(t *testing.T) {
 		t.Errorf("Expected value 'ABC', got '%s'", validationErr.Value)
 	}
 
+	// Test PermitRequiredError
+	permitErr := NewPermitRequiredError("0-1-2", "KA-01-HH-1234")
+	if permitErr.Code != CodePermitRequired || permitErr.SpotID != "0-1-2" || !errors.Is(permitErr, ErrPermitRequired) {
+		t.Errorf("Expected a permit error for spot 0-1-2, got %+v", permitErr)
+	}
+
+	// Test FloorRestrictedError
+	restrictedErr := NewFloorRestrictedError("0", "0-1-2", "AUTOMOBILE")
+	if restrictedErr.Code != CodeFloorRestricted || restrictedErr.SpotID != "0-1-2" ||
+		!errors.Is(restrictedErr, ErrFloorRestricted) {
+		t.Errorf("Expected a floor restriction error for spot 0-1-2, got %+v", restrictedErr)
+	}
+
+	// Test QuotaExceededError
+	quotaErr := NewQuotaExceededError("AUTOMOBILE", 50)
+	if quotaErr.Code != CodeQuotaExceeded || quotaErr.Quota != 50 || !errors.Is(quotaErr, ErrQuotaExceeded) {
+		t.Errorf("Expected a quota error for 50 automobiles, got %+v", quotaErr)
+	}
+
+	// Test LayoutMapError
+	mapErr := NewLayoutMapError(3, 5, "Q", "invalid spot character 'Q'")
+	if mapErr.Line != 3 || mapErr.Column != 5 || mapErr.Character != "Q" {
+		t.Errorf("Expected position 3:5 and character Q, got %d:%d and %s",
+			mapErr.Line, mapErr.Column, mapErr.Character)
+	}
+
+	if !strings.Contains(mapErr.Error(), "line 3, column 5") {
+		t.Errorf("Expected error message to contain the position, got %s", mapErr.Error())
+	}
+
 	// Test standard errors.Is usage
 	if !errors.Is(noSpaceErr, ErrNoSpaceAvailable) {
 		t.Errorf("errors.Is failed for NoSpaceError and ErrNoSpaceAvailable")
@@ -137,3 +168,43 @@ func TestSpecificErrors(t *testing.T) {
 		t.Errorf("errors.Is failed for VehicleNotFoundError and ErrVehicleNotFound")
 	}
 }
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		code string
+	}{
+		{NewNoSpaceError("AUTOMOBILE"), CodeNoSpaceAvailable},
+		{NewQuotaExceededError("AUTOMOBILE", 2), CodeQuotaExceeded},
+		{WrapError(NewVehicleNotFoundError("KA-01-HH-1234"), "RETRIEVAL_ERROR", "lookup failed"), "RETRIEVAL_ERROR"},
+		{fmt.Errorf("parking: %w", NewVehicleNotFoundError("KA-01-HH-1234")), CodeVehicleNotFound},
+		{errors.New("plain error"), CodeInternalError},
+	}
+
+	for _, test := range tests {
+		if code := CodeOf(test.err); code != test.code {
+			t.Errorf("Expected code %s for %v, got %s", test.code, test.err, code)
+		}
+	}
+}
+
+func TestDetailsOf(t *testing.T) {
+	tests := []struct {
+		err     error
+		details string
+	}{
+		{NewNoSpaceError("AUTOMOBILE"), "map[vehicleType:AUTOMOBILE]"},
+		{NewNoTaggedSpaceError("AUTOMOBILE", []string{"ev"}), "map[tags:[ev] vehicleType:AUTOMOBILE]"},
+		{fmt.Errorf("parking: %w", NewSpotNotOccupiedError("0-1-2")), "map[spotId:0-1-2]"},
+		{NewValidationError("vehicleNumber", "ABC", "too short"), "map[field:vehicleNumber value:ABC]"},
+		{NewQuotaExceededError("AUTOMOBILE", 0), "map[quota:0 vehicleType:AUTOMOBILE]"},
+		{NewParkingError(CodeInvalidOperation, "plain", nil), "map[]"},
+		{errors.New("plain error"), "map[]"},
+	}
+
+	for _, test := range tests {
+		if details := fmt.Sprint(DetailsOf(test.err)); details != test.details {
+			t.Errorf("Expected details %s for %v, got %s", test.details, test.err, details)
+		}
+	}
+}
@@ -57,6 +57,70 @@ func WrapError(err error, code, message string) *ParkingError {
 	}
 }
 
+// errorCode returns the code, which the specific errors embedding
+// ParkingError share
+func (e *ParkingError) errorCode() string {
+	return e.Code
+}
+
+// CodeOf returns the code of the first parking error in the chain of err,
+// or CodeInternalError when it has none
+func CodeOf(err error) string {
+	var coded interface{ errorCode() string }
+	if errors.As(err, &coded) {
+		return coded.errorCode()
+	}
+	return CodeInternalError
+}
+
+// CodesOf returns the codes of the parking errors in the chain of err,
+// outermost first
+func CodesOf(err error) []string {
+	var codes []string
+	for ; err != nil; err = errors.Unwrap(err) {
+		if coded, ok := err.(interface{ errorCode() string }); ok {
+			codes = append(codes, coded.errorCode())
+		}
+	}
+	return codes
+}
+
+// ErrorDetails returns the structured fields of the error, none for a
+// plain parking error
+func (e *ParkingError) ErrorDetails() map[string]interface{} {
+	return nil
+}
+
+// DetailsOf returns the structured fields of the first parking error in the
+// chain of err, the one CodeOf takes the code from
+func DetailsOf(err error) map[string]interface{} {
+	var detailed interface{ ErrorDetails() map[string]interface{} }
+	if errors.As(err, &detailed) {
+		return detailed.ErrorDetails()
+	}
+	return nil
+}
+
+// details returns the fields given, leaving out the empty ones
+func details(fields map[string]interface{}) map[string]interface{} {
+	for key, value := range fields {
+		switch v := value.(type) {
+		case string:
+			if v == "" {
+				delete(fields, key)
+			}
+		case []string:
+			if len(v) == 0 {
+				delete(fields, key)
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
 // Predefined error codes
 const (
 	CodeInvalidOperation     = "INVALID_OPERATION"
@@ -64,6 +128,7 @@ const (
 	CodeNoSpaceAvailable     = "NO_SPACE_AVAILABLE"
 	CodeVehicleNotFound      = "VEHICLE_NOT_FOUND"
 	CodeVehicleAlreadyParked = "VEHICLE_ALREADY_PARKED"
+	CodeVehicleNumberClash   = "VEHICLE_NUMBER_CLASH"
 	CodeSpotAlreadyOccupied  = "SPOT_ALREADY_OCCUPIED"
 	CodeSpotNotOccupied      = "SPOT_NOT_OCCUPIED"
 	CodeInvalidSpotID        = "INVALID_SPOT_ID"
@@ -73,6 +138,11 @@ const (
 	CodeSpotInactive         = "SPOT_INACTIVE"
 	CodeInvalidSpotType      = "INVALID_SPOT_TYPE"
 	CodeInvalidFloor         = "INVALID_FLOOR"
+	CodePermitRequired       = "PERMIT_REQUIRED"
+	CodeFloorRestricted      = "FLOOR_RESTRICTED"
+	CodeQuotaExceeded        = "QUOTA_EXCEEDED"
+	CodeLotNotInitialized    = "LOT_NOT_INITIALIZED"
+	CodeCommandFailed        = "COMMAND_FAILED"
 	CodeInternalError        = "INTERNAL_ERROR"
 )
 
@@ -92,5 +162,11 @@ var (
 	ErrSpotInactive         = errors.New("spot is inactive")
 	ErrInvalidSpotType      = errors.New("invalid spot type")
 	ErrInvalidFloor         = errors.New("invalid floor")
+	ErrPermitRequired       = errors.New("permit required")
+	ErrFloorRestricted      = errors.New("floor restricted")
+	ErrQuotaExceeded        = errors.New("quota exceeded")
 	ErrInternalError        = errors.New("internal error")
+
+	// Returned with a zero duration by an unpark that found no parking record
+	ErrNoParkingRecord = errors.New("no parking record")
 )
@@ -1,11 +1,16 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // NoSpaceError is returned when there's no available space for a vehicle
 type NoSpaceError struct {
 	ParkingError
 	VehicleType string
+	// Spot tags the vehicle required, if any
+	Tags []string
 }
 
 // NewNoSpaceError creates a new NoSpaceError
@@ -20,6 +25,26 @@ func NewNoSpaceError(vehicleType string) *NoSpaceError {
 	}
 }
 
+// NewNoTaggedSpaceError creates a NoSpaceError for a vehicle that needs a
+// spot carrying the given tags
+func NewNoTaggedSpaceError(vehicleType string, tags []string) *NoSpaceError {
+	return &NoSpaceError{
+		ParkingError: ParkingError{
+			Code: CodeNoSpaceAvailable,
+			Message: fmt.Sprintf("No available parking spot for vehicle type: %s with tags: %s",
+				vehicleType, strings.Join(tags, ", ")),
+			Err: ErrNoSpaceAvailable,
+		},
+		VehicleType: vehicleType,
+		Tags:        tags,
+	}
+}
+
+// ErrorDetails returns the vehicle type and tags that found no spot
+func (e *NoSpaceError) ErrorDetails() map[string]interface{} {
+	return details(map[string]interface{}{"vehicleType": e.VehicleType, "tags": e.Tags})
+}
+
 // InvalidOperationError is returned when an operation is invalid in the current state
 type InvalidOperationError struct {
 	ParkingError
@@ -40,6 +65,11 @@ func NewInvalidOperationError(operation, reason string) *InvalidOperationError {
 	}
 }
 
+// ErrorDetails returns the operation and why it was refused
+func (e *InvalidOperationError) ErrorDetails() map[string]interface{} {
+	return details(map[string]interface{}{"operation": e.Operation, "reason": e.Reason})
+}
+
 // VehicleNotFoundError is returned when a vehicle is not found
 type VehicleNotFoundError struct {
 	ParkingError
@@ -58,6 +88,11 @@ func NewVehicleNotFoundError(vehicleNumber string) *VehicleNotFoundError {
 	}
 }
 
+// ErrorDetails returns the vehicle number not found
+func (e *VehicleNotFoundError) ErrorDetails() map[string]interface{} {
+	return details(map[string]interface{}{"vehicleNumber": e.VehicleNumber})
+}
+
 // SpotOccupancyError is returned for errors related to spot occupancy
 type SpotOccupancyError struct {
 	ParkingError
@@ -107,6 +142,11 @@ func NewVehicleMismatchError(spotID, expected, actual string) *SpotOccupancyErro
 	}
 }
 
+// ErrorDetails returns the spot and, for a mismatch, the vehicle found there
+func (e *SpotOccupancyError) ErrorDetails() map[string]interface{} {
+	return details(map[string]interface{}{"spotId": e.SpotID, "vehicleNumber": e.VehicleNumber})
+}
+
 // ValidationError is returned for validation errors
 type ValidationError struct {
 	ParkingError
@@ -166,6 +206,11 @@ func NewInvalidVehicleNumberError(vehicleNumber, reason string) *ValidationError
 	}
 }
 
+// ErrorDetails returns the field and the value refused
+func (e *ValidationError) ErrorDetails() map[string]interface{} {
+	return details(map[string]interface{}{"field": e.Field, "value": e.Value})
+}
+
 // VehicleAlreadyParkedError is returned when trying to park a vehicle that's already parked
 type VehicleAlreadyParkedError struct {
 	ParkingError
@@ -186,6 +231,106 @@ func NewVehicleAlreadyParkedError(vehicleNumber, spotID string) *VehicleAlreadyP
 	}
 }
 
+// NewVehicleNumberClashError creates a VehicleAlreadyParkedError for a vehicle
+// whose number only matches a parked vehicle's once separators are ignored
+func NewVehicleNumberClashError(vehicleNumber, parkedNumber, spotID string) *VehicleAlreadyParkedError {
+	return &VehicleAlreadyParkedError{
+		ParkingError: ParkingError{
+			Code: CodeVehicleNumberClash,
+			Message: "Vehicle " + vehicleNumber + " matches " + parkedNumber +
+				", already parked at spot " + spotID + ", when separators are ignored",
+			Err: ErrVehicleAlreadyParked,
+		},
+		VehicleNumber: vehicleNumber,
+		CurrentSpotID: spotID,
+	}
+}
+
+// ErrorDetails returns the vehicle number and the spot of the parked vehicle
+func (e *VehicleAlreadyParkedError) ErrorDetails() map[string]interface{} {
+	return details(map[string]interface{}{"vehicleNumber": e.VehicleNumber, "spotId": e.CurrentSpotID})
+}
+
+// PermitRequiredError is returned when a vehicle without an accessibility
+// permit is parked in an accessible spot
+type PermitRequiredError struct {
+	ParkingError
+	SpotID        string
+	VehicleNumber string
+}
+
+// NewPermitRequiredError creates a new PermitRequiredError
+func NewPermitRequiredError(spotID, vehicleNumber string) *PermitRequiredError {
+	return &PermitRequiredError{
+		ParkingError: ParkingError{
+			Code:    CodePermitRequired,
+			Message: "Spot " + spotID + " is accessible and vehicle " + vehicleNumber + " has no permit",
+			Err:     ErrPermitRequired,
+		},
+		SpotID:        spotID,
+		VehicleNumber: vehicleNumber,
+	}
+}
+
+// ErrorDetails returns the accessible spot and the vehicle without a permit
+func (e *PermitRequiredError) ErrorDetails() map[string]interface{} {
+	return details(map[string]interface{}{"spotId": e.SpotID, "vehicleNumber": e.VehicleNumber})
+}
+
+// FloorRestrictedError is returned when a vehicle is parked on a floor whose
+// policy does not allow its vehicle type
+type FloorRestrictedError struct {
+	ParkingError
+	Floor       string
+	SpotID      string
+	VehicleType string
+}
+
+// NewFloorRestrictedError creates a new FloorRestrictedError
+func NewFloorRestrictedError(floor, spotID, vehicleType string) *FloorRestrictedError {
+	return &FloorRestrictedError{
+		ParkingError: ParkingError{
+			Code:    CodeFloorRestricted,
+			Message: "Floor " + floor + " of spot " + spotID + " does not allow vehicle type " + vehicleType,
+			Err:     ErrFloorRestricted,
+		},
+		Floor:       floor,
+		SpotID:      spotID,
+		VehicleType: vehicleType,
+	}
+}
+
+// ErrorDetails returns the floor, the spot and the vehicle type refused
+func (e *FloorRestrictedError) ErrorDetails() map[string]interface{} {
+	return details(map[string]interface{}{"floor": e.Floor, "spotId": e.SpotID, "vehicleType": e.VehicleType})
+}
+
+// QuotaExceededError is returned when a general park would take a vehicle
+// type past its quota
+type QuotaExceededError struct {
+	ParkingError
+	VehicleType string
+	Quota       int
+}
+
+// NewQuotaExceededError creates a new QuotaExceededError
+func NewQuotaExceededError(vehicleType string, quota int) *QuotaExceededError {
+	return &QuotaExceededError{
+		ParkingError: ParkingError{
+			Code:    CodeQuotaExceeded,
+			Message: fmt.Sprintf("Quota of %d general parks for vehicle type %s reached", quota, vehicleType),
+			Err:     ErrQuotaExceeded,
+		},
+		VehicleType: vehicleType,
+		Quota:       quota,
+	}
+}
+
+// ErrorDetails returns the vehicle type and its quota
+func (e *QuotaExceededError) ErrorDetails() map[string]interface{} {
+	return details(map[string]interface{}{"vehicleType": e.VehicleType, "quota": e.Quota})
+}
+
 // SpotTypeError is returned for errors related to spot types
 type SpotTypeError struct {
 	ParkingError
@@ -229,3 +374,50 @@ func NewVehicleSpotTypeMismatchError(vehicleType, spotType string) *SpotTypeErro
 		SpotType:    spotType,
 	}
 }
+
+// ErrorDetails returns the spot type and the vehicle type, if any
+func (e *SpotTypeError) ErrorDetails() map[string]interface{} {
+	return details(map[string]interface{}{"spotType": e.SpotType, "vehicleType": e.VehicleType})
+}
+
+// LayoutMapError is returned when a layout map file cannot be parsed
+type LayoutMapError struct {
+	ParkingError
+	Line      int
+	Column    int
+	Character string
+}
+
+// NewLayoutMapError creates a new LayoutMapError. Column and character are
+// zero values when the problem concerns a whole line
+func NewLayoutMapError(line, column int, character, reason string) *LayoutMapError {
+	position := fmt.Sprintf("line %d", line)
+	if column > 0 {
+		position = fmt.Sprintf("line %d, column %d", line, column)
+	}
+
+	return &LayoutMapError{
+		ParkingError: ParkingError{
+			Code:    CodeInvalidInput,
+			Message: "Invalid layout map at " + position + ": " + reason,
+			Err:     ErrInvalidInput,
+		},
+		Line:      line,
+		Column:    column,
+		Character: character,
+	}
+}
+
+// ErrorDetails returns the position of the problem in the map
+func (e *LayoutMapError) ErrorDetails() map[string]interface{} {
+	return details(map[string]interface{}{"line": e.Line, "column": e.Column, "character": e.Character})
+}
+
+// NewLotNotInitializedError creates the error returned for a command that
+// needs a lot before one is initialized
+func NewLotNotInitializedError() *ParkingError {
+	return &ParkingError{
+		Code:    CodeLotNotInitialized,
+		Message: "Parking lot not initialized, use 'init' command first",
+	}
+}
@@ -0,0 +1,134 @@
+package model
+
+import "sort"
+
+// AccessibleTag marks a spot reserved for vehicles with an accessibility permit
+const AccessibleTag = "accessible"
+
+// WithAccessibleOverflow lets vehicles without a permit park in accessible
+// spots when no other spot is free. By default they never do.
+func WithAccessibleOverflow() LotOption {
+	return func(p *ParkingLot) {
+		p.accessibleOverflow = true
+	}
+}
+
+// AllowsAccessibleOverflow reports whether vehicles without a permit may
+// take accessible spots once the others are full
+func (p *ParkingLot) AllowsAccessibleOverflow() bool {
+	return p.accessibleOverflow
+}
+
+// RegisterPermit records that the vehicle holds an accessibility permit, so
+// every park of it may use accessible spots
+func (p *ParkingLot) RegisterPermit(vehicleNumber string) error {
+	if err := p.validateVehicleNumber(vehicleNumber); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.permits == nil {
+		p.permits = make(map[string]bool)
+	}
+	p.permits[p.vehicleKey(vehicleNumber)] = true
+	return nil
+}
+
+// RevokePermit removes the vehicle's registered permit, reporting whether it
+// had one
+func (p *ParkingLot) RevokePermit(vehicleNumber string) bool {
+	key := p.vehicleKey(vehicleNumber)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.permits[key] {
+		return false
+	}
+	delete(p.permits, key)
+	return true
+}
+
+// HasPermit reports whether the vehicle has a registered permit
+func (p *ParkingLot) HasPermit(vehicleNumber string) bool {
+	key := p.vehicleKey(vehicleNumber)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.permits[key]
+}
+
+// GetPermits returns the vehicle keys with a registered permit, sorted
+func (p *ParkingLot) GetPermits() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	permits := make([]string, 0, len(p.permits))
+	for key := range p.permits {
+		permits = append(permits, key)
+	}
+	sort.Strings(permits)
+	return permits
+}
+
+// isAccessible reports whether the spot is reserved for permit holders
+func (s *ParkingSpot) isAccessible() bool {
+	return s.HasTags([]string{AccessibleTag})
+}
+
+// selectSpotLocked returns the free spots a park with the options takes
+// next, one unless the vehicle is oversized, or nil; p.mu must be held. Permit holders get accessible spots first and
+// EVs get working chargers first. Others skip accessible spots, unless the
+// lot allows overflow and nothing else is free.
+func (p *ParkingLot) selectSpotLocked(vehicleType VehicleType, opts ParkOptions) []*ParkingSpot {
+	matches := func(accessible, charger bool) func(*ParkingSpot) bool {
+		return func(spot *ParkingSpot) bool {
+			return spot.isAccessible() == accessible &&
+				(!charger || spot.hasWorkingCharger()) &&
+				spot.HasTags(opts.Tags)
+		}
+	}
+
+	var passes []func(*ParkingSpot) bool
+	if opts.Permit {
+		if opts.PreferCharger {
+			passes = append(passes, matches(true, true))
+		}
+		passes = append(passes, matches(true, false))
+	}
+	if opts.PreferCharger {
+		passes = append(passes, matches(false, true))
+	}
+	passes = append(passes, matches(false, false))
+	if !opts.Permit && p.accessibleOverflow {
+		passes = append(passes, matches(true, false))
+	}
+
+	for _, keep := range passes {
+		if span := p.firstAvailableSpanLocked(vehicleType, keep); span != nil {
+			return span
+		}
+	}
+
+	return nil
+}
+
+// AccessibleCount is how many accessible spots a lot has, and how many of
+// them are available
+type AccessibleCount struct {
+	Spots     int
+	Available int
+}
+
+// GetAccessibleCount counts the accessible spots, with availability as in TagCounts
+func (p *ParkingLot) GetAccessibleCount() AccessibleCount {
+	for _, count := range p.TagCounts() {
+		if count.Tag == AccessibleTag {
+			return AccessibleCount{Spots: count.Spots, Available: count.Available}
+		}
+	}
+	return AccessibleCount{}
+}
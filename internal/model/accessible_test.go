@@ -0,0 +1,158 @@
+package model
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// newAccessibleLot returns a lot whose automobile spots, in park order, are
+// listed, with the first two tagged accessible
+func newAccessibleLot(t *testing.T, opts ...LotOption) (*ParkingLot, []string) {
+	t.Helper()
+
+	lot, err := CreateParkingLot("Accessible Lot", 1, 2, 5, opts...)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	var spots []string
+	for _, info := range lot.ListSpots(SpotFilter{Type: SpotTypeAutomobile}) {
+		spots = append(spots, info.SpotID)
+	}
+	for _, spotID := range spots[:2] {
+		if err := lot.TagSpot(spotID, AccessibleTag); err != nil {
+			t.Fatalf("Failed to tag %s: %v", spotID, err)
+		}
+	}
+
+	return lot, spots
+}
+
+func TestAccessibleSpotsStrict(t *testing.T) {
+	lot, spots := newAccessibleLot(t)
+
+	// Vehicles without a permit skip the accessible spots, even when full
+	for i := range spots[2:] {
+		number := fmt.Sprintf("KA-01-HH-%04d", i+1)
+		spotID, err := lot.Park(VehicleTypeAutomobile, number)
+		if err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+		if spotID != spots[i+2] {
+			t.Errorf("Expected %s in %s, got %s", number, spots[i+2], spotID)
+		}
+	}
+	_, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-9999")
+	if !stderrors.Is(err, errors.ErrNoSpaceAvailable) {
+		t.Errorf("Expected no space with only accessible spots free, got %v", err)
+	}
+
+	// Choosing an accessible spot without a permit fails with its own code
+	_, err = lot.ParkAt(spots[0], VehicleTypeAutomobile, "KA-01-HH-9999")
+	var permitErr *errors.PermitRequiredError
+	if !stderrors.As(err, &permitErr) || permitErr.Code != errors.CodePermitRequired {
+		t.Errorf("Expected a permit required error, got %v", err)
+	}
+
+	// A permit shown at park time, or registered, opens the accessible spots
+	detail, err := lot.ParkWithOptions(VehicleTypeAutomobile, "KA-01-HH-9999", ParkOptions{Permit: true})
+	if err != nil || detail.SpotID != spots[0] {
+		t.Errorf("Expected a permit holder in %s, got %+v, %v", spots[0], detail, err)
+	}
+	if err := lot.RegisterPermit("KA-01-HH-8888"); err != nil {
+		t.Fatalf("Failed to register permit: %v", err)
+	}
+	if detail, err := lot.ParkAt(spots[1], VehicleTypeAutomobile, "ka-01-hh-8888"); err != nil || detail.SpotID != spots[1] {
+		t.Errorf("Expected a registered permit holder in %s, got %+v, %v", spots[1], detail, err)
+	}
+
+	if count := lot.GetAccessibleCount(); count != (AccessibleCount{Spots: 2, Available: 0}) {
+		t.Errorf("Unexpected accessible count %+v", count)
+	}
+
+	if !lot.RevokePermit("KA-01-HH-8888") || lot.HasPermit("KA-01-HH-8888") {
+		t.Error("Expected the permit to be revoked")
+	}
+	if lot.RevokePermit("KA-01-HH-8888") {
+		t.Error("Expected no permit to revoke twice")
+	}
+}
+
+func TestAccessibleSpotsPermitPreference(t *testing.T) {
+	lot, spots := newAccessibleLot(t)
+
+	// Permit holders get accessible spots first, then any other
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-0001"); err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	for i, expected := range []string{spots[0], spots[1], spots[3]} {
+		opts := ParkOptions{Permit: true}
+		detail, err := lot.ParkWithOptions(VehicleTypeAutomobile, fmt.Sprintf("KA-01-PH-%04d", i+1), opts)
+		if err != nil {
+			t.Fatalf("Failed to park: %v", err)
+		}
+		if detail.SpotID != expected {
+			t.Errorf("Expected permit holder %d in %s, got %s", i+1, expected, detail.SpotID)
+		}
+	}
+}
+
+func TestAccessibleSpotsOverflow(t *testing.T) {
+	lot, spots := newAccessibleLot(t, WithAccessibleOverflow())
+	if !lot.AllowsAccessibleOverflow() {
+		t.Fatal("Expected the lot to allow overflow")
+	}
+
+	// Other spots still fill first, then the accessible ones in order
+	for i, expected := range append(spots[2:], spots[0], spots[1]) {
+		number := fmt.Sprintf("KA-01-HH-%04d", i+1)
+		spotID, err := lot.Park(VehicleTypeAutomobile, number)
+		if err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+		if spotID != expected {
+			t.Errorf("Expected %s in %s, got %s", number, expected, spotID)
+		}
+	}
+
+	// Chosen spots still need a permit
+	if err := lot.Unpark(spots[0], "KA-01-HH-0005"); err != nil {
+		t.Fatalf("Failed to unpark: %v", err)
+	}
+	if _, err := lot.ParkAt(spots[0], VehicleTypeAutomobile, "KA-01-HH-9999"); !stderrors.Is(err, errors.ErrPermitRequired) {
+		t.Errorf("Expected a permit required error, got %v", err)
+	}
+}
+
+func TestParkAt(t *testing.T) {
+	lot, spots := newAccessibleLot(t)
+
+	detail, err := lot.ParkAt(spots[4], VehicleTypeAutomobile, "KA-01-HH-0001")
+	if err != nil || detail.SpotID != spots[4] {
+		t.Fatalf("Expected a park in %s, got %+v, %v", spots[4], detail, err)
+	}
+	if spotID, _, _ := lot.SearchVehicle("KA-01-HH-0001"); spotID != spots[4] {
+		t.Errorf("Expected the vehicle to be found in %s, got %s", spots[4], spotID)
+	}
+
+	if _, err := lot.ParkAt(spots[4], VehicleTypeAutomobile, "KA-01-HH-0002"); !stderrors.Is(err, errors.ErrSpotAlreadyOccupied) {
+		t.Errorf("Expected an occupied spot error, got %v", err)
+	}
+	if _, err := lot.ParkAt("9-9-9", VehicleTypeAutomobile, "KA-01-HH-0002"); err == nil {
+		t.Error("Expected an error for a missing spot")
+	}
+	bicycleSpot := lot.ListSpots(SpotFilter{Type: SpotTypeBicycle})[0].SpotID
+	if _, err := lot.ParkAt(bicycleSpot, VehicleTypeAutomobile, "KA-01-HH-0002"); err == nil {
+		t.Error("Expected an error for a vehicle the spot cannot take")
+	}
+
+	if err := lot.CloseFloor(0); err != nil {
+		t.Fatalf("Failed to close floor: %v", err)
+	}
+	if _, err := lot.ParkAt(spots[3], VehicleTypeAutomobile, "KA-01-HH-0002"); err == nil {
+		t.Error("Expected an error for a spot on a closed floor")
+	}
+}
@@ -16,6 +16,7 @@ func BenchmarkParkingOperations(b *testing.B) {
 	}
 
 	b.Run("ParkUnpark", func(b *testing.B) {
+		b.ReportAllocs()
 		// Reset the timer to exclude setup
 		b.ResetTimer()
 
@@ -182,3 +183,179 @@ func BenchmarkCreation(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkAvailableSpots compares listing, limiting and counting available spots.
+// The count and limit paths should not allocate in proportion to the lot size.
+func BenchmarkAvailableSpots(b *testing.B) {
+	for _, size := range []int{100, 400} {
+		lot, _ := CreateParkingLot("AvailableBench", 8, size, size)
+
+		b.Run(fmt.Sprintf("List-%dx%d", size, size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := lot.AvailableSpot(VehicleTypeAutomobile); err != nil {
+					b.Fatalf("Failed to get available spots: %v", err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("Limit10-%dx%d", size, size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := lot.AvailableSpotN(VehicleTypeAutomobile, 10); err != nil {
+					b.Fatalf("Failed to get available spots: %v", err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("Count-%dx%d", size, size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := lot.CountAvailableSpots(VehicleTypeAutomobile); err != nil {
+					b.Fatalf("Failed to count available spots: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkFirstAvailableSpot compares taking the first element of the full
+// list with asking the floor for the first spot, which does not allocate
+func BenchmarkFirstAvailableSpot(b *testing.B) {
+	floor, _ := CreateParkingFloor(0, 400, 400, nil)
+
+	b.Run("ListFirst", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if spots := floor.GetAvailableSpots(VehicleTypeAutomobile); len(spots) == 0 {
+				b.Fatal("Expected an available spot")
+			}
+		}
+	})
+
+	b.Run("FirstAvailableSpot", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if spot := floor.FirstAvailableSpot(VehicleTypeAutomobile); spot == nil {
+				b.Fatal("Expected an available spot")
+			}
+		}
+	})
+}
+
+// BenchmarkStatusCounts benchmarks the counts behind the status command,
+// which read running counters and so should not grow with the floor size
+func BenchmarkStatusCounts(b *testing.B) {
+	sizes := []struct {
+		floors, rows, columns int
+	}{
+		{4, 10, 10},
+		{4, 100, 100},
+		{4, 250, 250},
+	}
+
+	for _, size := range sizes {
+		lot, _ := CreateParkingLot("Benchmark", size.floors, size.rows, size.columns)
+		for i := 0; i < 100; i++ {
+			_, _ = lot.Park(VehicleTypeAutomobile, fmt.Sprintf("BENCH-%04d", i))
+		}
+
+		// The separate getters status used to call, against a single Stats call
+		spots := size.floors * size.rows * size.columns
+		b.Run(fmt.Sprintf("Spots-%d/Getters", spots), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = lot.GetTotalSpotCount()
+				_ = lot.GetActiveSpotCount()
+				_ = lot.GetOccupiedSpotCount()
+				_ = lot.GetAvailableSpotCount()
+				_ = lot.GetSpotCountByType()
+				_ = lot.GetAvailableSpotCountByType()
+				_ = lot.GetAvailableCapacityByType()
+				_ = lot.GetOccupiedCapacityByType()
+				_ = lot.GetFloorSummaries()
+			}
+		})
+		b.Run(fmt.Sprintf("Spots-%d/Stats", spots), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = lot.Stats()
+			}
+		})
+	}
+}
+
+// BenchmarkFindFreeSpot compares finding the next spot by scanning the grid,
+// as Park used to, with taking it from the free spot index, on a large lot
+// filled to different occupancies
+func BenchmarkFindFreeSpot(b *testing.B) {
+	for _, occupancy := range []int{10, 50, 95} {
+		lot, _ := CreateParkingLot("Benchmark", 2, 500, 500)
+		free := lot.GetAvailableSpotCountByType()[VehicleTypeAutomobile]
+		for i := 0; i < free*occupancy/100; i++ {
+			if _, err := lot.Park(VehicleTypeAutomobile, fmt.Sprintf("FILL-%06d", i)); err != nil {
+				b.Fatalf("Failed to fill lot: %v", err)
+			}
+		}
+
+		// Each iteration fills the spot found and frees it again
+		find := map[string]func() *ParkingSpot{
+			"Scan": func() *ParkingSpot {
+				return scanFirstAvailable(lot, VehicleTypeAutomobile)
+			},
+			"Index": func() *ParkingSpot {
+				lot.mu.RLock()
+				defer lot.mu.RUnlock()
+				return lot.firstAvailableSpotLocked(VehicleTypeAutomobile, nil)
+			},
+		}
+		for _, name := range []string{"Scan", "Index"} {
+			b.Run(fmt.Sprintf("Occupancy-%d/%s", occupancy, name), func(b *testing.B) {
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					spot := find[name]()
+					if err := spot.Occupy("BENCH-0001"); err != nil {
+						b.Fatalf("Failed to occupy %s: %v", spot.GetSpotID(), err)
+					}
+					_ = spot.Vacate("BENCH-0001")
+				}
+			})
+		}
+
+		b.Run(fmt.Sprintf("Occupancy-%d/Park", occupancy), func(b *testing.B) {
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				spotID, err := lot.Park(VehicleTypeAutomobile, "BENCH-0001")
+				if err != nil {
+					b.Fatalf("Failed to park: %v", err)
+				}
+				_ = lot.Unpark(spotID, "BENCH-0001")
+			}
+		})
+	}
+}
+
+// BenchmarkPark benchmarks parking alone; picking the spot no longer
+// allocates, leaving the allocations of the vehicle's history and indexes
+func BenchmarkPark(b *testing.B) {
+	lot, _ := CreateParkingLot("Benchmark", 4, 250, 250)
+	numbers := make([]string, 100000)
+	for i := range numbers {
+		numbers[i] = fmt.Sprintf("PARK-%06d", i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Empty the lot before it fills up
+		if i > 0 && i%len(numbers) == 0 {
+			b.StopTimer()
+			lot.Reset()
+			b.StartTimer()
+		}
+
+		if _, err := lot.Park(VehicleTypeAutomobile, numbers[i%len(numbers)]); err != nil {
+			b.Fatalf("Failed to park vehicle: %v", err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time; the lot and vehicle histories read it through
+// a Clock so tests can control it
+type Clock interface {
+	Now() time.Time
+}
+
+// RealClock is the Clock backed by the system time
+type RealClock struct{}
+
+// Now returns the current system time in UTC, which the lot records every
+// time in
+func (RealClock) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// FakeClock is a Clock that only moves when told to
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock creates a fake clock stopped at the given time
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the time the clock is stopped at
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set stops the clock at the given time
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	if !clock.Now().Equal(start) {
+		t.Errorf("Expected %v, got %v", start, clock.Now())
+	}
+
+	clock.Advance(90 * time.Minute)
+	if got := clock.Now().Sub(start); got != 90*time.Minute {
+		t.Errorf("Expected clock advanced by 90m, got %v", got)
+	}
+
+	clock.Set(start)
+	if !clock.Now().Equal(start) {
+		t.Errorf("Expected clock set back to %v, got %v", start, clock.Now())
+	}
+}
+
+func TestParkingLotClock(t *testing.T) {
+	lot, err := CreateParkingLot("Clock Lot", 1, 2, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	if _, ok := lot.GetClock().(RealClock); !ok {
+		t.Errorf("Expected the real clock by default, got %T", lot.GetClock())
+	}
+
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	lot.SetClock(clock)
+
+	spotID, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-1234")
+	if err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+
+	clock.Advance(2*time.Hour + 30*time.Minute)
+	if err := lot.Unpark(spotID, "KA-01-HH-1234"); err != nil {
+		t.Fatalf("Failed to unpark: %v", err)
+	}
+
+	// Every timestamp the lot records comes from its clock
+	records, _ := lot.GetVehicleRecords("KA-01-HH-1234")
+	if len(records) != 1 || !records[0].ParkedAt.Equal(start) || records[0].Duration() != 150*time.Minute {
+		t.Errorf("Expected one 2h30m record from 09:00, got %+v", records)
+	}
+
+	occupancies, _ := lot.GetSpotHistory(spotID, 0)
+	if len(occupancies) != 1 || !occupancies[0].VacatedAt.Equal(start.Add(150*time.Minute)) {
+		t.Errorf("Expected the spot vacated at 11:30, got %+v", occupancies)
+	}
+
+	stats := lot.GetParkingStats()
+	if stats.AverageDuration != 150*time.Minute {
+		t.Errorf("Expected 2h30m average, got %v", stats.AverageDuration)
+	}
+
+	// A vehicle still parked lasts until the clock's now
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-02-HH-1234"); err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	clock.Advance(time.Hour)
+	entries := lot.QueryHistory(start.Add(3*time.Hour+30*time.Minute), start.Add(4*time.Hour))
+	if len(entries) != 1 || entries[0].VehicleNumber != "KA-02-HH-1234" {
+		t.Errorf("Expected only KA-02-HH-1234 parked at 12:30, got %+v", entries)
+	}
+	entries = lot.QueryHistory(start.Add(4*time.Hour), start.Add(5*time.Hour))
+	if len(entries) != 0 {
+		t.Errorf("Expected nothing parked after the clock's now, got %+v", entries)
+	}
+
+	// Setting nil goes back to the system time
+	lot.SetClock(nil)
+	if _, ok := lot.GetClock().(RealClock); !ok {
+		t.Errorf("Expected the real clock after SetClock(nil), got %T", lot.GetClock())
+	}
+}
@@ -0,0 +1,73 @@
+package model
+
+// CloneLayout creates an empty lot called name with the same floors as this
+// one: floor numbers, dimensions, spot types including inactive spots, rack
+// capacities, labels and tags. The clone takes the options the lot was
+// created with and its entrance, but no vehicles, history, permits, quotas,
+// metadata or floor closures, and shares no mutable state with the original.
+func (p *ParkingLot) CloneLayout(name string) (*ParkingLot, error) {
+	p.mu.RLock()
+	floors := make([]*ParkingFloor, len(p.floors))
+	for i, floor := range p.floors {
+		floors[i] = floor.cloneLayout()
+	}
+	entrance := p.entrance
+	opts := []LotOption{
+		WithLogger(p.logger),
+		WithVehicleNumberValidator(p.numberValidator),
+		WithSpotIDFormatter(p.spotIDs),
+	}
+	if p.ignoreSeparators {
+		opts = append(opts, WithSeparatorInsensitiveMatching())
+	}
+	if p.accessibleOverflow {
+		opts = append(opts, WithAccessibleOverflow())
+	}
+	p.mu.RUnlock()
+
+	lot, err := NewParkingLot(name, floors, opts...)
+	if err != nil {
+		return nil, err
+	}
+	lot.entrance = entrance
+	return lot, nil
+}
+
+// cloneLayout returns an empty floor with the same spots as this one
+func (f *ParkingFloor) cloneLayout() *ParkingFloor {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	// Spots not made yet stay so in the copy
+	clone := newParkingFloor(f.FloorNumber, f.numRows, f.numColumns)
+	for r := range f.spots {
+		copy(clone.types[r], f.types[r])
+		for c := range f.spots[r] {
+			if spot := f.spots[r][c].Load(); spot != nil {
+				copied := spot.cloneLayout()
+				clone.spots[r][c].Store(copied)
+				copied.attach(clone.counters)
+			}
+		}
+	}
+	clone.countUnmadeSpotsLocked()
+
+	return clone
+}
+
+// cloneLayout returns an empty spot of the same type, capacity, label and
+// tags as this one
+func (s *ParkingSpot) cloneLayout() *ParkingSpot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return &ParkingSpot{
+		Type:     s.Type,
+		Floor:    s.Floor,
+		Row:      s.Row,
+		Column:   s.Column,
+		capacity: s.capacity,
+		label:    s.label,
+		tags:     append([]string(nil), s.tags...),
+	}
+}
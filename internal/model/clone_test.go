@@ -0,0 +1,76 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCloneLayout(t *testing.T) {
+	A, B, X := SpotTypeAutomobile, SpotTypeBicycle, SpotTypeInactive
+	lot, err := CreateParkingLotWithFloors("Production", -1, []FloorSpec{
+		{Rows: 1, Columns: 3, Layout: [][]SpotType{{A, X, B}}},
+		{Rows: 2, Columns: 2, Layout: [][]SpotType{{A, A}, {B, X}}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	if err := lot.SetSpotLabel("B1-0-0", "Gate A"); err != nil {
+		t.Fatalf("Failed to label spot: %v", err)
+	}
+	if err := lot.TagSpot("0-0-1", "ev"); err != nil {
+		t.Fatalf("Failed to tag spot: %v", err)
+	}
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-1234"); err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+
+	clone, err := lot.CloneLayout("Scratch")
+	if err != nil {
+		t.Fatalf("Failed to clone lot: %v", err)
+	}
+	if clone.GetName() != "Scratch" || clone.GetNumFloors() != 2 {
+		t.Errorf("Expected a two-floor lot called Scratch, got %s", clone.String())
+	}
+	if !reflect.DeepEqual(clone.GetSpotCountByType(), lot.GetSpotCountByType()) {
+		t.Errorf("Expected the clone to count %v by type, got %v", lot.GetSpotCountByType(), clone.GetSpotCountByType())
+	}
+	if row := rowState(t, clone, -1, 0); row != "[A X B]" {
+		t.Errorf("Expected the clone's basement empty with its inactive spot, got %s", row)
+	}
+	if clone.GetOccupiedSpotCount() != 0 || clone.IsVehicleParked("KA-01-HH-1234") {
+		t.Error("Expected the clone to hold no vehicles")
+	}
+	if spot, err := clone.FindSpotByLabel("Gate A"); err != nil || spot.GetSpotID() != "B1-0-0" {
+		t.Errorf("Expected the clone to keep the label, got %v (%v)", spot, err)
+	}
+
+	// Changing either lot leaves the other as it was
+	if err := clone.SetSpotType("0-1-1", SpotTypeAutomobile); err != nil {
+		t.Fatalf("Failed to change a spot of the clone: %v", err)
+	}
+	if err := clone.TagSpot("0-0-0", "covered"); err != nil {
+		t.Fatalf("Failed to tag a spot of the clone: %v", err)
+	}
+	if _, err := clone.ParkAt("0-0-1", VehicleTypeAutomobile, "KA-02-HH-5678"); err != nil {
+		t.Fatalf("Failed to park in the clone: %v", err)
+	}
+	if err := lot.SetSpotLabel("B1-0-0", ""); err != nil {
+		t.Fatalf("Failed to unlabel spot: %v", err)
+	}
+	if row := rowState(t, lot, 0, 1); row != "[B X]" {
+		t.Errorf("Expected the original's spot type unchanged, got %s", row)
+	}
+	if lot.IsVehicleParked("KA-02-HH-5678") || lot.GetOccupiedSpotCount() != 1 {
+		t.Error("Expected a park in the clone not to reach the original")
+	}
+	if got := fmt.Sprint(lot.TagCounts()); strings.Contains(got, "covered") {
+		t.Errorf("Expected a tag added to the clone not to reach the original, got %s", got)
+	}
+	if _, err := clone.FindSpotByLabel("Gate A"); err != nil {
+		t.Errorf("Expected the clone to keep its label: %v", err)
+	}
+	checkVehicleIndexes(t, lot)
+	checkVehicleIndexes(t, clone)
+}
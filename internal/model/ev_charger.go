@@ -0,0 +1,129 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// EVChargerTag marks a spot with an EV charger
+const EVChargerTag = "ev"
+
+// ChargerState is the state of a spot's EV charger
+type ChargerState string
+
+const (
+	// ChargerAvailable is a working charger not in use
+	ChargerAvailable ChargerState = "available"
+
+	// ChargerCharging is a working charger charging a vehicle
+	ChargerCharging ChargerState = "charging"
+
+	// ChargerOutOfOrder is a broken charger; its spot can still be parked in
+	ChargerOutOfOrder ChargerState = "out_of_order"
+)
+
+// ChargerStates lists the charger states in display order
+var ChargerStates = []ChargerState{ChargerAvailable, ChargerCharging, ChargerOutOfOrder}
+
+// ParseChargerState converts a string to a charger state, ignoring case and
+// accepting hyphens for underscores
+func ParseChargerState(s string) (ChargerState, error) {
+	normalized := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "-", "_")
+	for _, state := range ChargerStates {
+		if normalized == string(state) {
+			return state, nil
+		}
+	}
+
+	return "", errors.NewValidationError("state", s,
+		"charger state must be available, charging or out_of_order")
+}
+
+// GetChargerState returns the state of the spot's EV charger, and false
+// when the spot has no charger
+func (s *ParkingSpot) GetChargerState() (ChargerState, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !containsString(s.tags, EVChargerTag) {
+		return "", false
+	}
+	if s.charger == "" {
+		return ChargerAvailable, true
+	}
+	return s.charger, true
+}
+
+// hasWorkingCharger reports whether the spot has an EV charger that is not
+// out of order
+func (s *ParkingSpot) hasWorkingCharger() bool {
+	state, found := s.GetChargerState()
+	return found && state != ChargerOutOfOrder
+}
+
+// setChargerState records the state of the spot's charger
+func (s *ParkingSpot) setChargerState(state ChargerState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.charger = state
+}
+
+// SetChargerState records the state of the EV charger of the spot with the
+// given ID, which must be tagged ev
+func (p *ParkingLot) SetChargerState(spotID string, state ChargerState) error {
+	if _, err := ParseChargerState(string(state)); err != nil {
+		return err
+	}
+
+	spot, err := p.GetSpotByID(spotID)
+	if err != nil {
+		return err
+	}
+
+	if _, found := spot.GetChargerState(); !found {
+		return errors.NewInvalidOperationError("setChargerState",
+			fmt.Sprintf("spot %s has no EV charger, tag it %s first", spot.GetSpotID(), EVChargerTag))
+	}
+
+	spot.setChargerState(state)
+	return nil
+}
+
+// ParkEV parks an electric vehicle like ParkDetailed, in the first free spot
+// with a working charger. Without one it takes any free spot, and the
+// result's Charger field is false.
+func (p *ParkingLot) ParkEV(vehicleType VehicleType, vehicleNumber string) (*ParkResultDetail, error) {
+	return p.ParkWithOptions(vehicleType, vehicleNumber, ParkOptions{PreferCharger: true})
+}
+
+// ChargerSummary counts the spots with an EV charger and their chargers by state
+type ChargerSummary struct {
+	Spots  int
+	States map[ChargerState]int
+}
+
+// GetChargerSummary counts the lot's EV chargers by state
+func (p *ParkingLot) GetChargerSummary() ChargerSummary {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	summary := ChargerSummary{States: make(map[ChargerState]int, len(ChargerStates))}
+	for _, state := range ChargerStates {
+		summary.States[state] = 0
+	}
+
+	for _, floor := range p.floors {
+		floor.forEachMadeSpot(func(spot *ParkingSpot) bool {
+			if state, found := spot.GetChargerState(); found {
+				summary.Spots++
+				summary.States[state]++
+			}
+			return true
+		})
+	}
+
+	return summary
+}
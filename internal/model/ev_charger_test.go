@@ -0,0 +1,118 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParkEVPreference(t *testing.T) {
+	lot, err := CreateParkingLot("EV Lot", 2, 4, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	var automobileSpots []string
+	for _, info := range lot.ListSpots(SpotFilter{Type: SpotTypeAutomobile}) {
+		automobileSpots = append(automobileSpots, info.SpotID)
+	}
+
+	// Chargers deep in the lot: one broken, then two working
+	broken, first, second := automobileSpots[2], automobileSpots[4], automobileSpots[len(automobileSpots)-1]
+	for _, spotID := range []string{broken, first, second} {
+		if err := lot.TagSpot(spotID, EVChargerTag); err != nil {
+			t.Fatalf("Failed to tag %s: %v", spotID, err)
+		}
+	}
+	if err := lot.SetChargerState(broken, ChargerOutOfOrder); err != nil {
+		t.Fatalf("Failed to set charger state: %v", err)
+	}
+
+	// Working chargers are taken in order, ahead of earlier plain spots and
+	// the broken charger
+	for i, expected := range []string{first, second} {
+		detail, err := lot.ParkEV(VehicleTypeAutomobile, fmt.Sprintf("KA-01-EV-%04d", i+1))
+		if err != nil {
+			t.Fatalf("Failed to park: %v", err)
+		}
+		if detail.SpotID != expected || !detail.Charger {
+			t.Errorf("Expected a charging spot %s, got %s (charger %v)", expected, detail.SpotID, detail.Charger)
+		}
+	}
+
+	// With no working charger free an EV gets the first spot, without a charger
+	detail, err := lot.ParkEV(VehicleTypeAutomobile, "KA-01-EV-0003")
+	if err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	if detail.SpotID != automobileSpots[0] || detail.Charger {
+		t.Errorf("Expected the plain spot %s, got %s (charger %v)", automobileSpots[0], detail.SpotID, detail.Charger)
+	}
+
+	// Broken chargers are not preferred but can still be parked in
+	if err := lot.SetChargerState(first, ChargerCharging); err != nil {
+		t.Fatalf("Failed to set charger state: %v", err)
+	}
+	for _, number := range []string{"KA-01-HH-0004", "KA-01-HH-0005"} {
+		if _, err := lot.Park(VehicleTypeAutomobile, number); err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+	}
+	if spotID, _, _ := lot.SearchVehicle("KA-01-HH-0005"); spotID != broken {
+		t.Errorf("Expected an ordinary park in the broken charger spot %s, got %s", broken, spotID)
+	}
+
+	summary := lot.GetChargerSummary()
+	if summary.Spots != 3 || summary.States[ChargerAvailable] != 1 ||
+		summary.States[ChargerCharging] != 1 || summary.States[ChargerOutOfOrder] != 1 {
+		t.Errorf("Unexpected charger summary %+v", summary)
+	}
+}
+
+func TestChargerState(t *testing.T) {
+	lot, err := CreateParkingLot("EV Lot", 1, 2, 3)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	if err := lot.SetChargerState("0-0-0", ChargerCharging); err == nil {
+		t.Error("Expected an error for a spot without a charger")
+	}
+
+	if err := lot.TagSpot("0-0-0", "EV"); err != nil {
+		t.Fatalf("Failed to tag spot: %v", err)
+	}
+	spot, _ := lot.GetSpotByID("0-0-0")
+	if state, found := spot.GetChargerState(); !found || state != ChargerAvailable {
+		t.Errorf("Expected a new charger to be available, got %q, %v", state, found)
+	}
+
+	if err := lot.SetChargerState("0-0-0", ChargerOutOfOrder); err != nil {
+		t.Fatalf("Failed to set charger state: %v", err)
+	}
+	if err := lot.SetChargerState("0-0-0", "broken"); err == nil {
+		t.Error("Expected an error for an unknown state")
+	}
+
+	// Removing the charger forgets its state
+	if err := lot.UntagSpot("0-0-0", EVChargerTag); err != nil {
+		t.Fatalf("Failed to untag spot: %v", err)
+	}
+	if _, found := spot.GetChargerState(); found {
+		t.Error("Expected the spot to have no charger")
+	}
+	if err := lot.TagSpot("0-0-0", EVChargerTag); err != nil {
+		t.Fatalf("Failed to tag spot: %v", err)
+	}
+	if state, _ := spot.GetChargerState(); state != ChargerAvailable {
+		t.Errorf("Expected a re-added charger to be available, got %q", state)
+	}
+
+	for input, expected := range map[string]ChargerState{
+		"available": ChargerAvailable, "CHARGING": ChargerCharging,
+		"out-of-order": ChargerOutOfOrder, " out_of_order ": ChargerOutOfOrder,
+	} {
+		if state, err := ParseChargerState(input); err != nil || state != expected {
+			t.Errorf("ParseChargerState(%q): expected %q, got %q, %v", input, expected, state, err)
+		}
+	}
+}
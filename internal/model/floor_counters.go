@@ -0,0 +1,206 @@
+package model
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// spotState is what a single spot contributes to its floor's counters
+type spotState struct {
+	spotType SpotType
+	vehicles int
+	capacity int
+}
+
+// floorCounters keeps running spot counts of a floor per spot type and an
+// index of its free spots, so neither counting nor picking a spot has to
+// scan the grid. Spots update them on every change. Counts are indexed by
+// the position of the spot type in the type registry.
+type floorCounters struct {
+	// Spots of each type
+	spots [maxSpotTypes]atomic.Int64
+	// Spots holding at least one vehicle
+	occupied [maxSpotTypes]atomic.Int64
+	// Active spots with room for another vehicle
+	free [maxSpotTypes]atomic.Int64
+	// Vehicles parked
+	vehicles [maxSpotTypes]atomic.Int64
+	// Vehicles the active spots can hold
+	capacity [maxSpotTypes]atomic.Int64
+
+	// Which spots have room for another vehicle
+	freeSpots *freeSpotIndex
+}
+
+// newFloorCounters returns zero counters for a floor of the given size
+func newFloorCounters(rows, columns int) *floorCounters {
+	return &floorCounters{freeSpots: newFreeSpotIndex(rows, columns)}
+}
+
+// apply adds (sign 1) or removes (sign -1) a spot's contribution
+func (c *floorCounters) apply(state spotState, sign int64) {
+	i := spotTypeIndex(state.spotType)
+	c.spots[i].Add(sign)
+	if state.vehicles > 0 {
+		c.occupied[i].Add(sign)
+	}
+	c.vehicles[i].Add(sign * int64(state.vehicles))
+
+	if state.spotType.IsActive() {
+		c.capacity[i].Add(sign * int64(state.capacity))
+		if state.vehicles < state.capacity {
+			c.free[i].Add(sign)
+		}
+	}
+}
+
+// applySnapshot adds the counts of a snapshot, as applying each spot it
+// counts would
+func (c *floorCounters) applySnapshot(s counterSnapshot) {
+	for i := range c.spots {
+		c.spots[i].Add(int64(s.spots[i]))
+		c.occupied[i].Add(int64(s.occupied[i]))
+		c.free[i].Add(int64(s.free[i]))
+		c.vehicles[i].Add(int64(s.vehicles[i]))
+		c.capacity[i].Add(int64(s.capacity[i]))
+	}
+}
+
+// snapshot reads the counters into a plain value
+func (c *floorCounters) snapshot() counterSnapshot {
+	var s counterSnapshot
+	for i := range c.spots {
+		s.spots[i] = int(c.spots[i].Load())
+		s.occupied[i] = int(c.occupied[i].Load())
+		s.free[i] = int(c.free[i].Load())
+		s.vehicles[i] = int(c.vehicles[i].Load())
+		s.capacity[i] = int(c.capacity[i].Load())
+	}
+	return s
+}
+
+// counterSnapshot holds the values of floorCounters at one moment
+type counterSnapshot struct {
+	spots    [maxSpotTypes]int
+	occupied [maxSpotTypes]int
+	free     [maxSpotTypes]int
+	vehicles [maxSpotTypes]int
+	capacity [maxSpotTypes]int
+}
+
+// add counts a spot's state into the snapshot, as apply does for the counters
+func (s *counterSnapshot) add(state spotState) {
+	i := spotTypeIndex(state.spotType)
+	s.spots[i]++
+	if state.vehicles > 0 {
+		s.occupied[i]++
+	}
+	s.vehicles[i] += state.vehicles
+
+	if state.spotType.IsActive() {
+		s.capacity[i] += state.capacity
+		if state.vehicles < state.capacity {
+			s.free[i]++
+		}
+	}
+}
+
+// spotCounts returns the total, active and occupied spots; available spots
+// are left to the caller, which knows whether the floor is closed
+func (s counterSnapshot) spotCounts() spotCounts {
+	var counts spotCounts
+	for i, spotType := range registeredSpotTypeList() {
+		counts.total += s.spots[i]
+		counts.occupied += s.occupied[i]
+		if spotType.IsActive() {
+			counts.active += s.spots[i]
+		}
+	}
+	return counts
+}
+
+// room returns how many more vehicles the spots of each type can hold
+func (s counterSnapshot) room() [maxSpotTypes]int {
+	var room [maxSpotTypes]int
+	for i := range room {
+		room[i] = s.capacity[i] - s.vehicles[i]
+	}
+	return room
+}
+
+// byVehicleType sums a per spot type count over the spot types each
+// single-spot vehicle type can park in
+func byVehicleType(perSpotType [maxSpotTypes]int) map[VehicleType]int {
+	counts := make(map[VehicleType]int)
+	for _, vehicleType := range spotVehicleTypes() {
+		counts[vehicleType] = 0
+		for i, spotType := range registeredSpotTypeList() {
+			if spotType.CanParkVehicleType(vehicleType) {
+				counts[vehicleType] += perSpotType[i]
+			}
+		}
+	}
+	return counts
+}
+
+// scanCountersLocked recounts the floor by visiting every spot; f.mu must be held
+func (f *ParkingFloor) scanCountersLocked() counterSnapshot {
+	var s counterSnapshot
+	var blank ParkingSpot
+	for r := 0; r < f.numRows; r++ {
+		for c := 0; c < f.numColumns; c++ {
+			s.add(f.peekSpotLocked(r, c, &blank).state())
+		}
+	}
+	return s
+}
+
+// validateCounters checks the running counters against a full scan
+func (f *ParkingFloor) validateCounters() error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	scanned := f.scanCountersLocked()
+	counted := f.counters.snapshot()
+	if scanned != counted {
+		return errors.NewInvalidOperationError("validateCounters",
+			fmt.Sprintf("floor %d counters %+v do not match a full scan %+v",
+				f.FloorNumber, counted, scanned))
+	}
+
+	// Every spot with room is listed under its own type, and no other spot is
+	var blank ParkingSpot
+	for r := 0; r < f.numRows; r++ {
+		for c := 0; c < f.numColumns; c++ {
+			spot := f.peekSpotLocked(r, c, &blank)
+			state := spot.state()
+			var expected []SpotType
+			if state.spotType.IsActive() && state.vehicles < state.capacity {
+				expected = []SpotType{state.spotType}
+			}
+
+			listed := f.counters.freeSpots.listedTypes(r, c)
+			if fmt.Sprint(listed) != fmt.Sprint(expected) {
+				return errors.NewInvalidOperationError("validateCounters",
+					fmt.Sprintf("spot %s is listed as free for %v, expected %v",
+						spot.GetSpotID(), listed, expected))
+			}
+		}
+	}
+	return nil
+}
+
+// validateCounters checks the running counters of every floor against a full scan
+func (p *ParkingLot) validateCounters() error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, floor := range p.floors {
+		if err := floor.validateCounters(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
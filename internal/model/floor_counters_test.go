@@ -0,0 +1,110 @@
+package model
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestFloorCountersFollowChanges(t *testing.T) {
+	lot, err := CreateParkingLot("Counters Lot", 2, 4, 6)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	check := func(step string) {
+		t.Helper()
+		if err := lot.validateCounters(); err != nil {
+			t.Fatalf("After %s: %v", step, err)
+		}
+	}
+	check("creation")
+
+	spots := make(map[string]string)
+	for i, vehicleType := range []VehicleType{VehicleTypeBicycle, VehicleTypeMotorcycle, VehicleTypeAutomobile, VehicleTypeAutomobile} {
+		number := fmt.Sprintf("CT-%02d", i)
+		spotID, err := lot.Park(vehicleType, number)
+		if err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+		spots[number] = spotID
+	}
+	check("parking")
+
+	if err := lot.Unpark(spots["CT-02"], "CT-02"); err != nil {
+		t.Fatalf("Failed to unpark: %v", err)
+	}
+	check("unparking")
+
+	// Racks, type changes and closing all move the counters
+	if err := lot.SetSpotCapacity(spots["CT-00"], 3); err != nil {
+		t.Fatalf("Failed to set capacity: %v", err)
+	}
+	if _, err := lot.Park(VehicleTypeBicycle, "CT-10"); err != nil {
+		t.Fatalf("Failed to park in rack: %v", err)
+	}
+	check("filling a rack")
+
+	if err := lot.SetSpotType(spots["CT-02"], SpotTypeInactive); err != nil {
+		t.Fatalf("Failed to deactivate spot: %v", err)
+	}
+	check("deactivating a spot")
+
+	if err := lot.CloseFloor(1); err != nil {
+		t.Fatalf("Failed to close floor: %v", err)
+	}
+	check("closing a floor")
+	if summary := lot.GetFloorSummaries()[1]; summary.AvailableSpots != 0 || summary.AvailableByType[VehicleTypeAutomobile] != 0 {
+		t.Errorf("Expected a closed floor to have no available spots, got %+v", summary)
+	}
+
+	// Growing and shrinking attaches and detaches spots
+	if err := lot.ExpandFloor(1, 6, 8, SpotTypeMotorcycle); err != nil {
+		t.Fatalf("Failed to grow floor: %v", err)
+	}
+	check("growing a floor")
+	if err := lot.ExpandFloor(1, 2, 3, SpotTypeMotorcycle); err != nil {
+		t.Fatalf("Failed to shrink floor: %v", err)
+	}
+	check("shrinking a floor")
+
+	if err := lot.AddFloor(3, 3, nil); err != nil {
+		t.Fatalf("Failed to add floor: %v", err)
+	}
+	check("adding a floor")
+
+	lot.Reset()
+	check("reset")
+	if count := lot.GetOccupiedSpotCount(); count != 0 {
+		t.Errorf("Expected no occupied spots after reset, got %d", count)
+	}
+}
+
+func TestFloorCountersConcurrent(t *testing.T) {
+	lot, err := CreateParkingLot("Concurrent Counters Lot", 2, 10, 10)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			vehicleTypes := []VehicleType{VehicleTypeBicycle, VehicleTypeMotorcycle, VehicleTypeAutomobile}
+			for i := 0; i < 100; i++ {
+				number := fmt.Sprintf("CC-%02d-%03d", w, i)
+				spotID, err := lot.Park(vehicleTypes[(w+i)%3], number)
+				if err == nil && i%2 == 0 {
+					_ = lot.Unpark(spotID, number)
+				}
+				_ = lot.GetAvailableSpotCountByType()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if err := lot.validateCounters(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,222 @@
+package model
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// freeBitset is a set of spot positions with a summary word per 64 words,
+// so the lowest position at or after any point is found in a few steps
+// even when most of a large floor is full
+type freeBitset struct {
+	words []uint64
+	// Bit i is set when words[i] is not zero
+	summary []uint64
+}
+
+// newFreeBitset returns an empty set able to hold positions below size
+func newFreeBitset(size int) freeBitset {
+	numWords := (size + 63) / 64
+	return freeBitset{
+		words:   make([]uint64, numWords),
+		summary: make([]uint64, (numWords+63)/64),
+	}
+}
+
+// set adds a position to the set
+func (b *freeBitset) set(pos int) {
+	w := pos / 64
+	b.words[w] |= 1 << (pos % 64)
+	b.summary[w/64] |= 1 << (w % 64)
+}
+
+// clear removes a position from the set, which may not be made yet
+func (b *freeBitset) clear(pos int) {
+	w := pos / 64
+	if w >= len(b.words) {
+		return
+	}
+	b.words[w] &^= 1 << (pos % 64)
+	if b.words[w] == 0 {
+		b.summary[w/64] &^= 1 << (w % 64)
+	}
+}
+
+// next returns the lowest position in the set at or after from, or -1
+func (b *freeBitset) next(from int) int {
+	w := from / 64
+	if w >= len(b.words) {
+		return -1
+	}
+
+	if word := b.words[w] & (^uint64(0) << (from % 64)); word != 0 {
+		return w*64 + bits.TrailingZeros64(word)
+	}
+
+	// Find the next non-empty word through the summary
+	w++
+	for s := w / 64; s < len(b.summary); s++ {
+		summary := b.summary[s]
+		if s == w/64 {
+			summary &= ^uint64(0) << (w % 64)
+		}
+		if summary != 0 {
+			w = s*64 + bits.TrailingZeros64(summary)
+			return w*64 + bits.TrailingZeros64(b.words[w])
+		}
+	}
+
+	return -1
+}
+
+// freeSpotIndex records which spots of a floor have room for another
+// vehicle, one set per spot type with positions in row-by-row order.
+// Spots update it with every change, under their own lock. Sets are indexed
+// like floorCounters, and made for a spot type when it first has a free spot.
+type freeSpotIndex struct {
+	mu      sync.Mutex
+	rows    int
+	columns int
+	sets    [maxSpotTypes]freeBitset
+}
+
+// newFreeSpotIndex returns an empty index for a floor of the given size
+func newFreeSpotIndex(rows, columns int) *freeSpotIndex {
+	return &freeSpotIndex{rows: rows, columns: columns}
+}
+
+// update records whether the spot at row and column has room for another
+// vehicle; spots outside the floor are ignored
+func (x *freeSpotIndex) update(row, column int, state spotState) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if row >= x.rows || column >= x.columns {
+		return
+	}
+
+	pos := row*x.columns + column
+	free := state.spotType.IsActive() && state.vehicles < state.capacity
+	listed := spotTypeIndex(state.spotType)
+	for i := range x.sets {
+		if free && i == listed {
+			if x.sets[i].words == nil {
+				x.sets[i] = newFreeBitset(x.rows * x.columns)
+			}
+			x.sets[i].set(pos)
+		} else {
+			x.sets[i].clear(pos)
+		}
+	}
+}
+
+// resize changes the floor size, keeping the free spots that are still on it
+func (x *freeSpotIndex) resize(rows, columns int) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for i := range x.sets {
+		if x.sets[i].words == nil {
+			continue
+		}
+		resized := newFreeBitset(rows * columns)
+		for pos := x.sets[i].next(0); pos >= 0; pos = x.sets[i].next(pos + 1) {
+			r, c := pos/x.columns, pos%x.columns
+			if r < rows && c < columns {
+				resized.set(r*columns + c)
+			}
+		}
+		x.sets[i] = resized
+	}
+	x.rows = rows
+	x.columns = columns
+}
+
+// first returns the row and column of the first free spot for the vehicle
+// type in row-by-row order, without allocating
+func (x *freeSpotIndex) first(vehicleType VehicleType) (int, int, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	lowest := -1
+	for i, spotType := range registeredSpotTypeList() {
+		if !spotType.CanParkVehicleType(vehicleType) {
+			continue
+		}
+		if pos := x.sets[i].next(0); pos >= 0 && (lowest < 0 || pos < lowest) {
+			lowest = pos
+		}
+	}
+
+	if lowest < 0 {
+		return 0, 0, false
+	}
+	return lowest / x.columns, lowest % x.columns, true
+}
+
+// find returns the row and column of at most n free spots for the vehicle
+// type in row-by-row order, or of all of them when n is negative
+func (x *freeSpotIndex) find(vehicleType VehicleType, n int) [][2]int {
+	var found [][2]int
+	if n == 0 {
+		return found
+	}
+
+	x.each(vehicleType, func(row, column int) bool {
+		found = append(found, [2]int{row, column})
+		return n < 0 || len(found) < n
+	})
+	return found
+}
+
+// each calls fn with the row and column of every free spot for the vehicle
+// type in row-by-row order until fn returns false. The index stays locked
+// throughout, so fn must not change the index.
+func (x *freeSpotIndex) each(vehicleType VehicleType, fn func(row, column int) bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	// Merge the sets of every spot type the vehicle fits
+	var sets []*freeBitset
+	var cursors []int
+	for i, spotType := range registeredSpotTypeList() {
+		if spotType.CanParkVehicleType(vehicleType) {
+			sets = append(sets, &x.sets[i])
+			cursors = append(cursors, x.sets[i].next(0))
+		}
+	}
+
+	for {
+		lowest := -1
+		for i, pos := range cursors {
+			if pos >= 0 && (lowest < 0 || pos < cursors[lowest]) {
+				lowest = i
+			}
+		}
+		if lowest < 0 {
+			break
+		}
+
+		pos := cursors[lowest]
+		if !fn(pos/x.columns, pos%x.columns) {
+			return
+		}
+		cursors[lowest] = sets[lowest].next(pos + 1)
+	}
+}
+
+// listedTypes returns the spot types under which the index lists the spot
+// at row and column as free; a consistent index lists at most one
+func (x *freeSpotIndex) listedTypes(row, column int) []SpotType {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	pos := row*x.columns + column
+	var listed []SpotType
+	for i, spotType := range registeredSpotTypeList() {
+		if x.sets[i].next(pos) == pos {
+			listed = append(listed, spotType)
+		}
+	}
+	return listed
+}
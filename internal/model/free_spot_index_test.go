@@ -0,0 +1,205 @@
+package model
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// scanFirstAvailable finds the spot Park would assign by visiting every spot,
+// as Park did before the free spot index
+func scanFirstAvailable(lot *ParkingLot, vehicleType VehicleType) *ParkingSpot {
+	for _, floor := range lot.GetFloors() {
+		if floor.IsClosed() {
+			continue
+		}
+
+		rows, columns := floor.GetDimensions()
+		for r := 0; r < rows; r++ {
+			for c := 0; c < columns; c++ {
+				spot, _ := floor.GetSpot(r, c)
+				if spot.CanPark(vehicleType) {
+					return spot
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func TestFreeBitsetNext(t *testing.T) {
+	set := newFreeBitset(10000)
+	if pos := set.next(0); pos != -1 {
+		t.Errorf("Expected an empty set, got %d", pos)
+	}
+
+	// Positions on word and summary boundaries
+	positions := []int{0, 63, 64, 4095, 4096, 9999}
+	for _, pos := range positions {
+		set.set(pos)
+	}
+
+	tests := []struct {
+		from     int
+		expected int
+	}{
+		{0, 0},
+		{1, 63},
+		{64, 64},
+		{65, 4095},
+		{4096, 4096},
+		{4097, 9999},
+		{10000, -1},
+	}
+	for _, tc := range tests {
+		if pos := set.next(tc.from); pos != tc.expected {
+			t.Errorf("next(%d): expected %d, got %d", tc.from, tc.expected, pos)
+		}
+	}
+
+	// Emptying a word skips it through the summary
+	set.clear(4095)
+	set.clear(4096)
+	if pos := set.next(65); pos != 9999 {
+		t.Errorf("Expected 9999 after clearing, got %d", pos)
+	}
+	set.clear(9999)
+	if pos := set.next(65); pos != -1 {
+		t.Errorf("Expected no position after 64, got %d", pos)
+	}
+}
+
+func TestFreeSpotIndexFollowsOperations(t *testing.T) {
+	lot, err := CreateParkingLot("Index Lot", 2, 8, 12)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	check := func(step int, what string) {
+		t.Helper()
+		if err := lot.validateCounters(); err != nil {
+			t.Fatalf("Step %d (%s): %v", step, what, err)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1578))
+	vehicleTypes := []VehicleType{VehicleTypeBicycle, VehicleTypeMotorcycle, VehicleTypeAutomobile}
+	parked := make(map[string]string)
+	var numbers []string
+
+	for step := 0; step < 2000; step++ {
+		switch op := rng.Intn(10); {
+		case op < 6:
+			vehicleType := vehicleTypes[rng.Intn(len(vehicleTypes))]
+			number := fmt.Sprintf("IX-%04d", step)
+			expected := scanFirstAvailable(lot, vehicleType)
+
+			spotID, err := lot.Park(vehicleType, number)
+			if expected == nil {
+				if _, ok := err.(*errors.NoSpaceError); !ok {
+					t.Fatalf("Step %d: expected no space for %s, got %q, %v", step, vehicleType, spotID, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("Step %d: failed to park %s: %v", step, number, err)
+			}
+
+			// The index hands out exactly the spot a full scan would
+			if spotID != expected.GetSpotID() {
+				t.Fatalf("Step %d: expected %s at %s, got %s", step, number, expected.GetSpotID(), spotID)
+			}
+			spot, _ := lot.GetSpotByID(spotID)
+			if !spot.IsActive() || !spot.Type.CanParkVehicleType(vehicleType) ||
+				spot.GetOccupiedCapacity() > spot.GetCapacity() {
+				t.Fatalf("Step %d: %s handed out %s", step, number, spot)
+			}
+			parked[number] = spotID
+			numbers = append(numbers, number)
+
+		case op < 8 && len(numbers) > 0:
+			i := rng.Intn(len(numbers))
+			number := numbers[i]
+			if err := lot.Unpark(parked[number], number); err != nil {
+				t.Fatalf("Step %d: failed to unpark %s: %v", step, number, err)
+			}
+			delete(parked, number)
+			numbers = append(numbers[:i], numbers[i+1:]...)
+
+		case op == 8:
+			// Convert an empty spot, or turn a bicycle spot into a rack
+			spotID := FormatSpotID(rng.Intn(2), rng.Intn(8), rng.Intn(12))
+			spot, _ := lot.GetSpotByID(spotID)
+			if spot.Type == SpotTypeBicycle && rng.Intn(2) == 0 {
+				_ = lot.SetSpotCapacity(spotID, 1+rng.Intn(3))
+			} else {
+				all := registeredSpotTypeList()
+				_ = lot.SetSpotType(spotID, all[rng.Intn(len(all))])
+			}
+
+		default:
+			// Close or open the top floor
+			if rng.Intn(2) == 0 {
+				_ = lot.CloseFloor(1)
+			} else {
+				_ = lot.OpenFloor(1)
+			}
+		}
+
+		check(step, "random operation")
+	}
+
+	// Expanding keeps the index in step with the new grid
+	if err := lot.ExpandFloor(0, 10, 14, SpotTypeAutomobile); err != nil {
+		t.Fatalf("Failed to expand floor: %v", err)
+	}
+	check(2000, "expanding a floor")
+}
+
+func TestFreeSpotIndexConcurrentPark(t *testing.T) {
+	lot, err := CreateParkingLot("Concurrent Index Lot", 2, 10, 10)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	free := lot.GetAvailableSpotCountByType()[VehicleTypeAutomobile]
+
+	// More vehicles than spots race for them
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	spots := make(map[string]string)
+	noSpace := 0
+	for i := 0; i < free+25; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			number := fmt.Sprintf("CX-%03d", i)
+			spotID, err := lot.Park(VehicleTypeAutomobile, number)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if _, ok := err.(*errors.NoSpaceError); !ok {
+					t.Errorf("Unexpected error parking %s: %v", number, err)
+				}
+				noSpace++
+				return
+			}
+			if other, taken := spots[spotID]; taken {
+				t.Errorf("Spot %s handed to both %s and %s", spotID, other, number)
+			}
+			spots[spotID] = number
+		}(i)
+	}
+	wg.Wait()
+
+	if len(spots) != free || noSpace != 25 {
+		t.Errorf("Expected %d parked and 25 turned away, got %d and %d", free, len(spots), noSpace)
+	}
+	if err := lot.validateCounters(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// HistoryEntry is a parking record together with the vehicle it belongs to
+type HistoryEntry struct {
+	VehicleNumber string
+	VehicleType   VehicleType
+	ParkingRecord
+}
+
+// overlaps reports whether the record's stay overlaps [from, to]. A record
+// still in progress is treated as lasting until now.
+func (r *ParkingRecord) overlaps(from, to, now time.Time) bool {
+	end := now
+	if r.IsComplete() {
+		end = *r.UnparkedAt
+	}
+
+	return !r.ParkedAt.After(to) && !end.Before(from)
+}
+
+// QueryHistory returns the parking records whose stay overlaps the range
+// [from, to], ordered by parked time and then vehicle number. Records still
+// in progress count as lasting until now.
+func (p *ParkingLot) QueryHistory(from, to time.Time) []HistoryEntry {
+	if from.After(to) {
+		return nil
+	}
+
+	var entries []HistoryEntry
+	p.ForEachHistoryEntry(from, to, func(entry HistoryEntry) bool {
+		entries = append(entries, entry)
+		return true
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].ParkedAt.Equal(entries[j].ParkedAt) {
+			return entries[i].ParkedAt.Before(entries[j].ParkedAt)
+		}
+		return entries[i].VehicleNumber < entries[j].VehicleNumber
+	})
+
+	return entries
+}
+
+// ForEachHistoryEntry calls fn for the parking records QueryHistory returns,
+// in no particular order, until fn returns false, without building a list.
+// The lot and its history are locked throughout, so fn must not use the lot.
+func (p *ParkingLot) ForEachHistoryEntry(from, to time.Time, fn func(HistoryEntry) bool) {
+	if from.After(to) {
+		return
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	p.historyMu.Lock()
+	defer p.historyMu.Unlock()
+
+	now := p.clockLocked().Now()
+	p.vehicleHistory.each(func(_ string, history *VehicleHistory) bool {
+		for i := range history.Records {
+			record := &history.Records[i]
+			if !record.overlaps(from, to, now) {
+				continue
+			}
+			entry := HistoryEntry{
+				VehicleNumber: history.Vehicle.Number,
+				VehicleType:   history.Vehicle.Type,
+				ParkingRecord: *record,
+			}
+			if !fn(entry) {
+				return false
+			}
+		}
+		return true
+	})
+}
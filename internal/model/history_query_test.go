@@ -0,0 +1,80 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryHistory(t *testing.T) {
+	lot, err := CreateParkingLot("History Query Lot", 1, 2, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	stay := func(spotID string, from, to time.Time) ParkingRecord {
+		return completedRecord(spotID, from, to.Sub(from))
+	}
+
+	storeHistory(lot, VehicleTypeAutomobile, "KA-01-AA-0001", stay("0-0-0", at(17, 0), at(18, 30)))
+	storeHistory(lot, VehicleTypeAutomobile, "KA-01-AA-0002", stay("0-0-1", at(19, 0), at(21, 0)))
+	storeHistory(lot, VehicleTypeMotorcycle, "KA-01-AA-0003", stay("0-0-2", at(17, 0), at(21, 0)))
+	storeHistory(lot, VehicleTypeAutomobile, "KA-01-AA-0004",
+		stay("0-0-3", at(15, 0), at(17, 59)),
+		stay("0-0-3", at(18, 30), at(19, 30)))
+	storeHistory(lot, VehicleTypeAutomobile, "KA-01-AA-0005", stay("0-0-4", at(20, 1), at(22, 0)))
+	storeHistory(lot, VehicleTypeBicycle, "KA-01-AA-0006", stay("0-1-0", at(17, 0), at(18, 0)))
+	storeHistory(lot, VehicleTypeAutomobile, "KA-01-AA-0007", ParkingRecord{SpotID: "0-1-1", ParkedAt: at(19, 45)})
+	storeHistory(lot, VehicleTypeAutomobile, "KA-01-AA-0008", ParkingRecord{SpotID: "0-1-2", ParkedAt: at(20, 30)})
+
+	// Straddling either boundary, covering the range, inside it, touching the
+	// start, or still parked since inside it all overlap 18:00-20:00
+	expected := []struct {
+		vehicleNumber string
+		spotID        string
+	}{
+		{"KA-01-AA-0001", "0-0-0"},
+		{"KA-01-AA-0003", "0-0-2"},
+		{"KA-01-AA-0006", "0-1-0"},
+		{"KA-01-AA-0004", "0-0-3"},
+		{"KA-01-AA-0002", "0-0-1"},
+		{"KA-01-AA-0007", "0-1-1"},
+	}
+
+	entries := lot.QueryHistory(at(18, 0), at(20, 0))
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d: %+v", len(expected), len(entries), entries)
+	}
+	for i, entry := range entries {
+		if entry.VehicleNumber != expected[i].vehicleNumber || entry.SpotID != expected[i].spotID {
+			t.Errorf("Position %d: expected %s at %s, got %s at %s", i,
+				expected[i].vehicleNumber, expected[i].spotID, entry.VehicleNumber, entry.SpotID)
+		}
+	}
+
+	if entries[1].VehicleType != VehicleTypeMotorcycle {
+		t.Errorf("Expected the vehicle type to be included, got %s", entries[1].VehicleType)
+	}
+	if entries[5].IsComplete() {
+		t.Error("Expected the open record to stay open")
+	}
+
+	// Open records last until now, so a range in the future still finds them
+	entries = lot.QueryHistory(time.Now().Add(-time.Minute), time.Now().Add(time.Hour))
+	if len(entries) != 2 {
+		t.Errorf("Expected the 2 open records in a range ending in the future, got %d", len(entries))
+	}
+
+	// A zero-length range includes stays ending exactly at that instant
+	entries = lot.QueryHistory(at(21, 0), at(21, 0))
+	if len(entries) != 5 {
+		t.Errorf("Expected 5 stays overlapping 21:00, got %+v", entries)
+	}
+
+	if entries := lot.QueryHistory(at(20, 0), at(18, 0)); entries != nil {
+		t.Errorf("Expected no entries for a reversed range, got %+v", entries)
+	}
+}
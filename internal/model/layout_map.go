@@ -0,0 +1,144 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// Layout map files sketch floors as text, one character per spot:
+//
+//	# ground floor
+//	BMAA
+//	BMXA
+//	---
+//	AAAA
+//
+// B, M, A and X stand for bicycle, motorcycle, automobile and inactive spots,
+// and each registered spot type for its own letter.
+// Floors are separated by a "---" line, blank lines are ignored and lines
+// starting with '#' are comments.
+const layoutMapFloorSeparator = "---"
+
+// ParseLayoutMap reads a layout map and returns the spot layout it describes
+func ParseLayoutMap(r io.Reader) (*SpotLayout, error) {
+	var specs []FloorSpec
+	var current [][]SpotType
+	sectionLine := 1
+
+	// endFloor closes the floor being read, which must have at least one row
+	endFloor := func(line int) error {
+		if len(current) == 0 {
+			return errors.NewLayoutMapError(line, 0, "", "floor has no rows")
+		}
+		specs = append(specs, FloorSpec{
+			Rows:    len(current),
+			Columns: len(current[0]),
+			Layout:  current,
+		})
+		current = nil
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+		indent := len(raw) - len(strings.TrimLeft(raw, " \t"))
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if line == layoutMapFloorSeparator {
+			if err := endFloor(lineNumber); err != nil {
+				return nil, err
+			}
+			sectionLine = lineNumber
+			continue
+		}
+
+		row := make([]SpotType, 0, len(line))
+		for i, ch := range []rune(line) {
+			spotType, ok := spotTypeForLetter(unicode.ToUpper(ch))
+			if !ok {
+				return nil, errors.NewLayoutMapError(lineNumber, indent+i+1, string(ch),
+					fmt.Sprintf("invalid spot character %q, expected one of %s", ch, spotTypeLetters()))
+			}
+			row = append(row, spotType)
+		}
+
+		if len(current) > 0 && len(row) != len(current[0]) {
+			return nil, errors.NewLayoutMapError(lineNumber, 0, "",
+				fmt.Sprintf("row has %d spots, expected %d like the rest of the floor",
+					len(row), len(current[0])))
+		}
+
+		current = append(current, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read layout map: %v", err)
+	}
+
+	if len(specs) == 0 && len(current) == 0 {
+		return nil, errors.NewLayoutMapError(lineNumber, 0, "", "layout map has no floors")
+	}
+
+	if err := endFloor(sectionLine); err != nil {
+		return nil, err
+	}
+
+	return NewSpotLayoutFromSpecs(specs)
+}
+
+// LoadLayoutMap reads a layout map from a file
+func LoadLayoutMap(path string) (*SpotLayout, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open layout map: %v", err)
+	}
+	defer file.Close()
+
+	return ParseLayoutMap(file)
+}
+
+// WriteLayoutMap renders a spot layout in the layout map format
+func WriteLayoutMap(w io.Writer, layout *SpotLayout) error {
+	var sb strings.Builder
+
+	for f, floor := range layout.SpotMap {
+		if f > 0 {
+			sb.WriteString(layoutMapFloorSeparator + "\n")
+		}
+		sb.WriteString(fmt.Sprintf("# floor %d\n", f))
+
+		for _, row := range floor {
+			for _, spotType := range row {
+				sb.WriteByte(spotType.String()[0])
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// FloorSpecs returns one FloorSpec per floor of the layout, for creating a lot
+func (l *SpotLayout) FloorSpecs() []FloorSpec {
+	specs := make([]FloorSpec, len(l.SpotMap))
+	for f, floor := range l.SpotMap {
+		rows, columns, _ := l.GetFloorDimensions(f)
+		specs[f] = FloorSpec{Rows: rows, Columns: columns, Layout: floor}
+	}
+
+	return specs
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"bytes"
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+func TestParseLayoutMap(t *testing.T) {
+	input := `# ground floor
+BMAA
+bmxa
+
+---
+# upper floor, smaller
+AA
+AX
+AA
+`
+
+	layout, err := ParseLayoutMap(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse layout map: %v", err)
+	}
+
+	if len(layout.SpotMap) != 2 {
+		t.Fatalf("Expected 2 floors, got %d", len(layout.SpotMap))
+	}
+
+	rows, columns, _ := layout.GetFloorDimensions(0)
+	if rows != 2 || columns != 4 {
+		t.Errorf("Floor 0: expected 2x4, got %dx%d", rows, columns)
+	}
+
+	rows, columns, _ = layout.GetFloorDimensions(1)
+	if rows != 3 || columns != 2 {
+		t.Errorf("Floor 1: expected 3x2, got %dx%d", rows, columns)
+	}
+
+	if spotType, _ := layout.GetSpotType(0, 1, 2); spotType != SpotTypeInactive {
+		t.Errorf("Expected X-0 at 0-1-2, got %s", spotType)
+	}
+	if spotType, _ := layout.GetSpotType(0, 1, 1); spotType != SpotTypeMotorcycle {
+		t.Errorf("Expected lowercase m to parse as M-1, got %s", spotType)
+	}
+}
+
+func TestParseLayoutMapErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		line   int
+		column int
+	}{
+		{"invalid character", "BMA\nBQA\n", 2, 2},
+		{"invalid character after indent", "  BMA\n  BMZ\n", 2, 5},
+		{"ragged rows", "BMAA\nBMA\n", 2, 0},
+		{"empty floor", "BMA\n---\n---\nAAA\n", 3, 0},
+		{"trailing separator", "BMA\n---\n", 2, 0},
+		{"empty map", "# nothing here\n", 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLayoutMap(strings.NewReader(tt.input))
+			if err == nil {
+				t.Fatalf("Expected error, got nil")
+			}
+
+			var mapErr *errors.LayoutMapError
+			if !stderrors.As(err, &mapErr) {
+				t.Fatalf("Expected LayoutMapError, got %T: %v", err, err)
+			}
+
+			if mapErr.Line != tt.line || mapErr.Column != tt.column {
+				t.Errorf("Expected error at %d:%d, got %d:%d (%v)",
+					tt.line, tt.column, mapErr.Line, mapErr.Column, err)
+			}
+		})
+	}
+
+	// More than 8 floors is rejected by the layout validation
+	nine := strings.Repeat("A\n---\n", 8) + "A\n"
+	if _, err := ParseLayoutMap(strings.NewReader(nine)); err == nil {
+		t.Errorf("Expected error for 9 floors")
+	}
+}
+
+func TestLayoutMapRoundTrip(t *testing.T) {
+	lot, err := CreateParkingLotWithFloors("Round Trip", 0, []FloorSpec{
+		{Rows: 10, Columns: 10},
+		{Rows: 3, Columns: 5},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	original := lot.GetSpotLayout()
+
+	var buf bytes.Buffer
+	if err := WriteLayoutMap(&buf, original); err != nil {
+		t.Fatalf("Failed to write layout map: %v", err)
+	}
+
+	parsed, err := ParseLayoutMap(&buf)
+	if err != nil {
+		t.Fatalf("Failed to parse written layout map: %v\n%s", err, buf.String())
+	}
+
+	if len(parsed.SpotMap) != len(original.SpotMap) {
+		t.Fatalf("Expected %d floors, got %d", len(original.SpotMap), len(parsed.SpotMap))
+	}
+
+	for f := range original.SpotMap {
+		for r := range original.SpotMap[f] {
+			for c := range original.SpotMap[f][r] {
+				if parsed.SpotMap[f][r][c] != original.SpotMap[f][r][c] {
+					t.Fatalf("Mismatch at %d-%d-%d: expected %s, got %s", f, r, c,
+						original.SpotMap[f][r][c], parsed.SpotMap[f][r][c])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// LayoutTemplate is a named generator for realistic spot layouts
+type LayoutTemplate struct {
+	Name        string
+	Description string
+
+	// Generate returns the spot types of every floor for the given dimensions
+	Generate func(floors, rows, columns int) [][][]SpotType
+}
+
+// layoutTemplates lists the built-in templates, default first
+var layoutTemplates = []LayoutTemplate{
+	{
+		Name:        "mixed",
+		Description: "Balanced mix with scattered pillars (the default layout)",
+		Generate: func(floors, rows, columns int) [][][]SpotType {
+			spotMap := make([][][]SpotType, floors)
+			for f := range spotMap {
+				spotMap[f] = generateFloorLayout(f, rows, columns, DefaultPillarPattern())
+			}
+			return spotMap
+		},
+	},
+	{
+		Name:        "compact",
+		Description: "Mostly two-wheelers, suited to small urban sites",
+		Generate: func(floors, rows, columns int) [][][]SpotType {
+			dist := DistributionSpec{BicycleWeight: 40, MotorcycleWeight: 40, AutomobileWeight: 20}
+			spotMap := make([][][]SpotType, floors)
+			for f := range spotMap {
+				spotMap[f] = generateDistributedFloorLayout(rows, columns, dist)
+			}
+			return spotMap
+		},
+	},
+	{
+		Name:        "mall",
+		Description: "Automobile-heavy with clustered 2x2 pillars",
+		Generate: func(floors, rows, columns int) [][][]SpotType {
+			dist := DistributionSpec{BicycleWeight: 5, MotorcycleWeight: 10, AutomobileWeight: 85}
+			spotMap := make([][][]SpotType, floors)
+			for f := range spotMap {
+				spotMap[f] = generateDistributedFloorLayout(rows, columns, dist)
+
+				// Pillars stand in 2x2 clusters on a 6x6 grid
+				for r := range spotMap[f] {
+					for c := range spotMap[f][r] {
+						if r%6 >= 2 && r%6 <= 3 && c%6 >= 2 && c%6 <= 3 {
+							spotMap[f][r][c] = SpotTypeInactive
+						}
+					}
+				}
+			}
+			return spotMap
+		},
+	},
+}
+
+// GetLayoutTemplates returns the built-in layout templates
+func GetLayoutTemplates() []LayoutTemplate {
+	templates := make([]LayoutTemplate, len(layoutTemplates))
+	copy(templates, layoutTemplates)
+	return templates
+}
+
+// GetLayoutTemplate returns the built-in template with the given name
+func GetLayoutTemplate(name string) (LayoutTemplate, error) {
+	for _, template := range layoutTemplates {
+		if strings.EqualFold(template.Name, name) {
+			return template, nil
+		}
+	}
+
+	names := make([]string, len(layoutTemplates))
+	for i, template := range layoutTemplates {
+		names[i] = template.Name
+	}
+
+	return LayoutTemplate{}, errors.NewValidationError("template", name,
+		fmt.Sprintf("unknown template, expected one of %s", strings.Join(names, ", ")))
+}
+
+// NewSpotLayoutFromTemplate creates a uniform spot layout from a named template.
+// Like generated layouts, it has at least one spot of each active type.
+func NewSpotLayoutFromTemplate(name string, floors, rows, columns int) (*SpotLayout, error) {
+	template, err := GetLayoutTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if floors < 1 || floors > 8 {
+		return nil, errors.NewValidationError("floors",
+			fmt.Sprintf("%d", floors), "number of floors must be between 1 and 8")
+	}
+
+	if rows < 1 || rows > 1000 {
+		return nil, errors.NewValidationError("rows",
+			fmt.Sprintf("%d", rows), "number of rows must be between 1 and 1000")
+	}
+
+	if columns < 1 || columns > 1000 {
+		return nil, errors.NewValidationError("columns",
+			fmt.Sprintf("%d", columns), "number of columns must be between 1 and 1000")
+	}
+
+	layout := &SpotLayout{SpotMap: template.Generate(floors, rows, columns)}
+	layout.ensureAllSpotTypes()
+
+	return layout, nil
+}
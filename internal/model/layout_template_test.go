@@ -0,0 +1,74 @@
+package model
+
+import "testing"
+
+func TestLayoutTemplates(t *testing.T) {
+	sizes := [][3]int{{1, 1, 1}, {1, 1, 3}, {1, 2, 2}, {3, 1, 1}, {2, 5, 5}, {3, 10, 20}, {8, 100, 100}}
+
+	for _, template := range GetLayoutTemplates() {
+		for _, size := range sizes {
+			floors, rows, columns := size[0], size[1], size[2]
+			layout, err := NewSpotLayoutFromTemplate(template.Name, floors, rows, columns)
+			if err != nil {
+				t.Fatalf("%s %v: failed to create layout: %v", template.Name, size, err)
+			}
+
+			if len(layout.SpotMap) != floors {
+				t.Fatalf("%s %v: expected %d floors, got %d", template.Name, size, floors, len(layout.SpotMap))
+			}
+
+			for f := range layout.SpotMap {
+				r, c, _ := layout.GetFloorDimensions(f)
+				if r != rows || c != columns {
+					t.Errorf("%s %v: floor %d is %dx%d", template.Name, size, f, r, c)
+				}
+
+				for _, row := range layout.SpotMap[f] {
+					for _, spotType := range row {
+						if _, err := ParseSpotType(string(spotType)); err != nil {
+							t.Fatalf("%s %v: invalid spot type %q", template.Name, size, spotType)
+						}
+					}
+				}
+			}
+
+			// Layouts with at least three spots must include every active type
+			if floors*rows*columns < 3 {
+				continue
+			}
+			counts := layout.CountSpotsByType()
+			if counts[SpotTypeBicycle] == 0 || counts[SpotTypeMotorcycle] == 0 || counts[SpotTypeAutomobile] == 0 {
+				t.Errorf("%s %v: expected every active spot type, got %v", template.Name, size, counts)
+			}
+		}
+	}
+}
+
+func TestGetLayoutTemplate(t *testing.T) {
+	for _, name := range []string{"mixed", "compact", "mall", "MALL"} {
+		if _, err := GetLayoutTemplate(name); err != nil {
+			t.Errorf("Expected template %s to exist: %v", name, err)
+		}
+	}
+
+	if _, err := GetLayoutTemplate("stadium"); err == nil {
+		t.Errorf("Expected error for unknown template")
+	}
+
+	if _, err := NewSpotLayoutFromTemplate("mall", 9, 10, 10); err == nil {
+		t.Errorf("Expected error for 9 floors")
+	}
+
+	// The mixed template matches the default layout
+	mixed, _ := NewSpotLayoutFromTemplate("mixed", 3, 10, 20)
+	defaults, _ := NewSpotLayout(3, 10, 20)
+	for f := range defaults.SpotMap {
+		for r := range defaults.SpotMap[f] {
+			for c := range defaults.SpotMap[f][r] {
+				if mixed.SpotMap[f][r][c] != defaults.SpotMap[f][r][c] {
+					t.Fatalf("mixed template differs from the default layout at %d-%d-%d", f, r, c)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package model
+
+// Logger receives diagnostics from a parking lot, in the manner of
+// *slog.Logger: msg is a complete message, which may hold any text
+// including %, and args are key/value pairs. A printf-style logger must not
+// take msg as its format but print it with "%s", as the CLI's lotLogger does.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+}
+
+// nopLogger discards every message; lots use it until given a logger
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Warn(string, ...interface{})  {}
+
+// LotOption configures a parking lot when it is created
+type LotOption func(*ParkingLot)
+
+// WithLogger sends the lot's diagnostics to logger
+func WithLogger(logger Logger) LotOption {
+	return func(p *ParkingLot) {
+		p.logger = logger
+	}
+}
+
+// SetLogger sends the lot's diagnostics to logger; nil discards them
+func (p *ParkingLot) SetLogger(logger Logger) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.logger = logger
+}
+
+// loggerLocked returns the lot's logger, a no-op one when unset; p.mu must be held
+func (p *ParkingLot) loggerLocked() Logger {
+	if p.logger == nil {
+		return nopLogger{}
+	}
+	return p.logger
+}
@@ -0,0 +1,116 @@
+package model
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// slog loggers can receive a lot's diagnostics as they are
+var _ Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// recordingLogger keeps every message it receives
+type recordingLogger struct {
+	mu       sync.Mutex
+	debugs   []string
+	warnings []string
+}
+
+func (l *recordingLogger) Debug(msg string, _ ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.debugs = append(l.debugs, msg)
+}
+
+func (l *recordingLogger) Warn(msg string, _ ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.warnings = append(l.warnings, msg)
+}
+
+// captureStdout returns everything fn writes to standard output
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
+
+func TestUnparkWarnsWhenTheRecordCannotComplete(t *testing.T) {
+	logger := &recordingLogger{}
+	lot, err := CreateParkingLot("Logged Lot", 1, 2, 5, WithLogger(logger))
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	spotID, err := lot.Park(VehicleTypeAutomobile, "KA-01")
+	if err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+
+	// Complete the record behind the lot's back
+	history, _ := lot.GetVehicleHistory("KA-01")
+	_ = history.CompleteLastParkingRecord()
+
+	out := captureStdout(t, func() {
+		if err := lot.Unpark(spotID, "KA-01"); err == nil {
+			t.Error("Expected unpark to fail when the parking record cannot be completed")
+		}
+	})
+
+	if out != "" {
+		t.Errorf("Expected nothing on stdout, got %q", out)
+	}
+	if len(logger.warnings) != 1 || !strings.Contains(logger.warnings[0], "parking record of KA-01") {
+		t.Errorf("Expected a warning about the parking record, got %q", logger.warnings)
+	}
+}
+
+func TestRollbackIsLogged(t *testing.T) {
+	logger := &recordingLogger{}
+	lot, err := CreateParkingLot("Logged Lot", 1, 2, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	lot.SetLogger(logger)
+
+	lot.faults = func(name string) error {
+		if name == "park.index" {
+			return errors.NewInvalidOperationError("park", "injected failure")
+		}
+		return nil
+	}
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-01"); err == nil {
+		t.Fatal("Expected the injected failure")
+	}
+
+	if len(logger.debugs) != 1 || !strings.Contains(logger.debugs[0], "park of KA-01 failed") {
+		t.Errorf("Expected the rollback to be logged, got %q", logger.debugs)
+	}
+	if len(logger.warnings) != 0 {
+		t.Errorf("Expected a clean rollback, got warnings %q", logger.warnings)
+	}
+
+	// Without a logger diagnostics are discarded
+	lot.SetLogger(nil)
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-02"); err == nil {
+		t.Fatal("Expected the injected failure")
+	}
+	if len(logger.debugs) != 1 {
+		t.Errorf("Expected no more messages after removing the logger, got %q", logger.debugs)
+	}
+}
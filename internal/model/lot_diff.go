@@ -0,0 +1,150 @@
+package model
+
+import (
+	"slices"
+	"sort"
+)
+
+// SpotState is what one lot holds at a spot location, for a diff
+type SpotState struct {
+	// Missing is set when the lot has no spot there
+	Missing bool
+
+	Type     SpotType
+	Active   bool
+	Vehicles []string
+}
+
+// SpotDiff is a spot location whose type, active state or occupants differ
+// between two lots
+type SpotDiff struct {
+	// ID of the spot in the first lot that has it
+	SpotID string
+	Floor  int
+	Row    int
+	Column int
+
+	A SpotState
+	B SpotState
+}
+
+// LotDiff lists how two lots differ
+type LotDiff struct {
+	// Spots that differ, floor by floor in row-major order
+	Spots []SpotDiff
+
+	// Vehicles parked in one lot but not the other, sorted
+	OnlyInA []string
+	OnlyInB []string
+}
+
+// IsEmpty reports whether the lots hold the same spots and vehicles
+func (d LotDiff) IsEmpty() bool {
+	return len(d.Spots) == 0 && len(d.OnlyInA) == 0 && len(d.OnlyInB) == 0
+}
+
+// Floors returns the floors with differing spots, lowest first
+func (d LotDiff) Floors() []int {
+	var floors []int
+	for _, spot := range d.Spots {
+		if len(floors) == 0 || floors[len(floors)-1] != spot.Floor {
+			floors = append(floors, spot.Floor)
+		}
+	}
+	return floors
+}
+
+// spotLocation identifies a spot across lots
+type spotLocation struct {
+	floor, row, column int
+}
+
+// DiffLots compares two lots spot by spot, matching spots by floor, row and
+// column, and lists the vehicles parked in only one of them. Vehicle
+// numbers are compared normalized. The spots of each lot are read in one pass.
+func DiffLots(a, b *ParkingLot) LotDiff {
+	spotsA, spotsB := a.ListSpots(SpotFilter{}), b.ListSpots(SpotFilter{})
+
+	byLocation := make(map[spotLocation]SpotInfo, len(spotsB))
+	for _, spot := range spotsB {
+		byLocation[spotLocation{spot.Floor, spot.Row, spot.Column}] = spot
+	}
+
+	var diff LotDiff
+	for _, spot := range spotsA {
+		location := spotLocation{spot.Floor, spot.Row, spot.Column}
+		other, found := byLocation[location]
+		delete(byLocation, location)
+
+		stateA, stateB := stateOf(spot), SpotState{Missing: true}
+		if found {
+			stateB = stateOf(other)
+		}
+		if !stateA.equal(stateB) {
+			diff.Spots = append(diff.Spots, newSpotDiff(spot, stateA, stateB))
+		}
+	}
+	for _, spot := range spotsB {
+		if _, left := byLocation[spotLocation{spot.Floor, spot.Row, spot.Column}]; left {
+			diff.Spots = append(diff.Spots, newSpotDiff(spot, SpotState{Missing: true}, stateOf(spot)))
+		}
+	}
+	sort.SliceStable(diff.Spots, func(i, j int) bool {
+		si, sj := diff.Spots[i], diff.Spots[j]
+		if si.Floor != sj.Floor {
+			return si.Floor < sj.Floor
+		}
+		if si.Row != sj.Row {
+			return si.Row < sj.Row
+		}
+		return si.Column < sj.Column
+	})
+
+	diff.OnlyInA, diff.OnlyInB = vehiclesOnlyIn(spotsA, spotsB), vehiclesOnlyIn(spotsB, spotsA)
+	return diff
+}
+
+// stateOf returns the state of a listed spot, its vehicles normalized and sorted
+func stateOf(spot SpotInfo) SpotState {
+	vehicles := make([]string, len(spot.Vehicles))
+	for i, vehicleNumber := range spot.Vehicles {
+		vehicles[i] = NormalizeVehicleNumber(vehicleNumber)
+	}
+	sort.Strings(vehicles)
+
+	return SpotState{Type: spot.Type, Active: spot.Type.IsActive(), Vehicles: vehicles}
+}
+
+// equal reports whether two spot states match
+func (s SpotState) equal(other SpotState) bool {
+	return s.Missing == other.Missing && s.Type == other.Type && slices.Equal(s.Vehicles, other.Vehicles)
+}
+
+// newSpotDiff builds the diff of the spot at a listed location
+func newSpotDiff(spot SpotInfo, a, b SpotState) SpotDiff {
+	return SpotDiff{SpotID: spot.SpotID, Floor: spot.Floor, Row: spot.Row, Column: spot.Column, A: a, B: b}
+}
+
+// vehiclesOnlyIn returns the vehicles in the spots of one lot but in none of
+// the other's, sorted. An oversized vehicle is listed once.
+func vehiclesOnlyIn(spots, otherSpots []SpotInfo) []string {
+	parked := make(map[string]bool)
+	for _, spot := range otherSpots {
+		for _, vehicleNumber := range spot.Vehicles {
+			parked[NormalizeVehicleNumber(vehicleNumber)] = true
+		}
+	}
+
+	var only []string
+	for _, spot := range spots {
+		for _, vehicleNumber := range spot.Vehicles {
+			key := NormalizeVehicleNumber(vehicleNumber)
+			if !parked[key] {
+				only = append(only, vehicleNumber)
+				parked[key] = true
+			}
+		}
+	}
+	sort.Strings(only)
+	return only
+}
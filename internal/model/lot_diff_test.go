@@ -0,0 +1,84 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDiffLots(t *testing.T) {
+	newLot := func() *ParkingLot {
+		lot, err := CreateParkingLotWithFloors("Diff Lot", 0, []FloorSpec{
+			{Rows: 1, Columns: 3, Layout: [][]SpotType{{SpotTypeAutomobile, SpotTypeAutomobile, SpotTypeAutomobile}}},
+			{Rows: 1, Columns: 2, Layout: [][]SpotType{{SpotTypeMotorcycle, SpotTypeAutomobile}}},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create lot: %v", err)
+		}
+		return lot
+	}
+	a, b := newLot(), newLot()
+	for _, lot := range []*ParkingLot{a, b} {
+		if _, err := lot.ParkAt("0-0-0", VehicleTypeAutomobile, "KA-01-HH-0001"); err != nil {
+			t.Fatalf("Failed to park the shared vehicle: %v", err)
+		}
+	}
+	if diff := DiffLots(a, b); !diff.IsEmpty() {
+		t.Fatalf("Expected identical lots not to differ, got %+v", diff)
+	}
+
+	// Three differences: a spot made inactive, a vehicle only in a and a
+	// vehicle only in b
+	if err := b.SetSpotType("0-0-2", SpotTypeInactive); err != nil {
+		t.Fatalf("Failed to change spot type: %v", err)
+	}
+	if _, err := a.ParkAt("1-0-1", VehicleTypeAutomobile, "KA-02-HH-0002"); err != nil {
+		t.Fatalf("Failed to park in a: %v", err)
+	}
+	if _, err := b.ParkAt("1-0-0", VehicleTypeMotorcycle, "ka-03-hh-0003"); err != nil {
+		t.Fatalf("Failed to park in b: %v", err)
+	}
+
+	diff := DiffLots(a, b)
+	if len(diff.Spots) != 3 {
+		t.Fatalf("Expected 3 differing spots, got %+v", diff.Spots)
+	}
+	inactive, onlyB, onlyA := diff.Spots[0], diff.Spots[1], diff.Spots[2]
+	if inactive.SpotID != "0-0-2" || inactive.A.Type != SpotTypeAutomobile || !inactive.A.Active ||
+		inactive.B.Type != SpotTypeInactive || inactive.B.Active {
+		t.Errorf("Expected 0-0-2 to go from active A-1 to inactive, got %+v", inactive)
+	}
+	if onlyA.SpotID != "1-0-1" || fmt.Sprint(onlyA.A.Vehicles) != "[KA-02-HH-0002]" || len(onlyA.B.Vehicles) != 0 {
+		t.Errorf("Expected 1-0-1 occupied in a only, got %+v", onlyA)
+	}
+	if onlyB.SpotID != "1-0-0" || len(onlyB.A.Vehicles) != 0 || fmt.Sprint(onlyB.B.Vehicles) != "[KA-03-HH-0003]" {
+		t.Errorf("Expected 1-0-0 occupied in b only, got %+v", onlyB)
+	}
+	if fmt.Sprint(diff.OnlyInA) != "[KA-02-HH-0002]" || len(diff.OnlyInB) != 1 {
+		t.Errorf("Expected one vehicle only in each lot, got %v and %v", diff.OnlyInA, diff.OnlyInB)
+	}
+	if floors := fmt.Sprint(diff.Floors()); floors != "[0 1]" {
+		t.Errorf("Expected differences on floors [0 1], got %s", floors)
+	}
+}
+
+func TestDiffLotsMissingFloor(t *testing.T) {
+	floor := FloorSpec{Rows: 1, Columns: 2, Layout: [][]SpotType{{SpotTypeAutomobile, SpotTypeAutomobile}}}
+	a, err := CreateParkingLotWithFloors("Small", 0, []FloorSpec{floor})
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	b, err := CreateParkingLotWithFloors("Large", 0, []FloorSpec{floor, floor})
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	diff := DiffLots(a, b)
+	if len(diff.Spots) != 2 {
+		t.Fatalf("Expected the two spots of the extra floor, got %+v", diff.Spots)
+	}
+	for _, spot := range diff.Spots {
+		if spot.Floor != 1 || !spot.A.Missing || spot.B.Missing {
+			t.Errorf("Expected a spot only in the larger lot, got %+v", spot)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"time"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// LotMetadata describes a lot to the people running it
+type LotMetadata struct {
+	Address string
+
+	// IANA name of the zone the lot's times are shown in, UTC when empty.
+	// Times are recorded in UTC whatever the zone.
+	Timezone string
+
+	// Free-form notes from the operators
+	Notes string
+
+	// Loaded from Timezone when the metadata is set
+	location *time.Location
+}
+
+// Location returns the zone the lot's times are shown in
+func (m LotMetadata) Location() *time.Location {
+	if m.location == nil {
+		return time.UTC
+	}
+	return m.location
+}
+
+// load checks the timezone of the metadata and loads its zone
+func (m *LotMetadata) load() error {
+	if m.Timezone == "" {
+		m.location = nil
+		return nil
+	}
+
+	location, err := time.LoadLocation(m.Timezone)
+	if err != nil {
+		return errors.NewValidationError("timezone", m.Timezone, "unknown time zone")
+	}
+	m.location = location
+	return nil
+}
+
+// WithMetadata creates the lot with the given metadata. Creating the lot
+// fails if its timezone is unknown.
+func WithMetadata(metadata LotMetadata) LotOption {
+	return func(p *ParkingLot) {
+		p.metadata = metadata
+	}
+}
+
+// SetMetadata replaces the metadata of the lot, rejecting an unknown timezone
+func (p *ParkingLot) SetMetadata(metadata LotMetadata) error {
+	if err := metadata.load(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.metadata = metadata
+	return nil
+}
+
+// Metadata returns the metadata of the lot
+func (p *ParkingLot) Metadata() LotMetadata {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.metadata
+}
+
+// Location returns the zone the lot's times are shown in, UTC unless the
+// metadata names one
+func (p *ParkingLot) Location() *time.Location {
+	return p.Metadata().Location()
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+func TestLotMetadata(t *testing.T) {
+	lot, err := CreateParkingLot("Metadata Lot", 1, 1, 2,
+		WithMetadata(LotMetadata{Address: "1 Main Street", Timezone: "Asia/Kolkata"}))
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	if metadata := lot.Metadata(); metadata.Address != "1 Main Street" || metadata.Timezone != "Asia/Kolkata" {
+		t.Errorf("Expected the metadata given at creation, got %+v", metadata)
+	}
+
+	// Times are recorded as given and shown in the lot's zone
+	parkedAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	if shown := parkedAt.In(lot.Location()).Format(time.DateTime); shown != "2024-01-01 14:30:00" {
+		t.Errorf("Expected 09:00 UTC shown as 14:30 in Kolkata, got %s", shown)
+	}
+
+	// An unknown zone is rejected and the metadata kept
+	var validationErr *errors.ValidationError
+	err = lot.SetMetadata(LotMetadata{Timezone: "Mars/Olympus_Mons"})
+	if !stderrors.As(err, &validationErr) {
+		t.Errorf("Expected an unknown timezone to be rejected, got %v", err)
+	}
+	if lot.Metadata().Timezone != "Asia/Kolkata" {
+		t.Errorf("Expected the rejected metadata not to be set, got %+v", lot.Metadata())
+	}
+
+	if err := lot.SetMetadata(LotMetadata{Notes: "Gate B closed at night"}); err != nil {
+		t.Fatalf("Failed to set metadata: %v", err)
+	}
+	if lot.Location() != time.UTC {
+		t.Errorf("Expected times shown in UTC with no timezone, got %v", lot.Location())
+	}
+
+	_, err = CreateParkingLot("Bad Zone Lot", 1, 1, 2, WithMetadata(LotMetadata{Timezone: "Nowhere"}))
+	if !stderrors.As(err, &validationErr) {
+		t.Errorf("Expected creation with an unknown timezone to fail, got %v", err)
+	}
+}
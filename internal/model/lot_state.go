@@ -0,0 +1,372 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// LotState is everything a lot holds beyond what its snapshot does: the
+// options it was created with, the settings made on it since and the
+// history of its vehicles and spots. Restoring it gives the lot back as it
+// was, except for its operation counters, occupancy peaks and samples,
+// which start over.
+type LotState struct {
+	LotSnapshot
+
+	// Options the lot was created with
+	SpotIDFormat        string             `json:"spotIDFormat,omitempty"`
+	VehicleNumberRule   *VehicleRuleState  `json:"vehicleNumberRule,omitempty"`
+	IgnoreSeparators    bool               `json:"ignoreSeparators,omitempty"`
+	AccessibleOverflow  bool               `json:"accessibleOverflow,omitempty"`
+	Metadata            *LotMetadataState  `json:"metadata,omitempty"`
+	Entrance            Entrance           `json:"entrance"`
+	VehicleHistoryLimit int                `json:"vehicleHistoryLimit,omitempty"`
+	SpotHistoryLimit    int                `json:"spotHistoryLimit,omitempty"`
+	Spots               []SpotSettings     `json:"spotSettings,omitempty"`
+	FloorSettings       []FloorSettings    `json:"floorSettings,omitempty"`
+	Permits             []string           `json:"permits,omitempty"`
+	QuotaExempt         []string           `json:"quotaExempt,omitempty"`
+	Quotas              []QuotaState       `json:"quotas,omitempty"`
+	History             []HistoryState     `json:"history,omitempty"`
+	SpotHistory         []SpotHistoryState `json:"spotHistory,omitempty"`
+}
+
+// VehicleRuleState is the rule a lot checks vehicle numbers with
+type VehicleRuleState struct {
+	Pattern   string `json:"pattern,omitempty"`
+	MinLength int    `json:"minLength,omitempty"`
+	MaxLength int    `json:"maxLength,omitempty"`
+	NoSpaces  bool   `json:"noSpaces,omitempty"`
+}
+
+// LotMetadataState is the address, timezone and notes of a lot
+type LotMetadataState struct {
+	Address  string `json:"address,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+}
+
+// SpotSettings is the label, tags and charger state of a spot with any of them
+type SpotSettings struct {
+	Floor   int          `json:"floor"`
+	Row     int          `json:"row"`
+	Column  int          `json:"column"`
+	Label   string       `json:"label,omitempty"`
+	Tags    []string     `json:"tags,omitempty"`
+	Charger ChargerState `json:"charger,omitempty"`
+}
+
+// FloorSettings is whether a floor is closed and the vehicle types it is
+// restricted to, for a floor that is either
+type FloorSettings struct {
+	Floor   int           `json:"floor"`
+	Closed  bool          `json:"closed,omitempty"`
+	Allowed []VehicleType `json:"allowed,omitempty"`
+}
+
+// QuotaState is the quota of a vehicle type
+type QuotaState struct {
+	VehicleType VehicleType `json:"vehicleType"`
+	Limit       int         `json:"limit"`
+}
+
+// HistoryState is the parking history of a vehicle
+type HistoryState struct {
+	VehicleNumber string        `json:"vehicleNumber"`
+	VehicleType   VehicleType   `json:"vehicleType"`
+	Records       []RecordState `json:"records"`
+}
+
+// RecordState is one parking of a vehicle
+type RecordState struct {
+	SpotID     string     `json:"spotID"`
+	ParkedAt   time.Time  `json:"parkedAt"`
+	UnparkedAt *time.Time `json:"unparkedAt,omitempty"`
+}
+
+// SpotHistoryState is the recent occupancies of a spot, by its ID in the
+// lot's spot ID format
+type SpotHistoryState struct {
+	SpotID      string           `json:"spotID"`
+	Occupancies []OccupancyState `json:"occupancies"`
+}
+
+// OccupancyState is one vehicle's stay in a spot
+type OccupancyState struct {
+	VehicleNumber string     `json:"vehicleNumber"`
+	ParkedAt      time.Time  `json:"parkedAt"`
+	VacatedAt     *time.Time `json:"vacatedAt,omitempty"`
+}
+
+// State returns the snapshot of the lot together with its options, settings
+// and history
+func (p *ParkingLot) State() *LotState {
+	state := &LotState{LotSnapshot: *p.Snapshot()}
+
+	p.mu.RLock()
+	if p.spotIDs != nil {
+		state.SpotIDFormat = spotIDFormatterName(p.spotIDs)
+	}
+	if p.numberValidator != nil {
+		rule := p.numberValidator.Rule()
+		state.VehicleNumberRule = &VehicleRuleState{
+			Pattern:   rule.Pattern,
+			MinLength: rule.MinLength,
+			MaxLength: rule.MaxLength,
+			NoSpaces:  rule.NoSpaces,
+		}
+	}
+	state.IgnoreSeparators = p.ignoreSeparators
+	state.AccessibleOverflow = p.accessibleOverflow
+	if m := p.metadata; m.Address != "" || m.Timezone != "" || m.Notes != "" {
+		state.Metadata = &LotMetadataState{Address: m.Address, Timezone: m.Timezone, Notes: m.Notes}
+	}
+	state.Entrance = p.entrance
+	state.VehicleHistoryLimit = p.vehicleHistoryLimit
+	for _, floor := range p.floors {
+		state.Spots = append(state.Spots, floor.spotSettings()...)
+		closed, allowed := floor.IsClosed(), floor.AllowedVehicleTypes()
+		if closed || allowed != nil {
+			state.FloorSettings = append(state.FloorSettings,
+				FloorSettings{Floor: floor.FloorNumber, Closed: closed, Allowed: allowed})
+		}
+	}
+	state.Permits = sortedKeys(p.permits)
+	state.QuotaExempt = sortedKeys(p.quotaExempt)
+
+	p.historyMu.Lock()
+	p.vehicleHistory.each(func(_ string, history *VehicleHistory) bool {
+		saved := HistoryState{VehicleNumber: history.Vehicle.Number, VehicleType: history.Vehicle.Type}
+		for _, record := range history.Records {
+			saved.Records = append(saved.Records, RecordState{
+				SpotID:     record.SpotID,
+				ParkedAt:   record.ParkedAt,
+				UnparkedAt: record.UnparkedAt,
+			})
+		}
+		state.History = append(state.History, saved)
+		return true
+	})
+	p.historyMu.Unlock()
+	p.mu.RUnlock()
+	sort.Slice(state.History, func(i, j int) bool {
+		return state.History[i].VehicleNumber < state.History[j].VehicleNumber
+	})
+
+	for _, quota := range p.GetQuotas() {
+		state.Quotas = append(state.Quotas, QuotaState{VehicleType: quota.VehicleType, Limit: quota.Limit})
+	}
+	state.SpotHistory, state.SpotHistoryLimit = p.spotHistory.state()
+
+	return state
+}
+
+// spotSettings returns the settings of the floor's spots that have any
+func (f *ParkingFloor) spotSettings() []SpotSettings {
+	var settings []SpotSettings
+	f.forEachMadeSpot(func(spot *ParkingSpot) bool {
+		spot.mu.RLock()
+		defer spot.mu.RUnlock()
+
+		if spot.label != "" || len(spot.tags) > 0 {
+			settings = append(settings, SpotSettings{
+				Floor:   spot.Floor,
+				Row:     spot.Row,
+				Column:  spot.Column,
+				Label:   spot.label,
+				Tags:    append([]string(nil), spot.tags...),
+				Charger: spot.charger,
+			})
+		}
+		return true
+	})
+	return settings
+}
+
+// state returns the occupancies of every spot, by spot ID, and the limit
+// set, zero for the default one
+func (i *spotHistoryIndex) state() ([]SpotHistoryState, int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	states := make([]SpotHistoryState, 0, len(i.entries))
+	for spotID, entries := range i.entries {
+		state := SpotHistoryState{SpotID: spotID}
+		for _, entry := range entries {
+			state.Occupancies = append(state.Occupancies, OccupancyState{
+				VehicleNumber: entry.VehicleNumber,
+				ParkedAt:      entry.ParkedAt,
+				VacatedAt:     entry.VacatedAt,
+			})
+		}
+		states = append(states, state)
+	}
+	sort.Slice(states, func(a, b int) bool { return states[a].SpotID < states[b].SpotID })
+	return states, i.limit
+}
+
+// sortedKeys returns the keys of a set, sorted
+func sortedKeys(set map[string]bool) []string {
+	var keys []string
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Restore creates a lot from the state, as LotSnapshot.Restore does, with
+// the options, settings and history saved in it. Options given override
+// those saved.
+func (s *LotState) Restore(opts ...LotOption) (*ParkingLot, error) {
+	var saved []LotOption
+	if s.SpotIDFormat != "" {
+		formatter, err := SpotIDFormatterByName(s.SpotIDFormat)
+		if err != nil {
+			return nil, fmt.Errorf("invalid spot ID format in state: %v", err)
+		}
+		saved = append(saved, WithSpotIDFormatter(formatter))
+	}
+	if rule := s.VehicleNumberRule; rule != nil {
+		validator, err := NewVehicleNumberValidator(VehicleNumberRule{
+			Pattern:   rule.Pattern,
+			MinLength: rule.MinLength,
+			MaxLength: rule.MaxLength,
+			NoSpaces:  rule.NoSpaces,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid vehicle number rule in state: %v", err)
+		}
+		saved = append(saved, WithVehicleNumberValidator(validator))
+	}
+	if s.IgnoreSeparators {
+		saved = append(saved, WithSeparatorInsensitiveMatching())
+	}
+	if s.AccessibleOverflow {
+		saved = append(saved, WithAccessibleOverflow())
+	}
+	if m := s.Metadata; m != nil {
+		saved = append(saved, WithMetadata(LotMetadata{Address: m.Address, Timezone: m.Timezone, Notes: m.Notes}))
+	}
+
+	lot, err := s.LotSnapshot.Restore(append(saved, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.restoreSettings(lot); err != nil {
+		return nil, err
+	}
+	lot.restoreHistory(s.History, s.SpotHistory)
+
+	return lot, nil
+}
+
+// restoreSettings applies the saved settings to the restored lot. Floors
+// are closed and restricted only now, as the vehicles parked there before
+// had to be parked again first.
+func (s *LotState) restoreSettings(lot *ParkingLot) error {
+	lot.entrance = s.Entrance
+	if s.VehicleHistoryLimit > 0 {
+		if err := lot.SetVehicleHistoryLimit(s.VehicleHistoryLimit); err != nil {
+			return fmt.Errorf("invalid vehicle history limit in state: %v", err)
+		}
+	}
+	if s.SpotHistoryLimit > 0 {
+		if err := lot.SetSpotHistoryLimit(s.SpotHistoryLimit); err != nil {
+			return fmt.Errorf("invalid spot history limit in state: %v", err)
+		}
+	}
+
+	for _, settings := range s.Spots {
+		spotID := lot.FormatSpotID(settings.Floor, settings.Row, settings.Column)
+		if settings.Label != "" {
+			if err := lot.SetSpotLabel(spotID, settings.Label); err != nil {
+				return fmt.Errorf("invalid settings of spot %s in state: %v", spotID, err)
+			}
+		}
+		for _, tag := range settings.Tags {
+			if err := lot.TagSpot(spotID, tag); err != nil {
+				return fmt.Errorf("invalid settings of spot %s in state: %v", spotID, err)
+			}
+		}
+		if settings.Charger != "" {
+			if err := lot.SetChargerState(spotID, settings.Charger); err != nil {
+				return fmt.Errorf("invalid settings of spot %s in state: %v", spotID, err)
+			}
+		}
+	}
+
+	for _, settings := range s.FloorSettings {
+		var err error
+		if settings.Allowed != nil {
+			err = lot.RestrictFloor(settings.Floor, settings.Allowed)
+		}
+		if err == nil && settings.Closed {
+			err = lot.CloseFloor(settings.Floor)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid settings of floor %d in state: %v", settings.Floor, err)
+		}
+	}
+
+	// Permits and exemptions are saved by vehicle key, which the lot's rule
+	// need not accept as a number
+	for _, key := range s.Permits {
+		if lot.permits == nil {
+			lot.permits = make(map[string]bool)
+		}
+		lot.permits[key] = true
+	}
+	for _, key := range s.QuotaExempt {
+		if lot.quotaExempt == nil {
+			lot.quotaExempt = make(map[string]bool)
+		}
+		lot.quotaExempt[key] = true
+	}
+	for _, quota := range s.Quotas {
+		if err := lot.SetQuota(quota.VehicleType, quota.Limit); err != nil {
+			return fmt.Errorf("invalid quota in state: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// restoreHistory replaces the histories replaying the parks gave the lot
+// with the saved ones
+func (p *ParkingLot) restoreHistory(histories []HistoryState, spotHistories []SpotHistoryState) {
+	if histories != nil {
+		p.vehicleHistory.clear()
+		for _, saved := range histories {
+			vehicle := &Vehicle{Type: saved.VehicleType, Number: saved.VehicleNumber}
+			history := NewVehicleHistoryWithClock(vehicle, p.GetClock())
+			for _, record := range saved.Records {
+				history.Records = append(history.Records, ParkingRecord{
+					SpotID:     record.SpotID,
+					ParkedAt:   record.ParkedAt,
+					UnparkedAt: record.UnparkedAt,
+				})
+			}
+			p.vehicleHistory.put(p.vehicleKey(saved.VehicleNumber), history)
+		}
+	}
+
+	if spotHistories != nil {
+		p.spotHistory.mu.Lock()
+		defer p.spotHistory.mu.Unlock()
+
+		p.spotHistory.entries = make(map[string][]SpotOccupancy, len(spotHistories))
+		for _, saved := range spotHistories {
+			entries := make([]SpotOccupancy, 0, len(saved.Occupancies))
+			for _, occupancy := range saved.Occupancies {
+				entries = append(entries, SpotOccupancy{
+					VehicleNumber: occupancy.VehicleNumber,
+					ParkedAt:      occupancy.ParkedAt,
+					VacatedAt:     occupancy.VacatedAt,
+				})
+			}
+			p.spotHistory.entries[saved.SpotID] = entries
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+func TestLotStateRestore(t *testing.T) {
+	validator, _ := NewVehicleNumberValidator(VehicleNumberRule{Pattern: `[A-Z0-9-]+`, MaxLength: 16})
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	lot, _ := CreateParkingLot("State Lot", 2, 2, 5,
+		WithClock(clock),
+		WithSpotIDFormatter(SignageSpotIDFormatter{}),
+		WithVehicleNumberValidator(validator),
+		WithSeparatorInsensitiveMatching(),
+		WithMetadata(LotMetadata{Address: "1 Main St", Timezone: "Asia/Kolkata"}))
+
+	// A completed stay, a vehicle still parked and settings of every kind
+	spotID, _ := lot.Park(VehicleTypeAutomobile, "KA-01")
+	clock.Advance(time.Hour)
+	_ = lot.Unpark(spotID, "KA-01")
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-02"); err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	label := lot.FormatSpotID(0, 1, 4)
+	for _, err := range []error{
+		lot.SetSpotLabel(label, "EV-7"),
+		lot.TagSpot(label, EVChargerTag),
+		lot.SetChargerState(label, ChargerOutOfOrder),
+		lot.RegisterPermit("KA-03"),
+		lot.ExemptFromQuotas("KA-04"),
+		lot.SetQuota(VehicleTypeAutomobile, 1),
+		lot.CloseFloor(1),
+		lot.RestrictFloor(0, []VehicleType{VehicleTypeAutomobile}),
+		lot.SetEntrance(0, 1, 2),
+		lot.SetVehicleHistoryLimit(5),
+	} {
+		if err != nil {
+			t.Fatalf("Failed to set up the lot: %v", err)
+		}
+	}
+
+	data, err := json.Marshal(lot.State())
+	if err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+	var state LotState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("Failed to read state: %v", err)
+	}
+	restored, err := state.Restore(WithClock(clock))
+	if err != nil {
+		t.Fatalf("Failed to restore state: %v", err)
+	}
+
+	if diff := DiffLots(lot, restored); !diff.IsEmpty() {
+		t.Errorf("Expected the restored lot to match, got %+v", diff)
+	}
+	if _, ok := restored.SpotIDFormatter().(SignageSpotIDFormatter); !ok {
+		t.Errorf("Expected the signage spot IDs kept, got %T", restored.SpotIDFormatter())
+	}
+	if restored.VehicleNumberValidator().Rule() != validator.Rule() {
+		t.Errorf("Expected the vehicle number rule kept, got %+v", restored.VehicleNumberValidator().Rule())
+	}
+	if _, found := restored.GetVehicleHistory("KA02"); !found {
+		t.Error("Expected vehicle numbers matched without separators")
+	}
+	if restored.Metadata().Address != "1 Main St" || restored.Location().String() != "Asia/Kolkata" {
+		t.Errorf("Expected the metadata kept, got %+v", restored.Metadata())
+	}
+
+	records, err := restored.GetVehicleRecords("KA-01")
+	if err != nil || len(records) != 1 || !records[0].IsComplete() || records[0].Duration() != time.Hour {
+		t.Errorf("Expected the completed stay of KA-01 kept, got %+v (%v)", records, err)
+	}
+	if occupancies, _ := restored.GetSpotHistory(spotID, 0); len(occupancies) != 2 {
+		t.Errorf("Expected both stays in %s kept, got %+v", spotID, occupancies)
+	}
+
+	spot, err := restored.FindSpotByLabel("EV-7")
+	if state, _ := spot.GetChargerState(); err != nil || state != ChargerOutOfOrder {
+		t.Errorf("Expected the labelled charger out of order, got %v (%v)", state, err)
+	}
+	if !restored.HasPermit("KA-03") || restored.GetEntrance() != (Entrance{Floor: 0, Row: 1, Column: 2}) ||
+		restored.GetVehicleHistoryLimit() != 5 {
+		t.Error("Expected the permit, entrance and history limit kept")
+	}
+	floors := restored.GetFloors()
+	if !floors[1].IsClosed() || !reflect.DeepEqual(floors[0].AllowedVehicleTypes(), []VehicleType{VehicleTypeAutomobile}) {
+		t.Error("Expected the closed and restricted floors kept")
+	}
+
+	// KA-02 still uses up the quota, which KA-04 is exempt from
+	var quotaErr *errors.QuotaExceededError
+	if _, err := restored.Park(VehicleTypeAutomobile, "KA-05"); !stderrors.As(err, &quotaErr) {
+		t.Errorf("Expected the quota kept, got %v", err)
+	}
+	if _, err := restored.Park(VehicleTypeAutomobile, "KA-04"); err != nil {
+		t.Errorf("Expected the quota exemption kept, got %v", err)
+	}
+	checkVehicleIndexes(t, restored)
+}
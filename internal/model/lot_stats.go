@@ -0,0 +1,74 @@
+package model
+
+// LotStats holds the spot counts of the whole lot and of each floor
+type LotStats struct {
+	TotalSpots     int
+	ActiveSpots    int
+	OccupiedSpots  int
+	AvailableSpots int
+
+	// Spots of each type, including inactive ones
+	SpotCounts map[SpotType]int
+	// Spots that can take another vehicle of each type
+	AvailableByType map[VehicleType]int
+	// More vehicles of each type the lot can hold
+	AvailableCapacityByType map[VehicleType]int
+	// Vehicles of each type parked
+	OccupiedCapacityByType map[VehicleType]int
+
+	// Lowest floor first
+	Floors []FloorSummary
+}
+
+// Stats returns every spot count of the lot in one pass, reading the counters
+// of each floor once so the totals agree with the floor summaries
+// Spots on closed floors are not available, nor spots on restricted floors
+// for the vehicle types they do not accept
+func (p *ParkingLot) Stats() LotStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	stats := LotStats{
+		Floors:                  make([]FloorSummary, len(p.floors)),
+		AvailableByType:         byVehicleType([maxSpotTypes]int{}),
+		AvailableCapacityByType: byVehicleType([maxSpotTypes]int{}),
+	}
+	var spots, vehicles [maxSpotTypes]int
+	for i, floor := range p.floors {
+		closed := floor.IsClosed()
+		allowed := floor.AllowedVehicleTypes()
+		snapshot := floor.counters.snapshot()
+
+		summary := floor.summarize(snapshot, closed, allowed)
+		stats.Floors[i] = summary
+		stats.TotalSpots += summary.TotalSpots
+		stats.ActiveSpots += summary.ActiveSpots
+		stats.OccupiedSpots += summary.OccupiedSpots
+		stats.AvailableSpots += summary.AvailableSpots
+
+		for t := range spots {
+			spots[t] += snapshot.spots[t]
+			vehicles[t] += snapshot.vehicles[t]
+		}
+		if !closed {
+			addCounts(stats.AvailableByType, summary.AvailableByType)
+			addCounts(stats.AvailableCapacityByType, restrictCounts(byVehicleType(snapshot.room()), allowed))
+		}
+	}
+
+	spotTypes := registeredSpotTypeList()
+	stats.SpotCounts = make(map[SpotType]int, len(spotTypes))
+	for t, spotType := range spotTypes {
+		stats.SpotCounts[spotType] = spots[t]
+	}
+	stats.OccupiedCapacityByType = byVehicleType(vehicles)
+
+	return stats
+}
+
+// addCounts adds each count of a floor into the lot's
+func addCounts(total, counts map[VehicleType]int) {
+	for vehicleType, count := range counts {
+		total[vehicleType] += count
+	}
+}
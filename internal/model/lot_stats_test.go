@@ -0,0 +1,54 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestStatsMatchesGetters(t *testing.T) {
+	lot, err := CreateParkingLot("Stats Lot", 3, 4, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	// A rack, a closed floor and an inactive spot exercise every special case
+	_ = lot.SetSpotCapacity("0-0-0", 3)
+	_ = lot.SetSpotType("1-3-4", SpotTypeInactive)
+	for i := 0; i < 9; i++ {
+		_, _ = lot.Park(VehicleTypeBicycle, fmt.Sprintf("B-%02d", i))
+		_, _ = lot.Park(VehicleTypeAutomobile, fmt.Sprintf("A-%02d", i))
+	}
+	if err := lot.CloseFloor(2); err != nil {
+		t.Fatalf("Failed to close floor: %v", err)
+	}
+
+	stats := lot.Stats()
+	checks := []struct {
+		name     string
+		got      interface{}
+		expected interface{}
+	}{
+		{"total", stats.TotalSpots, lot.GetTotalSpotCount()},
+		{"active", stats.ActiveSpots, lot.GetActiveSpotCount()},
+		{"occupied", stats.OccupiedSpots, lot.GetOccupiedSpotCount()},
+		{"available", stats.AvailableSpots, lot.GetAvailableSpotCount()},
+		{"spot counts", stats.SpotCounts, lot.GetSpotCountByType()},
+		{"available by type", stats.AvailableByType, lot.GetAvailableSpotCountByType()},
+		{"available capacity", stats.AvailableCapacityByType, lot.GetAvailableCapacityByType()},
+		{"occupied capacity", stats.OccupiedCapacityByType, lot.GetOccupiedCapacityByType()},
+		{"floors", stats.Floors, lot.GetFloorSummaries()},
+	}
+	for _, check := range checks {
+		if !reflect.DeepEqual(check.got, check.expected) {
+			t.Errorf("Stats %s: expected %v, got %v", check.name, check.expected, check.got)
+		}
+	}
+
+	if stats.OccupiedCapacityByType[VehicleTypeBicycle] != 9 {
+		t.Errorf("Expected 9 bicycles parked, got %d", stats.OccupiedCapacityByType[VehicleTypeBicycle])
+	}
+	if stats.Floors[2].AvailableSpots != 0 {
+		t.Errorf("Expected the closed floor to have no available spots, got %d", stats.Floors[2].AvailableSpots)
+	}
+}
@@ -0,0 +1,79 @@
+package model
+
+// Utilization counts how many of a group of active spots are occupied
+type Utilization struct {
+	Occupied int
+	Active   int
+}
+
+// Percent returns the occupied share of active spots, 0 when there are none
+func (u Utilization) Percent() float64 {
+	if u.Active == 0 {
+		return 0
+	}
+	return 100 * float64(u.Occupied) / float64(u.Active)
+}
+
+// add returns the sum of two utilizations
+func (u Utilization) add(other Utilization) Utilization {
+	return Utilization{Occupied: u.Occupied + other.Occupied, Active: u.Active + other.Active}
+}
+
+// FloorOccupancy is the utilization of a single floor
+type FloorOccupancy struct {
+	FloorNumber int
+	Closed      bool
+	Utilization
+}
+
+// OccupancyReport is the utilization of the lot overall, per floor and per spot type
+type OccupancyReport struct {
+	Overall Utilization
+	Floors  []FloorOccupancy
+	// ByType has an entry for every active spot type, even when the lot has none
+	ByType map[SpotType]Utilization
+}
+
+// getOccupancyByType reads the active and occupied spots of each type from the counters
+func (f *ParkingFloor) getOccupancyByType() map[SpotType]Utilization {
+	snapshot := f.counters.snapshot()
+
+	byType := make(map[SpotType]Utilization)
+	for i, spotType := range registeredSpotTypeList() {
+		if !spotType.IsActive() || snapshot.spots[i] == 0 {
+			continue
+		}
+		byType[spotType] = Utilization{Occupied: snapshot.occupied[i], Active: snapshot.spots[i]}
+	}
+
+	return byType
+}
+
+// GetOccupancyReport returns how full the lot is overall, per floor and per spot type
+func (p *ParkingLot) GetOccupancyReport() OccupancyReport {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	report := OccupancyReport{
+		Floors: make([]FloorOccupancy, 0, len(p.floors)),
+		ByType: make(map[SpotType]Utilization),
+	}
+	for _, spotType := range registeredSpotTypeList() {
+		if spotType.IsActive() {
+			report.ByType[spotType] = Utilization{}
+		}
+	}
+
+	for _, floor := range p.floors {
+		floorOccupancy := FloorOccupancy{FloorNumber: floor.FloorNumber, Closed: floor.IsClosed()}
+		for spotType, u := range floor.getOccupancyByType() {
+			floorOccupancy.Utilization = floorOccupancy.Utilization.add(u)
+			report.ByType[spotType] = report.ByType[spotType].add(u)
+		}
+
+		report.Overall = report.Overall.add(floorOccupancy.Utilization)
+		report.Floors = append(report.Floors, floorOccupancy)
+	}
+
+	return report
+}
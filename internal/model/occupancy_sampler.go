@@ -0,0 +1,177 @@
+package model
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// DefaultSampleCapacity is the number of occupancy samples kept while sampling
+const DefaultSampleCapacity = 1000
+
+// OccupancySample is the number of parked vehicles at one point in time
+type OccupancySample struct {
+	At     time.Time
+	Total  int
+	ByType map[VehicleType]int
+}
+
+// Ticker tells the sampler when to take a sample
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// realTicker adapts time.Ticker to the Ticker interface
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time { return t.ticker.C }
+func (t realTicker) Stop()               { t.ticker.Stop() }
+
+// sampleRing keeps the most recent samples up to its capacity
+type sampleRing struct {
+	mu      sync.Mutex
+	samples []OccupancySample
+	next    int
+	full    bool
+}
+
+// newSampleRing creates a ring holding up to capacity samples
+func newSampleRing(capacity int) *sampleRing {
+	return &sampleRing{samples: make([]OccupancySample, capacity)}
+}
+
+// add stores a sample, overwriting the oldest once the ring is full
+func (r *sampleRing) add(sample OccupancySample) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.samples[r.next] = sample
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// list returns the samples oldest first
+func (r *sampleRing) list() []OccupancySample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]OccupancySample(nil), r.samples[:r.next]...)
+	}
+
+	samples := make([]OccupancySample, 0, len(r.samples))
+	samples = append(samples, r.samples[r.next:]...)
+	return append(samples, r.samples[:r.next]...)
+}
+
+// occupancySampler is a running sampling goroutine
+type occupancySampler struct {
+	ring *sampleRing
+	stop chan struct{}
+	done chan struct{}
+}
+
+// sample reads the current occupancy from the lot's counters, which needs no lot lock
+func (p *ParkingLot) sample(at time.Time) OccupancySample {
+	sample := OccupancySample{At: at, ByType: make(map[VehicleType]int)}
+	for _, vehicleType := range spotVehicleTypes() {
+		count := int(p.occupancy.forType(vehicleType).current.Load())
+		sample.ByType[vehicleType] = count
+		sample.Total += count
+	}
+
+	return sample
+}
+
+// StartSampling records the occupancy every interval, keeping the last capacity samples.
+// Samples from an earlier run are discarded.
+func (p *ParkingLot) StartSampling(interval time.Duration, capacity int) error {
+	if interval <= 0 {
+		return errors.NewValidationError("interval", interval.String(), "sampling interval must be positive")
+	}
+
+	return p.startSampling(realTicker{time.NewTicker(interval)}, capacity)
+}
+
+// startSampling records a sample on every tick of the given ticker
+func (p *ParkingLot) startSampling(ticker Ticker, capacity int) error {
+	if capacity < 1 {
+		ticker.Stop()
+		return errors.NewValidationError("capacity", fmt.Sprintf("%d", capacity), "sample capacity must be at least 1")
+	}
+
+	p.samplerMu.Lock()
+	defer p.samplerMu.Unlock()
+
+	if p.sampler != nil {
+		ticker.Stop()
+		return errors.NewInvalidOperationError("start sampling", "sampling is already running")
+	}
+
+	sampler := &occupancySampler{
+		ring: newSampleRing(capacity),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	p.sampler = sampler
+	p.samples = sampler.ring
+
+	go func() {
+		defer close(sampler.done)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-sampler.stop:
+				return
+			case <-ticker.C():
+				sampler.ring.add(p.sample(p.now()))
+			}
+		}
+	}()
+
+	return nil
+}
+
+// StopSampling stops the sampler and waits for it to finish.
+// The samples taken remain available.
+func (p *ParkingLot) StopSampling() error {
+	p.samplerMu.Lock()
+	sampler := p.sampler
+	p.sampler = nil
+	p.samplerMu.Unlock()
+
+	if sampler == nil {
+		return errors.NewInvalidOperationError("stop sampling", "sampling is not running")
+	}
+
+	close(sampler.stop)
+	<-sampler.done
+	return nil
+}
+
+// IsSampling reports whether the sampler is running
+func (p *ParkingLot) IsSampling() bool {
+	p.samplerMu.Lock()
+	defer p.samplerMu.Unlock()
+	return p.sampler != nil
+}
+
+// GetOccupancySamples returns the samples of the current or last sampling run, oldest first
+func (p *ParkingLot) GetOccupancySamples() []OccupancySample {
+	p.samplerMu.Lock()
+	samples := p.samples
+	p.samplerMu.Unlock()
+
+	if samples == nil {
+		return nil
+	}
+	return samples.list()
+}
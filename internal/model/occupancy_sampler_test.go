@@ -0,0 +1,143 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeTicker delivers ticks only when the test sends them
+type fakeTicker struct {
+	ch      chan time.Time
+	stopped bool
+}
+
+func newFakeTicker() *fakeTicker {
+	return &fakeTicker{ch: make(chan time.Time)}
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.ch }
+func (t *fakeTicker) Stop()               { t.stopped = true }
+
+// waitForSample waits until the sampler has recorded the sample taken at the given time
+func waitForSample(t *testing.T, lot *ParkingLot, at time.Time) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		samples := lot.GetOccupancySamples()
+		if len(samples) > 0 && samples[len(samples)-1].At.Equal(at) {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for the sample at %v", at)
+}
+
+func TestOccupancySampling(t *testing.T) {
+	lot, err := CreateParkingLot("Sampling Lot", 1, 2, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	if samples := lot.GetOccupancySamples(); samples != nil {
+		t.Errorf("Expected no samples before sampling, got %v", samples)
+	}
+
+	// Samples are stamped with the lot's clock, not the tick
+	clock := NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	lot.SetClock(clock)
+
+	ticker := newFakeTicker()
+	if err := lot.startSampling(ticker, 3); err != nil {
+		t.Fatalf("Failed to start sampling: %v", err)
+	}
+	if err := lot.startSampling(newFakeTicker(), 3); err == nil {
+		t.Error("Expected error starting sampling twice")
+	}
+
+	// Park one more vehicle before each tick and wait for its sample
+	start := clock.Now()
+	parks := []VehicleType{VehicleTypeAutomobile, VehicleTypeBicycle, VehicleTypeAutomobile, VehicleTypeMotorcycle}
+	for i, vehicleType := range parks {
+		if _, err := lot.Park(vehicleType, string(rune('A'+i))+"-01"); err != nil {
+			t.Fatalf("Failed to park: %v", err)
+		}
+		clock.Set(start.Add(time.Duration(i) * time.Minute))
+		ticker.ch <- time.Time{}
+		waitForSample(t, lot, start.Add(time.Duration(i)*time.Minute))
+	}
+
+	if err := lot.StopSampling(); err != nil {
+		t.Fatalf("Failed to stop sampling: %v", err)
+	}
+	if !ticker.stopped {
+		t.Error("Expected ticker to be stopped")
+	}
+	if lot.IsSampling() {
+		t.Error("Expected sampling to be stopped")
+	}
+	if err := lot.StopSampling(); err == nil {
+		t.Error("Expected error stopping sampling twice")
+	}
+
+	// Only the last three samples fit, oldest first
+	samples := lot.GetOccupancySamples()
+	expected := []struct {
+		minute     int
+		total      int
+		automobile int
+		bicycle    int
+		motorcycle int
+	}{
+		{1, 2, 1, 1, 0},
+		{2, 3, 2, 1, 0},
+		{3, 4, 2, 1, 1},
+	}
+	if len(samples) != len(expected) {
+		t.Fatalf("Expected %d samples, got %d", len(expected), len(samples))
+	}
+	for i, e := range expected {
+		sample := samples[i]
+		if !sample.At.Equal(start.Add(time.Duration(e.minute) * time.Minute)) {
+			t.Errorf("Sample %d: expected minute %d, got %v", i, e.minute, sample.At)
+		}
+		if sample.Total != e.total ||
+			sample.ByType[VehicleTypeAutomobile] != e.automobile ||
+			sample.ByType[VehicleTypeBicycle] != e.bicycle ||
+			sample.ByType[VehicleTypeMotorcycle] != e.motorcycle {
+			t.Errorf("Sample %d: expected %+v, got %+v", i, e, sample)
+		}
+	}
+
+	// A new run starts with an empty series
+	if err := lot.startSampling(newFakeTicker(), 3); err != nil {
+		t.Fatalf("Failed to restart sampling: %v", err)
+	}
+	if samples := lot.GetOccupancySamples(); len(samples) != 0 {
+		t.Errorf("Expected empty series after restart, got %d samples", len(samples))
+	}
+	_ = lot.StopSampling()
+}
+
+func TestStartSamplingValidation(t *testing.T) {
+	lot, err := CreateParkingLot("Sampling Lot", 1, 2, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	if err := lot.StartSampling(0, 10); err == nil {
+		t.Error("Expected error for zero interval")
+	}
+	if err := lot.StartSampling(time.Second, 0); err == nil {
+		t.Error("Expected error for zero capacity")
+	}
+	if lot.IsSampling() {
+		t.Error("Expected sampling not to start after errors")
+	}
+
+	if err := lot.StartSampling(time.Hour, 10); err != nil {
+		t.Fatalf("Failed to start sampling: %v", err)
+	}
+	if err := lot.StopSampling(); err != nil {
+		t.Errorf("Failed to stop sampling: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUtilizationPercent(t *testing.T) {
+	tests := []struct {
+		u        Utilization
+		expected float64
+	}{
+		{Utilization{Occupied: 0, Active: 0}, 0},
+		{Utilization{Occupied: 0, Active: 4}, 0},
+		{Utilization{Occupied: 1, Active: 3}, 100.0 / 3},
+		{Utilization{Occupied: 4, Active: 4}, 100},
+	}
+
+	for _, tc := range tests {
+		if got := tc.u.Percent(); math.Abs(got-tc.expected) > 1e-9 {
+			t.Errorf("%+v: expected %f%%, got %f%%", tc.u, tc.expected, got)
+		}
+	}
+}
+
+func TestGetOccupancyReport(t *testing.T) {
+	A, B, M, X := SpotTypeAutomobile, SpotTypeBicycle, SpotTypeMotorcycle, SpotTypeInactive
+	specs := []FloorSpec{
+		{Rows: 2, Columns: 3, Layout: [][]SpotType{
+			{A, A, B},
+			{M, X, A},
+		}},
+		// A floor of pillars only has no active spots
+		{Rows: 1, Columns: 2, Layout: [][]SpotType{{X, X}}},
+		{Rows: 1, Columns: 2, Layout: [][]SpotType{{A, A}}},
+	}
+
+	lot, err := CreateParkingLotWithFloors("Occupancy Lot", 0, specs)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	parked := []struct {
+		vehicleType   VehicleType
+		vehicleNumber string
+	}{
+		{VehicleTypeAutomobile, "KA-01-HH-0001"},
+		{VehicleTypeAutomobile, "KA-01-HH-0002"},
+		{VehicleTypeAutomobile, "KA-01-HH-0003"},
+		{VehicleTypeAutomobile, "KA-01-HH-0004"},
+		{VehicleTypeBicycle, "KA-01-HH-0005"},
+	}
+	for _, v := range parked {
+		if _, err := lot.Park(v.vehicleType, v.vehicleNumber); err != nil {
+			t.Fatalf("Failed to park %s: %v", v.vehicleNumber, err)
+		}
+	}
+
+	report := lot.GetOccupancyReport()
+
+	// Floor 0 has 5 active spots with 3 automobiles and a bicycle, the fourth
+	// automobile skips the inactive floor 1 and goes to floor 2
+	if report.Overall != (Utilization{Occupied: 5, Active: 7}) {
+		t.Errorf("Expected overall 5 of 7, got %+v", report.Overall)
+	}
+
+	expectedFloors := []FloorOccupancy{
+		{FloorNumber: 0, Utilization: Utilization{Occupied: 4, Active: 5}},
+		{FloorNumber: 1, Utilization: Utilization{Occupied: 0, Active: 0}},
+		{FloorNumber: 2, Utilization: Utilization{Occupied: 1, Active: 2}},
+	}
+	if len(report.Floors) != len(expectedFloors) {
+		t.Fatalf("Expected %d floors, got %d", len(expectedFloors), len(report.Floors))
+	}
+	for i, expected := range expectedFloors {
+		if report.Floors[i] != expected {
+			t.Errorf("Floor %d: expected %+v, got %+v", i, expected, report.Floors[i])
+		}
+	}
+
+	// The all-inactive floor reports 0% rather than dividing by zero
+	if percent := report.Floors[1].Percent(); percent != 0 {
+		t.Errorf("Expected 0%% for the inactive floor, got %f", percent)
+	}
+
+	expectedTypes := map[SpotType]Utilization{
+		SpotTypeBicycle:    {Occupied: 1, Active: 1},
+		SpotTypeMotorcycle: {Occupied: 0, Active: 1},
+		SpotTypeAutomobile: {Occupied: 4, Active: 5},
+	}
+	if len(report.ByType) != len(expectedTypes) {
+		t.Errorf("Expected %d spot types, got %v", len(expectedTypes), report.ByType)
+	}
+	for spotType, expected := range expectedTypes {
+		if report.ByType[spotType] != expected {
+			t.Errorf("%s: expected %+v, got %+v", spotType, expected, report.ByType[spotType])
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// OperationStats counts the operations done on a lot since it was created,
+// or since Reset last zeroed them
+type OperationStats struct {
+	Parks   int64
+	Unparks int64
+
+	// FailedParks counts the parks that failed, and FailedParksByCode the
+	// same by the code of their error
+	FailedParks       int64
+	FailedParksByCode map[string]int64
+
+	// Searches counts the searches for a vehicle by number or pattern
+	Searches int64
+	Resets   int64
+}
+
+// operationCounters counts a lot's operations without taking its locks
+type operationCounters struct {
+	parks    atomic.Int64
+	unparks  atomic.Int64
+	searches atomic.Int64
+	resets   atomic.Int64
+
+	// failedParks maps error codes to an *atomic.Int64 of parks failing with it
+	failedParks sync.Map
+}
+
+// recordPark counts a park, failed when err is set
+func (c *operationCounters) recordPark(err error) {
+	if err == nil {
+		c.parks.Add(1)
+		return
+	}
+	counter, _ := c.failedParks.LoadOrStore(errors.CodeOf(err), new(atomic.Int64))
+	counter.(*atomic.Int64).Add(1)
+}
+
+// snapshot returns the counts so far
+func (c *operationCounters) snapshot() OperationStats {
+	stats := OperationStats{
+		Parks:             c.parks.Load(),
+		Unparks:           c.unparks.Load(),
+		Searches:          c.searches.Load(),
+		Resets:            c.resets.Load(),
+		FailedParksByCode: make(map[string]int64),
+	}
+	c.failedParks.Range(func(code, counter any) bool {
+		count := counter.(*atomic.Int64).Load()
+		stats.FailedParksByCode[code.(string)] = count
+		stats.FailedParks += count
+		return true
+	})
+	return stats
+}
+
+// zero sets every count back to zero
+func (c *operationCounters) zero() {
+	c.parks.Store(0)
+	c.unparks.Store(0)
+	c.searches.Store(0)
+	c.resets.Store(0)
+	c.failedParks.Clear()
+}
+
+// OperationStats returns the counts of the operations done on the lot
+func (p *ParkingLot) OperationStats() OperationStats {
+	return p.ops.snapshot()
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	stderrors "errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+func TestOperationStats(t *testing.T) {
+	// Two automobile spots on the one floor
+	lot, _ := CreateParkingLot("Stats Test Lot", 1, 1, 4)
+
+	first, _ := lot.Park(VehicleTypeAutomobile, "KA-01-HH-0001")
+	lot.Park(VehicleTypeAutomobile, "KA-01-HH-0002")
+	lot.Park(VehicleTypeAutomobile, "KA-01-HH-0001") // already parked
+	lot.Park(VehicleTypeAutomobile, "KA-01-HH-0003") // no space
+	lot.Park(VehicleTypeAutomobile, "KA-01-HH-0004") // no space
+	lot.Unpark(first, "KA-01-HH-0001")
+	lot.Unpark(first, "KA-01-HH-0001") // not parked
+
+	// A park failing part way through counts as failed with its code
+	lot.faults = func(step string) error {
+		if step == "park.index" {
+			return stderrors.New("index lost")
+		}
+		return nil
+	}
+	lot.Park(VehicleTypeAutomobile, "KA-01-HH-0005")
+	lot.faults = nil
+
+	lot.SearchVehicle("KA-01-HH-0002")
+	lot.SearchVehicle("KA-01-HH-9999")
+	lot.FindVehiclesMatching("KA-*", 10)
+	lot.Reset()
+
+	expected := OperationStats{
+		Parks:       2,
+		Unparks:     1,
+		FailedParks: 4,
+		FailedParksByCode: map[string]int64{
+			errors.CodeVehicleAlreadyParked: 1,
+			errors.CodeNoSpaceAvailable:     2,
+			errors.CodeInternalError:        1,
+		},
+		Searches: 3,
+		Resets:   1,
+	}
+	if stats := lot.OperationStats(); !reflect.DeepEqual(stats, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+
+	// A reset asked to zero the counts leaves none, itself included
+	lot.ResetWithOptions(ResetOptions{ZeroOperationStats: true})
+	if stats := lot.OperationStats(); !reflect.DeepEqual(stats, OperationStats{FailedParksByCode: map[string]int64{}}) {
+		t.Errorf("Expected every count zeroed, got %+v", stats)
+	}
+}
+
+func TestOperationStatsConcurrent(t *testing.T) {
+	lot, _ := CreateParkingLot("Concurrent Stats Lot", 1, 10, 10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				lot.SearchVehicle("KA-01-HH-0001")
+				lot.Park(VehicleTypeAutomobile, "KA-01-HH-0001")
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := lot.OperationStats()
+	if stats.Searches != 1000 || stats.Parks != 1 || stats.FailedParks != 999 {
+		t.Errorf("Expected 1000 searches, 1 park and 999 failed, got %+v", stats)
+	}
+}
+
+func TestRestoredLotStartsCounting(t *testing.T) {
+	lot, _ := CreateParkingLot("Restore Stats Lot", 1, 2, 5)
+	lot.Park(VehicleTypeAutomobile, "KA-01-HH-0001")
+
+	restored, err := lot.Snapshot().Restore()
+	if err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if stats := restored.OperationStats(); stats.Parks != 0 {
+		t.Errorf("Expected restored vehicles not counted as parks, got %+v", stats)
+	}
+}
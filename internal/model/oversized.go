@@ -0,0 +1,151 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// availableSpanWhere returns the first run of empty spots side by side in
+// one row, in row-by-row order, that an oversized vehicle of the type takes
+// and that keep accepts, any such spots when keep is nil, or nil
+func (f *ParkingFloor) availableSpanWhere(vehicleType VehicleType, keep func(*ParkingSpot) bool) []*ParkingSpot {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	n := vehicleType.SpotsNeeded()
+	if f.closed || !f.acceptsLocked(vehicleType) || n < 1 {
+		return nil
+	}
+
+	// Only the spots of the span found are made
+	var blank ParkingSpot
+	var span [][2]int
+	lastRow, lastColumn := -1, -1
+	for _, position := range f.counters.freeSpots.find(vehicleType.BaseType(), -1) {
+		row, column := position[0], position[1]
+		spot := f.peekSpotLocked(row, column, &blank)
+
+		// A spot that is skipped breaks the run, as does a gap or a new row
+		if spot.IsOccupied() || (keep != nil && !keep(spot)) {
+			span = span[:0]
+			continue
+		}
+		if row != lastRow || column != lastColumn+1 {
+			span = span[:0]
+		}
+
+		span = append(span, position)
+		lastRow, lastColumn = row, column
+		if len(span) == n {
+			spots := make([]*ParkingSpot, n)
+			for i, position := range span {
+				spots[i] = f.spotAtLocked(position[0], position[1])
+			}
+			return spots
+		}
+	}
+
+	return nil
+}
+
+// firstAvailableSpanLocked returns the spots a vehicle of the type takes
+// first, of the lowest floor that has them: one spot, or for an oversized
+// type the first run of adjacent spots in a row. Each spot is one keep
+// accepts, any when keep is nil; p.mu must be held.
+func (p *ParkingLot) firstAvailableSpanLocked(vehicleType VehicleType, keep func(*ParkingSpot) bool) []*ParkingSpot {
+	if !vehicleType.IsOversized() {
+		if spot := p.firstAvailableSpotLocked(vehicleType, keep); spot != nil {
+			return []*ParkingSpot{spot}
+		}
+		return nil
+	}
+
+	for _, floor := range p.floors {
+		if span := floor.availableSpanWhere(vehicleType, keep); span != nil {
+			return span
+		}
+	}
+
+	return nil
+}
+
+// spanFromLocked returns the anchor spot and the spots to its right that a
+// vehicle of the type takes when parked there; p.mu must be held
+func (p *ParkingLot) spanFromLocked(anchor *ParkingSpot, vehicleType VehicleType) ([]*ParkingSpot, error) {
+	span := []*ParkingSpot{anchor}
+	if !vehicleType.IsOversized() {
+		return span, nil
+	}
+
+	floor, err := p.floorLocked(anchor.Floor)
+	if err != nil {
+		return nil, err
+	}
+
+	needed := vehicleType.SpotsNeeded()
+	for column := anchor.Column + 1; column < anchor.Column+needed; column++ {
+		spot, err := floor.GetSpot(anchor.Row, column)
+		if err != nil {
+			return nil, errors.NewInvalidOperationError("parkAt",
+				fmt.Sprintf("a %s needs %d adjacent spots and the row ends after %d from spot %s",
+					GetVehicleTypeDisplay(vehicleType), needed, len(span), anchor.GetSpotID()))
+		}
+		span = append(span, spot)
+	}
+
+	return span, nil
+}
+
+// occupySpan occupies every spot of the span for the vehicle, or none: a
+// spot that cannot be taken vacates the ones taken before it. The spots of
+// an oversized vehicle must be empty, not just have room.
+func occupySpan(span []*ParkingSpot, vehicleNumber string) error {
+	for i, spot := range span {
+		err := spot.Occupy(vehicleNumber)
+		if err == nil && len(span) > 1 && len(spot.GetVehicleNumbers()) > 1 {
+			_ = spot.Vacate(vehicleNumber)
+			err = errors.NewSpotAlreadyOccupiedError(spot.GetSpotID())
+		}
+		if err != nil {
+			for _, taken := range span[:i] {
+				_ = taken.Vacate(vehicleNumber)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// vacateSpan vacates every spot of the span for the vehicle, or none: a spot
+// that cannot be vacated occupies the ones vacated before it again
+func vacateSpan(span []*ParkingSpot, vehicleNumber string) error {
+	for i, spot := range span {
+		if err := spot.Vacate(vehicleNumber); err != nil {
+			for _, vacated := range span[:i] {
+				_ = vacated.Occupy(vehicleNumber)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// spanSpotIDs returns the IDs of the spots of a span
+func spanSpotIDs(span []*ParkingSpot) []string {
+	ids := make([]string, len(span))
+	for i, spot := range span {
+		ids[i] = spot.GetSpotID()
+	}
+	return ids
+}
+
+// GetSpotIDs returns the IDs of every spot the vehicle takes, its SpotID first
+func (v ParkedVehicle) GetSpotIDs() []string {
+	if len(v.SpotIDs) > 0 {
+		return v.SpotIDs
+	}
+	return []string{v.SpotID}
+}
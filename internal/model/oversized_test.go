@@ -0,0 +1,175 @@
+package model
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// newNarrowLot returns a lot whose only two adjacent automobile spots are
+// 0-1-1 and 0-1-2
+func newNarrowLot(t *testing.T) *ParkingLot {
+	t.Helper()
+
+	A, M, X := SpotTypeAutomobile, SpotTypeMotorcycle, SpotTypeInactive
+	specs := []FloorSpec{
+		{Rows: 2, Columns: 3, Layout: [][]SpotType{
+			{A, X, A},
+			{M, A, A},
+		}},
+	}
+
+	lot, err := CreateParkingLotWithFloors("Narrow Lot", 0, specs)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	lot.SetClock(NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)))
+	return lot
+}
+
+// rowState returns a row of a floor's display grid as one string
+func rowState(t *testing.T, lot *ParkingLot, floorNum, row int) string {
+	t.Helper()
+
+	floor, err := lot.GetFloor(floorNum)
+	if err != nil {
+		t.Fatalf("Failed to get floor %d: %v", floorNum, err)
+	}
+	return fmt.Sprint(floor.GetDisplayState()[row])
+}
+
+func TestParkOversizedVehicle(t *testing.T) {
+	lot := newNarrowLot(t)
+
+	detail, err := lot.ParkDetailed(VehicleTypeMinibus, "KA-01-MB-0007")
+	if err != nil {
+		t.Fatalf("Failed to park the minibus: %v", err)
+	}
+	if detail.SpotID != "0-1-1" || fmt.Sprint(detail.SpotIDs) != "[0-1-1 0-1-2]" {
+		t.Errorf("Expected the minibus at 0-1-1 across [0-1-1 0-1-2], got %s across %v",
+			detail.SpotID, detail.SpotIDs)
+	}
+	checkVehicleIndexes(t, lot)
+
+	// The vehicle is found at its anchor spot and counted once
+	spot, err := lot.FindVehicle("KA-01-MB-0007")
+	if err != nil || spot.GetSpotID() != "0-1-1" {
+		t.Errorf("Expected to find the minibus at 0-1-1, got %v (%v)", spot, err)
+	}
+	if count := lot.GetParkedVehicleCount(); count != 1 {
+		t.Errorf("Expected 1 parked vehicle, got %d", count)
+	}
+	if count := lot.GetOccupiedSpotCount(); count != 2 {
+		t.Errorf("Expected 2 occupied spots, got %d", count)
+	}
+	if peak := lot.GetPeakOccupancy().Overall.Count; peak != 1 {
+		t.Errorf("Expected an overall peak of 1, got %d", peak)
+	}
+	if row := rowState(t, lot, 0, 1); row != "[M a a]" {
+		t.Errorf("Expected both spots of the minibus shown as a, got %s", row)
+	}
+
+	// No other pair is free, though single automobile spots are
+	_, err = lot.Park(VehicleTypeMinibus, "KA-02-MB-0008")
+	if !stderrors.Is(err, errors.ErrNoSpaceAvailable) {
+		t.Errorf("Expected no space for a second minibus, got %v", err)
+	}
+	if spotID, err := lot.Park(VehicleTypeAutomobile, "KA-03-CA-0001"); err != nil || spotID != "0-0-0" {
+		t.Errorf("Expected the automobile at 0-0-0, got %s (%v)", spotID, err)
+	}
+
+	// Unparking from either spot frees both
+	if err := lot.Unpark("0-1-2", "KA-01-MB-0007"); err != nil {
+		t.Fatalf("Failed to unpark the minibus: %v", err)
+	}
+	if row := rowState(t, lot, 0, 1); row != "[M A A]" {
+		t.Errorf("Expected both spots free again, got %s", row)
+	}
+	if count := lot.GetParkedVehicleCount(); count != 1 {
+		t.Errorf("Expected only the automobile left, got %d vehicles", count)
+	}
+	checkVehicleIndexes(t, lot)
+}
+
+func TestParkOversizedVehicleAt(t *testing.T) {
+	lot := newNarrowLot(t)
+
+	// The span runs rightward from the given spot, and must fit in the row
+	for _, spotID := range []string{"0-0-0", "0-0-2", "0-1-2"} {
+		if _, err := lot.ParkAt(spotID, VehicleTypeMinibus, "KA-01-MB-0007"); err == nil {
+			t.Errorf("Expected no minibus to fit from %s", spotID)
+		}
+	}
+	if _, err := lot.ParkAt("0-1-0", VehicleTypeMinibus, "KA-01-MB-0007"); err == nil {
+		t.Error("Expected a motorcycle spot to be refused")
+	}
+
+	// A neighbour that is taken undoes the spot occupied before it
+	if _, err := lot.ParkAt("0-1-2", VehicleTypeAutomobile, "KA-03-CA-0001"); err != nil {
+		t.Fatalf("Failed to park the automobile: %v", err)
+	}
+	_, err := lot.ParkAt("0-1-1", VehicleTypeMinibus, "KA-01-MB-0007")
+	if !stderrors.Is(err, errors.ErrSpotAlreadyOccupied) {
+		t.Errorf("Expected the taken neighbour to be reported, got %v", err)
+	}
+	if row := rowState(t, lot, 0, 1); row != "[M A a]" {
+		t.Errorf("Expected 0-1-1 left free, got %s", row)
+	}
+	checkVehicleIndexes(t, lot)
+
+	if err := lot.Unpark("0-1-2", "KA-03-CA-0001"); err != nil {
+		t.Fatalf("Failed to unpark the automobile: %v", err)
+	}
+	detail, err := lot.ParkAt("0-1-1", VehicleTypeMinibus, "KA-01-MB-0007")
+	if err != nil || fmt.Sprint(detail.SpotIDs) != "[0-1-1 0-1-2]" {
+		t.Errorf("Expected the minibus across [0-1-1 0-1-2], got %+v (%v)", detail, err)
+	}
+}
+
+func TestOversizedVehicleRollsBack(t *testing.T) {
+	injected := errors.NewInvalidOperationError("park", "injected failure")
+
+	for _, step := range []string{"park.occupy", "park.index"} {
+		lot := newNarrowLot(t)
+		before := lotState(t, lot, "KA-01-MB-0007")
+
+		lot.faults = func(name string) error {
+			if name == step {
+				return injected
+			}
+			return nil
+		}
+		if _, err := lot.Park(VehicleTypeMinibus, "KA-01-MB-0007"); err != injected {
+			t.Errorf("%s: expected the injected error, got %v", step, err)
+		}
+		lot.faults = nil
+
+		if after := lotState(t, lot, "KA-01-MB-0007"); after != before {
+			t.Errorf("%s: lot changed\nbefore %s\nafter  %s", step, before, after)
+		}
+	}
+
+	// An unpark that fails after vacating occupies both spots again
+	lot := newNarrowLot(t)
+	if _, err := lot.Park(VehicleTypeMinibus, "KA-01-MB-0007"); err != nil {
+		t.Fatalf("Failed to park the minibus: %v", err)
+	}
+	lot.faults = func(name string) error {
+		if name == "unpark.vacate" {
+			return injected
+		}
+		return nil
+	}
+	if err := lot.Unpark("0-1-1", "KA-01-MB-0007"); err != injected {
+		t.Errorf("Expected the injected error, got %v", err)
+	}
+	lot.faults = nil
+
+	if row := rowState(t, lot, 0, 1); row != "[M a a]" {
+		t.Errorf("Expected the minibus still across both spots, got %s", row)
+	}
+	checkVehicleIndexes(t, lot)
+}
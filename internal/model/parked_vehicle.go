@@ -0,0 +1,106 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// ParkedVehicle is a vehicle currently parked in the lot
+type ParkedVehicle struct {
+	VehicleNumber string
+	VehicleType   VehicleType
+	SpotID        string
+	ParkedAt      time.Time
+
+	// SpotIDs lists the spots of an oversized vehicle from SpotID, the
+	// leftmost, rightward; nil for a vehicle in one spot
+	SpotIDs []string
+
+	// Reserved is set for a reserved park or a vehicle exempt from quotas,
+	// which no quota counts
+	Reserved bool
+}
+
+// DurationAt returns how long the vehicle has been parked as of now
+func (v ParkedVehicle) DurationAt(now time.Time) time.Duration {
+	return now.Sub(v.ParkedAt)
+}
+
+// VehicleFilter selects parked vehicles. Zero fields match every vehicle.
+type VehicleFilter struct {
+	// VehicleType keeps only vehicles of this type when set
+	VehicleType VehicleType
+
+	// Floor keeps only vehicles parked on this floor when set
+	Floor *int
+}
+
+// Matches reports whether the parked vehicle passes the filter, reading its
+// spot ID in the dash format
+func (f VehicleFilter) Matches(vehicle ParkedVehicle) bool {
+	return f.matches(vehicle, DashSpotIDFormatter{})
+}
+
+// matches reports whether the parked vehicle passes the filter, reading its
+// spot ID with spotIDs
+func (f VehicleFilter) matches(vehicle ParkedVehicle, spotIDs SpotIDFormatter) bool {
+	if f.VehicleType != "" && vehicle.VehicleType != f.VehicleType {
+		return false
+	}
+
+	if f.Floor != nil {
+		floor, _, _, err := spotIDs.Parse(vehicle.SpotID)
+		if err != nil || floor != *f.Floor {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ListParkedVehicles returns the parked vehicles matching the filter,
+// longest parked first with ties broken by vehicle number
+func (p *ParkingLot) ListParkedVehicles(filter VehicleFilter) []ParkedVehicle {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var vehicles []ParkedVehicle
+	p.parkedVehicles.each(func(_ string, vehicle ParkedVehicle) bool {
+		if filter.matches(vehicle, p.SpotIDFormatter()) {
+			vehicles = append(vehicles, vehicle)
+		}
+		return true
+	})
+
+	sort.Slice(vehicles, func(i, j int) bool {
+		if !vehicles[i].ParkedAt.Equal(vehicles[j].ParkedAt) {
+			return vehicles[i].ParkedAt.Before(vehicles[j].ParkedAt)
+		}
+		return vehicles[i].VehicleNumber < vehicles[j].VehicleNumber
+	})
+
+	return vehicles
+}
+
+// GetParkedVehicleDetails returns every parked vehicle, longest parked first
+func (p *ParkingLot) GetParkedVehicleDetails() []ParkedVehicle {
+	return p.ListParkedVehicles(VehicleFilter{})
+}
+
+// LongestParked returns up to n parked vehicles that have been parked the
+// longest, longest first. An empty vehicle type includes every type.
+func (p *ParkingLot) LongestParked(vehicleType VehicleType, n int) ([]ParkedVehicle, error) {
+	if n < 1 {
+		return nil, errors.NewValidationError("n", fmt.Sprintf("%d", n), "count must be at least 1")
+	}
+
+	vehicles := p.ListParkedVehicles(VehicleFilter{VehicleType: vehicleType})
+	if len(vehicles) > n {
+		vehicles = vehicles[:n]
+	}
+
+	return vehicles, nil
+}
@@ -0,0 +1,216 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestListParkedVehicles(t *testing.T) {
+	A, B := SpotTypeAutomobile, SpotTypeBicycle
+	specs := []FloorSpec{
+		{Rows: 1, Columns: 2, Layout: [][]SpotType{{A, B}}},
+		{Rows: 1, Columns: 2, Layout: [][]SpotType{{A, A}}},
+	}
+
+	lot, err := CreateParkingLotWithFloors("Vehicles Lot", 0, specs)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	clock := NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	lot.SetClock(clock)
+
+	// Park in an order that differs from vehicle number order
+	parked := []struct {
+		vehicleType   VehicleType
+		vehicleNumber string
+		spotID        string
+	}{
+		{VehicleTypeAutomobile, "ZZ-01", "0-0-0"},
+		{VehicleTypeBicycle, "BB-01", "0-0-1"},
+		{VehicleTypeAutomobile, "AA-01", "1-0-0"},
+		{VehicleTypeAutomobile, "MM-01", "1-0-1"},
+	}
+	for _, v := range parked {
+		spotID, err := lot.Park(v.vehicleType, v.vehicleNumber)
+		if err != nil {
+			t.Fatalf("Failed to park %s: %v", v.vehicleNumber, err)
+		}
+		if spotID != v.spotID {
+			t.Fatalf("Expected %s at %s, got %s", v.vehicleNumber, v.spotID, spotID)
+		}
+		clock.Advance(time.Minute)
+	}
+
+	floor0, floor1 := 0, 1
+	tests := []struct {
+		name     string
+		filter   VehicleFilter
+		expected []string
+	}{
+		{"no filter", VehicleFilter{}, []string{"ZZ-01", "BB-01", "AA-01", "MM-01"}},
+		{"type", VehicleFilter{VehicleType: VehicleTypeAutomobile}, []string{"ZZ-01", "AA-01", "MM-01"}},
+		{"floor", VehicleFilter{Floor: &floor1}, []string{"AA-01", "MM-01"}},
+		{"type and floor", VehicleFilter{VehicleType: VehicleTypeAutomobile, Floor: &floor0}, []string{"ZZ-01"}},
+		{"no match", VehicleFilter{VehicleType: VehicleTypeBicycle, Floor: &floor1}, nil},
+	}
+
+	for _, tc := range tests {
+		vehicles := lot.ListParkedVehicles(tc.filter)
+		if len(vehicles) != len(tc.expected) {
+			t.Errorf("%s: expected %d vehicles, got %d", tc.name, len(tc.expected), len(vehicles))
+			continue
+		}
+		for i, vehicle := range vehicles {
+			if vehicle.VehicleNumber != tc.expected[i] {
+				t.Errorf("%s: expected %s at position %d, got %s", tc.name, tc.expected[i], i, vehicle.VehicleNumber)
+			}
+		}
+	}
+
+	// Type and parked time are recorded with the spot
+	vehicles := lot.ListParkedVehicles(VehicleFilter{VehicleType: VehicleTypeBicycle})
+	if len(vehicles) != 1 {
+		t.Fatalf("Expected 1 bicycle, got %d", len(vehicles))
+	}
+	history, _ := lot.GetVehicleHistory("BB-01")
+	if vehicles[0].SpotID != "0-0-1" || !vehicles[0].ParkedAt.Equal(history.GetLastParkingRecord().ParkedAt) {
+		t.Errorf("Expected BB-01 at 0-0-1 parked at %v, got %+v", history.GetLastParkingRecord().ParkedAt, vehicles[0])
+	}
+
+	// Unparked vehicles are no longer listed
+	if err := lot.Unpark("0-0-0", "ZZ-01"); err != nil {
+		t.Fatalf("Failed to unpark: %v", err)
+	}
+	if vehicles := lot.ListParkedVehicles(VehicleFilter{Floor: &floor0}); len(vehicles) != 1 ||
+		vehicles[0].VehicleNumber != "BB-01" {
+		t.Errorf("Expected only BB-01 on floor 0, got %+v", vehicles)
+	}
+}
+
+func TestGetParkedVehicleDetails(t *testing.T) {
+	lot, err := CreateParkingLot("Details Lot", 1, 2, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	clock := NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	lot.SetClock(clock)
+
+	if details := lot.GetParkedVehicleDetails(); len(details) != 0 {
+		t.Errorf("Expected no parked vehicles, got %+v", details)
+	}
+
+	for _, number := range []string{"ZZ-01", "AA-01"} {
+		if _, err := lot.Park(VehicleTypeAutomobile, number); err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+		clock.Advance(time.Minute)
+	}
+
+	details := lot.GetParkedVehicleDetails()
+	if len(details) != 2 || details[0].VehicleNumber != "ZZ-01" || details[1].VehicleNumber != "AA-01" {
+		t.Fatalf("Expected ZZ-01 then AA-01, got %+v", details)
+	}
+
+	// Durations follow the lot's clock exactly
+	if d := details[0].DurationAt(clock.Now()); d != 2*time.Minute {
+		t.Errorf("Expected ZZ-01 parked for 2m, got %v", d)
+	}
+	if d := details[1].DurationAt(clock.Now()); d != time.Minute {
+		t.Errorf("Expected AA-01 parked for 1m, got %v", d)
+	}
+
+	// Each detail joins the spot with the active parking record
+	for _, detail := range details {
+		history, _ := lot.GetVehicleHistory(detail.VehicleNumber)
+		record := history.GetLastParkingRecord()
+		if detail.SpotID != record.SpotID || !detail.ParkedAt.Equal(record.ParkedAt) ||
+			detail.VehicleType != VehicleTypeAutomobile {
+			t.Errorf("Expected %s to match its parking record %+v, got %+v", detail.VehicleNumber, record, detail)
+		}
+	}
+}
+
+func TestGetAllParkedVehiclesSorted(t *testing.T) {
+	lot, err := CreateParkingLot("Sorted Lot", 1, 2, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	spots := make(map[string]string)
+	for _, number := range []string{"ZZ-01", "AA-01", "MM-01"} {
+		spotID, err := lot.Park(VehicleTypeAutomobile, number)
+		if err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+		spots[number] = spotID
+	}
+
+	vehicles := lot.GetAllParkedVehicles()
+	expected := []string{"AA-01", "MM-01", "ZZ-01"}
+	if len(vehicles) != len(expected) {
+		t.Fatalf("Expected %d vehicles, got %+v", len(expected), vehicles)
+	}
+	for i, number := range expected {
+		if vehicles[i].VehicleNumber != number || vehicles[i].SpotID != spots[number] {
+			t.Errorf("Position %d: expected %s at %s, got %+v", i, number, spots[number], vehicles[i])
+		}
+	}
+}
+
+func TestLongestParked(t *testing.T) {
+	lot, err := CreateParkingLot("Longest Lot", 1, 2, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	// Store vehicles with crafted parked times so durations are exact
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	crafted := []ParkedVehicle{
+		{VehicleNumber: "CC-01", VehicleType: VehicleTypeAutomobile, SpotID: "0-0-0", ParkedAt: now.Add(-30 * time.Minute)},
+		{VehicleNumber: "AA-01", VehicleType: VehicleTypeBicycle, SpotID: "0-0-1", ParkedAt: now.Add(-3 * time.Hour)},
+		{VehicleNumber: "BB-01", VehicleType: VehicleTypeAutomobile, SpotID: "0-1-0", ParkedAt: now.Add(-2 * time.Hour)},
+		{VehicleNumber: "DD-01", VehicleType: VehicleTypeAutomobile, SpotID: "0-1-1", ParkedAt: now.Add(-5 * time.Hour)},
+	}
+	for _, v := range crafted {
+		lot.parkedVehicles.put(v.VehicleNumber, v)
+	}
+
+	tests := []struct {
+		name        string
+		vehicleType VehicleType
+		n           int
+		expected    []string
+	}{
+		{"all", "", 10, []string{"DD-01", "AA-01", "BB-01", "CC-01"}},
+		{"top two", "", 2, []string{"DD-01", "AA-01"}},
+		{"type", VehicleTypeAutomobile, 10, []string{"DD-01", "BB-01", "CC-01"}},
+		{"type truncated", VehicleTypeAutomobile, 1, []string{"DD-01"}},
+		{"no match", VehicleTypeMotorcycle, 3, nil},
+	}
+
+	for _, tc := range tests {
+		vehicles, err := lot.LongestParked(tc.vehicleType, tc.n)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if len(vehicles) != len(tc.expected) {
+			t.Errorf("%s: expected %d vehicles, got %d", tc.name, len(tc.expected), len(vehicles))
+			continue
+		}
+		for i, vehicle := range vehicles {
+			if vehicle.VehicleNumber != tc.expected[i] {
+				t.Errorf("%s: expected %s at position %d, got %s", tc.name, tc.expected[i], i, vehicle.VehicleNumber)
+			}
+		}
+	}
+
+	vehicles, _ := lot.LongestParked("", 1)
+	if d := vehicles[0].DurationAt(now); d != 5*time.Hour {
+		t.Errorf("Expected DD-01 parked for 5h, got %v", d)
+	}
+
+	if _, err := lot.LongestParked("", 0); err == nil {
+		t.Error("Expected error for n = 0")
+	}
+}
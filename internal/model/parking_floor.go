@@ -2,34 +2,49 @@ package model
 
 import (
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
 )
 
 // ParkingFloor represents a single floor in the parking lot
 type ParkingFloor struct {
-	// Floor number (0-indexed)
+	// Floor number (0-indexed, negative for basements)
 	FloorNumber int
 
-	// Grid of parking spots
-	spots [][]*ParkingSpot
+	// Grid of parking spots, each made on first use. Until then its cell is
+	// nil and stands for an empty spot of the type in types, with no label,
+	// tags or charger, which the counters count all the same. A floor of a
+	// million spots costs little until its spots are used.
+	spots [][]atomic.Pointer[ParkingSpot]
+
+	// Type of each spot not made yet, by its position in the type registry
+	types [][]uint8
 
 	// Dimensions
 	numRows    int
 	numColumns int
 
+	// A closed floor accepts no new vehicles, but parked ones can still leave
+	closed bool
+
+	// The vehicle types a restricted floor accepts, every type when nil
+	allowed []VehicleType
+
+	// Running spot counts, kept up to date by the spots themselves
+	counters *floorCounters
+
+	// Writes the IDs of the floor's spots, the dash format when nil
+	spotIDs SpotIDFormatter
+
 	// Read-write mutex for thread safety
 	mu sync.RWMutex
 }
 
 // NewParkingFloor creates a new parking floor with the given spots
 func NewParkingFloor(floorNumber int, spots [][]*ParkingSpot) (*ParkingFloor, error) {
-	if floorNumber <= 0 {
-		return nil, errors.NewValidationError("floorNumber",
-			fmt.Sprintf("%d", floorNumber), "floor number cannot be less than 1")
-	}
-
 	if len(spots) == 0 {
 		return nil, errors.NewValidationError("spots",
 			"[]", "floor must have at least one row")
@@ -52,12 +67,42 @@ func NewParkingFloor(floorNumber int, spots [][]*ParkingSpot) (*ParkingFloor, er
 		}
 	}
 
-	return &ParkingFloor{
+	floor := newParkingFloor(floorNumber, numRows, numColumns)
+	for r, row := range spots {
+		for c, spot := range row {
+			floor.types[r][c] = uint8(spotTypeIndex(spot.Type))
+			floor.spots[r][c].Store(spot)
+			spot.attach(floor.counters)
+		}
+	}
+
+	return floor, nil
+}
+
+// newParkingFloor returns a floor of the given size with no spot made and
+// nothing counted yet
+func newParkingFloor(floorNumber, rows, columns int) *ParkingFloor {
+	floor := &ParkingFloor{
 		FloorNumber: floorNumber,
-		spots:       spots,
-		numRows:     numRows,
-		numColumns:  numColumns,
-	}, nil
+		numRows:     rows,
+		numColumns:  columns,
+		counters:    newFloorCounters(rows, columns),
+	}
+	floor.spots, floor.types = makeSpotGrid(rows, columns)
+
+	return floor
+}
+
+// makeSpotGrid returns a grid of the given size with no spot made yet
+func makeSpotGrid(rows, columns int) ([][]atomic.Pointer[ParkingSpot], [][]uint8) {
+	spots := make([][]atomic.Pointer[ParkingSpot], rows)
+	types := make([][]uint8, rows)
+	for r := 0; r < rows; r++ {
+		spots[r] = make([]atomic.Pointer[ParkingSpot], columns)
+		types[r] = make([]uint8, columns)
+	}
+
+	return spots, types
 }
 
 // CreateParkingFloor creates a new parking floor with the given dimensions and spot types
@@ -90,45 +135,184 @@ func CreateParkingFloor(floorNumber int, rows, columns int, spotTypes [][]SpotTy
 		}
 	}
 
-	// Create spots
-	spots := make([][]*ParkingSpot, rows)
+	// Record the spot types, leaving the spots to be made on first use
+	floor := newParkingFloor(floorNumber, rows, columns)
+	valid := make(map[SpotType]bool)
 	for r := 0; r < rows; r++ {
-		spots[r] = make([]*ParkingSpot, columns)
 		for c := 0; c < columns; c++ {
 			var spotType SpotType
 			if spotTypes != nil {
 				spotType = spotTypes[r][c]
 			} else {
 				// Default distribution if spot types not provided
-				switch {
-				case c < columns/4:
-					spotType = SpotTypeBicycle
-				case c < columns/2:
-					spotType = SpotTypeMotorcycle
-				default:
-					spotType = SpotTypeAutomobile
-				}
-				// Make some spots inactive (e.g., for pillars)
-				if (r%7 == 0 && c%7 == 0) || (r%7 == 0 && c%7 == 1) {
-					spotType = SpotTypeInactive
+				spotType = defaultSpotType(r, c, columns, DefaultPillarPattern())
+			}
+
+			if !valid[spotType] {
+				if _, err := ParseSpotType(string(spotType)); err != nil {
+					return nil, fmt.Errorf("failed to create spot at floor %d, row %d, column %d: %w",
+						floorNumber, r, c, errors.NewInvalidSpotTypeError(string(spotType)))
 				}
+				valid[spotType] = true
 			}
+			floor.types[r][c] = uint8(spotTypeIndex(spotType))
+		}
+	}
+	floor.countUnmadeSpotsLocked()
+
+	return floor, nil
+}
+
+// spotAtLocked returns the spot at row and column, making it on first use;
+// f.mu must be held, for reading at least
+func (f *ParkingFloor) spotAtLocked(row, column int) *ParkingSpot {
+	cell := &f.spots[row][column]
+	if spot := cell.Load(); spot != nil {
+		return spot
+	}
 
-			spot, err := NewParkingSpot(spotType, floorNumber, r, c)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create spot at floor %d, row %d, column %d: %w",
-					floorNumber, r, c, err)
+	// The counters already count the spot, so it is not attached again
+	spot := &ParkingSpot{
+		Type:     f.unmadeTypeLocked(row, column),
+		Floor:    f.FloorNumber,
+		Row:      row,
+		Column:   column,
+		capacity: 1,
+		counters: f.counters,
+		ids:      f.spotIDs,
+	}
+	if !cell.CompareAndSwap(nil, spot) {
+		return cell.Load()
+	}
+
+	return spot
+}
+
+// peekSpotLocked returns the spot at row and column when it is made, or else
+// blank, filled in as the empty spot that stands for it, so that a scan can
+// read spots without making them. The caller must neither change blank nor
+// keep it past the next call; f.mu must be held, for reading at least.
+func (f *ParkingFloor) peekSpotLocked(row, column int, blank *ParkingSpot) *ParkingSpot {
+	if spot := f.spots[row][column].Load(); spot != nil {
+		return spot
+	}
+
+	blank.Type = f.unmadeTypeLocked(row, column)
+	blank.Floor, blank.Row, blank.Column = f.FloorNumber, row, column
+	blank.capacity = 1
+	blank.ids = f.spotIDs
+
+	return blank
+}
+
+// unmadeTypeLocked returns the type of the spot at row and column as
+// recorded for a spot not made yet; f.mu must be held
+func (f *ParkingFloor) unmadeTypeLocked(row, column int) SpotType {
+	return registeredSpotTypeList()[f.types[row][column]]
+}
+
+// countUnmadeSpotsLocked adds the spots not made yet to the counters, as
+// attaching them would; f.mu must be held, or the floor not yet shared
+func (f *ParkingFloor) countUnmadeSpotsLocked() {
+	var counts counterSnapshot
+	for r := 0; r < f.numRows; r++ {
+		for c := 0; c < f.numColumns; c++ {
+			if f.spots[r][c].Load() != nil {
+				continue
 			}
-			spots[r][c] = spot
+
+			state := spotState{spotType: f.unmadeTypeLocked(r, c), capacity: 1}
+			counts.add(state)
+			f.counters.freeSpots.update(r, c, state)
 		}
 	}
+	f.counters.applySnapshot(counts)
+}
 
-	return &ParkingFloor{
-		FloorNumber: floorNumber,
-		spots:       spots,
-		numRows:     rows,
-		numColumns:  columns,
-	}, nil
+// Expand resizes the floor to the given dimensions. New cells are filled with
+// spots of the fill type, while existing spots keep their type and occupancy.
+// Shrinking is allowed only if every spot that would be dropped is empty.
+func (f *ParkingFloor) Expand(newRows, newColumns int, fill SpotType) error {
+	if _, err := ParseSpotType(string(fill)); err != nil {
+		return err
+	}
+
+	if newRows <= 0 || newRows > 1000 {
+		return errors.NewValidationError("rows",
+			fmt.Sprintf("%d", newRows), "number of rows must be between 1 and 1000")
+	}
+
+	if newColumns <= 0 || newColumns > 1000 {
+		return errors.NewValidationError("columns",
+			fmt.Sprintf("%d", newColumns), "number of columns must be between 1 and 1000")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Refuse to drop occupied spots when shrinking
+	for r := 0; r < f.numRows; r++ {
+		for c := 0; c < f.numColumns; c++ {
+			if r < newRows && c < newColumns {
+				continue
+			}
+
+			spot := f.spots[r][c].Load()
+			if spot != nil && spot.IsOccupied() {
+				return errors.NewInvalidOperationError("expandFloor",
+					fmt.Sprintf("spot %s is occupied by %s",
+						spot.GetSpotID(), spot.GetVehicleNumber()))
+			}
+		}
+	}
+
+	// New cells hold spots of the fill type, made on first use like the rest
+	spots, types := makeSpotGrid(newRows, newColumns)
+	fillIndex := uint8(spotTypeIndex(fill))
+	for r := 0; r < newRows; r++ {
+		for c := 0; c < newColumns; c++ {
+			if r < f.numRows && c < f.numColumns {
+				spots[r][c].Store(f.spots[r][c].Load())
+				types[r][c] = f.types[r][c]
+				continue
+			}
+			types[r][c] = fillIndex
+		}
+	}
+
+	// Move the counters to the new grid only once it is complete
+	f.counters.freeSpots.resize(newRows, newColumns)
+	for r := 0; r < f.numRows; r++ {
+		for c := 0; c < f.numColumns; c++ {
+			if r < newRows && c < newColumns {
+				continue
+			}
+
+			if spot := f.spots[r][c].Load(); spot != nil {
+				spot.detach()
+			} else {
+				f.counters.apply(spotState{spotType: f.unmadeTypeLocked(r, c), capacity: 1}, -1)
+			}
+		}
+	}
+	added := spotState{spotType: fill, capacity: 1}
+	for r := 0; r < newRows; r++ {
+		for c := 0; c < newColumns; c++ {
+			if r < f.numRows && c < f.numColumns {
+				continue
+			}
+
+			f.counters.apply(added, 1)
+			f.counters.freeSpots.update(r, c, added)
+		}
+	}
+
+	f.spots = spots
+	f.types = types
+	f.numRows = newRows
+	f.numColumns = newColumns
+
+	return nil
 }
 
 // GetSpot returns the parking spot at the given row and column
@@ -148,7 +332,7 @@ func (f *ParkingFloor) GetSpot(row, column int) (*ParkingSpot, error) {
 			fmt.Sprintf("column out of range [0-%d]", f.numColumns-1))
 	}
 
-	return f.spots[row][column], nil
+	return f.spotAtLocked(row, column), nil
 }
 
 // GetAvailableSpots returns all available spots for the given vehicle type
@@ -156,20 +340,116 @@ func (f *ParkingFloor) GetAvailableSpots(vehicleType VehicleType) []*ParkingSpot
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
-	var availableSpots []*ParkingSpot
+	return f.availableSpotsLocked(vehicleType, -1)
+}
+
+// GetAvailableSpotsN returns at most n spots that can fit the vehicle type,
+// in the same row-by-row order as GetAvailableSpots
+func (f *ParkingFloor) GetAvailableSpotsN(vehicleType VehicleType, n int) []*ParkingSpot {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if n <= 0 {
+		return nil
+	}
+
+	return f.availableSpotsLocked(vehicleType, n)
+}
+
+// ForEachAvailableSpot calls fn for every spot that can fit the vehicle
+// type, in the same row-by-row order as GetAvailableSpots, until fn returns
+// false, without building a list. The floor is read locked throughout, so
+// fn must not park or unpark on it.
+func (f *ParkingFloor) ForEachAvailableSpot(vehicleType VehicleType, fn func(*ParkingSpot) bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.closed || !f.acceptsLocked(vehicleType) {
+		return
+	}
+
+	f.counters.freeSpots.each(vehicleType, func(row, column int) bool {
+		return fn(f.spotAtLocked(row, column))
+	})
+}
+
+// FirstAvailableSpot returns the first spot in row-by-row order that can fit
+// the vehicle type, or nil when there is none, without building a list
+func (f *ParkingFloor) FirstAvailableSpot(vehicleType VehicleType) *ParkingSpot {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.closed || !f.acceptsLocked(vehicleType) {
+		return nil
+	}
+
+	row, column, found := f.counters.freeSpots.first(vehicleType)
+	if !found {
+		return nil
+	}
+
+	return f.spotAtLocked(row, column)
+}
+
+// ForEachSpot calls fn for every spot in row-by-row order until fn returns
+// false, making each spot not made yet. The floor's read lock is held
+// throughout, so fn must not call floor methods that take the floor lock;
+// spot methods are fine.
+func (f *ParkingFloor) ForEachSpot(fn func(*ParkingSpot) bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for r := 0; r < f.numRows; r++ {
+		for c := 0; c < f.numColumns; c++ {
+			if !fn(f.spotAtLocked(r, c)) {
+				return
+			}
+		}
+	}
+}
+
+// forEachMadeSpot calls fn for every spot made so far in row-by-row order
+// until fn returns false. A spot not made yet is empty, with no label, tags
+// or charger, so a scan for any of those can skip it. The floor's read lock
+// is held throughout, as for ForEachSpot.
+func (f *ParkingFloor) forEachMadeSpot(fn func(*ParkingSpot) bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
 
 	for r := 0; r < f.numRows; r++ {
 		for c := 0; c < f.numColumns; c++ {
-			spot := f.spots[r][c]
-			if spot.CanPark(vehicleType) {
-				availableSpots = append(availableSpots, spot)
+			if spot := f.spots[r][c].Load(); spot != nil && !fn(spot) {
+				return
 			}
 		}
 	}
+}
+
+// availableSpotsLocked looks up at most n (all when n is negative) spots that
+// can fit the vehicle type in the free spot index; f.mu must be held
+func (f *ParkingFloor) availableSpotsLocked(vehicleType VehicleType, n int) []*ParkingSpot {
+	var availableSpots []*ParkingSpot
+	if f.closed || !f.acceptsLocked(vehicleType) {
+		return availableSpots
+	}
+
+	for _, position := range f.counters.freeSpots.find(vehicleType, n) {
+		availableSpots = append(availableSpots, f.spotAtLocked(position[0], position[1]))
+	}
 
 	return availableSpots
 }
 
+// CountAvailableSpots returns the number of spots that can fit the vehicle type
+// without building a list of them
+func (f *ParkingFloor) CountAvailableSpots(vehicleType VehicleType) int {
+	if f.IsClosed() || !f.Accepts(vehicleType) {
+		return 0
+	}
+
+	return byVehicleType(f.counters.snapshot().free)[vehicleType]
+}
+
 // GetSpotCount returns the total number of spots on this floor
 func (f *ParkingFloor) GetSpotCount() int {
 	f.mu.RLock()
@@ -180,89 +460,273 @@ func (f *ParkingFloor) GetSpotCount() int {
 
 // GetActiveSpotCount returns the number of active spots on this floor
 func (f *ParkingFloor) GetActiveSpotCount() int {
+	return f.counters.snapshot().spotCounts().active
+}
+
+// GetSpotCountByType returns the number of spots of each type on this floor
+func (f *ParkingFloor) GetSpotCountByType() map[SpotType]int {
+	snapshot := f.counters.snapshot()
+
+	spotTypes := registeredSpotTypeList()
+	counts := make(map[SpotType]int, len(spotTypes))
+	for i, spotType := range spotTypes {
+		counts[spotType] = snapshot.spots[i]
+	}
+
+	return counts
+}
+
+// GetOccupiedSpotCount returns the number of occupied spots on this floor
+func (f *ParkingFloor) GetOccupiedSpotCount() int {
+	return f.counters.snapshot().spotCounts().occupied
+}
+
+// GetAvailableCapacityByType returns, for each vehicle type, how many more
+// vehicles of that type the floor can hold. This differs from the number of
+// available spots when bicycle racks hold more than one bicycle.
+func (f *ParkingFloor) GetAvailableCapacityByType() map[VehicleType]int {
+	if f.IsClosed() {
+		return byVehicleType([maxSpotTypes]int{})
+	}
+
+	return restrictCounts(byVehicleType(f.counters.snapshot().room()), f.AllowedVehicleTypes())
+}
+
+// GetOccupiedCapacityByType returns, for each vehicle type, how many vehicles
+// of that type are parked on the floor
+func (f *ParkingFloor) GetOccupiedCapacityByType() map[VehicleType]int {
+	return byVehicleType(f.counters.snapshot().vehicles)
+}
+
+// FloorSummary holds the spot counts of a single floor
+type FloorSummary struct {
+	FloorNumber int
+	Closed      bool
+	// Allowed lists the vehicle types a restricted floor accepts, nil when unrestricted
+	Allowed        []VehicleType
+	TotalSpots     int
+	ActiveSpots    int
+	OccupiedSpots  int
+	AvailableSpots int
+	// AvailableByType counts spots that can take another vehicle of each type
+	AvailableByType map[VehicleType]int
+}
+
+// spotCounts are the total, active, occupied and available spots of a floor or lot
+type spotCounts struct {
+	total     int
+	active    int
+	occupied  int
+	available int
+}
+
+// add returns the sum of two spot counts
+func (c spotCounts) add(other spotCounts) spotCounts {
+	return spotCounts{
+		total:     c.total + other.total,
+		active:    c.active + other.active,
+		occupied:  c.occupied + other.occupied,
+		available: c.available + other.available,
+	}
+}
+
+// countSpots reads the floor's spot counts from its counters
+func (f *ParkingFloor) countSpots() spotCounts {
+	counts := f.counters.snapshot().spotCounts()
+
+	// A closed floor has no available spots
+	if !f.IsClosed() {
+		counts.available = counts.active - counts.occupied
+	}
+
+	return counts
+}
+
+// GetSummary reads the floor's spot counts from its counters
+// A closed floor reports no available spots, like GetAvailableSpots, and a
+// restricted floor none for the vehicle types it does not accept
+func (f *ParkingFloor) GetSummary() FloorSummary {
+	return f.summarize(f.counters.snapshot(), f.IsClosed(), f.AllowedVehicleTypes())
+}
+
+// summarize builds the floor's summary from a snapshot of its counters
+func (f *ParkingFloor) summarize(snapshot counterSnapshot, closed bool, allowed []VehicleType) FloorSummary {
+	counts := snapshot.spotCounts()
+
+	summary := FloorSummary{
+		FloorNumber:     f.FloorNumber,
+		Closed:          closed,
+		Allowed:         allowed,
+		TotalSpots:      counts.total,
+		ActiveSpots:     counts.active,
+		OccupiedSpots:   counts.occupied,
+		AvailableByType: byVehicleType([maxSpotTypes]int{}),
+	}
+
+	if !closed {
+		summary.AvailableSpots = counts.active - counts.occupied
+		summary.AvailableByType = restrictCounts(byVehicleType(snapshot.free), allowed)
+	}
+
+	return summary
+}
+
+// setSpotIDFormatter makes the floor's spots, current and added later, write
+// their IDs with formatter. It runs before the floor joins a lot, while
+// nothing else reads the IDs.
+func (f *ParkingFloor) setSpotIDFormatter(formatter SpotIDFormatter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.spotIDs = formatter
+	for r := range f.spots {
+		for c := range f.spots[r] {
+			if spot := f.spots[r][c].Load(); spot != nil {
+				spot.ids = formatter
+			}
+		}
+	}
+}
+
+// FindVehicle searches for a vehicle by number on this floor
+// Returns the spot if found, nil otherwise
+func (f *ParkingFloor) FindVehicle(vehicleNumber string) *ParkingSpot {
+	normalizedNumber := NormalizeVehicleNumber(vehicleNumber)
+
+	var found *ParkingSpot
+	f.forEachMadeSpot(func(spot *ParkingSpot) bool {
+		if spot.HasVehicle(normalizedNumber) {
+			found = spot
+			return false
+		}
+		return true
+	})
+
+	return found
+}
+
+// GetParkedVehicles returns the numbers of all vehicles parked on this floor
+func (f *ParkingFloor) GetParkedVehicles() []string {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
-	count := 0
+	var vehicles []string
 	for r := 0; r < f.numRows; r++ {
 		for c := 0; c < f.numColumns; c++ {
-			if f.spots[r][c].IsActive() {
-				count++
+			if spot := f.spots[r][c].Load(); spot != nil {
+				vehicles = append(vehicles, spot.GetVehicleNumbers()...)
 			}
 		}
 	}
 
-	return count
+	return vehicles
 }
 
-// GetSpotCountByType returns the number of spots of each type on this floor
-func (f *ParkingFloor) GetSpotCountByType() map[SpotType]int {
+// Close stops the floor from accepting new vehicles
+// Vehicles already parked on the floor can still be unparked
+func (f *ParkingFloor) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+}
+
+// Open lets a closed floor accept new vehicles again
+func (f *ParkingFloor) Open() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = false
+}
+
+// IsClosed returns true if the floor is closed to new vehicles
+func (f *ParkingFloor) IsClosed() bool {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
-	counts := make(map[SpotType]int)
+	return f.closed
+}
 
-	// Initialize counts for all spot types
-	counts[SpotTypeBicycle] = 0
-	counts[SpotTypeMotorcycle] = 0
-	counts[SpotTypeAutomobile] = 0
-	counts[SpotTypeInactive] = 0
+// Restrict makes the floor accept new vehicles of the allowed types only,
+// whatever its spot types. Vehicles already parked there can still be unparked.
+func (f *ParkingFloor) Restrict(allowed []VehicleType) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 
-	// Count spots
-	for r := 0; r < f.numRows; r++ {
-		for c := 0; c < f.numColumns; c++ {
-			spotType := f.spots[r][c].Type
-			counts[spotType]++
-		}
-	}
+	f.allowed = append([]VehicleType{}, allowed...)
+}
 
-	return counts
+// ClearRestriction lets a restricted floor accept every vehicle type again
+func (f *ParkingFloor) ClearRestriction() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.allowed = nil
 }
 
-// GetOccupiedSpotCount returns the number of occupied spots on this floor
-func (f *ParkingFloor) GetOccupiedSpotCount() int {
+// AllowedVehicleTypes returns the vehicle types a restricted floor accepts,
+// or nil when the floor is not restricted
+func (f *ParkingFloor) AllowedVehicleTypes() []VehicleType {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
-	count := 0
-	for r := 0; r < f.numRows; r++ {
-		for c := 0; c < f.numColumns; c++ {
-			if f.spots[r][c].IsOccupied() {
-				count++
-			}
-		}
+	if f.allowed == nil {
+		return nil
 	}
-
-	return count
+	return append([]VehicleType{}, f.allowed...)
 }
 
-// FindVehicle searches for a vehicle by number on this floor
-// Returns the spot if found, nil otherwise
-func (f *ParkingFloor) FindVehicle(vehicleNumber string) *ParkingSpot {
+// Accepts returns true if the floor's restriction, if any, allows the vehicle type
+func (f *ParkingFloor) Accepts(vehicleType VehicleType) bool {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
-	normalizedNumber := NormalizeVehicleNumber(vehicleNumber)
+	return f.acceptsLocked(vehicleType)
+}
 
-	for r := 0; r < f.numRows; r++ {
-		for c := 0; c < f.numColumns; c++ {
-			spot := f.spots[r][c]
-			if spot.IsOccupied() && spot.GetVehicleNumber() == normalizedNumber {
-				return spot
-			}
+// acceptsLocked returns true if the floor's restriction, if any, allows the
+// vehicle type; f.mu must be held
+func (f *ParkingFloor) acceptsLocked(vehicleType VehicleType) bool {
+	return f.allowed == nil || allowsVehicleType(f.allowed, vehicleType)
+}
+
+// allowsVehicleType returns true if the vehicle type is in the list
+func allowsVehicleType(allowed []VehicleType, vehicleType VehicleType) bool {
+	for _, t := range allowed {
+		if t == vehicleType {
+			return true
 		}
 	}
+	return false
+}
 
-	return nil
+// restrictCounts zeroes the counts of the vehicle types a restricted floor
+// does not accept, leaving them all when allowed is nil
+func restrictCounts(counts map[VehicleType]int, allowed []VehicleType) map[VehicleType]int {
+	if allowed == nil {
+		return counts
+	}
+	for vehicleType := range counts {
+		if !allowsVehicleType(allowed, vehicleType) {
+			counts[vehicleType] = 0
+		}
+	}
+	return counts
 }
 
 // GetNumRows returns the number of rows on this floor
 func (f *ParkingFloor) GetNumRows() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
 	return f.numRows
 }
 
 // GetNumColumns returns the number of columns on this floor
 func (f *ParkingFloor) GetNumColumns() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
 	return f.numColumns
 }
 
@@ -271,11 +735,12 @@ func (f *ParkingFloor) GetLayout() [][]SpotType {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
+	var blank ParkingSpot
 	layout := make([][]SpotType, f.numRows)
 	for r := 0; r < f.numRows; r++ {
 		layout[r] = make([]SpotType, f.numColumns)
 		for c := 0; c < f.numColumns; c++ {
-			layout[r][c] = f.spots[r][c].Type
+			layout[r][c] = f.peekSpotLocked(r, c, &blank).GetType()
 		}
 	}
 
@@ -284,39 +749,29 @@ func (f *ParkingFloor) GetLayout() [][]SpotType {
 
 // GetDisplayState returns a string grid representing the current state of the floor
 // Each cell contains:
-// - 'B', 'M', 'A' for available spots of each type
-// - 'b', 'm', 'a' for occupied spots of each type
+// - 'B', 'M', 'A' or a registered type's letter for available spots of each type
+// - the lowercase letter, e.g. 'b', 'm', 'a', for occupied spots of each type
+// - that same lowercase letter on every spot an oversized vehicle spans
 // - 'X' for inactive spots
 func (f *ParkingFloor) GetDisplayState() [][]string {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
+	var blank ParkingSpot
 	display := make([][]string, f.numRows)
 	for r := 0; r < f.numRows; r++ {
 		display[r] = make([]string, f.numColumns)
 		for c := 0; c < f.numColumns; c++ {
-			spot := f.spots[r][c]
-			switch spot.Type {
-			case SpotTypeBicycle:
-				if spot.IsOccupied() {
-					display[r][c] = "b"
-				} else {
-					display[r][c] = "B"
-				}
-			case SpotTypeMotorcycle:
-				if spot.IsOccupied() {
-					display[r][c] = "m"
-				} else {
-					display[r][c] = "M"
-				}
-			case SpotTypeAutomobile:
-				if spot.IsOccupied() {
-					display[r][c] = "a"
-				} else {
-					display[r][c] = "A"
-				}
-			case SpotTypeInactive:
+			// The type is read once, as set-spot may change it meanwhile
+			spot := f.peekSpotLocked(r, c, &blank)
+			spotType := spot.GetType()
+			switch {
+			case !spotType.IsActive():
 				display[r][c] = "X"
+			case spot.IsOccupied():
+				display[r][c] = strings.ToLower(spotType.String()[:1])
+			default:
+				display[r][c] = spotType.String()[:1]
 			}
 		}
 	}
@@ -326,6 +781,9 @@ func (f *ParkingFloor) GetDisplayState() [][]string {
 
 // GetDimensions returns the number of rows and columns on this floor
 func (f *ParkingFloor) GetDimensions() (int, int) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
 	return f.numRows, f.numColumns
 }
 
@@ -334,7 +792,7 @@ func (f *ParkingFloor) String() string {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
-	return fmt.Sprintf("Floor %d (%dx%d): %d total spots, %d active, %d occupied",
-		f.FloorNumber, f.numRows, f.numColumns,
+	return fmt.Sprintf("Floor %s (%dx%d): %d total spots, %d active, %d occupied",
+		FormatFloorNumber(f.FloorNumber), f.numRows, f.numColumns,
 		f.GetSpotCount(), f.GetActiveSpotCount(), f.GetOccupiedSpotCount())
 }
@@ -43,12 +43,16 @@ func TestNewParkingFloor(t *testing.T) {
 		t.Errorf("Expected 3 columns, got %d", floor.GetNumColumns())
 	}
 
-	// Test error cases
-	_, err = NewParkingFloor(-1, spots)
-	if err == nil {
-		t.Errorf("Expected error for negative floor number")
+	// Negative floor numbers are basements
+	basement, err := NewParkingFloor(-1, spots)
+	if err != nil {
+		t.Errorf("Unexpected error for basement floor: %v", err)
+	} else if basement.FloorNumber != -1 {
+		t.Errorf("Expected floor number -1, got %d", basement.FloorNumber)
 	}
 
+	// Test error cases
+
 	_, err = NewParkingFloor(1, nil)
 	if err == nil {
 		t.Errorf("Expected error for nil spots")
@@ -261,6 +265,47 @@ func TestGetAvailableSpots(t *testing.T) {
 	}
 }
 
+func TestFirstAvailableSpotAndForEachSpot(t *testing.T) {
+	floor, _ := CreateParkingFloor(0, 2, 3, [][]SpotType{
+		{SpotTypeInactive, SpotTypeAutomobile, SpotTypeBicycle},
+		{SpotTypeAutomobile, SpotTypeAutomobile, SpotTypeMotorcycle},
+	})
+
+	// The first spot matches the head of the full list
+	spot := floor.FirstAvailableSpot(VehicleTypeAutomobile)
+	if spot == nil || spot.GetSpotID() != "0-0-1" {
+		t.Fatalf("Expected first automobile spot 0-0-1, got %v", spot)
+	}
+	if err := spot.Occupy("FIRST-01"); err != nil {
+		t.Fatalf("Failed to occupy spot: %v", err)
+	}
+	if spot := floor.FirstAvailableSpot(VehicleTypeAutomobile); spot == nil || spot.GetSpotID() != "0-1-0" {
+		t.Errorf("Expected next automobile spot 0-1-0, got %v", spot)
+	}
+
+	floor.Close()
+	if spot := floor.FirstAvailableSpot(VehicleTypeBicycle); spot != nil {
+		t.Errorf("Expected no spot on a closed floor, got %s", spot.GetSpotID())
+	}
+	floor.Open()
+
+	// Iteration visits spots row by row and stops when asked
+	var visited []string
+	floor.ForEachSpot(func(spot *ParkingSpot) bool {
+		visited = append(visited, spot.GetSpotID())
+		return spot.GetSpotID() != "0-1-0"
+	})
+	expected := []string{"0-0-0", "0-0-1", "0-0-2", "0-1-0"}
+	if len(visited) != len(expected) {
+		t.Fatalf("Expected to visit %v, got %v", expected, visited)
+	}
+	for i, spotID := range expected {
+		if visited[i] != spotID {
+			t.Errorf("Visit %d: expected %s, got %s", i, spotID, visited[i])
+		}
+	}
+}
+
 func TestCountingMethods(t *testing.T) {
 	// Create a floor with a mix of spot types
 	floor, _ := CreateParkingFloor(1, 2, 3, [][]SpotType{
@@ -459,3 +504,115 @@ func TestParkingFloorString(t *testing.T) {
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && s[:len(substr)] == substr || len(s) > len(substr) && contains(s[1:], substr)
 }
+
+func TestExpandFloor(t *testing.T) {
+	layout := [][]SpotType{
+		{SpotTypeBicycle, SpotTypeAutomobile},
+		{SpotTypeInactive, SpotTypeAutomobile},
+	}
+	floor, _ := CreateParkingFloor(1, 2, 2, layout)
+
+	occupied, _ := floor.GetSpot(1, 1)
+	_ = occupied.Occupy("KEEP-1")
+
+	if err := floor.Expand(3, 4, SpotTypeAutomobile); err != nil {
+		t.Fatalf("Failed to expand floor: %v", err)
+	}
+
+	rows, columns := floor.GetDimensions()
+	if rows != 3 || columns != 4 {
+		t.Errorf("Expected 3x4 floor, got %dx%d", rows, columns)
+	}
+
+	// Counts grow by exactly the new cell count
+	if floor.GetSpotCount() != 12 {
+		t.Errorf("Expected 12 spots, got %d", floor.GetSpotCount())
+	}
+
+	counts := floor.GetSpotCountByType()
+	if counts[SpotTypeAutomobile] != 2+8 || counts[SpotTypeBicycle] != 1 || counts[SpotTypeInactive] != 1 {
+		t.Errorf("Unexpected counts after expansion: %v", counts)
+	}
+
+	// Previously issued spot IDs stay valid with their occupancy
+	spot, err := floor.GetSpot(1, 1)
+	if err != nil || spot != occupied || !spot.IsOccupied() || spot.GetSpotID() != "1-1-1" {
+		t.Errorf("Expected spot 1-1-1 to be unchanged after expansion")
+	}
+
+	newSpot, _ := floor.GetSpot(2, 3)
+	if newSpot.Type != SpotTypeAutomobile || newSpot.GetSpotID() != "1-2-3" {
+		t.Errorf("Expected new automobile spot 1-2-3, got %s", newSpot)
+	}
+
+	// Shrinking over an occupied spot is rejected
+	if err := floor.Expand(1, 4, SpotTypeAutomobile); err == nil {
+		t.Errorf("Expected error when shrinking would drop an occupied spot")
+	}
+
+	// Shrinking over empty spots is allowed
+	if err := floor.Expand(2, 2, SpotTypeAutomobile); err != nil {
+		t.Errorf("Failed to shrink floor over empty spots: %v", err)
+	}
+
+	if floor.GetSpotCount() != 4 {
+		t.Errorf("Expected 4 spots after shrinking, got %d", floor.GetSpotCount())
+	}
+
+	// Limits and fill type are validated
+	if err := floor.Expand(1001, 2, SpotTypeAutomobile); err == nil {
+		t.Errorf("Expected error for 1001 rows")
+	}
+
+	if err := floor.Expand(3, 3, SpotType("Z-9")); err == nil {
+		t.Errorf("Expected error for invalid fill type")
+	}
+}
+
+func TestSpotsAreMadeOnFirstUse(t *testing.T) {
+	floor, _ := CreateParkingFloor(0, 1000, 1000, nil)
+	made := func() int {
+		count := 0
+		floor.forEachMadeSpot(func(*ParkingSpot) bool {
+			count++
+			return true
+		})
+		return count
+	}
+
+	// Counting, listing and drawing the floor makes no spot
+	if floor.GetSpotCount() != 1000000 || floor.CountAvailableSpots(VehicleTypeAutomobile) == 0 {
+		t.Fatalf("Expected a million spots with automobile spots free, got %s", floor)
+	}
+	_ = floor.GetDisplayState()
+	_ = floor.GetLayout()
+	if n := made(); n != 0 {
+		t.Fatalf("Expected no spot made before first use, got %d", n)
+	}
+
+	// Picking a spot makes only that one, which is then kept
+	spot := floor.FirstAvailableSpot(VehicleTypeAutomobile)
+	if err := spot.Occupy("LAZY-1"); err != nil {
+		t.Fatalf("Failed to occupy spot: %v", err)
+	}
+	if again, _ := floor.GetSpot(spot.Row, spot.Column); again != spot {
+		t.Errorf("Expected the same spot on a second lookup")
+	}
+	if n := made(); n != 1 {
+		t.Errorf("Expected 1 spot made, got %d", n)
+	}
+	if floor.FindVehicle("LAZY-1") != spot || floor.GetOccupiedSpotCount() != 1 {
+		t.Errorf("Expected to find LAZY-1 at %s", spot.GetSpotID())
+	}
+
+	// Spots not made yet are counted like made ones, also when resized
+	if err := floor.validateCounters(); err != nil {
+		t.Fatalf("Counters out of step: %v", err)
+	}
+	if err := floor.Expand(500, 1000, SpotTypeMotorcycle); err != nil {
+		t.Fatalf("Failed to shrink floor: %v", err)
+	}
+	if err := floor.validateCounters(); err != nil {
+		t.Errorf("Counters out of step after shrinking: %v", err)
+	}
+}
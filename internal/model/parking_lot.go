@@ -1,34 +1,127 @@
 package model
 
 import (
+	stderrors "errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
 )
 
 // ParkingLot represents a multi-storey parking lot
+//
+// Locking: mu is the lot lock. Its write lock is taken to change the lot's
+// structure or settings and by Reset. Its read lock is held for the whole
+// of every operation that reads or changes parked vehicles or history, so
+// Reset waits for operations in flight and none start while it runs.
+// Under the read lock, Park and Unpark hold vehicleLocks for the vehicle
+// number, spots are guarded by their floor and spot locks, the vehicle
+// indexes are safe for concurrent use, and the records of every vehicle
+// history are guarded by historyMu. Locks are taken in the order mu,
+// vehicle, floor, spot; historyMu and the spot history lock are never
+// held while taking another lock. Methods holding mu use the *Locked helpers instead
+// of calling public methods that would take it again.
 type ParkingLot struct {
-	// Name of the parking lot
-	Name string
+	// Name of the parking lot, guarded by mu
+	name string
+
+	// Address, timezone and notes of the lot
+	metadata LotMetadata
 
 	// Floors in the parking lot
 	floors []*ParkingFloor
 
-	// Map to track parked vehicles by vehicle number
-	// Key: vehicle number, Value: spot ID
-	parkedVehicles sync.Map
+	// Parked vehicles by normalized vehicle number
+	parkedVehicles vehicleIndex[ParkedVehicle]
+
+	// Parking history of every vehicle seen, by normalized vehicle number
+	vehicleHistory vehicleIndex[*VehicleHistory]
+
+	// Guards the records of every vehicle history in vehicleHistory
+	historyMu sync.Mutex
+
+	// Serializes parking and unparking of the same vehicle number
+	vehicleLocks vehicleLocks
+
+	// Where vehicles enter the lot, used to rank spots by distance
+	entrance Entrance
+
+	// Recent occupancies of each spot
+	spotHistory spotHistoryIndex
+
+	// Clock used for every timestamp the lot records, RealClock when nil
+	clock Clock
+
+	// Current and peak number of parked vehicles
+	occupancy occupancyTracker
+
+	// Running occupancy sampler and the samples of its latest run
+	sampler   *occupancySampler
+	samples   *sampleRing
+	samplerMu sync.Mutex
+
+	// Completed records kept per vehicle, DefaultVehicleHistoryLimit when zero
+	vehicleHistoryLimit int
+
+	// Receives diagnostics, discarded when nil
+	logger Logger
+
+	// Checks vehicle numbers, the default rule when nil
+	numberValidator *VehicleNumberValidator
+
+	// Indexes vehicles by CanonicalVehicleNumber instead of NormalizeVehicleNumber
+	ignoreSeparators bool
+
+	// Writes and reads spot IDs, the dash format when nil
+	spotIDs SpotIDFormatter
+
+	// Lets vehicles without a permit use accessible spots once others are full
+	accessibleOverflow bool
+
+	// Vehicles with a registered accessibility permit, by vehicle key
+	permits map[string]bool
 
-	// Map to track vehicle history
-	// Key: vehicle number, Value: *VehicleHistory
-	vehicleHistory sync.Map
+	// General parks of each vehicle type, counted against their quotas
+	quotas quotaCounter
+
+	// Vehicles parking on a reservation, which quotas do not limit, by vehicle key
+	quotaExempt map[string]bool
+
+	// Fails Park and Unpark at a named step, set only by tests
+	faults func(step string) error
+
+	// Counts of the operations done on the lot
+	ops operationCounters
 
 	// Read-write mutex for thread-safety
 	mu sync.RWMutex
 }
 
+// Entrance is the location vehicles enter the lot from
+type Entrance struct {
+	Floor  int
+	Row    int
+	Column int
+}
+
+// DefaultVehicleHistoryLimit is how many completed records each vehicle keeps by default
+const DefaultVehicleHistoryLimit = 100
+
+// FloorChangeDistance is how many spots of driving one floor change counts as
+// when ranking spots by distance from the entrance
+const FloorChangeDistance = 10
+
+// SpotDistance is a spot ID with its distance from the entrance
+type SpotDistance struct {
+	SpotID   string
+	Distance int
+}
+
 // NewParkingLot creates a new parking lot with the given floors
-func NewParkingLot(name string, floors []*ParkingFloor) (*ParkingLot, error) {
+func NewParkingLot(name string, floors []*ParkingFloor, opts ...LotOption) (*ParkingLot, error) {
 	if len(floors) == 0 {
 		return nil, errors.NewValidationError("floors", "[]", "parking lot must have at least one floor")
 	}
@@ -54,14 +147,51 @@ func NewParkingLot(name string, floors []*ParkingFloor) (*ParkingLot, error) {
 		floorNumbers[floor.FloorNumber] = true
 	}
 
-	return &ParkingLot{
-		Name:   name,
-		floors: floors,
-	}, nil
+	// Keep floors ordered basement-first so parking and display go bottom-up
+	sorted := make([]*ParkingFloor, len(floors))
+	copy(sorted, floors)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].FloorNumber < sorted[j].FloorNumber
+	})
+
+	// The entrance defaults to the first spot of the lowest floor
+	lot := &ParkingLot{
+		name:     name,
+		floors:   sorted,
+		entrance: Entrance{Floor: sorted[0].FloorNumber},
+	}
+	for _, opt := range opts {
+		opt(lot)
+	}
+	if err := lot.metadata.load(); err != nil {
+		return nil, err
+	}
+	if lot.spotIDs != nil {
+		for _, floor := range lot.floors {
+			floor.setSpotIDFormatter(lot.spotIDs)
+		}
+	}
+
+	return lot, nil
 }
 
 // CreateParkingLot creates a new parking lot with the specified dimensions
-func CreateParkingLot(name string, numFloors, rows, columns int) (*ParkingLot, error) {
+// Floors are numbered from 0 upward
+func CreateParkingLot(name string, numFloors, rows, columns int, opts ...LotOption) (*ParkingLot, error) {
+	return CreateParkingLotFromFloor(name, 0, numFloors, rows, columns, opts...)
+}
+
+// CreateParkingLotFromFloor creates a new parking lot whose floors are numbered
+// upward from startFloor. A negative startFloor adds basement floors, e.g. a
+// start of -2 with 4 floors gives floors -2, -1, 0 and 1.
+func CreateParkingLotFromFloor(name string, startFloor, numFloors, rows, columns int,
+	opts ...LotOption) (*ParkingLot, error) {
+	if startFloor < -8 || startFloor > 8 {
+		return nil, errors.NewValidationError("startFloor",
+			fmt.Sprintf("%d", startFloor),
+			"starting floor must be between -8 and 8")
+	}
+
 	if numFloors < 1 || numFloors > 8 {
 		return nil, errors.NewValidationError("numFloors",
 			fmt.Sprintf("%d", numFloors),
@@ -89,15 +219,209 @@ func CreateParkingLot(name string, numFloors, rows, columns int) (*ParkingLot, e
 	// Create floors
 	floors := make([]*ParkingFloor, numFloors)
 	for i := 0; i < numFloors; i++ {
-		floor, err := CreateParkingFloor(i, rows, columns, layout.SpotMap[i])
+		floorNumber := startFloor + i
+		floor, err := CreateParkingFloor(floorNumber, rows, columns, layout.SpotMap[i])
+		if err != nil {
+			return nil, errors.WrapError(err, "CREATION_ERROR",
+				fmt.Sprintf("failed to create floor %s", FormatFloorNumber(floorNumber)))
+		}
+		floors[i] = floor
+	}
+
+	return NewParkingLot(name, floors, opts...)
+}
+
+// CreateParkingLotWithFloors creates a new parking lot where each floor has its
+// own dimensions. Floors are numbered upward from startFloor in spec order.
+func CreateParkingLotWithFloors(name string, startFloor int, specs []FloorSpec,
+	opts ...LotOption) (*ParkingLot, error) {
+	if startFloor < -8 || startFloor > 8 {
+		return nil, errors.NewValidationError("startFloor",
+			fmt.Sprintf("%d", startFloor),
+			"starting floor must be between -8 and 8")
+	}
+
+	// Create spot layout, which also validates the number of floors and dimensions
+	layout, err := NewSpotLayoutFromSpecs(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create floors
+	floors := make([]*ParkingFloor, len(specs))
+	for i, spec := range specs {
+		floorNumber := startFloor + i
+		floor, err := CreateParkingFloor(floorNumber, spec.Rows, spec.Columns, layout.SpotMap[i])
 		if err != nil {
 			return nil, errors.WrapError(err, "CREATION_ERROR",
-				fmt.Sprintf("failed to create floor %d", i))
+				fmt.Sprintf("failed to create floor %s", FormatFloorNumber(floorNumber)))
 		}
 		floors[i] = floor
 	}
 
-	return NewParkingLot(name, floors)
+	return NewParkingLot(name, floors, opts...)
+}
+
+// CreateParkingLotWithDistribution creates a new parking lot like
+// CreateParkingLotFromFloor, with spot types generated from dist
+func CreateParkingLotWithDistribution(name string, startFloor, numFloors, rows, columns int,
+	dist DistributionSpec, opts ...LotOption) (*ParkingLot, error) {
+	if numFloors < 1 || numFloors > 8 {
+		return nil, errors.NewValidationError("numFloors",
+			fmt.Sprintf("%d", numFloors),
+			"number of floors must be between 1 and 8")
+	}
+
+	specs := make([]FloorSpec, numFloors)
+	for i := range specs {
+		specs[i] = FloorSpec{Rows: rows, Columns: columns, Distribution: &dist}
+	}
+
+	return CreateParkingLotWithFloors(name, startFloor, specs, opts...)
+}
+
+// AddFloor appends a new floor to the lot, numbered one above the current
+// highest floor. If layout is nil the default spot distribution is used.
+// The new floor's spots are immediately available for parking.
+func (p *ParkingLot) AddFloor(rows, columns int, layout [][]SpotType) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.floors) >= 8 {
+		return errors.NewValidationError("floors",
+			fmt.Sprintf("%d", len(p.floors)+1),
+			"parking lot cannot have more than 8 floors")
+	}
+
+	floorNumber := p.floors[0].FloorNumber
+	for _, floor := range p.floors {
+		if floor.FloorNumber >= floorNumber {
+			floorNumber = floor.FloorNumber + 1
+		}
+	}
+
+	floor, err := CreateParkingFloor(floorNumber, rows, columns, layout)
+	if err != nil {
+		return err
+	}
+	if p.spotIDs != nil {
+		floor.setSpotIDFormatter(p.spotIDs)
+	}
+
+	p.floors = append(p.floors, floor)
+
+	return nil
+}
+
+// RemoveFloor detaches the floor with the given number from the lot
+// The floor must be empty, and the lot must keep at least one floor
+func (p *ParkingLot) RemoveFloor(floorNum int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	index := -1
+	for i, floor := range p.floors {
+		if floor.FloorNumber == floorNum {
+			index = i
+			break
+		}
+	}
+
+	if index < 0 {
+		return errors.NewValidationError("floorNum",
+			fmt.Sprintf("%d", floorNum),
+			"floor not found")
+	}
+
+	if len(p.floors) == 1 {
+		return errors.NewInvalidOperationError("removeFloor",
+			"parking lot must have at least one floor")
+	}
+
+	if vehicles := p.floors[index].GetParkedVehicles(); len(vehicles) > 0 {
+		sort.Strings(vehicles)
+		return errors.NewInvalidOperationError("removeFloor",
+			fmt.Sprintf("floor %s still has parked vehicles: %s",
+				FormatFloorNumber(floorNum), strings.Join(vehicles, ", ")))
+	}
+
+	p.floors = append(p.floors[:index], p.floors[index+1:]...)
+
+	return nil
+}
+
+// ExpandFloor resizes the floor with the given number, filling new cells with
+// spots of the fill type. Existing spot IDs and occupancy are preserved.
+func (p *ParkingLot) ExpandFloor(floorNum, rows, columns int, fill SpotType) error {
+	// Resizing changes the lot's structure, so no park may run meanwhile
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	floor, err := p.floorLocked(floorNum)
+	if err != nil {
+		return err
+	}
+
+	return floor.Expand(rows, columns, fill)
+}
+
+// CloseFloor stops the floor with the given number from accepting new vehicles
+// Vehicles already parked there can still be unparked
+func (p *ParkingLot) CloseFloor(floorNum int) error {
+	floor, err := p.GetFloor(floorNum)
+	if err != nil {
+		return err
+	}
+
+	floor.Close()
+	return nil
+}
+
+// OpenFloor lets a closed floor accept new vehicles again
+func (p *ParkingLot) OpenFloor(floorNum int) error {
+	floor, err := p.GetFloor(floorNum)
+	if err != nil {
+		return err
+	}
+
+	floor.Open()
+	return nil
+}
+
+// RestrictFloor makes the floor with the given number accept new vehicles of
+// the allowed types only, even where its spots could take others. Park and
+// the available spot lookups skip the floor for other types, and parking one
+// there by spot ID fails with a FloorRestrictedError.
+func (p *ParkingLot) RestrictFloor(floorNum int, allowed []VehicleType) error {
+	if len(allowed) == 0 {
+		return errors.NewValidationError("allowed", "[]",
+			"a floor restriction needs at least one vehicle type, clear it to allow every type")
+	}
+	for _, vehicleType := range allowed {
+		if err := validateVehicleType(vehicleType); err != nil {
+			return err
+		}
+	}
+
+	floor, err := p.GetFloor(floorNum)
+	if err != nil {
+		return err
+	}
+
+	floor.Restrict(allowed)
+	return nil
+}
+
+// ClearFloorRestriction lets the floor with the given number accept every
+// vehicle type again
+func (p *ParkingLot) ClearFloorRestriction(floorNum int) error {
+	floor, err := p.GetFloor(floorNum)
+	if err != nil {
+		return err
+	}
+
+	floor.ClearRestriction()
+	return nil
 }
 
 // GetFloor returns the floor with the given number
@@ -105,6 +429,11 @@ func (p *ParkingLot) GetFloor(floorNum int) (*ParkingFloor, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
+	return p.floorLocked(floorNum)
+}
+
+// floorLocked returns the floor with the given number; p.mu must be held
+func (p *ParkingLot) floorLocked(floorNum int) (*ParkingFloor, error) {
 	for _, floor := range p.floors {
 		if floor.FloorNumber == floorNum {
 			return floor, nil
@@ -128,7 +457,7 @@ func (p *ParkingLot) GetSpot(floorNum, row, column int) (*ParkingSpot, error) {
 
 // GetSpotByID returns the parking spot with the given ID
 func (p *ParkingLot) GetSpotByID(spotID string) (*ParkingSpot, error) {
-	floor, row, column, err := ParseSpotID(spotID)
+	floor, row, column, err := p.ParseSpotID(spotID)
 	if err != nil {
 		return nil, err
 	}
@@ -136,6 +465,21 @@ func (p *ParkingLot) GetSpotByID(spotID string) (*ParkingSpot, error) {
 	return p.GetSpot(floor, row, column)
 }
 
+// spotByIDLocked returns the parking spot with the given ID; p.mu must be held
+func (p *ParkingLot) spotByIDLocked(spotID string) (*ParkingSpot, error) {
+	floorNum, row, column, err := p.ParseSpotID(spotID)
+	if err != nil {
+		return nil, err
+	}
+
+	floor, err := p.floorLocked(floorNum)
+	if err != nil {
+		return nil, err
+	}
+
+	return floor.GetSpot(row, column)
+}
+
 // GetNumFloors returns the number of floors in the parking lot
 func (p *ParkingLot) GetNumFloors() int {
 	p.mu.RLock()
@@ -156,17 +500,25 @@ func (p *ParkingLot) GetFloors() []*ParkingFloor {
 	return result
 }
 
-// GetTotalSpotCount returns the total number of parking spots in the lot
-func (p *ParkingLot) GetTotalSpotCount() int {
+// GetSpotLayout returns the spot types of every floor, lowest floor first
+func (p *ParkingLot) GetSpotLayout() *SpotLayout {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	total := 0
-	for _, floor := range p.floors {
-		total += floor.GetSpotCount()
+	layout := &SpotLayout{SpotMap: make([][][]SpotType, len(p.floors))}
+	for i, floor := range p.floors {
+		layout.SpotMap[i] = floor.GetLayout()
 	}
 
-	return total
+	return layout
+}
+
+// GetTotalSpotCount returns the total number of parking spots in the lot
+func (p *ParkingLot) GetTotalSpotCount() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.spotCountsLocked().total
 }
 
 // GetActiveSpotCount returns the number of active parking spots in the lot
@@ -174,12 +526,7 @@ func (p *ParkingLot) GetActiveSpotCount() int {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	total := 0
-	for _, floor := range p.floors {
-		total += floor.GetActiveSpotCount()
-	}
-
-	return total
+	return p.spotCountsLocked().active
 }
 
 // GetOccupiedSpotCount returns the number of occupied parking spots in the lot
@@ -187,17 +534,39 @@ func (p *ParkingLot) GetOccupiedSpotCount() int {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	total := 0
+	return p.spotCountsLocked().occupied
+}
+
+// GetAvailableSpotCount returns the number of available parking spots in the lot
+// Spots on closed floors are not available
+func (p *ParkingLot) GetAvailableSpotCount() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.spotCountsLocked().available
+}
+
+// spotCountsLocked sums the spot counts of every floor; p.mu must be held
+func (p *ParkingLot) spotCountsLocked() spotCounts {
+	var counts spotCounts
 	for _, floor := range p.floors {
-		total += floor.GetOccupiedSpotCount()
+		counts = counts.add(floor.countSpots())
 	}
 
-	return total
+	return counts
 }
 
-// GetAvailableSpotCount returns the number of available parking spots in the lot
-func (p *ParkingLot) GetAvailableSpotCount() int {
-	return p.GetActiveSpotCount() - p.GetOccupiedSpotCount()
+// GetFloorSummaries returns the spot counts of every floor, lowest floor first
+func (p *ParkingLot) GetFloorSummaries() []FloorSummary {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	summaries := make([]FloorSummary, len(p.floors))
+	for i, floor := range p.floors {
+		summaries[i] = floor.GetSummary()
+	}
+
+	return summaries
 }
 
 // GetSpotCountByType returns the number of spots of each type in the lot
@@ -208,10 +577,9 @@ func (p *ParkingLot) GetSpotCountByType() map[SpotType]int {
 	totalCounts := make(map[SpotType]int)
 
 	// Initialize counts for all spot types
-	totalCounts[SpotTypeBicycle] = 0
-	totalCounts[SpotTypeMotorcycle] = 0
-	totalCounts[SpotTypeAutomobile] = 0
-	totalCounts[SpotTypeInactive] = 0
+	for _, spotType := range registeredSpotTypeList() {
+		totalCounts[spotType] = 0
+	}
 
 	// Sum counts from all floors
 	for _, floor := range p.floors {
@@ -224,193 +592,685 @@ func (p *ParkingLot) GetSpotCountByType() map[SpotType]int {
 	return totalCounts
 }
 
-// GetVehicleHistory returns the parking history for a vehicle
+// GetVehicleHistory returns the parking history for a vehicle.
+// The history is live and changes as the vehicle parks; use GetVehicleRecords
+// for a copy that is safe to read while vehicles come and go.
 func (p *ParkingLot) GetVehicleHistory(vehicleNumber string) (*VehicleHistory, bool) {
-	normalizedNumber := NormalizeVehicleNumber(vehicleNumber)
-	historyObj, found := p.vehicleHistory.Load(normalizedNumber)
+	return p.vehicleHistory.get(p.vehicleKey(vehicleNumber))
+}
+
+// GetVehicleRecords returns a copy of a vehicle's parking records, oldest first
+// Returns a VehicleNotFoundError if the vehicle has never parked
+func (p *ParkingLot) GetVehicleRecords(vehicleNumber string) ([]ParkingRecord, error) {
+	if err := p.validateVehicleNumber(vehicleNumber); err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	history, found := p.GetVehicleHistory(vehicleNumber)
 	if !found {
-		return nil, false
+		return nil, errors.NewVehicleNotFoundError(vehicleNumber)
+	}
+
+	p.historyMu.Lock()
+	defer p.historyMu.Unlock()
+
+	if len(history.Records) == 0 {
+		return nil, errors.NewVehicleNotFoundError(vehicleNumber)
+	}
+
+	records := make([]ParkingRecord, len(history.Records))
+	copy(records, history.Records)
+
+	return records, nil
+}
+
+// SetVehicleHistoryLimit sets how many completed records each vehicle keeps.
+// Vehicles over the new limit drop their oldest completed records.
+func (p *ParkingLot) SetVehicleHistoryLimit(limit int) error {
+	if limit < 1 {
+		return errors.NewValidationError("limit", fmt.Sprintf("%d", limit),
+			"vehicle history limit must be at least 1")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.vehicleHistoryLimit = limit
+
+	p.historyMu.Lock()
+	defer p.historyMu.Unlock()
+
+	p.vehicleHistory.each(func(_ string, history *VehicleHistory) bool {
+		history.TrimCompleted(limit)
+		return true
+	})
+
+	return nil
+}
+
+// GetVehicleHistoryLimit returns how many completed records each vehicle keeps
+func (p *ParkingLot) GetVehicleHistoryLimit() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.vehicleHistoryLimitLocked()
+}
+
+// vehicleHistoryLimitLocked returns the vehicle history limit; p.mu must be held
+func (p *ParkingLot) vehicleHistoryLimitLocked() int {
+	if p.vehicleHistoryLimit == 0 {
+		return DefaultVehicleHistoryLimit
 	}
+	return p.vehicleHistoryLimit
+}
+
+// PruneHistory removes completed parking records that ended before olderThan.
+// Active records are kept, and vehicles still remember their last spot.
+// Returns the number of records removed.
+func (p *ParkingLot) PruneHistory(olderThan time.Time) int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	p.historyMu.Lock()
+	defer p.historyMu.Unlock()
+
+	removed := 0
+	p.vehicleHistory.each(func(_ string, history *VehicleHistory) bool {
+		removed += history.PruneCompletedBefore(olderThan)
+		return true
+	})
 
-	history, ok := historyObj.(*VehicleHistory)
-	return history, ok
+	return removed
 }
 
 // FindVehicle searches for a vehicle by number in the parking lot
 // Returns the spot if found, nil otherwise
 func (p *ParkingLot) FindVehicle(vehicleNumber string) (*ParkingSpot, error) {
-	normalizedNumber := NormalizeVehicleNumber(vehicleNumber)
+	key := p.vehicleKey(vehicleNumber)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 
 	// Check if vehicle is currently parked
-	spotIDObj, found := p.parkedVehicles.Load(normalizedNumber)
+	parked, found := p.parkedVehicles.get(key)
 	if !found {
 		// If not currently parked, check if it has parking history
-		history, historyFound := p.GetVehicleHistory(normalizedNumber)
-		if !historyFound || history.GetLastSpotID() == "" {
+		lastSpotID := p.lastSpotIDLocked(key)
+		if lastSpotID == "" {
 			return nil, errors.NewVehicleNotFoundError(vehicleNumber)
 		}
 
 		// Return information about the last spot (vehicle was previously parked)
 		return nil, errors.NewInvalidOperationError("findVehicle",
 			fmt.Sprintf("vehicle %s is not currently parked, but was last seen at spot %s",
-				vehicleNumber, history.GetLastSpotID()))
+				vehicleNumber, lastSpotID))
 	}
 
-	spotID, ok := spotIDObj.(string)
-	if !ok {
-		return nil, errors.NewParkingError("INTERNAL_ERROR",
-			"stored spot ID has invalid type", nil)
+	// Get the actual spot
+	return p.spotByIDLocked(parked.SpotID)
+}
+
+// lastSpotIDLocked returns the last spot a vehicle parked at, empty when it
+// never parked; p.mu must be held
+func (p *ParkingLot) lastSpotIDLocked(key string) string {
+	history, found := p.vehicleHistory.get(key)
+	if !found {
+		return ""
 	}
 
-	// Get the actual spot
-	return p.GetSpotByID(spotID)
+	p.historyMu.Lock()
+	defer p.historyMu.Unlock()
+
+	return history.GetLastSpotID()
 }
 
 // IsVehicleParked checks if a vehicle is currently parked
 func (p *ParkingLot) IsVehicleParked(vehicleNumber string) bool {
-	normalizedNumber := NormalizeVehicleNumber(vehicleNumber)
-	_, found := p.parkedVehicles.Load(normalizedNumber)
+	_, found := p.parkedVehicles.get(p.vehicleKey(vehicleNumber))
 	return found
 }
 
+// GetParkedVehicle returns the vehicle parked under the number, if it is parked
+func (p *ParkingLot) GetParkedVehicle(vehicleNumber string) (ParkedVehicle, bool) {
+	return p.parkedVehicles.get(p.vehicleKey(vehicleNumber))
+}
+
 // String returns a string representation of the parking lot
 func (p *ParkingLot) String() string {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
+	// Take every count from one snapshot rather than calling the locking getters
+	counts := p.spotCountsLocked()
 	return fmt.Sprintf("%s: %d floors, %d total spots, %d active, %d occupied, %d available",
-		p.Name, len(p.floors), p.GetTotalSpotCount(), p.GetActiveSpotCount(),
-		p.GetOccupiedSpotCount(), p.GetAvailableSpotCount())
+		p.name, len(p.floors), counts.total, counts.active, counts.occupied, counts.available)
 }
 
-// Park parks a vehicle of the given type and number in an available spot
-// Returns the assigned spot ID or an error if no spot is available
-func (p *ParkingLot) Park(vehicleType VehicleType, vehicleNumber string) (string, error) {
-	// Validate inputs
-	if vehicleType != VehicleTypeBicycle &&
-		vehicleType != VehicleTypeMotorcycle &&
-		vehicleType != VehicleTypeAutomobile {
-		return "", errors.NewInvalidVehicleTypeError(string(vehicleType))
-	}
+// GetName returns the name of the parking lot
+func (p *ParkingLot) GetName() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 
-	if err := ValidateVehicleNumber(vehicleNumber); err != nil {
-		return "", err
+	return p.name
+}
+
+// SetName renames the parking lot, rejecting a blank name
+func (p *ParkingLot) SetName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.NewValidationError("name", name, "lot name cannot be empty")
 	}
 
-	normalizedNumber := NormalizeVehicleNumber(vehicleNumber)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.name = name
+	return nil
+}
+
+// ParkResultDetail describes where and when a vehicle was parked
+type ParkResultDetail struct {
+	SpotID string
+
+	// Location of the spot
+	Floor  int
+	Row    int
+	Column int
+
+	// Human name of the spot, empty when it has none
+	Label string
+
+	// Charger reports whether the spot has a working EV charger
+	Charger bool
+
+	// SpotIDs lists every spot an oversized vehicle takes, SpotID first;
+	// nil for a vehicle in one spot
+	SpotIDs []string
+
+	VehicleType      VehicleType
+	NormalizedNumber string
+	ParkedAt         time.Time
+}
 
-	// Check if the vehicle is already parked
-	if spotIDObj, found := p.parkedVehicles.Load(normalizedNumber); found {
-		spotID := spotIDObj.(string)
-		return "", errors.NewVehicleAlreadyParkedError(vehicleNumber, spotID)
+// Park parks a vehicle of the given type and number in an available spot
+// Returns the assigned spot ID or an error if no spot is available
+func (p *ParkingLot) Park(vehicleType VehicleType, vehicleNumber string) (string, error) {
+	detail, err := p.ParkDetailed(vehicleType, vehicleNumber)
+	if err != nil {
+		return "", err
 	}
+	return detail.SpotID, nil
+}
+
+// ParkDetailed parks a vehicle like Park and describes the spot and time
+// it was parked at
+func (p *ParkingLot) ParkDetailed(vehicleType VehicleType, vehicleNumber string) (*ParkResultDetail, error) {
+	return p.ParkRequiring(vehicleType, vehicleNumber, nil)
+}
+
+// ParkRequiring parks a vehicle like ParkDetailed, in the first spot that
+// carries every one of the tags. When no such spot is free it fails with a
+// NoSpaceError naming the tags, even if untagged spots are free.
+func (p *ParkingLot) ParkRequiring(vehicleType VehicleType, vehicleNumber string, tags []string) (*ParkResultDetail, error) {
+	return p.ParkWithOptions(vehicleType, vehicleNumber, ParkOptions{Tags: tags})
+}
+
+// ParkAt parks a vehicle like ParkDetailed, in the spot with the given ID
+func (p *ParkingLot) ParkAt(spotID string, vehicleType VehicleType, vehicleNumber string) (*ParkResultDetail, error) {
+	return p.ParkWithOptions(vehicleType, vehicleNumber, ParkOptions{SpotID: spotID})
+}
+
+// ParkOptions narrow down the spot a park takes
+type ParkOptions struct {
+	// SpotID parks in this spot rather than the first free one
+	SpotID string
 
-	// Find an available parking spot for this vehicle type
+	// Tags limits the park to spots carrying every one of these tags
+	Tags []string
+
+	// PreferCharger takes a spot with a working EV charger when one is free
+	PreferCharger bool
+
+	// Permit lets the vehicle use accessible spots, as a registered permit does
+	Permit bool
+
+	// Reserved parks the vehicle on a reservation, which quotas do not limit,
+	// as an exemption from quotas does
+	Reserved bool
+
+	// Timings, when set, records how long selecting the spot and the
+	// bookkeeping after took
+	Timings *OperationTimings
+
+	// parkedAt, when set, is recorded as the time the vehicle parked instead
+	// of the clock's time, as restoring a snapshot replays earlier parks
+	parkedAt time.Time
+}
+
+// ParkWithOptions parks a vehicle like ParkDetailed, choosing the spot as
+// the options say
+func (p *ParkingLot) ParkWithOptions(vehicleType VehicleType, vehicleNumber string, opts ParkOptions) (*ParkResultDetail, error) {
+	detail, err := p.parkWithOptions(vehicleType, vehicleNumber, opts)
+	p.ops.recordPark(err)
+	return detail, err
+}
+
+// parkWithOptions parks a vehicle for ParkWithOptions, which counts it
+func (p *ParkingLot) parkWithOptions(vehicleType VehicleType, vehicleNumber string, opts ParkOptions) (*ParkResultDetail, error) {
+	opts.Timings.begin()
+
+	// Validate inputs
+	if err := validateVehicleType(vehicleType); err != nil {
+		return nil, err
+	}
+
+	if err := p.validateVehicleNumber(vehicleNumber); err != nil {
+		return nil, err
+	}
+
+	requiredTags, err := normalizeSpotTags(opts.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedNumber := NormalizeVehicleNumber(vehicleNumber)
+	key := p.vehicleKey(vehicleNumber)
+
+	// Hold the read lock throughout, so the floor cannot be removed between
+	// selecting and occupying the spot and Reset waits for the park to finish
 	p.mu.RLock()
-	var availableSpot *ParkingSpot
+	defer p.mu.RUnlock()
 
-	// Try to find a spot on each floor
-	for _, floor := range p.floors {
-		spots := floor.GetAvailableSpots(vehicleType)
-		if len(spots) > 0 {
-			availableSpot = spots[0]
-			break
+	// Concurrent parks of the same number would both pass the check below
+	// and occupy two spots, so they take turns
+	vehicleMu := p.vehicleLocks.forNumber(key)
+	vehicleMu.Lock()
+	defer vehicleMu.Unlock()
+
+	// Check if the vehicle is already parked, perhaps written differently
+	if parked, found := p.parkedVehicles.get(key); found {
+		if parked.VehicleNumber != normalizedNumber {
+			return nil, errors.NewVehicleNumberClashError(vehicleNumber, parked.VehicleNumber, parked.SpotID)
 		}
+		return nil, errors.NewVehicleAlreadyParkedError(vehicleNumber, parked.SpotID)
 	}
-	p.mu.RUnlock()
 
-	if availableSpot == nil {
-		return "", errors.NewNoSpaceError(string(vehicleType))
+	// A registered permit counts like one shown at the entrance
+	opts.Tags = requiredTags
+	opts.Permit = opts.Permit || p.permits[key]
+
+	// General parks count against the vehicle type's quota, and are refused
+	// once it is used up
+	reserved := opts.Reserved || p.quotaExempt[key]
+	if !reserved {
+		if err := p.quotas.acquire(vehicleType); err != nil {
+			return nil, err
+		}
 	}
 
-	// Occupy the spot
-	if err := availableSpot.Occupy(normalizedNumber); err != nil {
-		return "", errors.WrapError(err, "OCCUPATION_ERROR",
-			fmt.Sprintf("failed to occupy spot %s", availableSpot.GetSpotID()))
+	// An oversized vehicle takes several spots, the first of which it is
+	// found and recorded at
+	var span []*ParkingSpot
+	if opts.SpotID != "" {
+		span, err = p.occupySpotLocked(opts.SpotID, vehicleType, normalizedNumber, opts)
+	} else {
+		span, err = p.occupyFirstSpotLocked(vehicleType, normalizedNumber, opts)
+	}
+	if err != nil {
+		if !reserved {
+			p.quotas.release(vehicleType)
+		}
+		return nil, err
+	}
+	availableSpot := span[0]
+	opts.Timings.mark("selection")
+
+	// From here on every completed step is undone if a later one fails,
+	// so the spot is never left occupied by a vehicle nothing tracks
+	tx := newTransaction(fmt.Sprintf("park of %s", normalizedNumber), p.loggerLocked())
+	defer tx.finish()
+	tx.onRollback(func() error { return vacateSpan(span, normalizedNumber) })
+	if !reserved {
+		tx.onRollback(func() error {
+			p.quotas.release(vehicleType)
+			return nil
+		})
+	}
+	if err := p.step("park.occupy"); err != nil {
+		return nil, err
 	}
 
-	// Record the parking in the maps
+	// Find or create the vehicle's history
 	spotID := availableSpot.GetSpotID()
-	p.parkedVehicles.Store(normalizedNumber, spotID)
-
-	// Update vehicle history
-	historyObj, found := p.vehicleHistory.Load(normalizedNumber)
-	var history *VehicleHistory
-
+	var spotIDs []string
+	if len(span) > 1 {
+		spotIDs = spanSpotIDs(span)
+	}
+	history, found := p.vehicleHistory.get(key)
 	if !found {
-		// Create new vehicle
-		vehicle, _ := NewVehicle(vehicleType, normalizedNumber)
-		history = NewVehicleHistory(vehicle)
+		// The number passed the lot's rule, which may differ from NewVehicle's
+		vehicle := &Vehicle{Type: vehicleType, Number: normalizedNumber}
+		history = NewVehicleHistoryWithClock(vehicle, p.clockLocked())
+		p.vehicleHistory.put(key, history)
+		tx.onRollback(func() error {
+			p.vehicleHistory.delete(key)
+			return nil
+		})
+	}
+	if err := p.step("park.history"); err != nil {
+		return nil, err
+	}
+
+	p.historyMu.Lock()
+	if opts.parkedAt.IsZero() {
+		history.AddParkingRecord(spotID)
 	} else {
-		history = historyObj.(*VehicleHistory)
+		history.addParkingRecordAt(spotID, opts.parkedAt)
+	}
+	// Record the parking with the same time as its history record
+	parkedAt := history.GetLastParkingRecord().ParkedAt
+	p.historyMu.Unlock()
+	tx.onRollback(func() error {
+		p.historyMu.Lock()
+		defer p.historyMu.Unlock()
+		history.removeLastParkingRecord()
+		return nil
+	})
+	if err := p.step("park.record"); err != nil {
+		return nil, err
+	}
+
+	p.parkedVehicles.put(key, ParkedVehicle{
+		VehicleNumber: normalizedNumber,
+		VehicleType:   vehicleType,
+		SpotID:        spotID,
+		ParkedAt:      parkedAt,
+		SpotIDs:       spotIDs,
+		Reserved:      reserved,
+	})
+	tx.onRollback(func() error {
+		p.parkedVehicles.delete(key)
+		return nil
+	})
+	if err := p.step("park.index"); err != nil {
+		return nil, err
 	}
 
-	history.AddParkingRecord(spotID)
-	p.vehicleHistory.Store(normalizedNumber, history)
+	tx.commit()
 
-	return spotID, nil
+	// Trimming drops old records for good, so it waits for the commit
+	p.historyMu.Lock()
+	history.TrimCompleted(p.vehicleHistoryLimitLocked())
+	p.historyMu.Unlock()
+	for _, spot := range span {
+		p.spotHistory.recordOccupy(spot.GetSpotID(), normalizedNumber, parkedAt)
+	}
+	p.occupancy.recordPark(vehicleType, parkedAt)
+	opts.Timings.mark("bookkeeping")
+
+	return &ParkResultDetail{
+		SpotID:           spotID,
+		Floor:            availableSpot.Floor,
+		Row:              availableSpot.Row,
+		Column:           availableSpot.Column,
+		Label:            availableSpot.GetLabel(),
+		Charger:          availableSpot.hasWorkingCharger(),
+		SpotIDs:          spotIDs,
+		VehicleType:      vehicleType,
+		NormalizedNumber: normalizedNumber,
+		ParkedAt:         parkedAt,
+	}, nil
+}
+
+// firstAvailableSpotLocked returns the first free spot keep accepts, all
+// of them when keep is nil, of the lowest floor that has one; p.mu must be held
+func (p *ParkingLot) firstAvailableSpotLocked(vehicleType VehicleType, keep func(*ParkingSpot) bool) *ParkingSpot {
+	for _, floor := range p.floors {
+		if spot := floor.firstAvailableSpotWhere(vehicleType, keep); spot != nil {
+			return spot
+		}
+	}
+
+	return nil
+}
+
+// occupyFirstSpotLocked parks a vehicle in the first free spots the options
+// allow, taken from the floor indexes; p.mu must be held. A concurrent park
+// can fill one of them first, which also drops it from the index, so spots
+// found full or inactive are skipped by looking again.
+func (p *ParkingLot) occupyFirstSpotLocked(vehicleType VehicleType, vehicleNumber string, opts ParkOptions) ([]*ParkingSpot, error) {
+	for {
+		span := p.selectSpotLocked(vehicleType, opts)
+		if span == nil {
+			if len(opts.Tags) > 0 {
+				return nil, errors.NewNoTaggedSpaceError(string(vehicleType), opts.Tags)
+			}
+			return nil, errors.NewNoSpaceError(string(vehicleType))
+		}
+
+		err := occupySpan(span, vehicleNumber)
+		if err == nil {
+			return span, nil
+		}
+
+		switch err.(type) {
+		case *errors.SpotOccupancyError, *errors.SpotTypeError:
+			p.loggerLocked().Debug(fmt.Sprintf("spot %s was taken before %s could park there, looking again",
+				span[0].GetSpotID(), vehicleNumber))
+			continue
+		}
+		return nil, errors.WrapError(err, "OCCUPATION_ERROR",
+			fmt.Sprintf("failed to occupy spot %s", span[0].GetSpotID()))
+	}
+}
+
+// occupySpotLocked parks a vehicle in the spot with the given ID, and for an
+// oversized vehicle the spots to its right; p.mu must be held. Each spot
+// must carry the options' normalized tags and take the vehicle.
+func (p *ParkingLot) occupySpotLocked(spotID string, vehicleType VehicleType, vehicleNumber string,
+	opts ParkOptions) ([]*ParkingSpot, error) {
+	anchor, err := p.spotByIDLocked(spotID)
+	if err != nil {
+		return nil, err
+	}
+
+	if floor, err := p.floorLocked(anchor.Floor); err == nil {
+		if floor.IsClosed() {
+			return nil, errors.NewInvalidOperationError("parkAt",
+				fmt.Sprintf("floor %s of spot %s is closed", FormatFloorNumber(anchor.Floor), anchor.GetSpotID()))
+		}
+		if !floor.Accepts(vehicleType) {
+			return nil, errors.NewFloorRestrictedError(FormatFloorNumber(anchor.Floor), anchor.GetSpotID(),
+				string(vehicleType))
+		}
+	}
+
+	span, err := p.spanFromLocked(anchor, vehicleType)
+	if err != nil {
+		return nil, err
+	}
+	for _, spot := range span {
+		spotID := spot.GetSpotID()
+		if spotType := spot.GetType(); spotType.IsActive() && !spotType.CanParkVehicleType(vehicleType.BaseType()) {
+			return nil, errors.NewVehicleSpotTypeMismatchError(string(vehicleType), string(spotType))
+		}
+		if !spot.HasTags(opts.Tags) {
+			return nil, errors.NewInvalidOperationError("parkAt",
+				fmt.Sprintf("spot %s does not carry the tags %s", spotID, strings.Join(opts.Tags, ", ")))
+		}
+		if !opts.Permit && spot.HasTags([]string{AccessibleTag}) {
+			return nil, errors.NewPermitRequiredError(spotID, vehicleNumber)
+		}
+	}
+
+	if err := occupySpan(span, vehicleNumber); err != nil {
+		return nil, err
+	}
+	return span, nil
 }
 
 // Unpark removes a vehicle from its parking spot
 // Returns an error if the vehicle is not parked or if the spot ID doesn't match
 func (p *ParkingLot) Unpark(spotID, vehicleNumber string) error {
+	_, err := p.UnparkWithDuration(spotID, vehicleNumber)
+	if stderrors.Is(err, errors.ErrNoParkingRecord) {
+		return nil
+	}
+	return err
+}
+
+// UnparkWithDuration removes a vehicle like Unpark and returns how long it
+// was parked, measured by the lot's clock. A vehicle without a history to
+// measure is still removed, returning zero and errors.ErrNoParkingRecord.
+func (p *ParkingLot) UnparkWithDuration(spotID, vehicleNumber string) (time.Duration, error) {
+	return p.UnparkTimed(spotID, vehicleNumber, nil)
+}
+
+// UnparkTimed removes a vehicle like UnparkWithDuration, recording into
+// timings, when set, how long finding the vehicle's spots and the
+// bookkeeping after took
+func (p *ParkingLot) UnparkTimed(spotID, vehicleNumber string, timings *OperationTimings) (time.Duration, error) {
+	duration, err := p.unparkTimed(spotID, vehicleNumber, timings)
+	if err == nil || stderrors.Is(err, errors.ErrNoParkingRecord) {
+		p.ops.unparks.Add(1)
+	}
+	return duration, err
+}
+
+// unparkTimed removes a vehicle for UnparkTimed, which counts it
+func (p *ParkingLot) unparkTimed(spotID, vehicleNumber string, timings *OperationTimings) (time.Duration, error) {
+	timings.begin()
+
 	// Validate inputs
-	if err := ValidateVehicleNumber(vehicleNumber); err != nil {
-		return err
+	if err := p.validateVehicleNumber(vehicleNumber); err != nil {
+		return 0, err
 	}
 
-	normalizedNumber := NormalizeVehicleNumber(vehicleNumber)
+	key := p.vehicleKey(vehicleNumber)
+
+	// Hold the read lock throughout so Reset waits for the unpark to finish
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	vehicleMu := p.vehicleLocks.forNumber(key)
+	vehicleMu.Lock()
+	defer vehicleMu.Unlock()
 
 	// Check if the vehicle is parked
-	spotIDObj, found := p.parkedVehicles.Load(normalizedNumber)
+	parked, found := p.parkedVehicles.get(key)
 	if !found {
-		return errors.NewVehicleNotFoundError(vehicleNumber)
+		return 0, errors.NewVehicleNotFoundError(vehicleNumber)
 	}
 
-	currentSpotID := spotIDObj.(string)
-	if currentSpotID != spotID {
-		return errors.NewInvalidOperationError("unpark",
+	// The spot holds the number as written at park time
+	normalizedNumber := parked.VehicleNumber
+
+	// Compare the IDs as the lot writes them, so any spelling Parse accepts works
+	if floor, row, column, err := p.ParseSpotID(spotID); err == nil {
+		spotID = p.FormatSpotID(floor, row, column)
+	}
+
+	// An oversized vehicle can be unparked from any of its spots
+	currentSpotID := parked.SpotID
+	if currentSpotID != spotID && !containsString(parked.SpotIDs, spotID) {
+		return 0, errors.NewInvalidOperationError("unpark",
 			fmt.Sprintf("vehicle %s is parked at spot %s, not %s",
 				vehicleNumber, currentSpotID, spotID))
 	}
 
-	// Get the spot
-	spot, err := p.GetSpotByID(spotID)
-	if err != nil {
-		return errors.WrapError(err, "RETRIEVAL_ERROR",
-			fmt.Sprintf("failed to get spot %s", spotID))
+	// Get the spots, every one of them for an oversized vehicle
+	var span []*ParkingSpot
+	for _, id := range parked.GetSpotIDs() {
+		spot, err := p.spotByIDLocked(id)
+		if err != nil {
+			return 0, errors.WrapError(err, "RETRIEVAL_ERROR",
+				fmt.Sprintf("failed to get spot %s", id))
+		}
+		span = append(span, spot)
 	}
+	timings.mark("lookup")
+
+	// Every completed step is undone if a later one fails. The spot is
+	// vacated last, as another vehicle may take it as soon as it is free.
+	tx := newTransaction(fmt.Sprintf("unpark of %s", normalizedNumber), p.loggerLocked())
+	defer tx.finish()
+
+	// Complete the parking record
+	vacatedAt := p.clockLocked().Now()
+	var duration time.Duration
+	history, recorded := p.vehicleHistory.get(key)
+	if recorded {
+		p.historyMu.Lock()
+		err := history.CompleteLastParkingRecord()
+		if err == nil {
+			record := history.GetLastParkingRecord()
+			vacatedAt = *record.UnparkedAt
+			duration = record.Duration()
+		}
+		p.historyMu.Unlock()
+		if err != nil {
+			p.loggerLocked().Warn(fmt.Sprintf("failed to complete the parking record of %s: %v",
+				normalizedNumber, err))
+			return 0, errors.WrapError(err, "HISTORY_ERROR",
+				fmt.Sprintf("failed to complete the parking record of %s", vehicleNumber))
+		}
 
-	// Vacate the spot
-	if err := spot.Vacate(normalizedNumber); err != nil {
-		return errors.WrapError(err, "VACATION_ERROR",
-			fmt.Sprintf("failed to vacate spot %s", spotID))
+		tx.onRollback(func() error {
+			p.historyMu.Lock()
+			defer p.historyMu.Unlock()
+			history.reopenLastParkingRecord()
+			return nil
+		})
+	}
+	if err := p.step("unpark.record"); err != nil {
+		return 0, err
 	}
 
 	// Remove from parked vehicles map
-	p.parkedVehicles.Delete(normalizedNumber)
+	p.parkedVehicles.delete(key)
+	tx.onRollback(func() error {
+		p.parkedVehicles.put(key, parked)
+		return nil
+	})
+	if err := p.step("unpark.index"); err != nil {
+		return 0, err
+	}
 
-	// Update vehicle history
-	historyObj, found := p.vehicleHistory.Load(normalizedNumber)
-	if found {
-		history := historyObj.(*VehicleHistory)
-		if err := history.CompleteLastParkingRecord(); err != nil {
-			// Log this error but don't fail the operation
-			fmt.Printf("Warning: failed to complete parking record: %v\n", err)
-		}
-		p.vehicleHistory.Store(normalizedNumber, history)
+	// Vacate the spots together
+	if err := vacateSpan(span, normalizedNumber); err != nil {
+		return 0, errors.WrapError(err, "VACATION_ERROR",
+			fmt.Sprintf("failed to vacate spot %s", currentSpotID))
+	}
+	tx.onRollback(func() error { return occupySpan(span, normalizedNumber) })
+	if err := p.step("unpark.vacate"); err != nil {
+		return 0, err
 	}
 
-	return nil
+	tx.commit()
+
+	if !parked.Reserved {
+		p.quotas.release(parked.VehicleType)
+	}
+	p.occupancy.recordUnpark(parked.VehicleType)
+	for _, spot := range span {
+		p.spotHistory.recordVacate(spot.GetSpotID(), normalizedNumber, vacatedAt)
+	}
+	timings.mark("bookkeeping")
+
+	if !recorded {
+		return 0, errors.ErrNoParkingRecord
+	}
+	return duration, nil
 }
 
 // AvailableSpot returns the list of available spot IDs for the given vehicle type
 func (p *ParkingLot) AvailableSpot(vehicleType VehicleType) ([]string, error) {
 	// Validate input
-	if vehicleType != VehicleTypeBicycle &&
-		vehicleType != VehicleTypeMotorcycle &&
-		vehicleType != VehicleTypeAutomobile {
-		return nil, errors.NewInvalidVehicleTypeError(string(vehicleType))
+	if err := validateSpotVehicleType(vehicleType); err != nil {
+		return nil, err
 	}
 
 	p.mu.RLock()
@@ -429,106 +1289,561 @@ func (p *ParkingLot) AvailableSpot(vehicleType VehicleType) ([]string, error) {
 	return availableSpots, nil
 }
 
+// AvailableSpotN finds at most n available spots for a vehicle type, in the
+// order Park would pick them. It stops scanning once n spots are found.
+func (p *ParkingLot) AvailableSpotN(vehicleType VehicleType, n int) ([]string, error) {
+	// Validate input
+	if err := validateSpotVehicleType(vehicleType); err != nil {
+		return nil, err
+	}
+
+	if n < 0 {
+		return nil, errors.NewValidationError("n", fmt.Sprintf("%d", n), "limit cannot be negative")
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	availableSpots := make([]string, 0, n)
+	for _, floor := range p.floors {
+		if len(availableSpots) == n {
+			break
+		}
+
+		for _, spot := range floor.GetAvailableSpotsN(vehicleType, n-len(availableSpots)) {
+			availableSpots = append(availableSpots, spot.GetSpotID())
+		}
+	}
+
+	return availableSpots, nil
+}
+
+// ForEachAvailableSpot calls fn with the floor number and ID of every
+// available spot for a vehicle type, floor by floor in the order of
+// AvailableSpotsByFloor, until fn returns false. The lot is read locked
+// throughout, so fn must not park or unpark.
+func (p *ParkingLot) ForEachAvailableSpot(vehicleType VehicleType, fn func(floor int, spotID string) bool) error {
+	// Validate input
+	if err := validateSpotVehicleType(vehicleType); err != nil {
+		return err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, floor := range p.floors {
+		more := true
+		floor.ForEachAvailableSpot(vehicleType, func(spot *ParkingSpot) bool {
+			more = fn(floor.FloorNumber, spot.GetSpotID())
+			return more
+		})
+		if !more {
+			break
+		}
+	}
+
+	return nil
+}
+
+// CountAvailableSpots returns the number of available spots for a vehicle type
+// without building the list of spot IDs
+func (p *ParkingLot) CountAvailableSpots(vehicleType VehicleType) (int, error) {
+	// Validate input
+	if err := validateSpotVehicleType(vehicleType); err != nil {
+		return 0, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	count := 0
+	for _, floor := range p.floors {
+		count += floor.CountAvailableSpots(vehicleType)
+	}
+
+	return count, nil
+}
+
+// SetEntrance moves the entrance to the given location, which must be a spot
+// position on an existing floor
+func (p *ParkingLot) SetEntrance(floorNum, row, column int) error {
+	floor, err := p.GetFloor(floorNum)
+	if err != nil {
+		return err
+	}
+
+	if _, err := floor.GetSpot(row, column); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.entrance = Entrance{Floor: floorNum, Row: row, Column: column}
+	return nil
+}
+
+// GetEntrance returns the location of the entrance
+func (p *ParkingLot) GetEntrance() Entrance {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.entrance
+}
+
+// WithClock creates the lot timestamping parking with clock
+func WithClock(clock Clock) LotOption {
+	return func(p *ParkingLot) {
+		p.clock = clock
+	}
+}
+
+// SetClock sets the clock the lot timestamps parking with, nil for the system time.
+// Vehicles keep the clock they first parked with, so set it before parking.
+func (p *ParkingLot) SetClock(clock Clock) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.clock = clock
+}
+
+// GetClock returns the clock the lot timestamps parking with
+func (p *ParkingLot) GetClock() Clock {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.clockLocked()
+}
+
+// clockLocked returns the lot's clock; p.mu must be held
+func (p *ParkingLot) clockLocked() Clock {
+	if p.clock == nil {
+		return RealClock{}
+	}
+	return p.clock
+}
+
+// now returns the current time of the lot's clock
+func (p *ParkingLot) now() time.Time {
+	return p.GetClock().Now()
+}
+
+// distanceTo returns the driving distance from the entrance to a spot:
+// rows plus columns, with each floor change counting as FloorChangeDistance
+func (e Entrance) distanceTo(spot *ParkingSpot) int {
+	distance := abs(spot.Row-e.Row) + abs(spot.Column-e.Column)
+	return distance + FloorChangeDistance*abs(spot.Floor-e.Floor)
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// NearestAvailableSpots returns up to n available spots for a vehicle type,
+// closest to the entrance first. Spots at the same distance are ordered by ID.
+func (p *ParkingLot) NearestAvailableSpots(vehicleType VehicleType, n int) ([]SpotDistance, error) {
+	// Validate input
+	if err := validateSpotVehicleType(vehicleType); err != nil {
+		return nil, err
+	}
+
+	if n < 0 {
+		return nil, errors.NewValidationError("n", fmt.Sprintf("%d", n), "limit cannot be negative")
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var candidates []SpotDistance
+	for _, floor := range p.floors {
+		for _, spot := range floor.GetAvailableSpots(vehicleType) {
+			candidates = append(candidates, SpotDistance{
+				SpotID:   spot.GetSpotID(),
+				Distance: p.entrance.distanceTo(spot),
+			})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Distance != candidates[j].Distance {
+			return candidates[i].Distance < candidates[j].Distance
+		}
+		return candidates[i].SpotID < candidates[j].SpotID
+	})
+
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+
+	return candidates, nil
+}
+
+// AvailableSpotsByFloor finds available spots for a vehicle type grouped by
+// floor number. Spots are ordered by row, then column; floors without
+// available spots are left out.
+func (p *ParkingLot) AvailableSpotsByFloor(vehicleType VehicleType) (map[int][]string, error) {
+	// Validate input
+	if err := validateSpotVehicleType(vehicleType); err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	spotsByFloor := make(map[int][]string)
+	for _, floor := range p.floors {
+		// GetAvailableSpots walks the floor row by row
+		spots := floor.GetAvailableSpots(vehicleType)
+		if len(spots) == 0 {
+			continue
+		}
+
+		spotIDs := make([]string, len(spots))
+		for i, spot := range spots {
+			spotIDs[i] = spot.GetSpotID()
+		}
+		spotsByFloor[floor.FloorNumber] = spotIDs
+	}
+
+	return spotsByFloor, nil
+}
+
 // GetAvailableSpotCount returns the number of available spots for each vehicle type
 func (p *ParkingLot) GetAvailableSpotCountByType() map[VehicleType]int {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
 	counts := make(map[VehicleType]int)
-	counts[VehicleTypeBicycle] = 0
-	counts[VehicleTypeMotorcycle] = 0
-	counts[VehicleTypeAutomobile] = 0
+	for _, vehicleType := range spotVehicleTypes() {
+		counts[vehicleType] = 0
+	}
 
 	// Count available spots of each type
 	for _, floor := range p.floors {
-		for _, vehicleType := range []VehicleType{
-			VehicleTypeBicycle,
-			VehicleTypeMotorcycle,
-			VehicleTypeAutomobile,
-		} {
-			spots := floor.GetAvailableSpots(vehicleType)
-			counts[vehicleType] += len(spots)
+		for vehicleType := range counts {
+			counts[vehicleType] += floor.CountAvailableSpots(vehicleType)
+		}
+	}
+
+	return counts
+}
+
+// GetAvailableCapacityByType returns, for each vehicle type, how many more
+// vehicles of that type the lot can hold. Spot counts stay physical: a bicycle
+// rack with three free slots is one available spot but three units of capacity.
+func (p *ParkingLot) GetAvailableCapacityByType() map[VehicleType]int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	counts := make(map[VehicleType]int)
+	for _, vehicleType := range spotVehicleTypes() {
+		counts[vehicleType] = 0
+	}
+
+	for _, floor := range p.floors {
+		for vehicleType, count := range floor.GetAvailableCapacityByType() {
+			counts[vehicleType] += count
+		}
+	}
+
+	return counts
+}
+
+// GetOccupiedCapacityByType returns, for each vehicle type, how many vehicles
+// of that type are currently parked in the lot
+func (p *ParkingLot) GetOccupiedCapacityByType() map[VehicleType]int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	counts := make(map[VehicleType]int)
+	for _, vehicleType := range spotVehicleTypes() {
+		counts[vehicleType] = 0
+	}
+
+	for _, floor := range p.floors {
+		for vehicleType, count := range floor.GetOccupiedCapacityByType() {
+			counts[vehicleType] += count
 		}
 	}
 
 	return counts
 }
 
+// SetSpotCapacity sets how many vehicles the spot with the given ID can hold
+// Only bicycle spots can be turned into multi-bicycle racks
+func (p *ParkingLot) SetSpotCapacity(spotID string, capacity int) error {
+	spot, err := p.GetSpotByID(spotID)
+	if err != nil {
+		return err
+	}
+
+	return spot.SetCapacity(capacity)
+}
+
+// SetSpotType changes the type of the spot with the given ID
+// The spot must be empty; the change applies to counts and availability at once
+func (p *ParkingLot) SetSpotType(spotID string, spotType SpotType) error {
+	spot, err := p.GetSpotByID(spotID)
+	if err != nil {
+		return err
+	}
+
+	// Hold the write lock so no park is in flight while the type changes
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return spot.SetType(spotType)
+}
+
 // SearchVehicle finds a vehicle in the parking lot by its number
 // Returns the spot ID where the vehicle is parked, or the last spot ID if unparked
 func (p *ParkingLot) SearchVehicle(vehicleNumber string) (string, bool, error) {
-	if err := ValidateVehicleNumber(vehicleNumber); err != nil {
+	info, err := p.SearchVehicleDetailed(vehicleNumber)
+	if err != nil {
 		return "", false, err
 	}
+	return info.SpotID, info.IsParked, nil
+}
 
-	normalizedNumber := NormalizeVehicleNumber(vehicleNumber)
+// SearchInfo describes where a vehicle is parked, or was last seen
+type SearchInfo struct {
+	VehicleNumber string
+	VehicleType   VehicleType
+	IsParked      bool
 
+	// Spot the vehicle is parked at or last left, empty when unknown,
+	// and the floor of that spot
+	SpotID string
+	Floor  int
+
+	// Label of that spot, empty when it has none or no longer exists
+	SpotLabel string
+
+	// When the vehicle parked at the spot, and when it left; zero when
+	// unknown or, for LastSeenAt, while still parked
+	ParkedAt   time.Time
+	LastSeenAt time.Time
+}
+
+// SearchVehicleDetailed finds a vehicle like SearchVehicle and describes it
+// A vehicle with a history but no known spot is reported as not found,
+// along with what its history still knows
+func (p *ParkingLot) SearchVehicleDetailed(vehicleNumber string) (*SearchInfo, error) {
+	p.ops.searches.Add(1)
+	if err := p.validateVehicleNumber(vehicleNumber); err != nil {
+		return nil, err
+	}
+
+	key := p.vehicleKey(vehicleNumber)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	info := p.searchInfoLocked(key)
+	if info == nil || info.SpotID == "" {
+		return info, errors.NewVehicleNotFoundError(vehicleNumber)
+	}
+
+	return info, nil
+}
+
+// searchInfoLocked describes a vehicle from the parked vehicles and the
+// histories, nil when the lot has never seen it; p.mu must be held
+func (p *ParkingLot) searchInfoLocked(key string) *SearchInfo {
 	// Check if the vehicle is currently parked
-	if spotIDObj, found := p.parkedVehicles.Load(normalizedNumber); found {
-		return spotIDObj.(string), true, nil
+	if parked, found := p.parkedVehicles.get(key); found {
+		floor, _, _, _ := p.ParseSpotID(parked.SpotID)
+		return p.withSpotLabelLocked(&SearchInfo{
+			VehicleNumber: parked.VehicleNumber,
+			VehicleType:   parked.VehicleType,
+			IsParked:      true,
+			SpotID:        parked.SpotID,
+			Floor:         floor,
+			ParkedAt:      parked.ParkedAt,
+		})
 	}
 
 	// Check if the vehicle has parking history
-	historyObj, found := p.vehicleHistory.Load(normalizedNumber)
+	history, found := p.vehicleHistory.get(key)
 	if !found {
-		return "", false, errors.NewVehicleNotFoundError(vehicleNumber)
+		return nil
+	}
+
+	info := &SearchInfo{VehicleNumber: key}
+	p.historyMu.Lock()
+	if history.Vehicle != nil {
+		info.VehicleNumber = history.Vehicle.Number
+		info.VehicleType = history.Vehicle.Type
+	}
+	info.SpotID = history.GetLastSpotID()
+	if record := history.GetLastParkingRecord(); record != nil {
+		info.ParkedAt = record.ParkedAt
+		if record.UnparkedAt != nil {
+			info.LastSeenAt = *record.UnparkedAt
+		}
+	}
+	p.historyMu.Unlock()
+
+	if info.SpotID != "" {
+		info.Floor, _, _, _ = p.ParseSpotID(info.SpotID)
+	}
+
+	return p.withSpotLabelLocked(info)
+}
+
+// withSpotLabelLocked fills in the label of the info's spot; p.mu must be held
+func (p *ParkingLot) withSpotLabelLocked(info *SearchInfo) *SearchInfo {
+	if info.SpotID == "" {
+		return info
 	}
 
-	history := historyObj.(*VehicleHistory)
-	lastSpotID := history.GetLastSpotID()
-	if lastSpotID == "" {
-		return "", false, errors.NewVehicleNotFoundError(vehicleNumber)
+	// The spot a vehicle last left may have been removed since
+	if spot, err := p.spotByIDLocked(info.SpotID); err == nil {
+		info.SpotLabel = spot.GetLabel()
 	}
 
-	return lastSpotID, false, nil
+	return info
 }
 
 // GetParkedVehicleCount returns the total number of vehicles currently parked
 func (p *ParkingLot) GetParkedVehicleCount() int {
-	count := 0
-	p.parkedVehicles.Range(func(_, _ interface{}) bool {
-		count++
-		return true
-	})
-	return count
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.parkedVehicles.count()
 }
 
-// GetAllParkedVehicles returns all currently parked vehicles
-func (p *ParkingLot) GetAllParkedVehicles() map[string]string {
-	vehicles := make(map[string]string)
-	p.parkedVehicles.Range(func(k, v interface{}) bool {
-		vehicleNumber := k.(string)
-		spotID := v.(string)
-		vehicles[vehicleNumber] = spotID
+// GetAllParkedVehicles returns all currently parked vehicles sorted by vehicle number
+func (p *ParkingLot) GetAllParkedVehicles() []ParkedVehicle {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var vehicles []ParkedVehicle
+	p.parkedVehicles.each(func(_ string, parked ParkedVehicle) bool {
+		vehicles = append(vehicles, parked)
 		return true
 	})
+
+	sort.Slice(vehicles, func(i, j int) bool {
+		return vehicles[i].VehicleNumber < vehicles[j].VehicleNumber
+	})
 	return vehicles
 }
 
-// Reset removes all vehicles from the parking lot and clears history
-func (p *ParkingLot) Reset() {
+// Reset removes all vehicles from the parking lot and clears history,
+// returning the number of vehicles and parking records removed
+func (p *ParkingLot) Reset() (vehicles, records int) {
+	return p.ResetWithOptions(ResetOptions{})
+}
+
+// ResetOptions say what else a reset clears
+type ResetOptions struct {
+	// ZeroOperationStats sets the counts of OperationStats back to zero,
+	// the reset itself included
+	ZeroOperationStats bool
+}
+
+// ResetWithOptions resets the lot like Reset, clearing what the options say
+func (p *ParkingLot) ResetWithOptions(opts ResetOptions) (vehicles, records int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if opts.ZeroOperationStats {
+		p.ops.zero()
+	} else {
+		p.ops.resets.Add(1)
+	}
+
+	vehicles = p.clearVehiclesLocked()
+	records = p.clearHistoryLocked()
+
+	// Forget the peaks too, unlike ClearVehicles and ClearHistory
+	p.occupancy.reset()
+	p.quotas.reset()
+
+	return vehicles, records
+}
+
+// ClearVehicles removes every parked vehicle as if each had unparked now,
+// completing its parking record, so the history of the lot is kept
+// Returns the number of vehicles removed
+func (p *ParkingLot) ClearVehicles() int {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	// Reset parking spots
+	return p.clearVehiclesLocked()
+}
+
+// clearVehiclesLocked removes every parked vehicle; p.mu must be held for writing
+func (p *ParkingLot) clearVehiclesLocked() int {
+	vacatedAt := p.clockLocked().Now()
+
+	removed := 0
+	p.parkedVehicles.each(func(key string, parked ParkedVehicle) bool {
+		if history, recorded := p.vehicleHistory.get(key); recorded && history.IsCurrentlyParked() {
+			p.historyMu.Lock()
+			_ = history.CompleteLastParkingRecord()
+			p.historyMu.Unlock()
+		}
+
+		if !parked.Reserved {
+			p.quotas.release(parked.VehicleType)
+		}
+		p.occupancy.recordUnpark(parked.VehicleType)
+		for _, spotID := range parked.GetSpotIDs() {
+			p.spotHistory.recordVacate(spotID, parked.VehicleNumber, vacatedAt)
+		}
+
+		removed++
+		return true
+	})
+
+	// Vacate every spot, ignoring errors as we're forcefully clearing;
+	// a spot not made yet holds no vehicle
 	for _, floor := range p.floors {
-		rows, cols := floor.GetDimensions()
-		for r := 0; r < rows; r++ {
-			for c := 0; c < cols; c++ {
-				spot, err := floor.GetSpot(r, c)
-				if err != nil {
-					continue
-				}
-
-				if spot.IsOccupied() {
-					// Ignore errors as we're forcefully resetting
-					_ = spot.Vacate(spot.GetVehicleNumber())
-				}
+		floor.forEachMadeSpot(func(spot *ParkingSpot) bool {
+			for _, vehicleNumber := range spot.GetVehicleNumbers() {
+				_ = spot.Vacate(vehicleNumber)
 			}
-		}
+			return true
+		})
 	}
 
-	// Clear maps
-	p.parkedVehicles = sync.Map{}
-	p.vehicleHistory = sync.Map{}
+	// Clear the map in place; replacing it would race with readers
+	p.parkedVehicles.clear()
+
+	return removed
+}
+
+// ClearHistory drops every completed parking record and past spot
+// occupancy, keeping those of the vehicles still parked. Vehicles left with
+// no record are forgotten, so search no longer finds where they were.
+// Returns the number of parking records removed
+func (p *ParkingLot) ClearHistory() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.clearHistoryLocked()
+}
+
+// clearHistoryLocked drops every completed record; p.mu must be held for writing
+func (p *ParkingLot) clearHistoryLocked() int {
+	p.historyMu.Lock()
+	defer p.historyMu.Unlock()
+
+	removed := 0
+	p.vehicleHistory.each(func(key string, history *VehicleHistory) bool {
+		removed += history.removeCompleted(func(_ *ParkingRecord) bool { return true })
+		if len(history.Records) == 0 {
+			p.vehicleHistory.delete(key)
+		}
+		return true
+	})
+	p.spotHistory.removeVacated()
+
+	return removed
 }
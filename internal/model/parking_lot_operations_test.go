@@ -1,6 +1,7 @@
 package model
 
 import (
+	stderrors "errors"
 	"fmt"
 	"sync"
 	"testing"
@@ -82,6 +83,42 @@ func TestPark(t *testing.T) {
 	}
 }
 
+func TestParkDetailed(t *testing.T) {
+	lot, _ := CreateParkingLot("Park Test Lot", 2, 3, 4)
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	lot.SetClock(NewFakeClock(now))
+
+	detail, err := lot.ParkDetailed(VehicleTypeAutomobile, "ka-01-hh-1234")
+	if err != nil {
+		t.Fatalf("Failed to park vehicle: %v", err)
+	}
+
+	spot, err := lot.GetSpotByID(detail.SpotID)
+	if err != nil {
+		t.Fatalf("Failed to get spot %s: %v", detail.SpotID, err)
+	}
+	if detail.Floor != spot.Floor || detail.Row != spot.Row || detail.Column != spot.Column {
+		t.Errorf("Expected the location of %s, got %d-%d-%d",
+			detail.SpotID, detail.Floor, detail.Row, detail.Column)
+	}
+	if detail.VehicleType != VehicleTypeAutomobile || detail.NormalizedNumber != "KA-01-HH-1234" {
+		t.Errorf("Unexpected vehicle in result: %s %s", detail.VehicleType, detail.NormalizedNumber)
+	}
+	if !detail.ParkedAt.Equal(now) {
+		t.Errorf("Expected parked at %v, got %v", now, detail.ParkedAt)
+	}
+
+	// The time matches the vehicle's parking record
+	parked := lot.GetAllParkedVehicles()[0]
+	if !parked.ParkedAt.Equal(detail.ParkedAt) || parked.SpotID != detail.SpotID {
+		t.Errorf("Expected %+v to match the parked vehicle %+v", detail, parked)
+	}
+
+	if _, err := lot.ParkDetailed(VehicleTypeAutomobile, "KA-01-HH-1234"); err == nil {
+		t.Error("Expected error when parking the same vehicle again")
+	}
+}
+
 func TestUnpark(t *testing.T) {
 	lot, _ := CreateParkingLot("Unpark Test Lot", 2, 3, 4)
 
@@ -146,6 +183,48 @@ func TestUnpark(t *testing.T) {
 	}
 }
 
+func TestUnparkWithDuration(t *testing.T) {
+	lot, _ := CreateParkingLot("Unpark Test Lot", 2, 3, 4)
+	clock := NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	lot.SetClock(clock)
+
+	spotID, _ := lot.Park(VehicleTypeAutomobile, "KA-01-HH-1234")
+	clock.Advance(2*time.Hour + 13*time.Minute)
+
+	duration, err := lot.UnparkWithDuration(spotID, "KA-01-HH-1234")
+	if err != nil {
+		t.Fatalf("Failed to unpark vehicle: %v", err)
+	}
+	if duration != 2*time.Hour+13*time.Minute {
+		t.Errorf("Expected a duration of 2h13m, got %v", duration)
+	}
+
+	// A failed unpark measures nothing
+	if duration, err := lot.UnparkWithDuration(spotID, "KA-01-HH-1234"); err == nil || duration != 0 {
+		t.Errorf("Expected an error and no duration unparking again, got %v, %v", duration, err)
+	}
+
+	// Without a history the vehicle still leaves, but there is nothing to measure
+	spotID, _ = lot.Park(VehicleTypeAutomobile, "KA-01-HH-5678")
+	lot.vehicleHistory.delete("KA-01-HH-5678")
+	clock.Advance(time.Hour)
+
+	duration, err = lot.UnparkWithDuration(spotID, "KA-01-HH-5678")
+	if !stderrors.Is(err, errors.ErrNoParkingRecord) || duration != 0 {
+		t.Errorf("Expected ErrNoParkingRecord and no duration, got %v, %v", duration, err)
+	}
+	if lot.IsVehicleParked("KA-01-HH-5678") || lot.GetOccupiedSpotCount() != 0 {
+		t.Error("Expected the vehicle without history to be unparked")
+	}
+
+	// Unpark hides the missing history
+	spotID, _ = lot.Park(VehicleTypeAutomobile, "KA-01-HH-5678")
+	lot.vehicleHistory.delete("KA-01-HH-5678")
+	if err := lot.Unpark(spotID, "KA-01-HH-5678"); err != nil {
+		t.Errorf("Expected Unpark to succeed without history, got %v", err)
+	}
+}
+
 func TestAvailableSpot(t *testing.T) {
 	lot, _ := CreateParkingLot("Available Test Lot", 2, 3, 4)
 
@@ -267,6 +346,58 @@ func TestSearchVehicle(t *testing.T) {
 	}
 }
 
+func TestSearchVehicleDetailed(t *testing.T) {
+	lot, _ := CreateParkingLot("Search Test Lot", 2, 3, 4)
+	clock := NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	lot.SetClock(clock)
+
+	// A vehicle never seen is not found and nothing is known about it
+	info, err := lot.SearchVehicleDetailed("KA-01-HH-1234")
+	var notFoundErr *errors.VehicleNotFoundError
+	if !stderrors.As(err, &notFoundErr) || info != nil {
+		t.Errorf("Expected not found and no info, got %+v, %v", info, err)
+	}
+
+	// A parked vehicle reports its spot, floor, type and parked time
+	detail, _ := lot.ParkDetailed(VehicleTypeMotorcycle, "ka-01-hh-1234")
+	info, err = lot.SearchVehicleDetailed("KA-01-HH-1234")
+	if err != nil {
+		t.Fatalf("Failed to search for parked vehicle: %v", err)
+	}
+	expected := SearchInfo{
+		VehicleNumber: "KA-01-HH-1234",
+		VehicleType:   VehicleTypeMotorcycle,
+		IsParked:      true,
+		SpotID:        detail.SpotID,
+		Floor:         detail.Floor,
+		ParkedAt:      detail.ParkedAt,
+	}
+	if *info != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *info)
+	}
+
+	// A vehicle that left reports where it was and when it was last seen
+	clock.Advance(90 * time.Minute)
+	_ = lot.Unpark(detail.SpotID, "KA-01-HH-1234")
+	info, err = lot.SearchVehicleDetailed("KA-01-HH-1234")
+	if err != nil {
+		t.Fatalf("Failed to search for unparked vehicle: %v", err)
+	}
+	expected.IsParked = false
+	expected.LastSeenAt = detail.ParkedAt.Add(90 * time.Minute)
+	if *info != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *info)
+	}
+
+	// A history without any spot is not found, but still gives the type
+	history, _ := lot.GetVehicleHistory("KA-01-HH-1234")
+	history.Records = nil
+	info, err = lot.SearchVehicleDetailed("KA-01-HH-1234")
+	if !stderrors.As(err, &notFoundErr) || info == nil || info.VehicleType != VehicleTypeMotorcycle {
+		t.Errorf("Expected not found with the vehicle type, got %+v, %v", info, err)
+	}
+}
+
 func TestReset(t *testing.T) {
 	lot, _ := CreateParkingLot("Reset Test Lot", 2, 3, 4)
 
@@ -313,6 +444,91 @@ func TestReset(t *testing.T) {
 	}
 }
 
+func TestClearVehicles(t *testing.T) {
+	lot := newQuotaLot(t, 3)
+	lot.SetClock(NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)))
+	if err := lot.SetQuota(VehicleTypeAutomobile, 2); err != nil {
+		t.Fatalf("Failed to set quota: %v", err)
+	}
+
+	for _, number := range []string{"KA-01-HH-0001", "KA-01-HH-0002"} {
+		if _, err := lot.Park(VehicleTypeAutomobile, number); err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+	}
+
+	if removed := lot.ClearVehicles(); removed != 2 {
+		t.Errorf("Expected 2 vehicles removed, got %d", removed)
+	}
+	if lot.GetParkedVehicleCount() != 0 || lot.GetOccupiedSpotCount() != 0 {
+		t.Errorf("Expected the lot empty, got %d vehicles in %d spots",
+			lot.GetParkedVehicleCount(), lot.GetOccupiedSpotCount())
+	}
+	checkVehicleIndexes(t, lot)
+
+	// The vehicles' records are completed, not dropped
+	records, err := lot.GetVehicleRecords("KA-01-HH-0001")
+	if err != nil || len(records) != 1 || !records[0].IsComplete() {
+		t.Errorf("Expected one completed record, got %v (%v)", records, err)
+	}
+	if history, err := lot.GetSpotHistory("0-0-0", 0); err != nil || len(history) != 1 || history[0].VacatedAt == nil {
+		t.Errorf("Expected the spot's occupancy kept and ended, got %v (%v)", history, err)
+	}
+
+	// The peak is kept while the quota is given back
+	if peak := lot.GetPeakOccupancy().Overall.Count; peak != 2 {
+		t.Errorf("Expected the peak of 2 kept, got %d", peak)
+	}
+	if quotas := lot.GetQuotas(); fmt.Sprint(quotas) != "[{AUTOMOBILE 2 0}]" {
+		t.Errorf("Expected the quota released, got %v", quotas)
+	}
+}
+
+func TestClearHistory(t *testing.T) {
+	lot := newQuotaLot(t, 3)
+	lot.SetClock(NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)))
+
+	for _, number := range []string{"KA-01-HH-0001", "KA-01-HH-0002"} {
+		if _, err := lot.Park(VehicleTypeAutomobile, number); err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+	}
+	if err := lot.Unpark("0-0-0", "KA-01-HH-0001"); err != nil {
+		t.Fatalf("Failed to unpark: %v", err)
+	}
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-0001"); err != nil {
+		t.Fatalf("Failed to park again: %v", err)
+	}
+	if err := lot.Unpark("0-0-0", "KA-01-HH-0001"); err != nil {
+		t.Fatalf("Failed to unpark again: %v", err)
+	}
+
+	if removed := lot.ClearHistory(); removed != 2 {
+		t.Errorf("Expected 2 records removed, got %d", removed)
+	}
+
+	// The parked vehicle keeps its current record and spot
+	records, err := lot.GetVehicleRecords("KA-01-HH-0002")
+	if err != nil || len(records) != 1 || records[0].IsComplete() {
+		t.Errorf("Expected the open record kept, got %v (%v)", records, err)
+	}
+	if spotID, _, err := lot.SearchVehicle("KA-01-HH-0002"); err != nil || spotID != "0-0-1" {
+		t.Errorf("Expected KA-01-HH-0002 still at 0-0-1, got %s (%v)", spotID, err)
+	}
+	if history, _ := lot.GetSpotHistory("0-0-1", 0); len(history) != 1 {
+		t.Errorf("Expected the current occupancy kept, got %v", history)
+	}
+
+	// The vehicle that left is forgotten
+	if _, err := lot.GetVehicleRecords("KA-01-HH-0001"); !stderrors.Is(err, errors.ErrVehicleNotFound) {
+		t.Errorf("Expected the departed vehicle forgotten, got %v", err)
+	}
+	if history, _ := lot.GetSpotHistory("0-0-0", 0); len(history) != 0 {
+		t.Errorf("Expected the past occupancies dropped, got %v", history)
+	}
+	checkVehicleIndexes(t, lot)
+}
+
 func TestConcurrentOperations(t *testing.T) {
 	lot, _ := CreateParkingLot("Concurrent Test Lot", 3, 10, 10)
 
@@ -386,9 +602,8 @@ func TestConcurrentOperations(t *testing.T) {
 	}
 
 	// Verify each parked vehicle can be found at its spot
-	lot.parkedVehicles.Range(func(k, v interface{}) bool {
-		vehicleNumber := k.(string)
-		spotID := v.(string)
+	lot.parkedVehicles.each(func(vehicleNumber string, parked ParkedVehicle) bool {
+		spotID := parked.SpotID
 
 		spot, err := lot.GetSpotByID(spotID)
 		if err != nil {
@@ -479,3 +694,342 @@ func TestConcurrentFullParking(t *testing.T) {
 	t.Logf("Concurrent parking results - Success: %d, Failed: %d",
 		successCount, failCount)
 }
+
+// checkVehicleIndexes fails the test unless every occupied spot is known to
+// the parked vehicle index and every indexed vehicle occupies its spots
+func checkVehicleIndexes(t *testing.T, lot *ParkingLot) {
+	t.Helper()
+
+	parked := make(map[string][]string)
+	for _, vehicle := range lot.GetAllParkedVehicles() {
+		parked[vehicle.VehicleNumber] = vehicle.GetSpotIDs()
+	}
+	occupants := make(map[string]int)
+	for _, spot := range lot.ListSpots(SpotFilter{}) {
+		for _, vehicleNumber := range spot.Vehicles {
+			occupants[vehicleNumber]++
+			if !containsString(parked[vehicleNumber], spot.SpotID) {
+				t.Errorf("Spot %s is occupied by %s, which the index has at %q",
+					spot.SpotID, vehicleNumber, parked[vehicleNumber])
+			}
+		}
+	}
+	for vehicleNumber, spots := range occupants {
+		if spots != len(parked[vehicleNumber]) {
+			t.Errorf("Vehicle %s occupies %d spots, the index has %q", vehicleNumber, spots, parked[vehicleNumber])
+		}
+	}
+
+	if len(occupants) != len(parked) {
+		t.Errorf("Expected %d occupants to match the %d indexed vehicles", len(occupants), len(parked))
+	}
+	if count := int(lot.occupancy.overall.current.Load()); count != len(parked) {
+		t.Errorf("Expected occupancy counter %d to match the %d indexed vehicles", count, len(parked))
+	}
+}
+
+// slowClock is a clock that takes a moment to tell the time
+type slowClock struct {
+	Clock
+}
+
+func (c slowClock) Now() time.Time {
+	time.Sleep(time.Millisecond)
+	return c.Clock.Now()
+}
+
+func TestConcurrentParkSameVehicle(t *testing.T) {
+	lot, err := CreateParkingLot("Duplicate Park Lot", 2, 5, 10)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	// A slow clock widens the window between checking and recording a park
+	lot.SetClock(slowClock{NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))})
+
+	const attempts = 50
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var successes []string
+	failures := 0
+	start := make(chan struct{})
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			spotID, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-1234")
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if _, ok := err.(*errors.VehicleAlreadyParkedError); !ok {
+					t.Errorf("Expected VehicleAlreadyParkedError, got %T: %v", err, err)
+				}
+				failures++
+				return
+			}
+			successes = append(successes, spotID)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if len(successes) != 1 || failures != attempts-1 {
+		t.Fatalf("Expected exactly one park to succeed, got %d successes %v and %d failures",
+			len(successes), successes, failures)
+	}
+	if occupied := lot.GetOccupiedSpotCount(); occupied != 1 {
+		t.Errorf("Expected exactly one occupied spot, got %d", occupied)
+	}
+	checkVehicleIndexes(t, lot)
+}
+
+func TestConcurrentResetWithParkUnpark(t *testing.T) {
+	lot, err := CreateParkingLot("Reset Race Lot", 2, 5, 10)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	const workers = 8
+	const rounds = 200
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			vehicleTypes := []VehicleType{VehicleTypeBicycle, VehicleTypeMotorcycle, VehicleTypeAutomobile}
+			for i := 0; i < rounds; i++ {
+				number := fmt.Sprintf("RR-%02d-%03d", w, i)
+				spotID, err := lot.Park(vehicleTypes[i%3], number)
+				if err != nil {
+					continue
+				}
+
+				// Unpark some vehicles again; a reset in between makes this fail
+				if i%3 == 0 {
+					_ = lot.Unpark(spotID, number)
+				}
+			}
+		}(w)
+	}
+
+	// Reset repeatedly while vehicles come and go
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			lot.Reset()
+			_ = lot.GetParkingStats()
+			_ = lot.QueryHistory(time.Time{}, time.Now())
+		}
+	}()
+
+	wg.Wait()
+	<-done
+
+	checkVehicleIndexes(t, lot)
+
+	// A final reset leaves nothing behind
+	lot.Reset()
+	checkVehicleIndexes(t, lot)
+	if count := lot.GetOccupiedSpotCount(); count != 0 {
+		t.Errorf("Expected no occupied spots after reset, got %d", count)
+	}
+}
+
+func TestStringConcurrentWithWriters(t *testing.T) {
+	lot, err := CreateParkingLot("String Lot", 2, 3, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	stop := make(chan struct{})
+	var writers sync.WaitGroup
+	writers.Add(1)
+	go func() {
+		defer writers.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			_ = lot.SetEntrance(i%2, 0, i%5)
+		}
+	}()
+
+	// A reader that took the lot lock twice would deadlock once a writer
+	// queued between the two acquisitions
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var readers sync.WaitGroup
+		for r := 0; r < 4; r++ {
+			readers.Add(1)
+			go func() {
+				defer readers.Done()
+				for i := 0; i < 2000; i++ {
+					_ = lot.String()
+					_ = lot.GetAvailableSpotCount()
+				}
+			}()
+		}
+		readers.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("String deadlocked with a concurrent writer")
+	}
+	close(stop)
+	writers.Wait()
+
+	expected := fmt.Sprintf("String Lot: 2 floors, 30 total spots, %d active, 0 occupied, %d available",
+		lot.GetActiveSpotCount(), lot.GetAvailableSpotCount())
+	if got := lot.String(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestParkingLotVehicleNumberRule(t *testing.T) {
+	validator, err := NewVehicleNumberValidator(indianPlateRule)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	strictLot, err := CreateParkingLot("Strict Lot", 1, 2, 2, WithVehicleNumberValidator(validator))
+	if err != nil {
+		t.Fatalf("Failed to create strict lot: %v", err)
+	}
+	defaultLot, err := CreateParkingLot("Default Lot", 1, 2, 2)
+	if err != nil {
+		t.Fatalf("Failed to create default lot: %v", err)
+	}
+
+	// Both lots take a proper plate through park, search and unpark
+	for _, lot := range []*ParkingLot{strictLot, defaultLot} {
+		spotID, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-1234")
+		if err != nil {
+			t.Fatalf("%s: failed to park: %v", lot.GetName(), err)
+		}
+		if _, _, err := lot.SearchVehicle("KA-01-HH-1234"); err != nil {
+			t.Errorf("%s: failed to search: %v", lot.GetName(), err)
+		}
+		if err := lot.Unpark(spotID, "KA-01-HH-1234"); err != nil {
+			t.Errorf("%s: failed to unpark: %v", lot.GetName(), err)
+		}
+	}
+
+	// Only the default lot takes a short number
+	spotID, err := defaultLot.Park(VehicleTypeAutomobile, "B-12345")
+	if err != nil {
+		t.Fatalf("Default lot failed to park B-12345: %v", err)
+	}
+
+	if _, err := strictLot.Park(VehicleTypeAutomobile, "B-12345"); !stderrors.Is(err, errors.ErrInvalidVehicleNumber) {
+		t.Errorf("Expected strict lot to reject B-12345 as an invalid number, got %v", err)
+	}
+	if _, _, err := strictLot.SearchVehicle("B-12345"); !stderrors.Is(err, errors.ErrInvalidVehicleNumber) {
+		t.Errorf("Expected strict lot search to reject B-12345, got %v", err)
+	}
+	if err := strictLot.Unpark(spotID, "B-12345"); !stderrors.Is(err, errors.ErrInvalidVehicleNumber) {
+		t.Errorf("Expected strict lot unpark to reject B-12345, got %v", err)
+	}
+
+	// A rule looser than the default is honoured too
+	loose, err := NewVehicleNumberValidator(VehicleNumberRule{Pattern: `[A-Z0-9#]+`})
+	if err != nil {
+		t.Fatalf("Failed to create loose validator: %v", err)
+	}
+	looseLot, err := CreateParkingLot("Loose Lot", 1, 2, 2, WithVehicleNumberValidator(loose))
+	if err != nil {
+		t.Fatalf("Failed to create loose lot: %v", err)
+	}
+	if _, err := looseLot.Park(VehicleTypeAutomobile, "TEMP#42"); err != nil {
+		t.Errorf("Expected loose lot to park TEMP#42, got %v", err)
+	}
+	if history, found := looseLot.GetVehicleHistory("TEMP#42"); !found || history.Vehicle == nil {
+		t.Errorf("Expected a history with its vehicle for TEMP#42, got %+v", history)
+	}
+}
+
+func TestSeparatorInsensitiveMatching(t *testing.T) {
+	forms := []string{"KA01HH1234", "KA-01-HH-1234", "KA 01 HH 1234", "ka-01 hh1234"}
+
+	// Whichever form parks, every form finds and unparks the vehicle
+	for _, parkedAs := range forms {
+		for _, lookedUpAs := range forms {
+			lot, err := CreateParkingLot("Loose Lot", 1, 2, 2, WithSeparatorInsensitiveMatching())
+			if err != nil {
+				t.Fatalf("Failed to create lot: %v", err)
+			}
+
+			spotID, err := lot.Park(VehicleTypeAutomobile, parkedAs)
+			if err != nil {
+				t.Fatalf("Failed to park %q: %v", parkedAs, err)
+			}
+
+			info, err := lot.SearchVehicleDetailed(lookedUpAs)
+			if err != nil || info.SpotID != spotID {
+				t.Errorf("Parked as %q, search for %q: expected spot %s, got %+v, %v",
+					parkedAs, lookedUpAs, spotID, info, err)
+				continue
+			}
+			if info.VehicleNumber != NormalizeVehicleNumber(parkedAs) {
+				t.Errorf("Parked as %q: expected number shown as %q, got %q",
+					parkedAs, NormalizeVehicleNumber(parkedAs), info.VehicleNumber)
+			}
+
+			if err := lot.Unpark(spotID, lookedUpAs); err != nil {
+				t.Errorf("Parked as %q, unpark as %q: %v", parkedAs, lookedUpAs, err)
+			}
+			if lot.IsVehicleParked(parkedAs) {
+				t.Errorf("Parked as %q, unparked as %q: vehicle still parked", parkedAs, lookedUpAs)
+			}
+		}
+	}
+
+	// A second vehicle that only differs in separators is a clash
+	lot, err := CreateParkingLot("Loose Lot", 1, 2, 2, WithSeparatorInsensitiveMatching())
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-1234"); err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+
+	_, err = lot.Park(VehicleTypeAutomobile, "KA01HH1234")
+	var parkedErr *errors.VehicleAlreadyParkedError
+	if !stderrors.As(err, &parkedErr) || parkedErr.Code != errors.CodeVehicleNumberClash {
+		t.Errorf("Expected a vehicle number clash, got %v", err)
+	}
+
+	_, err = lot.Park(VehicleTypeAutomobile, "KA-01-HH-1234")
+	if !stderrors.As(err, &parkedErr) || parkedErr.Code != errors.CodeVehicleAlreadyParked {
+		t.Errorf("Expected the same number to be already parked, got %v", err)
+	}
+
+	// Spot listings still find the vehicle under the number it parked with
+	if occupied := lot.GetOccupiedSpots(); len(occupied) != 1 || occupied[0].OccupiedSince.IsZero() {
+		t.Errorf("Expected one occupied spot with its park time, got %+v", occupied)
+	}
+
+	// Partial searches ignore separators too
+	if matches, _, err := lot.FindVehiclesMatching("01 HH", 10); err != nil || len(matches) != 1 ||
+		matches[0].VehicleNumber != "KA-01-HH-1234" {
+		t.Errorf("Expected KA-01-HH-1234 to match 01 HH, got %+v, %v", matches, err)
+	}
+
+	// Without the option the forms stay different vehicles
+	strict, err := CreateParkingLot("Strict Lot", 1, 4, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	for _, number := range forms[:3] {
+		if _, err := strict.Park(VehicleTypeAutomobile, number); err != nil {
+			t.Errorf("Expected %q to park as its own vehicle, got %v", number, err)
+		}
+	}
+}
@@ -1,7 +1,14 @@
 package model
 
 import (
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
 )
 
 func TestNewParkingLot(t *testing.T) {
@@ -15,8 +22,8 @@ func TestNewParkingLot(t *testing.T) {
 		t.Fatalf("Failed to create parking lot: %v", err)
 	}
 
-	if lot.Name != "Test Parking Lot" {
-		t.Errorf("Expected name 'Test Parking Lot', got '%s'", lot.Name)
+	if lot.GetName() != "Test Parking Lot" {
+		t.Errorf("Expected name 'Test Parking Lot', got '%s'", lot.GetName())
 	}
 
 	if lot.GetNumFloors() != 2 {
@@ -62,8 +69,8 @@ func TestCreateParkingLot(t *testing.T) {
 		t.Fatalf("Failed to create parking lot: %v", err)
 	}
 
-	if lot.Name != "New Lot" {
-		t.Errorf("Expected name 'New Lot', got '%s'", lot.Name)
+	if lot.GetName() != "New Lot" {
+		t.Errorf("Expected name 'New Lot', got '%s'", lot.GetName())
 	}
 
 	if lot.GetNumFloors() != 3 {
@@ -242,3 +249,1144 @@ func TestParkingLotString(t *testing.T) {
 		}
 	}
 }
+
+func TestSetName(t *testing.T) {
+	lot, _ := CreateParkingLot("Old Name", 1, 2, 2)
+
+	if err := lot.SetName("  Orion Mall P2 "); err != nil {
+		t.Fatalf("Failed to rename lot: %v", err)
+	}
+	if lot.GetName() != "Orion Mall P2" || !contains(lot.String(), "Orion Mall P2:") {
+		t.Errorf("Expected the new name everywhere, got %q and %s", lot.GetName(), lot.String())
+	}
+
+	var validationErr *errors.ValidationError
+	if err := lot.SetName(" "); !stderrors.As(err, &validationErr) {
+		t.Errorf("Expected a blank name to be rejected, got %v", err)
+	}
+
+	// Reads while renaming always see a whole name
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = lot.SetName(fmt.Sprintf("Lot %d-%d", i, j))
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if name := lot.GetName(); !contains(lot.String(), ": ") || name == "" {
+					t.Errorf("Expected a name while renaming, got %q", name)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAddFloor(t *testing.T) {
+	lot, _ := CreateParkingLot("Add Floor Lot", 1, 2, 4)
+
+	// Fill every automobile spot on the existing floor
+	automobileSpots, _ := lot.AvailableSpot(VehicleTypeAutomobile)
+	for i := range automobileSpots {
+		if _, err := lot.Park(VehicleTypeAutomobile, fmt.Sprintf("FILL-%d", i)); err != nil {
+			t.Fatalf("Failed to fill spot %d: %v", i, err)
+		}
+	}
+
+	if _, err := lot.Park(VehicleTypeAutomobile, "NEW-FLOOR"); err == nil {
+		t.Fatalf("Expected lot to be full before adding a floor")
+	}
+
+	layout := [][]SpotType{
+		{SpotTypeAutomobile, SpotTypeInactive, SpotTypeBicycle},
+	}
+
+	if err := lot.AddFloor(1, 3, layout); err != nil {
+		t.Fatalf("Failed to add floor: %v", err)
+	}
+
+	if lot.GetNumFloors() != 2 {
+		t.Errorf("Expected 2 floors after adding one, got %d", lot.GetNumFloors())
+	}
+
+	if lot.GetTotalSpotCount() != 8+3 {
+		t.Errorf("Expected 11 total spots, got %d", lot.GetTotalSpotCount())
+	}
+
+	spotID, err := lot.Park(VehicleTypeAutomobile, "NEW-FLOOR")
+	if err != nil {
+		t.Fatalf("Failed to park on the new floor: %v", err)
+	}
+
+	if spotID != "1-0-0" {
+		t.Errorf("Expected spot 1-0-0 on the new floor, got %s", spotID)
+	}
+
+	// Dimension limits still apply
+	if err := lot.AddFloor(0, 3, nil); err == nil {
+		t.Errorf("Expected error for 0 rows")
+	}
+
+	if err := lot.AddFloor(1, 1001, nil); err == nil {
+		t.Errorf("Expected error for 1001 columns")
+	}
+
+	// Layout must match the dimensions
+	if err := lot.AddFloor(2, 3, layout); err == nil {
+		t.Errorf("Expected error for mismatched layout")
+	}
+
+	// The 8-floor maximum is enforced
+	for lot.GetNumFloors() < 8 {
+		if err := lot.AddFloor(1, 1, nil); err != nil {
+			t.Fatalf("Failed to add floor %d: %v", lot.GetNumFloors(), err)
+		}
+	}
+
+	if err := lot.AddFloor(1, 1, nil); err == nil {
+		t.Errorf("Expected error when adding a ninth floor")
+	}
+}
+
+func TestCapacityCounts(t *testing.T) {
+	layout := [][]SpotType{
+		{SpotTypeBicycle, SpotTypeBicycle, SpotTypeMotorcycle, SpotTypeAutomobile, SpotTypeInactive},
+	}
+	floor, _ := CreateParkingFloor(0, 1, 5, layout)
+	lot, _ := NewParkingLot("Capacity Lot", []*ParkingFloor{floor})
+
+	// One rack of 4 and one regular bicycle spot
+	if err := lot.SetSpotCapacity("0-0-0", 4); err != nil {
+		t.Fatalf("Failed to set rack capacity: %v", err)
+	}
+
+	if err := lot.SetSpotCapacity("0-0-3", 2); err == nil {
+		t.Errorf("Expected error when turning an automobile spot into a rack")
+	}
+
+	available := lot.GetAvailableCapacityByType()
+	if available[VehicleTypeBicycle] != 5 {
+		t.Errorf("Expected 5 free bicycle slots, got %d", available[VehicleTypeBicycle])
+	}
+
+	// Non-rack types are unaffected
+	if available[VehicleTypeMotorcycle] != 1 || available[VehicleTypeAutomobile] != 1 {
+		t.Errorf("Expected 1 free motorcycle and automobile slot, got %d and %d",
+			available[VehicleTypeMotorcycle], available[VehicleTypeAutomobile])
+	}
+
+	// Park three bicycles: all go into the rack first
+	for i := 0; i < 3; i++ {
+		spotID, err := lot.Park(VehicleTypeBicycle, fmt.Sprintf("BIKE-%d", i))
+		if err != nil {
+			t.Fatalf("Failed to park bicycle %d: %v", i, err)
+		}
+		if spotID != "0-0-0" {
+			t.Errorf("Expected bicycle %d in rack 0-0-0, got %s", i, spotID)
+		}
+	}
+
+	if _, err := lot.Park(VehicleTypeAutomobile, "CAR-1"); err != nil {
+		t.Fatalf("Failed to park automobile: %v", err)
+	}
+
+	available = lot.GetAvailableCapacityByType()
+	occupied := lot.GetOccupiedCapacityByType()
+
+	if available[VehicleTypeBicycle] != 2 || occupied[VehicleTypeBicycle] != 3 {
+		t.Errorf("Expected 2 free and 3 parked bicycle slots, got %d and %d",
+			available[VehicleTypeBicycle], occupied[VehicleTypeBicycle])
+	}
+
+	if available[VehicleTypeAutomobile] != 0 || occupied[VehicleTypeAutomobile] != 1 {
+		t.Errorf("Expected 0 free and 1 parked automobile slots, got %d and %d",
+			available[VehicleTypeAutomobile], occupied[VehicleTypeAutomobile])
+	}
+
+	// Spot counts stay physical: the partially filled rack is one occupied spot
+	if lot.GetOccupiedSpotCount() != 2 {
+		t.Errorf("Expected 2 occupied spots, got %d", lot.GetOccupiedSpotCount())
+	}
+
+	if lot.GetAvailableSpotCountByType()[VehicleTypeBicycle] != 2 {
+		t.Errorf("Expected 2 bicycle spots with free capacity, got %d",
+			lot.GetAvailableSpotCountByType()[VehicleTypeBicycle])
+	}
+
+	// Unparking from the rack frees one slot and keeps the others
+	if err := lot.Unpark("0-0-0", "BIKE-1"); err != nil {
+		t.Fatalf("Failed to unpark bicycle from rack: %v", err)
+	}
+
+	if lot.GetOccupiedCapacityByType()[VehicleTypeBicycle] != 2 {
+		t.Errorf("Expected 2 parked bicycles after unpark, got %d",
+			lot.GetOccupiedCapacityByType()[VehicleTypeBicycle])
+	}
+
+	spot, err := lot.FindVehicle("BIKE-2")
+	if err != nil || spot.GetSpotID() != "0-0-0" {
+		t.Errorf("Expected to find BIKE-2 in the rack, got %v, %v", spot, err)
+	}
+
+	lot.Reset()
+	if lot.GetOccupiedCapacityByType()[VehicleTypeBicycle] != 0 {
+		t.Errorf("Expected reset to empty the rack")
+	}
+}
+
+func TestRemoveFloor(t *testing.T) {
+	lot, _ := CreateParkingLot("Remove Floor Lot", 3, 2, 4)
+	floor1, _ := lot.GetFloor(1)
+	automobileSpots := floor1.GetAvailableSpots(VehicleTypeAutomobile)
+	if len(automobileSpots) == 0 {
+		t.Fatalf("Expected automobile spots on floor 1")
+	}
+
+	// Occupy a spot on floor 1 directly through the lot
+	if err := automobileSpots[0].Occupy("BLOCKER-1"); err != nil {
+		t.Fatalf("Failed to occupy spot: %v", err)
+	}
+
+	err := lot.RemoveFloor(1)
+	if err == nil {
+		t.Fatalf("Expected error when removing an occupied floor")
+	}
+
+	if !contains(err.Error(), "BLOCKER-1") {
+		t.Errorf("Expected error to name the blocking vehicle, got: %v", err)
+	}
+
+	if lot.GetNumFloors() != 3 {
+		t.Errorf("Expected 3 floors after rejected removal, got %d", lot.GetNumFloors())
+	}
+
+	// Removing an empty floor works and the counts follow
+	totalBefore := lot.GetTotalSpotCount()
+	if err := lot.RemoveFloor(2); err != nil {
+		t.Fatalf("Failed to remove empty floor: %v", err)
+	}
+
+	if lot.GetNumFloors() != 2 {
+		t.Errorf("Expected 2 floors, got %d", lot.GetNumFloors())
+	}
+
+	if lot.GetTotalSpotCount() != totalBefore-8 {
+		t.Errorf("Expected %d total spots, got %d", totalBefore-8, lot.GetTotalSpotCount())
+	}
+
+	available, _ := lot.AvailableSpot(VehicleTypeAutomobile)
+	for _, spotID := range available {
+		if spotID[0] == '2' {
+			t.Errorf("Removed floor still listed in available spots: %s", spotID)
+		}
+	}
+
+	if err := lot.RemoveFloor(7); err == nil {
+		t.Errorf("Expected error for non-existent floor")
+	}
+
+	_ = automobileSpots[0].Vacate("BLOCKER-1")
+	_ = lot.RemoveFloor(1)
+	if err := lot.RemoveFloor(0); err == nil {
+		t.Errorf("Expected error when removing the last floor")
+	}
+
+	// A basement floor is named by its label
+	basement, _ := CreateParkingLotFromFloor("Basement Lot", -1, 2, 2, 4)
+	if _, err := basement.Park(VehicleTypeAutomobile, "BLOCKER-2"); err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	if err := basement.RemoveFloor(-1); err == nil || !contains(err.Error(), "floor B1 still has parked vehicles") {
+		t.Errorf("Expected the error to name floor B1, got: %v", err)
+	}
+}
+
+func TestCloseFloor(t *testing.T) {
+	layout := [][]SpotType{{SpotTypeAutomobile, SpotTypeAutomobile}}
+	floor0, _ := CreateParkingFloor(0, 1, 2, layout)
+	floor1, _ := CreateParkingFloor(1, 1, 2, layout)
+	lot, _ := NewParkingLot("Close Floor Lot", []*ParkingFloor{floor0, floor1})
+
+	spotID, _ := lot.Park(VehicleTypeAutomobile, "EARLY-1")
+	if spotID != "0-0-0" {
+		t.Fatalf("Expected first park on floor 0, got %s", spotID)
+	}
+
+	if err := lot.CloseFloor(0); err != nil {
+		t.Fatalf("Failed to close floor: %v", err)
+	}
+
+	// New vehicles skip the closed floor
+	spotID, err := lot.Park(VehicleTypeAutomobile, "LATE-1")
+	if err != nil {
+		t.Fatalf("Failed to park with floor 0 closed: %v", err)
+	}
+	if spotID != "1-0-0" {
+		t.Errorf("Expected park on floor 1, got %s", spotID)
+	}
+
+	available, _ := lot.AvailableSpot(VehicleTypeAutomobile)
+	if len(available) != 1 || available[0] != "1-0-1" {
+		t.Errorf("Expected only 1-0-1 to be available, got %v", available)
+	}
+
+	if lot.GetAvailableSpotCount() != 1 {
+		t.Errorf("Expected 1 available spot, got %d", lot.GetAvailableSpotCount())
+	}
+
+	if lot.GetAvailableSpotCountByType()[VehicleTypeAutomobile] != 1 {
+		t.Errorf("Expected 1 available automobile spot, got %d",
+			lot.GetAvailableSpotCountByType()[VehicleTypeAutomobile])
+	}
+
+	// Vehicles already on the closed floor can leave
+	if err := lot.Unpark("0-0-0", "EARLY-1"); err != nil {
+		t.Errorf("Failed to unpark from closed floor: %v", err)
+	}
+
+	if lot.GetNumFloors() != 2 {
+		t.Errorf("Closing a floor should not change the floor count")
+	}
+
+	// Reopening makes the floor available again
+	if err := lot.OpenFloor(0); err != nil {
+		t.Fatalf("Failed to open floor: %v", err)
+	}
+
+	if lot.GetAvailableSpotCount() != 3 {
+		t.Errorf("Expected 3 available spots after reopening, got %d", lot.GetAvailableSpotCount())
+	}
+
+	if err := lot.CloseFloor(5); err == nil {
+		t.Errorf("Expected error when closing a non-existent floor")
+	}
+}
+
+func TestRestrictFloor(t *testing.T) {
+	layout := [][]SpotType{{SpotTypeBicycle, SpotTypeAutomobile, SpotTypeAutomobile}}
+	floor0, _ := CreateParkingFloor(0, 1, 3, layout)
+	floor1, _ := CreateParkingFloor(1, 1, 3, layout)
+	lot, _ := NewParkingLot("Restricted Floor Lot", []*ParkingFloor{floor0, floor1})
+
+	if err := lot.RestrictFloor(0, []VehicleType{VehicleTypeBicycle}); err != nil {
+		t.Fatalf("Failed to restrict floor: %v", err)
+	}
+
+	// Automobiles skip floor 0 though its automobile spots are free
+	spotID, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-1234")
+	if err != nil || spotID != "1-0-1" {
+		t.Errorf("Expected the automobile on floor 1 at 1-0-1, got %s (%v)", spotID, err)
+	}
+	if spotID, err := lot.Park(VehicleTypeBicycle, "CYCLE-1"); err != nil || spotID != "0-0-0" {
+		t.Errorf("Expected the bicycle on floor 0 at 0-0-0, got %s (%v)", spotID, err)
+	}
+
+	available, _ := lot.AvailableSpot(VehicleTypeAutomobile)
+	if fmt.Sprint(available) != "[1-0-2]" {
+		t.Errorf("Expected only 1-0-2 available for automobiles, got %v", available)
+	}
+	if count, _ := lot.CountAvailableSpots(VehicleTypeAutomobile); count != 1 {
+		t.Errorf("Expected 1 available automobile spot, got %d", count)
+	}
+	stats := lot.Stats()
+	if stats.AvailableByType[VehicleTypeAutomobile] != 1 || stats.Floors[0].AvailableByType[VehicleTypeAutomobile] != 0 {
+		t.Errorf("Expected floor 0 to have no automobile spots in the stats, got %v", stats.AvailableByType)
+	}
+	if fmt.Sprint(stats.Floors[0].Allowed) != "[BICYCLE]" || stats.Floors[1].Allowed != nil {
+		t.Errorf("Expected only floor 0 restricted, got %v and %v", stats.Floors[0].Allowed, stats.Floors[1].Allowed)
+	}
+
+	// Parking there by spot ID names the restriction
+	_, err = lot.ParkAt("0-0-1", VehicleTypeAutomobile, "KA-02-HH-5678")
+	var restricted *errors.FloorRestrictedError
+	if !stderrors.As(err, &restricted) || restricted.SpotID != "0-0-1" {
+		t.Errorf("Expected a floor restriction error for 0-0-1, got %v", err)
+	}
+
+	if err := lot.RestrictFloor(0, nil); err == nil {
+		t.Error("Expected an empty restriction to be rejected")
+	}
+	if err := lot.RestrictFloor(0, []VehicleType{"TRUCK"}); err == nil {
+		t.Error("Expected an unknown vehicle type to be rejected")
+	}
+	if err := lot.RestrictFloor(5, []VehicleType{VehicleTypeBicycle}); err == nil {
+		t.Error("Expected error when restricting a non-existent floor")
+	}
+
+	// Clearing the restriction lets automobiles back onto floor 0
+	if err := lot.ClearFloorRestriction(0); err != nil {
+		t.Fatalf("Failed to clear the restriction: %v", err)
+	}
+	if spotID, err := lot.Park(VehicleTypeAutomobile, "KA-02-HH-5678"); err != nil || spotID != "0-0-1" {
+		t.Errorf("Expected the automobile at 0-0-1 once cleared, got %s (%v)", spotID, err)
+	}
+}
+
+func TestExpandFloorOnLot(t *testing.T) {
+	lot, _ := CreateParkingLot("Expand Lot", 2, 3, 4)
+
+	spotID, err := lot.Park(VehicleTypeAutomobile, "EXPAND-1")
+	if err != nil {
+		t.Fatalf("Failed to park vehicle: %v", err)
+	}
+
+	totalBefore := lot.GetTotalSpotCount()
+	if err := lot.ExpandFloor(0, 3, 6, SpotTypeAutomobile); err != nil {
+		t.Fatalf("Failed to expand floor: %v", err)
+	}
+
+	if lot.GetTotalSpotCount() != totalBefore+3*2 {
+		t.Errorf("Expected %d total spots, got %d", totalBefore+6, lot.GetTotalSpotCount())
+	}
+
+	spot, err := lot.GetSpotByID("0-2-5")
+	if err != nil || spot.Type != SpotTypeAutomobile {
+		t.Errorf("Expected new automobile spot 0-2-5: %v", err)
+	}
+
+	// The parked vehicle is still found and can leave
+	if err := lot.Unpark(spotID, "EXPAND-1"); err != nil {
+		t.Errorf("Failed to unpark after expansion: %v", err)
+	}
+
+	if err := lot.ExpandFloor(9, 3, 6, SpotTypeAutomobile); err == nil {
+		t.Errorf("Expected error for non-existent floor")
+	}
+}
+
+func TestExpandFloorWhileParking(t *testing.T) {
+	lot, _ := CreateParkingLot("Expand Race Lot", 1, 2, 4)
+
+	// Parks and unparks running while the floor grows see it before or
+	// after each resize, never midway
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for n := 5; n <= 20; n++ {
+			if err := lot.ExpandFloor(0, n, n, SpotTypeAutomobile); err != nil {
+				t.Errorf("Failed to expand floor to %dx%d: %v", n, n, err)
+			}
+		}
+	}()
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				number := fmt.Sprintf("RACE-%d-%d", i, j)
+				spotID, err := lot.Park(VehicleTypeAutomobile, number)
+				if err != nil {
+					continue
+				}
+				if j%2 == 0 {
+					if err := lot.Unpark(spotID, number); err != nil {
+						t.Errorf("Failed to unpark %s from %s: %v", number, spotID, err)
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Every vehicle left parked is where the lot's counters say
+	parked := 0
+	for _, spot := range lot.ListSpots(SpotFilter{}) {
+		for _, number := range spot.Vehicles {
+			if found, err := lot.FindVehicle(number); err != nil || found.GetSpotID() != spot.SpotID {
+				t.Errorf("Expected %s found at %s: %v", number, spot.SpotID, err)
+			}
+		}
+		parked += len(spot.Vehicles)
+	}
+	if lot.GetTotalSpotCount() != 20*20 || lot.GetParkedVehicleCount() != parked {
+		t.Errorf("Expected 400 spots and %d vehicles, got %d and %d",
+			parked, lot.GetTotalSpotCount(), lot.GetParkedVehicleCount())
+	}
+}
+
+func TestBasementFloors(t *testing.T) {
+	lot, err := CreateParkingLotFromFloor("Basement Lot", -2, 3, 2, 4)
+	if err != nil {
+		t.Fatalf("Failed to create lot with basements: %v", err)
+	}
+
+	floors := lot.GetFloors()
+	for i, expected := range []int{-2, -1, 0} {
+		if floors[i].FloorNumber != expected {
+			t.Errorf("Expected floor %d at position %d, got %d", expected, i, floors[i].FloorNumber)
+		}
+	}
+
+	// Parking starts at the lowest basement
+	spotID, err := lot.Park(VehicleTypeAutomobile, "BASE-1")
+	if err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	if spotID[:3] != "B2-" {
+		t.Errorf("Expected a spot on floor B2, got %s", spotID)
+	}
+
+	// Searching and unparking on floor -1
+	_ = lot.CloseFloor(-2)
+	spotID, err = lot.Park(VehicleTypeAutomobile, "BASE-2")
+	if err != nil {
+		t.Fatalf("Failed to park on floor -1: %v", err)
+	}
+
+	spot, err := lot.GetSpotByID(spotID)
+	if err != nil || spot.Floor != -1 {
+		t.Fatalf("Expected spot on floor -1, got %s (%v)", spotID, err)
+	}
+
+	foundSpotID, isParked, err := lot.SearchVehicle("BASE-2")
+	if err != nil || !isParked || foundSpotID != spotID {
+		t.Errorf("Expected BASE-2 parked at %s, got %s, %v, %v", spotID, foundSpotID, isParked, err)
+	}
+
+	available, _ := lot.AvailableSpot(VehicleTypeAutomobile)
+	if len(available) == 0 || available[0][:3] != "B1-" {
+		t.Errorf("Expected available spots to start on floor B1, got %v", available)
+	}
+
+	if err := lot.Unpark(spotID, "BASE-2"); err != nil {
+		t.Errorf("Failed to unpark from floor -1: %v", err)
+	}
+
+	// Added floors continue above the top floor
+	if err := lot.AddFloor(1, 1, nil); err != nil {
+		t.Fatalf("Failed to add floor: %v", err)
+	}
+	if _, err := lot.GetFloor(1); err != nil {
+		t.Errorf("Expected added floor to be numbered 1: %v", err)
+	}
+
+	// Floors passed out of order are sorted basement-first
+	upper, _ := CreateParkingFloor(1, 1, 1, nil)
+	lower, _ := CreateParkingFloor(-1, 1, 1, nil)
+	unordered, _ := NewParkingLot("Unordered", []*ParkingFloor{upper, lower})
+	if unordered.GetFloors()[0].FloorNumber != -1 {
+		t.Errorf("Expected floors sorted basement-first")
+	}
+
+	if _, err := CreateParkingLotFromFloor("Too Deep", -9, 3, 2, 4); err == nil {
+		t.Errorf("Expected error for starting floor -9")
+	}
+}
+
+func TestCreateParkingLotWithFloors(t *testing.T) {
+	specs := []FloorSpec{
+		{Rows: 10, Columns: 10},
+		{Rows: 8, Columns: 8},
+		{Rows: 4, Columns: 4},
+	}
+
+	lot, err := CreateParkingLotWithFloors("Ragged Lot", 0, specs)
+	if err != nil {
+		t.Fatalf("Failed to create lot with per-floor dimensions: %v", err)
+	}
+
+	if lot.GetTotalSpotCount() != 100+64+16 {
+		t.Errorf("Expected %d total spots, got %d", 100+64+16, lot.GetTotalSpotCount())
+	}
+
+	for i, spec := range specs {
+		floor, err := lot.GetFloor(i)
+		if err != nil {
+			t.Fatalf("Failed to get floor %d: %v", i, err)
+		}
+
+		rows, columns := floor.GetDimensions()
+		if rows != spec.Rows || columns != spec.Columns {
+			t.Errorf("Floor %d: expected %dx%d, got %dx%d", i, spec.Rows, spec.Columns, rows, columns)
+		}
+
+		// The last cell of each floor exists, the cell past it does not
+		if _, err := lot.GetSpot(i, spec.Rows-1, spec.Columns-1); err != nil {
+			t.Errorf("Floor %d: expected spot %d-%d to exist: %v", i, spec.Rows-1, spec.Columns-1, err)
+		}
+		if _, err := lot.GetSpot(i, spec.Rows, 0); err == nil {
+			t.Errorf("Floor %d: expected error for row %d", i, spec.Rows)
+		}
+	}
+
+	// Spot 2-9-9 exists on the 10x10 floor only
+	if _, err := lot.GetSpotByID("0-9-9"); err != nil {
+		t.Errorf("Expected spot 0-9-9 to exist: %v", err)
+	}
+	if _, err := lot.GetSpotByID("2-9-9"); err == nil {
+		t.Errorf("Expected error for spot 2-9-9 on a 4x4 floor")
+	}
+
+	// Fill every automobile spot; vehicles spill over onto the smaller floors
+	automobiles := lot.GetSpotCountByType()[SpotTypeAutomobile]
+	parked := make(map[string]string, automobiles)
+	for i := 0; i < automobiles; i++ {
+		vehicleNumber := fmt.Sprintf("CAR-%d", i)
+		spotID, err := lot.Park(VehicleTypeAutomobile, vehicleNumber)
+		if err != nil {
+			t.Fatalf("Failed to park %s: %v", vehicleNumber, err)
+		}
+		parked[vehicleNumber] = spotID
+	}
+
+	if _, err := lot.Park(VehicleTypeAutomobile, "CAR-FULL"); err == nil {
+		t.Errorf("Expected error when all automobile spots are taken")
+	}
+
+	lastFloor, _ := lot.GetFloor(2)
+	if lastFloor.GetOccupiedSpotCount() == 0 {
+		t.Errorf("Expected vehicles parked on the 4x4 floor")
+	}
+
+	for vehicleNumber, spotID := range parked {
+		foundSpotID, isParked, err := lot.SearchVehicle(vehicleNumber)
+		if err != nil || !isParked || foundSpotID != spotID {
+			t.Fatalf("Expected %s at %s, got %s, %v, %v", vehicleNumber, spotID, foundSpotID, isParked, err)
+		}
+		if err := lot.Unpark(spotID, vehicleNumber); err != nil {
+			t.Fatalf("Failed to unpark %s: %v", vehicleNumber, err)
+		}
+	}
+
+	if lot.GetOccupiedSpotCount() != 0 {
+		t.Errorf("Expected empty lot, got %d occupied spots", lot.GetOccupiedSpotCount())
+	}
+
+	// Invalid specs are rejected
+	if _, err := CreateParkingLotWithFloors("Empty", 0, nil); err == nil {
+		t.Errorf("Expected error for no floors")
+	}
+	if _, err := CreateParkingLotWithFloors("Bad", 0, []FloorSpec{{Rows: 2, Columns: 0}}); err == nil {
+		t.Errorf("Expected error for zero columns")
+	}
+}
+
+func TestSetSpotTypeWhileDisplaying(t *testing.T) {
+	lot, _ := CreateParkingLotWithFloors("Display Lot", 0, []FloorSpec{
+		{Rows: 2, Columns: 4, Layout: [][]SpotType{
+			{SpotTypeAutomobile, SpotTypeAutomobile, SpotTypeAutomobile, SpotTypeAutomobile},
+			{SpotTypeBicycle, SpotTypeBicycle, SpotTypeMotorcycle, SpotTypeMotorcycle},
+		}},
+	})
+	floor, _ := lot.GetFloor(0)
+
+	// The map, layout and spot listings read each type whole while set-spot
+	// changes it, which the race detector checks
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			spotType := []SpotType{SpotTypeInactive, SpotTypeAutomobile}[i%2]
+			if err := lot.SetSpotType("0-0-1", spotType); err != nil {
+				t.Errorf("Failed to set spot type: %v", err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			if cell := floor.GetDisplayState()[0][1]; cell != "A" && cell != "X" {
+				t.Errorf("Expected A or X for the edited spot, got %s", cell)
+			}
+			if spotType := floor.GetLayout()[0][1]; spotType != SpotTypeAutomobile && spotType != SpotTypeInactive {
+				t.Errorf("Expected A-1 or X-0 for the edited spot, got %s", spotType)
+			}
+			_ = lot.ListSpots(SpotFilter{Type: SpotTypeAutomobile})
+		}
+	}()
+	wg.Wait()
+}
+
+func TestSetSpotType(t *testing.T) {
+	lot, err := CreateParkingLotWithFloors("Edit Lot", 0, []FloorSpec{
+		{Rows: 1, Columns: 2, Layout: [][]SpotType{{SpotTypeAutomobile, SpotTypeAutomobile}}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	// Toggle the first automobile spot to inactive
+	if err := lot.SetSpotType("0-0-0", SpotTypeInactive); err != nil {
+		t.Fatalf("Failed to set spot type: %v", err)
+	}
+
+	counts := lot.GetSpotCountByType()
+	if counts[SpotTypeAutomobile] != 1 || counts[SpotTypeInactive] != 1 {
+		t.Errorf("Expected 1 automobile and 1 inactive spot, got %v", counts)
+	}
+
+	available, _ := lot.AvailableSpot(VehicleTypeAutomobile)
+	if len(available) != 1 || available[0] != "0-0-1" {
+		t.Errorf("Expected only 0-0-1 available, got %v", available)
+	}
+
+	floor, _ := lot.GetFloor(0)
+	if display := floor.GetDisplayState(); display[0][0] != "X" {
+		t.Errorf("Expected X in the display grid, got %s", display[0][0])
+	}
+
+	spotID, err := lot.Park(VehicleTypeAutomobile, "EDIT-1")
+	if err != nil || spotID != "0-0-1" {
+		t.Fatalf("Expected park at 0-0-1, got %s (%v)", spotID, err)
+	}
+
+	// The lot is now full for automobiles
+	if _, err := lot.Park(VehicleTypeAutomobile, "EDIT-2"); err == nil {
+		t.Errorf("Expected park to fail once the only active spot is taken")
+	}
+
+	// Occupied spots cannot be edited
+	if err := lot.SetSpotType("0-0-1", SpotTypeBicycle); err == nil {
+		t.Errorf("Expected error editing an occupied spot")
+	}
+
+	// Reactivating the spot makes it available again
+	if err := lot.SetSpotType("0-0-0", SpotTypeMotorcycle); err != nil {
+		t.Fatalf("Failed to reactivate spot: %v", err)
+	}
+	if spotID, err := lot.Park(VehicleTypeMotorcycle, "EDIT-3"); err != nil || spotID != "0-0-0" {
+		t.Errorf("Expected motorcycle park at 0-0-0, got %s (%v)", spotID, err)
+	}
+
+	if err := lot.SetSpotType("0-0-5", SpotTypeBicycle); err == nil {
+		t.Errorf("Expected error for a spot outside the floor")
+	}
+	if err := lot.SetSpotType("0-0-0", "Z-9"); err == nil {
+		t.Errorf("Expected error for invalid spot type")
+	}
+}
+
+func TestFloorSummaries(t *testing.T) {
+	lot, err := CreateParkingLotWithFloors("Summary Lot", 0, []FloorSpec{
+		{Rows: 1, Columns: 4, Layout: [][]SpotType{{SpotTypeBicycle, SpotTypeMotorcycle, SpotTypeAutomobile, SpotTypeInactive}}},
+		{Rows: 1, Columns: 2, Layout: [][]SpotType{{SpotTypeAutomobile, SpotTypeAutomobile}}},
+		{Rows: 1, Columns: 1, Layout: [][]SpotType{{SpotTypeAutomobile}}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	if _, err := lot.Park(VehicleTypeAutomobile, "SUM-1"); err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	if err := lot.CloseFloor(2); err != nil {
+		t.Fatalf("Failed to close floor: %v", err)
+	}
+
+	summaries := lot.GetFloorSummaries()
+	if len(summaries) != 3 {
+		t.Fatalf("Expected 3 summaries, got %d", len(summaries))
+	}
+
+	ground := summaries[0]
+	if ground.FloorNumber != 0 || ground.TotalSpots != 4 || ground.ActiveSpots != 3 ||
+		ground.OccupiedSpots != 1 || ground.AvailableSpots != 2 {
+		t.Errorf("Unexpected ground floor summary: %+v", ground)
+	}
+	if ground.AvailableByType[VehicleTypeAutomobile] != 0 || ground.AvailableByType[VehicleTypeBicycle] != 1 {
+		t.Errorf("Unexpected ground floor availability: %v", ground.AvailableByType)
+	}
+
+	if summaries[1].AvailableByType[VehicleTypeAutomobile] != 2 {
+		t.Errorf("Expected 2 automobile spots on floor 1, got %v", summaries[1].AvailableByType)
+	}
+
+	// Closed floors report no availability
+	closed := summaries[2]
+	if !closed.Closed || closed.AvailableSpots != 0 || closed.AvailableByType[VehicleTypeAutomobile] != 0 {
+		t.Errorf("Unexpected closed floor summary: %+v", closed)
+	}
+
+	// Per-floor figures add up to the lot-wide ones
+	available := 0
+	for _, summary := range summaries {
+		available += summary.AvailableSpots
+	}
+	if available != lot.GetAvailableSpotCount() {
+		t.Errorf("Expected floor availability to sum to %d, got %d", lot.GetAvailableSpotCount(), available)
+	}
+}
+
+func TestAvailableSpotsByFloor(t *testing.T) {
+	lot, err := CreateParkingLotFromFloor("Grouped Lot", -1, 3, 3, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	if _, err := lot.Park(VehicleTypeAutomobile, "GROUP-1"); err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	if err := lot.CloseFloor(1); err != nil {
+		t.Fatalf("Failed to close floor: %v", err)
+	}
+
+	spotsByFloor, err := lot.AvailableSpotsByFloor(VehicleTypeAutomobile)
+	if err != nil {
+		t.Fatalf("Failed to get available spots: %v", err)
+	}
+
+	// The closed floor is left out
+	if len(spotsByFloor) != 2 {
+		t.Fatalf("Expected 2 floors with spots, got %v", spotsByFloor)
+	}
+	if _, found := spotsByFloor[1]; found {
+		t.Errorf("Expected no spots on the closed floor")
+	}
+
+	// The grouped result holds the same spots as the flat one, in order
+	flat, _ := lot.AvailableSpot(VehicleTypeAutomobile)
+	grouped := append(append([]string{}, spotsByFloor[-1]...), spotsByFloor[0]...)
+	if len(flat) != len(grouped) {
+		t.Fatalf("Expected %d spots, got %d", len(flat), len(grouped))
+	}
+	for i := range flat {
+		if flat[i] != grouped[i] {
+			t.Errorf("Spot %d: expected %s, got %s", i, flat[i], grouped[i])
+		}
+	}
+
+	// Spots within a floor are ordered by row, then column
+	for floor, spotIDs := range spotsByFloor {
+		for i := 1; i < len(spotIDs); i++ {
+			prev, _ := lot.GetSpotByID(spotIDs[i-1])
+			cur, _ := lot.GetSpotByID(spotIDs[i])
+			if prev.Row > cur.Row || (prev.Row == cur.Row && prev.Column >= cur.Column) {
+				t.Errorf("Floor %d: %s listed before %s", floor, spotIDs[i-1], spotIDs[i])
+			}
+		}
+	}
+
+	if _, err := lot.AvailableSpotsByFloor("TRUCK"); err == nil {
+		t.Errorf("Expected error for invalid vehicle type")
+	}
+}
+
+func TestForEachAvailableSpot(t *testing.T) {
+	lot, err := CreateParkingLotFromFloor("Streamed Lot", -1, 3, 3, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	if err := lot.CloseFloor(1); err != nil {
+		t.Fatalf("Failed to close floor: %v", err)
+	}
+
+	// Every spot comes out, in the order of AvailableSpot, closed floors left out
+	var streamed []string
+	err = lot.ForEachAvailableSpot(VehicleTypeAutomobile, func(floor int, spotID string) bool {
+		if floor == 1 {
+			t.Errorf("Expected no spots on the closed floor, got %s", spotID)
+		}
+		streamed = append(streamed, spotID)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Failed to stream available spots: %v", err)
+	}
+	flat, _ := lot.AvailableSpot(VehicleTypeAutomobile)
+	if !reflect.DeepEqual(streamed, flat) {
+		t.Errorf("Expected %v, got %v", flat, streamed)
+	}
+
+	// Returning false stops the walk, across floors too
+	count := 0
+	lot.ForEachAvailableSpot(VehicleTypeAutomobile, func(int, string) bool {
+		count++
+		return count < 3
+	})
+	if count != 3 {
+		t.Errorf("Expected the walk stopped after 3 spots, got %d", count)
+	}
+
+	if err := lot.ForEachAvailableSpot("TRUCK", func(int, string) bool { return true }); err == nil {
+		t.Errorf("Expected error for invalid vehicle type")
+	}
+}
+
+func TestAvailableSpotNAndCount(t *testing.T) {
+	lot, err := CreateParkingLot("Bounded Lot", 3, 10, 10)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	all, _ := lot.AvailableSpot(VehicleTypeAutomobile)
+
+	count, err := lot.CountAvailableSpots(VehicleTypeAutomobile)
+	if err != nil || count != len(all) {
+		t.Errorf("Expected count %d, got %d (%v)", len(all), count, err)
+	}
+
+	// The limited list is a prefix of the full list, across floor boundaries
+	perFloor := len(all) / 3
+	for _, n := range []int{0, 1, 5, perFloor, perFloor + 3, len(all), len(all) + 10} {
+		spots, err := lot.AvailableSpotN(VehicleTypeAutomobile, n)
+		if err != nil {
+			t.Fatalf("Failed to get %d spots: %v", n, err)
+		}
+
+		expected := min(n, len(all))
+		if len(spots) != expected {
+			t.Errorf("Limit %d: expected %d spots, got %d", n, expected, len(spots))
+			continue
+		}
+		for i := range spots {
+			if spots[i] != all[i] {
+				t.Errorf("Limit %d: spot %d is %s, expected %s", n, i, spots[i], all[i])
+			}
+		}
+	}
+
+	// The first spot is the one Park picks
+	first, _ := lot.AvailableSpotN(VehicleTypeAutomobile, 1)
+	spotID, _ := lot.Park(VehicleTypeAutomobile, "FIRST-1")
+	if first[0] != spotID {
+		t.Errorf("Expected Park to pick %s, got %s", first[0], spotID)
+	}
+
+	if count, _ := lot.CountAvailableSpots(VehicleTypeAutomobile); count != len(all)-1 {
+		t.Errorf("Expected count %d after parking, got %d", len(all)-1, count)
+	}
+
+	if _, err := lot.AvailableSpotN(VehicleTypeAutomobile, -1); err == nil {
+		t.Errorf("Expected error for negative limit")
+	}
+	if _, err := lot.CountAvailableSpots("TRUCK"); err == nil {
+		t.Errorf("Expected error for invalid vehicle type")
+	}
+}
+
+func TestNearestAvailableSpots(t *testing.T) {
+	A, B, M, X := SpotTypeAutomobile, SpotTypeBicycle, SpotTypeMotorcycle, SpotTypeInactive
+	specs := []FloorSpec{
+		{Rows: 3, Columns: 3, Layout: [][]SpotType{
+			{A, A, B},
+			{X, A, A},
+			{A, M, A},
+		}},
+		{Rows: 2, Columns: 2, Layout: [][]SpotType{
+			{A, A},
+			{A, A},
+		}},
+	}
+
+	lot, err := CreateParkingLotWithFloors("Nearest Lot", 0, specs)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	// The entrance defaults to the first spot of the lowest floor
+	if entrance := lot.GetEntrance(); entrance != (Entrance{Floor: 0, Row: 0, Column: 0}) {
+		t.Errorf("Expected default entrance 0-0-0, got %+v", entrance)
+	}
+
+	if err := lot.SetEntrance(0, 1, 1); err != nil {
+		t.Fatalf("Failed to set entrance: %v", err)
+	}
+
+	// Occupied spots are skipped
+	spotID, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-1234")
+	if err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	if spotID != "0-0-0" {
+		t.Fatalf("Expected vehicle parked at 0-0-0, got %s", spotID)
+	}
+
+	// Distance is |rows| + |columns| + 10 per floor change, ties broken by spot ID
+	expected := []SpotDistance{
+		{"0-1-1", 0},
+		{"0-0-1", 1},
+		{"0-1-2", 1},
+		{"0-2-0", 2},
+		{"0-2-2", 2},
+		{"1-1-1", 10},
+		{"1-0-1", 11},
+		{"1-1-0", 11},
+		{"1-0-0", 12},
+	}
+
+	spots, err := lot.NearestAvailableSpots(VehicleTypeAutomobile, 20)
+	if err != nil {
+		t.Fatalf("Failed to get nearest spots: %v", err)
+	}
+	if len(spots) != len(expected) {
+		t.Fatalf("Expected %d spots, got %d: %v", len(expected), len(spots), spots)
+	}
+	for i := range expected {
+		if spots[i] != expected[i] {
+			t.Errorf("Position %d: expected %+v, got %+v", i, expected[i], spots[i])
+		}
+	}
+
+	spots, err = lot.NearestAvailableSpots(VehicleTypeAutomobile, 4)
+	if err != nil {
+		t.Fatalf("Failed to get nearest spots: %v", err)
+	}
+	if len(spots) != 4 || spots[3] != expected[3] {
+		t.Errorf("Expected the first 4 spots ending at %+v, got %v", expected[3], spots)
+	}
+
+	spots, err = lot.NearestAvailableSpots(VehicleTypeMotorcycle, 5)
+	if err != nil {
+		t.Fatalf("Failed to get nearest motorcycle spots: %v", err)
+	}
+	if len(spots) != 1 || spots[0] != (SpotDistance{"0-2-1", 1}) {
+		t.Errorf("Expected only 0-2-1 at distance 1, got %v", spots)
+	}
+
+	if _, err := lot.NearestAvailableSpots(VehicleTypeAutomobile, -1); err == nil {
+		t.Error("Expected error for negative n")
+	}
+
+	// The entrance must be an existing spot
+	if err := lot.SetEntrance(1, 2, 0); err == nil {
+		t.Error("Expected error for entrance outside the floor")
+	}
+	if err := lot.SetEntrance(5, 0, 0); err == nil {
+		t.Error("Expected error for entrance on a missing floor")
+	}
+}
+
+func TestGetVehicleRecords(t *testing.T) {
+	lot, err := CreateParkingLot("History Lot", 1, 2, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	var notFoundErr *errors.VehicleNotFoundError
+	if _, err := lot.GetVehicleRecords("KA-01-HH-1234"); !stderrors.As(err, &notFoundErr) {
+		t.Errorf("Expected vehicle not found error, got %v", err)
+	}
+	if _, err := lot.GetVehicleRecords(""); err == nil {
+		t.Error("Expected error for an empty vehicle number")
+	}
+
+	spotID, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-1234")
+	if err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	if err := lot.Unpark(spotID, "KA-01-HH-1234"); err != nil {
+		t.Fatalf("Failed to unpark: %v", err)
+	}
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-1234"); err != nil {
+		t.Fatalf("Failed to park again: %v", err)
+	}
+
+	records, err := lot.GetVehicleRecords("ka-01-hh-1234")
+	if err != nil {
+		t.Fatalf("Failed to get records: %v", err)
+	}
+	if len(records) != 2 || !records[0].IsComplete() || records[1].IsComplete() {
+		t.Fatalf("Expected a completed and an active record, got %+v", records)
+	}
+
+	// The records are a copy
+	records[0].SpotID = "changed"
+	history, _ := lot.GetVehicleHistory("KA-01-HH-1234")
+	if history.Records[0].SpotID != spotID {
+		t.Errorf("Expected history to be unchanged, got spot %s", history.Records[0].SpotID)
+	}
+}
+
+func TestVehicleHistoryLimit(t *testing.T) {
+	lot, err := CreateParkingLot("History Limit Lot", 1, 2, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	if lot.GetVehicleHistoryLimit() != DefaultVehicleHistoryLimit {
+		t.Errorf("Expected default limit %d, got %d", DefaultVehicleHistoryLimit, lot.GetVehicleHistoryLimit())
+	}
+	if err := lot.SetVehicleHistoryLimit(0); err == nil {
+		t.Error("Expected error for a zero limit")
+	}
+	if err := lot.SetVehicleHistoryLimit(3); err != nil {
+		t.Fatalf("Failed to set limit: %v", err)
+	}
+
+	// A shuttle that parks over and over keeps only its last 3 completed sessions
+	for i := 0; i < 6; i++ {
+		spotID, err := lot.Park(VehicleTypeAutomobile, "KA-01-SH-0001")
+		if err != nil {
+			t.Fatalf("Failed to park: %v", err)
+		}
+		if err := lot.Unpark(spotID, "KA-01-SH-0001"); err != nil {
+			t.Fatalf("Failed to unpark: %v", err)
+		}
+	}
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-01-SH-0001"); err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+
+	records, _ := lot.GetVehicleRecords("KA-01-SH-0001")
+	if len(records) != 4 || records[3].IsComplete() {
+		t.Errorf("Expected 3 completed records and the active one, got %+v", records)
+	}
+
+	// Lowering the limit trims existing history, keeping the active record
+	if err := lot.SetVehicleHistoryLimit(1); err != nil {
+		t.Fatalf("Failed to set limit: %v", err)
+	}
+	records, _ = lot.GetVehicleRecords("KA-01-SH-0001")
+	if len(records) != 2 || !records[0].IsComplete() || records[1].IsComplete() {
+		t.Errorf("Expected 1 completed record and the active one, got %+v", records)
+	}
+}
+
+func TestPruneHistory(t *testing.T) {
+	lot, err := CreateParkingLot("Prune Lot", 1, 2, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	// One vehicle has left, another is still parked after an earlier visit
+	spotID, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-0001")
+	if err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	if err := lot.Unpark(spotID, "KA-01-HH-0001"); err != nil {
+		t.Fatalf("Failed to unpark: %v", err)
+	}
+	otherSpotID, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-0002")
+	if err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	if err := lot.Unpark(otherSpotID, "KA-01-HH-0002"); err != nil {
+		t.Fatalf("Failed to unpark: %v", err)
+	}
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-0002"); err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+
+	// Nothing ended before an hour ago
+	if removed := lot.PruneHistory(time.Now().Add(-time.Hour)); removed != 0 {
+		t.Errorf("Expected nothing pruned, got %d", removed)
+	}
+
+	// Both completed records go, the active one stays
+	if removed := lot.PruneHistory(time.Now().Add(time.Second)); removed != 2 {
+		t.Errorf("Expected 2 records pruned, got %d", removed)
+	}
+
+	records, err := lot.GetVehicleRecords("KA-01-HH-0002")
+	if err != nil || len(records) != 1 || records[0].IsComplete() {
+		t.Errorf("Expected only the active record to survive, got %+v, %v", records, err)
+	}
+
+	// The departed vehicle is still known by its last spot
+	lastSpotID, isParked, err := lot.SearchVehicle("KA-01-HH-0001")
+	if err != nil || isParked || lastSpotID != spotID {
+		t.Errorf("Expected KA-01-HH-0001 last seen at %s, got %s, %v, %v", spotID, lastSpotID, isParked, err)
+	}
+
+	stats := lot.GetParkingStats()
+	if stats.CompletedSessions != 0 || stats.ActiveSessions != 1 || stats.DistinctVehicles != 2 {
+		t.Errorf("Expected 0 completed, 1 active and 2 vehicles after pruning, got %+v", stats)
+	}
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
@@ -18,8 +19,24 @@ type ParkingSpot struct {
 	Column int
 
 	// State information
-	isOccupied    bool
-	vehicleNumber string
+	// Vehicles currently in the spot; only bicycle racks hold more than one
+	vehicles []string
+	capacity int
+
+	// Counters of the floor holding the spot, nil for a spot on no floor
+	counters *floorCounters
+
+	// Writes the spot's ID, the dash format when nil
+	ids SpotIDFormatter
+
+	// Human name of the spot, empty when it has none
+	label string
+
+	// Attributes such as "ev" or "covered", sorted
+	tags []string
+
+	// State of the EV charger of a spot tagged ev, empty meaning available
+	charger ChargerState
 
 	// Mutex for thread-safety
 	mu sync.RWMutex
@@ -33,11 +50,7 @@ func NewParkingSpot(spotType SpotType, floor, row, column int) (*ParkingSpot, er
 		return nil, errors.NewInvalidSpotTypeError(string(spotType))
 	}
 
-	// Validate location
-	if floor < 0 {
-		return nil, errors.NewValidationError("floor", fmt.Sprintf("%d", floor), "floor number cannot be negative")
-	}
-
+	// Validate location (negative floors are basements)
 	if row < 0 {
 		return nil, errors.NewValidationError("row", fmt.Sprintf("%d", row), "row number cannot be negative")
 	}
@@ -47,18 +60,37 @@ func NewParkingSpot(spotType SpotType, floor, row, column int) (*ParkingSpot, er
 	}
 
 	return &ParkingSpot{
-		Type:          spotType,
-		Floor:         floor,
-		Row:           row,
-		Column:        column,
-		isOccupied:    false,
-		vehicleNumber: "",
+		Type:     spotType,
+		Floor:    floor,
+		Row:      row,
+		Column:   column,
+		capacity: 1,
 	}, nil
 }
 
-// GetSpotID returns the ID of the spot in the format "floor-row-column"
+// GetSpotID returns the ID of the spot in its lot's format, by default
+// "floor-row-column" with basement floors written with a "B" prefix, e.g.
+// "B2-3-4" for floor -2
 func (s *ParkingSpot) GetSpotID() string {
-	return fmt.Sprintf("%d-%d-%d", s.Floor, s.Row, s.Column)
+	if s.ids != nil {
+		return s.ids.Format(s.Floor, s.Row, s.Column)
+	}
+	return FormatSpotID(s.Floor, s.Row, s.Column)
+}
+
+// FormatSpotID builds a spot ID in the format "floor-row-column"
+func FormatSpotID(floor, row, column int) string {
+	return fmt.Sprintf("%s-%d-%d", FormatFloorNumber(floor), row, column)
+}
+
+// FormatFloorNumber returns the display label of a floor number
+// Basement floors are numbered B1, B2, ... for floors -1, -2, ...
+func FormatFloorNumber(floor int) string {
+	if floor < 0 {
+		return fmt.Sprintf("B%d", -floor)
+	}
+
+	return fmt.Sprintf("%d", floor)
 }
 
 // IsActive returns true if the spot is active
@@ -69,21 +101,197 @@ func (s *ParkingSpot) IsActive() bool {
 	return s.Type.IsActive()
 }
 
-// IsOccupied returns true if the spot is currently occupied
+// GetType returns the spot's type, which SetType can change
+func (s *ParkingSpot) GetType() SpotType {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.Type
+}
+
+// IsOccupied returns true if at least one vehicle is in the spot
 func (s *ParkingSpot) IsOccupied() bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return s.isOccupied
+	return len(s.vehicles) > 0
 }
 
 // GetVehicleNumber returns the number of the vehicle occupying this spot
+// For a rack holding several bicycles this is the first one parked
 // Returns empty string if spot is unoccupied
 func (s *ParkingSpot) GetVehicleNumber() string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return s.vehicleNumber
+	if len(s.vehicles) == 0 {
+		return ""
+	}
+
+	return s.vehicles[0]
+}
+
+// GetVehicleNumbers returns the numbers of all vehicles in this spot
+func (s *ParkingSpot) GetVehicleNumbers() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]string, len(s.vehicles))
+	copy(result, s.vehicles)
+
+	return result
+}
+
+// HasVehicle returns true if the given vehicle is in this spot
+func (s *ParkingSpot) HasVehicle(vehicleNumber string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.indexOf(NormalizeVehicleNumber(vehicleNumber)) >= 0
+}
+
+// GetCapacity returns how many vehicles the spot can hold
+func (s *ParkingSpot) GetCapacity() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.capacity
+}
+
+// GetOccupiedCapacity returns how many vehicles are currently in the spot
+func (s *ParkingSpot) GetOccupiedCapacity() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.vehicles)
+}
+
+// GetAvailableCapacity returns how many more vehicles the spot can hold
+// Inactive spots have no available capacity
+func (s *ParkingSpot) GetAvailableCapacity() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.Type.IsActive() {
+		return 0
+	}
+
+	return s.capacity - len(s.vehicles)
+}
+
+// SetCapacity sets how many vehicles the spot can hold
+// Only bicycle spots (racks) can hold more than one vehicle, and the capacity
+// cannot drop below the number of vehicles already in the spot
+func (s *ParkingSpot) SetCapacity(capacity int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if capacity < 1 {
+		return errors.NewValidationError("capacity", fmt.Sprintf("%d", capacity),
+			"capacity must be at least 1")
+	}
+
+	if capacity > 1 && s.Type != SpotTypeBicycle {
+		return errors.NewValidationError("capacity", fmt.Sprintf("%d", capacity),
+			"only bicycle spots can hold more than one vehicle")
+	}
+
+	if capacity < len(s.vehicles) {
+		return errors.NewValidationError("capacity", fmt.Sprintf("%d", capacity),
+			fmt.Sprintf("spot %s currently holds %d vehicles", s.GetSpotID(), len(s.vehicles)))
+	}
+
+	before := s.stateLocked()
+	s.capacity = capacity
+	s.recordChangeLocked(before)
+	return nil
+}
+
+// SetType changes the spot type, e.g. to mark a spot inactive
+// Occupied spots cannot change type; racks shrink back to a single spot
+// unless they stay bicycle spots
+func (s *ParkingSpot) SetType(spotType SpotType) error {
+	if _, err := ParseSpotType(string(spotType)); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.vehicles) > 0 {
+		return errors.NewInvalidOperationError("setSpotType",
+			fmt.Sprintf("spot %s is occupied by %s", s.GetSpotID(), strings.Join(s.vehicles, ", ")))
+	}
+
+	before := s.stateLocked()
+	s.Type = spotType
+	if spotType != SpotTypeBicycle {
+		s.capacity = 1
+	}
+	s.recordChangeLocked(before)
+
+	return nil
+}
+
+// state returns what the spot contributes to its floor's counters
+func (s *ParkingSpot) state() spotState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.stateLocked()
+}
+
+// stateLocked is state for callers holding the spot lock
+func (s *ParkingSpot) stateLocked() spotState {
+	return spotState{spotType: s.Type, vehicles: len(s.vehicles), capacity: s.capacity}
+}
+
+// recordChangeLocked moves the floor counters from the state before a change
+// to the current state; the caller must hold the spot lock
+func (s *ParkingSpot) recordChangeLocked(before spotState) {
+	if s.counters == nil {
+		return
+	}
+	after := s.stateLocked()
+	s.counters.apply(before, -1)
+	s.counters.apply(after, 1)
+	s.counters.freeSpots.update(s.Row, s.Column, after)
+}
+
+// attach adds the spot to a floor's counters
+func (s *ParkingSpot) attach(counters *floorCounters) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counters = counters
+	state := s.stateLocked()
+	counters.apply(state, 1)
+	counters.freeSpots.update(s.Row, s.Column, state)
+}
+
+// detach removes the spot from its floor's counters
+func (s *ParkingSpot) detach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.counters != nil {
+		state := s.stateLocked()
+		s.counters.apply(state, -1)
+		s.counters.freeSpots.update(s.Row, s.Column, spotState{spotType: SpotTypeInactive})
+		s.counters = nil
+	}
+}
+
+// indexOf returns the position of a normalized vehicle number in the spot, or -1
+// Caller must hold the spot lock
+func (s *ParkingSpot) indexOf(normalizedNumber string) int {
+	for i, number := range s.vehicles {
+		if number == normalizedNumber {
+			return i
+		}
+	}
+
+	return -1
 }
 
 // CanPark checks if a vehicle of given type can park in this spot
@@ -91,8 +299,8 @@ func (s *ParkingSpot) CanPark(vehicleType VehicleType) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	// Must be active and unoccupied
-	if !s.Type.IsActive() || s.isOccupied {
+	// Must be active with room for another vehicle
+	if !s.Type.IsActive() || len(s.vehicles) >= s.capacity {
 		return false
 	}
 
@@ -110,19 +318,20 @@ func (s *ParkingSpot) Occupy(vehicleNumber string) error {
 		return errors.NewSpotInactiveError(s.GetSpotID())
 	}
 
-	// Check if spot is already occupied
-	if s.isOccupied {
+	// Check if spot is already full
+	if len(s.vehicles) >= s.capacity {
 		return errors.NewSpotAlreadyOccupiedError(s.GetSpotID())
 	}
 
-	// Validate vehicle number
-	if err := ValidateVehicleNumber(vehicleNumber); err != nil {
-		return errors.NewInvalidVehicleNumberError(vehicleNumber, err.Error())
+	// The lot enforces its own number rule, so the spot only needs a number
+	if strings.TrimSpace(vehicleNumber) == "" {
+		return errors.NewInvalidVehicleNumberError(vehicleNumber, "vehicle number cannot be empty")
 	}
 
 	// Mark as occupied
-	s.isOccupied = true
-	s.vehicleNumber = NormalizeVehicleNumber(vehicleNumber)
+	before := s.stateLocked()
+	s.vehicles = append(s.vehicles, NormalizeVehicleNumber(vehicleNumber))
+	s.recordChangeLocked(before)
 
 	return nil
 }
@@ -139,19 +348,22 @@ func (s *ParkingSpot) Vacate(vehicleNumber string) error {
 	}
 
 	// Check if spot is occupied
-	if !s.isOccupied {
+	if len(s.vehicles) == 0 {
 		return errors.NewSpotNotOccupiedError(s.GetSpotID())
 	}
 
 	// Validate the vehicle number matches
 	normalizedNumber := NormalizeVehicleNumber(vehicleNumber)
-	if normalizedNumber != s.vehicleNumber {
-		return errors.NewVehicleMismatchError(s.GetSpotID(), s.vehicleNumber, normalizedNumber)
+	index := s.indexOf(normalizedNumber)
+	if index < 0 {
+		return errors.NewVehicleMismatchError(s.GetSpotID(),
+			strings.Join(s.vehicles, ", "), normalizedNumber)
 	}
 
-	// Mark as unoccupied
-	s.isOccupied = false
-	s.vehicleNumber = ""
+	// Remove the vehicle from the spot
+	before := s.stateLocked()
+	s.vehicles = append(s.vehicles[:index], s.vehicles[index+1:]...)
+	s.recordChangeLocked(before)
 
 	return nil
 }
@@ -164,8 +376,9 @@ func (s *ParkingSpot) String() string {
 	spotType := GetSpotTypeDisplay(s.Type)
 	location := s.GetSpotID()
 
-	if s.isOccupied {
-		return fmt.Sprintf("%s at %s (Occupied by %s)", spotType, location, s.vehicleNumber)
+	if len(s.vehicles) > 0 {
+		return fmt.Sprintf("%s at %s (Occupied by %s)", spotType, location,
+			strings.Join(s.vehicles, ", "))
 	}
 
 	if s.Type.IsActive() {
@@ -175,19 +388,64 @@ func (s *ParkingSpot) String() string {
 	return fmt.Sprintf("%s at %s", spotType, location)
 }
 
+// splitSpotID splits the "floor-row-column" part of a spot ID into its three
+// numbers. Each is a run of ASCII digits, which rules out signs, spaces and
+// empty components; a leading minus is let through so the caller can say
+// why negative numbers are wrong. Anything after the column fails.
+func splitSpotID(rest string) ([3]int, bool) {
+	var numbers [3]int
+	for i := range numbers {
+		if i > 0 {
+			if !strings.HasPrefix(rest, "-") {
+				return numbers, false
+			}
+			rest = rest[1:]
+		}
+
+		negative := strings.HasPrefix(rest, "-")
+		if negative {
+			rest = rest[1:]
+		}
+
+		number, digits, ok := scanSpotIDNumber(rest)
+		if !ok {
+			return numbers, false
+		}
+		if negative {
+			number = -number
+		}
+		numbers[i] = number
+		rest = rest[digits:]
+	}
+
+	return numbers, rest == ""
+}
+
 // ParseSpotID parses a spot ID in the format "floor-row-column"
+// Basement floors use a "B" prefix, so "B1-2-3" is floor -1, row 2, column 3
 // Returns floor, row, column and error
 func ParseSpotID(spotID string) (int, int, int, error) {
-	var floor, row, column int
-	count, err := fmt.Sscanf(spotID, "%d-%d-%d", &floor, &row, &column)
+	basement := strings.HasPrefix(spotID, "B") || strings.HasPrefix(spotID, "b")
+	rest := spotID
+	if basement {
+		rest = spotID[1:]
+	}
 
-	if err != nil || count != 3 {
+	numbers, ok := splitSpotID(rest)
+	if !ok {
 		return 0, 0, 0, errors.NewInvalidSpotIDError(spotID, "invalid format, expected floor-row-column")
 	}
+	floor, row, column := numbers[0], numbers[1], numbers[2]
 
 	// Validate bounds
-	if floor < 0 {
-		return 0, 0, 0, errors.NewInvalidSpotIDError(spotID, "floor number cannot be negative")
+	if basement {
+		if floor < 1 {
+			return 0, 0, 0, errors.NewInvalidSpotIDError(spotID, "basement number must be at least 1")
+		}
+		floor = -floor
+	} else if floor < 0 {
+		return 0, 0, 0, errors.NewInvalidSpotIDError(spotID,
+			"floor number cannot be negative, use the B prefix for basements")
 	}
 
 	if row < 0 {
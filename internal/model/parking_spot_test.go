@@ -55,12 +55,12 @@ func TestNewParkingSpot(t *testing.T) {
 			expectErr: true,
 		},
 		{
-			name:      "Negative floor",
+			name:      "Basement floor",
 			spotType:  SpotTypeBicycle,
 			floor:     -1,
 			row:       1,
 			column:    1,
-			expectErr: true,
+			expectErr: false,
 		},
 		{
 			name:      "Negative row",
@@ -328,6 +328,29 @@ func TestString(t *testing.T) {
 	}
 }
 
+func TestBasementSpotID(t *testing.T) {
+	spot, err := NewParkingSpot(SpotTypeAutomobile, -2, 3, 4)
+	if err != nil {
+		t.Fatalf("Failed to create basement spot: %v", err)
+	}
+
+	if spot.GetSpotID() != "B2-3-4" {
+		t.Errorf("Expected spot ID B2-3-4, got %s", spot.GetSpotID())
+	}
+
+	// The ID round-trips through ParseSpotID
+	floor, row, column, err := ParseSpotID(spot.GetSpotID())
+	if err != nil || floor != -2 || row != 3 || column != 4 {
+		t.Errorf("Expected -2, 3, 4 from %s, got %d, %d, %d (%v)",
+			spot.GetSpotID(), floor, row, column, err)
+	}
+
+	if FormatFloorNumber(-1) != "B1" || FormatFloorNumber(0) != "0" || FormatFloorNumber(3) != "3" {
+		t.Errorf("Unexpected floor labels: %s, %s, %s",
+			FormatFloorNumber(-1), FormatFloorNumber(0), FormatFloorNumber(3))
+	}
+}
+
 func TestParseSpotID(t *testing.T) {
 	tests := []struct {
 		spotID    string
@@ -340,6 +363,14 @@ func TestParseSpotID(t *testing.T) {
 		{"0-0-0", 0, 0, 0, false},
 		{"10-20-30", 10, 20, 30, false},
 
+		// Basement floors
+		{"B1-2-3", -1, 2, 3, false},
+		{"B2-0-0", -2, 0, 0, false},
+		{"b3-4-5", -3, 4, 5, false},
+		{"B0-1-1", 0, 0, 0, true},
+		{"B-1-2-3", 0, 0, 0, true},
+		{"B1-2", 0, 0, 0, true},
+
 		// Invalid formats
 		{"1-2", 0, 0, 0, true},
 		{"1-2-", 0, 0, 0, true},
@@ -353,6 +384,32 @@ func TestParseSpotID(t *testing.T) {
 		{"-1-2-3", 0, 0, 0, true},
 		{"1--2-3", 0, 0, 0, true},
 		{"1-2--3", 0, 0, 0, true},
+		{"B-1-2-3x", 0, 0, 0, true},
+
+		// Trailing garbage and extra components
+		{"1-2-3xyz", 0, 0, 0, true},
+		{"1-2-3-4", 0, 0, 0, true},
+		{"1-2-3-", 0, 0, 0, true},
+		{"B1-2-3x", 0, 0, 0, true},
+
+		// Signs and whitespace
+		{"+1-2-3", 0, 0, 0, true},
+		{"1-+2-3", 0, 0, 0, true},
+		{"B+1-2-3", 0, 0, 0, true},
+		{" 1-2-3", 0, 0, 0, true},
+		{"1-2-3 ", 0, 0, 0, true},
+		{"1- 2-3", 0, 0, 0, true},
+		{"1 -2-3", 0, 0, 0, true},
+		{"B 1-2-3", 0, 0, 0, true},
+
+		// Empty components
+		{"--", 0, 0, 0, true},
+		{"1--3", 0, 0, 0, true},
+		{"B", 0, 0, 0, true},
+		{"B--", 0, 0, 0, true},
+
+		// Numbers too large to represent
+		{"99999999999999999999-1-1", 0, 0, 0, true},
 	}
 
 	for _, tt := range tests {
@@ -383,3 +440,97 @@ func TestParseSpotID(t *testing.T) {
 		})
 	}
 }
+
+func TestSpotCapacity(t *testing.T) {
+	rack, _ := NewParkingSpot(SpotTypeBicycle, 0, 0, 0)
+
+	if rack.GetCapacity() != 1 {
+		t.Errorf("Expected default capacity 1, got %d", rack.GetCapacity())
+	}
+
+	if err := rack.SetCapacity(3); err != nil {
+		t.Fatalf("Failed to set rack capacity: %v", err)
+	}
+
+	// Fill the rack
+	for _, number := range []string{"BIKE-1", "BIKE-2", "BIKE-3"} {
+		if !rack.CanPark(VehicleTypeBicycle) {
+			t.Fatalf("Expected rack to accept %s", number)
+		}
+		if err := rack.Occupy(number); err != nil {
+			t.Fatalf("Failed to park %s in rack: %v", number, err)
+		}
+	}
+
+	if rack.CanPark(VehicleTypeBicycle) {
+		t.Errorf("Expected full rack to reject another bicycle")
+	}
+
+	if err := rack.Occupy("BIKE-4"); err == nil {
+		t.Errorf("Expected error when occupying a full rack")
+	}
+
+	if rack.GetOccupiedCapacity() != 3 || rack.GetAvailableCapacity() != 0 {
+		t.Errorf("Expected 3 occupied and 0 free slots, got %d and %d",
+			rack.GetOccupiedCapacity(), rack.GetAvailableCapacity())
+	}
+
+	// Capacity cannot drop below the current occupancy
+	if err := rack.SetCapacity(2); err == nil {
+		t.Errorf("Expected error when shrinking an occupied rack")
+	}
+
+	// Vacating one bicycle leaves the others in place
+	if err := rack.Vacate("BIKE-2"); err != nil {
+		t.Fatalf("Failed to vacate rack slot: %v", err)
+	}
+
+	if !rack.HasVehicle("BIKE-1") || rack.HasVehicle("BIKE-2") || !rack.HasVehicle("BIKE-3") {
+		t.Errorf("Unexpected rack contents: %v", rack.GetVehicleNumbers())
+	}
+
+	if rack.GetVehicleNumber() != "BIKE-1" {
+		t.Errorf("Expected first vehicle BIKE-1, got %s", rack.GetVehicleNumber())
+	}
+
+	if err := rack.Vacate("BIKE-9"); err == nil {
+		t.Errorf("Expected error when vacating a bicycle that is not in the rack")
+	}
+
+	// Only bicycle spots can become racks
+	carSpot, _ := NewParkingSpot(SpotTypeAutomobile, 0, 0, 1)
+	if err := carSpot.SetCapacity(2); err == nil {
+		t.Errorf("Expected error when setting capacity on an automobile spot")
+	}
+
+	if err := carSpot.SetCapacity(0); err == nil {
+		t.Errorf("Expected error for zero capacity")
+	}
+
+	inactive, _ := NewParkingSpot(SpotTypeInactive, 0, 0, 2)
+	if inactive.GetAvailableCapacity() != 0 {
+		t.Errorf("Expected inactive spot to have no capacity")
+	}
+}
+
+func TestSpotSetType(t *testing.T) {
+	spot, _ := NewParkingSpot(SpotTypeBicycle, 0, 0, 0)
+	if err := spot.SetCapacity(4); err != nil {
+		t.Fatalf("Failed to set capacity: %v", err)
+	}
+
+	// A rack that becomes an automobile spot holds a single vehicle
+	if err := spot.SetType(SpotTypeAutomobile); err != nil {
+		t.Fatalf("Failed to set type: %v", err)
+	}
+	if spot.Type != SpotTypeAutomobile || spot.GetCapacity() != 1 {
+		t.Errorf("Expected A-1 with capacity 1, got %s with capacity %d", spot.Type, spot.GetCapacity())
+	}
+
+	if err := spot.Occupy("CAR-1"); err != nil {
+		t.Fatalf("Failed to occupy: %v", err)
+	}
+	if err := spot.SetType(SpotTypeInactive); err == nil {
+		t.Errorf("Expected error changing the type of an occupied spot")
+	}
+}
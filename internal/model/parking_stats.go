@@ -0,0 +1,86 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// ParkingStats aggregates the parking sessions recorded in vehicle history
+type ParkingStats struct {
+	// CompletedSessions have ended; ActiveSessions are vehicles still parked
+	CompletedSessions int
+	ActiveSessions    int
+
+	// DistinctVehicles is the number of vehicles that have ever parked
+	DistinctVehicles int
+
+	// Durations are over completed sessions only, zero when there are none
+	AverageDuration time.Duration
+	MedianDuration  time.Duration
+
+	// BusiestSpotID is the spot with the most sessions, completed or not,
+	// the lowest spot ID on ties and empty when nothing has parked
+	BusiestSpotID       string
+	BusiestSpotSessions int
+}
+
+// GetParkingStats computes statistics over the lot's vehicle history.
+// Records are visited in place; only completed session durations are kept
+// for the median.
+func (p *ParkingLot) GetParkingStats() ParkingStats {
+	var stats ParkingStats
+	var total time.Duration
+	var durations []time.Duration
+	spotSessions := make(map[string]int)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	p.historyMu.Lock()
+	defer p.historyMu.Unlock()
+
+	p.vehicleHistory.each(func(_ string, history *VehicleHistory) bool {
+		// Vehicles whose records were all pruned still count as seen
+		if history.GetLastSpotID() != "" {
+			stats.DistinctVehicles++
+		}
+
+		for i := range history.Records {
+			record := &history.Records[i]
+			spotSessions[record.SpotID]++
+
+			if !record.IsComplete() {
+				stats.ActiveSessions++
+				continue
+			}
+
+			stats.CompletedSessions++
+			duration := record.Duration()
+			total += duration
+			durations = append(durations, duration)
+		}
+		return true
+	})
+
+	if stats.CompletedSessions > 0 {
+		stats.AverageDuration = total / time.Duration(stats.CompletedSessions)
+
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		mid := len(durations) / 2
+		if len(durations)%2 == 1 {
+			stats.MedianDuration = durations[mid]
+		} else {
+			stats.MedianDuration = (durations[mid-1] + durations[mid]) / 2
+		}
+	}
+
+	for spotID, sessions := range spotSessions {
+		if sessions > stats.BusiestSpotSessions ||
+			(sessions == stats.BusiestSpotSessions && spotID < stats.BusiestSpotID) {
+			stats.BusiestSpotID = spotID
+			stats.BusiestSpotSessions = sessions
+		}
+	}
+
+	return stats
+}
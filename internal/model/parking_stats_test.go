@@ -0,0 +1,113 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+// storeHistory records a handcrafted history for a vehicle
+func storeHistory(lot *ParkingLot, vehicleType VehicleType, number string, records ...ParkingRecord) {
+	vehicle, _ := NewVehicle(vehicleType, number)
+	history := NewVehicleHistory(vehicle)
+	history.Records = append(history.Records, records...)
+	lot.vehicleHistory.put(vehicle.Number, history)
+}
+
+// completedRecord returns a finished session at spotID lasting d from start
+func completedRecord(spotID string, start time.Time, d time.Duration) ParkingRecord {
+	end := start.Add(d)
+	return ParkingRecord{SpotID: spotID, ParkedAt: start, UnparkedAt: &end}
+}
+
+func TestGetParkingStats(t *testing.T) {
+	lot, err := CreateParkingLot("Stats Lot", 1, 2, 2)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	// Nothing recorded yet
+	if stats := lot.GetParkingStats(); stats != (ParkingStats{}) {
+		t.Errorf("Expected empty stats, got %+v", stats)
+	}
+
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	storeHistory(lot, VehicleTypeAutomobile, "KA-01-AA-0001",
+		completedRecord("0-0-0", start, 10*time.Minute),
+		completedRecord("0-0-1", start.Add(time.Hour), 20*time.Minute))
+	storeHistory(lot, VehicleTypeAutomobile, "KA-01-AA-0002",
+		completedRecord("0-0-0", start, 30*time.Minute),
+		ParkingRecord{SpotID: "0-0-0", ParkedAt: start.Add(time.Hour)})
+	storeHistory(lot, VehicleTypeMotorcycle, "KA-01-AA-0003",
+		completedRecord("0-0-1", start, time.Hour))
+
+	// A history without records is not a vehicle seen in the lot
+	storeHistory(lot, VehicleTypeBicycle, "KA-01-AA-0004")
+
+	expected := ParkingStats{
+		CompletedSessions:   4,
+		ActiveSessions:      1,
+		DistinctVehicles:    3,
+		AverageDuration:     30 * time.Minute,
+		MedianDuration:      25 * time.Minute,
+		BusiestSpotID:       "0-0-0",
+		BusiestSpotSessions: 3,
+	}
+	if stats := lot.GetParkingStats(); stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+
+	// Replacing a history drops its sessions, and an odd number of sessions
+	// takes the middle duration
+	storeHistory(lot, VehicleTypeAutomobile, "KA-01-AA-0005",
+		completedRecord("0-1-1", start, 90*time.Minute))
+	storeHistory(lot, VehicleTypeAutomobile, "KA-01-AA-0002",
+		completedRecord("0-1-0", start, 5*time.Minute))
+
+	expected = ParkingStats{
+		CompletedSessions:   5,
+		ActiveSessions:      0,
+		DistinctVehicles:    4,
+		AverageDuration:     37 * time.Minute,
+		MedianDuration:      20 * time.Minute,
+		BusiestSpotID:       "0-0-1",
+		BusiestSpotSessions: 2,
+	}
+	if stats := lot.GetParkingStats(); stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+
+	// Ties on the busiest spot go to the lowest spot ID
+	storeHistory(lot, VehicleTypeMotorcycle, "KA-01-AA-0003",
+		completedRecord("0-1-1", start, time.Hour),
+		completedRecord("0-1-0", start.Add(time.Hour), time.Hour))
+	if stats := lot.GetParkingStats(); stats.BusiestSpotID != "0-1-0" || stats.BusiestSpotSessions != 2 {
+		t.Errorf("Expected busiest spot 0-1-0 with 2 sessions, got %s with %d",
+			stats.BusiestSpotID, stats.BusiestSpotSessions)
+	}
+}
+
+func TestGetParkingStatsFromParking(t *testing.T) {
+	lot, err := CreateParkingLot("Stats Lot", 1, 2, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	spotID, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-1234")
+	if err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-9999"); err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	if err := lot.Unpark(spotID, "KA-01-HH-1234"); err != nil {
+		t.Fatalf("Failed to unpark: %v", err)
+	}
+
+	stats := lot.GetParkingStats()
+	if stats.CompletedSessions != 1 || stats.ActiveSessions != 1 || stats.DistinctVehicles != 2 {
+		t.Errorf("Expected 1 completed, 1 active and 2 vehicles, got %+v", stats)
+	}
+	if stats.AverageDuration != stats.MedianDuration {
+		t.Errorf("Expected a single session's average and median to match, got %+v", stats)
+	}
+}
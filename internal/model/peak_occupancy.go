@@ -0,0 +1,106 @@
+package model
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// PeakOccupancy is the most vehicles parked at once and when that was first reached
+type PeakOccupancy struct {
+	Count int
+	At    time.Time
+}
+
+// PeakOccupancyReport holds the peaks of the lot overall and per vehicle type
+type PeakOccupancyReport struct {
+	Overall PeakOccupancy
+	// ByType has an entry for every vehicle type, zero when none has parked
+	ByType map[VehicleType]PeakOccupancy
+}
+
+// occupancyCounter counts parked vehicles and keeps their high-water mark
+// without taking a lock, since it is updated on every park
+type occupancyCounter struct {
+	current atomic.Int64
+	peak    atomic.Pointer[PeakOccupancy]
+}
+
+// increment counts a parked vehicle and raises the peak if it was exceeded
+func (c *occupancyCounter) increment(at time.Time) {
+	count := int(c.current.Add(1))
+	for {
+		peak := c.peak.Load()
+		if peak != nil && peak.Count >= count {
+			return
+		}
+		if c.peak.CompareAndSwap(peak, &PeakOccupancy{Count: count, At: at}) {
+			return
+		}
+	}
+}
+
+// decrement counts an unparked vehicle; the peak is kept
+func (c *occupancyCounter) decrement() {
+	c.current.Add(-1)
+}
+
+// getPeak returns the high-water mark, zero when nothing has parked
+func (c *occupancyCounter) getPeak() PeakOccupancy {
+	if peak := c.peak.Load(); peak != nil {
+		return *peak
+	}
+	return PeakOccupancy{}
+}
+
+// reset clears the count and the peak
+func (c *occupancyCounter) reset() {
+	c.current.Store(0)
+	c.peak.Store(nil)
+}
+
+// occupancyTracker keeps occupancy counters overall and per vehicle type,
+// indexed by the position of the type in the type registry
+type occupancyTracker struct {
+	overall occupancyCounter
+	byType  [maxVehicleTypes]occupancyCounter
+}
+
+// forType returns the counter of a vehicle type; oversized vehicles count
+// once with the type whose spots they take
+func (t *occupancyTracker) forType(vehicleType VehicleType) *occupancyCounter {
+	// Parks are validated, so an unknown type never reaches here
+	return &t.byType[types.Load().vehicleIndex[vehicleType.BaseType()]]
+}
+
+// recordPark counts a vehicle parked at the given time
+func (t *occupancyTracker) recordPark(vehicleType VehicleType, at time.Time) {
+	t.overall.increment(at)
+	t.forType(vehicleType).increment(at)
+}
+
+// recordUnpark counts a vehicle leaving
+func (t *occupancyTracker) recordUnpark(vehicleType VehicleType) {
+	t.overall.decrement()
+	t.forType(vehicleType).decrement()
+}
+
+// reset clears every counter
+func (t *occupancyTracker) reset() {
+	t.overall.reset()
+	for i := range t.byType {
+		t.byType[i].reset()
+	}
+}
+
+// GetPeakOccupancy returns the most vehicles parked at once since the lot was
+// created or last reset, overall and per vehicle type
+func (p *ParkingLot) GetPeakOccupancy() PeakOccupancyReport {
+	report := PeakOccupancyReport{
+		Overall: p.occupancy.overall.getPeak(),
+		ByType:  make(map[VehicleType]PeakOccupancy),
+	}
+	for _, vehicleType := range spotVehicleTypes() {
+		report.ByType[vehicleType] = p.occupancy.forType(vehicleType).getPeak()
+	}
+	return report
+}
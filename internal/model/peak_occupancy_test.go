@@ -0,0 +1,146 @@
+package model
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestGetPeakOccupancy(t *testing.T) {
+	lot, err := CreateParkingLot("Peak Lot", 1, 2, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	if peak := lot.GetPeakOccupancy(); peak.Overall.Count != 0 || !peak.Overall.At.IsZero() {
+		t.Errorf("Expected no peak before parking, got %+v", peak.Overall)
+	}
+
+	spots := make(map[string]string)
+	for _, number := range []string{"KA-01", "KA-02", "KA-03"} {
+		spotID, err := lot.Park(VehicleTypeAutomobile, number)
+		if err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+		spots[number] = spotID
+	}
+	if _, err := lot.Park(VehicleTypeBicycle, "BI-01"); err != nil {
+		t.Fatalf("Failed to park bicycle: %v", err)
+	}
+
+	// The peak is reached by the last vehicle to park
+	history, _ := lot.GetVehicleHistory("BI-01")
+	peakAt := history.GetLastParkingRecord().ParkedAt
+
+	// Leaving and parking again below the peak keeps it
+	if err := lot.Unpark(spots["KA-01"], "KA-01"); err != nil {
+		t.Fatalf("Failed to unpark: %v", err)
+	}
+	if err := lot.Unpark(spots["KA-02"], "KA-02"); err != nil {
+		t.Fatalf("Failed to unpark: %v", err)
+	}
+	if _, err := lot.Park(VehicleTypeMotorcycle, "MO-01"); err != nil {
+		t.Fatalf("Failed to park motorcycle: %v", err)
+	}
+
+	peak := lot.GetPeakOccupancy()
+	if peak.Overall.Count != 4 || !peak.Overall.At.Equal(peakAt) {
+		t.Errorf("Expected peak of 4 at %v, got %+v", peakAt, peak.Overall)
+	}
+
+	expected := map[VehicleType]int{
+		VehicleTypeBicycle:    1,
+		VehicleTypeMotorcycle: 1,
+		VehicleTypeAutomobile: 3,
+	}
+	for vehicleType, count := range expected {
+		if peak.ByType[vehicleType].Count != count {
+			t.Errorf("Expected %s peak of %d, got %d", vehicleType, count, peak.ByType[vehicleType].Count)
+		}
+	}
+
+	lot.Reset()
+	peak = lot.GetPeakOccupancy()
+	if peak.Overall.Count != 0 || peak.ByType[VehicleTypeAutomobile].Count != 0 {
+		t.Errorf("Expected peaks cleared by reset, got %+v", peak)
+	}
+
+	// Counting starts again from an empty lot
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-04"); err != nil {
+		t.Fatalf("Failed to park after reset: %v", err)
+	}
+	if peak := lot.GetPeakOccupancy(); peak.Overall.Count != 1 {
+		t.Errorf("Expected peak of 1 after reset, got %d", peak.Overall.Count)
+	}
+}
+
+func TestPeakOccupancyConcurrent(t *testing.T) {
+	lot, err := CreateParkingLot("Concurrent Peak Lot", 2, 10, 10)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	const workers = 8
+	const rounds = 50
+
+	var mu sync.Mutex
+	maxObserved := 0
+	observe := func() {
+		count := int(lot.occupancy.overall.current.Load())
+		mu.Lock()
+		if count > maxObserved {
+			maxObserved = count
+		}
+		mu.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				number := fmt.Sprintf("CP-%02d-%03d", w, i)
+				spotID, err := lot.Park(VehicleTypeAutomobile, number)
+				if err != nil {
+					continue
+				}
+				observe()
+
+				// Leave every other vehicle parked so the occupancy climbs
+				if i%2 == 0 {
+					_ = lot.Unpark(spotID, number)
+				}
+			}
+		}(w)
+	}
+
+	// The peak only ever rises while vehicles come and go
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		last := 0
+		for i := 0; i < 1000; i++ {
+			peak := lot.GetPeakOccupancy().Overall.Count
+			if peak < last {
+				t.Errorf("Peak dropped from %d to %d", last, peak)
+				return
+			}
+			last = peak
+		}
+	}()
+
+	wg.Wait()
+	<-done
+
+	peak := lot.GetPeakOccupancy()
+	if peak.Overall.Count < maxObserved {
+		t.Errorf("Peak %d is lower than observed occupancy %d", peak.Overall.Count, maxObserved)
+	}
+	if current := len(lot.GetParkedVehicleDetails()); peak.Overall.Count < current {
+		t.Errorf("Peak %d is lower than current occupancy %d", peak.Overall.Count, current)
+	}
+	if peak.Overall.Count > workers*rounds {
+		t.Errorf("Peak %d exceeds the number of vehicles parked", peak.Overall.Count)
+	}
+}
@@ -0,0 +1,116 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// PillarPattern places the inactive spots a floor's pillars stand on: on
+// every RowEvery-th row, Width spots from every ColumnEvery-th column. The
+// zero pattern places no pillars.
+type PillarPattern struct {
+	RowEvery    int
+	ColumnEvery int
+	Width       int
+}
+
+// DefaultPillarPattern returns the pattern of generated layouts, two spots
+// of pillar every 7 spots on every 7th row
+func DefaultPillarPattern() PillarPattern {
+	return PillarPattern{RowEvery: 7, ColumnEvery: 7, Width: 2}
+}
+
+// IsNone reports whether the pattern places no pillars
+func (p PillarPattern) IsNone() bool {
+	return p == PillarPattern{}
+}
+
+// IsPillar reports whether the spot at row r and column c is a pillar
+func (p PillarPattern) IsPillar(r, c int) bool {
+	if p.IsNone() {
+		return false
+	}
+	return r%p.RowEvery == 0 && c%p.ColumnEvery < p.Width
+}
+
+// Validate checks that the pattern is none, or has positive strides with a
+// width of at most the column stride
+func (p PillarPattern) Validate() error {
+	if p.IsNone() {
+		return nil
+	}
+	if p.RowEvery < 1 || p.ColumnEvery < 1 || p.Width < 1 || p.Width > p.ColumnEvery {
+		return errors.NewValidationError("pillars", p.String(),
+			"strides must be positive and the width between 1 and the column stride")
+	}
+	return nil
+}
+
+// String returns the pattern as ParsePillarPattern reads it
+func (p PillarPattern) String() string {
+	if p.IsNone() {
+		return "none"
+	}
+	return fmt.Sprintf("%d:%d:%d", p.RowEvery, p.ColumnEvery, p.Width)
+}
+
+// ParsePillarPattern parses a pattern written <rows>:<columns>[:<width>],
+// e.g. 7:7:2 for the default one and 1:9 for a pillar every 9 spots along
+// every row, or none for no pillars. The width is 1 when left out.
+func ParsePillarPattern(spec string) (PillarPattern, error) {
+	if spec == "none" {
+		return PillarPattern{}, nil
+	}
+
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return PillarPattern{}, errors.NewValidationError("pillars", spec,
+			"expected <rows>:<columns>[:<width>] or none")
+	}
+	values := []int{0, 0, 1}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return PillarPattern{}, errors.NewValidationError("pillars", spec,
+				fmt.Sprintf("%q is not a number", part))
+		}
+		values[i] = n
+	}
+
+	pattern := PillarPattern{RowEvery: values[0], ColumnEvery: values[1], Width: values[2]}
+	if err := pattern.Validate(); err != nil {
+		return PillarPattern{}, err
+	}
+	return pattern, nil
+}
+
+// placePillars makes the spots of a floor's layout where the pattern places
+// pillars inactive
+func placePillars(spotMap [][]SpotType, pillars PillarPattern) {
+	for r := range spotMap {
+		for c := range spotMap[r] {
+			if pillars.IsPillar(r, c) {
+				spotMap[r][c] = SpotTypeInactive
+			}
+		}
+	}
+}
+
+// defaultSpotType returns the type of a spot in the default distribution: a
+// pillar where the pattern places one, and otherwise by column a bicycle in
+// the first quarter, a motorcycle in the second and an automobile after
+func defaultSpotType(r, c, columns int, pillars PillarPattern) SpotType {
+	switch {
+	case pillars.IsPillar(r, c):
+		return SpotTypeInactive
+	case c < columns/4:
+		return SpotTypeBicycle
+	case c < columns/2:
+		return SpotTypeMotorcycle
+	default:
+		return SpotTypeAutomobile
+	}
+}
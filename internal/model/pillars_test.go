@@ -0,0 +1,68 @@
+package model
+
+import "testing"
+
+func TestPillarPatternLayouts(t *testing.T) {
+	tests := []struct {
+		spec     string
+		inactive int
+	}{
+		// Rows 0 and 7, columns 0, 1, 7, 8, 14 and 15
+		{"7:7:2", 12},
+		// Columns 0, 9 and 18 of every row
+		{"1:9", 30},
+		// Columns 0 to 2 and 10 to 12 of rows 0, 5
+		{"5:10:3", 12},
+		{"none", 0},
+	}
+
+	for _, test := range tests {
+		pattern, err := ParsePillarPattern(test.spec)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", test.spec, err)
+		}
+		layout, err := NewSpotLayoutFromSpecs([]FloorSpec{{Rows: 10, Columns: 20, Pillars: &pattern}})
+		if err != nil {
+			t.Fatalf("Failed to create layout with pillars %s: %v", test.spec, err)
+		}
+		if got := layout.CountSpotsByType()[SpotTypeInactive]; got != test.inactive {
+			t.Errorf("Expected %d inactive spots with pillars %s, got %d", test.inactive, test.spec, got)
+		}
+	}
+
+	// A distributed floor has only the pillars placed on it
+	pattern := PillarPattern{RowEvery: 1, ColumnEvery: 9, Width: 1}
+	dist := DistributionSpec{BicycleWeight: 1, MotorcycleWeight: 1, AutomobileWeight: 2}
+	layout, err := NewSpotLayoutFromSpecs([]FloorSpec{{Rows: 10, Columns: 20, Distribution: &dist, Pillars: &pattern}})
+	if err != nil {
+		t.Fatalf("Failed to create distributed layout with pillars: %v", err)
+	}
+	if got := layout.CountSpotsByType()[SpotTypeInactive]; got != 30 {
+		t.Errorf("Expected 30 inactive spots on the distributed floor, got %d", got)
+	}
+
+	// The default pattern is the one layouts have without a spec
+	defaults, _ := NewSpotLayout(1, 10, 20)
+	if got := defaults.CountSpotsByType()[SpotTypeInactive]; got != 12 {
+		t.Errorf("Expected 12 inactive spots by default, got %d", got)
+	}
+}
+
+func TestParsePillarPattern(t *testing.T) {
+	pattern, err := ParsePillarPattern("1:9")
+	if err != nil || pattern != (PillarPattern{RowEvery: 1, ColumnEvery: 9, Width: 1}) {
+		t.Errorf("Expected 1:9 with a width of 1, got %+v (%v)", pattern, err)
+	}
+	if pattern.String() != "1:9:1" || DefaultPillarPattern().String() != "7:7:2" {
+		t.Errorf("Expected the patterns written as parsed, got %s and %s", pattern, DefaultPillarPattern())
+	}
+	if pattern, _ := ParsePillarPattern("none"); !pattern.IsNone() || pattern.String() != "none" {
+		t.Errorf("Expected none to place no pillars, got %+v", pattern)
+	}
+
+	for _, spec := range []string{"", "7", "7:x", "0:7", "7:-1", "7:7:8", "7:7:0", "1:2:3:4"} {
+		if _, err := ParsePillarPattern(spec); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package model
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// Quota is the most general parks a vehicle type may have at once, and how
+// many it has. General parks are those neither reserved nor of a vehicle
+// exempt from quotas.
+type Quota struct {
+	VehicleType VehicleType
+	Limit       int
+	Used        int
+}
+
+// quotaCounter counts the general parks of each vehicle type against their
+// quotas. Parks are counted whether or not their type has a quota, so one
+// set later takes the vehicles already parked into account.
+type quotaCounter struct {
+	mu     sync.Mutex
+	limits map[VehicleType]int
+	used   map[VehicleType]int
+}
+
+// acquire counts a general park of the vehicle type, or fails with a
+// QuotaExceededError when its quota is used up
+func (q *quotaCounter) acquire(vehicleType VehicleType) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if limit, found := q.limits[vehicleType]; found && q.used[vehicleType] >= limit {
+		return errors.NewQuotaExceededError(string(vehicleType), limit)
+	}
+
+	if q.used == nil {
+		q.used = make(map[VehicleType]int)
+	}
+	q.used[vehicleType]++
+	return nil
+}
+
+// release stops counting a general park of the vehicle type
+func (q *quotaCounter) release(vehicleType VehicleType) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.used[vehicleType] > 0 {
+		q.used[vehicleType]--
+	}
+}
+
+// setLimit sets the quota of the vehicle type
+func (q *quotaCounter) setLimit(vehicleType VehicleType, limit int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.limits == nil {
+		q.limits = make(map[VehicleType]int)
+	}
+	q.limits[vehicleType] = limit
+}
+
+// clearLimit removes the quota of the vehicle type
+func (q *quotaCounter) clearLimit(vehicleType VehicleType) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.limits, vehicleType)
+}
+
+// quotas returns the quotas set, in vehicle type registration order
+func (q *quotaCounter) quotas() []Quota {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var quotas []Quota
+	for _, info := range types.Load().vehicleTypes {
+		if limit, found := q.limits[info.Type]; found {
+			quotas = append(quotas, Quota{VehicleType: info.Type, Limit: limit, Used: q.used[info.Type]})
+		}
+	}
+	return quotas
+}
+
+// reset forgets every counted park, keeping the quotas
+func (q *quotaCounter) reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.used = nil
+}
+
+// SetQuota limits the general parks of a vehicle type to limit at once.
+// Parks past it fail with a QuotaExceededError, while reserved parks and
+// vehicles exempt from quotas are admitted as long as spots are free. A
+// quota below the vehicles already parked admits no general parks until
+// enough of them leave.
+func (p *ParkingLot) SetQuota(vehicleType VehicleType, limit int) error {
+	if err := validateVehicleType(vehicleType); err != nil {
+		return err
+	}
+
+	if limit < 0 {
+		return errors.NewValidationError("quota", fmt.Sprintf("%d", limit), "quota cannot be negative")
+	}
+
+	p.quotas.setLimit(vehicleType, limit)
+	return nil
+}
+
+// ClearQuota removes the quota of a vehicle type, if it has one
+func (p *ParkingLot) ClearQuota(vehicleType VehicleType) error {
+	if err := validateVehicleType(vehicleType); err != nil {
+		return err
+	}
+
+	p.quotas.clearLimit(vehicleType)
+	return nil
+}
+
+// GetQuotas returns the quotas set, with the general parks counted against each
+func (p *ParkingLot) GetQuotas() []Quota {
+	return p.quotas.quotas()
+}
+
+// ExemptFromQuotas records that the vehicle parks on a reservation, so no
+// park of it is counted against or refused by a quota
+func (p *ParkingLot) ExemptFromQuotas(vehicleNumber string) error {
+	if err := p.validateVehicleNumber(vehicleNumber); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.quotaExempt == nil {
+		p.quotaExempt = make(map[string]bool)
+	}
+	p.quotaExempt[p.vehicleKey(vehicleNumber)] = true
+	return nil
+}
+
+// RevokeQuotaExemption makes later parks of the vehicle general again,
+// reporting whether it was exempt. A park in progress stays exempt.
+func (p *ParkingLot) RevokeQuotaExemption(vehicleNumber string) bool {
+	key := p.vehicleKey(vehicleNumber)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.quotaExempt[key] {
+		return false
+	}
+	delete(p.quotaExempt, key)
+	return true
+}
+
+// IsQuotaExempt reports whether the vehicle is exempt from quotas
+func (p *ParkingLot) IsQuotaExempt(vehicleNumber string) bool {
+	key := p.vehicleKey(vehicleNumber)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.quotaExempt[key]
+}
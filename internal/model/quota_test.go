@@ -0,0 +1,147 @@
+package model
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// newQuotaLot returns a lot of one row of automobile spots
+func newQuotaLot(t *testing.T, columns int) *ParkingLot {
+	t.Helper()
+
+	row := make([]SpotType, columns)
+	for i := range row {
+		row[i] = SpotTypeAutomobile
+	}
+	lot, err := CreateParkingLotWithFloors("Quota Lot", 0,
+		[]FloorSpec{{Rows: 1, Columns: columns, Layout: [][]SpotType{row}}})
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	return lot
+}
+
+func TestQuota(t *testing.T) {
+	lot := newQuotaLot(t, 6)
+
+	if err := lot.SetQuota(VehicleTypeAutomobile, 2); err != nil {
+		t.Fatalf("Failed to set quota: %v", err)
+	}
+
+	// Fill the quota, leaving spots free
+	for _, number := range []string{"KA-01-HH-0001", "KA-01-HH-0002"} {
+		if _, err := lot.Park(VehicleTypeAutomobile, number); err != nil {
+			t.Fatalf("Failed to park %s within the quota: %v", number, err)
+		}
+	}
+	_, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-0003")
+	var quotaErr *errors.QuotaExceededError
+	if !stderrors.As(err, &quotaErr) || quotaErr.Quota != 2 {
+		t.Fatalf("Expected the quota to refuse a third automobile, got %v", err)
+	}
+	if quotas := lot.GetQuotas(); fmt.Sprint(quotas) != "[{AUTOMOBILE 2 2}]" {
+		t.Errorf("Expected the quota used up, got %v", quotas)
+	}
+
+	// Reserved and exempt vehicles take the free spots regardless
+	if _, err := lot.ParkWithOptions(VehicleTypeAutomobile, "KA-02-RS-0001", ParkOptions{Reserved: true}); err != nil {
+		t.Errorf("Failed to park a reserved vehicle past the quota: %v", err)
+	}
+	if err := lot.ExemptFromQuotas("KA-02-RS-0002"); err != nil {
+		t.Fatalf("Failed to exempt vehicle: %v", err)
+	}
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-02-RS-0002"); err != nil {
+		t.Errorf("Failed to park an exempt vehicle past the quota: %v", err)
+	}
+	if err := lot.Unpark("0-0-2", "KA-02-RS-0001"); err != nil {
+		t.Fatalf("Failed to unpark the reserved vehicle: %v", err)
+	}
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-0003"); !stderrors.Is(err, errors.ErrQuotaExceeded) {
+		t.Errorf("Expected a reserved vehicle leaving to free no quota, got %v", err)
+	}
+
+	// Freeing a general park admits the next one
+	if err := lot.Unpark("0-0-0", "KA-01-HH-0001"); err != nil {
+		t.Fatalf("Failed to unpark: %v", err)
+	}
+	if spotID, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-0003"); err != nil || spotID != "0-0-0" {
+		t.Errorf("Expected admission to resume at 0-0-0, got %s (%v)", spotID, err)
+	}
+
+	// Other types, and a cleared quota, are not limited
+	if _, err := lot.Park(VehicleTypeMinibus, "KA-03-MB-0001"); err != nil {
+		t.Errorf("Failed to park a minibus with no quota: %v", err)
+	}
+	if err := lot.ClearQuota(VehicleTypeAutomobile); err != nil {
+		t.Fatalf("Failed to clear quota: %v", err)
+	}
+	if len(lot.GetQuotas()) != 0 {
+		t.Errorf("Expected no quotas once cleared, got %v", lot.GetQuotas())
+	}
+}
+
+func TestQuotaCountsParksMadeBeforeIt(t *testing.T) {
+	lot := newQuotaLot(t, 5)
+
+	for _, number := range []string{"KA-01-HH-0001", "KA-01-HH-0002"} {
+		if _, err := lot.Park(VehicleTypeAutomobile, number); err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+	}
+
+	// A quota below the vehicles parked admits none until enough leave
+	if err := lot.SetQuota(VehicleTypeAutomobile, 1); err != nil {
+		t.Fatalf("Failed to set quota: %v", err)
+	}
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-0003"); !stderrors.Is(err, errors.ErrQuotaExceeded) {
+		t.Errorf("Expected the quota to count earlier parks, got %v", err)
+	}
+
+	for i, number := range []string{"KA-01-HH-0001", "KA-01-HH-0002"} {
+		if err := lot.Unpark(fmt.Sprintf("0-0-%d", i), number); err != nil {
+			t.Fatalf("Failed to unpark %s: %v", number, err)
+		}
+	}
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-0003"); err != nil {
+		t.Errorf("Failed to park once below the quota: %v", err)
+	}
+
+	// Reset forgets the parks but keeps the quota
+	lot.Reset()
+	if quotas := lot.GetQuotas(); fmt.Sprint(quotas) != "[{AUTOMOBILE 1 0}]" {
+		t.Errorf("Expected the quota kept with nothing counted, got %v", quotas)
+	}
+
+	if err := lot.SetQuota(VehicleTypeAutomobile, -1); err == nil {
+		t.Error("Expected a negative quota to be rejected")
+	}
+	if err := lot.SetQuota("TRUCK", 1); err == nil {
+		t.Error("Expected a quota for an unknown vehicle type to be rejected")
+	}
+}
+
+func TestQuotaReleasedOnRollback(t *testing.T) {
+	lot := newQuotaLot(t, 2)
+	if err := lot.SetQuota(VehicleTypeAutomobile, 1); err != nil {
+		t.Fatalf("Failed to set quota: %v", err)
+	}
+
+	injected := errors.NewInvalidOperationError("park", "injected failure")
+	lot.faults = func(name string) error {
+		if name == "park.index" {
+			return injected
+		}
+		return nil
+	}
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-0001"); err != injected {
+		t.Errorf("Expected the injected error, got %v", err)
+	}
+	lot.faults = nil
+
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-0001"); err != nil {
+		t.Errorf("Expected the failed park to give its quota back, got %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// LotSnapshot is the layout of a lot and the vehicles parked in it at one
+// moment, in a form that can be saved and restored
+type LotSnapshot struct {
+	Name     string            `json:"name"`
+	TakenAt  time.Time         `json:"takenAt"`
+	Floors   []FloorSnapshot   `json:"floors"`
+	Vehicles []VehicleSnapshot `json:"vehicles"`
+}
+
+// FloorSnapshot is the spot type of every spot of a floor, row by row, and
+// the capacity of its racks
+type FloorSnapshot struct {
+	Floor int            `json:"floor"`
+	Spots [][]SpotType   `json:"spots"`
+	Racks []RackSnapshot `json:"racks,omitempty"`
+}
+
+// RackSnapshot is a bicycle spot holding more than one vehicle
+type RackSnapshot struct {
+	Row      int `json:"row"`
+	Column   int `json:"column"`
+	Capacity int `json:"capacity"`
+}
+
+// VehicleSnapshot is a parked vehicle and the spot it was parked at, the
+// leftmost one for an oversized vehicle
+type VehicleSnapshot struct {
+	VehicleNumber string      `json:"vehicleNumber"`
+	VehicleType   VehicleType `json:"vehicleType"`
+	Floor         int         `json:"floor"`
+	Row           int         `json:"row"`
+	Column        int         `json:"column"`
+	ParkedAt      time.Time   `json:"parkedAt"`
+
+	// Reserved is set for a park quotas did not count
+	Reserved bool `json:"reserved,omitempty"`
+}
+
+// Snapshot returns the layout and parked vehicles of the lot, taken in one
+// pass so the two agree
+func (p *ParkingLot) Snapshot() *LotSnapshot {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	snapshot := &LotSnapshot{
+		Name:    p.name,
+		TakenAt: p.clockLocked().Now(),
+		Floors:  make([]FloorSnapshot, len(p.floors)),
+	}
+	for i, floor := range p.floors {
+		snapshot.Floors[i] = floor.snapshot()
+	}
+
+	p.parkedVehicles.each(func(_ string, vehicle ParkedVehicle) bool {
+		floor, row, column, err := p.SpotIDFormatter().Parse(vehicle.SpotID)
+		if err == nil {
+			snapshot.Vehicles = append(snapshot.Vehicles, VehicleSnapshot{
+				VehicleNumber: vehicle.VehicleNumber,
+				VehicleType:   vehicle.VehicleType,
+				Floor:         floor,
+				Row:           row,
+				Column:        column,
+				ParkedAt:      vehicle.ParkedAt,
+				Reserved:      vehicle.Reserved,
+			})
+		}
+		return true
+	})
+	sortVehicleSnapshots(snapshot.Vehicles)
+
+	return snapshot
+}
+
+// snapshot returns the spot types of the floor and the capacity of its racks
+func (f *ParkingFloor) snapshot() FloorSnapshot {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var blank ParkingSpot
+	snapshot := FloorSnapshot{Floor: f.FloorNumber, Spots: make([][]SpotType, f.numRows)}
+	for r := 0; r < f.numRows; r++ {
+		snapshot.Spots[r] = make([]SpotType, f.numColumns)
+		for c := 0; c < f.numColumns; c++ {
+			spot := f.peekSpotLocked(r, c, &blank)
+			snapshot.Spots[r][c] = spot.GetType()
+			if capacity := spot.GetCapacity(); capacity > 1 {
+				snapshot.Racks = append(snapshot.Racks, RackSnapshot{Row: r, Column: c, Capacity: capacity})
+			}
+		}
+	}
+	return snapshot
+}
+
+// sortVehicleSnapshots orders vehicles by when they parked, ties broken by
+// vehicle number, so restoring them replays the parks in order
+func sortVehicleSnapshots(vehicles []VehicleSnapshot) {
+	sort.Slice(vehicles, func(i, j int) bool {
+		if !vehicles[i].ParkedAt.Equal(vehicles[j].ParkedAt) {
+			return vehicles[i].ParkedAt.Before(vehicles[j].ParkedAt)
+		}
+		return vehicles[i].VehicleNumber < vehicles[j].VehicleNumber
+	})
+}
+
+// Restore creates a lot with the snapshot's layout and parks its vehicles
+// again, each at the time it parked. Quotas and accessibility permits are
+// not checked, since the vehicles were admitted when they parked, but parks
+// that were not reserved count against quotas set later. The lot
+// keeps the clock the options give it, the system time by default.
+func (s *LotSnapshot) Restore(opts ...LotOption) (*ParkingLot, error) {
+	floors := make([]*ParkingFloor, len(s.Floors))
+	for i, floor := range s.Floors {
+		columns := 0
+		if len(floor.Spots) > 0 {
+			columns = len(floor.Spots[0])
+		}
+
+		var err error
+		floors[i], err = CreateParkingFloor(floor.Floor, len(floor.Spots), columns, floor.Spots)
+		if err != nil {
+			return nil, fmt.Errorf("invalid floor %d in snapshot: %v", floor.Floor, err)
+		}
+		for _, rack := range floor.Racks {
+			spot, err := floors[i].GetSpot(rack.Row, rack.Column)
+			if err == nil {
+				err = spot.SetCapacity(rack.Capacity)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid rack on floor %d in snapshot: %v", floor.Floor, err)
+			}
+		}
+	}
+
+	lot, err := NewParkingLot(s.Name, floors, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	vehicles := append([]VehicleSnapshot(nil), s.Vehicles...)
+	sortVehicleSnapshots(vehicles)
+	for _, vehicle := range vehicles {
+		// Restored vehicles are not counted as parks of the new lot
+		spotID := lot.FormatSpotID(vehicle.Floor, vehicle.Row, vehicle.Column)
+		_, err := lot.parkWithOptions(vehicle.VehicleType, vehicle.VehicleNumber,
+			ParkOptions{SpotID: spotID, Permit: true, Reserved: vehicle.Reserved, parkedAt: vehicle.ParkedAt})
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore vehicle %s: %v", vehicle.VehicleNumber, err)
+		}
+	}
+
+	return lot, nil
+}
+
+// WriteSnapshot writes a snapshot as JSON
+func WriteSnapshot(w io.Writer, snapshot *LotSnapshot) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snapshot)
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot
+func ReadSnapshot(r io.Reader) (*LotSnapshot, error) {
+	var snapshot LotSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %v", err)
+	}
+	return &snapshot, nil
+}
+
+// LoadSnapshot reads a snapshot from a file
+func LoadSnapshot(path string) (*LotSnapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %v", err)
+	}
+	defer file.Close()
+
+	return ReadSnapshot(file)
+}
+
+// SaveSnapshot writes a snapshot to a file
+func SaveSnapshot(path string, snapshot *LotSnapshot) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %v", err)
+	}
+
+	if err := WriteSnapshot(file, snapshot); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write snapshot: %v", err)
+	}
+	return file.Close()
+}
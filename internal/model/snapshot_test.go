@@ -0,0 +1,105 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	lot, err := CreateParkingLotWithFloors("Snapshot Lot", -1, []FloorSpec{
+		{Rows: 1, Columns: 3, Layout: [][]SpotType{{SpotTypeAutomobile, SpotTypeInactive, SpotTypeAutomobile}}},
+		{Rows: 1, Columns: 4, Layout: [][]SpotType{{SpotTypeAutomobile, SpotTypeAutomobile, SpotTypeAutomobile, SpotTypeBicycle}}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	parkedAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	lot.SetClock(NewFakeClock(parkedAt))
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-0001"); err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	if _, err := lot.ParkAt("0-0-0", VehicleTypeMinibus, "KA-02-MB-0002"); err != nil {
+		t.Fatalf("Failed to park the minibus: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, lot.Snapshot()); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+	snapshot, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+	restored, err := snapshot.Restore()
+	if err != nil {
+		t.Fatalf("Failed to restore snapshot: %v", err)
+	}
+
+	if diff := DiffLots(lot, restored); !diff.IsEmpty() {
+		t.Errorf("Expected the restored lot to match, got %+v", diff)
+	}
+	if restored.GetName() != "Snapshot Lot" {
+		t.Errorf("Expected the name restored, got %s", restored.GetName())
+	}
+	vehicles := restored.ListParkedVehicles(VehicleFilter{})
+	if len(vehicles) != 2 || !vehicles[0].ParkedAt.Equal(parkedAt) {
+		t.Errorf("Expected both vehicles restored at their park time, got %+v", vehicles)
+	}
+	checkVehicleIndexes(t, restored)
+
+	if _, err := ReadSnapshot(bytes.NewBufferString("{")); err == nil {
+		t.Error("Expected a truncated snapshot to be rejected")
+	}
+}
+
+func TestSnapshotRestoreKeepsTheClock(t *testing.T) {
+	parkedAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	lot, _ := CreateParkingLot("Clock Lot", 1, 2, 5, WithClock(NewFakeClock(parkedAt)))
+	spotID, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-0001")
+	if err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+
+	// The restored lot times the stay on its own clock, not the park time
+	clock := NewFakeClock(parkedAt.Add(2 * time.Hour))
+	restored, err := lot.Snapshot().Restore(WithClock(clock))
+	if err != nil {
+		t.Fatalf("Failed to restore snapshot: %v", err)
+	}
+	if restored.GetClock() != clock {
+		t.Errorf("Expected the restored lot to keep the clock it was given")
+	}
+	duration, err := restored.UnparkWithDuration(spotID, "KA-01-HH-0001")
+	if err != nil || duration != 2*time.Hour {
+		t.Errorf("Expected a stay of 2h after restoring, got %s (%v)", duration, err)
+	}
+}
+
+func TestSnapshotRestoreRacks(t *testing.T) {
+	lot, _ := CreateParkingLotWithFloors("Rack Lot", 0, []FloorSpec{
+		{Rows: 1, Columns: 2, Layout: [][]SpotType{{SpotTypeBicycle, SpotTypeAutomobile}}},
+	})
+	if err := lot.SetSpotCapacity("0-0-0", 3); err != nil {
+		t.Fatalf("Failed to make a rack: %v", err)
+	}
+	for _, number := range []string{"B-1", "B-2"} {
+		if _, err := lot.ParkAt("0-0-0", VehicleTypeBicycle, number); err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+	}
+
+	restored, err := lot.Snapshot().Restore()
+	if err != nil {
+		t.Fatalf("Failed to restore a rack holding two bicycles: %v", err)
+	}
+	if diff := DiffLots(lot, restored); !diff.IsEmpty() {
+		t.Errorf("Expected the restored lot to match, got %+v", diff)
+	}
+	spot, _ := restored.GetSpotByID("0-0-0")
+	if spot.GetCapacity() != 3 || len(spot.GetVehicleNumbers()) != 2 {
+		t.Errorf("Expected the rack of 3 with both bicycles, got capacity %d and %v",
+			spot.GetCapacity(), spot.GetVehicleNumbers())
+	}
+	checkVehicleIndexes(t, restored)
+}
@@ -0,0 +1,183 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// DistributionSpec controls how generated layouts split spots between types.
+// Weights are relative, so 10:20:70 and 1:2:7 give the same layout.
+type DistributionSpec struct {
+	BicycleWeight    int
+	MotorcycleWeight int
+	AutomobileWeight int
+	// ExtraWeights weighs registered spot types beyond the built-in ones,
+	// which generated layouts leave out unless weighed here
+	ExtraWeights map[SpotType]int
+	// InactiveFraction is the share of spots made inactive, e.g. 0.05 for 5%
+	InactiveFraction float64
+}
+
+// Validate checks that the weights and inactive fraction are usable
+func (d DistributionSpec) Validate() error {
+	weights := []struct {
+		field  string
+		weight int
+	}{
+		{"bicycleWeight", d.BicycleWeight},
+		{"motorcycleWeight", d.MotorcycleWeight},
+		{"automobileWeight", d.AutomobileWeight},
+	}
+
+	for _, w := range weights {
+		if w.weight < 1 {
+			return errors.NewValidationError(w.field,
+				fmt.Sprintf("%d", w.weight), "distribution weights must be positive")
+		}
+	}
+
+	extraTypes := make(map[SpotType]bool)
+	for _, spotType := range extraSpotTypes() {
+		extraTypes[spotType] = true
+	}
+	for spotType, weight := range d.ExtraWeights {
+		if !extraTypes[spotType] {
+			return errors.NewInvalidSpotTypeError(string(spotType))
+		}
+		if weight < 0 {
+			return errors.NewValidationError("extraWeights",
+				fmt.Sprintf("%s=%d", spotType, weight), "distribution weights cannot be negative")
+		}
+	}
+
+	if d.InactiveFraction < 0 || d.InactiveFraction >= 1 {
+		return errors.NewValidationError("inactiveFraction",
+			fmt.Sprintf("%g", d.InactiveFraction), "inactive fraction must be at least 0 and less than 1")
+	}
+
+	return nil
+}
+
+// ParseDistributionSpec parses a bicycle:motorcycle:automobile mix like
+// "10:20:70", optionally followed by weights of registered spot types in
+// registration order, e.g. "10:20:60:10" with a van spot type registered
+func ParseDistributionSpec(s string) (DistributionSpec, error) {
+	extraTypes := extraSpotTypes()
+	parts := strings.Split(s, ":")
+	if len(parts) < 3 || len(parts) > 3+len(extraTypes) {
+		return DistributionSpec{}, errors.NewValidationError("mix", s,
+			"expected format bicycle:motorcycle:automobile, e.g. 10:20:70")
+	}
+
+	weights := make([]int, len(parts))
+	for i, part := range parts {
+		weight, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return DistributionSpec{}, errors.NewValidationError("mix", s,
+				fmt.Sprintf("invalid weight: %s", part))
+		}
+		weights[i] = weight
+	}
+
+	spec := DistributionSpec{
+		BicycleWeight:    weights[0],
+		MotorcycleWeight: weights[1],
+		AutomobileWeight: weights[2],
+	}
+	for i, weight := range weights[3:] {
+		if spec.ExtraWeights == nil {
+			spec.ExtraWeights = make(map[SpotType]int)
+		}
+		spec.ExtraWeights[extraTypes[i]] = weight
+	}
+
+	if err := spec.Validate(); err != nil {
+		return DistributionSpec{}, err
+	}
+
+	return spec, nil
+}
+
+// apportion splits total spots between the weights using the largest remainder
+// method, so each share is within one spot of its exact value
+func apportion(total int, weights []int) []int {
+	sum := 0
+	for _, w := range weights {
+		sum += w
+	}
+
+	shares := make([]int, len(weights))
+	remainders := make([]float64, len(weights))
+	assigned := 0
+	for i, w := range weights {
+		exact := float64(total) * float64(w) / float64(sum)
+		shares[i] = int(math.Floor(exact))
+		remainders[i] = exact - float64(shares[i])
+		assigned += shares[i]
+	}
+
+	// Hand out the leftover spots to the largest remainders, earlier types first on ties
+	for ; assigned < total; assigned++ {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		shares[best]++
+		remainders[best] = -1
+	}
+
+	return shares
+}
+
+// generateDistributedFloorLayout builds one floor following a distribution spec.
+// Inactive spots are spread evenly over the floor and active types are laid out
+// column by column, bicycles first, like the default layout, then registered
+// types in registration order.
+func generateDistributedFloorLayout(rows, columns int, dist DistributionSpec) [][]SpotType {
+	types := []SpotType{SpotTypeBicycle, SpotTypeMotorcycle, SpotTypeAutomobile}
+	weights := []int{dist.BicycleWeight, dist.MotorcycleWeight, dist.AutomobileWeight}
+	for _, spotType := range extraSpotTypes() {
+		if weight := dist.ExtraWeights[spotType]; weight > 0 {
+			types = append(types, spotType)
+			weights = append(weights, weight)
+		}
+	}
+
+	cells := rows * columns
+	inactive := int(math.Round(float64(cells) * dist.InactiveFraction))
+	shares := apportion(cells-inactive, weights)
+
+	spotMap := make([][]SpotType, rows)
+	for r := range spotMap {
+		spotMap[r] = make([]SpotType, columns)
+		for c := range spotMap[r] {
+			i := r*columns + c
+			if (i+1)*inactive/cells > i*inactive/cells {
+				spotMap[r][c] = SpotTypeInactive
+			}
+		}
+	}
+
+	next := 0
+	for c := 0; c < columns; c++ {
+		for r := 0; r < rows; r++ {
+			if spotMap[r][c] == SpotTypeInactive {
+				continue
+			}
+
+			for shares[next] == 0 {
+				next++
+			}
+			spotMap[r][c] = types[next]
+			shares[next]--
+		}
+	}
+
+	return spotMap
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseDistributionSpec(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected DistributionSpec
+		hasError bool
+	}{
+		{"10:20:70", DistributionSpec{BicycleWeight: 10, MotorcycleWeight: 20, AutomobileWeight: 70}, false},
+		{"1: 1 :2", DistributionSpec{BicycleWeight: 1, MotorcycleWeight: 1, AutomobileWeight: 2}, false},
+		{"10:20", DistributionSpec{}, true},
+		{"10:20:70:5", DistributionSpec{}, true},
+		{"a:20:70", DistributionSpec{}, true},
+		{"0:20:80", DistributionSpec{}, true},
+		{"-1:20:80", DistributionSpec{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			spec, err := ParseDistributionSpec(tt.input)
+			if tt.hasError {
+				if err == nil {
+					t.Errorf("Expected error for %q, got nil", tt.input)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error for %q: %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(spec, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, spec)
+			}
+		})
+	}
+
+	if err := (DistributionSpec{BicycleWeight: 1, MotorcycleWeight: 1, AutomobileWeight: 1, InactiveFraction: 1}).Validate(); err == nil {
+		t.Errorf("Expected error for inactive fraction of 1")
+	}
+}
+
+func TestDistributedLayoutRatios(t *testing.T) {
+	dists := []DistributionSpec{
+		{BicycleWeight: 10, MotorcycleWeight: 20, AutomobileWeight: 70},
+		{BicycleWeight: 1, MotorcycleWeight: 1, AutomobileWeight: 1, InactiveFraction: 0.1},
+		{BicycleWeight: 5, MotorcycleWeight: 3, AutomobileWeight: 2, InactiveFraction: 0.05},
+	}
+	sizes := [][2]int{{10, 10}, {7, 13}, {1, 50}, {33, 3}, {100, 100}}
+
+	for _, dist := range dists {
+		for _, size := range sizes {
+			rows, columns := size[0], size[1]
+			layout, err := NewSpotLayoutWithDistribution(1, rows, columns, dist)
+			if err != nil {
+				t.Fatalf("Failed to create %dx%d layout: %v", rows, columns, err)
+			}
+
+			counts := layout.CountSpotsByType()
+			cells := float64(rows * columns)
+
+			if diff := math.Abs(float64(counts[SpotTypeInactive]) - cells*dist.InactiveFraction); diff > 1 {
+				t.Errorf("%dx%d %+v: %d inactive spots, expected about %.1f",
+					rows, columns, dist, counts[SpotTypeInactive], cells*dist.InactiveFraction)
+			}
+
+			active := float64(rows*columns - counts[SpotTypeInactive])
+			sum := float64(dist.BicycleWeight + dist.MotorcycleWeight + dist.AutomobileWeight)
+			for spotType, weight := range map[SpotType]int{
+				SpotTypeBicycle:    dist.BicycleWeight,
+				SpotTypeMotorcycle: dist.MotorcycleWeight,
+				SpotTypeAutomobile: dist.AutomobileWeight,
+			} {
+				expected := active * float64(weight) / sum
+				if diff := math.Abs(float64(counts[spotType]) - expected); diff > 1 {
+					t.Errorf("%dx%d %+v: %d %s spots, expected about %.1f",
+						rows, columns, dist, counts[spotType], spotType, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestDistributedLayoutHasAllTypes(t *testing.T) {
+	// A 1x2 floor cannot fit every type, so the second floor picks up the rest
+	layout, err := NewSpotLayoutWithDistribution(2, 1, 2,
+		DistributionSpec{BicycleWeight: 1, MotorcycleWeight: 1, AutomobileWeight: 98})
+	if err != nil {
+		t.Fatalf("Failed to create layout: %v", err)
+	}
+
+	counts := layout.CountSpotsByType()
+	if counts[SpotTypeBicycle] == 0 || counts[SpotTypeMotorcycle] == 0 || counts[SpotTypeAutomobile] == 0 {
+		t.Errorf("Expected at least one spot of each active type, got %v", counts)
+	}
+}
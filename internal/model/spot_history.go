@@ -0,0 +1,165 @@
+package model
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// DefaultSpotHistoryLimit is how many occupancies each spot keeps by default
+const DefaultSpotHistoryLimit = 100
+
+// SpotOccupancy is one vehicle's stay in a spot
+type SpotOccupancy struct {
+	VehicleNumber string
+	ParkedAt      time.Time
+	VacatedAt     *time.Time // nil if still parked
+}
+
+// spotHistoryIndex keeps the most recent occupancies of each spot,
+// evicting the oldest once a spot reaches the limit
+type spotHistoryIndex struct {
+	mu sync.Mutex
+
+	// limit is the per-spot cap, DefaultSpotHistoryLimit when zero
+	limit int
+
+	// Key: spot ID, Value: occupancies oldest first
+	entries map[string][]SpotOccupancy
+}
+
+// getLimit returns the per-spot cap; the caller must hold mu
+func (i *spotHistoryIndex) getLimit() int {
+	if i.limit == 0 {
+		return DefaultSpotHistoryLimit
+	}
+	return i.limit
+}
+
+// recordOccupy adds an occupancy for a vehicle parked at a spot
+func (i *spotHistoryIndex) recordOccupy(spotID, vehicleNumber string, at time.Time) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.entries == nil {
+		i.entries = make(map[string][]SpotOccupancy)
+	}
+
+	entries := append(i.entries[spotID], SpotOccupancy{VehicleNumber: vehicleNumber, ParkedAt: at})
+	if excess := len(entries) - i.getLimit(); excess > 0 {
+		entries = append(entries[:0], entries[excess:]...)
+	}
+	i.entries[spotID] = entries
+}
+
+// recordVacate closes the open occupancy of a vehicle leaving a spot
+func (i *spotHistoryIndex) recordVacate(spotID, vehicleNumber string, at time.Time) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	entries := i.entries[spotID]
+	for j := len(entries) - 1; j >= 0; j-- {
+		if entries[j].VehicleNumber == vehicleNumber && entries[j].VacatedAt == nil {
+			entries[j].VacatedAt = &at
+			return
+		}
+	}
+}
+
+// setLimit changes the per-spot cap, trimming spots already over it
+func (i *spotHistoryIndex) setLimit(limit int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.limit = limit
+	for spotID, entries := range i.entries {
+		if excess := len(entries) - limit; excess > 0 {
+			i.entries[spotID] = append(entries[:0], entries[excess:]...)
+		}
+	}
+}
+
+// get returns up to limit occupancies of a spot, most recent first
+func (i *spotHistoryIndex) get(spotID string, limit int) []SpotOccupancy {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	entries := i.entries[spotID]
+	if limit <= 0 || limit > len(entries) {
+		limit = len(entries)
+	}
+
+	result := make([]SpotOccupancy, 0, limit)
+	for j := len(entries) - 1; j >= len(entries)-limit; j-- {
+		result = append(result, entries[j])
+	}
+
+	return result
+}
+
+// clear removes every recorded occupancy
+func (i *spotHistoryIndex) clear() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.entries = nil
+}
+
+// removeVacated drops every occupancy that has ended, keeping the spots'
+// current ones
+func (i *spotHistoryIndex) removeVacated() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for spotID, entries := range i.entries {
+		kept := entries[:0]
+		for _, entry := range entries {
+			if entry.VacatedAt == nil {
+				kept = append(kept, entry)
+			}
+		}
+		if len(kept) == 0 {
+			delete(i.entries, spotID)
+		} else {
+			i.entries[spotID] = kept
+		}
+	}
+}
+
+// SetSpotHistoryLimit sets how many past occupancies each spot keeps.
+// Spots over the new limit drop their oldest occupancies.
+func (p *ParkingLot) SetSpotHistoryLimit(limit int) error {
+	if limit < 1 {
+		return errors.NewValidationError("limit", fmt.Sprintf("%d", limit),
+			"spot history limit must be at least 1")
+	}
+
+	p.spotHistory.setLimit(limit)
+	return nil
+}
+
+// GetSpotHistoryLimit returns how many past occupancies each spot keeps
+func (p *ParkingLot) GetSpotHistoryLimit() int {
+	p.spotHistory.mu.Lock()
+	defer p.spotHistory.mu.Unlock()
+
+	return p.spotHistory.getLimit()
+}
+
+// GetSpotHistory returns up to limit recorded occupancies of a spot, most
+// recent first. A limit of 0 returns everything still kept.
+func (p *ParkingLot) GetSpotHistory(spotID string, limit int) ([]SpotOccupancy, error) {
+	if limit < 0 {
+		return nil, errors.NewValidationError("limit", fmt.Sprintf("%d", limit),
+			"limit cannot be negative")
+	}
+
+	spot, err := p.GetSpotByID(spotID)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.spotHistory.get(spot.GetSpotID(), limit), nil
+}
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGetSpotHistory(t *testing.T) {
+	lot, err := CreateParkingLotWithFloors("Spot History Lot", 0, []FloorSpec{
+		{Rows: 1, Columns: 1, Layout: [][]SpotType{{SpotTypeAutomobile}}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	if lot.GetSpotHistoryLimit() != DefaultSpotHistoryLimit {
+		t.Errorf("Expected default limit %d, got %d", DefaultSpotHistoryLimit, lot.GetSpotHistoryLimit())
+	}
+
+	occupancies, err := lot.GetSpotHistory("0-0-0", 0)
+	if err != nil || len(occupancies) != 0 {
+		t.Errorf("Expected no occupancies for an unused spot, got %v, %v", occupancies, err)
+	}
+
+	for _, number := range []string{"KA-01-AA-0001", "KA-01-AA-0002"} {
+		if _, err := lot.Park(VehicleTypeAutomobile, number); err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+		if err := lot.Unpark("0-0-0", number); err != nil {
+			t.Fatalf("Failed to unpark %s: %v", number, err)
+		}
+	}
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-01-AA-0003"); err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+
+	occupancies, err = lot.GetSpotHistory("0-0-0", 0)
+	if err != nil {
+		t.Fatalf("Failed to get spot history: %v", err)
+	}
+	if len(occupancies) != 3 {
+		t.Fatalf("Expected 3 occupancies, got %d", len(occupancies))
+	}
+
+	// Most recent first, with the current occupant still open
+	expected := []string{"KA-01-AA-0003", "KA-01-AA-0002", "KA-01-AA-0001"}
+	for i, occupancy := range occupancies {
+		if occupancy.VehicleNumber != expected[i] {
+			t.Errorf("Position %d: expected %s, got %s", i, expected[i], occupancy.VehicleNumber)
+		}
+	}
+	if occupancies[0].VacatedAt != nil {
+		t.Errorf("Expected the current occupancy to be open, got vacated at %v", occupancies[0].VacatedAt)
+	}
+
+	// Times match the vehicle's own parking record
+	history, _ := lot.GetVehicleHistory("KA-01-AA-0001")
+	record := history.GetLastParkingRecord()
+	if !occupancies[2].ParkedAt.Equal(record.ParkedAt) || occupancies[2].VacatedAt == nil ||
+		!occupancies[2].VacatedAt.Equal(*record.UnparkedAt) {
+		t.Errorf("Expected occupancy times to match parking record %+v, got %+v", record, occupancies[2])
+	}
+
+	occupancies, _ = lot.GetSpotHistory("0-0-0", 2)
+	if len(occupancies) != 2 || occupancies[1].VehicleNumber != "KA-01-AA-0002" {
+		t.Errorf("Expected the 2 most recent occupancies, got %+v", occupancies)
+	}
+
+	if _, err := lot.GetSpotHistory("0-0-0", -1); err == nil {
+		t.Error("Expected error for negative limit")
+	}
+	if _, err := lot.GetSpotHistory("5-0-0", 0); err == nil {
+		t.Error("Expected error for a missing spot")
+	}
+
+	// Reset clears spot history
+	lot.Reset()
+	if occupancies, _ := lot.GetSpotHistory("0-0-0", 0); len(occupancies) != 0 {
+		t.Errorf("Expected no occupancies after reset, got %d", len(occupancies))
+	}
+}
+
+func TestSpotHistoryEviction(t *testing.T) {
+	lot, err := CreateParkingLotWithFloors("Eviction Lot", 0, []FloorSpec{
+		{Rows: 1, Columns: 1, Layout: [][]SpotType{{SpotTypeBicycle}}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	if err := lot.SetSpotHistoryLimit(0); err == nil {
+		t.Error("Expected error for a zero limit")
+	}
+	if err := lot.SetSpotHistoryLimit(3); err != nil {
+		t.Fatalf("Failed to set limit: %v", err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		number := fmt.Sprintf("KA-01-BB-%04d", i)
+		if _, err := lot.Park(VehicleTypeBicycle, number); err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+		if err := lot.Unpark("0-0-0", number); err != nil {
+			t.Fatalf("Failed to unpark %s: %v", number, err)
+		}
+	}
+
+	// Only the newest three are kept
+	occupancies, _ := lot.GetSpotHistory("0-0-0", 0)
+	expected := []string{"KA-01-BB-0005", "KA-01-BB-0004", "KA-01-BB-0003"}
+	if len(occupancies) != len(expected) {
+		t.Fatalf("Expected %d occupancies, got %d", len(expected), len(occupancies))
+	}
+	for i, occupancy := range occupancies {
+		if occupancy.VehicleNumber != expected[i] {
+			t.Errorf("Position %d: expected %s, got %s", i, expected[i], occupancy.VehicleNumber)
+		}
+	}
+
+	// Lowering the limit trims the oldest
+	if err := lot.SetSpotHistoryLimit(1); err != nil {
+		t.Fatalf("Failed to set limit: %v", err)
+	}
+	occupancies, _ = lot.GetSpotHistory("0-0-0", 0)
+	if len(occupancies) != 1 || occupancies[0].VehicleNumber != "KA-01-BB-0005" {
+		t.Errorf("Expected only KA-01-BB-0005 after lowering the limit, got %+v", occupancies)
+	}
+}
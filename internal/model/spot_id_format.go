@@ -0,0 +1,165 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// SpotIDFormatter writes and reads the IDs a lot gives its spots. Parse must
+// accept everything Format produces and return an InvalidSpotIDError for
+// anything it cannot read.
+type SpotIDFormatter interface {
+	Format(floor, row, column int) string
+	Parse(spotID string) (floor, row, column int, err error)
+}
+
+// DashSpotIDFormatter writes spot IDs as "floor-row-column", e.g. "2-3-15"
+// or "B1-3-15" for a basement; lots use it unless given another
+type DashSpotIDFormatter struct{}
+
+// Format builds a spot ID with FormatSpotID
+func (DashSpotIDFormatter) Format(floor, row, column int) string {
+	return FormatSpotID(floor, row, column)
+}
+
+// Parse reads a spot ID with ParseSpotID
+func (DashSpotIDFormatter) Parse(spotID string) (int, int, int, error) {
+	return ParseSpotID(spotID)
+}
+
+// SignageSpotIDFormatter writes spot IDs the way signs show them, e.g.
+// "F2-R03-C15", or "B1-R03-C15" for a basement. Rows and columns are padded
+// to two digits; Parse takes them padded or not, in either case.
+type SignageSpotIDFormatter struct{}
+
+// Format builds a spot ID in the format "F<floor>-R<row>-C<column>"
+func (SignageSpotIDFormatter) Format(floor, row, column int) string {
+	if floor < 0 {
+		return fmt.Sprintf("B%d-R%02d-C%02d", -floor, row, column)
+	}
+	return fmt.Sprintf("F%d-R%02d-C%02d", floor, row, column)
+}
+
+// Parse reads a spot ID in the format "F<floor>-R<row>-C<column>"
+func (SignageSpotIDFormatter) Parse(spotID string) (int, int, int, error) {
+	invalid := errors.NewInvalidSpotIDError(spotID, "invalid format, expected F<floor>-R<row>-C<column>")
+
+	upper := strings.ToUpper(spotID)
+	basement := strings.HasPrefix(upper, "B")
+	if !basement && !strings.HasPrefix(upper, "F") {
+		return 0, 0, 0, invalid
+	}
+
+	var numbers [3]int
+	rest := upper[1:]
+	for i, prefix := range []string{"", "-R", "-C"} {
+		if !strings.HasPrefix(rest, prefix) {
+			return 0, 0, 0, invalid
+		}
+		rest = rest[len(prefix):]
+
+		number, n, ok := scanSpotIDNumber(rest)
+		if !ok {
+			return 0, 0, 0, invalid
+		}
+		numbers[i] = number
+		rest = rest[n:]
+	}
+	if rest != "" {
+		return 0, 0, 0, invalid
+	}
+
+	floor := numbers[0]
+	if basement {
+		if floor < 1 {
+			return 0, 0, 0, errors.NewInvalidSpotIDError(spotID, "basement number must be at least 1")
+		}
+		floor = -floor
+	}
+
+	return floor, numbers[1], numbers[2], nil
+}
+
+// scanSpotIDNumber reads the run of ASCII digits at the start of s,
+// returning the number and how many bytes it took
+func scanSpotIDNumber(s string) (int, int, bool) {
+	digits := 0
+	for digits < len(s) && s[digits] >= '0' && s[digits] <= '9' {
+		digits++
+	}
+	if digits == 0 {
+		return 0, 0, false
+	}
+
+	number, err := strconv.Atoi(s[:digits])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return number, digits, true
+}
+
+// Spot ID formats selectable by name
+var spotIDFormatters = map[string]SpotIDFormatter{
+	"dash":    DashSpotIDFormatter{},
+	"signage": SignageSpotIDFormatter{},
+}
+
+// spotIDFormatterName returns the name a formatter is selected by, empty for
+// one that is not among them
+func spotIDFormatterName(formatter SpotIDFormatter) string {
+	for name, known := range spotIDFormatters {
+		if known == formatter {
+			return name
+		}
+	}
+	return ""
+}
+
+// SpotIDFormatterByName returns the spot ID format with the given name,
+// "dash" or "signage"
+func SpotIDFormatterByName(name string) (SpotIDFormatter, error) {
+	formatter, ok := spotIDFormatters[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(spotIDFormatters))
+		for name := range spotIDFormatters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, errors.NewValidationError("spotIDFormat", name,
+			fmt.Sprintf("unknown spot ID format, expected one of: %s", strings.Join(names, ", ")))
+	}
+
+	return formatter, nil
+}
+
+// WithSpotIDFormatter makes the lot write and read spot IDs with formatter
+func WithSpotIDFormatter(formatter SpotIDFormatter) LotOption {
+	return func(p *ParkingLot) {
+		p.spotIDs = formatter
+	}
+}
+
+// SpotIDFormatter returns the formatter the lot writes and reads spot IDs with.
+// Like the vehicle number rule it is fixed at creation, so no lock is needed.
+func (p *ParkingLot) SpotIDFormatter() SpotIDFormatter {
+	if p.spotIDs == nil {
+		return DashSpotIDFormatter{}
+	}
+	return p.spotIDs
+}
+
+// FormatSpotID builds the ID the lot gives the spot at floor, row and column
+func (p *ParkingLot) FormatSpotID(floor, row, column int) string {
+	return p.SpotIDFormatter().Format(floor, row, column)
+}
+
+// ParseSpotID reads a spot ID in the lot's format
+// Returns floor, row, column and error
+func (p *ParkingLot) ParseSpotID(spotID string) (int, int, int, error) {
+	return p.SpotIDFormatter().Parse(spotID)
+}
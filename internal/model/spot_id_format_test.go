@@ -0,0 +1,136 @@
+package model
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+func TestSignageSpotIDFormatter(t *testing.T) {
+	formatter := SignageSpotIDFormatter{}
+
+	tests := []struct {
+		floor, row, column int
+		expected           string
+	}{
+		{2, 3, 15, "F2-R03-C15"},
+		{0, 0, 0, "F0-R00-C00"},
+		{-1, 4, 5, "B1-R04-C05"},
+		{7, 120, 999, "F7-R120-C999"},
+	}
+	for _, tt := range tests {
+		spotID := formatter.Format(tt.floor, tt.row, tt.column)
+		if spotID != tt.expected {
+			t.Errorf("Format(%d, %d, %d) = %q, want %q", tt.floor, tt.row, tt.column, spotID, tt.expected)
+		}
+
+		floor, row, column, err := formatter.Parse(spotID)
+		if err != nil || floor != tt.floor || row != tt.row || column != tt.column {
+			t.Errorf("Parse(%q) = %d, %d, %d, %v; want %d, %d, %d", spotID, floor, row, column, err,
+				tt.floor, tt.row, tt.column)
+		}
+	}
+
+	// Unpadded and lowercase IDs are read too
+	if floor, row, column, err := formatter.Parse("f2-r3-c15"); err != nil || floor != 2 || row != 3 || column != 15 {
+		t.Errorf("Parse(%q) = %d, %d, %d, %v", "f2-r3-c15", floor, row, column, err)
+	}
+
+	for _, spotID := range []string{"", "2-3-15", "F2-3-15", "F2-R03", "F2-R03-C15x", "F2-C15-R03",
+		"F-R03-C15", "F+2-R03-C15", "F2-R 3-C15", "B0-R01-C01", "X2-R03-C15"} {
+		_, _, _, err := formatter.Parse(spotID)
+		if !stderrors.Is(err, errors.ErrInvalidSpotID) {
+			t.Errorf("Parse(%q): expected an invalid spot ID error, got %v", spotID, err)
+		}
+	}
+}
+
+func TestSpotIDFormatterByName(t *testing.T) {
+	if formatter, err := SpotIDFormatterByName("signage"); err != nil || formatter.Format(1, 2, 3) != "F1-R02-C03" {
+		t.Errorf("Expected the signage format, got %v, %v", formatter, err)
+	}
+	if formatter, err := SpotIDFormatterByName("Dash"); err != nil || formatter.Format(1, 2, 3) != "1-2-3" {
+		t.Errorf("Expected the dash format, got %v, %v", formatter, err)
+	}
+	if _, err := SpotIDFormatterByName("roman"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestLotWithSignageSpotIDs(t *testing.T) {
+	lot, err := CreateParkingLotFromFloor("Signage Lot", -1, 2, 3, 4, WithSpotIDFormatter(SignageSpotIDFormatter{}))
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	formatter := SignageSpotIDFormatter{}
+
+	// Park hands out IDs in the lot's format
+	detail, err := lot.ParkDetailed(VehicleTypeAutomobile, "KA-01-HH-1234")
+	if err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	if detail.SpotID != formatter.Format(detail.Floor, detail.Row, detail.Column) {
+		t.Fatalf("Expected a signage spot ID, got %q", detail.SpotID)
+	}
+
+	// Search, lookups and listings use the same IDs
+	info, err := lot.SearchVehicleDetailed("KA-01-HH-1234")
+	if err != nil || info.SpotID != detail.SpotID || info.Floor != detail.Floor {
+		t.Errorf("Expected search to find %s on floor %d, got %+v, %v", detail.SpotID, detail.Floor, info, err)
+	}
+	spot, err := lot.GetSpotByID(detail.SpotID)
+	if err != nil || spot.GetSpotID() != detail.SpotID {
+		t.Errorf("Expected GetSpotByID to return %s, got %v, %v", detail.SpotID, spot, err)
+	}
+	if occupied := lot.GetOccupiedSpots(); len(occupied) != 1 || occupied[0].SpotID != detail.SpotID {
+		t.Errorf("Expected the occupied spot %s, got %+v", detail.SpotID, occupied)
+	}
+	floor := detail.Floor
+	if parked := lot.ListParkedVehicles(VehicleFilter{Floor: &floor}); len(parked) != 1 {
+		t.Errorf("Expected one vehicle on floor %d, got %+v", floor, parked)
+	}
+	if _, err := lot.GetSpotByID(FormatSpotID(detail.Floor, detail.Row, detail.Column)); err == nil {
+		t.Error("Expected a dash spot ID to be rejected by a signage lot")
+	}
+
+	// A mismatched unpark names the spot in the lot's format
+	err = lot.Unpark(formatter.Format(detail.Floor, detail.Row+1, detail.Column), "KA-01-HH-1234")
+	var opErr *errors.InvalidOperationError
+	if !stderrors.As(err, &opErr) || !contains(opErr.Message, detail.SpotID) {
+		t.Errorf("Expected an error naming spot %s, got %v", detail.SpotID, err)
+	}
+
+	// Unpark takes any spelling of the ID the format reads
+	if err := lot.Unpark(lowerUnpadded(detail), "KA-01-HH-1234"); err != nil {
+		t.Errorf("Failed to unpark at %s: %v", lowerUnpadded(detail), err)
+	}
+	history, err := lot.GetSpotHistory(detail.SpotID, 0)
+	if err != nil || len(history) != 1 {
+		t.Errorf("Expected one occupancy of %s, got %+v, %v", detail.SpotID, history, err)
+	}
+
+	// Floors added or grown later follow the format too
+	if err := lot.AddFloor(2, 2, nil); err != nil {
+		t.Fatalf("Failed to add floor: %v", err)
+	}
+	if err := lot.ExpandFloor(-1, 4, 5, SpotTypeAutomobile); err != nil {
+		t.Fatalf("Failed to expand floor: %v", err)
+	}
+	for _, spot := range lot.ListSpots(SpotFilter{}) {
+		if _, _, _, err := formatter.Parse(spot.SpotID); err != nil {
+			t.Errorf("Expected every spot ID in the signage format, got %q", spot.SpotID)
+		}
+	}
+}
+
+// lowerUnpadded writes a parked spot's signage ID in lowercase without padding
+func lowerUnpadded(detail *ParkResultDetail) string {
+	prefix := "f"
+	floor := detail.Floor
+	if floor < 0 {
+		prefix, floor = "b", -floor
+	}
+	return fmt.Sprintf("%s%d-r%d-c%d", prefix, floor, detail.Row, detail.Column)
+}
@@ -0,0 +1,181 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// SpotInfo is a snapshot of a spot's state, for listings and exports
+type SpotInfo struct {
+	SpotID   string
+	Floor    int
+	Row      int
+	Column   int
+	Type     SpotType
+	Capacity int
+
+	// Human name of the spot, empty when it has none
+	Label string
+
+	// Attributes of the spot such as "ev", sorted
+	Tags []string
+
+	// Vehicles in the spot, empty when it is free
+	Vehicles []string
+
+	// OccupiedSince is when the earliest vehicle still in the spot parked,
+	// zero when the spot is free
+	OccupiedSince time.Time
+}
+
+// IsOccupied reports whether any vehicle is in the spot
+func (i SpotInfo) IsOccupied() bool {
+	return len(i.Vehicles) > 0
+}
+
+// IntRange is an inclusive range of rows or columns
+type IntRange struct {
+	Min int
+	Max int
+}
+
+// Contains reports whether n is within the range
+func (r IntRange) Contains(n int) bool {
+	return n >= r.Min && n <= r.Max
+}
+
+// SpotFilter selects spots. Zero fields match every spot.
+type SpotFilter struct {
+	// Floor keeps only spots on this floor when set
+	Floor *int
+
+	// Type keeps only spots of this type when set
+	Type SpotType
+
+	// Occupied keeps only occupied spots when true, or free ones when false
+	Occupied *bool
+
+	// Rows and Columns keep only spots within these ranges when set
+	Rows    *IntRange
+	Columns *IntRange
+
+	// Tags keeps only spots carrying every one of these tags, ignoring case
+	Tags []string
+}
+
+// eachSpot calls fn for the floor's spots matching the filter in a single
+// pass, in row-major order, and returns false once fn has. parkedAt gives
+// the parked time of each parked vehicle.
+func (f *ParkingFloor) eachSpot(filter SpotFilter, parkedAt map[string]time.Time, fn func(SpotInfo) bool) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	// Listing spots does not make them
+	var blank ParkingSpot
+	for r := 0; r < f.numRows; r++ {
+		if filter.Rows != nil && !filter.Rows.Contains(r) {
+			continue
+		}
+
+		for c := 0; c < f.numColumns; c++ {
+			if filter.Columns != nil && !filter.Columns.Contains(c) {
+				continue
+			}
+
+			spot := f.peekSpotLocked(r, c, &blank)
+			spotType := spot.GetType()
+			if filter.Type != "" && spotType != filter.Type {
+				continue
+			}
+			if len(filter.Tags) > 0 && !spot.HasTags(filter.Tags) {
+				continue
+			}
+
+			vehicles := spot.GetVehicleNumbers()
+			if filter.Occupied != nil && (len(vehicles) > 0) != *filter.Occupied {
+				continue
+			}
+
+			info := SpotInfo{
+				SpotID:   spot.GetSpotID(),
+				Floor:    f.FloorNumber,
+				Row:      r,
+				Column:   c,
+				Type:     spotType,
+				Capacity: spot.GetCapacity(),
+				Label:    spot.GetLabel(),
+				Tags:     spot.GetTags(),
+				Vehicles: vehicles,
+			}
+			for _, vehicleNumber := range vehicles {
+				// A vehicle still being parked has no parked time yet
+				at, found := parkedAt[vehicleNumber]
+				if !found {
+					continue
+				}
+				if info.OccupiedSince.IsZero() || at.Before(info.OccupiedSince) {
+					info.OccupiedSince = at
+				}
+			}
+
+			if !fn(info) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// ListSpots returns the spots matching the filter, floor by floor in row-major order
+func (p *ParkingLot) ListSpots(filter SpotFilter) []SpotInfo {
+	var spots []SpotInfo
+	p.ForEachSpot(filter, func(info SpotInfo) bool {
+		spots = append(spots, info)
+		return true
+	})
+	return spots
+}
+
+// ForEachSpot calls fn for the spots matching the filter, in the order of
+// ListSpots, until fn returns false, without building a list. The lot is
+// read locked throughout, so fn must not change it.
+func (p *ParkingLot) ForEachSpot(filter SpotFilter, fn func(SpotInfo) bool) {
+	if len(filter.Tags) > 0 {
+		tags := make([]string, len(filter.Tags))
+		for i, tag := range filter.Tags {
+			tags[i] = strings.ToLower(strings.TrimSpace(tag))
+		}
+		filter.Tags = tags
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	parkedAt := make(map[string]time.Time)
+	p.parkedVehicles.each(func(_ string, parked ParkedVehicle) bool {
+		parkedAt[parked.VehicleNumber] = parked.ParkedAt
+		return true
+	})
+
+	for _, floor := range p.floors {
+		if filter.Floor != nil && floor.FloorNumber != *filter.Floor {
+			continue
+		}
+
+		// A floor without vehicles has no occupied spots to look for
+		if filter.Occupied != nil && *filter.Occupied && floor.GetOccupiedSpotCount() == 0 {
+			continue
+		}
+		if !floor.eachSpot(filter, parkedAt, fn) {
+			return
+		}
+	}
+}
+
+// GetOccupiedSpots returns every spot holding a vehicle, floor by floor in
+// row-major order. Each entry is a copy, taken in one pass over the lot.
+func (p *ParkingLot) GetOccupiedSpots() []SpotInfo {
+	occupied := true
+	return p.ListSpots(SpotFilter{Occupied: &occupied})
+}
@@ -0,0 +1,267 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newSpotInfoTestLot creates a two-floor lot with two vehicles parked on floor 0
+func newSpotInfoTestLot(t *testing.T) *ParkingLot {
+	t.Helper()
+
+	A, B, M, X := SpotTypeAutomobile, SpotTypeBicycle, SpotTypeMotorcycle, SpotTypeInactive
+	specs := []FloorSpec{
+		{Rows: 2, Columns: 3, Layout: [][]SpotType{
+			{A, A, B},
+			{M, X, A},
+		}},
+		{Rows: 2, Columns: 2, Layout: [][]SpotType{
+			{A, B},
+			{A, M},
+		}},
+	}
+
+	lot, err := CreateParkingLotWithFloors("Spots Lot", 0, specs)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	if _, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-1234"); err != nil {
+		t.Fatalf("Failed to park automobile: %v", err)
+	}
+	if _, err := lot.Park(VehicleTypeBicycle, "KA-01-HH-9999"); err != nil {
+		t.Fatalf("Failed to park bicycle: %v", err)
+	}
+
+	return lot
+}
+
+// spotIDs returns the IDs of the given spots in order
+func spotIDs(spots []SpotInfo) []string {
+	ids := make([]string, len(spots))
+	for i, spot := range spots {
+		ids[i] = spot.SpotID
+	}
+	return ids
+}
+
+func TestListSpots(t *testing.T) {
+	lot := newSpotInfoTestLot(t)
+
+	floor1 := 1
+	occupied, free := true, false
+	tests := []struct {
+		name     string
+		filter   SpotFilter
+		expected []string
+	}{
+		{"no filter", SpotFilter{}, []string{
+			"0-0-0", "0-0-1", "0-0-2", "0-1-0", "0-1-1", "0-1-2", "1-0-0", "1-0-1", "1-1-0", "1-1-1",
+		}},
+		{"floor", SpotFilter{Floor: &floor1}, []string{"1-0-0", "1-0-1", "1-1-0", "1-1-1"}},
+		{"type", SpotFilter{Type: SpotTypeMotorcycle}, []string{"0-1-0", "1-1-1"}},
+		{"occupied", SpotFilter{Occupied: &occupied}, []string{"0-0-0", "0-0-2"}},
+		{"free", SpotFilter{Occupied: &free, Floor: &floor1}, []string{"1-0-0", "1-0-1", "1-1-0", "1-1-1"}},
+		{"rows", SpotFilter{Rows: &IntRange{Min: 1, Max: 1}}, []string{"0-1-0", "0-1-1", "0-1-2", "1-1-0", "1-1-1"}},
+		{"columns", SpotFilter{Columns: &IntRange{Min: 1, Max: 2}}, []string{
+			"0-0-1", "0-0-2", "0-1-1", "0-1-2", "1-0-1", "1-1-1",
+		}},
+		{"combined", SpotFilter{
+			Type:     SpotTypeAutomobile,
+			Occupied: &free,
+			Rows:     &IntRange{Min: 0, Max: 1},
+			Columns:  &IntRange{Min: 1, Max: 2},
+		}, []string{"0-0-1", "0-1-2"}},
+		{"no match", SpotFilter{Floor: &floor1, Occupied: &occupied}, nil},
+	}
+
+	for _, tc := range tests {
+		ids := spotIDs(lot.ListSpots(tc.filter))
+		if len(ids) != len(tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, ids)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tc.expected[i] {
+				t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, ids)
+				break
+			}
+		}
+	}
+}
+
+func TestForEachSpot(t *testing.T) {
+	lot := newSpotInfoTestLot(t)
+
+	var ids []string
+	lot.ForEachSpot(SpotFilter{Type: SpotTypeAutomobile}, func(spot SpotInfo) bool {
+		ids = append(ids, spot.SpotID)
+		return len(ids) < 4
+	})
+	if !reflect.DeepEqual(ids, []string{"0-0-0", "0-0-1", "0-1-2", "1-0-0"}) {
+		t.Errorf("Expected the first 4 automobile spots, got %v", ids)
+	}
+}
+
+func TestListSpotsDetails(t *testing.T) {
+	lot := newSpotInfoTestLot(t)
+
+	spots := lot.ListSpots(SpotFilter{Type: SpotTypeBicycle})
+	if len(spots) != 2 {
+		t.Fatalf("Expected 2 bicycle spots, got %d", len(spots))
+	}
+
+	// The occupied spot reports its vehicle and when it parked
+	parkedSpot := spots[0]
+	if parkedSpot.SpotID != "0-0-2" || parkedSpot.Floor != 0 || parkedSpot.Row != 0 || parkedSpot.Column != 2 {
+		t.Errorf("Expected spot 0-0-2, got %+v", parkedSpot)
+	}
+	if !parkedSpot.IsOccupied() || len(parkedSpot.Vehicles) != 1 || parkedSpot.Vehicles[0] != "KA-01-HH-9999" {
+		t.Errorf("Expected KA-01-HH-9999 in 0-0-2, got %v", parkedSpot.Vehicles)
+	}
+
+	history, _ := lot.GetVehicleHistory("KA-01-HH-9999")
+	if !parkedSpot.OccupiedSince.Equal(history.GetLastParkingRecord().ParkedAt) {
+		t.Errorf("Expected occupied since %v, got %v", history.GetLastParkingRecord().ParkedAt, parkedSpot.OccupiedSince)
+	}
+
+	// A free spot has no vehicles and no occupied time
+	freeSpot := spots[1]
+	if freeSpot.IsOccupied() || !freeSpot.OccupiedSince.IsZero() {
+		t.Errorf("Expected 1-0-1 to be free, got %+v", freeSpot)
+	}
+
+	// A rack reports the earliest vehicle still parked in it
+	if err := lot.SetSpotCapacity("1-0-1", 2); err != nil {
+		t.Fatalf("Failed to set rack capacity: %v", err)
+	}
+	if err := lot.Unpark("0-0-2", "KA-01-HH-9999"); err != nil {
+		t.Fatalf("Failed to unpark bicycle: %v", err)
+	}
+	if err := lot.SetSpotType("0-0-2", SpotTypeInactive); err != nil {
+		t.Fatalf("Failed to deactivate spot: %v", err)
+	}
+
+	clock := NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	lot.SetClock(clock)
+	for _, number := range []string{"KA-02-AA-0001", "KA-02-AA-0002"} {
+		if _, err := lot.Park(VehicleTypeBicycle, number); err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+		clock.Advance(time.Minute)
+	}
+
+	rack := lot.ListSpots(SpotFilter{Type: SpotTypeBicycle})[0]
+	first, _ := lot.GetVehicleHistory("KA-02-AA-0001")
+	if rack.SpotID != "1-0-1" || rack.Capacity != 2 || len(rack.Vehicles) != 2 {
+		t.Fatalf("Expected two bicycles in rack 1-0-1, got %+v", rack)
+	}
+	if !rack.OccupiedSince.Equal(first.GetLastParkingRecord().ParkedAt) {
+		t.Errorf("Expected rack occupied since %v, got %v", first.GetLastParkingRecord().ParkedAt, rack.OccupiedSince)
+	}
+}
+
+func TestGetOccupiedSpots(t *testing.T) {
+	lot, err := CreateParkingLot("Occupied Lot", 2, 3, 4)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	clock := NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	lot.SetClock(clock)
+
+	// Park a known set, one minute apart
+	expected := make(map[string]SpotInfo)
+	numbers := []string{"KA-01-AA-0003", "KA-01-AA-0001", "KA-01-AA-0002"}
+	for _, number := range numbers {
+		detail, err := lot.ParkDetailed(VehicleTypeAutomobile, number)
+		if err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+		spot, _ := lot.GetSpotByID(detail.SpotID)
+		expected[detail.SpotID] = SpotInfo{
+			SpotID:        detail.SpotID,
+			Floor:         detail.Floor,
+			Row:           detail.Row,
+			Column:        detail.Column,
+			Type:          spot.Type,
+			Capacity:      spot.GetCapacity(),
+			Vehicles:      []string{number},
+			OccupiedSince: detail.ParkedAt,
+		}
+		clock.Advance(time.Minute)
+	}
+
+	spots := lot.GetOccupiedSpots()
+	sort.Slice(spots, func(i, j int) bool { return spots[i].SpotID < spots[j].SpotID })
+	if len(spots) != len(expected) {
+		t.Fatalf("Expected %d occupied spots, got %d", len(expected), len(spots))
+	}
+	for _, spot := range spots {
+		if !reflect.DeepEqual(spot, expected[spot.SpotID]) {
+			t.Errorf("Expected %+v, got %+v", expected[spot.SpotID], spot)
+		}
+	}
+
+	// The vehicles are listed the other way round, by number
+	vehicles := lot.GetParkedVehicleDetails()
+	sort.Slice(vehicles, func(i, j int) bool { return vehicles[i].VehicleNumber < vehicles[j].VehicleNumber })
+	sort.Strings(numbers)
+	for i, vehicle := range vehicles {
+		info := expected[vehicle.SpotID]
+		if vehicle.VehicleNumber != numbers[i] || info.Vehicles[0] != vehicle.VehicleNumber ||
+			!vehicle.ParkedAt.Equal(info.OccupiedSince) {
+			t.Errorf("Vehicle %+v does not match its spot %+v", vehicle, info)
+		}
+	}
+
+	// Results are copies the lot does not share
+	spots[0].Vehicles[0] = "CHANGED"
+	if again := lot.GetOccupiedSpots(); again[0].Vehicles[0] == "CHANGED" {
+		t.Error("Expected GetOccupiedSpots to return copies")
+	}
+}
+
+func TestGetOccupiedSpotsConcurrent(t *testing.T) {
+	lot, err := CreateParkingLot("Occupied Lot", 2, 5, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				number := fmt.Sprintf("W%d-%03d", w, i)
+				spotID, err := lot.Park(VehicleTypeAutomobile, number)
+				if err == nil {
+					_ = lot.Unpark(spotID, number)
+				}
+			}
+		}(w)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			for _, spot := range lot.GetOccupiedSpots() {
+				if !spot.IsOccupied() {
+					t.Errorf("Free spot %s listed as occupied", spot.SpotID)
+				}
+			}
+			_ = lot.GetParkedVehicleDetails()
+		}
+	}()
+
+	wg.Wait()
+	<-done
+	if spots := lot.GetOccupiedSpots(); len(spots) != 0 {
+		t.Errorf("Expected no occupied spots after every vehicle left, got %d", len(spots))
+	}
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// MaxSpotLabelLength is the longest label a spot can be given, in characters
+const MaxSpotLabelLength = 64
+
+// GetLabel returns the spot's human name, empty when it has none
+func (s *ParkingSpot) GetLabel() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.label
+}
+
+// setLabel names the spot; the lot checks the label is unique first
+func (s *ParkingSpot) setLabel(label string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.label = label
+}
+
+// SetSpotLabel gives the spot with the given ID a human name such as
+// "near elevator A"; an empty label removes it. Labels are trimmed and
+// must be unique in the lot, ignoring case.
+func (p *ParkingLot) SetSpotLabel(spotID, label string) error {
+	label = strings.TrimSpace(label)
+	if utf8.RuneCountInString(label) > MaxSpotLabelLength {
+		return errors.NewValidationError("label", label,
+			fmt.Sprintf("label cannot be longer than %d characters", MaxSpotLabelLength))
+	}
+
+	// The write lock keeps two spots from taking the same label at once
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	spot, err := p.spotByIDLocked(spotID)
+	if err != nil {
+		return err
+	}
+
+	if label != "" {
+		if other := p.spotByLabelLocked(label); other != nil && other != spot {
+			return errors.NewValidationError("label", label,
+				fmt.Sprintf("label is already used by spot %s", other.GetSpotID()))
+		}
+	}
+
+	spot.setLabel(label)
+	return nil
+}
+
+// FindSpotByLabel returns the spot with the given label, ignoring case and
+// surrounding spaces
+func (p *ParkingLot) FindSpotByLabel(label string) (*ParkingSpot, error) {
+	label = strings.TrimSpace(label)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	spot := p.spotByLabelLocked(label)
+	if label == "" || spot == nil {
+		return nil, errors.NewInvalidSpotIDError("@"+label, "no spot has this label")
+	}
+
+	return spot, nil
+}
+
+// spotByLabelLocked returns the spot with the given trimmed label, nil when
+// none has it; p.mu must be held. Labels are rare, so the spots are scanned
+// rather than indexed, which keeps floor and layout changes free of upkeep.
+func (p *ParkingLot) spotByLabelLocked(label string) *ParkingSpot {
+	var found *ParkingSpot
+	for _, floor := range p.floors {
+		floor.forEachMadeSpot(func(spot *ParkingSpot) bool {
+			if spotLabel := spot.GetLabel(); spotLabel != "" && strings.EqualFold(spotLabel, label) {
+				found = spot
+				return false
+			}
+			return true
+		})
+		if found != nil {
+			break
+		}
+	}
+
+	return found
+}
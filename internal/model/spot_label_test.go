@@ -0,0 +1,115 @@
+package model
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+func TestSpotLabels(t *testing.T) {
+	lot, err := CreateParkingLot("Label Lot", 2, 2, 3)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	if err := lot.SetSpotLabel("0-0-0", "  near elevator A "); err != nil {
+		t.Fatalf("Failed to label spot: %v", err)
+	}
+	if err := lot.SetSpotLabel("1-1-2", "EV-7"); err != nil {
+		t.Fatalf("Failed to label spot: %v", err)
+	}
+
+	// Labels are trimmed and found regardless of case
+	spot, err := lot.FindSpotByLabel("NEAR ELEVATOR A")
+	if err != nil || spot.GetSpotID() != "0-0-0" || spot.GetLabel() != "near elevator A" {
+		t.Errorf("Expected spot 0-0-0 labelled %q, got %v, %v", "near elevator A", spot, err)
+	}
+	if spot, err := lot.FindSpotByLabel("ev-7"); err != nil || spot.GetSpotID() != "1-1-2" {
+		t.Errorf("Expected spot 1-1-2 for ev-7, got %v, %v", spot, err)
+	}
+
+	// Unknown labels read like unknown spot IDs
+	for _, label := range []string{"EV-8", "", "  "} {
+		if _, err := lot.FindSpotByLabel(label); !stderrors.Is(err, errors.ErrInvalidSpotID) {
+			t.Errorf("FindSpotByLabel(%q): expected an invalid spot ID error, got %v", label, err)
+		}
+	}
+
+	// A label belongs to one spot only, but a spot can be relabelled with its own
+	err = lot.SetSpotLabel("0-1-1", "ev-7")
+	if err == nil || !strings.Contains(err.Error(), "1-1-2") {
+		t.Errorf("Expected a duplicate label error naming 1-1-2, got %v", err)
+	}
+	if err := lot.SetSpotLabel("1-1-2", "ev-7"); err != nil {
+		t.Errorf("Expected a spot to take its own label again, got %v", err)
+	}
+
+	// Clearing a label frees it for another spot
+	if err := lot.SetSpotLabel("1-1-2", ""); err != nil {
+		t.Fatalf("Failed to clear label: %v", err)
+	}
+	if err := lot.SetSpotLabel("0-1-1", "EV-7"); err != nil {
+		t.Errorf("Expected a cleared label to be free, got %v", err)
+	}
+
+	if err := lot.SetSpotLabel("9-9-9", "Nowhere"); err == nil {
+		t.Error("Expected an error labelling a missing spot")
+	}
+	if err := lot.SetSpotLabel("0-0-1", strings.Repeat("x", MaxSpotLabelLength+1)); err == nil {
+		t.Error("Expected an error for an overlong label")
+	}
+
+	// Listings show the labels
+	labels := make(map[string]string)
+	for _, info := range lot.ListSpots(SpotFilter{}) {
+		if info.Label != "" {
+			labels[info.SpotID] = info.Label
+		}
+	}
+	if len(labels) != 2 || labels["0-0-0"] != "near elevator A" || labels["0-1-1"] != "EV-7" {
+		t.Errorf("Expected labels on 0-0-0 and 0-1-1, got %v", labels)
+	}
+}
+
+func TestSearchReportsSpotLabel(t *testing.T) {
+	lot, err := CreateParkingLot("Label Lot", 1, 4, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	detail, err := lot.ParkDetailed(VehicleTypeAutomobile, "KA-01-HH-1234")
+	if err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	if detail.Label != "" {
+		t.Errorf("Expected no label on an unlabelled spot, got %q", detail.Label)
+	}
+	if err := lot.SetSpotLabel(detail.SpotID, "EV-7"); err != nil {
+		t.Fatalf("Failed to label spot: %v", err)
+	}
+
+	info, err := lot.SearchVehicleDetailed("KA-01-HH-1234")
+	if err != nil || info.SpotLabel != "EV-7" {
+		t.Errorf("Expected the parked spot's label, got %+v, %v", info, err)
+	}
+
+	// The label still shows for the spot the vehicle last left
+	if err := lot.Unpark(detail.SpotID, "KA-01-HH-1234"); err != nil {
+		t.Fatalf("Failed to unpark: %v", err)
+	}
+	info, _ = lot.SearchVehicleDetailed("KA-01-HH-1234")
+	if info == nil || info.SpotLabel != "EV-7" {
+		t.Errorf("Expected the last spot's label, got %+v", info)
+	}
+
+	// Parking in a labelled spot reports the label
+	detail, err = lot.ParkDetailed(VehicleTypeAutomobile, "KA-01-HH-1234")
+	if err != nil {
+		t.Fatalf("Failed to park again: %v", err)
+	}
+	if detail.Label != "EV-7" {
+		t.Errorf("Expected park to report label EV-7, got %q", detail.Label)
+	}
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"math/rand"
 
 	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
 )
@@ -11,6 +12,19 @@ type SpotLayout struct {
 	SpotMap [][][]SpotType
 }
 
+// FloorSpec describes the dimensions and, optionally, the layout of one floor
+// If Layout is nil the floor is generated from Distribution, or from the
+// default spot distribution when Distribution is nil too. Pillars places
+// the floor's pillars: a distributed floor has none unless it is set, and a
+// default one the default pattern.
+type FloorSpec struct {
+	Rows         int
+	Columns      int
+	Layout       [][]SpotType
+	Distribution *DistributionSpec
+	Pillars      *PillarPattern
+}
+
 // NewSpotLayout creates a new spot layout with the given dimensions
 func NewSpotLayout(floors, rows, columns int) (*SpotLayout, error) {
 	// Validate dimensions
@@ -19,127 +33,262 @@ func NewSpotLayout(floors, rows, columns int) (*SpotLayout, error) {
 			fmt.Sprintf("%d", floors), "number of floors must be between 1 and 8")
 	}
 
-	if rows < 1 || rows > 1000 {
-		return nil, errors.NewValidationError("rows",
-			fmt.Sprintf("%d", rows), "number of rows must be between 1 and 1000")
+	specs := make([]FloorSpec, floors)
+	for f := range specs {
+		specs[f] = FloorSpec{Rows: rows, Columns: columns}
+	}
+
+	return NewSpotLayoutFromSpecs(specs)
+}
+
+// NewSpotLayoutWithDistribution creates a uniform spot layout whose spot types
+// follow the given distribution instead of the default one
+func NewSpotLayoutWithDistribution(floors, rows, columns int, dist DistributionSpec) (*SpotLayout, error) {
+	if floors < 1 || floors > 8 {
+		return nil, errors.NewValidationError("floors",
+			fmt.Sprintf("%d", floors), "number of floors must be between 1 and 8")
+	}
+
+	specs := make([]FloorSpec, floors)
+	for f := range specs {
+		specs[f] = FloorSpec{Rows: rows, Columns: columns, Distribution: &dist}
+	}
+
+	return NewSpotLayoutFromSpecs(specs)
+}
+
+// NewSeededSpotLayout creates a uniform spot layout like NewSpotLayout, or
+// like NewSpotLayoutWithDistribution when dist is set, then places each
+// floor's spots at random. The same seed and dimensions always give the
+// same layout, so one seen elsewhere can be reproduced from its seed.
+func NewSeededSpotLayout(floors, rows, columns int, dist *DistributionSpec, seed int64) (*SpotLayout, error) {
+	var layout *SpotLayout
+	var err error
+	if dist != nil {
+		layout, err = NewSpotLayoutWithDistribution(floors, rows, columns, *dist)
+	} else {
+		layout, err = NewSpotLayout(floors, rows, columns)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Shuffling keeps the counts, so every active type is still present
+	rng := rand.New(rand.NewSource(seed))
+	for _, spotMap := range layout.SpotMap {
+		shuffleFloorLayout(spotMap, rng)
 	}
 
-	if columns < 1 || columns > 1000 {
-		return nil, errors.NewValidationError("columns",
-			fmt.Sprintf("%d", columns), "number of columns must be between 1 and 1000")
+	return layout, nil
+}
+
+// shuffleFloorLayout moves the spots of a floor to random cells
+func shuffleFloorLayout(spotMap [][]SpotType, rng *rand.Rand) {
+	columns := len(spotMap[0])
+	rng.Shuffle(len(spotMap)*columns, func(i, j int) {
+		spotMap[i/columns][i%columns], spotMap[j/columns][j%columns] =
+			spotMap[j/columns][j%columns], spotMap[i/columns][i%columns]
+	})
+}
+
+// NewSpotLayoutFromSpecs creates a spot layout where every floor can have its
+// own dimensions. Floors without an explicit layout get the default distribution.
+func NewSpotLayoutFromSpecs(specs []FloorSpec) (*SpotLayout, error) {
+	if len(specs) < 1 || len(specs) > 8 {
+		return nil, errors.NewValidationError("floors",
+			fmt.Sprintf("%d", len(specs)), "number of floors must be between 1 and 8")
 	}
 
-	// Initialize layout with default distribution
 	layout := &SpotLayout{
-		SpotMap: make([][][]SpotType, floors),
+		SpotMap: make([][][]SpotType, len(specs)),
 	}
 
-	for f := 0; f < floors; f++ {
-		layout.SpotMap[f] = make([][]SpotType, rows)
+	generated := true
+	for f, spec := range specs {
+		if spec.Rows < 1 || spec.Rows > 1000 {
+			return nil, errors.NewValidationError("rows",
+				fmt.Sprintf("%d", spec.Rows), "number of rows must be between 1 and 1000")
+		}
 
-		for r := 0; r < rows; r++ {
-			layout.SpotMap[f][r] = make([]SpotType, columns)
+		if spec.Columns < 1 || spec.Columns > 1000 {
+			return nil, errors.NewValidationError("columns",
+				fmt.Sprintf("%d", spec.Columns), "number of columns must be between 1 and 1000")
+		}
 
-			for c := 0; c < columns; c++ {
-				// Special case for very small layouts to ensure at least one of each spot type
-				if rows == 1 && columns < 4 {
-					// For minimal layouts, ensure we have at least one of each active type
-					if columns == 1 {
-						// With just one column, pick a type based on the floor
-						if f%3 == 0 {
-							layout.SpotMap[f][r][c] = SpotTypeBicycle
-						} else if f%3 == 1 {
-							layout.SpotMap[f][r][c] = SpotTypeMotorcycle
-						} else {
-							layout.SpotMap[f][r][c] = SpotTypeAutomobile
-						}
-					} else if columns == 2 {
-						// With two columns, alternate between types
-						if c == 0 {
-							if f%2 == 0 {
-								layout.SpotMap[f][r][c] = SpotTypeBicycle
-							} else {
-								layout.SpotMap[f][r][c] = SpotTypeMotorcycle
-							}
+		if spec.Layout == nil && spec.Distribution != nil {
+			if err := spec.Distribution.Validate(); err != nil {
+				return nil, err
+			}
+			layout.SpotMap[f] = generateDistributedFloorLayout(spec.Rows, spec.Columns, *spec.Distribution)
+			if spec.Pillars != nil {
+				if err := spec.Pillars.Validate(); err != nil {
+					return nil, err
+				}
+				placePillars(layout.SpotMap[f], *spec.Pillars)
+			}
+			continue
+		}
+
+		if spec.Layout == nil {
+			pillars := DefaultPillarPattern()
+			if spec.Pillars != nil {
+				if err := spec.Pillars.Validate(); err != nil {
+					return nil, err
+				}
+				pillars = *spec.Pillars
+			}
+			layout.SpotMap[f] = generateFloorLayout(f, spec.Rows, spec.Columns, pillars)
+			continue
+		}
+
+		// Copy an explicit layout after checking it matches the dimensions
+		generated = false
+		if len(spec.Layout) != spec.Rows {
+			return nil, errors.NewValidationError("layout",
+				fmt.Sprintf("floor %d has %d rows", f, len(spec.Layout)),
+				fmt.Sprintf("expected %d rows", spec.Rows))
+		}
+
+		layout.SpotMap[f] = make([][]SpotType, spec.Rows)
+		for r, row := range spec.Layout {
+			if len(row) != spec.Columns {
+				return nil, errors.NewValidationError("layout",
+					fmt.Sprintf("floor %d row %d has %d columns", f, r, len(row)),
+					fmt.Sprintf("expected %d columns", spec.Columns))
+			}
+
+			for _, spotType := range row {
+				if _, err := ParseSpotType(string(spotType)); err != nil {
+					return nil, err
+				}
+			}
+
+			layout.SpotMap[f][r] = append([]SpotType(nil), row...)
+		}
+	}
+
+	// Only generated layouts are adjusted; explicit layouts are used as given
+	if generated {
+		layout.ensureAllSpotTypes()
+	}
+
+	return layout, nil
+}
+
+// generateFloorLayout builds the default spot distribution for one floor,
+// with pillars where the pattern places them
+func generateFloorLayout(f, rows, columns int, pillars PillarPattern) [][]SpotType {
+	spotMap := make([][]SpotType, rows)
+
+	for r := 0; r < rows; r++ {
+		spotMap[r] = make([]SpotType, columns)
+
+		for c := 0; c < columns; c++ {
+			// Special case for very small layouts to ensure at least one of each spot type
+			if rows == 1 && columns < 4 {
+				// For minimal layouts, ensure we have at least one of each active type
+				if columns == 1 {
+					// With just one column, pick a type based on the floor
+					if f%3 == 0 {
+						spotMap[r][c] = SpotTypeBicycle
+					} else if f%3 == 1 {
+						spotMap[r][c] = SpotTypeMotorcycle
+					} else {
+						spotMap[r][c] = SpotTypeAutomobile
+					}
+				} else if columns == 2 {
+					// With two columns, alternate between types
+					if c == 0 {
+						if f%2 == 0 {
+							spotMap[r][c] = SpotTypeBicycle
 						} else {
-							if f%2 == 0 {
-								layout.SpotMap[f][r][c] = SpotTypeAutomobile
-							} else {
-								layout.SpotMap[f][r][c] = SpotTypeBicycle
-							}
+							spotMap[r][c] = SpotTypeMotorcycle
 						}
-					} else { // columns == 3
-						// With three columns, one of each type
-						if c == 0 {
-							layout.SpotMap[f][r][c] = SpotTypeBicycle
-						} else if c == 1 {
-							layout.SpotMap[f][r][c] = SpotTypeMotorcycle
+					} else {
+						if f%2 == 0 {
+							spotMap[r][c] = SpotTypeAutomobile
 						} else {
-							layout.SpotMap[f][r][c] = SpotTypeAutomobile
+							spotMap[r][c] = SpotTypeBicycle
 						}
 					}
-				} else {
-					// Regular distribution for larger layouts
-					// Make some spots inactive (e.g., pillars, structural elements)
-					if (r%7 == 0 && c%7 == 0) || (r%7 == 0 && c%7 == 1) {
-						layout.SpotMap[f][r][c] = SpotTypeInactive
-						continue
-					}
-
-					// Distribute different vehicle types
-					switch {
-					case c < columns/4:
-						// First 25% of columns for bicycles
-						layout.SpotMap[f][r][c] = SpotTypeBicycle
-					case c < columns/2:
-						// Next 25% for motorcycles
-						layout.SpotMap[f][r][c] = SpotTypeMotorcycle
-					default:
-						// Remaining 50% for automobiles
-						layout.SpotMap[f][r][c] = SpotTypeAutomobile
+				} else { // columns == 3
+					// With three columns, one of each type
+					if c == 0 {
+						spotMap[r][c] = SpotTypeBicycle
+					} else if c == 1 {
+						spotMap[r][c] = SpotTypeMotorcycle
+					} else {
+						spotMap[r][c] = SpotTypeAutomobile
 					}
 				}
+			} else {
+				// Regular distribution for larger layouts
+				spotMap[r][c] = defaultSpotType(r, c, columns, pillars)
 			}
 		}
 	}
 
-	// Ensure we have at least one of each spot type in the entire layout
-	counts := layout.CountSpotsByType()
-	if counts[SpotTypeBicycle] == 0 || counts[SpotTypeMotorcycle] == 0 || counts[SpotTypeAutomobile] == 0 {
-		// Force at least one of each type
-		if floors > 0 && rows > 0 && columns >= 3 {
-			layout.SpotMap[0][0][0] = SpotTypeBicycle
-			layout.SpotMap[0][0][1] = SpotTypeMotorcycle
-			layout.SpotMap[0][0][2] = SpotTypeAutomobile
-		} else if floors > 0 && rows > 0 && columns == 2 {
-			layout.SpotMap[0][0][0] = SpotTypeBicycle
-			layout.SpotMap[0][0][1] = SpotTypeMotorcycle
-			// If we have another row or floor, add the automobile there
-			if rows > 1 {
-				layout.SpotMap[0][1][0] = SpotTypeAutomobile
+	return spotMap
+}
+
+// ensureAllSpotTypes makes sure the layout has at least one spot of each active type
+func (l *SpotLayout) ensureAllSpotTypes() {
+	counts := l.CountSpotsByType()
+	if counts[SpotTypeBicycle] > 0 && counts[SpotTypeMotorcycle] > 0 && counts[SpotTypeAutomobile] > 0 {
+		return
+	}
+
+	// Force at least one of each type, starting from the first floor
+	floors := len(l.SpotMap)
+	rows := len(l.SpotMap[0])
+	columns := len(l.SpotMap[0][0])
+
+	if columns >= 3 {
+		l.SpotMap[0][0][0] = SpotTypeBicycle
+		l.SpotMap[0][0][1] = SpotTypeMotorcycle
+		l.SpotMap[0][0][2] = SpotTypeAutomobile
+	} else if columns == 2 {
+		l.SpotMap[0][0][0] = SpotTypeBicycle
+		l.SpotMap[0][0][1] = SpotTypeMotorcycle
+		// If we have another row or floor, add the automobile there
+		if rows > 1 {
+			l.SpotMap[0][1][0] = SpotTypeAutomobile
+		} else if floors > 1 {
+			l.SpotMap[1][0][0] = SpotTypeAutomobile
+		}
+	} else {
+		// With only one column, we need multiple rows or floors
+		l.SpotMap[0][0][0] = SpotTypeBicycle
+		if rows > 1 {
+			l.SpotMap[0][1][0] = SpotTypeMotorcycle
+			if rows > 2 {
+				l.SpotMap[0][2][0] = SpotTypeAutomobile
 			} else if floors > 1 {
-				layout.SpotMap[1][0][0] = SpotTypeAutomobile
+				l.SpotMap[1][0][0] = SpotTypeAutomobile
 			}
-		} else if floors > 0 && rows > 0 && columns == 1 {
-			// With only one column, we need multiple rows or floors
-			layout.SpotMap[0][0][0] = SpotTypeBicycle
-			if rows > 1 {
-				layout.SpotMap[0][1][0] = SpotTypeMotorcycle
-				if rows > 2 {
-					layout.SpotMap[0][2][0] = SpotTypeAutomobile
-				} else if floors > 1 {
-					layout.SpotMap[1][0][0] = SpotTypeAutomobile
-				}
-			} else if floors > 1 {
-				layout.SpotMap[1][0][0] = SpotTypeMotorcycle
-				if floors > 2 {
-					layout.SpotMap[2][0][0] = SpotTypeAutomobile
-				}
+		} else if floors > 1 {
+			l.SpotMap[1][0][0] = SpotTypeMotorcycle
+			if floors > 2 {
+				l.SpotMap[2][0][0] = SpotTypeAutomobile
 			}
 		}
 	}
+}
+
+// GetFloorDimensions returns the number of rows and columns of a floor in the layout
+func (l *SpotLayout) GetFloorDimensions(floor int) (int, int, error) {
+	if floor < 0 || floor >= len(l.SpotMap) {
+		return 0, 0, errors.NewValidationError("floor",
+			fmt.Sprintf("%d", floor),
+			fmt.Sprintf("floor out of range [0-%d]", len(l.SpotMap)-1))
+	}
 
-	return layout, nil
+	rows := len(l.SpotMap[floor])
+	if rows == 0 {
+		return 0, 0, nil
+	}
+
+	return rows, len(l.SpotMap[floor][0]), nil
 }
 
 // GetSpotType returns the spot type at the given location
@@ -202,10 +351,9 @@ func (l *SpotLayout) CountSpotsByType() map[SpotType]int {
 	counts := make(map[SpotType]int)
 
 	// Initialize counts for all spot types
-	counts[SpotTypeBicycle] = 0
-	counts[SpotTypeMotorcycle] = 0
-	counts[SpotTypeAutomobile] = 0
-	counts[SpotTypeInactive] = 0
+	for _, spotType := range registeredSpotTypeList() {
+		counts[spotType] = 0
+	}
 
 	// Count spots
 	for f := range l.SpotMap {
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"bytes"
+	"fmt"
 	"testing"
 )
 
@@ -315,3 +317,89 @@ func TestMinimumLayoutWithAllActiveTypes(t *testing.T) {
 		t.Errorf("Expected 3 total spots, counted %d", totalCount)
 	}
 }
+
+func TestNewSpotLayoutFromSpecs(t *testing.T) {
+	layout, err := NewSpotLayoutFromSpecs([]FloorSpec{
+		{Rows: 10, Columns: 10},
+		{Rows: 8, Columns: 8},
+		{Rows: 2, Columns: 2, Layout: [][]SpotType{
+			{SpotTypeBicycle, SpotTypeInactive},
+			{SpotTypeAutomobile, SpotTypeAutomobile},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create ragged layout: %v", err)
+	}
+
+	expected := [][2]int{{10, 10}, {8, 8}, {2, 2}}
+	for f, dims := range expected {
+		rows, columns, err := layout.GetFloorDimensions(f)
+		if err != nil {
+			t.Fatalf("Failed to get dimensions of floor %d: %v", f, err)
+		}
+		if rows != dims[0] || columns != dims[1] {
+			t.Errorf("Floor %d: expected %dx%d, got %dx%d", f, dims[0], dims[1], rows, columns)
+		}
+	}
+
+	// Explicit layouts are used as given
+	if spotType, _ := layout.GetSpotType(2, 0, 1); spotType != SpotTypeInactive {
+		t.Errorf("Expected X-0 at 2-0-1, got %s", spotType)
+	}
+
+	if _, _, err := layout.GetFloorDimensions(3); err == nil {
+		t.Errorf("Expected error for floor out of range")
+	}
+
+	// An explicit layout must match its dimensions
+	_, err = NewSpotLayoutFromSpecs([]FloorSpec{
+		{Rows: 2, Columns: 2, Layout: [][]SpotType{{SpotTypeBicycle, SpotTypeBicycle}}},
+	})
+	if err == nil {
+		t.Errorf("Expected error for layout with missing rows")
+	}
+
+	_, err = NewSpotLayoutFromSpecs([]FloorSpec{
+		{Rows: 1, Columns: 2, Layout: [][]SpotType{{SpotTypeBicycle, "Z-9"}}},
+	})
+	if err == nil {
+		t.Errorf("Expected error for invalid spot type")
+	}
+}
+
+func TestNewSeededSpotLayout(t *testing.T) {
+	// writeLayout renders a seeded layout as a layout map
+	writeLayout := func(dist *DistributionSpec, seed int64) []byte {
+		t.Helper()
+		layout, err := NewSeededSpotLayout(3, 6, 9, dist, seed)
+		if err != nil {
+			t.Fatalf("Failed to create seeded layout: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := WriteLayoutMap(&buf, layout); err != nil {
+			t.Fatalf("Failed to write layout: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	dist := &DistributionSpec{BicycleWeight: 1, MotorcycleWeight: 1, AutomobileWeight: 2, InactiveFraction: 0.1}
+	for _, d := range []*DistributionSpec{nil, dist} {
+		if !bytes.Equal(writeLayout(d, 42), writeLayout(d, 42)) {
+			t.Errorf("Expected equal seeds to give identical layouts with distribution %v", d)
+		}
+		if bytes.Equal(writeLayout(d, 42), writeLayout(d, 43)) {
+			t.Errorf("Expected different seeds to give different layouts with distribution %v", d)
+		}
+	}
+
+	// Placement changes, the counts do not
+	fixed, _ := NewSpotLayoutWithDistribution(3, 6, 9, *dist)
+	seeded, _ := NewSeededSpotLayout(3, 6, 9, dist, 42)
+	if fmt.Sprint(seeded.CountSpotsByType()) != fmt.Sprint(fixed.CountSpotsByType()) {
+		t.Errorf("Expected seeding to keep the counts %v, got %v", fixed.CountSpotsByType(), seeded.CountSpotsByType())
+	}
+
+	if _, err := NewSeededSpotLayout(0, 6, 9, nil, 42); err == nil {
+		t.Error("Expected invalid dimensions to be rejected")
+	}
+}
@@ -0,0 +1,303 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// MaxSpotTagLength is the longest tag a spot can carry, in characters
+const MaxSpotTagLength = 32
+
+// Tags are short lowercase words such as "ev" or "covered"
+var spotTagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// NormalizeSpotTag lowercases and trims a spot tag, checking it is a word of
+// letters, digits, hyphens and underscores
+func NormalizeSpotTag(tag string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(tag))
+	if len(normalized) > MaxSpotTagLength {
+		return "", errors.NewValidationError("tag", tag,
+			fmt.Sprintf("tag cannot be longer than %d characters", MaxSpotTagLength))
+	}
+	if !spotTagPattern.MatchString(normalized) {
+		return "", errors.NewValidationError("tag", tag,
+			"tag must be letters, digits, hyphens and underscores, starting with a letter or digit")
+	}
+
+	return normalized, nil
+}
+
+// normalizeSpotTags normalizes a list of tags, dropping duplicates
+func normalizeSpotTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := NormalizeSpotTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !containsString(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+
+	return normalized, nil
+}
+
+// containsString reports whether list holds s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTags returns the spot's tags in alphabetical order, nil when it has none
+func (s *ParkingSpot) GetTags() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.tags) == 0 {
+		return nil
+	}
+	tags := make([]string, len(s.tags))
+	copy(tags, s.tags)
+	return tags
+}
+
+// HasTags reports whether the spot carries every one of the normalized tags
+func (s *ParkingSpot) HasTags(tags []string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, tag := range tags {
+		if !containsString(s.tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// addTag adds a normalized tag, keeping the tags sorted
+func (s *ParkingSpot) addTag(tag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if containsString(s.tags, tag) {
+		return
+	}
+	s.tags = append(s.tags, tag)
+	sort.Strings(s.tags)
+}
+
+// removeTag removes a normalized tag, reporting whether the spot carried it
+func (s *ParkingSpot) removeTag(tag string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.tags {
+		if existing == tag {
+			s.tags = append(s.tags[:i], s.tags[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// TagSpot marks the spot with the given ID with an attribute such as "ev"
+// or "covered". Tagging a spot with a tag it already carries does nothing.
+func (p *ParkingLot) TagSpot(spotID, tag string) error {
+	normalized, err := NormalizeSpotTag(tag)
+	if err != nil {
+		return err
+	}
+
+	spot, err := p.GetSpotByID(spotID)
+	if err != nil {
+		return err
+	}
+
+	spot.addTag(normalized)
+	return nil
+}
+
+// UntagSpot removes a tag from the spot with the given ID
+func (p *ParkingLot) UntagSpot(spotID, tag string) error {
+	normalized, err := NormalizeSpotTag(tag)
+	if err != nil {
+		return err
+	}
+
+	spot, err := p.GetSpotByID(spotID)
+	if err != nil {
+		return err
+	}
+
+	if !spot.removeTag(normalized) {
+		return errors.NewInvalidOperationError("untagSpot",
+			fmt.Sprintf("spot %s has no tag %s", spot.GetSpotID(), normalized))
+	}
+
+	// A spot that loses its charger forgets the charger's state
+	if normalized == EVChargerTag {
+		spot.setChargerState("")
+	}
+	return nil
+}
+
+// FirstAvailableSpotWithTags returns the first spot in row-by-row order that
+// can fit the vehicle type and carries every normalized tag, or nil
+func (f *ParkingFloor) FirstAvailableSpotWithTags(vehicleType VehicleType, tags []string) *ParkingSpot {
+	if spots := f.GetAvailableSpotsWithTags(vehicleType, tags, 1); len(spots) > 0 {
+		return spots[0]
+	}
+	return nil
+}
+
+// GetAvailableSpotsWithTags returns at most n spots that can fit the vehicle
+// type and carry every normalized tag, all of them when n is negative, in the
+// same row-by-row order as GetAvailableSpots
+func (f *ParkingFloor) GetAvailableSpotsWithTags(vehicleType VehicleType, tags []string, n int) []*ParkingSpot {
+	return f.availableSpotsWhere(vehicleType, n, func(spot *ParkingSpot) bool {
+		return spot.HasTags(tags)
+	})
+}
+
+// firstAvailableSpotWhere returns the first spot in row-by-row order that
+// can fit the vehicle type and that keep accepts, any such spot when keep is
+// nil, or nil
+func (f *ParkingFloor) firstAvailableSpotWhere(vehicleType VehicleType, keep func(*ParkingSpot) bool) *ParkingSpot {
+	// The first free spot usually qualifies, and needs no scan
+	spot := f.FirstAvailableSpot(vehicleType)
+	if spot == nil || keep == nil || keep(spot) {
+		return spot
+	}
+
+	if spots := f.availableSpotsWhere(vehicleType, 1, keep); len(spots) > 0 {
+		return spots[0]
+	}
+	return nil
+}
+
+// availableSpotsWhere returns at most n spots that can fit the vehicle type
+// and that keep accepts, all of them when n is negative, in row-by-row order
+func (f *ParkingFloor) availableSpotsWhere(vehicleType VehicleType, n int, keep func(*ParkingSpot) bool) []*ParkingSpot {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var spots []*ParkingSpot
+	if f.closed || !f.acceptsLocked(vehicleType) || n == 0 {
+		return spots
+	}
+
+	// Tagged spots are few, so the free spots are filtered rather than
+	// indexed, and made only once kept
+	var blank ParkingSpot
+	for _, position := range f.counters.freeSpots.find(vehicleType, -1) {
+		if !keep(f.peekSpotLocked(position[0], position[1], &blank)) {
+			continue
+		}
+
+		spots = append(spots, f.spotAtLocked(position[0], position[1]))
+		if n > 0 && len(spots) == n {
+			break
+		}
+	}
+
+	return spots
+}
+
+// AvailableSpotsWithTags finds at most n available spots for a vehicle type
+// that carry every tag, all of them when n is negative, in the order
+// ParkRequiring would pick them
+func (p *ParkingLot) AvailableSpotsWithTags(vehicleType VehicleType, tags []string, n int) ([]string, error) {
+	// Validate input
+	if err := validateSpotVehicleType(vehicleType); err != nil {
+		return nil, err
+	}
+
+	normalized, err := normalizeSpotTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var spotIDs []string
+	for _, floor := range p.floors {
+		remaining := -1
+		if n >= 0 {
+			remaining = n - len(spotIDs)
+			if remaining == 0 {
+				break
+			}
+		}
+
+		for _, spot := range floor.GetAvailableSpotsWithTags(vehicleType, normalized, remaining) {
+			spotIDs = append(spotIDs, spot.GetSpotID())
+		}
+	}
+
+	return spotIDs, nil
+}
+
+// TagCount is how many spots carry a tag, and how many of those can take
+// another vehicle
+type TagCount struct {
+	Tag       string
+	Spots     int
+	Available int
+}
+
+// TagCounts returns the counts of every tag in use, ordered by tag. Spots
+// that are inactive, full or on a closed floor are not available.
+func (p *ParkingLot) TagCounts() []TagCount {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	counts := make(map[string]*TagCount)
+	for _, floor := range p.floors {
+		closed := floor.IsClosed()
+		floor.forEachMadeSpot(func(spot *ParkingSpot) bool {
+			tags := spot.GetTags()
+			if len(tags) == 0 {
+				return true
+			}
+
+			available := !closed && spot.IsActive() && spot.GetAvailableCapacity() > 0
+			for _, tag := range tags {
+				count, found := counts[tag]
+				if !found {
+					count = &TagCount{Tag: tag}
+					counts[tag] = count
+				}
+				count.Spots++
+				if available {
+					count.Available++
+				}
+			}
+			return true
+		})
+	}
+
+	result := make([]TagCount, 0, len(counts))
+	for _, count := range counts {
+		result = append(result, *count)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Tag < result[j].Tag
+	})
+
+	return result
+}
@@ -0,0 +1,124 @@
+package model
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+func TestParkRequiringTag(t *testing.T) {
+	lot, err := CreateParkingLot("Tag Lot", 2, 4, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	// Tag three automobile spots, the last on the upper floor
+	var automobileSpots []string
+	for _, info := range lot.ListSpots(SpotFilter{Type: SpotTypeAutomobile}) {
+		automobileSpots = append(automobileSpots, info.SpotID)
+	}
+	if len(automobileSpots) < 4 {
+		t.Fatalf("Expected at least four automobile spots, got %v", automobileSpots)
+	}
+	evSpots := []string{automobileSpots[1], automobileSpots[2], automobileSpots[len(automobileSpots)-1]}
+	for _, spotID := range evSpots {
+		if err := lot.TagSpot(spotID, " EV "); err != nil {
+			t.Fatalf("Failed to tag %s: %v", spotID, err)
+		}
+	}
+
+	// Fill the first two tagged spots
+	for i, number := range []string{"KA-01-HH-0001", "KA-01-HH-0002"} {
+		detail, err := lot.ParkRequiring(VehicleTypeAutomobile, number, []string{"ev"})
+		if err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+		if detail.SpotID != evSpots[i] {
+			t.Errorf("Expected %s in %s, got %s", number, evSpots[i], detail.SpotID)
+		}
+	}
+
+	// The third tagged spot is chosen over the free untagged ones before it
+	detail, err := lot.ParkRequiring(VehicleTypeAutomobile, "KA-01-HH-0003", []string{"Ev"})
+	if err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+	if detail.SpotID != evSpots[2] {
+		t.Errorf("Expected the third EV spot %s, got %s", evSpots[2], detail.SpotID)
+	}
+
+	// With every tagged spot taken the park fails, naming the tag
+	_, err = lot.ParkRequiring(VehicleTypeAutomobile, "KA-01-HH-0004", []string{"ev"})
+	var noSpace *errors.NoSpaceError
+	if !stderrors.As(err, &noSpace) || len(noSpace.Tags) != 1 || !strings.Contains(err.Error(), "ev") {
+		t.Errorf("Expected a no space error naming ev, got %v", err)
+	}
+
+	// Untagged spots are still free for ordinary parks
+	if spotID, err := lot.Park(VehicleTypeAutomobile, "KA-01-HH-0004"); err != nil || spotID != automobileSpots[0] {
+		t.Errorf("Expected an ordinary park in %s, got %s, %v", automobileSpots[0], spotID, err)
+	}
+
+	if _, err := lot.ParkRequiring(VehicleTypeAutomobile, "KA-01-HH-0005", []string{"not a tag"}); err == nil {
+		t.Error("Expected an error requiring an invalid tag")
+	}
+}
+
+func TestSpotTags(t *testing.T) {
+	lot, err := CreateParkingLot("Tag Lot", 1, 2, 3)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	for _, tag := range []string{"covered", "ev", "EV"} {
+		if err := lot.TagSpot("0-0-0", tag); err != nil {
+			t.Fatalf("Failed to tag spot with %s: %v", tag, err)
+		}
+	}
+	if err := lot.TagSpot("0-1-2", "covered"); err != nil {
+		t.Fatalf("Failed to tag spot: %v", err)
+	}
+
+	spot, _ := lot.GetSpotByID("0-0-0")
+	if tags := spot.GetTags(); strings.Join(tags, ",") != "covered,ev" {
+		t.Errorf("Expected tags covered,ev, got %v", tags)
+	}
+
+	// Listings keep only spots carrying every tag
+	if spots := lot.ListSpots(SpotFilter{Tags: []string{"Covered"}}); len(spots) != 2 {
+		t.Errorf("Expected two covered spots, got %d", len(spots))
+	}
+	if spots := lot.ListSpots(SpotFilter{Tags: []string{"covered", "ev"}}); len(spots) != 1 || spots[0].SpotID != "0-0-0" {
+		t.Errorf("Expected only 0-0-0 to be covered and ev, got %+v", spots)
+	}
+
+	// Counts are per tag, with inactive spots not available
+	counts := lot.TagCounts()
+	if len(counts) != 2 || counts[0] != (TagCount{Tag: "covered", Spots: 2, Available: 2}) ||
+		counts[1] != (TagCount{Tag: "ev", Spots: 1, Available: 1}) {
+		t.Errorf("Unexpected tag counts %+v", counts)
+	}
+	if err := lot.SetSpotType("0-1-2", SpotTypeInactive); err != nil {
+		t.Fatalf("Failed to deactivate spot: %v", err)
+	}
+	if counts := lot.TagCounts(); counts[0].Available != 1 {
+		t.Errorf("Expected one available covered spot, got %+v", counts[0])
+	}
+
+	if err := lot.UntagSpot("0-0-0", "EV"); err != nil {
+		t.Fatalf("Failed to untag spot: %v", err)
+	}
+	if err := lot.UntagSpot("0-0-0", "ev"); err == nil {
+		t.Error("Expected an error removing a tag the spot lacks")
+	}
+	for _, tag := range []string{"", "-ev", "two words", strings.Repeat("x", MaxSpotTagLength+1)} {
+		if err := lot.TagSpot("0-0-1", tag); err == nil {
+			t.Errorf("Expected an error tagging with %q", tag)
+		}
+	}
+	if err := lot.TagSpot("9-9-9", "ev"); err == nil {
+		t.Error("Expected an error tagging a missing spot")
+	}
+}
@@ -34,33 +34,28 @@ func (s SpotType) CanParkVehicleType(vt VehicleType) bool {
 		return false
 	}
 
-	// Only allow exact matches between vehicle types and spot types
-	switch s {
-	case SpotTypeBicycle:
-		return vt == VehicleTypeBicycle
-	case SpotTypeMotorcycle:
-		return vt == VehicleTypeMotorcycle
-	case SpotTypeAutomobile:
-		return vt == VehicleTypeAutomobile
-	default:
+	// A vehicle parks only in the spot types registered for it; one spanning
+	// several spots parks in none on its own
+	info, ok := types.Load().vehicleType(vt)
+	if !ok || info.Spans != "" {
 		return false
 	}
+	for _, spotType := range info.SpotTypes {
+		if spotType == s {
+			return true
+		}
+	}
+	return false
 }
 
-// ParseSpotType converts a string to SpotType
+// ParseSpotType converts a registered spot code such as "A-1", in any case,
+// to SpotType
 func ParseSpotType(s string) (SpotType, error) {
-	switch strings.ToUpper(s) {
-	case string(SpotTypeBicycle):
-		return SpotTypeBicycle, nil
-	case string(SpotTypeMotorcycle):
-		return SpotTypeMotorcycle, nil
-	case string(SpotTypeAutomobile):
-		return SpotTypeAutomobile, nil
-	case string(SpotTypeInactive):
-		return SpotTypeInactive, nil
-	default:
-		return "", errors.NewInvalidSpotTypeError(s)
+	spotType := SpotType(strings.ToUpper(s))
+	if _, ok := types.Load().spotIndex[spotType]; ok {
+		return spotType, nil
 	}
+	return "", errors.NewInvalidSpotTypeError(s)
 }
 
 // String returns the string representation of SpotType
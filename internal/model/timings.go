@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// PhaseTiming is how long one phase of an operation took
+type PhaseTiming struct {
+	Phase    string
+	Duration time.Duration
+}
+
+// OperationTimings collects how long the phases of a park or unpark took,
+// by the wall clock rather than the lot's. Operations given nil record
+// nothing.
+type OperationTimings struct {
+	Phases []PhaseTiming
+
+	// last is when the phase being timed began
+	last time.Time
+}
+
+// begin starts timing the first phase
+func (t *OperationTimings) begin() {
+	if t != nil {
+		t.last = time.Now()
+	}
+}
+
+// mark ends the phase being timed, naming it, and starts the next
+func (t *OperationTimings) mark(phase string) {
+	if t == nil {
+		return
+	}
+	now := time.Now()
+	t.Phases = append(t.Phases, PhaseTiming{Phase: phase, Duration: now.Sub(t.last)})
+	t.last = now
+}
@@ -0,0 +1,48 @@
+package model
+
+import "testing"
+
+// phaseNames returns the names of the phases timed, in order
+func phaseNames(timings *OperationTimings) []string {
+	var names []string
+	for _, phase := range timings.Phases {
+		if phase.Duration < 0 {
+			return nil
+		}
+		names = append(names, phase.Phase)
+	}
+	return names
+}
+
+func TestOperationTimings(t *testing.T) {
+	lot, _ := CreateParkingLot("Timings Test Lot", 1, 2, 5)
+
+	parkTimings := &OperationTimings{}
+	detail, err := lot.ParkWithOptions(VehicleTypeAutomobile, "KA-01-HH-1234", ParkOptions{Timings: parkTimings})
+	if err != nil {
+		t.Fatalf("Failed to park vehicle: %v", err)
+	}
+	if names := phaseNames(parkTimings); len(names) != 2 || names[0] != "selection" || names[1] != "bookkeeping" {
+		t.Errorf("Expected selection and bookkeeping timed, got %+v", parkTimings.Phases)
+	}
+
+	unparkTimings := &OperationTimings{}
+	if _, err := lot.UnparkTimed(detail.SpotID, "KA-01-HH-1234", unparkTimings); err != nil {
+		t.Fatalf("Failed to unpark vehicle: %v", err)
+	}
+	if names := phaseNames(unparkTimings); len(names) != 2 || names[0] != "lookup" || names[1] != "bookkeeping" {
+		t.Errorf("Expected lookup and bookkeeping timed, got %+v", unparkTimings.Phases)
+	}
+
+	// A failed park times only what it got through, and nil times nothing
+	failed := &OperationTimings{}
+	if _, err := lot.ParkWithOptions(VehicleTypeAutomobile, "KA-01-HH-1234", ParkOptions{SpotID: "9-9-9", Timings: failed}); err == nil {
+		t.Fatal("Expected parking at a missing spot to fail")
+	}
+	if len(failed.Phases) != 0 {
+		t.Errorf("Expected no phases for a failed park, got %+v", failed.Phases)
+	}
+	if _, err := lot.ParkWithOptions(VehicleTypeAutomobile, "KA-01-HH-1234", ParkOptions{}); err != nil {
+		t.Errorf("Expected an untimed park to succeed, got %v", err)
+	}
+}
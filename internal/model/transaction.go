@@ -0,0 +1,54 @@
+package model
+
+import "fmt"
+
+// transaction undoes the completed steps of an operation unless the
+// operation commits. Deferring finish right after creating it rolls back on
+// every early return and on panics alike.
+type transaction struct {
+	// Names the operation in diagnostics
+	name   string
+	logger Logger
+
+	undo      []func() error
+	committed bool
+}
+
+// newTransaction starts a transaction that reports rollbacks to logger
+func newTransaction(name string, logger Logger) *transaction {
+	return &transaction{name: name, logger: logger}
+}
+
+// onRollback registers how to undo a step that has just completed
+func (t *transaction) onRollback(fn func() error) {
+	t.undo = append(t.undo, fn)
+}
+
+// commit keeps every completed step
+func (t *transaction) commit() {
+	t.committed = true
+}
+
+// finish undoes the completed steps in reverse order unless committed. A
+// step that cannot be undone is reported and the rest are still undone.
+func (t *transaction) finish() {
+	if t.committed || len(t.undo) == 0 {
+		return
+	}
+
+	t.logger.Debug(fmt.Sprintf("%s failed, undoing %d completed steps", t.name, len(t.undo)))
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		if err := t.undo[i](); err != nil {
+			t.logger.Warn(fmt.Sprintf("%s: failed to undo a step: %v", t.name, err))
+		}
+	}
+}
+
+// step reports an injected failure for the named step of an operation, so
+// tests can check that a failure at any point leaves the lot unchanged
+func (p *ParkingLot) step(name string) error {
+	if p.faults == nil {
+		return nil
+	}
+	return p.faults(name)
+}
@@ -0,0 +1,184 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+func TestTransactionRollback(t *testing.T) {
+	var undone []int
+	logger := &recordingLogger{}
+	tx := newTransaction("test", logger)
+	for i := 1; i <= 3; i++ {
+		i := i
+		tx.onRollback(func() error {
+			undone = append(undone, i)
+			if i == 2 {
+				return fmt.Errorf("step %d stuck", i)
+			}
+			return nil
+		})
+	}
+	tx.finish()
+
+	// A step that cannot be undone is reported and the others still run
+	if fmt.Sprint(undone) != "[3 2 1]" {
+		t.Errorf("Expected steps undone in reverse order, got %v", undone)
+	}
+	if len(logger.warnings) != 1 || !strings.Contains(logger.warnings[0], "step 2 stuck") {
+		t.Errorf("Expected a warning about the stuck step, got %q", logger.warnings)
+	}
+
+	undone = nil
+	tx = newTransaction("test", logger)
+	tx.onRollback(func() error {
+		undone = append(undone, 1)
+		return nil
+	})
+	tx.commit()
+	tx.finish()
+	if len(undone) != 0 {
+		t.Errorf("Expected a committed transaction to keep its steps, got %v undone", undone)
+	}
+}
+
+// lotState describes everything a failed park or unpark must leave as it was
+func lotState(t *testing.T, lot *ParkingLot, vehicleNumber string) string {
+	t.Helper()
+	if err := lot.validateCounters(); err != nil {
+		t.Fatal(err)
+	}
+	checkVehicleIndexes(t, lot)
+
+	records, err := lot.GetVehicleRecords(vehicleNumber)
+	_, known := lot.GetVehicleHistory(vehicleNumber)
+	return fmt.Sprintf("parked=%v occupied=%d known=%v records=%v/%v peak=%+v",
+		lot.GetAllParkedVehicles(), lot.GetOccupiedSpotCount(), known, records, err,
+		lot.GetPeakOccupancy().Overall)
+}
+
+func TestParkRollsBackAtEveryStep(t *testing.T) {
+	steps := []string{"park.occupy", "park.history", "park.record", "park.index"}
+
+	for _, returning := range []bool{true, false} {
+		for _, step := range steps {
+			for _, seen := range []bool{false, true} {
+				lot, err := CreateParkingLot("Rollback Lot", 1, 2, 5)
+				if err != nil {
+					t.Fatalf("Failed to create lot: %v", err)
+				}
+				lot.SetClock(NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)))
+
+				// A vehicle seen before already has a history to append to
+				if seen {
+					spotID, _ := lot.Park(VehicleTypeAutomobile, "KA-01")
+					_ = lot.Unpark(spotID, "KA-01")
+				}
+				if _, err := lot.Park(VehicleTypeAutomobile, "KA-02"); err != nil {
+					t.Fatalf("Failed to park: %v", err)
+				}
+				before := lotState(t, lot, "KA-01")
+
+				injected := errors.NewInvalidOperationError("park", "injected failure")
+				lot.faults = func(name string) error {
+					if name != step {
+						return nil
+					}
+					if !returning {
+						panic(injected)
+					}
+					return injected
+				}
+
+				func() {
+					defer func() {
+						if r := recover(); r != nil && r != injected {
+							panic(r)
+						}
+					}()
+					if _, err := lot.Park(VehicleTypeAutomobile, "KA-01"); err != injected {
+						t.Errorf("%s: expected the injected error, got %v", step, err)
+					}
+				}()
+
+				lot.faults = nil
+				if after := lotState(t, lot, "KA-01"); after != before {
+					t.Errorf("%s (seen %v, panic %v): lot changed\nbefore %s\nafter  %s",
+						step, seen, !returning, before, after)
+				}
+
+				// The lot still works once the failure is gone
+				if _, err := lot.Park(VehicleTypeAutomobile, "KA-01"); err != nil {
+					t.Errorf("%s: failed to park after the rollback: %v", step, err)
+				}
+			}
+		}
+	}
+}
+
+func TestUnparkRollsBackAtEveryStep(t *testing.T) {
+	steps := []string{"unpark.record", "unpark.index", "unpark.vacate"}
+
+	for _, step := range steps {
+		lot, err := CreateParkingLot("Rollback Lot", 1, 2, 5)
+		if err != nil {
+			t.Fatalf("Failed to create lot: %v", err)
+		}
+		lot.SetClock(NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)))
+
+		spotID, err := lot.Park(VehicleTypeAutomobile, "KA-01")
+		if err != nil {
+			t.Fatalf("Failed to park: %v", err)
+		}
+		before := lotState(t, lot, "KA-01")
+
+		injected := errors.NewInvalidOperationError("unpark", "injected failure")
+		lot.faults = func(name string) error {
+			if name == step {
+				return injected
+			}
+			return nil
+		}
+		if err := lot.Unpark(spotID, "KA-01"); err != injected {
+			t.Errorf("%s: expected the injected error, got %v", step, err)
+		}
+
+		lot.faults = nil
+		if after := lotState(t, lot, "KA-01"); after != before {
+			t.Errorf("%s: lot changed\nbefore %s\nafter  %s", step, before, after)
+		}
+
+		if err := lot.Unpark(spotID, "KA-01"); err != nil {
+			t.Errorf("%s: failed to unpark after the rollback: %v", step, err)
+		}
+	}
+}
+
+func TestUnparkFailsWhenTheRecordCannotComplete(t *testing.T) {
+	lot, err := CreateParkingLot("Rollback Lot", 1, 2, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	spotID, err := lot.Park(VehicleTypeAutomobile, "KA-01")
+	if err != nil {
+		t.Fatalf("Failed to park: %v", err)
+	}
+
+	// Complete the record behind the lot's back
+	history, _ := lot.GetVehicleHistory("KA-01")
+	_ = history.CompleteLastParkingRecord()
+
+	if err := lot.Unpark(spotID, "KA-01"); err == nil {
+		t.Fatal("Expected unpark to fail when the parking record cannot be completed")
+	}
+	if !lot.IsVehicleParked("KA-01") {
+		t.Error("Expected the vehicle to stay parked after the failed unpark")
+	}
+	if spot, _ := lot.GetSpotByID(spotID); !spot.HasVehicle("KA-01") {
+		t.Errorf("Expected %s to stay occupied by KA-01", spotID)
+	}
+}
@@ -0,0 +1,394 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// maxSpotTypes bounds the spot types the registry holds, so the per-type
+// counters of a floor stay fixed-size arrays
+const maxSpotTypes = 16
+
+// maxVehicleTypes bounds the vehicle types the registry holds
+const maxVehicleTypes = 16
+
+// spotCodePattern matches the code of a registrable spot type, a capital
+// letter for the type followed by "-1" for active
+var spotCodePattern = regexp.MustCompile(`^[A-Z]-1$`)
+
+// SpotTypeInfo describes a spot type known to the type registry
+type SpotTypeInfo struct {
+	// Type is the spot code, a capital letter and a status digit, e.g. "V-1"
+	Type SpotType
+
+	// Display is the name shown for the spot type, e.g. "Van Spot"
+	Display string
+}
+
+// Letter returns the letter standing for the spot type in layout and floor maps
+func (i SpotTypeInfo) Letter() byte {
+	return string(i.Type)[0]
+}
+
+// VehicleTypeInfo describes a vehicle type known to the type registry
+type VehicleTypeInfo struct {
+	// Type is the canonical upper-case name, e.g. "VAN"
+	Type VehicleType
+
+	// Display is the name shown for the vehicle type, e.g. "Van"
+	Display string
+
+	// Aliases are other names ParseVehicleType accepts, e.g. "CAR"
+	Aliases []string
+
+	// SpotTypes lists the spot types the vehicle parks in, preferred first
+	SpotTypes []SpotType
+
+	// Spans names the vehicle type whose spots an oversized vehicle takes,
+	// Spots of them side by side in a row; empty for a vehicle taking one
+	Spans VehicleType
+	Spots int
+}
+
+// typeRegistry is one immutable version of the registered types;
+// registering builds a new version rather than changing this one
+type typeRegistry struct {
+	spotTypes    []SpotTypeInfo
+	vehicleTypes []VehicleTypeInfo
+
+	// The codes of spotTypes, shared read-only with callers
+	spotCodes []SpotType
+
+	spotIndex    map[SpotType]int
+	vehicleIndex map[VehicleType]int
+	// Names and aliases of vehicle types, upper case
+	names map[string]VehicleType
+}
+
+var (
+	// types holds the current registry, read without a lock on every park
+	types atomic.Pointer[typeRegistry]
+
+	// registerMu makes registrations take turns
+	registerMu sync.Mutex
+)
+
+func init() {
+	r := &typeRegistry{
+		spotIndex:    make(map[SpotType]int),
+		vehicleIndex: make(map[VehicleType]int),
+		names:        make(map[string]VehicleType),
+	}
+
+	for _, info := range []SpotTypeInfo{
+		{Type: SpotTypeBicycle, Display: "Bicycle Spot"},
+		{Type: SpotTypeMotorcycle, Display: "Motorcycle Spot"},
+		{Type: SpotTypeAutomobile, Display: "Automobile Spot"},
+		{Type: SpotTypeInactive, Display: "Inactive Spot"},
+	} {
+		r.addSpotType(info)
+	}
+
+	for _, info := range []VehicleTypeInfo{
+		{Type: VehicleTypeBicycle, Display: "Bicycle", Aliases: []string{"B", "BIKE"},
+			SpotTypes: []SpotType{SpotTypeBicycle}},
+		{Type: VehicleTypeMotorcycle, Display: "Motorcycle", Aliases: []string{"M", "MOTORBIKE"},
+			SpotTypes: []SpotType{SpotTypeMotorcycle}},
+		{Type: VehicleTypeAutomobile, Display: "Automobile", Aliases: []string{"A", "CAR", "AUTO"},
+			SpotTypes: []SpotType{SpotTypeAutomobile}},
+		{Type: VehicleTypeMinibus, Display: "Minibus", Aliases: []string{"BUS"},
+			Spans: VehicleTypeAutomobile, Spots: 2},
+	} {
+		r.addVehicleType(info)
+	}
+
+	types.Store(r)
+}
+
+// clone returns a copy of the registry that can be added to
+func (r *typeRegistry) clone() *typeRegistry {
+	c := &typeRegistry{
+		spotTypes:    append([]SpotTypeInfo(nil), r.spotTypes...),
+		spotCodes:    append([]SpotType(nil), r.spotCodes...),
+		vehicleTypes: append([]VehicleTypeInfo(nil), r.vehicleTypes...),
+		spotIndex:    make(map[SpotType]int, len(r.spotIndex)),
+		vehicleIndex: make(map[VehicleType]int, len(r.vehicleIndex)),
+		names:        make(map[string]VehicleType, len(r.names)),
+	}
+	for k, v := range r.spotIndex {
+		c.spotIndex[k] = v
+	}
+	for k, v := range r.vehicleIndex {
+		c.vehicleIndex[k] = v
+	}
+	for k, v := range r.names {
+		c.names[k] = v
+	}
+	return c
+}
+
+// addSpotType appends a spot type that was already validated
+func (r *typeRegistry) addSpotType(info SpotTypeInfo) {
+	r.spotIndex[info.Type] = len(r.spotTypes)
+	r.spotTypes = append(r.spotTypes, info)
+	r.spotCodes = append(r.spotCodes, info.Type)
+}
+
+// addVehicleType appends a vehicle type that was already validated
+func (r *typeRegistry) addVehicleType(info VehicleTypeInfo) {
+	info.Aliases = append([]string(nil), info.Aliases...)
+	info.SpotTypes = append([]SpotType(nil), info.SpotTypes...)
+	if info.Spots < 1 {
+		info.Spots = 1
+	}
+
+	r.vehicleIndex[info.Type] = len(r.vehicleTypes)
+	r.vehicleTypes = append(r.vehicleTypes, info)
+	r.names[string(info.Type)] = info.Type
+	for _, alias := range info.Aliases {
+		r.names[alias] = info.Type
+	}
+}
+
+// vehicleType returns the registered description of a vehicle type
+func (r *typeRegistry) vehicleType(vt VehicleType) (VehicleTypeInfo, bool) {
+	i, ok := r.vehicleIndex[vt]
+	if !ok {
+		return VehicleTypeInfo{}, false
+	}
+	return r.vehicleTypes[i], true
+}
+
+// RegisterSpotType adds a spot type, such as "V-1" for vans, that layouts,
+// layout maps and vehicle types can then use. Types are meant to be
+// registered at startup, before lots are created.
+func RegisterSpotType(info SpotTypeInfo) error {
+	info.Type = SpotType(strings.ToUpper(strings.TrimSpace(string(info.Type))))
+	info.Display = strings.TrimSpace(info.Display)
+
+	if !spotCodePattern.MatchString(string(info.Type)) {
+		return errors.NewValidationError("spotType", string(info.Type),
+			"spot type code must be a capital letter followed by -1, e.g. V-1")
+	}
+	if info.Display == "" {
+		return errors.NewValidationError("display", info.Display, "spot type needs a display name")
+	}
+
+	// Floor maps show the spots of an oversized vehicle as o
+	if info.Letter() == 'O' {
+		return errors.NewValidationError("spotType", string(info.Type),
+			"letter O stands for oversized vehicles in floor maps")
+	}
+
+	registerMu.Lock()
+	defer registerMu.Unlock()
+
+	current := types.Load()
+	for _, existing := range current.spotTypes {
+		if existing.Letter() == info.Letter() {
+			return errors.NewValidationError("spotType", string(info.Type),
+				fmt.Sprintf("letter %c is already used by spot type %s", info.Letter(), existing.Type))
+		}
+	}
+	if len(current.spotTypes) >= maxSpotTypes {
+		return errors.NewValidationError("spotType", string(info.Type),
+			fmt.Sprintf("at most %d spot types can be registered", maxSpotTypes))
+	}
+
+	next := current.clone()
+	next.addSpotType(info)
+	types.Store(next)
+	return nil
+}
+
+// RegisterVehicleType adds a vehicle type that parks in registered spot
+// types, or that spans several spots of a registered vehicle type. Its name
+// and aliases are parsed case-insensitively and must not clash with another
+// type's. Types are meant to be registered at startup, before lots are created.
+func RegisterVehicleType(info VehicleTypeInfo) error {
+	info.Type = VehicleType(strings.ToUpper(strings.TrimSpace(string(info.Type))))
+	info.Display = strings.TrimSpace(info.Display)
+	aliases := make([]string, len(info.Aliases))
+	for i, alias := range info.Aliases {
+		aliases[i] = strings.ToUpper(strings.TrimSpace(alias))
+	}
+	info.Aliases = aliases
+
+	if info.Type == "" {
+		return errors.NewValidationError("vehicleType", string(info.Type), "vehicle type needs a name")
+	}
+	if info.Display == "" {
+		return errors.NewValidationError("display", info.Display, "vehicle type needs a display name")
+	}
+
+	registerMu.Lock()
+	defer registerMu.Unlock()
+
+	current := types.Load()
+	for _, name := range append([]string{string(info.Type)}, info.Aliases...) {
+		if name == "" {
+			return errors.NewValidationError("alias", name, "aliases cannot be empty")
+		}
+		if existing, taken := current.names[name]; taken {
+			return errors.NewValidationError("vehicleType", name,
+				fmt.Sprintf("name is already used by vehicle type %s", existing))
+		}
+	}
+
+	if info.Spans != "" {
+		base, ok := current.vehicleType(info.Spans)
+		if !ok || base.Spans != "" {
+			return errors.NewValidationError("spans", string(info.Spans),
+				"an oversized vehicle must span spots of a registered single-spot vehicle type")
+		}
+		if info.Spots < 2 || len(info.SpotTypes) > 0 {
+			return errors.NewValidationError("spots", fmt.Sprintf("%d", info.Spots),
+				"an oversized vehicle takes at least 2 spots, of the types of the vehicle it spans")
+		}
+	} else {
+		if len(info.SpotTypes) == 0 || info.Spots > 1 {
+			return errors.NewValidationError("spotTypes", string(info.Type),
+				"a vehicle type needs the spot types it parks in, and takes one of them")
+		}
+		for _, spotType := range info.SpotTypes {
+			if _, ok := current.spotIndex[spotType]; !ok || !spotType.IsActive() {
+				return errors.NewInvalidSpotTypeError(string(spotType))
+			}
+		}
+	}
+
+	if len(current.vehicleTypes) >= maxVehicleTypes {
+		return errors.NewValidationError("vehicleType", string(info.Type),
+			fmt.Sprintf("at most %d vehicle types can be registered", maxVehicleTypes))
+	}
+
+	next := current.clone()
+	next.addVehicleType(info)
+	types.Store(next)
+	return nil
+}
+
+// RegisteredSpotTypes returns every spot type in registration order, the
+// built-in B-1, M-1, A-1 and X-0 first
+func RegisteredSpotTypes() []SpotTypeInfo {
+	return append([]SpotTypeInfo(nil), types.Load().spotTypes...)
+}
+
+// RegisteredVehicleTypes returns every vehicle type in registration order,
+// the built-in types first
+func RegisteredVehicleTypes() []VehicleTypeInfo {
+	registered := types.Load().vehicleTypes
+	infos := make([]VehicleTypeInfo, len(registered))
+	for i, info := range registered {
+		info.Aliases = append([]string(nil), info.Aliases...)
+		info.SpotTypes = append([]SpotType(nil), info.SpotTypes...)
+		infos[i] = info
+	}
+	return infos
+}
+
+// LookupVehicleType returns the registered description of a vehicle type
+func LookupVehicleType(vt VehicleType) (VehicleTypeInfo, bool) {
+	info, ok := types.Load().vehicleType(vt)
+	if !ok {
+		return VehicleTypeInfo{}, false
+	}
+	info.Aliases = append([]string(nil), info.Aliases...)
+	info.SpotTypes = append([]SpotType(nil), info.SpotTypes...)
+	return info, true
+}
+
+// LookupSpotType returns the registered description of a spot type
+func LookupSpotType(st SpotType) (SpotTypeInfo, bool) {
+	r := types.Load()
+	i, ok := r.spotIndex[st]
+	if !ok {
+		return SpotTypeInfo{}, false
+	}
+	return r.spotTypes[i], true
+}
+
+// spotTypeIndex returns the position of a spot type among the registered
+// ones, which floor counters index by; unknown types count as inactive
+func spotTypeIndex(spotType SpotType) int {
+	r := types.Load()
+	if i, ok := r.spotIndex[spotType]; ok {
+		return i
+	}
+	return r.spotIndex[SpotTypeInactive]
+}
+
+// registeredSpotTypeList returns the registered spot types, each at the
+// position spotTypeIndex gives it, without allocating; callers must not
+// change the list
+func registeredSpotTypeList() []SpotType {
+	return types.Load().spotCodes
+}
+
+// spotTypeForLetter returns the registered spot type a layout map letter
+// stands for
+func spotTypeForLetter(letter rune) (SpotType, bool) {
+	for _, info := range types.Load().spotTypes {
+		if rune(info.Letter()) == letter {
+			return info.Type, true
+		}
+	}
+	return "", false
+}
+
+// spotTypeLetters lists the letters of the registered spot types, e.g. "B, M, A, X"
+func spotTypeLetters() string {
+	registered := types.Load().spotTypes
+	letters := make([]string, len(registered))
+	for i, info := range registered {
+		letters[i] = string(info.Letter())
+	}
+	return strings.Join(letters, ", ")
+}
+
+// extraSpotTypes returns the active spot types registered beyond the built-in ones
+func extraSpotTypes() []SpotType {
+	var extra []SpotType
+	for _, spotType := range registeredSpotTypeList() {
+		switch spotType {
+		case SpotTypeBicycle, SpotTypeMotorcycle, SpotTypeAutomobile, SpotTypeInactive:
+			continue
+		}
+		extra = append(extra, spotType)
+	}
+	return extra
+}
+
+// spotVehicleTypes returns the registered vehicle types that take a single
+// spot, which per-type counts are kept for
+func spotVehicleTypes() []VehicleType {
+	var list []VehicleType
+	for _, info := range types.Load().vehicleTypes {
+		if info.Spans == "" {
+			list = append(list, info.Type)
+		}
+	}
+	return list
+}
+
+// validateVehicleType rejects vehicle types that are not registered
+func validateVehicleType(vt VehicleType) error {
+	if _, ok := types.Load().vehicleType(vt); !ok {
+		return errors.NewInvalidVehicleTypeError(string(vt))
+	}
+	return nil
+}
+
+// validateSpotVehicleType rejects vehicle types that are not registered or
+// that span several spots, which lists of single free spots cannot serve
+func validateSpotVehicleType(vt VehicleType) error {
+	if info, ok := types.Load().vehicleType(vt); !ok || info.Spans != "" {
+		return errors.NewInvalidVehicleTypeError(string(vt))
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package model
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+const (
+	SpotTypeVan    SpotType    = "V-1"
+	VehicleTypeVan VehicleType = "VAN"
+)
+
+// registerVan registers a van spot type and a van vehicle type parking in
+// it, restoring the built-in types when the test ends
+func registerVan(t *testing.T) {
+	t.Helper()
+
+	saved := types.Load()
+	t.Cleanup(func() { types.Store(saved) })
+
+	if err := RegisterSpotType(SpotTypeInfo{Type: SpotTypeVan, Display: "Van Spot"}); err != nil {
+		t.Fatalf("Failed to register the van spot type: %v", err)
+	}
+	err := RegisterVehicleType(VehicleTypeInfo{Type: "van", Display: "Van", Aliases: []string{"lcv"},
+		SpotTypes: []SpotType{SpotTypeVan}})
+	if err != nil {
+		t.Fatalf("Failed to register the van vehicle type: %v", err)
+	}
+}
+
+func TestRegisterVehicleType(t *testing.T) {
+	registerVan(t)
+
+	for _, name := range []string{"van", "VAN", "Lcv"} {
+		if vt, err := ParseVehicleType(name); err != nil || vt != VehicleTypeVan {
+			t.Errorf("Expected %q to parse as VAN, got %q (%v)", name, vt, err)
+		}
+	}
+	if st, err := ParseSpotType("V-1"); err != nil || st != SpotTypeVan {
+		t.Errorf("Expected V-1 to parse, got %q (%v)", st, err)
+	}
+	if !IsValidSpotCode("V-1") {
+		t.Error("Expected V-1 to be a valid spot code")
+	}
+
+	if display := GetVehicleTypeDisplay(VehicleTypeVan); display != "Van" {
+		t.Errorf("Expected display Van, got %s", display)
+	}
+	if display := GetSpotTypeDisplay(SpotTypeVan); display != "Van Spot" {
+		t.Errorf("Expected display Van Spot, got %s", display)
+	}
+	if got := fmt.Sprint(VehicleTypeVan.GetCompatibleSpotTypes()); got != "[V-1]" {
+		t.Errorf("Expected van compatible with [V-1], got %s", got)
+	}
+	if !SpotTypeVan.CanParkVehicleType(VehicleTypeVan) || SpotTypeVan.CanParkVehicleType(VehicleTypeAutomobile) {
+		t.Error("Expected van spots to take vans only")
+	}
+
+	// The built-in types come first, in their usual order
+	registered := RegisteredVehicleTypes()
+	if len(registered) != 5 || registered[0].Type != VehicleTypeBicycle || registered[4].Type != VehicleTypeVan {
+		t.Errorf("Expected the van registered after the built-in types, got %v", registered)
+	}
+	if info, ok := LookupSpotType(SpotTypeVan); !ok || info.Letter() != 'V' {
+		t.Errorf("Expected the van spot type under letter V, got %+v", info)
+	}
+}
+
+func TestRegisterTypeValidation(t *testing.T) {
+	registerVan(t)
+
+	spotTests := []SpotTypeInfo{
+		{Type: "VV", Display: "Bad Code"},
+		{Type: "Q-0", Display: "Inactive Code"},
+		{Type: "Q-1", Display: " "},
+		{Type: "O-1", Display: "Oversized Letter"},
+		{Type: "B-1", Display: "Taken Letter"},
+		{Type: "V-1", Display: "Registered Twice"},
+	}
+	for _, info := range spotTests {
+		var validationErr *errors.ValidationError
+		if err := RegisterSpotType(info); !stderrors.As(err, &validationErr) {
+			t.Errorf("Expected %+v to be rejected, got %v", info, err)
+		}
+	}
+
+	vehicleTests := []VehicleTypeInfo{
+		{Type: "", Display: "No Name", SpotTypes: []SpotType{SpotTypeVan}},
+		{Type: "TRUCK", Display: "", SpotTypes: []SpotType{SpotTypeVan}},
+		{Type: "CAR", Display: "Taken Alias", SpotTypes: []SpotType{SpotTypeVan}},
+		{Type: "TRUCK", Display: "Truck", Aliases: []string{"LCV"}, SpotTypes: []SpotType{SpotTypeVan}},
+		{Type: "TRUCK", Display: "Truck"},
+		{Type: "TRUCK", Display: "Truck", SpotTypes: []SpotType{"T-1"}},
+		{Type: "TRUCK", Display: "Truck", SpotTypes: []SpotType{SpotTypeInactive}},
+		{Type: "TRUCK", Display: "Truck", Spans: VehicleTypeVan, Spots: 1},
+		{Type: "TRUCK", Display: "Truck", Spans: VehicleTypeMinibus, Spots: 2},
+	}
+	for _, info := range vehicleTests {
+		if err := RegisterVehicleType(info); err == nil {
+			t.Errorf("Expected %+v to be rejected", info)
+		}
+	}
+
+	// An oversized type can span the registered van spots
+	err := RegisterVehicleType(VehicleTypeInfo{Type: "TRUCK", Display: "Truck", Spans: VehicleTypeVan, Spots: 2})
+	if err != nil {
+		t.Errorf("Failed to register a truck spanning two van spots: %v", err)
+	}
+	if needed := VehicleType("TRUCK").SpotsNeeded(); needed != 2 {
+		t.Errorf("Expected a truck to need 2 spots, got %d", needed)
+	}
+}
+
+func TestParkRegisteredVehicleType(t *testing.T) {
+	registerVan(t)
+
+	A, M, V := SpotTypeAutomobile, SpotTypeMotorcycle, SpotTypeVan
+	specs := []FloorSpec{
+		{Rows: 2, Columns: 3, Layout: [][]SpotType{
+			{A, V, A},
+			{M, V, V},
+		}},
+	}
+	lot, err := CreateParkingLotWithFloors("Van Lot", 0, specs)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+	lot.SetClock(NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)))
+
+	stats := lot.Stats()
+	if stats.SpotCounts[SpotTypeVan] != 3 || stats.AvailableByType[VehicleTypeVan] != 3 {
+		t.Errorf("Expected 3 free van spots, got %d of %d", stats.AvailableByType[VehicleTypeVan],
+			stats.SpotCounts[SpotTypeVan])
+	}
+	if available, err := lot.AvailableSpot(VehicleTypeVan); err != nil || fmt.Sprint(available) != "[0-0-1 0-1-1 0-1-2]" {
+		t.Errorf("Expected van spots [0-0-1 0-1-1 0-1-2], got %v (%v)", available, err)
+	}
+
+	if spotID, err := lot.Park(VehicleTypeVan, "KA-01-VN-0001"); err != nil || spotID != "0-0-1" {
+		t.Errorf("Expected the van at 0-0-1, got %s (%v)", spotID, err)
+	}
+	if row := rowState(t, lot, 0, 0); row != "[A v A]" {
+		t.Errorf("Expected the parked van shown as v, got %s", row)
+	}
+	if _, err := lot.ParkAt("0-0-0", VehicleTypeVan, "KA-02-VN-0002"); err == nil {
+		t.Error("Expected a van to be refused an automobile spot")
+	}
+	if _, err := lot.ParkAt("0-1-1", VehicleTypeAutomobile, "KA-03-CA-0003"); err == nil {
+		t.Error("Expected an automobile to be refused a van spot")
+	}
+
+	for _, number := range []string{"KA-04-VN-0004", "KA-05-VN-0005"} {
+		if _, err := lot.Park(VehicleTypeVan, number); err != nil {
+			t.Fatalf("Failed to park van %s: %v", number, err)
+		}
+	}
+	if _, err := lot.Park(VehicleTypeVan, "KA-06-VN-0006"); !stderrors.Is(err, errors.ErrNoSpaceAvailable) {
+		t.Errorf("Expected no space once the van spots are full, got %v", err)
+	}
+	if peak := lot.GetPeakOccupancy().ByType[VehicleTypeVan].Count; peak != 3 {
+		t.Errorf("Expected a van peak of 3, got %d", peak)
+	}
+	checkVehicleIndexes(t, lot)
+}
+
+func TestParseDistributionSpecExtraWeights(t *testing.T) {
+	if _, err := ParseDistributionSpec("10:20:60:10"); err == nil {
+		t.Error("Expected a fourth weight to be rejected with no extra spot type")
+	}
+
+	registerVan(t)
+	spec, err := ParseDistributionSpec("10:20:60:10")
+	if err != nil {
+		t.Fatalf("Failed to parse the mix: %v", err)
+	}
+	if spec.ExtraWeights[SpotTypeVan] != 10 {
+		t.Errorf("Expected a van weight of 10, got %v", spec.ExtraWeights)
+	}
+}
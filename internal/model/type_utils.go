@@ -7,18 +7,26 @@ import (
 )
 
 // IsValidSpotCode checks if a string is a valid spot code in the format "TYPE-STATUS"
-// where TYPE is one of B, M, A, X and STATUS is 0 or 1
+// where TYPE is the letter of a registered spot type (B, M, A, X built in)
+// and STATUS is 0 or 1
 func IsValidSpotCode(code string) bool {
 	parts := strings.Split(code, "-")
-	if len(parts) != 2 {
+	if len(parts) != 2 || len(parts[0]) != 1 {
 		return false
 	}
 
-	typeChar := parts[0]
+	typeChar := parts[0][0]
 	status := parts[1]
 
 	// Check type
-	if typeChar != "B" && typeChar != "M" && typeChar != "A" && typeChar != "X" {
+	known := false
+	for _, info := range types.Load().spotTypes {
+		if info.Letter() == typeChar {
+			known = true
+			break
+		}
+	}
+	if !known {
 		return false
 	}
 
@@ -41,30 +49,16 @@ func SpotCodeToSpotType(code string) (SpotType, error) {
 
 // GetVehicleTypeDisplay returns a user-friendly display name for a vehicle type
 func GetVehicleTypeDisplay(vt VehicleType) string {
-	switch vt {
-	case VehicleTypeBicycle:
-		return "Bicycle"
-	case VehicleTypeMotorcycle:
-		return "Motorcycle"
-	case VehicleTypeAutomobile:
-		return "Automobile"
-	default:
-		return "Unknown"
+	if info, ok := types.Load().vehicleType(vt); ok {
+		return info.Display
 	}
+	return "Unknown"
 }
 
 // GetSpotTypeDisplay returns a user-friendly display name for a spot type
 func GetSpotTypeDisplay(st SpotType) string {
-	switch st {
-	case SpotTypeBicycle:
-		return "Bicycle Spot"
-	case SpotTypeMotorcycle:
-		return "Motorcycle Spot"
-	case SpotTypeAutomobile:
-		return "Automobile Spot"
-	case SpotTypeInactive:
-		return "Inactive Spot"
-	default:
-		return "Unknown Spot"
+	if info, ok := LookupSpotType(st); ok {
+		return info.Display
 	}
+	return "Unknown Spot"
 }
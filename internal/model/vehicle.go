@@ -1,15 +1,18 @@
 package model
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
 )
 
 // Vehicle represents a vehicle that can be parked in the parking lot
 type Vehicle struct {
-	// Type of the vehicle (bicycle, motorcycle, automobile)
+	// Type of the vehicle (bicycle, motorcycle, automobile, minibus)
 	Type VehicleType
 
 	// Registration number of the vehicle (must be unique)
@@ -20,13 +23,14 @@ type Vehicle struct {
 // This is a simple pattern that accepts alphanumeric characters with possible hyphens and spaces
 var vehicleNumberPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9\s-]*$`)
 
+// Runs of whitespace collapsed to one space by NormalizeVehicleNumber
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
 // NewVehicle creates a new vehicle with the given type and number
 func NewVehicle(vehicleType VehicleType, number string) (*Vehicle, error) {
 	// Validate vehicle type
-	if vehicleType != VehicleTypeBicycle &&
-		vehicleType != VehicleTypeMotorcycle &&
-		vehicleType != VehicleTypeAutomobile {
-		return nil, errors.NewInvalidVehicleTypeError(string(vehicleType))
+	if err := validateVehicleType(vehicleType); err != nil {
+		return nil, err
 	}
 
 	// Validate vehicle number
@@ -40,30 +44,157 @@ func NewVehicle(vehicleType VehicleType, number string) (*Vehicle, error) {
 	}, nil
 }
 
-// ValidateVehicleNumber checks if the vehicle number is valid
+// ValidateVehicleNumber checks if the vehicle number is valid under the
+// default rule
 func ValidateVehicleNumber(number string) error {
+	return defaultVehicleNumberValidator.Validate(number)
+}
+
+// VehicleNumberRule describes which vehicle numbers a lot accepts. The zero
+// value is the default rule: ValidateVehicleNumber's pattern, any length and
+// spaces allowed.
+type VehicleNumberRule struct {
+	// Regular expression the whole number must match, the default pattern when empty
+	Pattern string
+
+	// Length limits in characters after trimming, unlimited when zero
+	MinLength int
+	MaxLength int
+
+	// Rejects numbers with spaces inside them
+	NoSpaces bool
+}
+
+// VehicleNumberValidator checks vehicle numbers against a VehicleNumberRule
+type VehicleNumberValidator struct {
+	rule    VehicleNumberRule
+	pattern *regexp.Regexp
+}
+
+// Validator for the zero VehicleNumberRule
+var defaultVehicleNumberValidator = &VehicleNumberValidator{pattern: vehicleNumberPattern}
+
+// NewVehicleNumberValidator compiles a rule into a validator
+func NewVehicleNumberValidator(rule VehicleNumberRule) (*VehicleNumberValidator, error) {
+	if rule.MinLength < 0 || rule.MaxLength < 0 {
+		return nil, errors.NewValidationError("length", fmt.Sprintf("%d:%d", rule.MinLength, rule.MaxLength),
+			"length limits cannot be negative")
+	}
+	if rule.MaxLength > 0 && rule.MinLength > rule.MaxLength {
+		return nil, errors.NewValidationError("length", fmt.Sprintf("%d:%d", rule.MinLength, rule.MaxLength),
+			"minimum length cannot exceed maximum length")
+	}
+
+	pattern := vehicleNumberPattern
+	if rule.Pattern != "" {
+		// Anchor the pattern so it has to match the whole number
+		var err error
+		pattern, err = regexp.Compile(`^(?:` + rule.Pattern + `)$`)
+		if err != nil {
+			return nil, errors.NewValidationError("pattern", rule.Pattern,
+				fmt.Sprintf("invalid regular expression: %v", err))
+		}
+	}
+
+	return &VehicleNumberValidator{rule: rule, pattern: pattern}, nil
+}
+
+// Rule returns the rule the validator checks
+func (v *VehicleNumberValidator) Rule() VehicleNumberRule {
+	return v.rule
+}
+
+// Validate checks if the vehicle number is valid under the validator's rule
+func (v *VehicleNumberValidator) Validate(number string) error {
 	// Check for empty string
-	if strings.TrimSpace(number) == "" {
+	trimmed := strings.TrimSpace(number)
+	if trimmed == "" {
 		return errors.NewInvalidVehicleNumberError(number, "vehicle number cannot be empty")
 	}
 
 	// Check pattern
-	if !vehicleNumberPattern.MatchString(number) {
+	if !v.pattern.MatchString(number) {
 		return errors.NewInvalidVehicleNumberError(number, "invalid format")
 	}
 
+	// Check length and spacing
+	length := utf8.RuneCountInString(trimmed)
+	if v.rule.MinLength > 0 && length < v.rule.MinLength {
+		return errors.NewInvalidVehicleNumberError(number,
+			fmt.Sprintf("must be at least %d characters", v.rule.MinLength))
+	}
+	if v.rule.MaxLength > 0 && length > v.rule.MaxLength {
+		return errors.NewInvalidVehicleNumberError(number,
+			fmt.Sprintf("must be at most %d characters", v.rule.MaxLength))
+	}
+	if v.rule.NoSpaces && strings.IndexFunc(trimmed, unicode.IsSpace) >= 0 {
+		return errors.NewInvalidVehicleNumberError(number, "spaces are not allowed")
+	}
+
 	return nil
 }
 
+// WithVehicleNumberValidator makes the lot accept vehicle numbers by
+// validator's rule instead of the default one
+func WithVehicleNumberValidator(validator *VehicleNumberValidator) LotOption {
+	return func(p *ParkingLot) {
+		p.numberValidator = validator
+	}
+}
+
+// VehicleNumberValidator returns the validator the lot checks vehicle numbers with
+func (p *ParkingLot) VehicleNumberValidator() *VehicleNumberValidator {
+	if p.numberValidator == nil {
+		return defaultVehicleNumberValidator
+	}
+	return p.numberValidator
+}
+
+// validateVehicleNumber checks a vehicle number against the lot's rule. The
+// validator is fixed at creation, so no lock is needed.
+func (p *ParkingLot) validateVehicleNumber(number string) error {
+	return p.VehicleNumberValidator().Validate(number)
+}
+
+// WithSeparatorInsensitiveMatching makes the lot treat vehicle numbers that
+// differ only in spaces and hyphens as the same vehicle, so KA01HH1234,
+// KA-01-HH-1234 and KA 01 HH 1234 find one another. Numbers are still shown
+// as they were written at park time.
+func WithSeparatorInsensitiveMatching() LotOption {
+	return func(p *ParkingLot) {
+		p.ignoreSeparators = true
+	}
+}
+
+// vehicleKey returns the form of a vehicle number the lot indexes vehicles
+// by; like the validator, the matching mode is fixed at creation
+func (p *ParkingLot) vehicleKey(number string) string {
+	if p.ignoreSeparators {
+		return CanonicalVehicleNumber(number)
+	}
+	return NormalizeVehicleNumber(number)
+}
+
+// CanonicalVehicleNumber normalizes a vehicle number and strips its spaces
+// and hyphens
+func CanonicalVehicleNumber(number string) string {
+	return separatorReplacer.Replace(NormalizeVehicleNumber(number))
+}
+
+// Removes the separators ignored by CanonicalVehicleNumber
+var separatorReplacer = strings.NewReplacer(" ", "", "-", "")
+
 // NormalizeVehicleNumber standardizes a vehicle number by trimming spaces
 // and converting to uppercase
 func NormalizeVehicleNumber(number string) string {
 	// Convert to uppercase and trim spaces
 	normalized := strings.ToUpper(strings.TrimSpace(number))
 
-	// Standardize spacing by replacing multiple spaces with a single space
-	spaceRegex := regexp.MustCompile(`\s+`)
-	normalized = spaceRegex.ReplaceAllString(normalized, " ")
+	// Standardize spacing by replacing multiple spaces with a single space,
+	// skipping the regexp for the common case of nothing to replace
+	if strings.Contains(normalized, "  ") || strings.ContainsAny(normalized, "\t\n\f\r") {
+		normalized = whitespacePattern.ReplaceAllString(normalized, " ")
+	}
 
 	return normalized
 }
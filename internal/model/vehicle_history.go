@@ -24,10 +24,16 @@ func (r *ParkingRecord) IsComplete() bool {
 // Duration returns the duration for which the vehicle was parked
 // If the vehicle is still parked, it returns the duration until now
 func (r *ParkingRecord) Duration() time.Duration {
+	return r.DurationAt(time.Now())
+}
+
+// DurationAt returns the duration for which the vehicle was parked
+// If the vehicle is still parked, it returns the duration until the given time
+func (r *ParkingRecord) DurationAt(now time.Time) time.Duration {
 	if r.IsComplete() {
 		return r.UnparkedAt.Sub(r.ParkedAt)
 	}
-	return time.Since(r.ParkedAt)
+	return now.Sub(r.ParkedAt)
 }
 
 // VehicleHistory tracks the parking history of a vehicle
@@ -37,21 +43,47 @@ type VehicleHistory struct {
 
 	// History of parking records of this vehicle
 	Records []ParkingRecord
+
+	// Spot of the newest record, kept when pruning removes every record
+	prunedLastSpotID string
+
+	// Clock used to timestamp records
+	clock Clock
 }
 
 // NewVehicleHistory creates a new vehicle history for a vehicle
 func NewVehicleHistory(vehicle *Vehicle) *VehicleHistory {
+	return NewVehicleHistoryWithClock(vehicle, RealClock{})
+}
+
+// NewVehicleHistoryWithClock creates a new vehicle history that timestamps
+// records with the given clock
+func NewVehicleHistoryWithClock(vehicle *Vehicle, clock Clock) *VehicleHistory {
 	return &VehicleHistory{
 		Vehicle: vehicle,
 		Records: make([]ParkingRecord, 0),
+		clock:   clock,
+	}
+}
+
+// now returns the current time of the history's clock
+func (h *VehicleHistory) now() time.Time {
+	if h.clock == nil {
+		return time.Now()
 	}
+	return h.clock.Now()
 }
 
 // AddParkingRecord adds a new parking record to the history
 func (h *VehicleHistory) AddParkingRecord(spotID string) {
+	h.addParkingRecordAt(spotID, h.now())
+}
+
+// addParkingRecordAt adds a parking record starting at the given time
+func (h *VehicleHistory) addParkingRecordAt(spotID string, parkedAt time.Time) {
 	record := ParkingRecord{
 		SpotID:     spotID,
-		ParkedAt:   time.Now(),
+		ParkedAt:   parkedAt,
 		UnparkedAt: nil,
 	}
 
@@ -71,11 +103,26 @@ func (h *VehicleHistory) CompleteLastParkingRecord() error {
 			"last record is already complete")
 	}
 
-	now := time.Now()
+	now := h.now()
 	h.Records[lastIndex].UnparkedAt = &now
 	return nil
 }
 
+// removeLastParkingRecord drops the last record, undoing AddParkingRecord
+func (h *VehicleHistory) removeLastParkingRecord() {
+	if len(h.Records) > 0 {
+		h.Records = h.Records[:len(h.Records)-1]
+	}
+}
+
+// reopenLastParkingRecord marks the last record active again, undoing
+// CompleteLastParkingRecord
+func (h *VehicleHistory) reopenLastParkingRecord() {
+	if record := h.GetLastParkingRecord(); record != nil {
+		record.UnparkedAt = nil
+	}
+}
+
 // GetLastParkingRecord returns the last parking record for the vehicle
 // Returns nil if there is no history
 func (h *VehicleHistory) GetLastParkingRecord() *ParkingRecord {
@@ -108,8 +155,73 @@ func (h *VehicleHistory) GetCurrentSpotID() string {
 func (h *VehicleHistory) GetLastSpotID() string {
 	record := h.GetLastParkingRecord()
 	if record == nil {
-		return ""
+		return h.prunedLastSpotID
 	}
 
 	return record.SpotID
 }
+
+// removeCompleted drops the completed records for which drop returns true,
+// keeping the active record and remembering the last spot
+// Returns the number of records removed
+func (h *VehicleHistory) removeCompleted(drop func(record *ParkingRecord) bool) int {
+	lastSpotID := h.GetLastSpotID()
+
+	kept := h.Records[:0]
+	removed := 0
+	for i := range h.Records {
+		record := h.Records[i]
+		if record.IsComplete() && drop(&record) {
+			removed++
+			continue
+		}
+		kept = append(kept, record)
+	}
+
+	// Clear the tail so dropped records can be garbage collected
+	for i := len(kept); i < len(h.Records); i++ {
+		h.Records[i] = ParkingRecord{}
+	}
+	h.Records = kept
+
+	if len(h.Records) == 0 {
+		h.prunedLastSpotID = lastSpotID
+	}
+
+	return removed
+}
+
+// TrimCompleted keeps only the most recent limit completed records
+// The active record is never removed
+// Returns the number of records removed
+func (h *VehicleHistory) TrimCompleted(limit int) int {
+	completed := 0
+	for i := range h.Records {
+		if h.Records[i].IsComplete() {
+			completed++
+		}
+	}
+
+	excess := completed - limit
+	if excess <= 0 {
+		return 0
+	}
+
+	// Completed records are oldest first, so drop the first excess of them
+	return h.removeCompleted(func(_ *ParkingRecord) bool {
+		if excess == 0 {
+			return false
+		}
+		excess--
+		return true
+	})
+}
+
+// PruneCompletedBefore removes completed records that ended before t
+// The active record is never removed
+// Returns the number of records removed
+func (h *VehicleHistory) PruneCompletedBefore(t time.Time) int {
+	return h.removeCompleted(func(record *ParkingRecord) bool {
+		return record.UnparkedAt.Before(t)
+	})
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -75,21 +76,46 @@ func TestParkingRecordDuration(t *testing.T) {
 		})
 	}
 
-	// Test ongoing parking (can't use exact duration comparison)
+	// Ongoing parking is measured until the given time
 	t.Run("Ongoing parking", func(t *testing.T) {
 		record := ParkingRecord{
 			SpotID:     "1-1-1",
-			ParkedAt:   time.Now().Add(-10 * time.Second),
+			ParkedAt:   now,
 			UnparkedAt: nil,
 		}
 
-		duration := record.Duration()
-		if duration < 9*time.Second || duration > 11*time.Second {
-			t.Errorf("Expected Duration() to be around 10 seconds, got %v", duration)
+		if duration := record.DurationAt(now.Add(10 * time.Second)); duration != 10*time.Second {
+			t.Errorf("Expected DurationAt() = 10s, got %v", duration)
+		}
+
+		// A complete record ignores the given time
+		complete := ParkingRecord{SpotID: "1-1-1", ParkedAt: now, UnparkedAt: &later}
+		if duration := complete.DurationAt(now.Add(5 * time.Hour)); duration != time.Hour {
+			t.Errorf("Expected DurationAt() = 1h for a complete record, got %v", duration)
 		}
 	})
 }
 
+func TestVehicleHistoryClock(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	vehicle, _ := NewVehicle(VehicleTypeAutomobile, "KA-01-HH-1234")
+	history := NewVehicleHistoryWithClock(vehicle, clock)
+
+	history.AddParkingRecord("1-2-3")
+	clock.Advance(45 * time.Minute)
+	if err := history.CompleteLastParkingRecord(); err != nil {
+		t.Fatalf("Failed to complete record: %v", err)
+	}
+
+	record := history.GetLastParkingRecord()
+	if !record.ParkedAt.Equal(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected record parked at 09:00, got %v", record.ParkedAt)
+	}
+	if record.Duration() != 45*time.Minute {
+		t.Errorf("Expected 45m parked, got %v", record.Duration())
+	}
+}
+
 func TestVehicleHistory(t *testing.T) {
 	vehicle, _ := NewVehicle(VehicleTypeAutomobile, "KA-01-HH-1234")
 	history := NewVehicleHistory(vehicle)
@@ -227,3 +253,81 @@ func TestVehicleHistoryErrors(t *testing.T) {
 		t.Errorf("Expected InvalidOperationError, got %T", err)
 	}
 }
+
+func TestVehicleHistoryTrimCompleted(t *testing.T) {
+	vehicle, _ := NewVehicle(VehicleTypeAutomobile, "KA-01-HH-1234")
+	history := NewVehicleHistory(vehicle)
+
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		end := start.Add(time.Duration(i+1) * time.Hour)
+		history.Records = append(history.Records, ParkingRecord{
+			SpotID:     fmt.Sprintf("0-0-%d", i),
+			ParkedAt:   start.Add(time.Duration(i) * time.Hour),
+			UnparkedAt: &end,
+		})
+	}
+	history.Records = append(history.Records, ParkingRecord{SpotID: "0-1-0", ParkedAt: start.Add(5 * time.Hour)})
+
+	if removed := history.TrimCompleted(5); removed != 0 {
+		t.Errorf("Expected nothing removed under the limit, got %d", removed)
+	}
+
+	// The two oldest completed records go, the active one stays
+	if removed := history.TrimCompleted(2); removed != 2 {
+		t.Errorf("Expected 2 records removed, got %d", removed)
+	}
+
+	expected := []string{"0-0-2", "0-0-3", "0-1-0"}
+	if len(history.Records) != len(expected) {
+		t.Fatalf("Expected %d records, got %d", len(expected), len(history.Records))
+	}
+	for i, record := range history.Records {
+		if record.SpotID != expected[i] {
+			t.Errorf("Record %d: expected spot %s, got %s", i, expected[i], record.SpotID)
+		}
+	}
+	if !history.IsCurrentlyParked() {
+		t.Error("Expected the active record to survive trimming")
+	}
+}
+
+func TestVehicleHistoryPruneCompletedBefore(t *testing.T) {
+	vehicle, _ := NewVehicle(VehicleTypeAutomobile, "KA-01-HH-1234")
+	history := NewVehicleHistory(vehicle)
+
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		end := start.Add(time.Duration(i+1) * time.Hour)
+		history.Records = append(history.Records, ParkingRecord{
+			SpotID:     fmt.Sprintf("0-0-%d", i),
+			ParkedAt:   start.Add(time.Duration(i) * time.Hour),
+			UnparkedAt: &end,
+		})
+	}
+
+	// Records ending at 10:00 and 11:00 are before 11:30, the one ending at 12:00 is not
+	if removed := history.PruneCompletedBefore(start.Add(150 * time.Minute)); removed != 2 {
+		t.Errorf("Expected 2 records removed, got %d", removed)
+	}
+	if len(history.Records) != 1 || history.Records[0].SpotID != "0-0-2" {
+		t.Fatalf("Expected only the record at 0-0-2 to remain, got %+v", history.Records)
+	}
+
+	// Pruning everything still remembers the last spot
+	if removed := history.PruneCompletedBefore(start.Add(24 * time.Hour)); removed != 1 {
+		t.Errorf("Expected 1 record removed, got %d", removed)
+	}
+	if len(history.Records) != 0 {
+		t.Errorf("Expected no records, got %d", len(history.Records))
+	}
+	if history.GetLastSpotID() != "0-0-2" {
+		t.Errorf("Expected last spot 0-0-2 after pruning, got %q", history.GetLastSpotID())
+	}
+
+	// A new parking takes over as the last spot
+	history.AddParkingRecord("1-0-0")
+	if history.GetLastSpotID() != "1-0-0" {
+		t.Errorf("Expected last spot 1-0-0, got %q", history.GetLastSpotID())
+	}
+}
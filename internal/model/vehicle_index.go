@@ -0,0 +1,73 @@
+package model
+
+import "sync"
+
+// vehicleIndex maps normalized vehicle numbers to values of a single type.
+// It is safe for concurrent use and keeps the sync.Map it wraps out of reach,
+// so storing or reading the wrong type fails to compile.
+type vehicleIndex[V any] struct {
+	m sync.Map
+}
+
+// get returns the value stored for a vehicle number
+func (x *vehicleIndex[V]) get(vehicleNumber string) (V, bool) {
+	value, found := x.m.Load(vehicleNumber)
+	if !found {
+		var zero V
+		return zero, false
+	}
+	return value.(V), true
+}
+
+// put stores the value for a vehicle number, replacing any previous one
+func (x *vehicleIndex[V]) put(vehicleNumber string, value V) {
+	x.m.Store(vehicleNumber, value)
+}
+
+// delete removes a vehicle number from the index
+func (x *vehicleIndex[V]) delete(vehicleNumber string) {
+	x.m.Delete(vehicleNumber)
+}
+
+// each calls fn for every vehicle number and value until fn returns false,
+// in no particular order
+func (x *vehicleIndex[V]) each(fn func(vehicleNumber string, value V) bool) {
+	x.m.Range(func(k, v any) bool {
+		return fn(k.(string), v.(V))
+	})
+}
+
+// count returns the number of vehicle numbers in the index
+func (x *vehicleIndex[V]) count() int {
+	count := 0
+	x.m.Range(func(_, _ any) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// clear removes every vehicle number in place, so concurrent readers never
+// see a replaced map
+func (x *vehicleIndex[V]) clear() {
+	x.m.Clear()
+}
+
+// vehicleLocks serializes operations on the same vehicle number, so checking
+// the indexes for a vehicle and the changes that follow cannot interleave
+// with another operation on that vehicle. Vehicle numbers share a fixed set
+// of mutexes, picked by hash.
+type vehicleLocks struct {
+	stripes [64]sync.Mutex
+}
+
+// forNumber returns the mutex guarding a normalized vehicle number
+func (l *vehicleLocks) forNumber(vehicleNumber string) *sync.Mutex {
+	// FNV-1a
+	hash := uint32(2166136261)
+	for i := 0; i < len(vehicleNumber); i++ {
+		hash ^= uint32(vehicleNumber[i])
+		hash *= 16777619
+	}
+	return &l.stripes[hash%uint32(len(l.stripes))]
+}
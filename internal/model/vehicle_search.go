@@ -0,0 +1,94 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prasaria/go-multistorey-parking-lot/internal/errors"
+)
+
+// matchVehicleNumber reports whether a normalized vehicle number matches a
+// normalized pattern. A pattern with * wildcards must match the whole number,
+// each * standing for any run of characters; any other pattern matches
+// anywhere in the number.
+func matchVehicleNumber(pattern, number string) bool {
+	if !strings.Contains(pattern, "*") {
+		return strings.Contains(number, pattern)
+	}
+
+	parts := strings.Split(pattern, "*")
+
+	// The first and last parts are anchored to the ends of the number
+	first, last := parts[0], parts[len(parts)-1]
+	if !strings.HasPrefix(number, first) {
+		return false
+	}
+	number = number[len(first):]
+
+	// Each middle part takes its earliest match, leaving the most room for the rest
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(number, part)
+		if i < 0 {
+			return false
+		}
+		number = number[i+len(part):]
+	}
+
+	return strings.HasSuffix(number, last)
+}
+
+// FindVehiclesMatching returns the vehicles, parked or seen before, whose
+// numbers match the pattern, ordered by vehicle number. Matching ignores
+// case; see matchVehicleNumber for the pattern syntax. At most limit
+// vehicles are returned, and truncated reports whether more matched.
+func (p *ParkingLot) FindVehiclesMatching(pattern string, limit int) (matches []SearchInfo, truncated bool, err error) {
+	p.ops.searches.Add(1)
+
+	normalizedPattern := p.vehicleKey(pattern)
+	if strings.Trim(normalizedPattern, "*") == "" {
+		return nil, false, errors.NewValidationError("pattern", pattern,
+			"pattern must contain more than wildcards")
+	}
+	if limit < 1 {
+		return nil, false, errors.NewValidationError("limit", fmt.Sprintf("%d", limit),
+			"limit must be at least 1")
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	// Parked vehicles are checked too, in case their history is missing
+	numbers := make(map[string]bool)
+	p.parkedVehicles.each(func(vehicleNumber string, _ ParkedVehicle) bool {
+		if matchVehicleNumber(normalizedPattern, vehicleNumber) {
+			numbers[vehicleNumber] = true
+		}
+		return true
+	})
+	p.vehicleHistory.each(func(vehicleNumber string, _ *VehicleHistory) bool {
+		if matchVehicleNumber(normalizedPattern, vehicleNumber) {
+			numbers[vehicleNumber] = true
+		}
+		return true
+	})
+
+	sorted := make([]string, 0, len(numbers))
+	for vehicleNumber := range numbers {
+		sorted = append(sorted, vehicleNumber)
+	}
+	sort.Strings(sorted)
+
+	if len(sorted) > limit {
+		sorted, truncated = sorted[:limit], true
+	}
+
+	matches = make([]SearchInfo, 0, len(sorted))
+	for _, vehicleNumber := range sorted {
+		if info := p.searchInfoLocked(vehicleNumber); info != nil {
+			matches = append(matches, *info)
+		}
+	}
+
+	return matches, truncated, nil
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMatchVehicleNumber(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		number   string
+		expected bool
+	}{
+		// Plain patterns match anywhere
+		{"1234", "KA-01-HH-1234", true},
+		{"HH", "KA-01-HH-1234", true},
+		{"4321", "KA-01-HH-1234", false},
+
+		// Wildcard patterns match the whole number
+		{"*1234", "KA-01-HH-1234", true},
+		{"*1234", "KA-01-HH-12345", false},
+		{"KA-*", "KA-01-HH-1234", true},
+		{"KA-*-1234", "KA-01-HH-1234", true},
+		{"KA-*-1234", "MH-01-HH-1234", false},
+		{"*01*12*", "KA-01-HH-1234", true},
+		{"*12*12*", "KA-01-HH-1234", false},
+		{"K*A*", "KA", true},
+		{"KA*KA", "KA", false},
+	}
+
+	for _, tc := range tests {
+		if got := matchVehicleNumber(tc.pattern, tc.number); got != tc.expected {
+			t.Errorf("matchVehicleNumber(%q, %q): expected %v, got %v", tc.pattern, tc.number, tc.expected, got)
+		}
+	}
+}
+
+func TestFindVehiclesMatching(t *testing.T) {
+	lot, err := CreateParkingLot("Search Lot", 2, 4, 5)
+	if err != nil {
+		t.Fatalf("Failed to create lot: %v", err)
+	}
+
+	// Two vehicles ending in 1234 left, one is still parked, and others
+	// share only part of the number
+	for _, number := range []string{"KA-01-HH-1234", "MH-02-AB-1234", "DL-03-CD-5678"} {
+		spotID, err := lot.Park(VehicleTypeAutomobile, number)
+		if err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+		if err := lot.Unpark(spotID, number); err != nil {
+			t.Fatalf("Failed to unpark %s: %v", number, err)
+		}
+	}
+	for _, number := range []string{"TN-04-EF-1234", "KA-05-GH-12345", "MH-02-AB-1234"} {
+		if _, err := lot.Park(VehicleTypeAutomobile, number); err != nil {
+			t.Fatalf("Failed to park %s: %v", number, err)
+		}
+	}
+
+	describe := func(matches []SearchInfo) string {
+		var s string
+		for _, m := range matches {
+			s += fmt.Sprintf("%s:%v ", m.VehicleNumber, m.IsParked)
+		}
+		return s
+	}
+
+	tests := []struct {
+		pattern  string
+		expected string
+	}{
+		{"*1234", "KA-01-HH-1234:false MH-02-AB-1234:true TN-04-EF-1234:true "},
+		{"1234", "KA-01-HH-1234:false KA-05-GH-12345:true MH-02-AB-1234:true TN-04-EF-1234:true "},
+		{"mh-*", "MH-02-AB-1234:true "},
+		{"ka", "KA-01-HH-1234:false KA-05-GH-12345:true "},
+		{"9999", ""},
+	}
+	for _, tc := range tests {
+		matches, truncated, err := lot.FindVehiclesMatching(tc.pattern, 10)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.pattern, err)
+		}
+		if got := describe(matches); got != tc.expected || truncated {
+			t.Errorf("%q: expected %q, got %q (truncated %v)", tc.pattern, tc.expected, got, truncated)
+		}
+	}
+
+	// Matches describe where each vehicle is or was last seen
+	matches, _, _ := lot.FindVehiclesMatching("KA-01", 10)
+	if len(matches) != 1 || matches[0].SpotID == "" || matches[0].LastSeenAt.IsZero() {
+		t.Errorf("Expected KA-01-HH-1234 with its last spot and time, got %+v", matches)
+	}
+
+	// Results beyond the limit are cut and reported
+	matches, truncated, _ := lot.FindVehiclesMatching("1234", 2)
+	if describe(matches) != "KA-01-HH-1234:false KA-05-GH-12345:true " || !truncated {
+		t.Errorf("Expected the first two matches and truncation, got %q (truncated %v)",
+			describe(matches), truncated)
+	}
+
+	// Patterns that would match everything and useless limits are rejected
+	for _, pattern := range []string{"", "*", " ** "} {
+		if _, _, err := lot.FindVehiclesMatching(pattern, 10); err == nil {
+			t.Errorf("Expected an error for pattern %q", pattern)
+		}
+	}
+	if _, _, err := lot.FindVehiclesMatching("1234", 0); err == nil {
+		t.Error("Expected an error for a zero limit")
+	}
+}
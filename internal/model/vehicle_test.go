@@ -103,6 +103,14 @@ func TestVehicleNumberNormalization(t *testing.T) {
 	}
 }
 
+func TestCanonicalVehicleNumber(t *testing.T) {
+	for _, input := range []string{"KA01HH1234", "KA-01-HH-1234", "KA 01 HH 1234", " ka-01  hh 1234 ", "KA--01-HH1234"} {
+		if result := CanonicalVehicleNumber(input); result != "KA01HH1234" {
+			t.Errorf("CanonicalVehicleNumber(%q) = %q, want %q", input, result, "KA01HH1234")
+		}
+	}
+}
+
 func TestValidateVehicleNumber(t *testing.T) {
 	tests := []struct {
 		number    string
@@ -134,6 +142,92 @@ func TestValidateVehicleNumber(t *testing.T) {
 	}
 }
 
+// Indian registration plates: state, district, series and number
+var indianPlateRule = VehicleNumberRule{
+	Pattern:   `(?i)[A-Z]{2}-[0-9]{2}-[A-Z]{1,3}-[0-9]{4}`,
+	MinLength: 10,
+	MaxLength: 14,
+	NoSpaces:  true,
+}
+
+func TestVehicleNumberValidatorRules(t *testing.T) {
+	strict, err := NewVehicleNumberValidator(indianPlateRule)
+	if err != nil {
+		t.Fatalf("Failed to create strict validator: %v", err)
+	}
+	permissive, err := NewVehicleNumberValidator(VehicleNumberRule{})
+	if err != nil {
+		t.Fatalf("Failed to create default validator: %v", err)
+	}
+
+	tests := []struct {
+		number          string
+		strictValid     bool
+		permissiveValid bool
+	}{
+		{"KA-01-HH-1234", true, true},
+		{"ka-01-hh-1234", true, true},
+		{"MH-12-A-0001", true, true},
+		{"DL-03-ABC-5678", true, true},
+		{"KA-01-HH-123", false, true},
+		{"KA01HH1234", false, true},
+		{"KA 01 HH 1234", false, true},
+		{"B-12345", false, true},
+		{"123456", false, true},
+		{"KA-01-HH-1234-X", false, true},
+		{"", false, false},
+		{"*INVALID*", false, false},
+	}
+
+	for _, tt := range tests {
+		if err := strict.Validate(tt.number); (err == nil) != tt.strictValid {
+			t.Errorf("strict.Validate(%q): expected valid %v, got %v", tt.number, tt.strictValid, err)
+		}
+		if err := permissive.Validate(tt.number); (err == nil) != tt.permissiveValid {
+			t.Errorf("permissive.Validate(%q): expected valid %v, got %v", tt.number, tt.permissiveValid, err)
+		}
+
+		// The zero rule behaves exactly like ValidateVehicleNumber
+		if (permissive.Validate(tt.number) == nil) != (ValidateVehicleNumber(tt.number) == nil) {
+			t.Errorf("Default validator and ValidateVehicleNumber disagree on %q", tt.number)
+		}
+	}
+}
+
+func TestVehicleNumberValidatorLimits(t *testing.T) {
+	validator, err := NewVehicleNumberValidator(VehicleNumberRule{MinLength: 4, MaxLength: 6, NoSpaces: true})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	tests := []struct {
+		number    string
+		expectErr bool
+	}{
+		{"AB1", true},
+		{"AB12", false},
+		{"AB-123", false},
+		{"AB-1234", true},
+		{"AB 12", true},
+	}
+	for _, tt := range tests {
+		if err := validator.Validate(tt.number); (err != nil) != tt.expectErr {
+			t.Errorf("Validate(%q): expected error %v, got %v", tt.number, tt.expectErr, err)
+		}
+	}
+
+	// Rules that cannot be checked are rejected up front
+	for _, rule := range []VehicleNumberRule{
+		{Pattern: "[A-Z"},
+		{MinLength: -1},
+		{MinLength: 8, MaxLength: 4},
+	} {
+		if _, err := NewVehicleNumberValidator(rule); err == nil {
+			t.Errorf("Expected an error for rule %+v", rule)
+		}
+	}
+}
+
 func TestVehicleEqual(t *testing.T) {
 	v1, _ := NewVehicle(VehicleTypeAutomobile, "KA-01-HH-1234")
 	v2, _ := NewVehicle(VehicleTypeAutomobile, "KA-01-HH-1234")
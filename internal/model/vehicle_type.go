@@ -18,49 +18,62 @@ const (
 
 	// VehicleTypeAutomobile represents an automobile
 	VehicleTypeAutomobile VehicleType = "AUTOMOBILE"
+
+	// VehicleTypeMinibus represents a minibus, which takes two automobile
+	// spots side by side
+	VehicleTypeMinibus VehicleType = "MINIBUS"
 )
 
+// BaseType returns the vehicle type whose spots a vehicle of this type
+// takes: the type itself, or for an oversized type the type it spans spots of
+func (v VehicleType) BaseType() VehicleType {
+	if info, ok := types.Load().vehicleType(v); ok && info.Spans != "" {
+		return info.Spans
+	}
+	return v
+}
+
+// SpotsNeeded returns how many adjacent spots in one row a vehicle of this
+// type takes
+func (v VehicleType) SpotsNeeded() int {
+	if info, ok := types.Load().vehicleType(v); ok {
+		return info.Spots
+	}
+	return 1
+}
+
+// IsOversized reports whether a vehicle of this type spans several spots
+func (v VehicleType) IsOversized() bool {
+	return v.SpotsNeeded() > 1
+}
+
 // GetPreferredSpotType returns the preferred spot type for this vehicle type
 func (v VehicleType) GetPreferredSpotType() SpotType {
-	switch v {
-	case VehicleTypeBicycle:
-		return SpotTypeBicycle
-	case VehicleTypeMotorcycle:
-		return SpotTypeMotorcycle
-	case VehicleTypeAutomobile:
-		return SpotTypeAutomobile
-	default:
-		// This should never happen if VehicleType is properly validated
-		return SpotTypeInactive
+	if spotTypes := v.GetCompatibleSpotTypes(); len(spotTypes) > 0 {
+		return spotTypes[0]
 	}
+
+	// This should never happen if VehicleType is properly validated
+	return SpotTypeInactive
 }
 
-// GetCompatibleSpotTypes returns all spot types that can accommodate this vehicle type
+// GetCompatibleSpotTypes returns all spot types that can accommodate this
+// vehicle type, those of the type it spans for an oversized one
 func (v VehicleType) GetCompatibleSpotTypes() []SpotType {
-	switch v {
-	case VehicleTypeBicycle:
-		return []SpotType{SpotTypeBicycle}
-	case VehicleTypeMotorcycle:
-		return []SpotType{SpotTypeMotorcycle}
-	case VehicleTypeAutomobile:
-		return []SpotType{SpotTypeAutomobile}
-	default:
+	info, ok := types.Load().vehicleType(v.BaseType())
+	if !ok {
 		return []SpotType{}
 	}
+	return append([]SpotType{}, info.SpotTypes...)
 }
 
-// ParseVehicleType converts a string to VehicleType
+// ParseVehicleType converts a vehicle type's name or one of its aliases,
+// in any case, to VehicleType
 func ParseVehicleType(s string) (VehicleType, error) {
-	switch strings.ToUpper(s) {
-	case "BICYCLE", "B", "BIKE":
-		return VehicleTypeBicycle, nil
-	case "MOTORCYCLE", "M", "MOTORBIKE":
-		return VehicleTypeMotorcycle, nil
-	case "AUTOMOBILE", "A", "CAR", "AUTO":
-		return VehicleTypeAutomobile, nil
-	default:
-		return "", errors.NewInvalidVehicleTypeError(s)
+	if vehicleType, ok := types.Load().names[strings.ToUpper(s)]; ok {
+		return vehicleType, nil
 	}
+	return "", errors.NewInvalidVehicleTypeError(s)
 }
 
 // String returns the string representation of VehicleType
@@ -13,6 +13,7 @@ func TestVehicleTypeGetPreferredSpotType(t *testing.T) {
 		{VehicleTypeBicycle, SpotTypeBicycle},
 		{VehicleTypeMotorcycle, SpotTypeMotorcycle},
 		{VehicleTypeAutomobile, SpotTypeAutomobile},
+		{VehicleTypeMinibus, SpotTypeAutomobile},
 	}
 
 	for _, tt := range tests {
@@ -33,6 +34,7 @@ func TestVehicleTypeGetCompatibleSpotTypes(t *testing.T) {
 		{VehicleTypeBicycle, []SpotType{SpotTypeBicycle}},
 		{VehicleTypeMotorcycle, []SpotType{SpotTypeMotorcycle}},
 		{VehicleTypeAutomobile, []SpotType{SpotTypeAutomobile}},
+		{VehicleTypeMinibus, []SpotType{SpotTypeAutomobile}},
 	}
 
 	for _, tt := range tests {
@@ -69,6 +71,9 @@ func TestParseVehicleType(t *testing.T) {
 		{"CAR", VehicleTypeAutomobile, false},
 		{"AUTO", VehicleTypeAutomobile, false},
 
+		{"MINIBUS", VehicleTypeMinibus, false},
+		{"bus", VehicleTypeMinibus, false},
+
 		{"invalid", "", true},
 		{"", "", true},
 	}
@@ -0,0 +1,608 @@
+// Package api defines the JSON results the parking lot CLI writes. Their
+// field names are a stable schema that scripts consume; a change to them
+// is a change to SchemaVersion and to the golden files of the tests.
+package api
+
+// SchemaVersion is the version of the JSON results, raised whenever a field
+// is renamed, removed or changes meaning
+const SchemaVersion = 1
+
+// JSONResult is the root structure for JSON output
+type JSONResult struct {
+	// SchemaVersion is the version of these structures the result follows
+	SchemaVersion int         `json:"schemaVersion"`
+	Success       bool        `json:"success"`
+	Command       string      `json:"command"`
+	Data          interface{} `json:"data,omitempty"`
+	Error         *JSONError  `json:"error,omitempty"`
+	Time          string      `json:"time"`
+}
+
+// JSONError describes the error a command failed with
+type JSONError struct {
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// InitResult contains data for init command output
+type InitResult struct {
+	Lot     string         `json:"lot"`
+	Floors  int            `json:"floors"`
+	Rows    int            `json:"rows"`
+	Columns int            `json:"columns"`
+	Total   int            `json:"totalSpots"`
+	Counts  map[string]int `json:"spotCounts"`
+	// The seed spots were placed from, left out for the fixed placement
+	Seed *int64 `json:"seed,omitempty"`
+}
+
+// FloorMapResult contains the display grid of one floor
+type FloorMapResult struct {
+	Floor int        `json:"floor"`
+	Grid  [][]string `json:"grid"`
+}
+
+// MapResult contains data for map command output
+type MapResult struct {
+	Floors []FloorMapResult `json:"floors"`
+}
+
+// TemplateResult describes a layout template and its approximate spot mix in percent
+type TemplateResult struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Mix         map[string]int `json:"mixPercent"`
+}
+
+// TemplatesResult contains data for templates command output
+type TemplatesResult struct {
+	Templates []TemplateResult `json:"templates"`
+}
+
+// AddFloorResult contains data for add-floor and expand-floor command output
+type AddFloorResult struct {
+	Floor   int            `json:"floor"`
+	Rows    int            `json:"rows"`
+	Columns int            `json:"columns"`
+	Total   int            `json:"totalSpots"`
+	Counts  map[string]int `json:"spotCounts"`
+}
+
+// FloorResult contains data for remove-floor, close-floor, open-floor and
+// restrict-floor output
+type FloorResult struct {
+	Floor  int  `json:"floor"`
+	Closed bool `json:"closed"`
+	// The vehicle types a restricted floor accepts, left out when unrestricted
+	Allowed []string `json:"allowed,omitempty"`
+}
+
+// SetSpotResult contains data for set-spot command output
+type SetSpotResult struct {
+	SpotID   string `json:"spotId"`
+	SpotType string `json:"spotType"`
+}
+
+// LabelResult contains data for label command output
+type LabelResult struct {
+	SpotID string `json:"spotId"`
+	Label  string `json:"label"`
+}
+
+// ChargeStatusResult contains data for charge-status command output
+type ChargeStatusResult struct {
+	SpotID string `json:"spotId"`
+	State  string `json:"state"`
+}
+
+// ChargerSummaryResult counts the EV chargers by state
+type ChargerSummaryResult struct {
+	Spots  int            `json:"spots"`
+	States map[string]int `json:"states"`
+}
+
+// PermitResult contains data for permit command output
+type PermitResult struct {
+	VehicleNumber string `json:"vehicleNumber"`
+	Permit        bool   `json:"permit"`
+}
+
+// PermitsResult lists the vehicles with a registered permit
+type PermitsResult struct {
+	Permits []string `json:"permits"`
+}
+
+// QuotaResult is the quota of a vehicle type and the general parks counted against it
+type QuotaResult struct {
+	VehicleType string `json:"vehicleType"`
+	Limit       int    `json:"limit"`
+	Used        int    `json:"used"`
+}
+
+// QuotasResult contains data for quota command output
+type QuotasResult struct {
+	Quotas []QuotaResult `json:"quotas"`
+}
+
+// RenameResult contains data for rename command output
+type RenameResult struct {
+	OldName string `json:"oldName"`
+	Name    string `json:"name"`
+}
+
+// SimulateResult contains data for simulate command output
+type SimulateResult struct {
+	Seed            int64   `json:"seed"`
+	Workers         int     `json:"workers"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	Operations      int     `json:"operations"`
+	Throughput      float64 `json:"operationsPerSecond"`
+	Parks           int     `json:"parks"`
+	Unparks         int     `json:"unparks"`
+	Searches        int     `json:"searches"`
+	Failures        int     `json:"failures"`
+	// Failed operations by the code of their error
+	Errors map[string]int `json:"errors"`
+
+	// Simulated vehicles removed at the end, or left parked with --keep
+	Removed         int `json:"removed"`
+	Kept            int `json:"kept"`
+	CleanupFailures int `json:"cleanupFailures"`
+
+	// Consistent is set when the occupied spots are exactly those the
+	// parked vehicles hold
+	OccupiedSpots int  `json:"occupiedSpots"`
+	HeldSpots     int  `json:"heldSpots"`
+	Consistent    bool `json:"consistent"`
+}
+
+// UndoResult contains data for undo command output
+type UndoResult struct {
+	// Operation is the park or unpark undone
+	Operation     string `json:"operation"`
+	VehicleNumber string `json:"vehicleNumber"`
+	SpotID        string `json:"spotId"`
+}
+
+// ResetResult contains data for reset command output
+type ResetResult struct {
+	// Mode is all, vehicles or history
+	Mode     string `json:"mode"`
+	Vehicles int    `json:"vehiclesRemoved"`
+	Records  int    `json:"recordsRemoved"`
+
+	// StatsZeroed reports whether the operation counts were zeroed too
+	StatsZeroed bool `json:"statsZeroed,omitempty"`
+}
+
+// SaveResult contains data for save command output
+type SaveResult struct {
+	Path     string `json:"path"`
+	TakenAt  string `json:"takenAt"`
+	Floors   int    `json:"floors"`
+	Vehicles int    `json:"vehicles"`
+}
+
+// SpotStateResult is what a lot holds at a spot location, in diff output
+type SpotStateResult struct {
+	Missing  bool     `json:"missing,omitempty"`
+	Type     string   `json:"type,omitempty"`
+	Active   bool     `json:"active"`
+	Vehicles []string `json:"vehicles"`
+}
+
+// SpotDiffResult is a spot that differs between a snapshot and the lot
+type SpotDiffResult struct {
+	SpotID   string          `json:"spotId"`
+	Row      int             `json:"row"`
+	Column   int             `json:"column"`
+	Snapshot SpotStateResult `json:"snapshot"`
+	Current  SpotStateResult `json:"current"`
+}
+
+// FloorDiffResult lists the differing spots of one floor
+type FloorDiffResult struct {
+	Floor int              `json:"floor"`
+	Spots []SpotDiffResult `json:"spots"`
+}
+
+// DiffResult contains data for diff command output
+type DiffResult struct {
+	Snapshot       string            `json:"snapshot"`
+	TakenAt        string            `json:"takenAt"`
+	Floors         []FloorDiffResult `json:"floors"`
+	OnlyInSnapshot []string          `json:"onlyInSnapshot"`
+	OnlyInCurrent  []string          `json:"onlyInCurrent"`
+}
+
+// LotMetadataResult contains the address, timezone and notes of a lot, for
+// describe output
+type LotMetadataResult struct {
+	Address  string `json:"address"`
+	Timezone string `json:"timezone"`
+	Notes    string `json:"notes"`
+}
+
+// QuotaExemptionResult contains data for quota --exempt output
+type QuotaExemptionResult struct {
+	VehicleNumber string `json:"vehicleNumber"`
+	Exempt        bool   `json:"exempt"`
+}
+
+// AccessibleResult counts the accessible spots
+type AccessibleResult struct {
+	Spots     int `json:"spots"`
+	Available int `json:"available"`
+}
+
+// TagResult contains data for tag and untag command output
+type TagResult struct {
+	SpotID string   `json:"spotId"`
+	Tags   []string `json:"tags"`
+}
+
+// TagCountResult is how many spots carry a tag and how many are available
+type TagCountResult struct {
+	Tag       string `json:"tag"`
+	Spots     int    `json:"spots"`
+	Available int    `json:"available"`
+}
+
+// TagsResult contains data for tags command output
+type TagsResult struct {
+	Tags []TagCountResult `json:"tags"`
+}
+
+// SpotLocation is where a spot is in the lot, so that its ID need not be
+// parsed
+type SpotLocation struct {
+	Floor  int `json:"floor"`
+	Row    int `json:"row"`
+	Column int `json:"column"`
+}
+
+// LocatedSpotResult is a spot and its location
+type LocatedSpotResult struct {
+	SpotID   string       `json:"spotId"`
+	Location SpotLocation `json:"location"`
+}
+
+// ParkResult contains data for park command output
+type ParkResult struct {
+	VehicleType   string       `json:"vehicleType"`
+	VehicleNumber string       `json:"vehicleNumber"`
+	SpotID        string       `json:"spotId"`
+	Location      SpotLocation `json:"location"`
+	// Floor, Row and Column repeat the location, from before it was added
+	Floor    int      `json:"floor"`
+	Row      int      `json:"row"`
+	Column   int      `json:"column"`
+	Label    string   `json:"label,omitempty"`
+	Charger  bool     `json:"charger,omitempty"`
+	SpotIDs  []string `json:"spotIds,omitempty"`
+	ParkedAt string   `json:"parkedAt"`
+}
+
+// UnparkResult contains data for unpark command output
+type UnparkResult struct {
+	VehicleNumber   string       `json:"vehicleNumber"`
+	SpotID          string       `json:"spotId"`
+	Location        SpotLocation `json:"location"`
+	DurationSeconds float64      `json:"durationSeconds"`
+}
+
+// AvailableResult contains data for available command output
+type AvailableResult struct {
+	VehicleType       string              `json:"vehicleType"`
+	Tags              []string            `json:"tags,omitempty"`
+	SpotsByFloor      map[int][]string    `json:"spotsByFloor,omitempty"`
+	Nearest           []NearestSpotResult `json:"nearest,omitempty"`
+	Count             int                 `json:"count"`
+	AvailableCapacity int                 `json:"availableCapacity"`
+
+	// Locations lists the spots of SpotsByFloor floor by floor, with where
+	// they are
+	Locations []LocatedSpotResult `json:"locations,omitempty"`
+
+	// Accessible is how many of the counted spots need a permit
+	Accessible int `json:"accessible,omitempty"`
+}
+
+// UtilizationResult is an occupied share of active spots
+type UtilizationResult struct {
+	Occupied int     `json:"occupied"`
+	Active   int     `json:"active"`
+	Percent  float64 `json:"percent"`
+}
+
+// FloorOccupancyResult is the utilization of a single floor
+type FloorOccupancyResult struct {
+	Floor  int  `json:"floor"`
+	Closed bool `json:"closed"`
+	UtilizationResult
+}
+
+// OccupancyResult contains data for occupancy command output
+type OccupancyResult struct {
+	Overall UtilizationResult            `json:"overall"`
+	Floors  []FloorOccupancyResult       `json:"floors"`
+	ByType  map[string]UtilizationResult `json:"byType"`
+}
+
+// ParkingRecordResult is one parking session in history command output
+type ParkingRecordResult struct {
+	SpotID          string  `json:"spotId"`
+	ParkedAt        string  `json:"parkedAt"`
+	UnparkedAt      string  `json:"unparkedAt,omitempty"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	Status          string  `json:"status"`
+}
+
+// HistoryEntryResult is one parking session in history-range command output
+type HistoryEntryResult struct {
+	VehicleNumber string `json:"vehicleNumber"`
+	VehicleType   string `json:"vehicleType"`
+	ParkingRecordResult
+}
+
+// PruneHistoryResult contains data for prune-history command output
+type PruneHistoryResult struct {
+	Removed   int    `json:"removed"`
+	OlderThan string `json:"olderThan"`
+}
+
+// SpotOccupancyResult is one vehicle's stay in spot-history command output
+type SpotOccupancyResult struct {
+	VehicleNumber   string  `json:"vehicleNumber"`
+	ParkedAt        string  `json:"parkedAt"`
+	VacatedAt       string  `json:"vacatedAt,omitempty"`
+	DurationSeconds float64 `json:"durationSeconds"`
+}
+
+// SpotHistoryResult contains data for spot-history command output
+type SpotHistoryResult struct {
+	SpotID      string                `json:"spotId"`
+	Occupancies []SpotOccupancyResult `json:"occupancies"`
+}
+
+// VehicleResult is one parked vehicle in vehicles command output
+type VehicleResult struct {
+	VehicleNumber string `json:"vehicleNumber"`
+	VehicleType   string `json:"vehicleType"`
+	SpotID        string `json:"spotId"`
+	ParkedAt      string `json:"parkedAt"`
+}
+
+// SpotInfoResult is one spot in spots command output
+type SpotInfoResult struct {
+	SpotID        string   `json:"spotId"`
+	Floor         int      `json:"floor"`
+	Row           int      `json:"row"`
+	Column        int      `json:"column"`
+	Type          string   `json:"type"`
+	Capacity      int      `json:"capacity"`
+	Label         string   `json:"label,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	Occupied      bool     `json:"occupied"`
+	Vehicles      []string `json:"vehicles,omitempty"`
+	OccupiedSince string   `json:"occupiedSince,omitempty"`
+}
+
+// AvailableSpotResult is one spot in available --ndjson output
+type AvailableSpotResult struct {
+	VehicleType string       `json:"vehicleType"`
+	Floor       int          `json:"floor"`
+	SpotID      string       `json:"spotId"`
+	Location    SpotLocation `json:"location"`
+}
+
+// SpotsResult contains data for spots command output
+type SpotsResult struct {
+	Spots  []SpotInfoResult `json:"spots"`
+	Total  int              `json:"total"`
+	Offset int              `json:"offset"`
+	Limit  int              `json:"limit"`
+}
+
+// OccupancySampleResult is one sample in timeseries command output
+type OccupancySampleResult struct {
+	At     string         `json:"at"`
+	Total  int            `json:"total"`
+	ByType map[string]int `json:"byType"`
+}
+
+// LongestParkedResult is one vehicle in longest command output
+type LongestParkedResult struct {
+	VehicleResult
+	Floor           int     `json:"floor"`
+	DurationSeconds float64 `json:"durationSeconds"`
+}
+
+// NearestSpotResult is an available spot with its distance from the entrance
+type NearestSpotResult struct {
+	SpotID   string       `json:"spotId"`
+	Location SpotLocation `json:"location"`
+	Distance int          `json:"distance"`
+}
+
+// EntranceResult contains data for set-entrance command output
+type EntranceResult struct {
+	Floor  int    `json:"floor"`
+	Row    int    `json:"row"`
+	Column int    `json:"column"`
+	SpotID string `json:"spotId"`
+}
+
+// SearchResult contains data for search command output
+type SearchResult struct {
+	VehicleNumber string `json:"vehicleNumber"`
+	SpotID        string `json:"spotId"`
+	SpotLabel     string `json:"spotLabel,omitempty"`
+	// Location is where the spot is, omitted when no spot is known
+	Location    *SpotLocation `json:"location,omitempty"`
+	IsParked    bool          `json:"isParked"`
+	VehicleType string        `json:"vehicleType,omitempty"`
+	Floor       *int          `json:"floor,omitempty"`
+	ParkedAt    string        `json:"parkedAt,omitempty"`
+	LastSeenAt  string        `json:"lastSeenAt,omitempty"`
+}
+
+// SearchMatchesResult contains data for search --like command output
+type SearchMatchesResult struct {
+	Pattern   string         `json:"pattern"`
+	Matches   []SearchResult `json:"matches"`
+	Truncated bool           `json:"truncated"`
+}
+
+// LotSearchResult contains what one lot knows of a vehicle, for search --all-lots output
+type LotSearchResult struct {
+	Lot string `json:"lot"`
+	SearchResult
+}
+
+// LotSummaryResult contains the spot counts of one lot, for lots command output
+type LotSummaryResult struct {
+	Name           string `json:"name"`
+	Active         bool   `json:"active"`
+	NumFloors      int    `json:"numFloors"`
+	TotalSpots     int    `json:"totalSpots"`
+	OccupiedSpots  int    `json:"occupiedSpots"`
+	AvailableSpots int    `json:"availableSpots"`
+}
+
+// StatusResult contains data for status command output
+type StatusResult struct {
+	Name            string            `json:"name"`
+	NumFloors       int               `json:"numFloors"`
+	TotalSpots      int               `json:"totalSpots"`
+	ActiveSpots     int               `json:"activeSpots"`
+	OccupiedSpots   int               `json:"occupiedSpots"`
+	AvailableSpots  int               `json:"availableSpots"`
+	SpotCounts      map[string]int    `json:"spotCounts"`
+	AvailableCounts map[string]int    `json:"availableCounts"`
+	ParkedVehicles  map[string]string `json:"parkedVehicles"`
+
+	// Vehicles lists the parked vehicles with when they parked
+	Vehicles []VehicleResult `json:"vehicles"`
+
+	// Capacity counts vehicles rather than spots; they differ for bicycle racks
+	AvailableCapacityByType map[string]int `json:"availableCapacityByType"`
+	OccupiedCapacityByType  map[string]int `json:"occupiedCapacityByType"`
+
+	// PeakOccupancy is the most vehicles parked at once since init
+	PeakOccupancy PeakOccupancyResult `json:"peakOccupancy"`
+
+	// Chargers counts the EV chargers by state, omitted when there are none
+	Chargers *ChargerSummaryResult `json:"chargers,omitempty"`
+
+	// Accessible counts the accessible spots, omitted when there are none
+	Accessible *AccessibleResult `json:"accessible,omitempty"`
+
+	// Quotas lists the quotas set, omitted when there are none
+	Quotas []QuotaResult `json:"quotas,omitempty"`
+
+	// Metadata describes the lot, omitted when nothing is set
+	Metadata *LotMetadataResult `json:"metadata,omitempty"`
+
+	// Operations counts the operations done on the lot, given with --ops
+	Operations *OperationStatsResult `json:"operations,omitempty"`
+
+	Floors []FloorSummaryResult `json:"floors"`
+}
+
+// OperationStatsResult counts the operations done on a lot since it was
+// created or its counts were last zeroed
+type OperationStatsResult struct {
+	Parks             int64            `json:"parks"`
+	Unparks           int64            `json:"unparks"`
+	FailedParks       int64            `json:"failedParks"`
+	FailedParksByCode map[string]int64 `json:"failedParksByCode"`
+	Searches          int64            `json:"searches"`
+	Resets            int64            `json:"resets"`
+}
+
+// PeakOccupancyResult contains the peak occupancy overall and per vehicle type
+type PeakOccupancyResult struct {
+	Overall PeakResult            `json:"overall"`
+	ByType  map[string]PeakResult `json:"byType"`
+}
+
+// PeakResult is a peak vehicle count and when it was reached, empty when none
+type PeakResult struct {
+	Count int    `json:"count"`
+	At    string `json:"at,omitempty"`
+}
+
+// FloorSummaryResult contains the spot counts of one floor
+type FloorSummaryResult struct {
+	Floor           int            `json:"floor"`
+	Closed          bool           `json:"closed"`
+	Allowed         []string       `json:"allowed,omitempty"`
+	TotalSpots      int            `json:"totalSpots"`
+	ActiveSpots     int            `json:"activeSpots"`
+	OccupiedSpots   int            `json:"occupiedSpots"`
+	AvailableSpots  int            `json:"availableSpots"`
+	AvailableCounts map[string]int `json:"availableCounts"`
+}
+
+// FloorStatusResult contains data for status --floor output
+type FloorStatusResult struct {
+	FloorSummaryResult
+	ParkedVehicles map[string]string `json:"parkedVehicles"`
+	Vehicles       []VehicleResult   `json:"vehicles"`
+}
+
+// CommandStatsResult contains data for stats --commands output
+type CommandStatsResult struct {
+	Commands []CommandUsage `json:"commands"`
+}
+
+// CommandUsage is a point-in-time snapshot of the usage counters for a command
+type CommandUsage struct {
+	Name       string `json:"name"`
+	Executions int64  `json:"executions"`
+	Failures   int64  `json:"failures"`
+}
+
+// ParkingStatsResult contains data for stats command output
+type ParkingStatsResult struct {
+	CompletedSessions      int     `json:"completedSessions"`
+	ActiveSessions         int     `json:"activeSessions"`
+	DistinctVehicles       int     `json:"distinctVehicles"`
+	AverageDurationSeconds float64 `json:"averageDurationSeconds"`
+	MedianDurationSeconds  float64 `json:"medianDurationSeconds"`
+	BusiestSpotID          string  `json:"busiestSpotId,omitempty"`
+	BusiestSpotSessions    int     `json:"busiestSpotSessions"`
+
+	Operations OperationStatsResult `json:"operations"`
+}
+
+// Helper functions
+
+// AliasResult describes an alias and the command it stands for
+type AliasResult struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+}
+
+// AliasesResult contains data for alias command output
+type AliasesResult struct {
+	Aliases []AliasResult `json:"aliases"`
+}
+
+// OptionsResult contains data for set and show options output
+type OptionsResult struct {
+	Format  string `json:"format"`
+	Verbose bool   `json:"verbose"`
+	Quiet   bool   `json:"quiet"`
+	// Width is the width tables are fitted to, 0 to follow the terminal's
+	Width  int  `json:"width"`
+	Wrap   bool `json:"wrap"`
+	Color  bool `json:"color"`
+	Paging bool `json:"paging"`
+	// TimeFormat and Timezone are how text output shows times; Timezone is
+	// "lot" when times follow the lot's zone
+	TimeFormat string `json:"timeFormat"`
+	Timezone   string `json:"timezone"`
+}
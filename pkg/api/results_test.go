@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// resultTime is the time of every result marshaled by the tests
+const resultTime = "2024-03-01T09:15:00Z"
+
+func TestResultsGolden(t *testing.T) {
+	seed := int64(42)
+	floor := 0
+
+	// Every optional field is set, so that renaming any of them shows
+	tests := []struct {
+		name   string
+		result JSONResult
+	}{
+		{"init", JSONResult{Command: "init", Data: InitResult{
+			Lot: "default", Floors: 1, Rows: 2, Columns: 5, Total: 10,
+			Counts: map[string]int{"A-1": 6, "B-1": 1, "M-1": 1, "X-0": 2},
+			Seed:   &seed,
+		}}},
+		{"park", JSONResult{Command: "park", Data: ParkResult{
+			VehicleType: "AUTOMOBILE", VehicleNumber: "KA-01-HH-1234", SpotID: "0-0-2",
+			Location: SpotLocation{Floor: 0, Row: 0, Column: 2}, Floor: 0, Row: 0, Column: 2, Label: "near-exit", Charger: true,
+			SpotIDs: []string{"0-0-2", "0-0-3"}, ParkedAt: resultTime,
+		}}},
+		{"unpark", JSONResult{Command: "unpark", Data: UnparkResult{
+			VehicleNumber: "KA-01-HH-1234", SpotID: "0-0-2", Location: SpotLocation{Floor: 0, Row: 0, Column: 2},
+			DurationSeconds: 5400,
+		}}},
+		{"search", JSONResult{Command: "search", Data: SearchResult{
+			VehicleNumber: "KA-01-HH-1234", SpotID: "0-0-2", SpotLabel: "near-exit",
+			Location: &SpotLocation{Floor: 0, Row: 0, Column: 2}, IsParked: true,
+			VehicleType: "AUTOMOBILE", Floor: &floor, ParkedAt: resultTime, LastSeenAt: resultTime,
+		}}},
+		{"available", JSONResult{Command: "available", Data: AvailableResult{
+			VehicleType: "AUTOMOBILE", Tags: []string{"ev"},
+			SpotsByFloor: map[int][]string{0: {"0-0-2"}, 1: {"1-1-4"}},
+			Locations: []LocatedSpotResult{
+				{SpotID: "0-0-2", Location: SpotLocation{Floor: 0, Row: 0, Column: 2}},
+				{SpotID: "1-1-4", Location: SpotLocation{Floor: 1, Row: 1, Column: 4}},
+			},
+			Nearest: []NearestSpotResult{
+				{SpotID: "0-0-2", Location: SpotLocation{Floor: 0, Row: 0, Column: 2}, Distance: 2},
+			},
+			Count: 2, AvailableCapacity: 2, Accessible: 1,
+		}}},
+		{"history", JSONResult{Command: "history", Data: []ParkingRecordResult{
+			{SpotID: "0-0-2", ParkedAt: resultTime, UnparkedAt: resultTime, DurationSeconds: 0, Status: "Completed"},
+			{SpotID: "0-0-3", ParkedAt: resultTime, DurationSeconds: 60, Status: "Active"},
+		}}},
+		{"status", JSONResult{Command: "status", Data: StatusResult{
+			Name: "Orion Mall P2", NumFloors: 1, TotalSpots: 10, ActiveSpots: 8,
+			OccupiedSpots: 1, AvailableSpots: 7,
+			SpotCounts:      map[string]int{"A-1": 6, "B-1": 1, "M-1": 1},
+			AvailableCounts: map[string]int{"AUTOMOBILE": 5, "BICYCLE": 1, "MOTORCYCLE": 1},
+			ParkedVehicles:  map[string]string{"0-0-2": "KA-01-HH-1234"},
+			Vehicles: []VehicleResult{
+				{VehicleNumber: "KA-01-HH-1234", VehicleType: "AUTOMOBILE", SpotID: "0-0-2", ParkedAt: resultTime},
+			},
+			AvailableCapacityByType: map[string]int{"AUTOMOBILE": 5, "BICYCLE": 4, "MOTORCYCLE": 1},
+			OccupiedCapacityByType:  map[string]int{"AUTOMOBILE": 1},
+			PeakOccupancy: PeakOccupancyResult{
+				Overall: PeakResult{Count: 1, At: resultTime},
+				ByType:  map[string]PeakResult{"AUTOMOBILE": {Count: 1, At: resultTime}, "BICYCLE": {}},
+			},
+			Chargers:   &ChargerSummaryResult{Spots: 2, States: map[string]int{"available": 1, "charging": 1}},
+			Accessible: &AccessibleResult{Spots: 1, Available: 1},
+			Quotas:     []QuotaResult{{VehicleType: "AUTOMOBILE", Limit: 4, Used: 1}},
+			Metadata:   &LotMetadataResult{Address: "1 Main Street", Timezone: "Asia/Kolkata", Notes: "Gate B closed at night"},
+			Floors: []FloorSummaryResult{{
+				Floor: 0, Allowed: []string{"AUTOMOBILE"}, TotalSpots: 10, ActiveSpots: 8,
+				OccupiedSpots: 1, AvailableSpots: 7, AvailableCounts: map[string]int{"AUTOMOBILE": 5},
+			}},
+		}}},
+		{"error", JSONResult{Command: "park", Error: &JSONError{
+			Code:    "NO_SPACE_AVAILABLE",
+			Message: "failed to park vehicle: NO_SPACE_AVAILABLE: No available parking spot for vehicle type: AUTOMOBILE (no space available)",
+			Details: map[string]interface{}{"vehicleType": "AUTOMOBILE"},
+		}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := test.result
+			result.SchemaVersion, result.Success, result.Time = SchemaVersion, result.Error == nil, resultTime
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+			checkGolden(t, test.name+".json", string(output)+"\n")
+		})
+	}
+}
+
+// checkGolden compares output with the golden file testdata/name, writing
+// it instead when run with -update
+func checkGolden(t *testing.T, name, output string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(output), 0o644); err != nil {
+			t.Fatalf("Failed to update %s: %v", path, err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	if output != string(expected) {
+		t.Errorf("Unexpected JSON for %s, update the golden file if the change is meant:\n%s\nexpected:\n%s",
+			name, output, expected)
+	}
+}
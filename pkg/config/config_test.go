@@ -1,6 +1,9 @@
 package config
 
 import (
+	"errors"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -66,6 +69,40 @@ func TestConfigValidation(t *testing.T) {
 			},
 			valid: false,
 		},
+		{
+			name: "vehicle number rule",
+			config: ParkingLotConfig{
+				Floors:  3,
+				Rows:    10,
+				Columns: 20,
+				VehicleNumbers: VehicleNumberRule{
+					Pattern:   `[A-Z]{2}-[0-9]{2}-[A-Z]{1,3}-[0-9]{4}`,
+					MinLength: 10,
+					MaxLength: 13,
+				},
+			},
+			valid: true,
+		},
+		{
+			name: "invalid vehicle number pattern",
+			config: ParkingLotConfig{
+				Floors:         3,
+				Rows:           10,
+				Columns:        20,
+				VehicleNumbers: VehicleNumberRule{Pattern: `[A-Z`},
+			},
+			valid: false,
+		},
+		{
+			name: "vehicle number min length above max",
+			config: ParkingLotConfig{
+				Floors:         3,
+				Rows:           10,
+				Columns:        20,
+				VehicleNumbers: VehicleNumberRule{MinLength: 12, MaxLength: 10},
+			},
+			valid: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -102,6 +139,54 @@ func TestParseFlagsValidInput(t *testing.T) {
 	}
 }
 
+func TestParseFlagsVehicleNumberRule(t *testing.T) {
+	// The default rule accepts any length and spaces
+	config, err := ParseInitCommand(nil)
+	if err != nil {
+		t.Fatalf("Failed to parse empty flags: %v", err)
+	}
+	if config.VehicleNumbers != (VehicleNumberRule{AllowSpaces: true}) {
+		t.Errorf("Expected the permissive default rule, got %+v", config.VehicleNumbers)
+	}
+
+	args := []string{"-plate-pattern", "[A-Z]{2}-[0-9]{4}", "-plate-min-length", "7",
+		"-plate-max-length", "7", "-plate-allow-spaces=false"}
+	config, err = ParseInitCommand(args)
+	if err != nil {
+		t.Fatalf("Failed to parse vehicle number flags: %v", err)
+	}
+
+	expected := VehicleNumberRule{Pattern: "[A-Z]{2}-[0-9]{4}", MinLength: 7, MaxLength: 7}
+	if config.VehicleNumbers != expected {
+		t.Errorf("Expected rule %+v, got %+v", expected, config.VehicleNumbers)
+	}
+
+	if _, err := ParseInitCommand([]string{"-plate-pattern", "("}); !errors.Is(err, ErrInvalidVehicleNumberPattern) {
+		t.Errorf("Expected ErrInvalidVehicleNumberPattern, got %v", err)
+	}
+}
+
+func TestDisplayConfigValidate(t *testing.T) {
+	display := DefaultDisplayConfig()
+	if err := display.Validate(); err != nil {
+		t.Errorf("Expected the default display valid, got %v", err)
+	}
+
+	display = DisplayConfig{TimeLayout: "Jan 2 3:04PM", Timezone: "Asia/Kolkata"}
+	if err := display.Validate(); err != nil {
+		t.Errorf("Expected %+v valid, got %v", display, err)
+	}
+
+	display = DisplayConfig{TimeLayout: "hello"}
+	if err := display.Validate(); !errors.Is(err, ErrInvalidTimeLayout) {
+		t.Errorf("Expected ErrInvalidTimeLayout, got %v", err)
+	}
+	display = DisplayConfig{TimeLayout: DefaultTimeLayout, Timezone: "Mars/Base"}
+	if err := display.Validate(); !errors.Is(err, ErrInvalidTimezone) {
+		t.Errorf("Expected ErrInvalidTimezone, got %v", err)
+	}
+}
+
 func TestParseFlagsInvalidInput(t *testing.T) {
 	args := []string{"-floors", "9", "-rows", "15", "-columns", "25"}
 
@@ -109,4 +194,128 @@ func TestParseFlagsInvalidInput(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expected error for invalid floors, got nil")
 	}
+
+	if _, err := ParseInitCommand([]string{"-floors", "3", "5", "10"}); err == nil {
+		t.Error("Expected error for arguments left over, got nil")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		check func(env EnvConfig) bool
+		err   string
+	}{
+		{
+			name:  "nothing set",
+			env:   map[string]string{},
+			check: func(env EnvConfig) bool { return env.Lot == nil && env.Display == DefaultDisplayConfig() },
+		},
+		{
+			name: "lot dimensions",
+			env:  map[string]string{EnvFloors: "2", EnvColumns: "4", EnvName: "Depot"},
+			check: func(env EnvConfig) bool {
+				return env.Lot != nil && env.Lot.Floors == 2 && env.Lot.Rows == DefaultConfig().Rows &&
+					env.Lot.Columns == 4 && env.Name == "Depot"
+			},
+		},
+		{
+			name:  "name only",
+			env:   map[string]string{EnvName: "Depot"},
+			check: func(env EnvConfig) bool { return env.Lot != nil && *env.Lot == DefaultConfig() },
+		},
+		{
+			name: "runtime options",
+			env: map[string]string{EnvFormat: "json", EnvLogLevel: "warn", EnvLogFormat: "json",
+				EnvStateFile: "lot.json", EnvTimezone: "Asia/Kolkata"},
+			check: func(env EnvConfig) bool {
+				return env.Lot == nil && env.Format == "json" && env.LogLevel == "warn" && env.LogFormat == "json" &&
+					env.StateFile == "lot.json" && env.Display.Timezone == "Asia/Kolkata"
+			},
+		},
+		{
+			name: "session files",
+			env:  map[string]string{EnvStatsFile: "stats.json", EnvAliasesFile: "aliases.txt", EnvHistoryFile: "history.txt"},
+			check: func(env EnvConfig) bool {
+				return env.Lot == nil && env.StatsFile == "stats.json" && env.AliasesFile == "aliases.txt" &&
+					env.HistoryFile == "history.txt"
+			},
+		},
+		{
+			name: "session files in the home directory",
+			env:  map[string]string{"HOME": "/home/attendant"},
+			check: func(env EnvConfig) bool {
+				return env.StatsFile == "" && env.AliasesFile == filepath.Join("/home/attendant", ".parkinglot_aliases") &&
+					env.HistoryFile == filepath.Join("/home/attendant", ".parkinglot_history")
+			},
+		},
+		{
+			name: "preset under explicit dimensions",
+			env:  map[string]string{EnvPreset: "small", EnvFloors: "2"},
+			check: func(env EnvConfig) bool {
+				return env.Lot != nil && env.Lot.Floors == 2 && env.Lot.Columns == 10 && env.Lot.Pillars == "none"
+			},
+		},
+		{name: "unknown preset", env: map[string]string{EnvPreset: "huge"}, err: EnvPreset},
+		{name: "non-numeric floors", env: map[string]string{EnvFloors: "three"}, err: EnvFloors},
+		{name: "too many rows", env: map[string]string{EnvRows: "1001"}, err: EnvRows},
+		{name: "unknown format", env: map[string]string{EnvFormat: "xml"}, err: EnvFormat},
+		{name: "unknown log level", env: map[string]string{EnvLogLevel: "loud"}, err: EnvLogLevel},
+		{name: "unknown log format", env: map[string]string{EnvLogFormat: "xml"}, err: EnvLogFormat},
+		{name: "unknown timezone", env: map[string]string{EnvTimezone: "Mars/Base"}, err: EnvTimezone},
+	}
+
+	variables := []string{EnvFloors, EnvRows, EnvColumns, EnvName, EnvPreset, EnvFormat, EnvLogLevel, EnvLogFormat,
+		EnvStateFile, EnvStatsFile, EnvAliasesFile, EnvHistoryFile, EnvTimeFormat, EnvTimezone}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, name := range variables {
+				t.Setenv(name, test.env[name])
+			}
+			if home, found := test.env["HOME"]; found {
+				t.Setenv("HOME", home)
+			}
+
+			env, err := FromEnv()
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("Expected an error naming %s, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !test.check(env) {
+				t.Errorf("Unexpected configuration %+v", env)
+			}
+		})
+	}
+}
+
+func TestPresets(t *testing.T) {
+	names := make([]string, 0)
+	for _, preset := range Presets() {
+		if err := preset.Config.Validate(); err != nil {
+			t.Errorf("Expected preset %s valid, got %v", preset.Name, err)
+		}
+		config, err := GetPreset(preset.Name)
+		if err != nil || config != preset.Config {
+			t.Errorf("Expected preset %s by name, got %+v (%v)", preset.Name, config, err)
+		}
+		names = append(names, preset.Name)
+	}
+	if strings.Join(names, " ") != "small standard mega" {
+		t.Errorf("Expected the small, standard and mega presets, got %v", names)
+	}
+
+	if standard, _ := GetPreset("standard"); DefaultConfig() != standard {
+		t.Errorf("Expected the default configuration to be the standard preset, got %+v", DefaultConfig())
+	}
+
+	_, err := GetPreset("huge")
+	if !errors.Is(err, ErrUnknownPreset) || !strings.Contains(err.Error(), "small, standard, mega") {
+		t.Errorf("Expected an unknown preset error listing the presets, got %v", err)
+	}
 }
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultTimeLayout is the layout text output shows times in, e.g.
+// "2024-03-01 09:15:00"
+const DefaultTimeLayout = "2006-01-02 15:04:05"
+
+// DisplayConfig represents how text output shows times; JSON output always
+// shows them as RFC 3339 in UTC
+type DisplayConfig struct {
+	// Layout of the times shown, in the form of the time package
+	TimeLayout string
+
+	// Zone the times are shown in, such as "Asia/Kolkata" or "Local"; the
+	// zone of the lot when empty
+	Timezone string
+}
+
+// DefaultDisplayConfig returns the default display configuration
+func DefaultDisplayConfig() DisplayConfig {
+	return DisplayConfig{TimeLayout: DefaultTimeLayout}
+}
+
+// Validate checks that the layout shows some part of a time and that the
+// zone is known
+func (c *DisplayConfig) Validate() error {
+	// A layout without any element formats to itself
+	if reference := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC); reference.Format(c.TimeLayout) == c.TimeLayout {
+		return fmt.Errorf("%w: %q has no date or time elements", ErrInvalidTimeLayout, c.TimeLayout)
+	}
+
+	if _, err := c.Location(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Location loads the zone times are shown in, nil when they follow the lot's
+func (c *DisplayConfig) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return nil, nil
+	}
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTimezone, c.Timezone)
+	}
+	return location, nil
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Environment variables the tool is configured by
+const (
+	EnvFloors      = "PARKINGLOT_FLOORS"
+	EnvRows        = "PARKINGLOT_ROWS"
+	EnvColumns     = "PARKINGLOT_COLUMNS"
+	EnvName        = "PARKINGLOT_NAME"
+	EnvPreset      = "PARKINGLOT_PRESET"
+	EnvFormat      = "PARKINGLOT_FORMAT"
+	EnvLogLevel    = "PARKINGLOT_LOG_LEVEL"
+	EnvLogFormat   = "PARKINGLOT_LOG_FORMAT"
+	EnvStateFile   = "PARKINGLOT_STATE_FILE"
+	EnvStatsFile   = "PARKINGLOT_STATS_FILE"
+	EnvAliasesFile = "PARKINGLOT_ALIASES_FILE"
+	EnvHistoryFile = "PARKINGLOT_HISTORY_FILE"
+	EnvTimeFormat  = "PARKINGLOT_TIME_FORMAT"
+	EnvTimezone    = "PARKINGLOT_TIMEZONE"
+)
+
+// EnvConfig represents the configuration the environment gives, as a
+// container is configured; flags given explicitly override any of it
+type EnvConfig struct {
+	// Lot to initialize at startup, nil unless one of its variables is set;
+	// what is not set comes from the preset named, or the default one
+	Lot *ParkingLotConfig
+
+	// Name of the lot initialized at startup, empty for the default name
+	Name string
+
+	// Output format of commands, "text" or "json", and level and format of
+	// the logs; empty when not set
+	Format    string
+	LogLevel  string
+	LogFormat string
+
+	// File the lot is loaded from and saved to, empty when not set
+	StateFile string
+
+	// File command usage stats are carried across sessions in, empty when
+	// not set
+	StatsFile string
+
+	// Files aliases are loaded from and the prompt's history is kept in,
+	// by default in the home directory; empty when not set and there is no
+	// home directory
+	AliasesFile string
+	HistoryFile string
+
+	// How text output shows times
+	Display DisplayConfig
+}
+
+// envErrors names the variable each validation error is about
+var envErrors = map[error]string{
+	ErrInvalidFloorCount:  EnvFloors,
+	ErrInvalidRowCount:    EnvRows,
+	ErrInvalidColumnCount: EnvColumns,
+	ErrInvalidTimeLayout:  EnvTimeFormat,
+	ErrInvalidTimezone:    EnvTimezone,
+}
+
+// FromEnv reads the configuration from the environment. An invalid value is
+// an error naming its variable.
+func FromEnv() (EnvConfig, error) {
+	env := EnvConfig{
+		Name:      os.Getenv(EnvName),
+		Format:    os.Getenv(EnvFormat),
+		LogLevel:  strings.ToLower(os.Getenv(EnvLogLevel)),
+		LogFormat: os.Getenv(EnvLogFormat),
+		StateFile: os.Getenv(EnvStateFile),
+		StatsFile: os.Getenv(EnvStatsFile),
+		Display:   DefaultDisplayConfig(),
+
+		AliasesFile: homeFile(EnvAliasesFile, ".parkinglot_aliases"),
+		HistoryFile: homeFile(EnvHistoryFile, ".parkinglot_history"),
+	}
+
+	// Any of the lot's variables asks for a lot
+	lot := DefaultConfig()
+	if preset := os.Getenv(EnvPreset); preset != "" {
+		var err error
+		if lot, err = GetPreset(preset); err != nil {
+			return EnvConfig{}, fmt.Errorf("invalid %s: %w", EnvPreset, err)
+		}
+		env.Lot = &lot
+	}
+	dimensions := []struct {
+		name  string
+		value *int
+	}{
+		{EnvFloors, &lot.Floors},
+		{EnvRows, &lot.Rows},
+		{EnvColumns, &lot.Columns},
+	}
+	for _, dimension := range dimensions {
+		value := os.Getenv(dimension.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return EnvConfig{}, fmt.Errorf("invalid %s: %q is not a number", dimension.name, value)
+		}
+		*dimension.value = n
+		env.Lot = &lot
+	}
+	if env.Name != "" {
+		env.Lot = &lot
+	}
+	if env.Lot != nil {
+		if err := env.Lot.Validate(); err != nil {
+			return EnvConfig{}, envError(err)
+		}
+	}
+
+	if err := checkChoice(EnvFormat, env.Format, "text", "json"); err != nil {
+		return EnvConfig{}, err
+	}
+	if err := checkChoice(EnvLogLevel, env.LogLevel, "debug", "info", "warn", "error"); err != nil {
+		return EnvConfig{}, err
+	}
+	if err := checkChoice(EnvLogFormat, env.LogFormat, "text", "json"); err != nil {
+		return EnvConfig{}, err
+	}
+
+	if layout := os.Getenv(EnvTimeFormat); layout != "" {
+		env.Display.TimeLayout = layout
+	}
+	env.Display.Timezone = os.Getenv(EnvTimezone)
+	if err := env.Display.Validate(); err != nil {
+		return EnvConfig{}, envError(err)
+	}
+
+	return env, nil
+}
+
+// homeFile returns the file named by the variable, or else the named file in
+// the home directory, empty when there is none
+func homeFile(variable, name string) string {
+	if path := os.Getenv(variable); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, name)
+}
+
+// envError prefixes a validation error with the variable it is about
+func envError(err error) error {
+	for known, name := range envErrors {
+		if errors.Is(err, known) {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return err
+}
+
+// checkChoice checks that a variable, when set, is one of the choices
+func checkChoice(name, value string, choices ...string) error {
+	if value == "" {
+		return nil
+	}
+	for _, choice := range choices {
+		if value == choice {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid %s: unknown value %q, expected %s", name, value, strings.Join(choices, ", "))
+}
@@ -7,4 +7,12 @@ var (
 	ErrInvalidFloorCount  = errors.New("invalid floor count: must be between 1 and 8")
 	ErrInvalidRowCount    = errors.New("invalid row count: must be between 1 and 1000")
 	ErrInvalidColumnCount = errors.New("invalid column count: must be between 1 and 1000")
+
+	ErrInvalidVehicleNumberLength  = errors.New("invalid vehicle number length: limits must be non-negative with min not above max")
+	ErrInvalidVehicleNumberPattern = errors.New("invalid vehicle number pattern")
+
+	ErrInvalidTimeLayout = errors.New("invalid time layout")
+	ErrInvalidTimezone   = errors.New("unknown timezone")
+
+	ErrUnknownPreset = errors.New("unknown preset")
 )
@@ -1,26 +1,45 @@
 package config
 
-import "flag"
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
 
-// ParseInitCommand: parses the init command flag
+// ParseInitCommand parses the init command's flags into a validated
+// configuration, the defaults standing in for any flag not given. Errors
+// are only returned, never printed, and arguments left over are an error.
 func ParseInitCommand(args []string) (ParkingLotConfig, error) {
 	// Start  with the default configuration
 	config := DefaultConfig()
 
 	// Create a FlagSet for parsing init command
 	initCmd := flag.NewFlagSet("init", flag.ContinueOnError)
+	initCmd.SetOutput(io.Discard)
 
 	// Define flags
 	initCmd.IntVar(&config.Floors, "floors", config.Floors, "Number of floors (1-8)")
 	initCmd.IntVar(&config.Rows, "rows", config.Rows, "Number of rows per floor (1-1000)")
 	initCmd.IntVar(&config.Columns, "columns", config.Columns, "Number of columns per row (1-1000)")
+	initCmd.StringVar(&config.VehicleNumbers.Pattern, "plate-pattern", config.VehicleNumbers.Pattern,
+		"Regular expression vehicle numbers must match")
+	initCmd.IntVar(&config.VehicleNumbers.MinLength, "plate-min-length", config.VehicleNumbers.MinLength,
+		"Minimum vehicle number length (0 for no limit)")
+	initCmd.IntVar(&config.VehicleNumbers.MaxLength, "plate-max-length", config.VehicleNumbers.MaxLength,
+		"Maximum vehicle number length (0 for no limit)")
+	initCmd.BoolVar(&config.VehicleNumbers.AllowSpaces, "plate-allow-spaces", config.VehicleNumbers.AllowSpaces,
+		"Allow spaces in vehicle numbers")
 
 	// Parse flags
 	if err := initCmd.Parse(args); err != nil {
 		return config, err
 	}
+	if initCmd.NArg() > 0 {
+		return config, fmt.Errorf("unexpected arguments: %s", strings.Join(initCmd.Args(), " "))
+	}
 
-	// Parse flags
+	// Validate the configuration
 	if err := config.Validate(); err != nil {
 		return config, err
 	}
@@ -34,10 +53,15 @@ func GetUsage() string {
   parking-lot init [options]
 
 Options:
-  -floors int    Number of floors (1-8) (default 3)
-  -rows int      Number of rows per floor (1-1000) (default 5)
-  -columns int   Number of columns per row (1-1000) (default 10)
+  -floors int               Number of floors (1-8) (default 3)
+  -rows int                 Number of rows per floor (1-1000) (default 5)
+  -columns int              Number of columns per row (1-1000) (default 10)
+  -plate-pattern string     Regular expression vehicle numbers must match
+  -plate-min-length int     Minimum vehicle number length (0 for no limit)
+  -plate-max-length int     Maximum vehicle number length (0 for no limit)
+  -plate-allow-spaces bool  Allow spaces in vehicle numbers (default true)
 
 Example:
-  parking-lot init -floors 4 -rows 10 -columns 20`
+  parking-lot init -floors 4 -rows 10 -columns 20
+  parking-lot init -plate-pattern '[A-Z]{2}-[0-9]{2}-[A-Z]{1,3}-[0-9]{4}' -plate-allow-spaces=false`
 }
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultPreset names the preset DefaultConfig returns
+const DefaultPreset = "standard"
+
+// Preset is a named configuration of a whole lot, for init --preset
+type Preset struct {
+	Name        string
+	Description string
+	Config      ParkingLotConfig
+}
+
+// presets lists the built-in presets, smallest first
+var presets = []Preset{
+	{
+		Name:        "small",
+		Description: "One floor of 5x10 without pillars, for trying things out",
+		Config: ParkingLotConfig{Floors: 1, Rows: 5, Columns: 10, Pillars: "none",
+			VehicleNumbers: VehicleNumberRule{AllowSpaces: true}},
+	},
+	{
+		Name:        "standard",
+		Description: "Three floors of 5x10 with the default mix and pillars",
+		Config: ParkingLotConfig{Floors: 3, Rows: 5, Columns: 10, Pillars: "7:7:2",
+			VehicleNumbers: VehicleNumberRule{AllowSpaces: true}},
+	},
+	{
+		Name: "mega",
+		Description: "Eight floors of 1000x1000, mostly automobiles, with pillars every 10 spots of every 10th row; " +
+			"spots are made as they are first used",
+		Config: ParkingLotConfig{Floors: 8, Rows: 1000, Columns: 1000, Mix: "5:15:80", Pillars: "10:10:2",
+			VehicleNumbers: VehicleNumberRule{AllowSpaces: true}},
+	},
+}
+
+// Presets returns the built-in presets
+func Presets() []Preset {
+	list := make([]Preset, len(presets))
+	copy(list, presets)
+	return list
+}
+
+// GetPreset returns the configuration of the named preset; an unknown name
+// is an error listing the presets there are
+func GetPreset(name string) (ParkingLotConfig, error) {
+	names := make([]string, 0, len(presets))
+	for _, preset := range Presets() {
+		if strings.EqualFold(preset.Name, name) {
+			return preset.Config, nil
+		}
+		names = append(names, preset.Name)
+	}
+	return ParkingLotConfig{}, fmt.Errorf("%w %q, expected one of %s", ErrUnknownPreset, name, strings.Join(names, ", "))
+}
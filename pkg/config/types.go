@@ -1,10 +1,53 @@
 package config
 
+import (
+	"fmt"
+	"regexp"
+)
+
 // ParkingLotConfig represents the configuration for parking lot
 type ParkingLotConfig struct {
 	Floors  int
 	Rows    int
 	Columns int
+
+	// Spot mix as init --mix takes it, the default distribution when empty
+	Mix string
+
+	// Pillar pattern as init --pillars takes it, the default pattern of the
+	// distribution when empty
+	Pillars string
+
+	// Rule for the vehicle numbers the lot accepts
+	VehicleNumbers VehicleNumberRule
+}
+
+// VehicleNumberRule represents which vehicle numbers a parking lot accepts
+type VehicleNumberRule struct {
+	// Regular expression the whole number must match, the default pattern when empty
+	Pattern string
+
+	// Length limits in characters, unlimited when zero
+	MinLength int
+	MaxLength int
+
+	// Whether numbers may contain spaces
+	AllowSpaces bool
+}
+
+// Validate checks if the vehicle number rule is valid
+func (r *VehicleNumberRule) Validate() error {
+	if r.MinLength < 0 || r.MaxLength < 0 || (r.MaxLength > 0 && r.MinLength > r.MaxLength) {
+		return ErrInvalidVehicleNumberLength
+	}
+
+	if r.Pattern != "" {
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidVehicleNumberPattern, err)
+		}
+	}
+
+	return nil
 }
 
 // Validate checks if the parking lot configuration is valid
@@ -21,14 +64,11 @@ func (c *ParkingLotConfig) Validate() error {
 		return ErrInvalidColumnCount
 	}
 
-	return nil
+	return c.VehicleNumbers.Validate()
 }
 
-// DefaultConfig returns a default configuration
+// DefaultConfig returns a default configuration, the standard preset
 func DefaultConfig() ParkingLotConfig {
-	return ParkingLotConfig{
-		Floors:  3,
-		Rows:    5,
-		Columns: 10,
-	}
+	config, _ := GetPreset(DefaultPreset)
+	return config
 }
@@ -1,11 +1,18 @@
 package test
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/prasaria/go-multistorey-parking-lot/internal/cli"
 	"github.com/prasaria/go-multistorey-parking-lot/internal/model"
+	"github.com/prasaria/go-multistorey-parking-lot/pkg/api"
 )
 
 // TestIntegrationScenarios tests various integrated scenarios
@@ -183,6 +190,190 @@ func TestIntegrationScenarios(t *testing.T) {
 	})
 }
 
+// captureStdout returns everything fn writes to standard output
+func captureStdout(t *testing.T, fn func()) []byte {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return out
+}
+
+// TestParkJSONDetails checks the park result carries the spot location and time
+func TestParkJSONDetails(t *testing.T) {
+	registry := cli.NewCommandRegistry()
+	registry.RegisterAllCommands()
+
+	if err := registry.ExecuteCommand("init", []string{"2", "3", "4"}); err != nil {
+		t.Fatalf("Failed to initialize parking lot: %v", err)
+	}
+	parkedAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	registry.GetParkingLot().SetClock(model.NewFakeClock(parkedAt))
+
+	out := captureStdout(t, func() {
+		if err := registry.ExecuteCommand("park", []string{"automobile", "ka-01-hh-1234", "--json"}); err != nil {
+			t.Errorf("Failed to park automobile: %v", err)
+		}
+	})
+
+	var result struct {
+		Success bool           `json:"success"`
+		Data    api.ParkResult `json:"data"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		t.Fatalf("Failed to decode park output %q: %v", out, err)
+	}
+	if !result.Success {
+		t.Fatalf("Expected a successful park, got %s", out)
+	}
+
+	spot, err := registry.GetParkingLot().FindVehicle("KA-01-HH-1234")
+	if err != nil {
+		t.Fatalf("Failed to find the parked vehicle: %v", err)
+	}
+	expected := api.ParkResult{
+		VehicleType:   "AUTOMOBILE",
+		VehicleNumber: "KA-01-HH-1234",
+		SpotID:        spot.GetSpotID(),
+		Location:      api.SpotLocation{Floor: spot.Floor, Row: spot.Row, Column: spot.Column},
+		Floor:         spot.Floor,
+		Row:           spot.Row,
+		Column:        spot.Column,
+		ParkedAt:      parkedAt.Format(time.RFC3339),
+	}
+	if !reflect.DeepEqual(result.Data, expected) {
+		t.Errorf("Expected park result %+v, got %+v", expected, result.Data)
+	}
+}
+
+// TestUnparkJSONDuration checks the unpark result reports how long the vehicle stayed
+func TestUnparkJSONDuration(t *testing.T) {
+	registry := cli.NewCommandRegistry()
+	registry.RegisterAllCommands()
+
+	if err := registry.ExecuteCommand("init", []string{"2", "3", "4"}); err != nil {
+		t.Fatalf("Failed to initialize parking lot: %v", err)
+	}
+	clock := model.NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	lot := registry.GetParkingLot()
+	lot.SetClock(clock)
+
+	spotID, err := lot.Park(model.VehicleTypeAutomobile, "KA-01-HH-1234")
+	if err != nil {
+		t.Fatalf("Failed to park automobile: %v", err)
+	}
+	clock.Advance(2*time.Hour + 13*time.Minute)
+
+	out := captureStdout(t, func() {
+		if err := registry.ExecuteCommand("unpark", []string{spotID, "KA-01-HH-1234", "--json"}); err != nil {
+			t.Errorf("Failed to unpark automobile: %v", err)
+		}
+	})
+
+	var result struct {
+		Data api.UnparkResult `json:"data"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		t.Fatalf("Failed to decode unpark output %q: %v", out, err)
+	}
+	if result.Data.DurationSeconds != (2*time.Hour + 13*time.Minute).Seconds() {
+		t.Errorf("Expected a duration of 2h13m, got %v seconds", result.Data.DurationSeconds)
+	}
+	floor, row, column, _ := model.ParseSpotID(spotID)
+	if expected := (api.SpotLocation{Floor: floor, Row: row, Column: column}); result.Data.Location != expected {
+		t.Errorf("Expected the location %+v of %s, got %+v", expected, spotID, result.Data.Location)
+	}
+}
+
+// TestSignageSpotIDs checks a lot configured with signage spot IDs prints and
+// accepts them in every command
+func TestSignageSpotIDs(t *testing.T) {
+	registry := cli.NewCommandRegistry()
+	registry.RegisterAllCommands()
+
+	if err := registry.ExecuteCommand("init", []string{"2", "3", "4", "--spot-ids", "signage"}); err != nil {
+		t.Fatalf("Failed to initialize parking lot: %v", err)
+	}
+
+	out := captureStdout(t, func() {
+		if err := registry.ExecuteCommand("park", []string{"automobile", "KA-01-HH-1234"}); err != nil {
+			t.Errorf("Failed to park automobile: %v", err)
+		}
+	})
+	spot, err := registry.GetParkingLot().FindVehicle("KA-01-HH-1234")
+	if err != nil {
+		t.Fatalf("Failed to find the parked vehicle: %v", err)
+	}
+	spotID := spot.GetSpotID()
+	if spotID != fmt.Sprintf("F%d-R%02d-C%02d", spot.Floor, spot.Row, spot.Column) {
+		t.Fatalf("Expected a signage spot ID, got %q", spotID)
+	}
+	if !strings.Contains(string(out), spotID) {
+		t.Errorf("Expected park to print %s, got %q", spotID, out)
+	}
+
+	// Searches and tables show the same ID
+	for _, args := range [][]string{
+		{"search", "KA-01-HH-1234"},
+		{"spots", "--occupied"},
+		{"vehicles"},
+		{"longest"},
+	} {
+		out := captureStdout(t, func() {
+			if err := registry.ExecuteCommand(args[0], args[1:]); err != nil {
+				t.Errorf("Failed to execute %v: %v", args, err)
+			}
+		})
+		if !strings.Contains(string(out), spotID) {
+			t.Errorf("Expected %v to print %s, got %q", args, spotID, out)
+		}
+	}
+
+	// The location in JSON is read from the signage ID
+	out = captureStdout(t, func() {
+		if err := registry.ExecuteCommand("search", []string{"KA-01-HH-1234", "--json"}); err != nil {
+			t.Errorf("Failed to search: %v", err)
+		}
+	})
+	var search struct {
+		Data api.SearchResult `json:"data"`
+	}
+	if err := json.Unmarshal(out, &search); err != nil {
+		t.Fatalf("Failed to decode search output %q: %v", out, err)
+	}
+	expected := api.SpotLocation{Floor: spot.Floor, Row: spot.Row, Column: spot.Column}
+	if search.Data.Location == nil || *search.Data.Location != expected {
+		t.Errorf("Expected the location %+v, got %+v", expected, search.Data.Location)
+	}
+
+	out = captureStdout(t, func() {
+		if err := registry.ExecuteCommand("available", []string{"automobile", "--limit", "1"}); err != nil {
+			t.Errorf("Failed to list available spots: %v", err)
+		}
+	})
+	if !strings.Contains(string(out), "-R0") {
+		t.Errorf("Expected available spots in the signage format, got %q", out)
+	}
+
+	// Unpark takes the ID in the lot's format, not the dash one
+	if err := registry.ExecuteCommand("unpark", []string{model.FormatSpotID(spot.Floor, spot.Row, spot.Column),
+		"KA-01-HH-1234"}); err == nil {
+		t.Error("Expected a dash spot ID to be rejected")
+	}
+	if err := registry.ExecuteCommand("unpark", []string{strings.ToLower(spotID), "KA-01-HH-1234"}); err != nil {
+		t.Errorf("Failed to unpark at %s: %v", spotID, err)
+	}
+}
+
 // TestLargeParkingLot tests scenarios with a large parking lot
 func TestLargeParkingLot(t *testing.T) {
 	if testing.Short() {